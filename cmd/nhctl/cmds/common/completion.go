@@ -0,0 +1,235 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"nocalhost/internal/nhctl/daemon_client"
+	"nocalhost/internal/nhctl/daemon_handler/item"
+	"nocalhost/internal/nhctl/model"
+	"nocalhost/internal/nhctl/ui/picker"
+	"nocalhost/internal/nhctl/utils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// ListApplications the applications of namespace queried from the daemon
+func ListApplications() ([]string, error) {
+	data, err := getResourceInfo("", "app", "")
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []*model.Namespace
+	if err = remarshal(data, &namespaces); err != nil {
+		return nil, err
+	}
+	apps := make([]string, 0)
+	for _, ns := range namespaces {
+		for _, a := range ns.Application {
+			apps = append(apps, a.Name)
+		}
+	}
+	return apps, nil
+}
+
+// ListWorkloads the workloads of svcType in the application
+func ListWorkloads(appName, svcType string) ([]string, error) {
+	data, err := getResourceInfo(appName, strings.ToLower(svcType)+"s", "")
+	if err != nil {
+		return nil, err
+	}
+	var items []item.Item
+	if err = remarshal(data, &items); err != nil {
+		return nil, err
+	}
+	workloads := make([]string, 0, len(items))
+	for _, it := range items {
+		if um, ok := it.Metadata.(map[string]interface{}); ok {
+			if name, _, _ := unstructured.NestedString(um, "metadata", "name"); name != "" {
+				workloads = append(workloads, name)
+			}
+		}
+	}
+	return workloads, nil
+}
+
+// ListContainers the containers of the pod template of workload
+func ListContainers(appName, workload, svcType string) ([]string, error) {
+	svcType = strings.ToLower(svcType)
+	data, err := getResourceInfo(appName, svcType+"s", workload)
+	if err != nil {
+		return nil, err
+	}
+	it := item.Item{}
+	if err = remarshal(data, &it); err != nil {
+		return nil, err
+	}
+	um, ok := it.Metadata.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Workload " + workload + " is not found")
+	}
+
+	fields := []string{"spec", "template", "spec", "containers"}
+	switch svcType {
+	case "pod":
+		fields = []string{"spec", "containers"}
+	case "cronjob":
+		fields = []string{"spec", "jobTemplate", "spec", "template", "spec", "containers"}
+	}
+	specs, _, _ := unstructured.NestedSlice(um, fields...)
+	containers := make([]string, 0, len(specs))
+	for _, spec := range specs {
+		if c, ok := spec.(map[string]interface{}); ok {
+			if name, _, _ := unstructured.NestedString(c, "name"); name != "" {
+				containers = append(containers, name)
+			}
+		}
+	}
+	return containers, nil
+}
+
+func getResourceInfo(appName, resource, resourceName string) (interface{}, error) {
+	if err := Prepare(); err != nil {
+		return nil, err
+	}
+	cli, err := daemon_client.GetDaemonClient(utils.IsSudoUser())
+	if err != nil {
+		return nil, err
+	}
+	return cli.SendGetResourceInfoCommand(KubeConfig, NameSpace, appName, resource, resourceName, nil, false)
+}
+
+func remarshal(data interface{}, v interface{}) error {
+	bys, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return errors.Wrap(json.Unmarshal(bys, v), "")
+}
+
+// PickApplication the application picked interactively, for the commands
+// whose application argument is omitted
+func PickApplication() (string, error) {
+	apps, err := ListApplications()
+	if err != nil {
+		return "", err
+	}
+	return pick("application", apps)
+}
+
+func PickWorkload(appName, svcType string) (string, error) {
+	workloads, err := ListWorkloads(appName, svcType)
+	if err != nil {
+		return "", err
+	}
+	return pick(strings.ToLower(svcType), workloads)
+}
+
+// PickOmittedWorkload picks the application and --deployment omitted in the
+// interactive terminal, and --container if container is not nil. The container
+// is left as it is if there is only one or it fails to list them
+func PickOmittedWorkload(args []string, container *string) (string, error) {
+	var err error
+	applicationName := ""
+	if len(args) > 0 {
+		applicationName = args[0]
+	} else if applicationName, err = PickApplication(); err != nil {
+		return "", err
+	}
+	if WorkloadName == "" {
+		if WorkloadName, err = PickWorkload(applicationName, ServiceType); err != nil {
+			return "", err
+		}
+	}
+	if container != nil && *container == "" && picker.IsInteractive() {
+		containers, err := ListContainers(applicationName, WorkloadName, ServiceType)
+		if err != nil || len(containers) < 2 {
+			return applicationName, nil
+		}
+		if *container, err = pick("container", containers); err != nil {
+			return "", err
+		}
+	}
+	return applicationName, nil
+}
+
+func pick(title string, items []string) (string, error) {
+	picked, err := picker.Pick(title, items)
+	if err == picker.ErrNotInteractive {
+		return "", errors.New("The " + title + " must be specified in the non-interactive terminal")
+	}
+	return picked, err
+}
+
+// RegisterWorkloadCompletions completes the application argument, --deployment
+// and --container of the commands of workloads
+func RegisterWorkloadCompletions(root *cobra.Command) {
+	for _, cmd := range root.Commands() {
+		RegisterWorkloadCompletions(cmd)
+		if cmd.Flags().Lookup("deployment") == nil {
+			continue
+		}
+		if cmd.ValidArgsFunction == nil && strings.Contains(cmd.Use, "[NAME]") {
+			cmd.ValidArgsFunction = completeApplications
+		}
+		_ = cmd.RegisterFlagCompletionFunc("deployment", completeWorkloads)
+		if cmd.Flags().Lookup("container") != nil {
+			_ = cmd.RegisterFlagCompletionFunc("container", completeContainers)
+		}
+	}
+}
+
+func completeApplications(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	silenceLogs()
+	apps, err := ListApplications()
+	return completions(apps, toComplete, err)
+}
+
+func completeWorkloads(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	silenceLogs()
+	workloads, err := ListWorkloads(args[0], ServiceType)
+	return completions(workloads, toComplete, err)
+}
+
+func completeContainers(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 || WorkloadName == "" {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	silenceLogs()
+	containers, err := ListContainers(args[0], WorkloadName, ServiceType)
+	return completions(containers, toComplete, err)
+}
+
+// silenceLogs the logs printed to stdout are taken as completions by shells
+func silenceLogs() {
+	log.RedirectionDefaultLogger(zapcore.AddSync(ioutil.Discard))
+}
+
+func completions(candidates []string, toComplete string, err error) ([]string, cobra.ShellCompDirective) {
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	result := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if strings.HasPrefix(c, toComplete) {
+			result = append(result, c)
+		}
+	}
+	return result, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,58 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+var completionCmd = &cobra.Command{
+	Use:       "completion [bash|zsh|fish|powershell]",
+	Short:     "Generate the completion script of shell",
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	Args:      cobra.ExactValidArgs(1),
+	Long: `Generate the completion script of shell. The applications, workloads and containers
+are completed by querying the daemon.
+
+  Bash:
+    $ source <(nhctl completion bash)
+    # or load it for each session, on Linux:
+    $ nhctl completion bash > /etc/bash_completion.d/nhctl
+    # on macOS:
+    $ nhctl completion bash > /usr/local/etc/bash_completion.d/nhctl
+
+  Zsh:
+    # enable the completion if it is not enabled
+    $ echo "autoload -U compinit; compinit" >> ~/.zshrc
+    $ nhctl completion zsh > "${fpath[1]}/_nhctl"
+
+  Fish:
+    $ nhctl completion fish > ~/.config/fish/completions/nhctl.fish
+
+  PowerShell:
+    PS> nhctl completion powershell | Out-String | Invoke-Expression
+    # or load it for each session
+    PS> nhctl completion powershell >> $PROFILE`,
+	DisableFlagsInUseLine: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		switch args[0] {
+		case "bash":
+			must(rootCmd.GenBashCompletionV2(os.Stdout, true))
+		case "zsh":
+			must(rootCmd.GenZshCompletion(os.Stdout))
+		case "fish":
+			must(rootCmd.GenFishCompletion(os.Stdout, true))
+		case "powershell":
+			must(rootCmd.GenPowerShellCompletionWithDesc(os.Stdout))
+		}
+	},
+}
@@ -84,6 +84,9 @@ var configEditCmd = &cobra.Command{
 		}
 
 		var unmashaler func(interface{}) error
+		// yamlContent the content written by users, the one of --content is
+		// generated by plugin from the struct
+		var yamlContent []byte
 
 		// first, resolve config from content or file
 		if len(configEditFlags.Content) > 0 {
@@ -104,6 +107,7 @@ var configEditCmd = &cobra.Command{
 
 			content, err := ioutil.ReadAll(reader)
 			must(err)
+			yamlContent = content
 
 			unmashaler = func(i interface{}) error {
 				return yaml.Unmarshal(content, i)
@@ -111,6 +115,7 @@ var configEditCmd = &cobra.Command{
 		} else {
 			text, err := fp.NewFilePath(configEditFlags.file).ReadFileCompel()
 			must(err)
+			yamlContent = []byte(text)
 
 			unmashaler = func(i interface{}) error {
 				return yaml.Unmarshal([]byte(text), i)
@@ -118,8 +123,15 @@ var configEditCmd = &cobra.Command{
 		}
 
 		// set application config, plugin do not provide services struct, update application config only
+		validateSchema := func(v interface{}) {
+			if yamlContent != nil {
+				must(config_validate.ValidateSchema(yamlContent, config_validate.SchemaOf(v)))
+			}
+		}
+
 		if configEditFlags.AppConfig {
 			applicationConfig := &profile.ApplicationConfig{}
+			validateSchema(applicationConfig)
 			must(errors.Wrap(unmashaler(applicationConfig), "fail to unmarshal content"))
 			must(nocalhostApp.SaveAppProfileV2(applicationConfig))
 			return
@@ -129,6 +141,8 @@ var configEditCmd = &cobra.Command{
 		nocalhostSvc, err := nocalhostApp.InitAndCheckIfSvcExist(configEditFlags.SvcName, common.ServiceType)
 		must(err)
 
+		validateSchema(svcConfig)
+
 		if err := unmashaler(svcConfig); err != nil {
 			log.Fatal(err)
 		}
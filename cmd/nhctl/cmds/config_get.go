@@ -13,6 +13,7 @@ import (
 	"nocalhost/cmd/nhctl/cmds/common"
 	"nocalhost/internal/nhctl/appmeta"
 	"nocalhost/internal/nhctl/dev_dir"
+	"nocalhost/internal/nhctl/devcontainer"
 	"nocalhost/internal/nhctl/fp"
 	"nocalhost/internal/nhctl/profile"
 	"nocalhost/pkg/nhctl/log"
@@ -57,6 +58,15 @@ var svcNotificationTipsCmLoaded = `# Tips: This configuration is a in-memory rep
 # take effect immediately. (Dev modification will take effect the next time you enter the DevMode)
 #`
 
+var svcNotificationTipsDevContainerLoaded = `# Tips: This configuration is derived from: 
+# 
+# '%s'
+# 
+# as no nocalhost configuration found, you can paste the configuration follow into
+# %s
+# to customize it. (Dev modification will take effect the next time you enter the DevMode)
+#`
+
 var svcNotificationTipsAnnotationLoaded = `# Tips: This configuration is a in-memory replica of annotation: 
 # 
 # annotations:
@@ -176,6 +186,11 @@ var configGetCmd = &cobra.Command{
 						svcNotificationTipsCmLoaded,
 						appmeta.ConfigMapName(commonFlags.AppName),
 					)
+				} else if svcProfile.DevContainerConfigLoaded {
+					notification += fmt.Sprintf(
+						svcNotificationTipsDevContainerLoaded,
+						devcontainer.Find(string(pack.GetAssociatePath())), path,
+					)
 				} else if svcProfile.AnnotationsConfigLoaded {
 					notification += fmt.Sprintf(
 						svcNotificationTipsAnnotationLoaded,
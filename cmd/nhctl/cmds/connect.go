@@ -0,0 +1,84 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/internal/nhctl/vpn/socks"
+	"nocalhost/internal/nhctl/vpn/util"
+	"nocalhost/pkg/nhctl/clientgoutils"
+)
+
+const (
+	// the TUN device and dns of sudo daemon route the traffic of cluster
+	tunConnectMode = "tun"
+	// the SOCKS5 proxy resolves the services, no root privilege needed
+	socksConnectMode = "socks"
+)
+
+var (
+	connectMode  string
+	socksAddress string
+)
+
+func init() {
+	trafficConnectCmd.Flags().StringVar(&common.KubeConfig, "kubeconfig", clientcmd.RecommendedHomeFile, "kubeconfig")
+	trafficConnectCmd.Flags().StringVarP(&common.NameSpace, "namespace", "n", "", "namespace")
+	trafficConnectCmd.Flags().StringVar(
+		&connectMode, "mode", tunConnectMode,
+		"tun: route the traffic of cluster by the TUN device and dns, needs root privilege; "+
+			"socks: serve a SOCKS5 proxy resolving the services by name",
+	)
+	trafficConnectCmd.Flags().StringVar(
+		&socksAddress, "socks-address", "127.0.0.1:1080", "the address listened by the SOCKS5 proxy of socks mode",
+	)
+	// the elevated one is run as 'nhctl connect elevate' in tun mode
+	trafficConnectCmd.AddCommand(
+		&cobra.Command{
+			Use:    "elevate",
+			Short:  "elevate",
+			Long:   `elevate`,
+			Hidden: true,
+			Run:    vpnElevateCmd.Run,
+		},
+	)
+	rootCmd.AddCommand(trafficConnectCmd)
+}
+
+var trafficConnectCmd = &cobra.Command{
+	Use:   "connect",
+	Short: "Connect to the services of DevSpace from local",
+	Long: `Connect to the services of DevSpace from local, so that the processes of local
+reach the ClusterIP and headless services by name without port-forwards`,
+	Example: `  nhctl connect -n dev
+  nhctl connect -n dev --mode socks
+  curl --socks5-hostname 127.0.0.1:1080 http://details:9080`,
+	Run: func(cmd *cobra.Command, args []string) {
+		switch connectMode {
+		case tunConnectMode:
+			prepareVPN()
+			vpnConnect("")
+		case socksConnectMode:
+			util.InitLogger(util.Debug)
+			must(common.Prepare())
+			client, err := clientgoutils.NewClientGoUtils(common.KubeConfig, common.NameSpace)
+			must(err)
+			listener, err := net.Listen("tcp", socksAddress)
+			must(errors.Wrap(err, "Failed to listen the address of SOCKS5 proxy"))
+			log.Infof("SOCKS5 proxy to the services of namespace %s is listening on %s", common.NameSpace, socksAddress)
+			log.Infof("The hosts must be resolved by the proxy, such as socks5h://%s", socksAddress)
+			must(socks.Serve(listener, socks.NewResolver(client).Dial))
+		default:
+			must(errors.Errorf("Unsupported connect mode %s, tun or socks", connectMode))
+		}
+	},
+}
@@ -0,0 +1,107 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/internal/nhctl/nocalhost"
+	"nocalhost/pkg/nhctl/log"
+)
+
+var (
+	daemonLogsApp      string
+	daemonLogsWorkload string
+	daemonLogsFollow   bool
+	daemonLogsTail     int
+)
+
+func init() {
+	daemonLogsCmd.Flags().BoolVar(&isSudoUser, "sudo", false, "Is run as sudo")
+	daemonLogsCmd.Flags().StringVar(&daemonLogsApp, "app", "", "print the session logs of the application")
+	daemonLogsCmd.Flags().StringVar(
+		&daemonLogsWorkload, "workload", "", "print the session logs of the workload in the application",
+	)
+	daemonLogsCmd.Flags().BoolVarP(&daemonLogsFollow, "follow", "f", false, "specify if the logs should be streamed")
+	daemonLogsCmd.Flags().IntVar(
+		&daemonLogsTail, "tail", 100, "lines of recent log to display, -1 to display all of them",
+	)
+	daemonCmd.AddCommand(daemonLogsCmd)
+}
+
+var daemonLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Print the logs of nhctl daemon",
+	Long: `Print the logs of nhctl daemon, or the session logs of workloads,
+such as their port-forwards and file sync, if --app is specified`,
+	Example: `
+  # print the log of daemon
+  nhctl daemon logs
+
+  # stream the session log of a workload
+  nhctl daemon logs --app bookinfo --workload details -n nocalhost --follow`,
+	Run: func(cmd *cobra.Command, args []string) {
+		paths, err := daemonLogPaths()
+		must(err)
+		if daemonLogsFollow && len(paths) > 1 {
+			log.Fatalf(
+				"%d session logs found, specify the namespace and --workload to follow one of them", len(paths),
+			)
+		}
+
+		stopCh := make(chan struct{})
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			close(stopCh)
+		}()
+
+		for i, path := range paths {
+			if len(paths) > 1 {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("==> %s <==\n", path)
+			}
+			must(log.Tail(path, daemonLogsTail, daemonLogsFollow, os.Stdout, stopCh))
+		}
+	},
+}
+
+// daemonLogPaths the log file of daemon, or the session log files matching
+// the namespace, application and workload
+func daemonLogPaths() ([]string, error) {
+	if daemonLogsApp == "" {
+		if daemonLogsWorkload != "" {
+			return nil, errors.New("--app must be specified with --workload")
+		}
+		return []string{filepath.Join(nocalhost.GetLogDir(), daemonLogFileName(isSudoUser))}, nil
+	}
+
+	ns, workload := common.NameSpace, daemonLogsWorkload
+	if ns == "" {
+		ns = "*"
+	}
+	if workload == "" {
+		workload = "*"
+	}
+	paths, err := filepath.Glob(filepath.Join(log.SessionLogDir(), ns, daemonLogsApp, workload+".log"))
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	if len(paths) == 0 {
+		return nil, errors.New(fmt.Sprintf("No session log found of application %s", daemonLogsApp))
+	}
+	return paths, nil
+}
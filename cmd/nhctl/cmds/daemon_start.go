@@ -7,11 +7,25 @@ package cmds
 
 import (
 	"github.com/spf13/cobra"
+	"go.uber.org/zap/zapcore"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/daemon_common"
 	"nocalhost/internal/nhctl/daemon_server"
+	"nocalhost/internal/nhctl/nocalhost"
 	"nocalhost/pkg/nhctl/log"
 )
 
+// daemonLogRotation the daemon keeps running for days, so its log is rotated
+// more often than nhctl.log
+var daemonLogRotation = log.Rotation{MaxSizeMB: 20, MaxBackups: 10, MaxAgeDays: 14}
+
+func daemonLogFileName(isSudoUser bool) string {
+	if isSudoUser {
+		return _const.SudoDaemonLogFileName
+	}
+	return _const.DaemonLogFileName
+}
+
 func init() {
 	daemonStartCmd.Flags().BoolVar(&isSudoUser, "sudo", false, "Is run as sudo")
 	daemonStartCmd.Flags().BoolVarP(&runInBackground, "daemon", "d", false, "Is run as daemon(background)")
@@ -30,6 +44,11 @@ var daemonStartCmd = &cobra.Command{
 			return
 		}
 
+		level := zapcore.InfoLevel
+		if debug {
+			level = zapcore.DebugLevel
+		}
+		_ = log.InitWithRotation(level, nocalhost.GetLogDir(), daemonLogFileName(isSudoUser), daemonLogRotation)
 		must(daemon_server.StartDaemon(isSudoUser, Version, GitCommit))
 	},
 }
@@ -7,13 +7,29 @@ package cmds
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/daemon_client"
+	"nocalhost/internal/nhctl/daemon_common"
 	"nocalhost/pkg/nhctl/log"
 )
 
+var daemonStatusVerbose bool
+
 func init() {
 	daemonStatusCmd.Flags().BoolVar(&isSudoUser, "sudo", false, "Is run as sudo")
+	daemonStatusCmd.Flags().BoolVarP(
+		&daemonStatusVerbose, "verbose", "v", false,
+		"render the internals of daemon, such as port-forwards, sync sessions, goroutines and errors",
+	)
 	daemonCmd.AddCommand(daemonStatusCmd)
 }
 
@@ -25,6 +41,13 @@ var daemonStatusCmd = &cobra.Command{
 		client, err := daemon_client.GetDaemonClient(isSudoUser)
 		must(err)
 
+		if daemonStatusVerbose {
+			diagnostics, err := client.SendGetDaemonDiagnosticsCommand()
+			must(err)
+			renderDaemonDiagnostics(os.Stdout, diagnostics)
+			return
+		}
+
 		status, err := client.SendGetDaemonServerStatusCommand()
 		must(err)
 
@@ -34,3 +57,65 @@ var daemonStatusCmd = &cobra.Command{
 		log.Infof("%s", marshal)
 	},
 }
+
+func renderDaemonDiagnostics(w io.Writer, d *daemon_common.DaemonDiagnostics) {
+	_, _ = fmt.Fprintf(w, "Version:     %s (%s)\n", d.Version, d.CommitId)
+	_, _ = fmt.Fprintf(w, "Sudo:        %t\n", d.Sudo)
+	_, _ = fmt.Fprintf(w, "Uptime:      %s\n", time.Duration(d.UptimeSeconds)*time.Second)
+	_, _ = fmt.Fprintf(w, "Goroutines:  %d\n", d.Goroutines)
+	_, _ = fmt.Fprintf(w, "Memory:      %.1f MiB\n", float64(d.MemAllocBytes)/1024/1024)
+	if c := d.KubeconfigCache; c != nil {
+		_, _ = fmt.Fprintf(
+			w, "Kubeconfig:  %d files (%d bytes), %d searchers, %d application watchers\n",
+			c.Files, c.Bytes, c.Searchers, c.AppMetaWatchers,
+		)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nPort-forwards (%d):\n", len(d.PortForwards))
+	if len(d.PortForwards) > 0 {
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"NAMESPACE", "APP", "WORKLOAD", "PORTS", "PROTOCOL", "ROLE", "RECONNECTS"})
+		for _, pf := range d.PortForwards {
+			ports := fmt.Sprintf("%d:%d", pf.LocalPort, pf.RemotePort)
+			if pf.Reverse {
+				ports = fmt.Sprintf("%d<-%d", pf.LocalPort, pf.RemotePort)
+			}
+			table.Append(
+				[]string{
+					pf.NameSpace, pf.AppName, pf.SvcType + "/" + pf.SvcName, ports, pf.Protocol, pf.Role,
+					strconv.Itoa(pf.Reconnects),
+				},
+			)
+		}
+		table.Render()
+	}
+
+	_, _ = fmt.Fprintf(w, "\nSync sessions (%d):\n", len(d.SyncSessions))
+	if len(d.SyncSessions) > 0 {
+		table := tablewriter.NewWriter(w)
+		table.SetHeader([]string{"NAMESPACE", "APP", "WORKLOAD", "ENGINE", "PAUSED"})
+		for _, s := range d.SyncSessions {
+			engine := s.SyncEngine
+			if engine == "" {
+				engine = _const.SyncthingSyncEngine
+			}
+			table.Append(
+				[]string{s.NameSpace, s.AppName, s.SvcType + "/" + s.SvcName, engine, strconv.FormatBool(s.Paused)},
+			)
+		}
+		table.Render()
+	}
+
+	_, _ = fmt.Fprintf(w, "\nErrors:\n")
+	if len(d.Errors) == 0 {
+		_, _ = fmt.Fprintln(w, "  none")
+	}
+	kinds := make([]string, 0, len(d.Errors))
+	for kind := range d.Errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		_, _ = fmt.Fprintf(w, "  %s: %d\n", kind, d.Errors[kind])
+	}
+}
@@ -0,0 +1,20 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(remoteDebugCmd)
+}
+
+var remoteDebugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Debug the workload in DevMode remotely",
+	Long:  `Debug the workload in DevMode remotely by the debugger of its language`,
+}
@@ -0,0 +1,85 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/controller"
+	"nocalhost/internal/nhctl/debugger"
+)
+
+var (
+	debugOptions = &controller.RemoteDebugOptions{}
+	debugApp     string
+)
+
+func init() {
+	debugStartCmd.Flags().StringVarP(
+		&debugApp, "application", "a", _const.DefaultNocalhostApplication, "application of the workload",
+	)
+	debugStartCmd.Flags().StringVarP(
+		&common.ServiceType, "controller-type", "t", "deployment",
+		"kind of k8s controller,such as deployment,statefulSet",
+	)
+	debugStartCmd.Flags().StringVarP(&debugOptions.Container, "container", "c", "", "container to debug")
+	debugStartCmd.Flags().StringVar(
+		&debugOptions.Language, "lang", "",
+		"language of the program, "+strings.Join(debugger.Languages, "|")+", the one of debug config by default",
+	)
+	debugStartCmd.Flags().IntVar(
+		&debugOptions.RemotePort, "remote-port", 0,
+		"port of the debugger in the container, the one of debug config or the default of language by default",
+	)
+	debugStartCmd.Flags().IntVar(
+		&debugOptions.LocalPort, "local-port", 0, "local port for the IDE to attach, the remote port by default",
+	)
+	remoteDebugCmd.AddCommand(debugStartCmd)
+}
+
+var debugStartCmd = &cobra.Command{
+	Use:   "start [WORKLOAD] [-- COMMAND [args...]]",
+	Short: "Run the program under the debugger and wait for attach",
+	Long: `Run the program under the debugger of its language in the dev container, forward the debug port to local
+and wait for the debugger to attach, the program is killed and the port-forward is closed on exit.
+The program is the run command of dev config if the command is omitted, its debugger is:
+  go      dlv debug for go run, dlv exec for the binary
+  java    jdwp agent by JAVA_TOOL_OPTIONS
+  node    the inspector by --inspect-brk or NODE_OPTIONS
+  python  debugpy`,
+	Example: `
+  nhctl debug start details -a bookinfo --lang go -- go run ./cmd/details
+  nhctl debug start reviews -a bookinfo --lang java --local-port 15005 -- java -jar reviews.jar
+  nhctl debug start productpage -a bookinfo --lang python -- python productpage.py 9080`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 || cmd.ArgsLenAtDash() == 0 {
+			return errors.Errorf("%q requires the workload\n", cmd.CommandPath())
+		}
+		if cmd.ArgsLenAtDash() > 1 || (cmd.ArgsLenAtDash() < 0 && len(args) > 1) {
+			return errors.Errorf("%q requires only the workload before --\n", cmd.CommandPath())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if cmd.ArgsLenAtDash() > 0 {
+			debugOptions.Command = args[cmd.ArgsLenAtDash():]
+		}
+		_, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(debugApp, args[0], common.ServiceType)
+		must(err)
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		must(nocalhostSvc.RemoteDebug(ctx, debugOptions, os.Stdout, os.Stderr))
+	},
+}
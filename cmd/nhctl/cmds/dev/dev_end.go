@@ -13,6 +13,7 @@ import (
 	"nocalhost/internal/nhctl/coloredoutput"
 	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/controller"
+	"nocalhost/internal/nhctl/ui/picker"
 	"nocalhost/internal/nhctl/utils"
 	"nocalhost/pkg/nhctl/log"
 	"strconv"
@@ -29,13 +30,14 @@ var DevEndCmd = &cobra.Command{
 	Short: "end dev model",
 	Long:  `end dev model`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 1 {
+		if len(args) < 1 && !picker.IsInteractive() {
 			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		applicationName := args[0]
+		applicationName, err := common.PickOmittedWorkload(args, nil)
+		must(err)
 		_, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(applicationName, common.WorkloadName, common.ServiceType)
 		must(err)
 		EndDevMode(nocalhostSvc)
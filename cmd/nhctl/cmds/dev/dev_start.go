@@ -11,6 +11,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"nocalhost/cmd/nhctl/cmds/common"
 	"nocalhost/internal/nhctl/app"
 	"nocalhost/internal/nhctl/coloredoutput"
@@ -23,9 +24,11 @@ import (
 	"nocalhost/internal/nhctl/nocalhost_path"
 	"nocalhost/internal/nhctl/profile"
 	"nocalhost/internal/nhctl/syncthing"
+	"nocalhost/internal/nhctl/ui/picker"
 	"nocalhost/internal/nhctl/utils"
 	"nocalhost/pkg/nhctl/log"
 	utils2 "nocalhost/pkg/nhctl/utils"
+	"os"
 	"strconv"
 	"strings"
 )
@@ -61,6 +64,18 @@ func init() {
 		&devStartOps.Container, "container", "c", "",
 		"container to develop",
 	)
+	DevStartCmd.Flags().StringSliceVar(
+		&devStartOps.ExtraContainers, "extra-container", []string{},
+		"other containers of the pod to develop with --container, they share its synced work directory",
+	)
+	DevStartCmd.Flags().StringVar(
+		&devStartOps.Cpu, "cpu", "",
+		"cpu limit of DevContainer, such as 4, overriding the resources of dev config and the workload",
+	)
+	DevStartCmd.Flags().StringVar(
+		&devStartOps.Memory, "memory", "",
+		"memory limit of DevContainer, such as 8Gi, overriding the resources of dev config and the workload",
+	)
 	//DevStartCmd.Flags().StringVar(&devStartOps.WorkDir, "work-dir", "", "container's work directory")
 	DevStartCmd.Flags().StringVar(&devStartOps.StorageClass, "storage-class", "", "StorageClass used by PV")
 	DevStartCmd.Flags().StringVar(
@@ -88,14 +103,32 @@ func init() {
 		&devStartOps.NoSyncthing, "without-sync", false,
 		"do not start file-sync while dev start success",
 	)
+	DevStartCmd.Flags().BoolVar(
+		&devStartOps.SSH, "ssh", false,
+		"run sshd in the sidecar, so that `nhctl ssh` forwards the ssh agent to the dev container",
+	)
 	DevStartCmd.Flags().StringVarP(
 		&devStartOps.DevModeType, "dev-mode", "m", "",
 		"specify which DevMode you want to enter, such as: replace,duplicate. Default: replace",
 	)
+	DevStartCmd.Flags().StringVar(
+		&devStartOps.DevModeType, "mode", "",
+		"same as --dev-mode, duplicate clones the workload and leaves the original pods untouched",
+	)
 	DevStartCmd.Flags().StringToStringVar(
 		&devStartOps.MeshHeader, "header", map[string]string{},
 		"mesh header while use duplicate devMode, traffic which have those headers will route to current workload",
 	)
+	DevStartCmd.Flags().StringVar(
+		&devStartOps.MeshProvider, "mesh-provider", "",
+		"route the traffic with the headers by the service mesh of the namespace, such as: istio,linkerd. "+
+			"Default: the envoy sidecar of nocalhost",
+	)
+	DevStartCmd.Flags().StringVar(
+		&devStartOps.Sidecars, "sidecars", "",
+		"keep or strip the sidecars injected by mesh such as istio-proxy, the dev pod stripped leaves the mesh. "+
+			"Default: the sidecars of dev config, or keep",
+	)
 }
 
 var DevStartCmd = &cobra.Command{
@@ -103,14 +136,16 @@ var DevStartCmd = &cobra.Command{
 	Short: "Start DevMode",
 	Long:  `Start DevMode`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 1 {
+		if len(args) < 1 && !picker.IsInteractive() {
 			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		d := DevStartOps{DevStartOptions: devStartOps}
-		must(d.StartDevMode(args[0]))
+		applicationName, err := common.PickOmittedWorkload(args, &d.Container)
+		must(err)
+		must(d.StartDevMode(applicationName))
 	},
 }
 
@@ -120,6 +155,24 @@ func (d *DevStartOps) StartDevMode(applicationName string) error {
 	if !dt.IsDuplicateDevMode() && !dt.IsReplaceDevMode() {
 		return errors.New(fmt.Sprintf("Unsupported DevModeType %s", dt))
 	}
+	if err := controller.CheckMeshProvider(d.MeshProvider); err != nil {
+		return err
+	}
+	if d.MeshProvider != "" && (!dt.IsDuplicateDevMode() || len(d.MeshHeader) == 0) {
+		return errors.New("'mesh-provider' requires duplicate DevMode and 'header'")
+	}
+	if d.Sidecars != "" && d.Sidecars != _const.KeepSidecarPolicy && d.Sidecars != _const.StripSidecarPolicy {
+		return errors.New(fmt.Sprintf("Unsupported sidecars %s, it should be keep or strip", d.Sidecars))
+	}
+	if d.Sidecars == _const.StripSidecarPolicy && d.MeshProvider != "" {
+		return errors.New("'mesh-provider' requires the sidecars of mesh, they can not be stripped")
+	}
+
+	for flag, q := range map[string]string{"cpu": d.Cpu, "memory": d.Memory} {
+		if _, err := resource.ParseQuantity(q); q != "" && err != nil {
+			return errors.New(fmt.Sprintf("Invalid --%s %s: %s", flag, q, err.Error()))
+		}
+	}
 
 	if len(d.LocalSyncDir) > 1 {
 		log.Fatal("Can not define multi 'local-sync(-s)'")
@@ -176,7 +229,8 @@ func (d *DevStartOps) StartDevMode(applicationName string) error {
 	// 7) enter developing (replace image)
 	// 8) port forward for dev-container
 	// 9) start syncthing
-	// 10) entering dev container
+	// 10) run post create command
+	// 11) entering dev container
 
 	coloredoutput.Hint(fmt.Sprintf("Starting %s DevMode...", dt.ToString()))
 
@@ -201,6 +255,11 @@ func (d *DevStartOps) StartDevMode(applicationName string) error {
 		coloredoutput.Success("File sync is not started caused by --without-sync flag..")
 	}
 
+	utils.ShouldI(
+		d.NocalhostSvc.RunPostCreateCommand(devPodName, d.Container, !d.NoSyncthing, os.Stdout, os.Stderr),
+		"Failed to run the post create command",
+	)
+
 	if !d.NoTerminal || shell != "" {
 		must(d.NocalhostSvc.EnterPodTerminal(devPodName, "", shell, ""))
 	}
@@ -378,7 +437,7 @@ func (d *DevStartOps) enterDevMode(devModeType profile.DevModeType) error {
 
 func (d *DevStartOps) startPortForwardAfterDevStart(devPodName string) {
 	for _, pf := range pfListBeforeDevStart {
-		utils.Should(d.NocalhostSvc.PortForward(devPodName, pf.LocalPort, pf.RemotePort, pf.Role))
+		utils.Should(d.NocalhostSvc.RestorePortForward(devPodName, pf))
 	}
 	must(d.NocalhostSvc.PortForwardAfterDevStart(devPodName, d.Container))
 }
@@ -34,6 +34,11 @@ func (d *DevStartOps) StartSyncthing(podName string, resume bool, stop bool, syn
 		)
 	}
 
+	if d.NocalhostSvc.GetSyncEngine(d.Container) == _const.MutagenSyncEngine {
+		d.startMutagen(resume, stop, syncDouble)
+		return
+	}
+
 	// resume port-forward and syncthing
 	if resume || stop {
 		utils.ShouldI(d.NocalhostSvc.StopFileSyncOnly(), "Error occurs when stopping sync process")
@@ -68,20 +73,7 @@ func (d *DevStartOps) StartSyncthing(podName string, resume bool, stop bool, syn
 	utils2.KillSyncthingProcess(str)
 
 	if syncDouble == nil {
-		flag := false
-
-		config := d.NocalhostSvc.Config()
-		if cfg := config.GetContainerDevConfig(d.Container); cfg != nil && cfg.Sync != nil {
-			switch cfg.Sync.Type {
-
-			case _const.DefaultSyncType:
-				flag = true
-
-			default:
-				flag = false
-			}
-		}
-
+		flag := d.isSyncDouble()
 		syncDouble = &flag
 	}
 
@@ -139,3 +131,42 @@ func (d *DevStartOps) StartSyncthing(podName string, resume bool, stop bool, syn
 		}
 	}
 }
+
+// startMutagen the conflicts are resolved by the local files as syncthing
+// does, see FileSync
+func (d *DevStartOps) startMutagen(resume bool, stop bool, syncDouble *bool) {
+	if resume || stop {
+		utils.ShouldI(d.NocalhostSvc.StopFileSyncOnly(), "Error occurs when stopping sync process")
+		if stop {
+			return
+		}
+	}
+
+	if syncDouble == nil {
+		flag := d.isSyncDouble()
+		syncDouble = &flag
+	}
+	svcProfile, err := d.NocalhostSvc.GetProfile()
+	must(err)
+	must(
+		d.NocalhostSvc.StartMutagenSync(
+			d.Container, svcProfile.LocalAbsoluteSyncDirFromDevStartPlugin, *syncDouble,
+		),
+	)
+	must(d.NocalhostSvc.SetSyncingStatus(true))
+}
+
+func (d *DevStartOps) isSyncDouble() bool {
+	config := d.NocalhostSvc.Config()
+	if cfg := config.GetContainerDevConfig(d.Container); cfg != nil && cfg.Sync != nil {
+		switch cfg.Sync.Type {
+
+		case _const.DefaultSyncType:
+			return true
+
+		default:
+			return false
+		}
+	}
+	return false
+}
@@ -11,6 +11,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"nocalhost/cmd/nhctl/cmds/common"
 	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/ui/picker"
 	"nocalhost/pkg/nhctl/log"
 )
 
@@ -40,13 +41,14 @@ var devTerminalCmd = &cobra.Command{
 	Short: "Enter dev container's terminal",
 	Long:  `Enter dev container's terminal`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 1 {
+		if len(args) < 1 && !picker.IsInteractive() {
 			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		applicationName := args[0]
+		applicationName, err := common.PickOmittedWorkload(args, nil)
+		must(err)
 		_, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(applicationName, common.WorkloadName, common.ServiceType)
 		must(err)
 
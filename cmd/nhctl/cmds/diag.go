@@ -0,0 +1,20 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(diagCmd)
+}
+
+var diagCmd = &cobra.Command{
+	Use:   "diag",
+	Short: "Collect the diagnostics of nhctl",
+	Long:  `Collect the diagnostics of nhctl for reporting bugs`,
+}
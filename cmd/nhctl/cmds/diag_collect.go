@@ -0,0 +1,63 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/internal/nhctl/diag"
+	"nocalhost/internal/nhctl/utils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+var (
+	diagOutput    string
+	diagMaxLogMB  int64
+	diagMaxEvents int
+)
+
+func init() {
+	diagCollectCmd.Flags().StringVarP(
+		&diagOutput, "output", "o", "", "path of the bundle, nhctl-diag-<time>.tar.gz in current directory if empty",
+	)
+	diagCollectCmd.Flags().Int64Var(&diagMaxLogMB, "max-log-size", 10, "only the tail of each log in MB is collected")
+	diagCollectCmd.Flags().IntVar(&diagMaxEvents, "max-events", 200, "the latest events of namespace to collect")
+	diagCmd.AddCommand(diagCollectCmd)
+}
+
+var diagCollectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Collect the diagnostics into a tar.gz",
+	Long: `Collect the logs, the profiles, the port-forwards and file sync of daemon, the metadata of kubeconfig
+and the events of namespace into a tar.gz for attaching to bug reports. The secrets, such as the tokens,
+passwords and private keys are redacted, and the credentials of kubeconfig are never collected`,
+	Example: `
+  nhctl diag collect --kubeconfig ~/.kube/config -n nocalhost -o nhctl-diag.tar.gz`,
+	Run: func(cmd *cobra.Command, args []string) {
+		kubeconfig := common.KubeConfig
+		if kubeconfig == "" {
+			kubeconfig = filepath.Join(utils.GetHomePath(), ".kube", "config")
+		}
+		if diagOutput == "" {
+			diagOutput = fmt.Sprintf("nhctl-diag-%s.tar.gz", time.Now().Format("20060102-150405"))
+		}
+		manifest, err := diag.Collect(
+			diag.Options{
+				KubeConfig: kubeconfig, Namespace: common.NameSpace, Output: diagOutput,
+				MaxLogBytes: diagMaxLogMB * 1024 * 1024, MaxEvents: diagMaxEvents,
+			},
+		)
+		must(err)
+		for _, e := range manifest.Errors {
+			log.Warnf("Failed to collect %s", e)
+		}
+		log.Infof("%d files are collected into %s, please check it before attaching", len(manifest.Files), diagOutput)
+	},
+}
@@ -0,0 +1,86 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/internal/nhctl/doctor"
+	"nocalhost/internal/nhctl/utils"
+)
+
+var (
+	doctorPorts   []int
+	doctorOutput  string
+	doctorTimeout time.Duration
+)
+
+func init() {
+	doctorCmd.Flags().IntSliceVarP(
+		&doctorPorts, "port", "p", []int{}, "the local ports should be available, such as the ones to forward",
+	)
+	doctorCmd.Flags().StringVarP(&doctorOutput, "output", "o", "", "json or empty")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 5*time.Second, "timeout of each check of network")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the environment of nhctl",
+	Long: `Diagnose the environment of nhctl and the connectivity to the cluster, such as the kubeconfig,
+the permissions of namespace, the syncthing installed, the daemon and the local ports`,
+	Example: `
+  nhctl doctor --kubeconfig ~/.kube/config -n nocalhost -p 8080`,
+	Run: func(cmd *cobra.Command, args []string) {
+		kubeconfig := common.KubeConfig
+		if kubeconfig == "" {
+			kubeconfig = filepath.Join(utils.GetHomePath(), ".kube", "config")
+		}
+		results := doctor.Run(
+			doctor.Options{
+				KubeConfig: kubeconfig, Namespace: common.NameSpace, Ports: doctorPorts, Timeout: doctorTimeout,
+			},
+		)
+
+		if doctorOutput == "json" {
+			bys, err := json.Marshal(results)
+			must(err)
+			fmt.Println(string(bys))
+		} else {
+			renderDoctorResults(os.Stdout, results)
+		}
+		if !doctor.Passed(results) {
+			os.Exit(1)
+		}
+	},
+}
+
+func renderDoctorResults(w io.Writer, results []*doctor.Result) {
+	counts := map[doctor.Status]int{}
+	for _, r := range results {
+		counts[r.Status]++
+		_, _ = fmt.Fprintf(w, "[%s] %-14s %s\n", r.Status, r.Name, r.Message)
+		if r.Fix != "" && r.Status != doctor.Pass {
+			_, _ = fmt.Fprintf(w, "       %-14s fix: %s\n", "", r.Fix)
+		}
+	}
+
+	overall := doctor.Pass
+	if !doctor.Passed(results) {
+		overall = doctor.Fail
+	}
+	_, _ = fmt.Fprintf(
+		w, "\n%s: %d passed, %d warnings, %d failed, %d skipped\n", overall,
+		counts[doctor.Pass], counts[doctor.Warn], counts[doctor.Fail], counts[doctor.Skip],
+	)
+}
@@ -6,19 +6,25 @@
 package cmds
 
 import (
+	"fmt"
+	"os"
+	"regexp"
+
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/exec"
 	"nocalhost/cmd/nhctl/cmds/common"
 	_const "nocalhost/internal/nhctl/const"
-	"nocalhost/pkg/nhctl/log"
-	"regexp"
+	"nocalhost/internal/nhctl/ui/picker"
 )
 
 type ExecFlags struct {
 	CommonFlags
 	Commands  []string
 	Container string
+	Pod       string
+	Shell     string
 }
 
 var execFlags = ExecFlags{}
@@ -26,11 +32,13 @@ var execFlags = ExecFlags{}
 func init() {
 	execCmd.Flags().StringArrayVarP(
 		&execFlags.Commands, "command", "c", nil,
-		"command to execute in container",
+		"command to execute in container, the command after -- is preferred",
 	)
 	execCmd.Flags().StringVarP(&execFlags.Container, "container", "", "", "container name")
+	execCmd.Flags().StringVar(&execFlags.Pod, "pod", "", "pod to execute, the one in DevMode by default")
+	execCmd.Flags().StringVar(&execFlags.Shell, "shell", "", "shell to enter if the command is omitted")
 	execCmd.Flags().StringVarP(
-		&execFlags.SvcName, "deployment", "d", "",
+		&common.WorkloadName, "deployment", "d", "",
 		"k8s deployment which your developing service exists",
 	)
 	execCmd.Flags().StringVarP(
@@ -41,50 +49,91 @@ func init() {
 }
 
 var execCmd = &cobra.Command{
-	Use:   "exec [NAME]",
+	Use:   "exec [NAME] [-- COMMAND [args...]]",
 	Short: "Execute a command in container",
-	Long:  `Execute a command in container`,
+	Long: `Execute a command in container, or enter the shell of dev container if the command is omitted.
+The tty is allocated only if stdin is a terminal, and the exit code of command is the one of nhctl`,
+	Example: `
+  # Execute a command in the dev container of deployment details
+  nhctl exec bookinfo -d details -- ls -al
+
+  # Enter the shell of dev container, reconnecting if the connection drops
+  nhctl exec bookinfo -d details
+
+  # Pipe the input to the command
+  cat app.sql | nhctl exec bookinfo -d mysql --container mysql -- mysql -uroot`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 1 {
+		if cmd.ArgsLenAtDash() > 1 || (cmd.ArgsLenAtDash() < 0 && len(args) > 1) {
+			return errors.Errorf("%q requires at most 1 argument before --\n", cmd.CommandPath())
+		}
+		if (len(args) < 1 || cmd.ArgsLenAtDash() == 0) && !picker.IsInteractive() {
 			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		execFlags.AppName = args[0]
-		// replace $(XXX) --> ${XXX}, support environment variable
-		compile, _ := regexp.Compile(`\$\((.*?)\)`)
-		for i := 0; i < len(execFlags.Commands); i++ {
-			execFlags.Commands[i] = compile.ReplaceAllString(execFlags.Commands[i], "${$1}")
+		commands := execFlags.Commands
+		if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+			args, commands = args[:dash], args[dash:]
+		} else {
+			// replace $(XXX) --> ${XXX}, support environment variable
+			compile, _ := regexp.Compile(`\$\((.*?)\)`)
+			for i := 0; i < len(commands); i++ {
+				commands[i] = compile.ReplaceAllString(commands[i], "${$1}")
+			}
 		}
-		nocalhostApp, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(execFlags.AppName, execFlags.SvcName, common.ServiceType)
+
+		var err error
+		execFlags.AppName, err = common.PickOmittedWorkload(args, &execFlags.Container)
+		must(err)
+		nocalhostApp, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(
+			execFlags.AppName, common.WorkloadName, common.ServiceType,
+		)
 		must(err)
 
 		podList, err := nocalhostSvc.GetPodList()
-		if err != nil {
-			log.Fatal(err)
-		}
-		runningPod := make([]v1.Pod, 0, 1)
-
-		var ableToExec *v1.Pod = nil
+		must(err)
+		pod, err := podToExec(podList, execFlags.Pod)
+		must(err)
 
-	Exit:
-		for _, item := range podList {
-			if item.Status.Phase == v1.PodRunning && item.DeletionTimestamp == nil {
-				runningPod = append(runningPod, item)
-			}
+		if len(commands) == 0 {
+			err = nocalhostSvc.EnterPodTerminal(pod.Name, execFlags.Container, execFlags.Shell, "")
+		} else {
+			err = nocalhostApp.ExecInteractive(*pod, execFlags.Container, commands)
+		}
+		// the exit code of command is propagated, such as the one of test in scripts
+		if e, ok := err.(exec.CodeExitError); ok {
+			os.Exit(e.Code)
+		}
+		must(err)
+	},
+}
 
-			for _, c := range item.Spec.Containers {
-				if c.Name == _const.NocalhostDefaultDevSidecarName {
-					ableToExec = &item
-					break Exit
-				}
+// podToExec the pod named, or the pod in DevMode, or the only one running
+func podToExec(pods []v1.Pod, name string) (*v1.Pod, error) {
+	running := make([]v1.Pod, 0, 1)
+	for _, item := range pods {
+		if item.Status.Phase != v1.PodRunning || item.DeletionTimestamp != nil {
+			continue
+		}
+		if name != "" {
+			if item.Name == name {
+				return &item, nil
 			}
+			continue
 		}
-		if ableToExec == nil && len(runningPod) != 1 {
-			log.Fatalf("pod number: %d, is not 1, please make sure pod number is 1", len(runningPod))
+		for _, c := range item.Spec.Containers {
+			if c.Name == _const.NocalhostDefaultDevSidecarName {
+				return &item, nil
+			}
 		}
-		ableToExec = &runningPod[0]
-		must(nocalhostApp.Exec(*ableToExec, execFlags.Container, execFlags.Commands))
-	},
+		running = append(running, item)
+	}
+	if name != "" {
+		return nil, errors.New(fmt.Sprintf("Pod %s is not found or not running", name))
+	}
+	if len(running) != 1 {
+		return nil, errors.New(fmt.Sprintf("Pod num is %d (not 1), please specify one by --pod", len(running)))
+	}
+	return &running[0], nil
 }
@@ -8,35 +8,34 @@ package cmds
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"nocalhost/cmd/nhctl/cmds/common"
 	"nocalhost/internal/nhctl/daemon_client"
 	"nocalhost/internal/nhctl/daemon_handler/item"
 	"nocalhost/internal/nhctl/model"
+	"nocalhost/internal/nhctl/printer"
 	"nocalhost/internal/nhctl/utils"
 	k8sutil "nocalhost/pkg/nhctl/k8sutils"
 	"nocalhost/pkg/nhctl/log"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"time"
 )
 
 var outputType string
 var label map[string]string
 
-const JSON = "json"
-const YAML = "yaml"
-
 func init() {
 	getCmd.PersistentFlags().StringVarP(
 		&appName, "application", "a", "", "application name",
 	)
 	getCmd.PersistentFlags().StringVarP(
-		&outputType, "outputType", "o", "", "json or yaml",
+		&outputType, "outputType", "o", "",
+		"output format, one of: "+strings.Join(printer.Formats, ", ")+", such as custom-columns=NAME:.info.metadata.name",
 	)
 	getCmd.PersistentFlags().StringToStringVarP(
 		&label, "selector", "l", map[string]string{}, "Selector (label query) to filter on, "+
@@ -58,9 +57,23 @@ nhctl get service serviceName [-n namespace] --kubeconfig=kubeconfigfile
 
 	# Get all application in namespace
 	nhctl get application -n namespaceName --kubeconfig=kubeoconfigpath
+
+	# Get application with its revisions
+	nhctl get application bookinfo -n namespaceName --kubeconfig=kubeoconfigpath
   
 	# Get all deployment of application in namespace
 	nhctl get deployment -n namespaceName -a bookinfo --kubeconfig=kubeconfigpath
+
+	# Get the status of DevMode and file sync of deployments
+	nhctl get deployment -a bookinfo -o wide --kubeconfig=kubeconfigpath
+
+	# Get the names and images of deployments
+	nhctl get deployment -a bookinfo --kubeconfig=kubeconfigpath \
+	  -o custom-columns=NAME:.info.metadata.name,IMAGE:.info.spec.template.spec.containers[*].image
+
+	# Get the names of applications by go-template or jsonpath
+	nhctl get application -o go-template='{{range .}}{{range .application}}{{.name}}{{"\n"}}{{end}}{{end}}'
+	nhctl get application -o jsonpath='{[*].application[*].name}'
 `,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) < 1 {
@@ -69,6 +82,8 @@ nhctl get service serviceName [-n namespace] --kubeconfig=kubeconfigfile
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		p, err := printer.New(outputType)
+		must(err)
 		resourceType := args[0]
 		resourceName := ""
 		if len(args) >= 2 {
@@ -105,121 +120,162 @@ nhctl get service serviceName [-n namespace] --kubeconfig=kubeconfigfile
 			return
 		}
 
-		switch outputType {
-		case JSON:
-			out(json.Marshal, data)
-		case YAML:
-			out(yaml.Marshal, data)
-		default:
-			bytes, err := json.Marshal(data)
-			if err != nil {
-				log.Error(err)
-				return
-			}
-			switch resourceType {
-			case "all":
-				multiple := reflect.ValueOf(data).Kind() == reflect.Slice
-				var results []item.Result
-				var result item.Result
-				if multiple {
-					_ = json.Unmarshal(bytes, &results)
-				} else {
-					_ = json.Unmarshal(bytes, &result)
-				}
-				if !multiple {
-					results = append(results, result)
-				}
-				printResult(results)
-			case "app", "application":
-				multiple := reflect.ValueOf(data).Kind() == reflect.Slice
-				var metas []*model.Namespace
-				var meta *model.Namespace
-				if multiple {
-					_ = json.Unmarshal(bytes, &metas)
-				} else {
-					_ = json.Unmarshal(bytes, &meta)
-				}
-				if !multiple {
-					metas = append(metas, meta)
-				}
-				printMeta(metas)
-			default:
-				multiple := reflect.ValueOf(data).Kind() == reflect.Slice
-				var items []item.Item
-				var item item.Item
-				if multiple {
-					_ = json.Unmarshal(bytes, &items)
-				} else {
-					_ = json.Unmarshal(bytes, &item)
-				}
-				if !multiple {
-					items = append(items, item)
-				}
-				printItem(items)
-			}
+		table, revisions, err := getTableOf(resourceType, data)
+		must(err)
+		must(p.Print(os.Stdout, data, table))
+
+		// the history is returned if the application is specified
+		if len(revisions) > 0 && (outputType == printer.Default || outputType == printer.Wide) {
+			fmt.Println()
+			must(p.Print(os.Stdout, nil, revisionsTable(revisions)))
 		}
 	},
 }
 
-func out(f func(interface{}) ([]byte, error), data interface{}) {
-	if bytes, err := f(data); err == nil {
-		fmt.Print(string(bytes))
+var (
+	itemColumns = []printer.Column{
+		{Header: "namespace", Path: ".info.metadata.namespace"}, {Header: "name", Path: ".info.metadata.name"},
 	}
-}
+	itemWideColumns = []printer.Column{
+		{Header: "kind", Path: ".info.kind"},
+		{Header: "dev mode", Path: ".description.devModeType"},
+		{Header: "dev status", Path: ".description.develop_status"},
+		{Header: "syncing", Path: ".description.syncing"},
+		{Header: "port-forwards", Path: "{range .description.devPortForwardList[*]}{.localport}:{.remoteport} {end}"},
+		{Header: "vpn", Path: ".vpn.status"},
+	}
+	resultColumns = []printer.Column{
+		{Header: "namespace", Path: ".namespace"}, {Header: "application", Path: ".application"},
+		{Header: "group", Path: ".group"}, {Header: "name", Path: ".name"},
+	}
+	resultWideColumns = []printer.Column{
+		{Header: "dev status", Path: ".description.develop_status"},
+		{Header: "syncing", Path: ".description.syncing"},
+	}
+	applicationColumns = []printer.Column{
+		{Header: "namespace", Path: ".namespace"}, {Header: "name", Path: ".name"}, {Header: "type", Path: ".type"},
+		{Header: "revision", Path: ".revision"}, {Header: "blocked by", Path: ".blocked_by"},
+	}
+	revisionColumns = []printer.Column{
+		{Header: "revision", Path: ".revision"}, {Header: "updated", Path: ".updated"},
+		{Header: "action", Path: ".action"}, {Header: "chart", Path: ".chart"},
+		{Header: "manifest hash", Path: ".manifest_hash"}, {Header: "triggered by", Path: ".triggered_by"},
+	}
+)
 
-func write(headers []string, rows [][]string) {
-	writer := tablewriter.NewWriter(os.Stdout)
-	writer.SetBorder(false)
-	writer.SetColumnSeparator("")
-	writer.SetRowSeparator("")
-	writer.SetCenterSeparator("")
-	writer.SetHeaderLine(false)
-	writer.SetHeader(headers)
-	writer.AppendBulk(rows)
-	writer.Render()
+// applicationRow the application with its namespace, for the columns
+type applicationRow struct {
+	Namespace string `json:"namespace"`
+	*model.ApplicationInfo
 }
 
-func printResult(results []item.Result) {
-	for _, r := range results {
-		var needsToComplete = true
-		var rows [][]string
-		for _, app := range r.Application {
-			for _, group := range app.Groups {
-				for _, list := range group.List {
-					for _, omItem := range list.List {
-						_, name, err := k8sutil.GetNamespaceAndNameFromObjectMeta(omItem.Metadata)
-						if err == nil {
-							needsToComplete = false
-							row := []string{r.Namespace, app.Name, group.GroupName, list.Name + "/" + name}
-							rows = append(rows, row)
-						}
-					}
-				}
+// getTableOf the rows of table by the resource type, and the revisions of
+// the application specified
+func getTableOf(resourceType string, data interface{}) (*printer.Table, []*model.RevisionInfo, error) {
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+	multiple := reflect.ValueOf(data).Kind() == reflect.Slice
+
+	table := &printer.Table{Rows: make([]interface{}, 0)}
+	switch resourceType {
+	case "all":
+		var results []item.Result
+		if multiple {
+			err = json.Unmarshal(bytes, &results)
+		} else {
+			var result item.Result
+			err = json.Unmarshal(bytes, &result)
+			results = append(results, result)
+		}
+		table.Columns, table.Wide = resultColumns, resultWideColumns
+		for _, r := range results {
+			table.Rows = append(table.Rows, resultRows(r)...)
+		}
+		return table, nil, errors.Wrap(err, "")
+	case "app", "application":
+		var metas []*model.Namespace
+		if multiple {
+			err = json.Unmarshal(bytes, &metas)
+		} else {
+			var meta *model.Namespace
+			err = json.Unmarshal(bytes, &meta)
+			metas = append(metas, meta)
+		}
+		table.Columns = applicationColumns
+		var revisions []*model.RevisionInfo
+		for _, e := range metas {
+			if e == nil {
+				continue
+			}
+			for _, appInfo := range e.Application {
+				table.Rows = append(table.Rows, &applicationRow{Namespace: e.Namespace, ApplicationInfo: appInfo})
+				revisions = append(revisions, appInfo.Revisions...)
 			}
 		}
-		if needsToComplete {
-			rows = append(rows, []string{r.Namespace})
+		return table, revisions, errors.Wrap(err, "")
+	default:
+		var items []item.Item
+		if multiple {
+			err = json.Unmarshal(bytes, &items)
+		} else {
+			var i item.Item
+			err = json.Unmarshal(bytes, &i)
+			items = append(items, i)
 		}
-		write([]string{"namespace", "application", "group", "name"}, rows)
+		table.Columns, table.Wide = itemColumns, itemWideColumns
+		for _, i := range items {
+			if _, _, err2 := k8sutil.GetNamespaceAndNameFromObjectMeta(i.Metadata); err2 == nil {
+				table.Rows = append(table.Rows, i)
+			}
+		}
+		return table, nil, errors.Wrap(err, "")
 	}
 }
 
-func printItem(items []item.Item) {
-	var rows [][]string
-	for _, i := range items {
-		if namespace, name, err2 := k8sutil.GetNamespaceAndNameFromObjectMeta(i.Metadata); err2 == nil {
-			rows = append(rows, []string{namespace, name})
+// resultRows the resources of applications flattened, the namespace has a row
+// without resources if it is empty
+func resultRows(r item.Result) []interface{} {
+	rows := make([]interface{}, 0)
+	for _, app := range r.Application {
+		for _, group := range app.Groups {
+			for _, list := range group.List {
+				for _, omItem := range list.List {
+					_, name, err := k8sutil.GetNamespaceAndNameFromObjectMeta(omItem.Metadata)
+					if err != nil {
+						continue
+					}
+					rows = append(
+						rows, map[string]interface{}{
+							"namespace": r.Namespace, "application": app.Name, "group": group.GroupName,
+							"resource": list.Name, "name": list.Name + "/" + name,
+							"info": omItem.Metadata, "description": omItem.Description, "vpn": omItem.VPN,
+						},
+					)
+				}
+			}
 		}
 	}
-	write([]string{"namespace", "name"}, rows)
+	if len(rows) == 0 {
+		rows = append(rows, map[string]interface{}{"namespace": r.Namespace})
+	}
+	return rows
 }
 
-func printMeta(metas []*model.Namespace) {
-	var rows [][]string
-	for _, e := range metas {
-		for _, appInfo := range e.Application {
-			rows = append(rows, []string{e.Namespace, appInfo.Name, appInfo.Type})
+func revisionsTable(revisions []*model.RevisionInfo) *printer.Table {
+	table := &printer.Table{Rows: make([]interface{}, 0, len(revisions)), Columns: revisionColumns}
+	for _, r := range revisions {
+		action := r.Action
+		if r.RollbackTo > 0 {
+			action = fmt.Sprintf("%s to %d", action, r.RollbackTo)
 		}
+		table.Rows = append(
+			table.Rows, map[string]interface{}{
+				"revision": r.Revision, "updated": time.Unix(r.Time, 0).Format(time.RFC3339), "action": action,
+				"chart": r.ChartVersion, "manifest_hash": r.ManifestHash, "triggered_by": r.TriggeredBy,
+			},
+		)
 	}
-	write([]string{"namespace", "name", "type"}, rows)
+	return table
 }
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	common2 "nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/internal/nhctl/app"
 	"nocalhost/internal/nhctl/appmeta"
 	"nocalhost/internal/nhctl/common"
 	"nocalhost/internal/nhctl/const"
@@ -36,10 +37,22 @@ func init() {
 		&installFlags.GitRef, "git-ref", "r", "",
 		"resources git ref",
 	)
+	installCmd.Flags().StringVar(
+		&installFlags.GitSshKey, "git-ssh-key", "",
+		"private key of ssh pulling private repo, the token of https is read from env "+app.GitTokenEnv,
+	)
 	installCmd.Flags().StringSliceVar(
 		&installFlags.ResourcePath, "resource-path", []string{},
 		"resources path",
 	)
+	installCmd.Flags().StringVar(
+		&installFlags.KustomizeOverlay, "kustomize-overlay", "",
+		"overlay of kustomize relative to resources dir, e.g. overlays/dev, the namespace is injected",
+	)
+	installCmd.Flags().StringSliceVar(
+		&installFlags.KustomizeImages, "kustomize-image", []string{},
+		"override image of kustomization, e.g. nginx=registry.io/nginx:1.21, nginx:1.21 or nginx@sha256:...",
+	)
 	installCmd.Flags().StringVarP(
 		&installFlags.OuterConfig, "outer-config", "c", "",
 		"specify a config.yaml in local path",
@@ -78,7 +91,8 @@ func init() {
 	)
 	installCmd.Flags().StringVar(
 		&installFlags.HelmRepoUrl, "helm-repo-url", "",
-		"chart repository url where to locate the requested chart",
+		"chart repository url where to locate the requested chart, "+
+			"oci://registry/path for OCI registry with the credential read from env "+app.HelmPasswordEnv,
 	)
 	installCmd.Flags().StringVar(
 		&installFlags.HelmRepoVersion, "helm-repo-version", "",
@@ -88,6 +102,10 @@ func init() {
 		&installFlags.HelmChartName, "helm-chart-name", "",
 		"chart name",
 	)
+	installCmd.Flags().StringVar(
+		&installFlags.HelmChartDigest, "helm-chart-digest", "",
+		"digest pinned of the chart from OCI registry, e.g. sha256:...",
+	)
 	installCmd.Flags().StringVar(
 		&installFlags.LocalPath, "local-path", "",
 		"local path for application",
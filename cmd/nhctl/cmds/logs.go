@@ -0,0 +1,82 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+
+	"github.com/moby/term"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/podlogs"
+)
+
+var (
+	logsOptions = &podlogs.Options{}
+	logsApp     string
+	logsInclude string
+	logsExclude string
+	logsNoColor bool
+)
+
+func init() {
+	logsCmd.Flags().StringVarP(
+		&logsApp, "application", "a", _const.DefaultNocalhostApplication, "application of the workload",
+	)
+	logsCmd.Flags().StringVarP(
+		&common.ServiceType, "controller-type", "t", "deployment",
+		"kind of k8s controller,such as deployment,statefulSet",
+	)
+	logsCmd.Flags().StringVarP(&logsOptions.Container, "container", "c", "", "container to get logs, the first if empty")
+	logsCmd.Flags().BoolVar(&logsOptions.AllContainers, "all-containers", false, "get logs of all containers")
+	logsCmd.Flags().BoolVarP(&logsOptions.Follow, "follow", "f", false, "follow the logs, and the pods recreated")
+	logsCmd.Flags().DurationVar(&logsOptions.Since, "since", 0, "only the logs newer than it, such as 10m")
+	logsCmd.Flags().Int64Var(&logsOptions.Tail, "tail", -1, "lines of each container to show, all if negative")
+	logsCmd.Flags().BoolVar(&logsOptions.Timestamps, "timestamps", false, "show the timestamps of lines")
+	logsCmd.Flags().StringVarP(&logsInclude, "include", "i", "", "only the lines matching the regex are shown")
+	logsCmd.Flags().StringVarP(&logsExclude, "exclude", "e", "", "the lines matching the regex are not shown")
+	logsCmd.Flags().BoolVar(&logsNoColor, "no-color", false, "do not color the prefixes of pods")
+	rootCmd.AddCommand(logsCmd)
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [WORKLOAD]",
+	Short: "Print the logs of all pods of workload",
+	Long: `Print the logs of all pods of workload aggregated, the lines are prefixed by their pods and containers.
+The pods restarted or recreated are reconnected while following`,
+	Example: `
+  nhctl logs productpage -a bookinfo -f --all-containers --since 10m
+  nhctl logs ratings -a bookinfo -t statefulset -f --include 'ERROR|WARN'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if logsInclude != "" {
+			logsOptions.Include, err = regexp.Compile(logsInclude)
+			must(errors.Wrap(err, "Invalid --include"))
+		}
+		if logsExclude != "" {
+			logsOptions.Exclude, err = regexp.Compile(logsExclude)
+			must(errors.Wrap(err, "Invalid --exclude"))
+		}
+		logsOptions.Color = !logsNoColor && term.IsTerminal(os.Stdout.Fd())
+
+		_, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(logsApp, args[0], common.ServiceType)
+		must(err)
+		logsOptions.Pods = nocalhostSvc.GetPodList
+		logsOptions.PollInterval = 2 * time.Second
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		must(podlogs.Stream(ctx, nocalhostSvc.Client.GetPodClient(), logsOptions, os.Stdout))
+	},
+}
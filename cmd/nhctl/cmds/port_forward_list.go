@@ -30,6 +30,9 @@ type PortForwardItem struct {
 	DaemonServerPid int    `json:"daemonserverpid" yaml:"daemonserverpid"`
 	Updated         string `json:"updated" yaml:"updated"`
 	Reason          string `json:"reason" yaml:"reason"`
+	Reverse         bool   `json:"reverse,omitempty" yaml:"reverse,omitempty"`
+	Protocol        string `json:"protocol" yaml:"protocol"`
+	Reconnects      int    `json:"reconnects" yaml:"reconnects"`
 }
 
 var portForwardListCmd = &cobra.Command{
@@ -63,6 +66,9 @@ var portForwardListCmd = &cobra.Command{
 					DaemonServerPid: pf.DaemonServerPid,
 					Updated:         pf.Updated,
 					Reason:          pf.Reason,
+					Reverse:         pf.Reverse,
+					Protocol:        pf.GetProtocol(),
+					Reconnects:      pf.Reconnects,
 				})
 			}
 		}
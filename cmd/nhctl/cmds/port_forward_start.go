@@ -10,6 +10,7 @@ import (
 	"github.com/spf13/cobra"
 	"nocalhost/cmd/nhctl/cmds/common"
 	"nocalhost/internal/nhctl/app"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/daemon_client"
 	"nocalhost/internal/nhctl/utils"
 	"nocalhost/pkg/nhctl/log"
@@ -23,7 +24,11 @@ func init() {
 	)
 	portForwardStartCmd.Flags().StringSliceVarP(
 		&portForwardOptions.DevPort, "dev-port", "p", []string{},
-		"port-forward between pod and local, such 8080:8080 or :8080(random localPort)",
+		"port-forward between pod and local, such 8080:8080, :8080(random localPort) or 5353:53/udp(relayed by the sidecar of DevMode with --ssh)",
+	)
+	portForwardStartCmd.Flags().StringSliceVar(
+		&portForwardOptions.ReversePort, "reverse", []string{},
+		"reverse port-forward from pod to local by the sshd of sidecar(DevMode with --ssh), such 9000:9000(localPort:remotePort)",
 	)
 	//portForwardStartCmd.Flags().BoolVarP(&portForwardOptions.RunAsDaemon,
 	// "daemon", "m", true, "if port-forward run as daemon")
@@ -81,7 +86,13 @@ var portForwardStartCmd = &cobra.Command{
 		}
 
 		var localPorts, remotePorts []int
-		for _, port := range portForwardOptions.DevPort {
+		var protocols []string
+		for _, devPort := range portForwardOptions.DevPort {
+			port, protocol, err := utils.SplitPortProtocol(devPort)
+			if err != nil {
+				log.WarnE(err, "")
+				continue
+			}
 			localPort, remotePort, err := utils.GetPortForwardForString(port)
 			if err != nil {
 				log.WarnE(err, "")
@@ -89,15 +100,28 @@ var portForwardStartCmd = &cobra.Command{
 			}
 			localPorts = append(localPorts, localPort)
 			remotePorts = append(remotePorts, remotePort)
+			protocols = append(protocols, protocol)
 		}
 
 		for index, localPort := range localPorts {
-			if portForwardOptions.Follow {
+			if protocols[index] == _const.UDPProtocol {
+				must(nocalhostSvc.UDPPortForward(podName, localPort, remotePorts[index]))
+			} else if portForwardOptions.Follow {
 				must(nocalhostApp.PortForwardFollow(podName, localPort, remotePorts[index], nil))
 			} else {
 				must(nocalhostSvc.PortForward(podName, localPort, remotePorts[index], ""))
 			}
 		}
+
+		for _, port := range portForwardOptions.ReversePort {
+			localPort, remotePort, err := utils.GetPortForwardForString(port)
+			if err != nil {
+				log.WarnE(err, "")
+				continue
+			}
+			must(nocalhostSvc.ReversePortForward(podName, localPort, remotePort))
+			log.Infof("Reverse port-forward %d <- %d started", localPort, remotePort)
+		}
 		// notify daemon to invalid cache before return
 		if client, err := daemon_client.GetDaemonClient(false); err == nil {
 			_ = client.SendFlushDirMappingCacheCommand(
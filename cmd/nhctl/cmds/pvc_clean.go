@@ -14,10 +14,18 @@ import (
 	"nocalhost/internal/nhctl/controller"
 	"nocalhost/pkg/nhctl/clientgoutils"
 	"path/filepath"
+	"time"
 
 	"nocalhost/pkg/nhctl/log"
 )
 
+type PVCCleanFlags struct {
+	UnusedFor time.Duration
+	Force     bool
+}
+
+var pvcCleanFlags = PVCCleanFlags{}
+
 func init() {
 	pvcCleanCmd.Flags().StringVar(&pvcFlags.App, "app", "", "Clean up PVCs of specified application")
 	pvcCleanCmd.Flags().StringVar(&pvcFlags.Svc, "controller", "", "Clean up PVCs of specified service")
@@ -26,71 +34,71 @@ func init() {
 		&common.ServiceType, "controller-type", "t", "deployment",
 		"kind of k8s controller,such as deployment,statefulSet",
 	)
+	pvcCleanCmd.Flags().DurationVar(
+		&pvcCleanFlags.UnusedFor, "unused-for", 0,
+		"Only clean up PVCs not used by dev start for the duration, such as 720h",
+	)
+	pvcCleanCmd.Flags().BoolVar(&pvcCleanFlags.Force, "force", false, "Clean up PVCs mounted by pods as well")
 	pvcCmd.AddCommand(pvcCleanCmd)
 }
 
 var pvcCleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Clean up PersistVolumeClaims",
-	Long:  `Clean up PersistVolumeClaims`,
+	Long: `Clean up PersistVolumeClaims, such as the caches of dev containers persisted across dev sessions.
+The PVCs mounted by pods are skipped unless --force`,
+	Example: `
+  # Clean up the PVCs of service details not used in 30 days
+  nhctl pvc clean --app bookinfo --controller details --unused-for 720h`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		// Clean up specified pvc
+		var (
+			cli  *clientgoutils.ClientGoUtils
+			pvcs []v1.PersistentVolumeClaim
+			err  error
+		)
+
 		if pvcFlags.Name != "" {
+			// Clean up specified pvc
 			if abs, err := filepath.Abs(common.KubeConfig); err == nil {
 				common.KubeConfig = abs
 			}
-			cli, err := clientgoutils.NewClientGoUtils(common.KubeConfig, common.NameSpace)
+			cli, err = clientgoutils.NewClientGoUtils(common.KubeConfig, common.NameSpace)
 			must(err)
-			mustI(cli.DeletePVC(pvcFlags.Name), "Failed to clean up pvc: "+pvcFlags.Name)
-			log.Infof("Persistent volume %s has been cleaned up", pvcFlags.Name)
-			return
-		}
-
-		if pvcFlags.App == "" {
+			pvc, err := cli.GetPvcByName(pvcFlags.Name)
+			mustI(err, "Failed to clean up pvc: "+pvcFlags.Name)
+			pvcs = []v1.PersistentVolumeClaim{*pvc}
+		} else if pvcFlags.App == "" {
 			// Clean up all pvcs in namespace
-			cli, err := clientgoutils.NewClientGoUtils(common.KubeConfig, common.NameSpace)
-			must(err)
-			pvcList, err := cli.ListPvcs()
+			cli, err = clientgoutils.NewClientGoUtils(common.KubeConfig, common.NameSpace)
 			must(err)
-			if len(pvcList) == 0 {
-				log.Info("No pvc found")
-			}
-			for _, pvc := range pvcList {
-				must(cli.DeletePVC(pvc.Name))
-				log.Infof("Persistent volume %s has been cleaned up", pvc.Name)
-			}
-			return
-		}
-
-		var (
-			pvcs []v1.PersistentVolumeClaim
-			err  error
-		)
-
-		var nocalhostApp *app.Application
-		var nocalhostSvc *controller.Controller
-		// Clean up PVCs of specified service
-		if pvcFlags.Svc != "" {
+			pvcs, err = cli.ListPvcs()
+		} else if pvcFlags.Svc != "" {
+			// Clean up PVCs of specified service
+			var nocalhostApp *app.Application
+			var nocalhostSvc *controller.Controller
 			nocalhostApp, nocalhostSvc, err = common.InitAppAndCheckIfSvcExist(pvcFlags.App, pvcFlags.Svc, common.ServiceType)
 			must(err)
+			cli = nocalhostApp.GetClient()
 			pvcs, err = nocalhostSvc.GetPVCsBySvc()
 		} else {
 			// Clean up all pvcs in application
+			var nocalhostApp *app.Application
 			nocalhostApp, err = common.InitApp(pvcFlags.App)
 			must(err)
+			cli = nocalhostApp.GetClient()
 			pvcs, err = nocalhostApp.GetAllPVCs()
 		}
-
 		must(err)
 
+		pvcs, err = pvcsToClean(cli, pvcs)
+		must(err)
 		if len(pvcs) == 0 {
 			log.Info("No Persistent volume needs to be cleaned up")
 		}
 
-		// todo check if pvc still is used by some pods
 		for _, pvc := range pvcs {
-			err = nocalhostApp.GetClient().DeletePVC(pvc.Name)
+			err = cli.DeletePVC(pvc.Name)
 			if err != nil {
 				log.WarnE(err, fmt.Sprintf("error occurs while deleting persistent volume %s", pvc.Name))
 			} else {
@@ -99,3 +107,33 @@ var pvcCleanCmd = &cobra.Command{
 		}
 	},
 }
+
+// pvcsToClean the pvcs not used for --unused-for, the ones mounted by pods are
+// skipped unless --force
+func pvcsToClean(cli *clientgoutils.ClientGoUtils, pvcs []v1.PersistentVolumeClaim) (
+	[]v1.PersistentVolumeClaim, error) {
+	inUse := map[string]bool{}
+	if !pvcCleanFlags.Force {
+		pods, err := cli.ListPods()
+		if err != nil {
+			return nil, err
+		}
+		inUse = clientgoutils.PvcsInUse(pods)
+	}
+	result := make([]v1.PersistentVolumeClaim, 0, len(pvcs))
+	for _, pvc := range pvcs {
+		if inUse[pvc.Name] {
+			log.Infof("Persistent volume %s is skipped as it is in use, clean it up by --force", pvc.Name)
+			continue
+		}
+		if lastUsed := clientgoutils.PvcLastUsed(&pvc); time.Since(lastUsed) < pvcCleanFlags.UnusedFor {
+			log.Infof(
+				"Persistent volume %s is skipped as it is used %s ago",
+				pvc.Name, time.Since(lastUsed).Round(time.Minute),
+			)
+			continue
+		}
+		result = append(result, pvc)
+	}
+	return result, nil
+}
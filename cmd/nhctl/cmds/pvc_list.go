@@ -13,6 +13,7 @@ import (
 	"nocalhost/cmd/nhctl/cmds/common"
 	"nocalhost/internal/nhctl/const"
 	"nocalhost/pkg/nhctl/clientgoutils"
+	"time"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
@@ -84,6 +85,7 @@ type pvcObject struct {
 	StorageClass string `json:"storage_class" yaml:"storageClass"`
 	Status       string `json:"status" yaml:"status"`
 	MountPath    string `json:"mount_path" yaml:"mountPath"`
+	LastUsed     string `json:"last_used" yaml:"lastUsed"`
 }
 
 func makePVCObjectList(pvcList []v1.PersistentVolumeClaim) []*pvcObject {
@@ -100,6 +102,7 @@ func makePVCObjectList(pvcList []v1.PersistentVolumeClaim) []*pvcObject {
 			Capacity:    quantity.String(),
 			Status:      string(pvc.Status.Phase),
 			MountPath:   annotations[_const.PersistentVolumeDirLabel],
+			LastUsed:    clientgoutils.PvcLastUsed(&pvc).Format(time.RFC3339),
 		}
 		if pvc.Spec.StorageClassName != nil {
 			pY.StorageClass = *pvc.Spec.StorageClassName
@@ -0,0 +1,54 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/pkg/nhctl/log"
+)
+
+var rollbackRevision int
+
+func init() {
+	rollbackCmd.Flags().IntVar(&rollbackRevision, "revision", 0,
+		"revision to roll back to, list the revisions by 'nhctl get application NAME'")
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback [NAME]",
+	Short: "rollback k8s application to a previous revision",
+	Long:  `rollback k8s application to a previous revision`,
+	Example: `
+	# List the revisions of application
+	nhctl get application bookinfo -n namespaceName --kubeconfig=kubeconfigpath
+
+	# Roll back to revision 2
+	nhctl rollback bookinfo --revision 2 -n namespaceName --kubeconfig=kubeconfigpath
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
+		}
+		if rollbackRevision <= 0 {
+			return errors.New("--revision must be specified")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		nocalhostApp, err := common.InitApp(args[0])
+		must(err)
+
+		if nocalhostApp.IsAnyServiceInDevMode() {
+			log.Fatal("Please make sure all services have exited DevMode")
+		}
+
+		must(nocalhostApp.Rollback(rollbackRevision))
+		log.Infof("Application %s has been rolled back to revision %d", args[0], rollbackRevision)
+	},
+}
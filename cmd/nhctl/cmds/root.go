@@ -16,6 +16,7 @@ import (
 	"nocalhost/cmd/nhctl/cmds/install"
 	"nocalhost/internal/nhctl/common/base"
 	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/daemon_client"
 	"nocalhost/internal/nhctl/nocalhost"
 	"nocalhost/internal/nhctl/nocalhost_path"
 	"nocalhost/internal/nhctl/vpn/util"
@@ -32,6 +33,8 @@ var (
 
 	// pre check the nocalhost commands permissions
 	authCheck bool
+
+	noAutoUpgrade bool
 )
 
 func init() {
@@ -52,6 +55,10 @@ func init() {
 		&common.KubeConfig, "kubeconfig", "",
 		"the path of the kubeconfig file",
 	)
+	rootCmd.PersistentFlags().BoolVar(
+		&noAutoUpgrade, "no-auto-upgrade", noAutoUpgrade,
+		"do not restart the daemon of other version by this nhctl",
+	)
 
 	rootCmd.AddCommand(install.UninstallCmd)
 
@@ -77,6 +84,7 @@ var rootCmd = &cobra.Command{
 		log.AddField("COMMIT", GitCommit)
 		log.AddField("BRANCH", Branch)
 		log.AddField("ARGS", strings.Join(os.Args, " "))
+		daemon_client.AutoUpgrade = !noAutoUpgrade
 
 		var esUrl string
 		bys, err := ioutil.ReadFile(filepath.Join(nocalhost_path.GetNhctlHomeDir(), "config"))
@@ -144,6 +152,7 @@ func Execute() {
 		rootCmd.SetArgs(args)
 	}
 
+	common.RegisterWorkloadCompletions(rootCmd)
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -1,20 +1,122 @@
 /*
 * Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
 * This source code is licensed under the Apache License Version 2.0.
-*/
+ */
 
 package cmds
 
 import (
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	v1 "k8s.io/api/core/v1"
+	"nocalhost/cmd/nhctl/cmds/common"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/controller"
+	"nocalhost/internal/nhctl/dev_ssh"
+	"nocalhost/pkg/nhctl/log"
+)
+
+var (
+	sshAddress        string
+	sshPort           int
+	sshAuthorizedKeys string
 )
 
 func init() {
+	sshCmd.Flags().StringVarP(
+		&common.WorkloadName, "deployment", "d", "",
+		"k8s deployment which your developing service exists",
+	)
+	sshCmd.Flags().StringVarP(
+		&common.ServiceType, "controller-type", "t", "deployment",
+		"kind of k8s controller,such as deployment,statefulSet",
+	)
+	sshCmd.Flags().StringVarP(&container, "container", "c", "", "container to ssh into")
+	sshCmd.Flags().StringVar(&sshAddress, "address", "127.0.0.1", "address the ssh server listens on")
+	sshCmd.Flags().IntVar(&sshPort, "port", 2222, "port the ssh server listens on")
+	sshCmd.Flags().StringVar(
+		&sshAuthorizedKeys, "authorized-keys", "",
+		"file of the authorized keys, the public keys in ~/.ssh and ssh agent are authorized by default",
+	)
 	rootCmd.AddCommand(sshCmd)
 }
 
 var sshCmd = &cobra.Command{
-	Use:   "ssh",
+	Use:   "ssh [NAME]",
 	Short: "ssh client",
-	Long:  `ssh client`,
+	Long: `ssh client, serve a ssh endpoint of the dev container for the tools
+remoting by ssh, such as JetBrains Gateway, rsync and scp`,
+	Example: `  nhctl ssh bookinfo -d details
+  ssh -A -p 2222 root@127.0.0.1`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		applicationName := args[0]
+		_, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(applicationName, common.WorkloadName, common.ServiceType)
+		must(err)
+		if !nocalhostSvc.IsInDevMode() {
+			log.Fatalf("%s is not in DevMode", common.WorkloadName)
+		}
+
+		devPodName, err := nocalhostSvc.GetDevModePodName()
+		must(err)
+		devPod, err := nocalhostSvc.Client.GetPod(devPodName)
+		must(err)
+		target := sshTarget(nocalhostSvc, devPod)
+		if !target.Agent {
+			log.Warn("Sshd is not running in the sidecar, the agent is not forwarded, start DevMode with --ssh to enable it")
+		}
+
+		hostKey, err := dev_ssh.LoadHostKey(dev_ssh.HostKeyFile())
+		must(err)
+		keys, err := dev_ssh.LoadAuthorizedKeys(sshAuthorizedKeys)
+		must(err)
+
+		listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", sshAddress, sshPort))
+		must(errors.WithStack(err))
+		alias := fmt.Sprintf("%s.%s.nocalhost", common.WorkloadName, applicationName)
+		log.Infof("Ssh server of %s/%s is listening on %s", devPodName, target.Container, listener.Addr())
+		log.Infof("Connect by: ssh -A -p %d root@%s", sshPort, sshAddress)
+		log.Infof(
+			"Or add to ~/.ssh/config:\n\nHost %s\n  HostName %s\n  Port %d\n  User root\n  ForwardAgent yes\n",
+			alias, sshAddress, sshPort,
+		)
+		must(dev_ssh.NewServer(target, hostKey, keys).Serve(listener))
+	},
+}
+
+// sshTarget the container specified, or the dev container
+func sshTarget(nocalhostSvc *controller.Controller, pod *v1.Pod) dev_ssh.Target {
+	target := dev_ssh.Target{Client: nocalhostSvc.Client, Pod: pod.Name}
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name == _const.NocalhostSSHVolumeName {
+			target.Agent = true
+		}
+	}
+
+	target.Container = _const.NocalhostDefaultDevContainerName
+	if pf := nocalhostSvc.Config(); pf != nil {
+		if cfg := pf.GetContainerDevConfigOrDefault(container); cfg != nil && cfg.DevContainerName != "" {
+			target.Container = cfg.DevContainerName
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		// the extra containers are developed by their own names
+		if container != "" && c.Name == container {
+			target.Container = c.Name
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == target.Container {
+			target.WorkDir = c.WorkingDir
+		}
+	}
+	return target
 }
@@ -0,0 +1,71 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/pkg/nhctl/log"
+)
+
+func init() {
+	for _, cmd := range []*cobra.Command{syncPauseCmd, syncResumeCmd} {
+		cmd.Flags().StringVarP(
+			&common.WorkloadName, "deployment", "d", "",
+			"k8s deployment which your developing service exists",
+		)
+		cmd.Flags().StringVarP(
+			&common.ServiceType, "controller-type", "t", "deployment",
+			"kind of k8s controller,such as deployment,statefulSet",
+		)
+		fileSyncCmd.AddCommand(cmd)
+	}
+}
+
+var syncPauseCmd = &cobra.Command{
+	Use:   "pause [NAME]",
+	Short: "Pause file sync without ending DevMode",
+	Long:  `Pause file sync without ending DevMode, such as while running a large local build`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		setSyncPaused(args[0], true)
+	},
+}
+
+var syncResumeCmd = &cobra.Command{
+	Use:   "resume [NAME]",
+	Short: "Resume file sync paused",
+	Long:  `Resume file sync paused by nhctl sync pause`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		setSyncPaused(args[0], false)
+	},
+}
+
+func setSyncPaused(applicationName string, paused bool) {
+	_, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(applicationName, common.WorkloadName, common.ServiceType)
+	must(err)
+	if !nocalhostSvc.IsInDevMode() {
+		log.Fatalf("%s is not in DevMode", common.WorkloadName)
+	}
+	must(nocalhostSvc.SetSyncPaused(paused))
+	if paused {
+		log.Infof("File sync of %s paused", common.WorkloadName)
+	} else {
+		log.Infof("File sync of %s resumed", common.WorkloadName)
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"nocalhost/cmd/nhctl/cmds/common"
 	"nocalhost/internal/nhctl/app"
 	"nocalhost/internal/nhctl/common/base"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/controller"
+	"nocalhost/internal/nhctl/syncthing"
 	"nocalhost/internal/nhctl/syncthing/network/req"
 	"nocalhost/pkg/nhctl/log"
 	"time"
@@ -47,6 +50,18 @@ func init() {
 		&syncStatusOps.Timeout, "timeout", 120,
 		"wait for sync process finished timeout, default is 120 seconds, unit is seconds ",
 	)
+	syncStatusCmd.Flags().BoolVar(
+		&syncStatusOps.Conflicts, "conflicts", false,
+		"list the files modified by both the local and the remote",
+	)
+	syncStatusCmd.Flags().BoolVar(
+		&syncStatusOps.Resolve, "resolve", false,
+		"resolve the conflicts by the conflict strategy",
+	)
+	syncStatusCmd.Flags().StringVar(
+		&syncStatusOps.Strategy, "strategy", "",
+		"conflict strategy to resolve by, such as preferLocal, preferRemote or keepBoth, default is the one configured",
+	)
 	rootCmd.AddCommand(syncStatusCmd)
 }
 
@@ -106,6 +121,19 @@ func SyncStatus(opt *app.SyncStatusOptions, ns, app, svc, svcType, kubeconfig st
 	//	return req.NotSyncthingProcessFound
 	//}
 
+	if opt != nil && (opt.Conflicts || opt.Resolve) {
+		syncConflicts(nhSvc, opt)
+		return nil
+	}
+
+	if svcProfile, _ := nhSvc.GetProfile(); svcProfile != nil && svcProfile.SyncPaused {
+		return req.SyncPausedTemplate
+	}
+
+	if fileSync := nhSvc.NewFileSync(); fileSync.Engine() != _const.SyncthingSyncEngine {
+		return fileSyncStatus(fileSync, opt)
+	}
+
 	client := nhSvc.NewSyncthingHttpClient(2)
 
 	if opt != nil {
@@ -129,6 +157,74 @@ func SyncStatus(opt *app.SyncStatusOptions, ns, app, svc, svcType, kubeconfig st
 	return client.GetSyncthingStatus()
 }
 
+type syncConflictsView struct {
+	Strategy  string                `json:"strategy"`
+	Resolved  int                   `json:"resolved,omitempty"`
+	Conflicts []*syncthing.Conflict `json:"conflicts"`
+}
+
+// syncConflicts display the conflicts not resolved, they are resolved by the
+// strategy first if resolve
+func syncConflicts(nhSvc *controller.Controller, opt *app.SyncStatusOptions) {
+	fileSync := nhSvc.NewFileSync()
+	view := syncConflictsView{Strategy: opt.Strategy}
+	if view.Strategy == "" {
+		view.Strategy = nhSvc.GetConflictStrategy("")
+	}
+
+	if opt.Resolve {
+		resolved, err := fileSync.Resolve(view.Strategy)
+		must(err)
+		view.Resolved = resolved
+	}
+	conflicts, err := fileSync.Conflicts()
+	must(err)
+	view.Conflicts = conflicts
+	display(view)
+}
+
+// fileSyncStatus the status of the sync engines other than syncthing, which
+// are reported by FileSync only
+func fileSyncStatus(fileSync controller.FileSync, opt *app.SyncStatusOptions) *req.SyncthingStatus {
+	if opt != nil {
+		if opt.Override {
+			must(fileSync.Override())
+			display("Succeed")
+			return nil
+		}
+
+		if opt.WaitForSync {
+			ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*time.Duration(opt.Timeout))
+			defer cancelFunc()
+			for {
+				if status := fileSync.Status(); status.Status == req.Idle {
+					display(req.SyncthingStatus{Status: req.Idle, Msg: "sync finished"})
+					return nil
+				}
+				select {
+				case <-ctx.Done():
+					display(req.SyncthingStatus{Status: req.Error, Msg: "wait for sync finished timeout"})
+					return nil
+				case <-time.After(time.Second):
+				}
+			}
+		}
+
+		if opt.Watch {
+			var last req.SyncthingStatus
+			for {
+				if status := fileSync.Status(); *status != last {
+					displayLn(status)
+					last = *status
+				}
+				time.Sleep(time.Second * 2)
+			}
+		}
+	}
+
+	return fileSync.Status()
+}
+
 func display(v interface{}) {
 	marshal, _ := json.Marshal(v)
 	fmt.Printf("%s", string(marshal))
@@ -0,0 +1,68 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cmds
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/pkg/nhctl/log"
+)
+
+var triggerContainer string
+
+func init() {
+	syncTriggerCmd.Flags().StringVarP(
+		&common.WorkloadName, "deployment", "d", "",
+		"k8s deployment which your developing service exists",
+	)
+	syncTriggerCmd.Flags().StringVarP(
+		&common.ServiceType, "controller-type", "t", "deployment",
+		"kind of k8s controller,such as deployment,statefulSet",
+	)
+	syncTriggerCmd.Flags().StringVarP(
+		&triggerContainer, "container", "c", "",
+		"container whose dev config defines the triggers",
+	)
+	fileSyncCmd.AddCommand(syncTriggerCmd)
+}
+
+var syncTriggerCmd = &cobra.Command{
+	Use:   "trigger [NAME]",
+	Short: "Run the triggers of dev config after the files synced",
+	Long: `Run the command of trigger in dev container after the files matched synced,
+the output is streamed to the terminal until interrupted`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.Errorf("%q requires at least 1 argument\n", cmd.CommandPath())
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		_, nocalhostSvc, err := common.InitAppAndCheckIfSvcExist(args[0], common.WorkloadName, common.ServiceType)
+		must(err)
+		if !nocalhostSvc.IsInDevMode() {
+			log.Fatalf("%s is not in DevMode", common.WorkloadName)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-signals
+			cancel()
+		}()
+
+		log.Infof("Watching the triggers of %s, press Ctrl+C to stop", common.WorkloadName)
+		must(nocalhostSvc.RunSyncTriggers(ctx, triggerContainer, os.Stdout, os.Stderr))
+	},
+}
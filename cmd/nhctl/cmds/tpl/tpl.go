@@ -155,6 +155,18 @@ containers:
 			# default value: 10Gi
 			# optional
 			#   capacity: 100Gi
+		# Caches persisted across dev sessions like persistentVolumeDirs, by the names of
+		# tools: maven, gradle, go, npm, yarn, pip and cargo, or by the dirs relative to workDir
+		# type: string[]
+		# default value: []
+		# optional
+		# caches: ["maven", "node_modules"]
+		# Keep or strip the sidecars injected by mesh, such as istio-proxy and its init containers,
+		# the dev pod stripped leaves the mesh: keep, strip
+		# type: string
+		# default value: keep
+		# optional
+		# sidecars: strip
 		command: 
 			# Run command of the service
 			# type: string[]
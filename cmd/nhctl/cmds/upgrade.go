@@ -6,22 +6,45 @@
 package cmds
 
 import (
+	"bufio"
 	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"nocalhost/cmd/nhctl/cmds/common"
+	"nocalhost/internal/nhctl/app"
 	"nocalhost/internal/nhctl/controller"
+	"nocalhost/internal/nhctl/diff"
 	"nocalhost/internal/nhctl/profile"
+	"nocalhost/internal/nhctl/ui/picker"
 	"nocalhost/internal/nhctl/utils"
 	"nocalhost/pkg/nhctl/log"
+	"os"
+	"strings"
 	"time"
 )
 
+// upgradeChangedExitCode the exit code of --dry-run if there are changes
+const upgradeChangedExitCode = 2
+
+var (
+	upgradeDryRun bool
+	upgradeYes    bool
+)
+
 func init() {
 
 	upgradeCmd.Flags().StringVarP(&installFlags.GitUrl, "git-url", "u", "", "resources git url")
 	upgradeCmd.Flags().StringVarP(&installFlags.GitRef, "git-ref", "r", "", "resources git ref")
+	upgradeCmd.Flags().StringVar(
+		&installFlags.GitSshKey, "git-ssh-key", "",
+		"private key of ssh pulling private repo, the token of https is read from env "+app.GitTokenEnv,
+	)
 	upgradeCmd.Flags().StringSliceVar(&installFlags.ResourcePath, "resource-path", []string{}, "resources path")
+	upgradeCmd.Flags().StringVar(&installFlags.KustomizeOverlay, "kustomize-overlay", "",
+		"overlay of kustomize relative to resources dir, e.g. overlays/dev")
+	upgradeCmd.Flags().StringSliceVar(&installFlags.KustomizeImages, "kustomize-image", []string{},
+		"override image of kustomization, e.g. nginx=registry.io/nginx:1.21")
 	upgradeCmd.Flags().StringVar(&installFlags.Config, "config", "", "specify a config relative to .nocalhost dir")
 	upgradeCmd.Flags().StringVarP(&installFlags.OuterConfig, "outer-config", "c", "",
 		"specify a config.yaml in local path")
@@ -35,7 +58,16 @@ func init() {
 		"chart repository url where to locate the requested chart")
 	upgradeCmd.Flags().StringVar(&installFlags.HelmRepoVersion, "helm-repo-version", "", "chart repository version")
 	upgradeCmd.Flags().StringVar(&installFlags.HelmChartName, "helm-chart-name", "", "chart name")
+	upgradeCmd.Flags().StringVar(&installFlags.HelmChartDigest, "helm-chart-digest", "",
+		"digest pinned of the chart from OCI registry, e.g. sha256:...")
 	upgradeCmd.Flags().StringVar(&installFlags.LocalPath, "local-path", "", "local path for application")
+	upgradeCmd.Flags().BoolVar(
+		&upgradeDryRun, "dry-run", false,
+		fmt.Sprintf(
+			"show the diff of upgrading without applying it, exit with %d if there are changes", upgradeChangedExitCode,
+		),
+	)
+	upgradeCmd.Flags().BoolVarP(&upgradeYes, "yes", "y", false, "upgrade with the changes without confirmation")
 	rootCmd.AddCommand(upgradeCmd)
 }
 
@@ -55,10 +87,35 @@ var upgradeCmd = &cobra.Command{
 		must(err)
 
 		// Check if there are services in developing
-		if nocalhostApp.IsAnyServiceInDevMode() {
+		if !upgradeDryRun && nocalhostApp.IsAnyServiceInDevMode() {
 			log.Fatal("Please make sure all services have exited DevMode")
 		}
 
+		// todo: Validate flags
+		// Prepare for upgrading
+		must(nocalhostApp.PrepareForUpgrade(installFlags))
+
+		changes, err := nocalhostApp.UpgradeDiff(installFlags)
+		must(err)
+		if changes == "" {
+			log.Info("No changes of the resources to upgrade")
+		} else {
+			fmt.Print(diff.Colorize(changes))
+		}
+		if upgradeDryRun {
+			utils.Should(nocalhostApp.CleanUpTmpResources())
+			if changes != "" {
+				os.Exit(upgradeChangedExitCode)
+			}
+			return
+		}
+		if changes != "" && !upgradeYes {
+			if err = confirmUpgrade(); err != nil {
+				utils.Should(nocalhostApp.CleanUpTmpResources())
+				log.Fatal(err.Error())
+			}
+		}
+
 		// Stop Port-forward
 		appProfile, err := nocalhostApp.GetProfile()
 		must(err)
@@ -81,10 +138,6 @@ var upgradeCmd = &cobra.Command{
 			}
 		}
 
-		// todo: Validate flags
-		// Prepare for upgrading
-		must(nocalhostApp.PrepareForUpgrade(installFlags))
-
 		must(nocalhostApp.Upgrade(installFlags))
 
 		// Restart port forward
@@ -100,8 +153,23 @@ var upgradeCmd = &cobra.Command{
 					continue
 				}
 				log.Infof("Starting pf %d:%d for %s", pf.LocalPort, pf.RemotePort, svcName)
-				utils.Should(nhSvc.PortForward(podName, pf.LocalPort, pf.RemotePort, pf.Role))
+				utils.Should(nhSvc.RestorePortForward(podName, pf))
 			}
 		}
 	},
 }
+
+// confirmUpgrade asks for the confirmation of the changes shown, which is
+// required unless --yes in non-interactive mode
+func confirmUpgrade() error {
+	if !picker.IsInteractive() {
+		return errors.New("Upgrading requires confirmation of the changes, use --yes to upgrade without it")
+	}
+	fmt.Print("Upgrade the application with the changes above? [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New("Upgrading is canceled")
+}
@@ -35,35 +35,45 @@ var connectCmd = &cobra.Command{
 	Short: "connect",
 	Long:  `connect`,
 	PreRun: func(*cobra.Command, []string) {
-		util.InitLogger(util.Debug)
-		if util.IsWindows() {
-			_ = driver.InstallWireGuardTunDriver()
-		}
+		prepareVPN()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		// if not sudo and sudo daemon is not running, needs sudo permission
-		if !util.IsAdmin() && !util.IsSudoDaemonServing() {
-			if err := util.RunWithElevated(); err != nil {
-				log.Warn(err)
-				return
-			}
-		}
-		_, err := daemon_client.GetDaemonClient(true)
-		if err != nil {
-			log.Warn(err)
-			return
-		}
-		client, err := daemon_client.GetDaemonClient(false)
-		if err != nil {
+		vpnConnect(workloads)
+	},
+}
+
+func prepareVPN() {
+	util.InitLogger(util.Debug)
+	if util.IsWindows() {
+		_ = driver.InstallWireGuardTunDriver()
+	}
+}
+
+// vpnConnect connect to the cluster by the TUN device of sudo daemon, and
+// reverse the workloads if specified
+func vpnConnect(workloads string) {
+	// if not sudo and sudo daemon is not running, needs sudo permission
+	if !util.IsAdmin() && !util.IsSudoDaemonServing() {
+		if err := util.RunWithElevated(); err != nil {
 			log.Warn(err)
 			return
 		}
-		must(common.Prepare())
-		err = client.SendVPNOperateCommand(common.KubeConfig, common.NameSpace, command.Connect, workloads, f)
-		if err != nil {
-			log.Warn(err)
-		}
-	},
+	}
+	_, err := daemon_client.GetDaemonClient(true)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+	client, err := daemon_client.GetDaemonClient(false)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+	must(common.Prepare())
+	err = client.SendVPNOperateCommand(common.KubeConfig, common.NameSpace, command.Connect, workloads, f)
+	if err != nil {
+		log.Warn(err)
+	}
 }
 
 var f = func(reader io.Reader) error {
@@ -0,0 +1,48 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+// nocalhost-agent dials out to nocalhost-api from the private cluster,
+// so that nocalhost-api can access the cluster without inbound connectivity
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"nocalhost/pkg/nocalhost-api/pkg/agent"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+var GIT_COMMIT_SHA string
+
+func main() {
+	a := &agent.Agent{}
+	flag.StringVar(&a.Server, "server", "", "The url of nocalhost-api, such as https://nocalhost.example.com")
+	flag.StringVar(&a.Token, "token", os.Getenv("NOCALHOST_AGENT_TOKEN"), "The registration token of agent")
+	flag.BoolVar(&a.Insecure, "insecure", false, "Skip verifying the certificate of nocalhost-api")
+	flag.StringVar(&a.ApiServer, "api-server", "", "The address of api server, default to the in-cluster one")
+	flag.Parse()
+
+	_ = log.NewLogger(&log.Config{Writers: "stdout", LoggerLevel: "info"}, log.InstanceZapLogger)
+	log.Infof("Current Version :[%s]", GIT_COMMIT_SHA)
+
+	if a.Server == "" || a.Token == "" {
+		log.Fatal("--server and --token are required")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signalChan
+		log.Info("Got OS shutdown signal, shutting down agent...")
+		cancel()
+	}()
+
+	_ = a.Run(ctx)
+}
@@ -0,0 +1,78 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/backup"
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+// the passphrase encrypting the secrets in the archive, read from the
+// environment to keep it out of the process list
+const backupPassphraseEnv = "NOCALHOST_BACKUP_PASSPHRASE"
+
+const backupUsage = `usage: NOCALHOST_BACKUP_PASSPHRASE=... nocalhost-api -c config.yaml <command> <file>
+
+  backup <file>    export users, clusters, dev spaces and applications to the archive,
+                   the kubeconfigs are encrypted by the passphrase
+  restore <file>   import the archive into a fresh instance, the schema is migrated
+                   to the version of archive before, and to the latest after`
+
+// backupOrRestore the commands of backup and restore
+func backupOrRestore(command string, args []string) error {
+	if len(args) != 1 {
+		return errors.New(backupUsage)
+	}
+	passphrase := os.Getenv(backupPassphraseEnv)
+	if passphrase == "" {
+		return errors.New(fmt.Sprintf("%s is required\n%s", backupPassphraseEnv, backupUsage))
+	}
+
+	db := model.Init()
+	defer db.Close()
+
+	if command == "backup" {
+		f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return errors.Wrap(err, "create archive")
+		}
+		archive, err := backup.Backup(db, f, passphrase)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(args[0])
+			return err
+		}
+		for _, rows := range archive.Tables {
+			fmt.Printf("backed up %d rows of %s\n", len(rows.Rows), rows.Name)
+		}
+		fmt.Printf("archive %s of schema %d is written\n", args[0], archive.Schema)
+		return nil
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return errors.Wrap(err, "open archive")
+	}
+	defer f.Close()
+	archive, err := backup.Read(f)
+	if err != nil {
+		return err
+	}
+	if err := backup.Restore(db, archive, passphrase); err != nil {
+		return err
+	}
+	for _, rows := range archive.Tables {
+		fmt.Printf("restored %d rows of %s\n", len(rows.Rows), rows.Name)
+	}
+	return nil
+}
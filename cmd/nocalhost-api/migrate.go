@@ -0,0 +1,81 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/migration"
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+const migrateUsage = `usage: nocalhost-api -c config.yaml migrate <command>
+
+  up [version]     apply the migrations pending up to version, the latest by default
+  down <version>   roll back the migrations applied after version
+  status           list the migrations and whether they are applied`
+
+// migrate the commands of the database migrations
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	var to uint64
+	if len(args) > 1 {
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid version %s\n%s", args[1], migrateUsage))
+		}
+		to = version
+	}
+
+	db := model.Init()
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		done, err := migration.Up(db, to)
+		for _, m := range done {
+			fmt.Printf("applied %s\n", m)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("no migrations pending")
+		}
+		return err
+	case "down":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		done, err := migration.Down(db, to)
+		for _, m := range done {
+			fmt.Printf("rolled back %s\n", m)
+		}
+		return err
+	case "status":
+		statuses, err := migration.List(db)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.Applied {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
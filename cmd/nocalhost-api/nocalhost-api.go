@@ -7,20 +7,35 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"nocalhost/internal/nocalhost-api/global"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/cluster"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/cluster_user"
 	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/leader"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	v2 "nocalhost/pkg/nocalhost-api/app/api/v2"
 	routers "nocalhost/pkg/nocalhost-api/app/router"
+	"nocalhost/pkg/nocalhost-api/app/rpc"
 	"nocalhost/pkg/nocalhost-api/conf"
 	"nocalhost/pkg/nocalhost-api/napp"
+	"nocalhost/pkg/nocalhost-api/pkg/health"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/notify"
+	"nocalhost/pkg/nocalhost-api/pkg/token"
+	"nocalhost/pkg/nocalhost-api/pkg/trace/otlp"
+	"nocalhost/pkg/nocalhost-api/pkg/vault"
 	v "nocalhost/pkg/nocalhost-api/pkg/version"
 )
 
@@ -29,6 +44,7 @@ var GIT_COMMIT_SHA string
 var (
 	cfg     = pflag.StringP("config", "c", "", "config file path.")
 	version = pflag.BoolP("version", "v", false, "show version info.")
+	openapi = pflag.String("openapi", "", "write the OpenAPI 3 document to the file.")
 
 	Svc *service.Service
 )
@@ -54,20 +70,69 @@ func main() {
 		fmt.Println(string(marshaled))
 		return
 	}
+	if *openapi != "" {
+		doc, err := v2.Document()
+		if err == nil {
+			err = ioutil.WriteFile(*openapi, doc, 0644)
+		}
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// init config
 	if err := conf.Init(*cfg); err != nil {
 		panic(err)
 	}
+	// the secrets and the database credentials from vault, disabled if no address
+	if err := vault.Init(model.Driver()); err != nil {
+		panic(err)
+	}
+
+	// the commands of the database migrations, e.g. nocalhost-api migrate up
+	if pflag.Arg(0) == "migrate" {
+		if err := migrate(pflag.Args()[1:]); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// the commands of backup and restore, e.g. nocalhost-api backup nocalhost.json.gz
+	if command := pflag.Arg(0); command == "backup" || command == "restore" {
+		if err := backupOrRestore(command, pflag.Args()[1:]); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// export the traces to the OTLP collector, disabled if no endpoint
+	var exporter *otlp.Exporter
+	if endpoint := viper.GetString("tracing.otlp_endpoint"); endpoint != "" {
+		exporter = otlp.NewExporter(endpoint, viper.GetString("tracing.service_name"))
+		otlp.Start(exporter)
+	}
+
+	// the asymmetric keys of the access tokens, signed by jwt_secret if none
+	if err := token.InitKeys(); err != nil {
+		panic(err)
+	}
 
 	// init app
 	napp.App = napp.New(conf.Conf)
+	vault.RegisterGormCallbacks(napp.App.DB)
 
 	// Initial the Gin engine.
 	router := napp.App.Router
 
 	// Health Check
 	router.GET("/health", api.HealthCheck)
+	router.GET("/livez", api.Livez)
+	router.GET("/readyz", api.Readyz)
+	router.GET("/healthz", api.Healthz)
 	// metrics prometheus
 	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
@@ -76,17 +141,51 @@ func main() {
 	// Load router
 	//routers.LoadWebRouter(router)
 
+	// notify the events to the webhooks, disabled if none
+	if err := notify.Start(); err != nil {
+		panic(err)
+	}
+
 	// init service
 	service.Init()
+	// the replica is not ready if all the clusters are unreachable
+	if health.RequireCluster() {
+		health.Register("clusters", service.CheckClusterReachable)
+	}
 
 	cluster.Init()
 
+	// the background jobs run on the leader only if there are replicas
+	leader.Start(napp.App.DB)
+	cluster_user.StartReaper()
+
 	service.StartJob()
+	service.StartKubeConfigChecker()
+	service.StartClusterProber()
+	service.StartDevSpaceSleeper()
+	service.StartRegistryCredentialSyncer()
+	service.StartGarbageCollector()
+	service.StartCostSampler()
+	metrics.RegisterDevSpaceCounter(service.CountDevSpaces)
 	fmt.Printf("current run version %s, tag %s, branch %s \n", global.CommitId, global.Version, global.Branch)
 
-	// start grpc server reserved
-	//go server.New(controller)
+	// gRPC services served by the routes, disabled if no address
+	if addr := viper.GetString("app.grpc_addr"); addr != "" {
+		go rpc.Serve(addr, router)
+	}
 
-	// start server
+	// start server until shut down
 	napp.App.Run()
+
+	// the background jobs are drained, let another replica take them over
+	leader.Resign()
+
+	// export the spans of the last requests
+	if exporter != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := exporter.Flush(ctx); err != nil {
+			fmt.Printf("flush traces: %v\n", err)
+		}
+	}
 }
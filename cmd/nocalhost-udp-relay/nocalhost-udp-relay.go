@@ -0,0 +1,36 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+// nocalhost-udp-relay runs in the sidecar of DevMode, it relays the udp
+// port-forward of nhctl to the udp ports of the dev container
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/udp_relay"
+	"nocalhost/pkg/nhctl/log"
+)
+
+var GIT_COMMIT_SHA string
+
+func main() {
+	port := flag.Int("port", _const.UDPRelayPort, "The tcp port listened by the relay")
+	flag.Parse()
+
+	log.Infof("Current Version :[%s]", GIT_COMMIT_SHA)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", *port))
+	if err != nil {
+		log.FatalE(err, "Failed to listen the port of relay")
+	}
+	log.Infof("Relaying udp on %d", *port)
+	if err = udp_relay.Serve(listener); err != nil {
+		log.FatalE(err, "Relay exited")
+	}
+}
@@ -170,7 +170,7 @@ CREATE TABLE `users` (
   `uuid` varchar(100) NOT NULL DEFAULT '',
   `username` varchar(255) NOT NULL DEFAULT '',
   `name` varchar(20) DEFAULT NULL,
-  `password` varchar(60) NOT NULL DEFAULT '',
+  `password` varchar(255) NOT NULL DEFAULT '',
   `avatar` varchar(255) NOT NULL DEFAULT '',
   `phone` bigint(20) NOT NULL DEFAULT 0 ,
   `email` varchar(100) NOT NULL DEFAULT '',
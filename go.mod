@@ -50,6 +50,7 @@ require (
 	github.com/olivere/elastic/v7 v7.0.27
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
 	github.com/qiniu/api.v7 v0.0.0-20190520053455-bea02cd22bf4
 	github.com/rivo/tview v0.0.0-20211202162923-2a6de950f73b
 	github.com/satori/go.uuid v1.2.0
@@ -75,6 +76,7 @@ require (
 	golang.org/x/crypto v0.0.0-20211215153901-e495a2d5b3d3
 	golang.org/x/mod v0.4.2
 	golang.org/x/net v0.0.0-20211215060638-4ddde0e984e9
+	golang.org/x/oauth2 v0.0.0-20210402161424-2e8d93401602
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
 	golang.org/x/sys v0.0.0-20211216021012-1d35b9e2eb4e
 	golang.org/x/text v0.3.8-0.20211105212822-18b340fc7af2
@@ -16,6 +16,7 @@ import (
 	"nocalhost/internal/nhctl/common/base"
 	"nocalhost/internal/nhctl/controller"
 	"nocalhost/internal/nhctl/dev_dir"
+	"nocalhost/internal/nhctl/devcontainer"
 	"nocalhost/internal/nhctl/envsubst"
 	"nocalhost/internal/nhctl/fp"
 	"nocalhost/internal/nhctl/nocalhost"
@@ -197,9 +198,9 @@ func (a *Application) ReloadCfg(reloadFromMeta, silence bool) error {
 	return nil
 }
 
-// ReloadSvcCfg try load config from cm first
-// then load from local under associateDir/.nocalhost/config.yaml
-// at last load config from local profile
+// ReloadSvcCfg try load config from local under associateDir/.nocalhost/config.yaml first
+// then load from annotations, then from cm
+// at last derive the dev config from associateDir/.devcontainer/devcontainer.json
 func (a *Application) ReloadSvcCfg(svcName string, svcType base.SvcType, reloadFromMeta, silence bool) error {
 
 	if a.loadSvcCfgFromLocalIfValid(svcName, svcType, silence) {
@@ -210,7 +211,11 @@ func (a *Application) ReloadSvcCfg(svcName string, svcType base.SvcType, reloadF
 		return nil
 	}
 
-	a.loadSvcCfgFromCmIfValid(svcName, svcType, silence)
+	if a.loadSvcCfgFromCmIfValid(svcName, svcType, silence) {
+		return nil
+	}
+
+	a.loadSvcCfgFromDevContainerIfValid(svcName, svcType, silence)
 	return nil
 }
 
@@ -265,6 +270,7 @@ func (a *Application) loadSvcCfmFromAnnotationIfValid(svcName string, svcType ba
 					svcProfile.LocalConfigLoaded = false
 					svcProfile.AnnotationsConfigLoaded = true
 					svcProfile.CmConfigLoaded = false
+					svcProfile.DevContainerConfigLoaded = false
 					return nil
 				},
 			)
@@ -322,6 +328,7 @@ func (a *Application) loadSvcCfgFromCmIfValid(svcName string, svcType base.SvcTy
 				svcProfile.LocalConfigLoaded = false
 				svcProfile.AnnotationsConfigLoaded = false
 				svcProfile.CmConfigLoaded = true
+				svcProfile.DevContainerConfigLoaded = false
 				return nil
 			},
 		)
@@ -416,6 +423,7 @@ func (a *Application) loadSvcCfgFromLocalIfValid(svcName string, svcType base.Sv
 				svcProfile.LocalConfigLoaded = true
 				svcProfile.AnnotationsConfigLoaded = false
 				svcProfile.CmConfigLoaded = false
+				svcProfile.DevContainerConfigLoaded = false
 				return nil
 			},
 		)
@@ -427,6 +435,81 @@ func (a *Application) loadSvcCfgFromLocalIfValid(svcName string, svcType base.Sv
 	return true
 }
 
+// loadSvcCfgFromDevContainerIfValid derive the dev config from devcontainer.json
+// under the associate dir, only if no nocalhost dev config of svc is present
+func (a *Application) loadSvcCfgFromDevContainerIfValid(svcName string, svcType base.SvcType, silence bool) bool {
+	hint := hintFunc(svcName, svcType, silence)
+
+	meta := a.GetAppMeta()
+	pack := dev_dir.NewSvcPack(meta.Ns, meta.NamespaceId, meta.Application, svcType, svcName, "")
+	associatePath := pack.GetAssociatePath()
+	if associatePath == "" || devcontainer.Find(string(associatePath)) == "" {
+		return false
+	}
+
+	c, err := a.Controller(svcName, svcType)
+	if err != nil {
+		return false
+	}
+	// the config derived before is refreshed as devcontainer.json may change
+	svcProfile, err := c.GetProfile()
+	if err != nil {
+		return false
+	}
+	if !svcProfile.DevContainerConfigLoaded && hasDevConfig(a.appMeta.Config.GetSvcConfigV2(svcName, svcType)) {
+		return false
+	}
+
+	devContainer, err := devcontainer.Load(string(associatePath))
+	if err != nil {
+		hint("Load devcontainer.json fail, err: %s", err.Error())
+		return false
+	}
+	svcCfg, err := devContainer.ServiceConfig(svcName, svcType.String())
+	if err != nil {
+		hint("Derive dev config from devcontainer.json fail, err: %s", err.Error())
+		return false
+	}
+
+	a.appMeta.Config.SetSvcConfigV2(*svcCfg)
+	if err = a.appMeta.Update(); err != nil {
+		log.WarnE(err, "Failed to update svc config to meta")
+		return false
+	}
+
+	err = c.UpdateSvcProfile(
+		func(svcProfile *profile.SvcProfileV2) error {
+			hint("Success derive svc config from %s", devcontainer.Find(string(associatePath)))
+
+			svcProfile.Name = svcName
+			svcProfile.Type = svcType.String()
+			svcProfile.LocalConfigLoaded = false
+			svcProfile.AnnotationsConfigLoaded = false
+			svcProfile.CmConfigLoaded = false
+			svcProfile.DevContainerConfigLoaded = true
+			return nil
+		},
+	)
+	if err != nil {
+		hint("Derive svc config from devcontainer.json fail, fail while updating svc profile, err: %s", err.Error())
+		return false
+	}
+	return true
+}
+
+// hasDevConfig the svc config has the dev config of any container
+func hasDevConfig(svcCfg *profile.ServiceConfigV2) bool {
+	if svcCfg == nil {
+		return false
+	}
+	for _, c := range svcCfg.ContainerConfigs {
+		if c.Dev != nil && (c.Dev.Image != "" || c.Dev.WorkDir != "") {
+			return true
+		}
+	}
+	return false
+}
+
 func hintFunc(svcName string, svcType base.SvcType, silence bool) func(string, ...string) {
 	metaInfo := fmt.Sprintf("[name: %s serviceType: %s]", svcName, svcType)
 	return func(format string, s ...string) {
@@ -597,6 +680,8 @@ type HelmFlags struct {
 	RepoName string
 	RepoUrl  string
 	Version  string
+	// Digest pinned of the chart from OCI registry
+	Digest string
 }
 
 func (a *Application) GetApplicationConfigV2() *profile.ApplicationConfig {
@@ -624,7 +709,8 @@ type PortForwardOptions struct {
 	Way         string // port-forward way, value is manual or devPorts
 	RunAsDaemon bool
 	Forward     bool
-	Follow      bool // will stock until send ctrl+c or occurs error
+	Follow      bool     // will stock until send ctrl+c or occurs error
+	ReversePort []string // 9000:9000 forwards the port 9000 in pod to the local port 9000
 }
 
 type PortForwardEndOptions struct {
@@ -14,6 +14,7 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"nocalhost/internal/nhctl/app_flags"
 	"nocalhost/internal/nhctl/appmeta"
+	"nocalhost/internal/nhctl/config_validate"
 	"nocalhost/internal/nhctl/envsubst"
 	"nocalhost/internal/nhctl/envsubst/parse"
 	"nocalhost/internal/nhctl/fp"
@@ -87,7 +88,7 @@ func BuildApplication(name string, flags *app_flags.InstallFlags, kubeconfig str
 	}
 
 	if flags.GitUrl != "" {
-		if err = downloadResourcesFromGit(flags.GitUrl, flags.GitRef, app.ResourceTmpDir); err != nil {
+		if err = downloadResourcesFromGit(flags.GitUrl, flags.GitRef, flags.GitSshKey, app.ResourceTmpDir); err != nil {
 			return nil, err
 		}
 	} else if flags.LocalPath != "" {
@@ -107,6 +108,7 @@ func BuildApplication(name string, flags *app_flags.InstallFlags, kubeconfig str
 		//}
 		config.ApplicationConfig.ResourcePath = flags.ResourcePath
 	}
+	applyKustomizeFlags(config, flags)
 
 	appMeta.Config = config
 	appMeta.Config.Migrated = true
@@ -271,6 +273,11 @@ func RenderConfig(renderItem envsubst.RenderItem) (*profile.NocalHostAppConfigV2
 	// Render end, start to unmarshal the config
 	// ------
 
+	// the invalid fields are ignored by unmarshal, so they are only reported
+	if err := config_validate.ValidateAppConfig([]byte(renderedStr)); err != nil {
+		log.WarnE(err, "Nocalhost config does not match the schema")
+	}
+
 	// convert un strict yaml to strict yaml
 	renderedConfig := &profile.NocalHostAppConfigV2{}
 	if err := parseNocalhostConfigEnvFile(
@@ -0,0 +1,266 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nhctl/profile"
+	"nocalhost/pkg/nhctl/clientgoutils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// DefaultDependencyTimeout seconds waiting for a dependency if not configured
+const DefaultDependencyTimeout = 600
+
+var dependencyPollInterval = 2 * time.Second
+
+// dependencyKey e.g. statefulset/mysql, or statefulset/mysql.db if external
+func dependencyKey(d *profile.Dependency, namespace string) string {
+	key := strings.ToLower(d.Type) + "/" + d.Name
+	if d.Namespace != "" && d.Namespace != namespace {
+		key += "." + d.Namespace
+	}
+	return key
+}
+
+func infoKey(info *resource.Info) string {
+	return strings.ToLower(info.Mapping.GroupVersionKind.Kind) + "/" + info.Name
+}
+
+// dependencyStatus whether the dependency is ready, with its status such as
+// 1/3 ready, the failed job is not waited any more
+func dependencyStatus(client kubernetes.Interface, namespace string, d *profile.Dependency) (bool, string, error) {
+	if d.Namespace != "" {
+		namespace = d.Namespace
+	}
+	ctx := context.TODO()
+	ready := func(ready, desired int32) (bool, string, error) {
+		return ready >= desired, fmt.Sprintf("%d/%d ready", ready, desired), nil
+	}
+
+	var err error
+	switch strings.ToLower(d.Type) {
+	case "deployment":
+		deployment, e := client.AppsV1().Deployments(namespace).Get(ctx, d.Name, metav1.GetOptions{})
+		if err = e; err == nil {
+			if deployment.Status.ObservedGeneration < deployment.Generation {
+				return false, "rolling out", nil
+			}
+			desired := int32(1)
+			if deployment.Spec.Replicas != nil {
+				desired = *deployment.Spec.Replicas
+			}
+			return ready(deployment.Status.ReadyReplicas, desired)
+		}
+	case "statefulset":
+		statefulSet, e := client.AppsV1().StatefulSets(namespace).Get(ctx, d.Name, metav1.GetOptions{})
+		if err = e; err == nil {
+			desired := int32(1)
+			if statefulSet.Spec.Replicas != nil {
+				desired = *statefulSet.Spec.Replicas
+			}
+			return ready(statefulSet.Status.ReadyReplicas, desired)
+		}
+	case "daemonset":
+		daemonSet, e := client.AppsV1().DaemonSets(namespace).Get(ctx, d.Name, metav1.GetOptions{})
+		if err = e; err == nil {
+			return ready(daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled)
+		}
+	case "job":
+		job, e := client.BatchV1().Jobs(namespace).Get(ctx, d.Name, metav1.GetOptions{})
+		if err = e; err == nil {
+			for _, condition := range job.Status.Conditions {
+				if condition.Status != corev1.ConditionTrue {
+					continue
+				}
+				switch condition.Type {
+				case batchv1.JobComplete:
+					return true, "completed", nil
+				case batchv1.JobFailed:
+					return false, "failed", errors.Errorf("job %s failed: %s", d.Name, condition.Message)
+				}
+			}
+			return false, fmt.Sprintf("%d active", job.Status.Active), nil
+		}
+	case "pod":
+		pod, e := client.CoreV1().Pods(namespace).Get(ctx, d.Name, metav1.GetOptions{})
+		if err = e; err == nil {
+			if pod.Status.Phase == corev1.PodSucceeded {
+				return true, "succeeded", nil
+			}
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+					return true, "ready", nil
+				}
+			}
+			return false, strings.ToLower(string(pod.Status.Phase)), nil
+		}
+	default:
+		return false, "", errors.Errorf("unsupported type %s of dependency %s", d.Type, d.Name)
+	}
+
+	if k8serrors.IsNotFound(err) {
+		return false, "not found", nil
+	}
+	// try again later
+	return false, err.Error(), nil
+}
+
+// waitForDependencies wait for the dependencies one by one, the dependency
+// blocking currently is reported by onBlocked, empty if none is blocking
+func waitForDependencies(
+	client kubernetes.Interface, namespace string, dependencies []*profile.Dependency, onBlocked func(string),
+) error {
+	defer onBlocked("")
+	for _, d := range dependencies {
+		timeout := d.Timeout
+		if timeout <= 0 {
+			timeout = DefaultDependencyTimeout
+		}
+
+		key, status := dependencyKey(d, namespace), ""
+		err := wait.PollImmediate(
+			dependencyPollInterval, time.Duration(timeout)*time.Second, func() (bool, error) {
+				ready, s, err := dependencyStatus(client, namespace, d)
+				if err != nil || ready {
+					return ready, err
+				}
+				if s != status {
+					status = s
+					log.Infof("Waiting for dependency %s: %s", key, status)
+					onBlocked(fmt.Sprintf("%s (%s)", key, status))
+				}
+				return false, nil
+			},
+		)
+		if err == wait.ErrWaitTimeout {
+			return errors.Errorf("dependency %s is not ready in %ds: %s", key, timeout, status)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "dependency %s", key)
+		}
+	}
+	return nil
+}
+
+// sortByDependencies the resources depended by others of the application are
+// applied first, the order of resources is kept as much as possible
+func sortByDependencies(infos []*resource.Info, services []*profile.ServiceConfigV2, namespace string) (
+	[]*resource.Info, error,
+) {
+	dependencies := map[string][]string{}
+	for _, svc := range services {
+		key := strings.ToLower(svc.Type) + "/" + svc.Name
+		for _, d := range svc.Dependencies {
+			dependencies[key] = append(dependencies[key], dependencyKey(d, namespace))
+		}
+	}
+	if len(dependencies) == 0 {
+		return infos, nil
+	}
+
+	indexes := map[string]bool{}
+	for _, info := range infos {
+		indexes[infoKey(info)] = true
+	}
+
+	result := make([]*resource.Info, 0, len(infos))
+	sorted := make([]bool, len(infos))
+	applied := map[string]bool{}
+	for len(result) < len(infos) {
+		progressed := false
+		for i, info := range infos {
+			if sorted[i] {
+				continue
+			}
+			blocked := false
+			for _, dependency := range dependencies[infoKey(info)] {
+				// the external ones are waited only
+				if indexes[dependency] && !applied[dependency] {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				sorted[i], applied[infoKey(info)] = true, true
+				result = append(result, info)
+				progressed = true
+			}
+		}
+		if !progressed {
+			var cycle []string
+			for i, info := range infos {
+				if !sorted[i] {
+					cycle = append(cycle, infoKey(info))
+				}
+			}
+			return nil, errors.Errorf("dependency cycle among %s", strings.Join(cycle, ", "))
+		}
+	}
+	return result, nil
+}
+
+// reportBlocked record the dependency blocking the install in app meta, so
+// that it can be queried while installing
+func (a *Application) reportBlocked(blockedBy string) {
+	if a.appMeta.BlockedBy == blockedBy {
+		return
+	}
+	a.appMeta.BlockedBy = blockedBy
+	if err := a.appMeta.Update(); err != nil {
+		log.WarnE(err, "Failed to update the dependency blocking application "+a.Name)
+	}
+}
+
+// waitForAppDependencies the dependencies of application are waited before
+// installing anything
+func (a *Application) waitForAppDependencies() error {
+	dependencies := a.GetApplicationConfigV2().Dependencies
+	if len(dependencies) == 0 {
+		return nil
+	}
+	return waitForDependencies(a.client.ClientSet, a.NameSpace, dependencies, a.reportBlocked)
+}
+
+// withDependencies the resources are applied in order of dependencies, the
+// workload of service waits for its dependencies before applied
+func (a *Application) withDependencies(flags *clientgoutils.ApplyFlags) *clientgoutils.ApplyFlags {
+	services := a.GetApplicationConfigV2().ServiceConfigs
+	return flags.SetSort(
+		func(infos []*resource.Info) ([]*resource.Info, error) {
+			return sortByDependencies(infos, services, a.NameSpace)
+		},
+	).SetBeforeApplyInfo(
+		func(info *resource.Info) error {
+			for _, svc := range services {
+				if len(svc.Dependencies) > 0 && strings.ToLower(svc.Type)+"/"+svc.Name == infoKey(info) {
+					return waitForDependencies(
+						a.client.ClientSet, a.NameSpace, svc.Dependencies, func(blockedBy string) {
+							if blockedBy != "" {
+								blockedBy += " before applying " + infoKey(info)
+							}
+							a.reportBlocked(blockedBy)
+						},
+					)
+				}
+			}
+			return nil
+		},
+	)
+}
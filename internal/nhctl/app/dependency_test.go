@@ -0,0 +1,122 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"nocalhost/internal/nhctl/profile"
+)
+
+func TestDependencyStatus(t *testing.T) {
+	replicas := int32(2)
+	client := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysql", Namespace: "dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "db"},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			},
+		},
+	)
+
+	for _, c := range []struct {
+		dependency *profile.Dependency
+		ready      bool
+		status     string
+	}{
+		{&profile.Dependency{Name: "mysql", Type: "Deployment"}, false, "1/2 ready"},
+		{&profile.Dependency{Name: "migrate", Type: "job", Namespace: "db"}, true, "completed"},
+		{&profile.Dependency{Name: "redis", Type: "statefulset"}, false, "not found"},
+	} {
+		ready, status, err := dependencyStatus(client, "dev", c.dependency)
+		if err != nil || ready != c.ready || status != c.status {
+			t.Errorf("unexpected status of %s: %v %s %v", c.dependency.Name, ready, status, err)
+		}
+	}
+
+	if _, _, err := dependencyStatus(client, "dev", &profile.Dependency{Name: "mysql", Type: "service"}); err == nil {
+		t.Fatal("service is not supported")
+	}
+}
+
+func TestWaitForDependencies(t *testing.T) {
+	dependencyPollInterval = 10 * time.Millisecond
+	defer func() { dependencyPollInterval = 2 * time.Second }()
+
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "mysql-0", Namespace: "dev"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	)
+
+	var blocked []string
+	err := waitForDependencies(
+		client, "dev", []*profile.Dependency{{Name: "mysql-0", Type: "pod", Timeout: 1}},
+		func(s string) { blocked = append(blocked, s) },
+	)
+	if err == nil || !strings.Contains(err.Error(), "dependency pod/mysql-0 is not ready in 1s: pending") {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(blocked) != 2 || blocked[0] != "pod/mysql-0 (pending)" || blocked[1] != "" {
+		t.Fatalf("unexpected blocked %v", blocked)
+	}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	info := func(kind, name string) *resource.Info {
+		return &resource.Info{
+			Name:    name,
+			Mapping: &meta.RESTMapping{GroupVersionKind: schema.GroupVersionKind{Kind: kind}},
+		}
+	}
+	infos := []*resource.Info{info("Deployment", "web"), info("Service", "mysql"), info("StatefulSet", "mysql")}
+	services := []*profile.ServiceConfigV2{
+		{
+			Name: "web", Type: "deployment",
+			Dependencies: []*profile.Dependency{
+				{Name: "mysql", Type: "statefulset"}, {Name: "cache", Type: "deployment", Namespace: "shared"},
+			},
+		},
+	}
+
+	sorted, err := sortByDependencies(infos, services, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, i := range sorted {
+		keys = append(keys, infoKey(i))
+	}
+	if got := strings.Join(keys, ","); got != "service/mysql,statefulset/mysql,deployment/web" {
+		t.Fatalf("unexpected order %s", got)
+	}
+
+	services = append(
+		services, &profile.ServiceConfigV2{
+			Name: "mysql", Type: "statefulset", Dependencies: []*profile.Dependency{{Name: "web", Type: "deployment"}},
+		},
+	)
+	if _, err := sortByDependencies(infos, services, "dev"); err == nil {
+		t.Fatal("dependency cycle must be reported")
+	}
+}
@@ -11,6 +11,16 @@ import (
 )
 
 func (a *Application) Exec(pod v1.Pod, container string, commands []string) error {
+	return a.client.Exec(pod.Name, containerToExec(pod, container), commands)
+}
+
+// ExecInteractive exec commands in the container as Exec does, but the tty is
+// allocated only if stdin is a terminal, see ClientGoUtils.ExecInteractive
+func (a *Application) ExecInteractive(pod v1.Pod, container string, commands []string) error {
+	return a.client.ExecInteractive(pod.Name, containerToExec(pod, container), commands)
+}
+
+func containerToExec(pod v1.Pod, container string) string {
 	var name string
 
 	// if container arguments are available, using container arguments
@@ -31,5 +41,5 @@ func (a *Application) Exec(pod v1.Pod, container string, commands []string) erro
 			break
 		}
 	}
-	return a.client.Exec(pod.Name, name, commands)
+	return name
 }
@@ -6,13 +6,42 @@
 package app
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	"nocalhost/pkg/nhctl/tools"
 	"os"
 	"strings"
 )
 
-func cloneFromGit(gitUrl string, gitRef string, destPath string) error {
+const (
+	// GitTokenEnv the token of https pulling private repo, it is read from env
+	// so that it is not exposed by the args of process
+	GitTokenEnv = "NOCALHOST_GIT_TOKEN"
+	// GitUsernameEnv the username of https, default to oauth2
+	GitUsernameEnv = "NOCALHOST_GIT_USERNAME"
+)
+
+// gitCredentialConfig the config of git clone with the ssh key and the token of env
+func gitCredentialConfig(sshKey string) []string {
+	sshCommand := "ssh -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no"
+	if sshKey != "" {
+		sshCommand += fmt.Sprintf(" -o IdentitiesOnly=yes -i '%s'", sshKey)
+	}
+	config := []string{"--config", "core.sshCommand=" + sshCommand}
+
+	if os.Getenv(GitTokenEnv) != "" {
+		// the empty helper resets the ones configured globally
+		config = append(
+			config, "--config", "credential.helper=", "--config", fmt.Sprintf(
+				`credential.helper=!f() { test "$1" = get && echo username=${%s:-oauth2} && echo password=$%s; }; f`,
+				GitUsernameEnv, GitTokenEnv,
+			),
+		)
+	}
+	return config
+}
+
+func cloneFromGit(gitUrl string, gitRef string, sshKey string, destPath string) error {
 
 	var (
 		err        error
@@ -29,13 +58,16 @@ func cloneFromGit(gitUrl string, gitRef string, destPath string) error {
 		gitDirName = strs[len(strs)-1] // todo : for default application name
 		if len(gitRef) > 0 {
 			_, err = tools.ExecCommand(
-				nil, true, false, false, "git", "clone", "--branch", gitRef, "--depth", "1", gitUrl, destPath,
-				"--config", "core.sshCommand=ssh -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no",
+				nil, true, false, false, "git", append(
+					[]string{"clone", "--branch", gitRef, "--depth", "1", gitUrl, destPath},
+					gitCredentialConfig(sshKey)...,
+				)...,
 			)
 		} else {
 			_, err = tools.ExecCommand(
-				nil, false, false, false, "git", "clone", "--depth", "1", gitUrl, destPath,
-				"--config", "core.sshCommand=ssh -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no",
+				nil, false, false, false, "git", append(
+					[]string{"clone", "--depth", "1", gitUrl, destPath}, gitCredentialConfig(sshKey)...,
+				)...,
 			)
 		}
 		if err != nil {
@@ -46,8 +78,8 @@ func cloneFromGit(gitUrl string, gitRef string, destPath string) error {
 
 }
 
-func downloadResourcesFromGit(gitUrl, gitRef, destPath string) error {
-	return cloneFromGit(gitUrl, gitRef, destPath)
+func downloadResourcesFromGit(gitUrl, gitRef, sshKey, destPath string) error {
+	return cloneFromGit(gitUrl, gitRef, sshKey, destPath)
 }
 
 // remove srcDir to destDir
@@ -0,0 +1,32 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGitCredentialConfig(t *testing.T) {
+	_ = os.Unsetenv(GitTokenEnv)
+	config := gitCredentialConfig("")
+	if len(config) != 2 || strings.Contains(config[1], "-i") {
+		t.Fatalf("unexpected config without credential: %v", config)
+	}
+
+	config = gitCredentialConfig("/tmp/id_rsa")
+	if !strings.Contains(config[1], "-i '/tmp/id_rsa'") {
+		t.Fatalf("ssh key is not used: %v", config)
+	}
+
+	_ = os.Setenv(GitTokenEnv, "token")
+	defer os.Unsetenv(GitTokenEnv)
+	config = gitCredentialConfig("")
+	if len(config) != 6 || config[3] != "credential.helper=" || strings.Contains(config[5], "token") {
+		t.Fatalf("unexpected config with token: %v", config)
+	}
+}
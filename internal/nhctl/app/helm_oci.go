@@ -0,0 +1,104 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/pkg/nhctl/log"
+	"nocalhost/pkg/nhctl/tools"
+)
+
+const (
+	// HelmUsernameEnv the username of OCI registry, credentials are read from
+	// env so that they are not exposed by the args of process
+	HelmUsernameEnv = "NOCALHOST_HELM_USERNAME"
+	// HelmPasswordEnv the password or token of OCI registry
+	HelmPasswordEnv = "NOCALHOST_HELM_PASSWORD"
+
+	ociScheme = "oci://"
+)
+
+var ociDigestRegexp = regexp.MustCompile(`(?m)^Digest:\s*(sha256:[a-f0-9]{64})\s*$`)
+
+// IsOciChart the chart is published to OCI registry, such as Harbor or ECR,
+// it requires helm 3.8+
+func IsOciChart(repoUrl string) bool {
+	return strings.HasPrefix(repoUrl, ociScheme)
+}
+
+// ociChartRef the reference of chart in registry, e.g. oci://harbor.io/charts/nginx
+func ociChartRef(repoUrl, chart string) string {
+	return strings.TrimSuffix(repoUrl, "/") + "/" + chart
+}
+
+// ociRegistryHost the host of registry where the chart is published
+func ociRegistryHost(ref string) string {
+	return strings.SplitN(strings.TrimPrefix(ref, ociScheme), "/", 2)[0]
+}
+
+// loginOciRegistry login the registry with the credential of env, the
+// password is passed by stdin
+func loginOciRegistry(ref string) error {
+	username, password := os.Getenv(HelmUsernameEnv), os.Getenv(HelmPasswordEnv)
+	if password == "" {
+		return nil
+	}
+
+	host := ociRegistryHost(ref)
+	log.Infof("Logging in registry %s...", host)
+	cmd := exec.Command("helm", "registry", "login", host, "--username", username, "--password-stdin")
+	cmd.Stdin = strings.NewReader(password)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "fail to login registry %s: %s", host, string(output))
+	}
+	return nil
+}
+
+// pullOciChart pull the chart to a temp dir and returns the path of archive,
+// the digest reported by helm must match the one pinned if specified
+func pullOciChart(ref, version, digest string) (string, error) {
+	if err := loginOciRegistry(ref); err != nil {
+		return "", err
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+
+	params := []string{"pull", ref, "--destination", dir}
+	if version != "" {
+		params = append(params, "--version", version)
+	}
+	output, err := tools.ExecCommand(nil, true, false, false, "helm", params...)
+	if err != nil {
+		return "", errors.Wrapf(err, "fail to pull chart %s", ref)
+	}
+
+	if digest != "" {
+		match := ociDigestRegexp.FindStringSubmatch(output)
+		if len(match) != 2 {
+			return "", errors.Errorf("digest of chart %s is not reported by helm, helm 3.8+ is required", ref)
+		}
+		if match[1] != digest {
+			return "", errors.Errorf("digest of chart %s is %s, but %s is pinned", ref, match[1], digest)
+		}
+	}
+
+	archives, _ := filepath.Glob(filepath.Join(dir, "*.tgz"))
+	if len(archives) != 1 {
+		return "", errors.Errorf("chart archive of %s is not found", ref)
+	}
+	return archives[0], nil
+}
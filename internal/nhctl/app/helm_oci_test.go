@@ -0,0 +1,32 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"testing"
+)
+
+func TestOciChartRef(t *testing.T) {
+	if !IsOciChart("oci://harbor.example.com/charts") || IsOciChart("https://charts.example.com") {
+		t.Fatal("unexpected oci detection")
+	}
+
+	ref := ociChartRef("oci://harbor.example.com/charts/", "nginx")
+	if ref != "oci://harbor.example.com/charts/nginx" {
+		t.Fatalf("unexpected ref %s", ref)
+	}
+	if host := ociRegistryHost(ref); host != "harbor.example.com" {
+		t.Fatalf("unexpected host %s", host)
+	}
+}
+
+func TestOciDigest(t *testing.T) {
+	digest := "sha256:0b2d2a4a4ed5b0b1d9e6f1f0ad3bb3a6c89f9c3f0c5d1e1b3f9c0b6b9e1f2a3c"
+	output := "Pulled: harbor.example.com/charts/nginx:1.0.0\nDigest: " + digest + "\n"
+	if match := ociDigestRegexp.FindStringSubmatch(output); len(match) != 2 || match[1] != digest {
+		t.Fatalf("unexpected match %v", match)
+	}
+}
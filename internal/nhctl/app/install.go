@@ -32,6 +32,10 @@ func (a *Application) Install(flags *HelmFlags) (err error) {
 		return errors.Wrap(err, "failed to install dep config map")
 	}
 
+	if err := a.waitForAppDependencies(); err != nil {
+		return err
+	}
+
 	switch a.appMeta.ApplicationType {
 	case appmeta.Helm, appmeta.HelmLocal:
 		err = a.installHelm(flags, false)
@@ -86,6 +90,7 @@ func (a *Application) Install(flags *HelmFlags) (err error) {
 		return err
 	}
 
+	a.recordRevision(appmeta.RevisionInstall, 0)
 	return a.CleanUpTmpResources()
 }
 
@@ -95,11 +100,14 @@ func (a *Application) InstallKustomize(doApply bool) error {
 	if len(resourcesPath) > 1 {
 		log.Warn(`There are multiple resourcesPath settings, will use first one`)
 	}
-	useResourcePath := resourcesPath[0]
+	useResourcePath, err := a.kustomizePath()
+	if err != nil {
+		return err
+	}
 
-	err := a.client.Apply(
+	err = a.client.Apply(
 		[]string{}, true,
-		StandardNocalhostMetas(a.Name, a.NameSpace).
+		a.withDependencies(StandardNocalhostMetas(a.Name, a.NameSpace)).
 			SetDoApply(doApply).
 			SetBeforeApply(
 				func(manifest string) error {
@@ -121,7 +129,7 @@ func (a *Application) InstallManifest(doApply bool) error {
 
 	return a.client.Apply(
 		manifestPaths, true,
-		StandardNocalhostMetas(a.Name, a.NameSpace).
+		a.withDependencies(StandardNocalhostMetas(a.Name, a.NameSpace)).
 			SetDoApply(doApply).
 			SetBeforeApply(
 				func(manifest string) error {
@@ -177,7 +185,17 @@ func (a *Application) installHelm(flags *HelmFlags, fromRepo bool) error {
 		if a.appMeta.Config != nil && a.appMeta.Config.ApplicationConfig.Name != "" {
 			chartName = a.appMeta.Config.ApplicationConfig.Name
 		}
-		if len(findRepoNameFromLocal(flags.RepoUrl)) != 0 {
+		if IsOciChart(flags.RepoUrl) {
+			version := flags.Version
+			if version == "" && a.appMeta.Config != nil {
+				version = a.appMeta.Config.ApplicationConfig.HelmVersion
+			}
+			archive, err := pullOciChart(ociChartRef(flags.RepoUrl, chartName), version, flags.Digest)
+			if err != nil {
+				return err
+			}
+			installParams = append(installParams, archive)
+		} else if len(findRepoNameFromLocal(flags.RepoUrl)) != 0 {
 			installParams = append(installParams, fmt.Sprintf("%s/%s", findRepoNameFromLocal(flags.RepoUrl), chartName))
 		} else if flags.RepoUrl != "" {
 			installParams = append(installParams, chartName, "--repo", flags.RepoUrl)
@@ -194,7 +212,9 @@ func (a *Application) installHelm(flags *HelmFlags, fromRepo bool) error {
 			}
 		}
 
-		if flags.Version != "" {
+		if IsOciChart(flags.RepoUrl) {
+			// the version is pulled already
+		} else if flags.Version != "" {
 			installParams = append(installParams, "--version", flags.Version)
 		} else {
 			if a.appMeta.Config != nil && a.appMeta.Config.ApplicationConfig.HelmVersion != "" {
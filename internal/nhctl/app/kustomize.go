@@ -0,0 +1,119 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/pkg/image"
+	"sigs.k8s.io/kustomize/pkg/types"
+
+	"nocalhost/internal/nhctl/app_flags"
+	"nocalhost/internal/nhctl/profile"
+)
+
+// applyKustomizeFlags the overlay and images specified by flags take place of
+// the ones of config
+func applyKustomizeFlags(config *profile.NocalHostAppConfigV2, flags *app_flags.InstallFlags) {
+	if flags.KustomizeOverlay != "" {
+		config.ApplicationConfig.KustomizeOverlay = flags.KustomizeOverlay
+	}
+	if len(flags.KustomizeImages) > 0 {
+		config.ApplicationConfig.KustomizeImages = flags.KustomizeImages
+	}
+}
+
+// ParseKustomizeImage parse the image override like 'kustomize edit set image',
+// e.g. nginx=registry.io/nginx:1.21, nginx:1.21 or nginx@sha256:...
+func ParseKustomizeImage(s string) (image.Image, error) {
+	img := image.Image{}
+	override := s
+	if i := strings.Index(s, "="); i >= 0 {
+		if i == 0 {
+			return img, errors.Errorf("invalid image override %s, name of image is missing", s)
+		}
+		img.Name, override = s[:i], s[i+1:]
+	}
+
+	newName := override
+	if i := strings.Index(override, "@"); i >= 0 {
+		newName, img.Digest = override[:i], override[i+1:]
+	} else if i := strings.LastIndex(override, ":"); i > strings.LastIndex(override, "/") {
+		newName, img.NewTag = override[:i], override[i+1:]
+	}
+
+	if img.Name == "" {
+		// nginx:1.21 only overrides the tag of nginx
+		img.Name = newName
+	} else if newName != img.Name {
+		img.NewName = newName
+	}
+	if img.Name == "" || (img.NewName == "" && img.NewTag == "" && img.Digest == "") {
+		return img, errors.Errorf("invalid image override %s, must be name=newName:tag, name:tag or name@digest", s)
+	}
+	return img, nil
+}
+
+// kustomizePath the kustomization to build, an overlay selected or images
+// overridden is wrapped by a kustomization which also injects the namespace
+// of application, so that the overlay is not required to know the namespace
+func (a *Application) kustomizePath() (string, error) {
+	config := a.GetApplicationConfigV2()
+	path := filepath.Join(a.ResourceTmpDir, config.KustomizeOverlay)
+	if config.KustomizeOverlay == "" {
+		resourcesPath := a.GetResourceDir(a.ResourceTmpDir)
+		path = resourcesPath[0]
+		if len(config.KustomizeImages) == 0 {
+			return path, nil
+		}
+	}
+
+	base, err := filepath.Abs(path)
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	return renderKustomization(base, a.NameSpace, config.KustomizeImages)
+}
+
+// renderKustomization write the kustomization based on the overlay to a temp
+// dir, returns the dir, the overlay is referred by relative path as kustomize
+// refuses the absolute one
+func renderKustomization(base, namespace string, images []string) (string, error) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	rel, err := filepath.Rel(dir, base)
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+
+	kustomization := types.Kustomization{
+		TypeMeta:  types.TypeMeta{APIVersion: types.KustomizationVersion, Kind: types.KustomizationKind},
+		Namespace: namespace,
+		Bases:     []string{filepath.ToSlash(rel)},
+	}
+	for _, s := range images {
+		img, err := ParseKustomizeImage(s)
+		if err != nil {
+			return "", err
+		}
+		kustomization.Images = append(kustomization.Images, img)
+	}
+
+	content, err := yaml.Marshal(kustomization)
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "kustomization.yaml"), content, DefaultNewFilePermission); err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	return dir, nil
+}
@@ -0,0 +1,73 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kustomize/pkg/image"
+
+	"nocalhost/pkg/nhctl/clientgoutils"
+)
+
+func TestParseKustomizeImage(t *testing.T) {
+	for s, expect := range map[string]image.Image{
+		"nginx=registry.io/nginx:1.21": {Name: "nginx", NewName: "registry.io/nginx", NewTag: "1.21"},
+		"nginx:1.21":                   {Name: "nginx", NewTag: "1.21"},
+		"nginx@sha256:abc":             {Name: "nginx", Digest: "sha256:abc"},
+		"nginx=registry.io:5000/nginx": {Name: "nginx", NewName: "registry.io:5000/nginx"},
+	} {
+		if img, err := ParseKustomizeImage(s); err != nil || img != expect {
+			t.Fatalf("unexpected image of %s: %v %v", s, img, err)
+		}
+	}
+	for _, s := range []string{"nginx", "=nginx:1.21"} {
+		if _, err := ParseKustomizeImage(s); err == nil {
+			t.Fatalf("%s should be invalid", s)
+		}
+	}
+}
+
+func TestRenderKustomization(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(dir)
+
+	overlay := filepath.Join(dir, "overlays", "dev")
+	_ = os.MkdirAll(overlay, 0700)
+	_ = ioutil.WriteFile(filepath.Join(overlay, "kustomization.yaml"), []byte("namespace: dev\nresources:\n- deploy.yaml\n"), 0600)
+	_ = ioutil.WriteFile(
+		filepath.Join(overlay, "deploy.yaml"), []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.19
+`), 0600,
+	)
+
+	path, err := renderKustomization(overlay, "pr-123", []string{"nginx=registry.io/nginx:1.21"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	resource, err := clientgoutils.NewKustomizeResourceReader(path).LoadResource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := resource.String()
+	if !strings.Contains(manifest, "namespace: pr-123") || !strings.Contains(manifest, "image: registry.io/nginx:1.21") {
+		t.Fatalf("namespace or image is not injected:\n%s", manifest)
+	}
+}
@@ -21,6 +21,9 @@ type SyncStatusOptions struct {
 	WaitForSync bool
 	Watch       bool
 	Timeout     int64
+	Conflicts   bool
+	Resolve     bool
+	Strategy    string
 }
 
 type SyncStatusDirOptions struct {
@@ -0,0 +1,144 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nhctl/appmeta"
+	"nocalhost/pkg/nhctl/clientgoutils"
+	"nocalhost/pkg/nhctl/log"
+	"nocalhost/pkg/nhctl/tools"
+)
+
+type helmHistory struct {
+	Revision int    `json:"revision"`
+	Chart    string `json:"chart"`
+}
+
+func (a *Application) helmReleaseName() string {
+	if appProfile, err := a.GetProfile(); err == nil && appProfile.ReleaseName != "" {
+		return appProfile.ReleaseName
+	}
+	if a.appMeta.HelmReleaseName != "" {
+		return a.appMeta.HelmReleaseName
+	}
+	return a.appMeta.Application
+}
+
+func (a *Application) helmParams(params ...string) []string {
+	if a.NameSpace != "" {
+		params = append(params, "--namespace", a.NameSpace)
+	}
+	if a.KubeConfig != "" {
+		params = append(params, "--kubeconfig", a.KubeConfig)
+	}
+	return params
+}
+
+// newRevision describe the application installed currently, the release of
+// helm application is queried from helm
+func (a *Application) newRevision(action appmeta.RevisionAction) (*appmeta.Revision, error) {
+	revision := &appmeta.Revision{Action: action, TriggeredBy: appmeta.RevisionTrigger()}
+	if !a.appMeta.IsHelm() {
+		revision.Manifest = a.appMeta.Manifest
+		revision.ManifestHash = appmeta.ManifestHash(a.appMeta.Manifest)
+		return revision, nil
+	}
+
+	releaseName := a.helmReleaseName()
+	output, err := tools.ExecCommand(
+		nil, false, false, false, "helm", a.helmParams("history", releaseName, "--max", "1", "-o", "json")...,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to get history of release %s", releaseName)
+	}
+	var histories []helmHistory
+	if err := json.Unmarshal([]byte(output), &histories); err != nil || len(histories) == 0 {
+		return nil, errors.Errorf("no history of release %s", releaseName)
+	}
+	revision.HelmRevision = histories[0].Revision
+	revision.ChartVersion = histories[0].Chart
+
+	if revision.Values, err = tools.ExecCommand(
+		nil, false, false, false, "helm", a.helmParams("get", "values", releaseName, "-o", "yaml")...,
+	); err != nil {
+		return nil, errors.Wrapf(err, "fail to get values of release %s", releaseName)
+	}
+	manifest, err := tools.ExecCommand(
+		nil, false, false, false, "helm", a.helmParams("get", "manifest", releaseName)...,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to get manifest of release %s", releaseName)
+	}
+	revision.ManifestHash = appmeta.ManifestHash(manifest)
+	return revision, nil
+}
+
+// recordRevision the application is installed already, so it is not failed
+// if the revision can not be recorded
+func (a *Application) recordRevision(action appmeta.RevisionAction, rollbackTo int) {
+	revision, err := a.newRevision(action)
+	if err == nil {
+		revision.RollbackTo = rollbackTo
+		err = a.appMeta.AddRevision(revision)
+	}
+	if err != nil {
+		log.WarnE(err, "Failed to record revision of application "+a.Name)
+		return
+	}
+	log.Infof("Revision %d of application %s recorded", revision.Revision, a.Name)
+}
+
+// Rollback re-apply the revision of application, the helm release is rolled
+// back to the revision of release, the manifest of others is applied again
+func (a *Application) Rollback(revision int) error {
+	target, err := a.appMeta.GetRevision(revision)
+	if err != nil {
+		return err
+	}
+	if current := a.appMeta.CurrentRevision(); current != nil && current.Revision == revision {
+		return errors.Errorf("application %s is at revision %d already", a.Name, revision)
+	}
+
+	switch a.GetType() {
+	case appmeta.Helm, appmeta.HelmLocal, appmeta.HelmRepo:
+		if target.HelmRevision == 0 {
+			return errors.Errorf("revision %d has no release of helm", revision)
+		}
+		log.Infof("Rolling back helm release to revision %d...", target.HelmRevision)
+		if _, err := tools.ExecCommand(
+			nil, true, false, false, "helm",
+			a.helmParams("rollback", a.helmReleaseName(), strconv.Itoa(target.HelmRevision), "--timeout", "60m")...,
+		); err != nil {
+			return errors.Wrap(err, "fail to rollback helm application")
+		}
+	case appmeta.Manifest, appmeta.ManifestLocal, appmeta.ManifestGit, appmeta.KustomizeGit, appmeta.KustomizeLocal:
+		targetInfos, err := clientgoutils.NewResourceFromStr(target.Manifest).GetResourceInfo(a.client, true)
+		if err != nil {
+			return err
+		}
+		oldInfos, err := a.appMeta.NewResourceReader().GetResourceInfo(a.client, true)
+		if err != nil {
+			return err
+		}
+		if err = a.upgradeInfos(oldInfos, targetInfos, true); err != nil {
+			return err
+		}
+		a.appMeta.Manifest = target.Manifest
+		if err := a.appMeta.Update(); err != nil {
+			return err
+		}
+	default:
+		return errors.New("Unsupported app type")
+	}
+
+	a.recordRevision(appmeta.RevisionRollback, revision)
+	return nil
+}
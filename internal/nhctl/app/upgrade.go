@@ -28,7 +28,7 @@ func (a *Application) PrepareForUpgrade(flags *flag.InstallFlags) error {
 		return errors.New("Fail to create tmp dir for upgrade")
 	}
 	if flags.GitUrl != "" {
-		if err = downloadResourcesFromGit(flags.GitUrl, flags.GitRef, a.ResourceTmpDir); err != nil {
+		if err = downloadResourcesFromGit(flags.GitUrl, flags.GitRef, flags.GitSshKey, a.ResourceTmpDir); err != nil {
 			return err
 		}
 	} else if flags.LocalPath != "" {
@@ -45,13 +45,19 @@ func (a *Application) PrepareForUpgrade(flags *flag.InstallFlags) error {
 		return err
 	}
 
+	applyKustomizeFlags(config, flags)
 	a.appMeta.Config = config
 	a.appMeta.Config.Migrated = true
-	return a.appMeta.Update()
+	return nil
 }
 
 func (a *Application) Upgrade(installFlags *flag.InstallFlags) error {
 
+	// the config prepared is persisted only if it is upgraded, not by the diff
+	if err := a.appMeta.Update(); err != nil {
+		return err
+	}
+
 	switch a.GetType() {
 	case appmeta.HelmRepo:
 
@@ -98,6 +104,7 @@ func (a *Application) Upgrade(installFlags *flag.InstallFlags) error {
 		return err
 	}
 
+	a.recordRevision(appmeta.RevisionUpgrade, 0)
 	return a.CleanUpTmpResources()
 }
 
@@ -106,7 +113,10 @@ func (a *Application) upgradeForKustomize() error {
 	if len(resourcesPath) > 1 {
 		log.Warn(`There are multiple resourcesPath settings, will use first one`)
 	}
-	useResourcePath := resourcesPath[0]
+	useResourcePath, err := a.kustomizePath()
+	if err != nil {
+		return err
+	}
 
 	return a.client.Apply(
 		[]string{}, true,
@@ -222,6 +232,21 @@ func isContainsInfo(info *resource.Info, infos []*resource.Info) bool {
 
 func (a *Application) upgradeForHelm(installFlags *flag.InstallFlags, fromRepo bool) error {
 
+	params, _, err := a.helmUpgradeParams(installFlags, fromRepo)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Upgrade helm application, this may take several minutes, please waiting...")
+
+	_, err = tools.ExecCommand(nil, true, false, false, "helm", params...)
+	return errors.Wrap(err, "")
+}
+
+// helmUpgradeParams prepares the chart to upgrade to, it returns the params of
+// helm upgrade and the common params of helm, such as the namespace
+func (a *Application) helmUpgradeParams(installFlags *flag.InstallFlags, fromRepo bool) ([]string, []string, error) {
+
 	_, err := tools.ExecCommand(nil, true, false, false, "helm", "repo", "update")
 	if err != nil {
 		log.Info(err.Error())
@@ -230,7 +255,7 @@ func (a *Application) upgradeForHelm(installFlags *flag.InstallFlags, fromRepo b
 	resourceDir := a.ResourceTmpDir
 	appProfile, err := a.GetProfile()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	releaseName := appProfile.ReleaseName
@@ -256,13 +281,23 @@ func (a *Application) upgradeForHelm(installFlags *flag.InstallFlags, fromRepo b
 		if a.appMeta.Config != nil && a.appMeta.Config.ApplicationConfig.Name != "" {
 			chartName = a.appMeta.Config.ApplicationConfig.Name
 		}
-		if installFlags.HelmRepoUrl != "" {
-			params = append(params, chartName, "--repo", installFlags.HelmRepoUrl)
-		} else if installFlags.HelmRepoName != "" {
-			params = append(params, fmt.Sprintf("%s/%s", installFlags.HelmRepoName, chartName))
-		}
-		if installFlags.HelmRepoVersion != "" {
-			params = append(params, "--version", installFlags.HelmRepoVersion)
+		if IsOciChart(installFlags.HelmRepoUrl) {
+			archive, err := pullOciChart(
+				ociChartRef(installFlags.HelmRepoUrl, chartName), installFlags.HelmRepoVersion, installFlags.HelmChartDigest,
+			)
+			if err != nil {
+				return nil, nil, err
+			}
+			params = append(params, archive)
+		} else {
+			if installFlags.HelmRepoUrl != "" {
+				params = append(params, chartName, "--repo", installFlags.HelmRepoUrl)
+			} else if installFlags.HelmRepoName != "" {
+				params = append(params, fmt.Sprintf("%s/%s", installFlags.HelmRepoName, chartName))
+			}
+			if installFlags.HelmRepoVersion != "" {
+				params = append(params, "--version", installFlags.HelmRepoVersion)
+			}
 		}
 	} else {
 		resourcesPath := a.GetResourceDir(resourceDir)
@@ -271,7 +306,7 @@ func (a *Application) upgradeForHelm(installFlags *flag.InstallFlags, fromRepo b
 		depParams := []string{"dependency", "build", resourcesPath[0]}
 		depParams = append(depParams, commonParams...)
 		if _, err = tools.ExecCommand(nil, true, false, false, "helm", depParams...); err != nil {
-			return errors.Wrap(err, "fail to build dependency for helm app")
+			return nil, nil, errors.Wrap(err, "fail to build dependency for helm app")
 		}
 	}
 
@@ -288,9 +323,5 @@ func (a *Application) upgradeForHelm(installFlags *flag.InstallFlags, fromRepo b
 	}
 	params = append(params, "--timeout", "60m")
 	params = append(params, commonParams...)
-
-	log.Info("Upgrade helm application, this may take several minutes, please waiting...")
-
-	_, err = tools.ExecCommand(nil, true, false, false, "helm", params...)
-	return errors.Wrap(err, "")
+	return params, commonParams, nil
 }
@@ -0,0 +1,244 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	flag "nocalhost/internal/nhctl/app_flags"
+	"nocalhost/internal/nhctl/appmeta"
+	"nocalhost/internal/nhctl/diff"
+	"nocalhost/internal/nhctl/fp"
+	"nocalhost/pkg/nhctl/clientgoutils"
+	"nocalhost/pkg/nhctl/log"
+	"nocalhost/pkg/nhctl/tools"
+)
+
+// the fields set by the server, which are not changed by upgrading
+var serverSetMetadata = []string{
+	"managedFields", "resourceVersion", "uid", "generation", "creationTimestamp", "selfLink",
+}
+
+var manifestSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// UpgradeDiff the unified diff of the resources from the live state to the one
+// upgraded, empty if nothing changes. Nothing is applied: the resources are
+// rendered server-side by the dry run of applying them, and the ones of helm
+// by the dry run of helm upgrade against the manifest of the release.
+// PrepareForUpgrade is required before it
+func (a *Application) UpgradeDiff(installFlags *flag.InstallFlags) (string, error) {
+	var (
+		live, upgraded map[string]string
+		err            error
+	)
+	switch a.GetType() {
+	case appmeta.HelmRepo:
+		live, upgraded, err = a.helmUpgradeManifests(installFlags, true)
+	case appmeta.Helm, appmeta.HelmLocal:
+		live, upgraded, err = a.helmUpgradeManifests(installFlags, false)
+	case appmeta.Manifest, appmeta.ManifestLocal, appmeta.ManifestGit:
+		manifests := a.GetAppMeta().GetApplicationConfig().LoadManifests(fp.NewFilePath(a.ResourceTmpDir))
+		live, upgraded, err = a.dryRunUpgrade(clientgoutils.NewManifestResourceReader(manifests), true)
+	case appmeta.KustomizeGit, appmeta.KustomizeLocal:
+		var kustomizePath string
+		if kustomizePath, err = a.kustomizePath(); err != nil {
+			return "", err
+		}
+		// the resources are not pruned by upgrading kustomize
+		live, upgraded, err = a.dryRunUpgrade(clientgoutils.NewKustomizeResourceReader(kustomizePath), false)
+	default:
+		return "", errors.New("Unsupported app type")
+	}
+	if err != nil {
+		return "", err
+	}
+	return diffResources(live, upgraded), nil
+}
+
+// dryRunUpgrade the live resources and the ones upgraded by kind/name, the
+// resources not upgraded any more are removed if prune
+func (a *Application) dryRunUpgrade(
+	reader clientgoutils.ResourceReader, prune bool) (map[string]string, map[string]string, error) {
+	updateResource, err := reader.LoadResource()
+	if err != nil {
+		return nil, nil, err
+	}
+	upgradeInfos, err := updateResource.GetResourceInfo(a.client, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	live := make(map[string]string)
+	upgraded := make(map[string]string)
+	for _, info := range upgradeInfos {
+		key := infoKey(info)
+		liveObj, obj, err := a.client.DryRunApplyResourceInfo(info, StandardNocalhostMetas(a.Name, a.NameSpace))
+		if err != nil {
+			// the same as upgrading, which continues on error
+			log.WarnE(err, fmt.Sprintf("Failed to dry run upgrading resource %s", key))
+			continue
+		}
+		if liveObj != nil {
+			if live[key], err = normalizeResource(liveObj); err != nil {
+				return nil, nil, err
+			}
+		}
+		if upgraded[key], err = normalizeResource(obj); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !prune {
+		return live, upgraded, nil
+	}
+
+	oldInfos, err := a.appMeta.NewResourceReader().GetResourceInfo(a.client, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, info := range oldInfos {
+		key := infoKey(info)
+		if _, ok := upgraded[key]; ok {
+			continue
+		}
+		if err = info.Get(); err != nil {
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			return nil, nil, errors.Wrap(err, "")
+		}
+		if live[key], err = normalizeResource(info.Object); err != nil {
+			return nil, nil, err
+		}
+	}
+	return live, upgraded, nil
+}
+
+// helmUpgradeManifests the manifests of the release and the ones rendered by
+// the dry run of helm upgrade, by kind/name
+func (a *Application) helmUpgradeManifests(
+	installFlags *flag.InstallFlags, fromRepo bool) (map[string]string, map[string]string, error) {
+	params, commonParams, err := a.helmUpgradeParams(installFlags, fromRepo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// params are: upgrade release chart ...
+	getParams := append([]string{"get", "manifest", params[1]}, commonParams...)
+	liveManifest, err := tools.ExecCommand(nil, false, false, false, "helm", getParams...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fail to get the manifest of helm release")
+	}
+
+	output, err := tools.ExecCommand(nil, false, false, false, "helm", append(params, "--dry-run", "-o", "json")...)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fail to dry run helm upgrade")
+	}
+	release := struct {
+		Manifest string `json:"manifest"`
+	}{}
+	if err = json.Unmarshal([]byte(output), &release); err != nil {
+		return nil, nil, errors.Wrap(err, "fail to parse the release of helm upgrade")
+	}
+
+	live, err := splitManifest(liveManifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	upgraded, err := splitManifest(release.Manifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return live, upgraded, nil
+}
+
+// splitManifest the documents of the manifest by kind/name
+func splitManifest(manifest string) (map[string]string, error) {
+	docs := make(map[string]string)
+	for _, doc := range manifestSeparator.Split(manifest, -1) {
+		obj := struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return nil, errors.Wrap(err, "fail to parse the manifest of helm")
+		}
+		if obj.Kind == "" {
+			continue
+		}
+		docs[strings.ToLower(obj.Kind)+"/"+obj.Metadata.Name] = strings.TrimSpace(doc) + "\n"
+	}
+	return docs, nil
+}
+
+// normalizeResource the yaml of the object without the status and the fields
+// set by the server
+func normalizeResource(obj runtime.Object) (string, error) {
+	bys, err := json.Marshal(obj)
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	content := make(map[string]interface{})
+	if err = json.Unmarshal(bys, &content); err != nil {
+		return "", errors.Wrap(err, "")
+	}
+
+	delete(content, "status")
+	if metadata, ok := content["metadata"].(map[string]interface{}); ok {
+		for _, field := range serverSetMetadata {
+			delete(metadata, field)
+		}
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, corev1.LastAppliedConfigAnnotation)
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+
+	bys, err = yaml.Marshal(content)
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	return string(bys), nil
+}
+
+// diffResources the diff of the resources by kind/name, in the order of them
+func diffResources(live, upgraded map[string]string) string {
+	keys := make([]string, 0, len(live)+len(upgraded))
+	for key := range live {
+		keys = append(keys, key)
+	}
+	for key := range upgraded {
+		if _, ok := live[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		from, to := "live/"+key, "upgraded/"+key
+		if _, ok := live[key]; !ok {
+			from = "/dev/null"
+		}
+		if _, ok := upgraded[key]; !ok {
+			to = "/dev/null"
+		}
+		sb.WriteString(diff.Unified(live[key], upgraded[key], from, to))
+	}
+	return sb.String()
+}
@@ -8,6 +8,7 @@ package app_flags
 type InstallFlags struct {
 	GitUrl           string // resource url
 	GitRef           string
+	GitSshKey        string // private key of ssh pulling private repo
 	AppType          string
 	HelmValueFile    []string
 	ForceInstall     bool
@@ -17,10 +18,13 @@ type InstallFlags struct {
 	HelmRepoUrl      string
 	HelmRepoVersion  string
 	HelmChartName    string
+	HelmChartDigest  string // digest pinned of the chart from OCI registry
 	HelmWait         bool
 	OuterConfig      string
 	Config           string
 	ResourcePath     []string
+	KustomizeOverlay string   // overlay of kustomize relative to resources dir
+	KustomizeImages  []string // images overridden of kustomization
 	//Namespace        string
 	LocalPath string
 }
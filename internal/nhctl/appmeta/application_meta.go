@@ -56,6 +56,7 @@ const (
 	SecretConfigKey           = "c"
 	SecretStateKey            = "s"
 	SecretDepKey              = "d"
+	SecretBlockedByKey        = "bb"
 
 	Helm           AppType = "helmGit"
 	HelmRepo       AppType = "helmRepo"
@@ -236,6 +237,12 @@ type ApplicationMeta struct {
 	// to distinguish same ns/app when using multiple K8s cluster
 	NamespaceId string `json:"namespace_id"`
 
+	// the history of install, upgrade and rollback, the latest is the last
+	Revisions []*Revision `json:"revisions"`
+
+	// the dependency blocking the install, empty if not waiting
+	BlockedBy string `json:"blocked_by"`
+
 	// current client go util is injected, may null, be care!
 	operator *operator.ClientGoUtilClient
 }
@@ -332,6 +339,13 @@ func (a *ApplicationMeta) ReAssignmentBySecret(secret *corev1.Secret) error {
 		a.NamespaceId = string(bs)
 	}
 
+	a.Revisions = nil
+	if bs, ok := secret.Data[SecretRevisionKey]; ok {
+		_ = yaml.Unmarshal(decompress(bs), &a.Revisions)
+	}
+
+	a.BlockedBy = string(secret.Data[SecretBlockedByKey])
+
 	return nil
 }
 
@@ -788,6 +802,10 @@ func (a *ApplicationMeta) prepare() {
 
 	devMeta, _ := yaml.Marshal(&a.DevMeta)
 	a.Secret.Data[SecretDevMetaKey] = devMeta
+
+	revisions, _ := yaml.Marshal(a.Revisions)
+	a.Secret.Data[SecretRevisionKey] = compress(revisions)
+	a.Secret.Data[SecretBlockedByKey] = []byte(a.BlockedBy)
 }
 
 func (a *ApplicationMeta) IsInstalled() bool {
@@ -953,6 +971,7 @@ func (a *ApplicationMeta) Delete() error {
 	a.PreDeleteManifest = ""
 	a.PostDeleteManifest = ""
 	a.Manifest = ""
+	a.Revisions = nil
 	a.DevMeta = map[base.SvcType]map[string]string{}
 	a.UninstallBackOff = time.Now().Add(time.Second * 10).UnixNano()
 
@@ -0,0 +1,129 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package appmeta
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"github.com/pkg/errors"
+	"nocalhost/internal/nhctl/model"
+	"os"
+	"os/user"
+	"time"
+)
+
+const (
+	SecretRevisionKey = "rv"
+
+	// MaxRevisionHistory the revisions kept in the secret, the same as
+	// the default --history-max of helm
+	MaxRevisionHistory = 10
+
+	// RevisionTriggerEnv who triggers the install or upgrade, e.g. the
+	// installer job of nocalhost-api, the local user if not set
+	RevisionTriggerEnv = "NOCALHOST_REVISION_TRIGGER_BY"
+
+	RevisionInstall  RevisionAction = "install"
+	RevisionUpgrade  RevisionAction = "upgrade"
+	RevisionRollback RevisionAction = "rollback"
+)
+
+type RevisionAction string
+
+// Revision is recorded for every install, upgrade or rollback of application,
+// the manifest is kept for rolling back the manifest and kustomize
+// application, helm application is rolled back by the revision of release
+type Revision struct {
+	Revision     int            `json:"revision" yaml:"revision"`
+	Action       RevisionAction `json:"action" yaml:"action"`
+	ChartVersion string         `json:"chart_version,omitempty" yaml:"chartVersion,omitempty"`
+	HelmRevision int            `json:"helm_revision,omitempty" yaml:"helmRevision,omitempty"`
+	Values       string         `json:"values,omitempty" yaml:"values,omitempty"`
+	ManifestHash string         `json:"manifest_hash" yaml:"manifestHash"`
+	Manifest     string         `json:"manifest,omitempty" yaml:"manifest,omitempty"`
+	TriggeredBy  string         `json:"triggered_by" yaml:"triggeredBy"`
+	// RollbackTo the revision rolled back to
+	RollbackTo int   `json:"rollback_to,omitempty" yaml:"rollbackTo,omitempty"`
+	Time       int64 `json:"time" yaml:"time"`
+}
+
+func ManifestHash(manifest string) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(manifest)))
+}
+
+// RevisionTrigger the user triggering the install or upgrade
+func RevisionTrigger() string {
+	if trigger := os.Getenv(RevisionTriggerEnv); trigger != "" {
+		return trigger
+	}
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		name = name + "@" + host
+	}
+	return name
+}
+
+// CurrentRevision the latest revision of application, nil if none recorded
+func (a *ApplicationMeta) CurrentRevision() *Revision {
+	if len(a.Revisions) == 0 {
+		return nil
+	}
+	return a.Revisions[len(a.Revisions)-1]
+}
+
+func (a *ApplicationMeta) GetRevision(revision int) (*Revision, error) {
+	for _, r := range a.Revisions {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+	return nil, errors.Errorf("revision %d of application %s not found", revision, a.Application)
+}
+
+// AddRevision number the revision after the latest one and save it, the
+// oldest ones are dropped beyond MaxRevisionHistory
+func (a *ApplicationMeta) AddRevision(r *Revision) error {
+	a.Revisions = appendRevision(a.Revisions, r)
+	return a.Update()
+}
+
+func appendRevision(revisions []*Revision, r *Revision) []*Revision {
+	r.Revision = 1
+	if len(revisions) > 0 {
+		r.Revision = revisions[len(revisions)-1].Revision + 1
+	}
+	if r.Time == 0 {
+		r.Time = time.Now().Unix()
+	}
+	revisions = append(revisions, r)
+	if len(revisions) > MaxRevisionHistory {
+		revisions = revisions[len(revisions)-MaxRevisionHistory:]
+	}
+	return revisions
+}
+
+// RevisionInfos the history of application for output, the latest first
+func (a *ApplicationMeta) RevisionInfos() []*model.RevisionInfo {
+	result := make([]*model.RevisionInfo, 0, len(a.Revisions))
+	for i := len(a.Revisions) - 1; i >= 0; i-- {
+		r := a.Revisions[i]
+		result = append(
+			result, &model.RevisionInfo{
+				Revision:     r.Revision,
+				Action:       string(r.Action),
+				ChartVersion: r.ChartVersion,
+				ManifestHash: r.ManifestHash,
+				TriggeredBy:  r.TriggeredBy,
+				RollbackTo:   r.RollbackTo,
+				Time:         r.Time,
+			},
+		)
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package appmeta
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAppendRevision(t *testing.T) {
+	var revisions []*Revision
+	for i := 0; i < MaxRevisionHistory+2; i++ {
+		revisions = appendRevision(revisions, &Revision{Action: RevisionUpgrade})
+	}
+	if len(revisions) != MaxRevisionHistory {
+		t.Fatalf("%d revisions kept, expect %d", len(revisions), MaxRevisionHistory)
+	}
+	if revisions[0].Revision != 3 || revisions[len(revisions)-1].Revision != MaxRevisionHistory+2 {
+		t.Fatalf("unexpected revisions from %d to %d", revisions[0].Revision, revisions[len(revisions)-1].Revision)
+	}
+}
+
+func TestRevisionsInSecret(t *testing.T) {
+	meta := &ApplicationMeta{
+		Application:      "bookinfo",
+		ApplicationState: INSTALLED,
+		Secret:           &corev1.Secret{Data: map[string][]byte{}},
+		Revisions: []*Revision{
+			{Revision: 1, Action: RevisionInstall, Manifest: "kind: Service", ManifestHash: ManifestHash("kind: Service")},
+			{Revision: 2, Action: RevisionRollback, RollbackTo: 1},
+		},
+	}
+	meta.prepare()
+	meta.Secret.Name = SecretNamePrefix + meta.Application
+
+	decoded, err := Decode(meta.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current := decoded.CurrentRevision(); current == nil || current.RollbackTo != 1 {
+		t.Fatalf("unexpected current revision %v", current)
+	}
+	if r, err := decoded.GetRevision(1); err != nil || r.Manifest != "kind: Service" {
+		t.Fatalf("unexpected revision 1 %v %v", r, err)
+	}
+	if _, err := decoded.GetRevision(3); err == nil {
+		t.Fatal("revision 3 should not be found")
+	}
+}
@@ -270,3 +270,18 @@ func GetAllApplicationMetas() []*appmeta.ApplicationMeta {
 	)
 	return metas
 }
+
+// WatcherCount the application secret watchers of the namespaces and
+// kubeconfigs
+func WatcherCount() int {
+	count := 0
+	supervisor.deck.Range(
+		func(_, value interface{}) bool {
+			if value != nil {
+				count++
+			}
+			return true
+		},
+	)
+	return count
+}
@@ -112,6 +112,7 @@ func InstallApplication(flags *app_flags.InstallFlags, applicationName, kubeconf
 		RepoUrl:  flags.HelmRepoUrl,
 		RepoName: flags.HelmRepoName,
 		Version:  flags.HelmRepoVersion,
+		Digest:   flags.HelmChartDigest,
 	}
 
 	err = nocalhostApp.Install(flag)
@@ -26,20 +26,26 @@ import (
 )
 
 var (
-	DNS1123      = "DNS1123"
-	WorkLoads    = "WorkLoads"
-	SyncType     = "SyncType"
-	SyncMode     = "SyncMode"
-	Quantity     = "Quantity"
-	StorageClass = "StorageClass"
-	PortForward  = "PortForward"
-	Port         = "Port"
-	Container    = "Container"
-	Language     = "Language"
+	DNS1123          = "DNS1123"
+	WorkLoads        = "WorkLoads"
+	SyncType         = "SyncType"
+	SyncMode         = "SyncMode"
+	SyncEngine       = "SyncEngine"
+	ConflictStrategy = "ConflictStrategy"
+	LargeFilePolicy  = "LargeFilePolicy"
+	Quantity         = "Quantity"
+	StorageClass     = "StorageClass"
+	PortForward      = "PortForward"
+	Port             = "Port"
+	Container        = "Container"
+	Language         = "Language"
+	SidecarPolicy    = "SidecarPolicy"
 
 	SUPPORT_SC = "NOCALHOST_SUPPORT_SC"
 	CONTAINERS = "NOCALHOST_CONTAINERS"
 	validate   = validator.New()
+
+	languages = []string{"node", "java", "go", "python", "php", "ruby"}
 )
 
 func init() {
@@ -47,12 +53,16 @@ func init() {
 	_ = validate.RegisterValidationWithErrorMsg(WorkLoads, IsSupportsWorkLoads)
 	_ = validate.RegisterValidationWithErrorMsg(SyncType, IsSyncType)
 	_ = validate.RegisterValidationWithErrorMsg(SyncMode, IsSyncMode)
+	_ = validate.RegisterValidationWithErrorMsg(SyncEngine, IsSyncEngine)
+	_ = validate.RegisterValidationWithErrorMsg(ConflictStrategy, IsConflictStrategy)
+	_ = validate.RegisterValidationWithErrorMsg(LargeFilePolicy, IsLargeFilePolicy)
 	_ = validate.RegisterValidationWithErrorMsg(Quantity, IsQuantity)
 	_ = validate.RegisterValidationWithErrorMsg(StorageClass, StorageClassSupported)
 	_ = validate.RegisterValidationWithErrorMsg(PortForward, PortForwardCheck)
 	_ = validate.RegisterValidationWithErrorMsg(Port, PortCheck)
 	_ = validate.RegisterValidationWithErrorMsg(Container, ContainerCheck)
 	_ = validate.RegisterValidationWithErrorMsg(Language, LanguageCheck)
+	_ = validate.RegisterValidationWithErrorMsg(SidecarPolicy, IsSidecarPolicy)
 
 	validate.RegisterTagNameFunc(
 		func(field reflect.StructField) string {
@@ -141,6 +151,62 @@ func IsSyncMode(fl validator.FieldLevel) string {
 	)
 }
 
+func IsSyncEngine(fl validator.FieldLevel) string {
+	val := fl.Field().String()
+
+	return hintIfNoPass(
+		val == "" ||
+			val == _const.SyncthingSyncEngine ||
+			val == _const.MutagenSyncEngine,
+		func() string {
+			return fmt.Sprintf("Must be %s or %s", _const.SyncthingSyncEngine, _const.MutagenSyncEngine)
+		},
+	)
+}
+
+func IsConflictStrategy(fl validator.FieldLevel) string {
+	val := fl.Field().String()
+
+	return hintIfNoPass(
+		val == "" ||
+			val == _const.PreferLocalConflictStrategy ||
+			val == _const.PreferRemoteConflictStrategy ||
+			val == _const.KeepBothConflictStrategy,
+		func() string {
+			return fmt.Sprintf(
+				"Must be %s, %s or %s", _const.PreferLocalConflictStrategy,
+				_const.PreferRemoteConflictStrategy, _const.KeepBothConflictStrategy,
+			)
+		},
+	)
+}
+
+func IsLargeFilePolicy(fl validator.FieldLevel) string {
+	val := fl.Field().String()
+
+	return hintIfNoPass(
+		val == "" ||
+			val == _const.SkipLargeFilePolicy ||
+			val == _const.WarnLargeFilePolicy,
+		func() string {
+			return fmt.Sprintf("Must be %s or %s", _const.SkipLargeFilePolicy, _const.WarnLargeFilePolicy)
+		},
+	)
+}
+
+func IsSidecarPolicy(fl validator.FieldLevel) string {
+	val := fl.Field().String()
+
+	return hintIfNoPass(
+		val == "" ||
+			val == _const.KeepSidecarPolicy ||
+			val == _const.StripSidecarPolicy,
+		func() string {
+			return fmt.Sprintf("Must be %s or %s", _const.KeepSidecarPolicy, _const.StripSidecarPolicy)
+		},
+	)
+}
+
 func IsQuantity(fl validator.FieldLevel) string {
 	val := fl.Field().String()
 	if val == "" {
@@ -182,6 +248,11 @@ func StorageClassSupported(fl validator.FieldLevel) string {
 	}
 }
 
+// Languages the languages of debug config supported
+func Languages() []string {
+	return append([]string{}, languages...)
+}
+
 func LanguageCheck(fl validator.FieldLevel) string {
 	val := fl.Field().String()
 
@@ -189,7 +260,6 @@ func LanguageCheck(fl validator.FieldLevel) string {
 		return ""
 	}
 
-	languages := []string{"node", "java", "go", "python", "php", "ruby"}
 	set := sets.NewString(languages...)
 	return hintIfNoPass(
 		set.Has(val),
@@ -223,9 +293,10 @@ func ContainerCheck(fl validator.FieldLevel) string {
 }
 
 func PortForwardCheck(fl validator.FieldLevel) string {
-	val := fl.Field().String()
-
-	_, _, err := utils.GetPortForwardForString(val)
+	val, _, err := utils.SplitPortProtocol(fl.Field().String())
+	if err == nil {
+		_, _, err = utils.GetPortForwardForString(val)
+	}
 	return hintIfNoPass(
 		err == nil,
 		func() string {
@@ -0,0 +1,295 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package config_validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/profile"
+	customyaml3 "nocalhost/pkg/nhctl/utils/custom_yaml_v3"
+)
+
+const (
+	SchemaObject  = "object"
+	SchemaArray   = "array"
+	SchemaString  = "string"
+	SchemaInteger = "integer"
+	SchemaNumber  = "number"
+	SchemaBoolean = "boolean"
+
+	jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+)
+
+// Schema the JSON schema (draft-07) of config, which is generated by the
+// yaml tags of the structs of config, so that it never differs from what
+// nhctl actually unmarshal
+type Schema struct {
+	Schema     string             `json:"$schema,omitempty"`
+	Title      string             `json:"title,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	// AdditionalProperties false for structs, the schema of values for maps
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+}
+
+// SchemaError the error of config with its location, e.g.
+// line 14: unknown field syncDirs
+type SchemaError struct {
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return "[Configuration Schema Error]: \n" + strings.Join(messages, "\n")
+}
+
+// enums the values of fields validated by enum, other validations such as
+// DNS1123 or Quantity are still done by Validate
+var enums = map[string][]string{
+	SyncType:   {_const.DefaultSyncType, _const.SendOnlySyncType, _const.SendOnlySyncTypeAlias},
+	SyncMode:   {_const.PatternMode, _const.GitIgnoreMode},
+	SyncEngine: {_const.SyncthingSyncEngine, _const.MutagenSyncEngine},
+	ConflictStrategy: {
+		_const.PreferLocalConflictStrategy, _const.PreferRemoteConflictStrategy, _const.KeepBothConflictStrategy,
+	},
+	LargeFilePolicy: {_const.SkipLargeFilePolicy, _const.WarnLargeFilePolicy},
+	Language:        languages,
+	SidecarPolicy:   {_const.KeepSidecarPolicy, _const.StripSidecarPolicy},
+}
+
+// AppConfigSchema the schema of .nocalhost/config.yaml
+func AppConfigSchema() *Schema {
+	schema := SchemaOf(&profile.NocalHostAppConfigV2{})
+	schema.Schema = jsonSchemaDraft
+	schema.Title = "Nocalhost application config"
+	return schema
+}
+
+// SchemaOf generate the schema of the struct
+func SchemaOf(v interface{}) *Schema {
+	return schemaOfType(reflect.TypeOf(v))
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		schema := &Schema{Type: SchemaObject, Properties: map[string]*Schema{}, AdditionalProperties: false}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := fieldName(field)
+			if name == "" {
+				continue
+			}
+			property := schemaOfType(field.Type)
+			tag := field.Tag.Get("validate")
+			if values, ok := enums[tag]; ok {
+				property.Enum = values
+			} else if strings.HasPrefix(tag, "oneof=") {
+				property.Enum = strings.Fields(strings.TrimPrefix(tag, "oneof="))
+			}
+			schema.Properties[name] = property
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: SchemaArray, Items: schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: SchemaObject, AdditionalProperties: schemaOfType(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: SchemaString}
+	case reflect.Bool:
+		return &Schema{Type: SchemaBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: SchemaInteger}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaNumber}
+	default:
+		// interface{} such as helmVals accepts anything
+		return &Schema{}
+	}
+}
+
+// fieldName the name of field in yaml, empty if it is not unmarshalled
+func fieldName(field reflect.StructField) string {
+	if field.PkgPath != "" {
+		return ""
+	}
+	tag := field.Tag.Get("yaml")
+	if tag == "" {
+		tag = field.Tag.Get("json")
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// ValidateAppConfig validate .nocalhost/config.yaml against AppConfigSchema,
+// the config of v1 is skipped as it is converted to v2 before used
+func ValidateAppConfig(content []byte) error {
+	version := struct {
+		ConfigProperties profile.ConfigProperties `yaml:"configProperties"`
+	}{}
+	if err := customyaml3.Unmarshal(content, &version); err == nil && version.ConfigProperties.Version == "v1" {
+		return nil
+	}
+	return ValidateSchema(content, AppConfigSchema())
+}
+
+// ValidateSchema validate the yaml content against the schema, the errors of
+// all fields are returned as SchemaErrors
+func ValidateSchema(content []byte, schema *Schema) error {
+	node := customyaml3.Node{}
+	if err := customyaml3.Unmarshal(content, &node); err != nil {
+		return errors.Wrap(err, "invalid yaml")
+	}
+	if len(node.Content) == 0 {
+		return nil
+	}
+
+	var result SchemaErrors
+	schema.validate(node.Content[0], "", &result)
+	if len(result) > 0 {
+		return result
+	}
+	return nil
+}
+
+func (s *Schema) validate(node *customyaml3.Node, path string, result *SchemaErrors) {
+	if node.Kind == customyaml3.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*result = append(
+			*result, &SchemaError{
+				Line: node.Line, Column: node.Column, Field: path, Message: fmt.Sprintf(format, args...),
+			},
+		)
+	}
+
+	switch s.Type {
+	case SchemaObject:
+		if node.Kind != customyaml3.MappingNode {
+			fail("%s must be object", fieldOrRoot(path))
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			property, ok := s.Properties[key.Value]
+			if !ok {
+				if additional, isSchema := s.AdditionalProperties.(*Schema); isSchema {
+					property = additional
+				} else {
+					*result = append(
+						*result, &SchemaError{
+							Line: key.Line, Column: key.Column, Field: joinField(path, key.Value),
+							Message: "unknown field " + key.Value,
+						},
+					)
+					continue
+				}
+			}
+			property.validate(value, joinField(path, key.Value), result)
+		}
+	case SchemaArray:
+		if node.Kind != customyaml3.SequenceNode {
+			fail("%s must be array", fieldOrRoot(path))
+			return
+		}
+		for i, item := range node.Content {
+			s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), result)
+		}
+	case SchemaString, SchemaInteger, SchemaNumber, SchemaBoolean:
+		if node.Kind != customyaml3.ScalarNode {
+			fail("%s must be %s", fieldOrRoot(path), s.Type)
+			return
+		}
+		if !scalarMatches(s.Type, node) {
+			fail("%s must be %s, but got %s", fieldOrRoot(path), s.Type, node.Value)
+			return
+		}
+		if len(s.Enum) > 0 && node.Value != "" && !contains(s.Enum, node.Value) {
+			fail("%s must be one of %s, but got %s", fieldOrRoot(path), strings.Join(s.Enum, ", "), node.Value)
+		}
+	}
+}
+
+// scalarMatches any scalar can be unmarshalled to string, and yaml 1.1
+// bools such as yes/no are accepted by typed bool
+func scalarMatches(schemaType string, node *customyaml3.Node) bool {
+	if strings.Contains(node.Value, "${") {
+		// env placeholder of config template not rendered yet
+		return true
+	}
+	switch schemaType {
+	case SchemaInteger:
+		return node.Tag == "!!int"
+	case SchemaNumber:
+		return node.Tag == "!!int" || node.Tag == "!!float"
+	case SchemaBoolean:
+		return node.Tag == "!!bool" || contains(
+			[]string{"y", "Y", "yes", "Yes", "YES", "n", "N", "no", "No", "NO", "on", "On", "ON", "off", "Off", "OFF"},
+			node.Value,
+		)
+	}
+	return true
+}
+
+func joinField(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func fieldOrRoot(path string) string {
+	if path == "" {
+		return "config"
+	}
+	return path
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package config_validate
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"nocalhost/internal/nhctl/profile"
+)
+
+const schemaTestConfig = `configProperties:
+  version: v2
+application:
+  name: bookinfo
+  manifestType: rawManifestGit
+  resourcePath: ["manifest/templates"]
+  helmVals:
+    any:
+      thing: true
+  services:
+    - name: productpage
+      serviceType: deployment
+      containers:
+        - name: productpage
+          dev:
+            image: python:3.7
+            hotReload: yes
+            debug:
+              remoteDebugPort: 9009
+              language: python
+            sync:
+              type: send
+              syncDirs: ["."]
+            portForward:
+              - 39080:9080
+`
+
+func TestValidateSchema(t *testing.T) {
+	if err := ValidateSchema([]byte(strings.Replace(schemaTestConfig, "              syncDirs: [\".\"]\n", "", 1)),
+		AppConfigSchema()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := ValidateSchema([]byte(schemaTestConfig), AppConfigSchema())
+	schemaErrors, ok := err.(SchemaErrors)
+	if !ok || len(schemaErrors) != 1 {
+		t.Fatalf("unexpected errors %v", err)
+	}
+	if got := schemaErrors[0].Error(); got != "line 23: unknown field syncDirs" {
+		t.Fatalf("unexpected error %s", got)
+	}
+	if schemaErrors[0].Field != "application.services[0].containers[0].dev.sync.syncDirs" {
+		t.Fatalf("unexpected field %s", schemaErrors[0].Field)
+	}
+
+	invalid := strings.NewReplacer(
+		"remoteDebugPort: 9009", "remoteDebugPort: debug",
+		"language: python", "language: rust",
+		`resourcePath: ["manifest/templates"]`, "resourcePath: manifest/templates",
+	).Replace(schemaTestConfig)
+	err = ValidateSchema([]byte(invalid), AppConfigSchema())
+	if schemaErrors, ok = err.(SchemaErrors); !ok || len(schemaErrors) != 4 {
+		t.Fatalf("unexpected errors %v", err)
+	}
+	for i, expected := range []string{
+		"line 6: application.resourcePath must be array",
+		"line 19: application.services[0].containers[0].dev.debug.remoteDebugPort must be integer, but got debug",
+		"line 20: application.services[0].containers[0].dev.debug.language must be one of",
+		"line 23: unknown field syncDirs",
+	} {
+		if !strings.HasPrefix(schemaErrors[i].Error(), expected) {
+			t.Errorf("expected %s, got %s", expected, schemaErrors[i].Error())
+		}
+	}
+
+	placeholder := strings.Replace(schemaTestConfig, "remoteDebugPort: 9009", "remoteDebugPort: ${DEBUG_PORT}", 1)
+	if err := ValidateSchema([]byte(placeholder), AppConfigSchema()); len(err.(SchemaErrors)) != 1 {
+		t.Fatalf("env placeholder must be accepted, %v", err)
+	}
+	if err := ValidateAppConfig([]byte("configProperties:\n  version: v1\nname: bookinfo\n")); err != nil {
+		t.Fatalf("config of v1 must be skipped, %v", err)
+	}
+
+	if err := ValidateSchema([]byte("application: [\n"), AppConfigSchema()); err == nil {
+		t.Fatal("invalid yaml must be reported")
+	}
+}
+
+func TestSchemaOf(t *testing.T) {
+	content, err := json.Marshal(AppConfigSchema())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`"$schema":"http://json-schema.org/draft-07/schema#"`,
+		`"remoteDebugPort":{"type":"integer"}`,
+		`"additionalProperties":false`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("%s not found in schema", expected)
+		}
+	}
+
+	resourcePath := SchemaOf(&profile.ApplicationConfig{}).Properties["resourcePath"]
+	if resourcePath.Type != SchemaArray || resourcePath.Items.Type != SchemaString {
+		t.Fatalf("unexpected schema of resourcePath %v", resourcePath)
+	}
+}
@@ -18,6 +18,8 @@ const (
 	DefaultBinSyncThingDirName = "syncthing"
 	DefaultLogDirName          = "logs"
 	DefaultLogFileName         = "nhctl.log"
+	DaemonLogFileName          = "daemon.log"
+	SudoDaemonLogFileName      = "daemon-sudo.log"
 
 	NocalhostApplicationName         = "dev.nocalhost/application-name"
 	NocalhostApplicationNamespace    = "dev.nocalhost/application-namespace"
@@ -42,10 +44,23 @@ const (
 	SSHSideCarImage     = "nocalhost-docker.pkg.coding.net/nocalhost/public/nocalhost-sidecar:sshversion"
 	DefaultVPNImage     = "nocalhost-docker.pkg.coding.net/nocalhost/public/nocalhost-vpn:v1"
 
+	// the sshd of SSHSideCarImage, it logins by root/root
+	SSHSideCarPort         = 50022
+	NocalhostSSHVolumeName = "nocalhost-ssh"
+	NocalhostSSHAgentDir   = "/var/run/nocalhost-ssh"
+
+	// the udp relay of SSHSideCarImage, the datagrams of udp port-forward
+	// are relayed by it to the ports of pod
+	UDPRelayPort = 50023
+
 	DefaultApplicationSyncPidFile = "syncthing.pid"
 
 	EnableFullLogEnvKey = "NH_FULL_LOG"
 
+	// PreviousDaemonPidEnvKey the pid of daemon upgraded, the new daemon waits
+	// for it to exit before recovering its port-forwards
+	PreviousDaemonPidEnvKey = "_NOCALHOST_PREVIOUS_DAEMON_PID_"
+
 	// default is a special app type, it can be uninstalled neither installed
 	// it's a virtual application to managed that those manifest out of Nocalhost management
 	DefaultNocalhostApplication           = "default.application"
@@ -87,6 +102,30 @@ const (
 	GitIgnoreMode = "gitIgnore"
 	PatternMode   = "pattern"
 
+	// conflict strategy of sendReceive, the version of the side preferred is
+	// kept, or both the versions are kept with the suffix of the side
+	PreferLocalConflictStrategy  = "preferLocal"
+	PreferRemoteConflictStrategy = "preferRemote"
+	KeepBothConflictStrategy     = "keepBoth"
+
+	// sync engine
+	SyncthingSyncEngine = "syncthing"
+	MutagenSyncEngine   = "mutagen"
+
+	// policy of the files larger than the threshold of sync
+	SkipLargeFilePolicy = "skip"
+	WarnLargeFilePolicy = "warn"
+
+	// sidecar policy, the sidecars injected by mesh are kept in the dev pod, or
+	// stripped with the injection disabled
+	KeepSidecarPolicy  = "keep"
+	StripSidecarPolicy = "strip"
+
+	// protocol of port-forward
+	TCPProtocol  = "tcp"
+	UDPProtocol  = "udp"
+	SCTPProtocol = "sctp"
+
 	banner = `
 ****************************************
 *      Nocalhost DevMode Terminal      *
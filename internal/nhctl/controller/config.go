@@ -67,11 +67,7 @@ func (c *Controller) GetDevImage(container string) string {
 }
 
 func (c *Controller) GetPersistentVolumeDirs(container string) []*profile.PersistentVolumeDir {
-	devConfig := c.config.GetContainerDevConfigOrDefault(container)
-	if devConfig != nil {
-		return devConfig.PersistentVolumeDirs
-	}
-	return nil
+	return c.config.GetContainerDevConfigOrDefault(container).GetPersistentVolumeDirs(c.GetWorkDir(container))
 }
 
 func (c *Controller) GetImagePullPolicy(container string) corev1.PullPolicy {
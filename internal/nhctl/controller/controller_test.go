@@ -8,10 +8,13 @@ package controller
 import (
 	"encoding/json"
 	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"nocalhost/internal/nhctl/profile"
 	"nocalhost/pkg/nhctl/clientgoutils"
+	"strings"
 	"testing"
 )
 
@@ -53,3 +56,135 @@ func TestDeploymentController_ReplaceImage(t *testing.T) {
 
 	fmt.Printf("%v\n", podSpec)
 }
+
+func TestExcludeNodeAffinity(t *testing.T) {
+	affinity := excludeNodeAffinity(nil, "node-1")
+	terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchFields) != 1 || terms[0].MatchFields[0].Values[0] != "node-1" {
+		t.Fatalf("node is not excluded: %v", terms)
+	}
+
+	// the node is excluded in each of the terms ORed
+	affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = append(terms, terms[0])
+	excluded := excludeNodeAffinity(affinity, "node-2")
+	for _, term := range excluded.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if len(term.MatchFields) != 2 || term.MatchFields[1].Values[0] != "node-2" {
+			t.Fatalf("node is not excluded in term: %v", term)
+		}
+	}
+	if len(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchFields) != 1 {
+		t.Fatal("the affinity given is modified")
+	}
+}
+
+func TestGetDuplicateServiceName(t *testing.T) {
+	c := &Controller{Identifier: "abcdef123456"}
+	if name := c.getDuplicateServiceName("reviews"); name != "reviews-abcde" {
+		t.Fatalf("unexpected name %s", name)
+	}
+	long := strings.Repeat("a", 56) + "-" + strings.Repeat("b", 10)
+	if name := c.getDuplicateServiceName(long); len(name) > 63 || !strings.HasSuffix(name, "a-abcde") {
+		t.Fatalf("unexpected name %s of %d", name, len(name))
+	}
+}
+
+func TestPatchDevContainerKeepsSidecars(t *testing.T) {
+	probe := &corev1.Probe{}
+	podSpec := &corev1.PodSpec{
+		Containers: []corev1.Container{
+			{Name: "istio-proxy", ReadinessProbe: probe},
+			{Name: "app", ReadinessProbe: probe},
+			{Name: "worker", ReadinessProbe: probe},
+		},
+	}
+	if index := defaultDevContainerIndex(podSpec); index != -1 {
+		t.Fatalf("app and worker are both candidates, but %d is chosen", index)
+	}
+	if index := defaultDevContainerIndex(&corev1.PodSpec{Containers: podSpec.Containers[:2]}); index != 1 {
+		t.Fatalf("app is expected, but %d is chosen", index)
+	}
+
+	dev := &corev1.Container{Name: "nocalhost-dev", ReadinessProbe: probe}
+	extra := corev1.Container{Name: "worker", ReadinessProbe: probe}
+	patchDevContainerToPodSpec(
+		podSpec, "app", dev, &corev1.Container{Name: "nocalhost-sidecar"}, nil, []corev1.Container{extra},
+	)
+	if podSpec.Containers[0].ReadinessProbe == nil {
+		t.Fatal("probe of istio-proxy is removed")
+	}
+	if podSpec.Containers[1].Name != "nocalhost-dev" || podSpec.Containers[1].ReadinessProbe != nil {
+		t.Fatalf("app is not replaced: %v", podSpec.Containers[1])
+	}
+	if podSpec.Containers[2].ReadinessProbe != nil || len(podSpec.Containers) != 4 {
+		t.Fatalf("worker is not replaced: %v", podSpec.Containers)
+	}
+}
+
+func TestMeshRoutes(t *testing.T) {
+	route := serviceRoute{
+		Origin: "reviews", Duplicate: "reviews-abcde",
+		Ports: []corev1.ServicePort{{Port: 9080}, {Port: 53, Protocol: corev1.ProtocolUDP}},
+	}
+	header := map[string]string{"x-dev": "me"}
+
+	vs, _ := json.Marshal(istioVirtualService(route, header, nil))
+	if !strings.Contains(string(vs), `"match":[{"headers":{"x-dev":{"exact":"me"}}}]`) ||
+		!strings.Contains(string(vs), `"destination":{"host":"reviews-abcde"}`) {
+		t.Fatalf("unexpected virtual service %s", vs)
+	}
+
+	routes := linkerdHTTPRoutes(route, header, nil)
+	if len(routes) != 1 {
+		t.Fatalf("only the tcp port is expected, but %d routes", len(routes))
+	}
+	hr, _ := json.Marshal(routes[0])
+	if !strings.Contains(string(hr), `"backendRefs":[{"name":"reviews-abcde","port":9080}]`) {
+		t.Fatalf("unexpected http route %s", hr)
+	}
+	if CheckMeshProvider("consul") == nil || CheckMeshProvider(MeshProviderIstio) != nil {
+		t.Fatal("unexpected check of mesh provider")
+	}
+}
+
+func TestOverrideResourceLimits(t *testing.T) {
+	r := &corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+	}
+	if err := overrideResourceLimits(r, "4", "2Gi"); err != nil {
+		t.Fatal(err)
+	}
+	if r.Limits.Cpu().String() != "4" || r.Limits.Memory().String() != "2Gi" {
+		t.Errorf("unexpected limits %v", r.Limits)
+	}
+	if r.Requests.Cpu().String() != "200m" || r.Requests.Memory().String() != "2Gi" {
+		t.Errorf("the requests higher than limits should be lowered, got %v", r.Requests)
+	}
+	if err := overrideResourceLimits(r, "", "1x"); err == nil {
+		t.Error("invalid memory should fail")
+	}
+}
+
+func TestStripMeshSidecars(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "istio-init"}, {Name: "migrate"}},
+		Containers:     []corev1.Container{{Name: "app"}, {Name: "istio-proxy"}},
+	}
+	stripMeshSidecars(podSpec)
+	if len(podSpec.Containers) != 1 || podSpec.Containers[0].Name != "app" {
+		t.Errorf("unexpected containers %v", podSpec.Containers)
+	}
+	if len(podSpec.InitContainers) != 1 || podSpec.InitContainers[0].Name != "migrate" {
+		t.Errorf("unexpected init containers %v", podSpec.InitContainers)
+	}
+
+	labels := map[string]string{istioInjectKey: "true"}
+	if !disableMeshInjectionLabel(labels) || labels[istioInjectKey] != "false" {
+		t.Errorf("the label of istio injection should be disabled, got %v", labels)
+	}
+	if disableMeshInjectionLabel(map[string]string{}) {
+		t.Error("the label absent should not be added")
+	}
+}
@@ -0,0 +1,56 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"nocalhost/internal/nhctl/devimage"
+	"nocalhost/internal/nhctl/profile"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// resolveDevImage the image specified, or the one of dev config, or the default
+// of language in the catalog distributed by nocalhost-api. The image not in the
+// catalog is refused if the catalog is enforced
+func (c *Controller) resolveDevImage(container, image string) (string, error) {
+	if image == "" {
+		if devConfig := c.config.GetContainerDevConfigOrDefault(container); devConfig != nil {
+			image = devConfig.Image
+		}
+	}
+
+	catalog, err := devimage.Get(c.Client.ClientSet, c.NameSpace)
+	if err != nil {
+		log.WarnE(err, "Failed to get the catalog of dev images")
+	}
+	if catalog == nil {
+		if image == "" {
+			image = profile.DefaultDevImage
+		}
+		return image, nil
+	}
+
+	language := c.GetDevSidecarLanguage(container)
+	if image == "" {
+		image = profile.DefaultDevImage
+		if i := catalog.Default(language); i != nil {
+			log.Infof("Using the dev image %s of %s in the catalog", i.Image, language)
+			image = i.Image
+		}
+	}
+	if !catalog.Allowed(image) {
+		return "", errors.New(
+			fmt.Sprintf(
+				"Dev image %s is not in the catalog of dev images, allowed: %s", image,
+				strings.Join(catalog.ImagesOf(language), ", "),
+			),
+		)
+	}
+	return image, nil
+}
@@ -13,17 +13,27 @@ import (
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"nocalhost/internal/nhctl/model"
 
 	//"nocalhost/internal/nhctl/common/base"
 	"nocalhost/internal/nhctl/nocalhost"
 	"nocalhost/internal/nhctl/profile"
 	secret_config "nocalhost/internal/nhctl/syncthing/secret-config"
 	"nocalhost/internal/nhctl/utils"
+	"nocalhost/pkg/nhctl/clientgoutils"
 	"nocalhost/pkg/nhctl/log"
 	"strings"
 	"time"
 )
 
+// the well-known sidecars injected into the pods, they are not chosen as the
+// dev container by default
+var sidecarContainerNames = sets.NewString(
+	"istio-proxy", "linkerd-proxy", "envoy", "envoy-sidecar", "vault-agent", "cloud-sql-proxy",
+	"fluentd", "fluent-bit", "filebeat", "promtail", "jaeger-agent", EnvoyMeshSidecarName,
+)
+
 func (c *Controller) GetDevContainerEnv(container string) *ContainerDevEnv {
 	// Find service env
 	devEnv := make([]*profile.Env, 0)
@@ -220,6 +230,12 @@ func (c *Controller) genWorkDirAndPVAndMounts(container, storageClass string, ig
 		var claimName string
 		if len(claims) == 1 { // pvc for this path found
 			claimName = claims[0].Name
+			// the pvcs unused for long are cleaned up by nhctl pvc clean --unused-for
+			if err = c.Client.AnnotatePVC(
+				claimName, map[string]string{clientgoutils.PvcLastUsedAnnotation: time.Now().Format(time.RFC3339)},
+			); err != nil {
+				log.WarnE(err, "Failed to mark the last use of pvc "+claimName)
+			}
 		} else { // no pvc for this path, create one
 			var pvc *corev1.PersistentVolumeClaim
 			if c.GetStorageClass(container) != "" {
@@ -304,7 +320,10 @@ func (c *Controller) createPvcForPersistentVolumeDir(
 	)
 
 	pvcName := fmt.Sprintf("%s-%d", c.AppName, time.Now().UnixNano())
-	annotations := map[string]string{_const.PersistentVolumeDirLabel: persistentVolume.Path}
+	annotations := map[string]string{
+		_const.PersistentVolumeDirLabel:     persistentVolume.Path,
+		clientgoutils.PvcLastUsedAnnotation: time.Now().Format(time.RFC3339),
+	}
 	capacity := persistentVolume.Capacity
 	if persistentVolume.Capacity == "" {
 		capacity = "10Gi"
@@ -401,7 +420,8 @@ func generateSideCarContainer(sidecarImage, workDir string, sshUsed bool) corev1
 		var rootUID int64 = 0
 		sideCarContainer.SecurityContext = &corev1.SecurityContext{RunAsUser: &rootUID}
 		sideCarContainer.Args = []string{
-			"rc-service sshd restart && unset STGUIADDRESS && cp " + secret_config.DefaultSyncthingSecretHome +
+			"rc-service sshd restart && (/bin/nocalhost-udp-relay > /dev/null 2>&1 &) && unset STGUIADDRESS && cp " +
+				secret_config.DefaultSyncthingSecretHome +
 				"/* " + secret_config.DefaultSyncthingHome +
 				"/ && /bin/entrypoint.sh && /bin/syncthing -home /var/syncthing",
 		}
@@ -463,6 +483,39 @@ func convertResourceQuota(quota *profile.ResourceQuota) (*corev1.ResourceRequire
 	return requirements, nil
 }
 
+// overrideResourceLimits the limits of cpu and memory override the ones of r,
+// the requests higher than the limits are lowered to them
+func overrideResourceLimits(r *corev1.ResourceRequirements, cpu, memory string) error {
+	limits, err := convertToResourceList(cpu, memory)
+	if err != nil {
+		return err
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	if r.Limits == nil {
+		r.Limits = corev1.ResourceList{}
+	}
+	for name, limit := range limits {
+		r.Limits[name] = limit
+		if request, ok := r.Requests[name]; ok && request.Cmp(limit) > 0 {
+			r.Requests[name] = limit
+		}
+	}
+	log.Infof("DevContainer uses resource limits %s", strings.Join(resourceListString(limits), ", "))
+	return nil
+}
+
+func resourceListString(l corev1.ResourceList) []string {
+	s := make([]string, 0, len(l))
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if q, ok := l[name]; ok {
+			s = append(s, q.String()+" "+string(name))
+		}
+	}
+	return s
+}
+
 func convertToResourceList(cpu string, mem string) (corev1.ResourceList, error) {
 	requestMap := make(map[corev1.ResourceName]resource.Quantity, 0)
 	if mem != "" {
@@ -608,9 +661,14 @@ func (c *Controller) sidecarContainerSSHUsed(DevLanguage string, DevImage string
 }
 
 func (c *Controller) genContainersAndVolumes(podSpec *corev1.PodSpec,
-	containerName, devImage, storageClass string, duplicateDevMode bool) (*corev1.Container,
+	ops *model.DevStartOptions, duplicateDevMode bool) (*corev1.Container,
 	*corev1.Container, []corev1.Volume, error) {
 
+	containerName, devImage, storageClass := ops.Container, ops.DevImage, ops.StorageClass
+	// the sidecars stripped are removed from the pod spec the dev container is patched to
+	if c.GetSidecarPolicy(ops) == _const.StripSidecarPolicy {
+		stripMeshSidecars(podSpec)
+	}
 	devContainer, err := findDevContainerInPodSpec(podSpec, containerName)
 	if err != nil {
 		return nil, nil, nil, err
@@ -646,12 +704,25 @@ func (c *Controller) genContainersAndVolumes(podSpec *corev1.PodSpec,
 	devModeVolumes = append(devModeVolumes, workDirAndPersistVolumes...)
 	devModeMounts = append(devModeMounts, workDirAndPersistVolumeMounts...)
 
-	if devImage == "" {
-		devImage = c.GetDevImage(containerName) // Default : replace the first container
+	// Default : replace the first container
+	if devImage, err = c.resolveDevImage(containerName, devImage); err != nil {
+		return nil, nil, nil, err
 	}
 
-	sideCarContainer := generateSideCarContainer(c.GetDevSidecarImage(containerName), workDir,
-		c.sidecarContainerSSHUsed(c.GetDevSidecarLanguage(containerName), devImage))
+	sshUsed := ops.SSH || c.sidecarContainerSSHUsed(c.GetDevSidecarLanguage(containerName), devImage)
+	sideCarContainer := generateSideCarContainer(c.GetDevSidecarImage(containerName), workDir, sshUsed)
+	if sshUsed {
+		// the socket of the agent forwarded by the sshd of sidecar is shared
+		// with the dev container, see `nhctl ssh`
+		sshVol := corev1.Volume{
+			Name:         _const.NocalhostSSHVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}
+		devModeVolumes = append(devModeVolumes, sshVol)
+		devModeMounts = append(
+			devModeMounts, corev1.VolumeMount{Name: sshVol.Name, MountPath: _const.NocalhostSSHAgentDir},
+		)
+	}
 
 	devContainer.Image = devImage
 	devContainer.Name = c.GetDevContainerName(containerName)
@@ -681,6 +752,9 @@ func (c *Controller) genContainersAndVolumes(podSpec *corev1.PodSpec,
 	if requirements != nil {
 		devContainer.Resources = *requirements
 	}
+	if err = overrideResourceLimits(&devContainer.Resources, ops.Cpu, ops.Memory); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "Invalid resource limits of dev container")
+	}
 
 	if IsResourcesLimitTooLow(&devContainer.Resources) {
 		limits := ""
@@ -697,7 +771,8 @@ func (c *Controller) genContainersAndVolumes(podSpec *corev1.PodSpec,
 		}
 		log.PWarnf(
 			`Resources Limits: %s is less than the recommended minimum: 2 cpu, 2Gi memory. `+
-				"Running programs in DevContainer may fail. You can increase Resource Limits in Nocalhost Config",
+				"Running programs in DevContainer may fail. "+
+				"You can increase Resource Limits in Nocalhost Config, or by --cpu and --memory of dev start",
 			limits,
 		)
 	}
@@ -712,7 +787,8 @@ func (c *Controller) genContainersAndVolumes(podSpec *corev1.PodSpec,
 }
 
 func patchDevContainerToPodSpec(podSpec *corev1.PodSpec, containerName string, devContainer,
-	sidecarContainer *corev1.Container, devModeVolumes []corev1.Volume) {
+	sidecarContainer *corev1.Container, devModeVolumes []corev1.Volume, extraContainers []corev1.Container) {
+	devContainers := sets.NewString(devContainer.Name)
 	if containerName != "" {
 		for index, c := range podSpec.Containers {
 			if c.Name == containerName {
@@ -720,8 +796,17 @@ func patchDevContainerToPodSpec(podSpec *corev1.PodSpec, containerName string, d
 				break
 			}
 		}
-	} else {
-		podSpec.Containers[0] = *devContainer
+	} else if index := defaultDevContainerIndex(podSpec); index >= 0 {
+		podSpec.Containers[index] = *devContainer
+	}
+	for _, extra := range extraContainers {
+		devContainers.Insert(extra.Name)
+		for index, c := range podSpec.Containers {
+			if c.Name == extra.Name {
+				podSpec.Containers[index] = extra
+				break
+			}
+		}
 	}
 
 	// Add volumes to deployment spec
@@ -730,8 +815,11 @@ func patchDevContainerToPodSpec(podSpec *corev1.PodSpec, containerName string, d
 	}
 	podSpec.Volumes = append(podSpec.Volumes, devModeVolumes...)
 
-	// disable readiness probes
+	// disable probes of dev containers, the others, e.g. istio-proxy, are kept intact
 	for i := 0; i < len(podSpec.Containers); i++ {
+		if !devContainers.Has(podSpec.Containers[i].Name) {
+			continue
+		}
 		podSpec.Containers[i].LivenessProbe = nil
 		podSpec.Containers[i].ReadinessProbe = nil
 		podSpec.Containers[i].StartupProbe = nil
@@ -740,6 +828,76 @@ func patchDevContainerToPodSpec(podSpec *corev1.PodSpec, containerName string, d
 	podSpec.Containers = append(podSpec.Containers, *sidecarContainer)
 }
 
+// genExtraDevContainers the other containers entering DevMode with the dev
+// container, each of them runs its own dev image, and shares the work dir and
+// the volumes of DevMode with the dev container, their names are kept
+func (c *Controller) genExtraDevContainers(podSpec *corev1.PodSpec, ops *model.DevStartOptions,
+	devContainer *corev1.Container, devModeVolumes []corev1.Volume) ([]corev1.Container, error) {
+	if len(ops.ExtraContainers) == 0 {
+		return nil, nil
+	}
+
+	devModeVolumeNames := sets.NewString()
+	for _, v := range devModeVolumes {
+		devModeVolumeNames.Insert(v.Name)
+	}
+	sharedMounts := make([]corev1.VolumeMount, 0)
+	for _, m := range devContainer.VolumeMounts {
+		if devModeVolumeNames.Has(m.Name) || m.MountPath == devContainer.WorkingDir {
+			sharedMounts = append(sharedMounts, m)
+		}
+	}
+
+	extraContainers := make([]corev1.Container, 0, len(ops.ExtraContainers))
+	names := sets.NewString()
+	for _, name := range ops.ExtraContainers {
+		if name == ops.Container || name == devContainer.Name || names.Has(name) {
+			return nil, errors.New(fmt.Sprintf("Container %s is specified more than once", name))
+		}
+		names.Insert(name)
+		if sidecarContainerNames.Has(name) || name == _const.NocalhostDefaultDevSidecarName {
+			log.Warnf("Container %s is a sidecar, entering DevMode on it may break the pod", name)
+		}
+
+		container, err := findDevContainerInPodSpec(podSpec, name)
+		if err != nil {
+			return nil, err
+		}
+		extra := *container.DeepCopy()
+		if extra.Image, err = c.resolveDevImage(name, ""); err != nil {
+			return nil, err
+		}
+		extra.Command = []string{"/bin/sh", "-c", "tail -f /dev/null"}
+		extra.Args = nil
+		extra.WorkingDir = devContainer.WorkingDir
+		extra.ImagePullPolicy = c.GetImagePullPolicy(name)
+		for _, v := range c.GetDevContainerEnv(name).DevEnv {
+			extra.Env = append(extra.Env, corev1.EnvVar{Name: v.Name, Value: v.Value})
+		}
+		if requirements := c.genResourceReq(name); requirements != nil {
+			extra.Resources = *requirements
+		}
+
+		// the mounts of the same paths are replaced by the shared ones
+		mounts := make([]corev1.VolumeMount, 0, len(extra.VolumeMounts)+len(sharedMounts))
+		for _, m := range extra.VolumeMounts {
+			replaced := false
+			for _, shared := range sharedMounts {
+				if m.MountPath == shared.MountPath {
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				mounts = append(mounts, m)
+			}
+		}
+		extra.VolumeMounts = append(mounts, sharedMounts...)
+		extraContainers = append(extraContainers, extra)
+	}
+	return extraContainers, nil
+}
+
 // IsResourcesLimitTooLow
 // Check if resource limit is lower than 2 cpu, 2Gi men
 func IsResourcesLimitTooLow(r *corev1.ResourceRequirements) bool {
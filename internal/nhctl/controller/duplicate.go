@@ -24,6 +24,7 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes"
@@ -71,6 +72,14 @@ func (c *Controller) ReplaceDuplicateModeImage(ctx context.Context, ops *model.D
 
 	RemoveUselessInfo(um)
 
+	// the services selecting the original pods are duplicated for the duplicate
+	originLabels := map[string]string{}
+	if pt, err := GetPodTemplateFromSpecPath(c.DevModeAction.PodTemplatePath, um.Object); err == nil {
+		for k, v := range pt.Labels {
+			originLabels[k] = v
+		}
+	}
+
 	var podTemplate *v1.PodTemplateSpec
 	var podTemplateOrigin *v1.PodTemplateSpec
 	if !c.DevModeAction.Create {
@@ -88,17 +97,23 @@ func (c *Controller) ReplaceDuplicateModeImage(ctx context.Context, ops *model.D
 		}
 
 		podTemplate.Labels = c.getDuplicateLabelsMap()
-		podTemplate.Annotations = c.getDevContainerAnnotations(ops.Container, podTemplate.Annotations)
+		podTemplate.Annotations = c.getDevContainerAnnotations(ops, podTemplate.Annotations)
 
 		devContainer, sideCarContainer, devModeVolumes, err :=
-			c.genContainersAndVolumes(&podTemplate.Spec, ops.Container, ops.DevImage, ops.StorageClass, true)
+			c.genContainersAndVolumes(&podTemplate.Spec, ops, true)
+		if err != nil {
+			return err
+		}
+		extraContainers, err := c.genExtraDevContainers(&podTemplate.Spec, ops, devContainer, devModeVolumes)
 		if err != nil {
 			return err
 		}
 
-		patchDevContainerToPodSpec(&podTemplate.Spec, ops.Container, devContainer, sideCarContainer, devModeVolumes)
-		// add envoy sidecar
-		if len(ops.MeshHeader) != 0 {
+		patchDevContainerToPodSpec(
+			&podTemplate.Spec, ops.Container, devContainer, sideCarContainer, devModeVolumes, extraContainers,
+		)
+		// add envoy sidecar, the routes of istio or linkerd are used instead if specified
+		if len(ops.MeshHeader) != 0 && ops.MeshProvider == "" {
 			err = createMeshManagerIfNotExist(ctx, c.Client.ClientSet, c.NameSpace)
 			if err != nil {
 				return err
@@ -189,7 +204,7 @@ func (c *Controller) ReplaceDuplicateModeImage(ctx context.Context, ops *model.D
 		if podTemplate, err = GetPodTemplateFromSpecPath(c.DevModeAction.PodTemplatePath, um.Object); err != nil {
 			return err
 		}
-		podTemplate.Annotations = c.getDevContainerAnnotations(ops.Container, podTemplate.Annotations)
+		podTemplate.Annotations = c.getDevContainerAnnotations(ops, podTemplate.Annotations)
 		genDeploy := &appsv1.Deployment{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:   c.getDuplicateResourceName(),
@@ -206,14 +221,21 @@ func (c *Controller) ReplaceDuplicateModeImage(ctx context.Context, ops *model.D
 
 		devContainer, sideCarContainer, devModeVolumes, err :=
 			c.genContainersAndVolumes(
-				&genDeploy.Spec.Template.Spec, ops.Container, ops.DevImage, ops.StorageClass, true,
+				&genDeploy.Spec.Template.Spec, ops, true,
 			)
 		if err != nil {
 			return err
 		}
+		extraContainers, err := c.genExtraDevContainers(
+			&genDeploy.Spec.Template.Spec, ops, devContainer, devModeVolumes,
+		)
+		if err != nil {
+			return err
+		}
 
 		patchDevContainerToPodSpec(
 			&genDeploy.Spec.Template.Spec, ops.Container, devContainer, sideCarContainer, devModeVolumes,
+			extraContainers,
 		)
 
 		genDeploy.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyAlways
@@ -231,10 +253,91 @@ func (c *Controller) ReplaceDuplicateModeImage(ctx context.Context, ops *model.D
 	delete(podTemplate.Labels, "pod-template-hash")
 	c.devModePodLabels = podTemplate.Labels
 
+	routes, err := c.createDuplicateServices(originLabels)
+	if err != nil {
+		log.WarnE(err, "Failed to create services of duplicate")
+	}
+	if len(ops.MeshHeader) != 0 && ops.MeshProvider != "" {
+		if err = c.createMeshRoutes(ops.MeshProvider, ops.MeshHeader, routes); err != nil {
+			return err
+		}
+	}
+
 	c.waitDevPodToBeReady()
 	return nil
 }
 
+func (c *Controller) getDuplicateServiceName(origin string) string {
+	name := fmt.Sprintf("%s-%s", origin, c.Identifier[0:5])
+	if len(name) > 63 {
+		name = strings.TrimRight(origin[:57], "-") + "-" + c.Identifier[0:5]
+	}
+	return name
+}
+
+// createDuplicateServices create a service for each of the ones selecting the
+// original pods, which selects the duplicate only, so that the developer
+// reaches it by the service, and the traffic of others is left to the original
+func (c *Controller) createDuplicateServices(originLabels map[string]string) ([]serviceRoute, error) {
+	routes := make([]serviceRoute, 0)
+	if len(originLabels) == 0 {
+		return routes, nil
+	}
+	services, err := c.Client.ListServices()
+	if err != nil {
+		return routes, err
+	}
+	for _, svc := range services {
+		if len(svc.Spec.Selector) == 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(originLabels)) {
+			continue
+		}
+		if _, ok := svc.Labels[IdentifierKey]; ok {
+			continue
+		}
+
+		ports := make([]v1.ServicePort, 0, len(svc.Spec.Ports))
+		for _, port := range svc.Spec.Ports {
+			port.NodePort = 0
+			ports = append(ports, port)
+		}
+		duplicate := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   c.getDuplicateServiceName(svc.Name),
+				Labels: c.getDuplicateLabelsMap(),
+			},
+			Spec: v1.ServiceSpec{
+				Ports:    ports,
+				Selector: c.getDuplicateLabelsMap(),
+				Type:     v1.ServiceTypeClusterIP,
+			},
+		}
+		route := serviceRoute{Origin: svc.Name, Duplicate: duplicate.Name, Ports: ports}
+		if _, err = c.Client.CreateService(duplicate); err != nil {
+			if k8serrors.IsAlreadyExists(errors.Cause(err)) {
+				routes = append(routes, route)
+				continue
+			}
+			return routes, err
+		}
+		log.Infof("Service %s routes to the duplicate only, the one of %s is untouched", duplicate.Name, svc.Name)
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+func (c *Controller) deleteDuplicateServices() {
+	services, err := c.Client.Labels(c.getDuplicateLabelsMap()).ListServices()
+	if err != nil {
+		log.WarnE(err, "Failed to list services of duplicate")
+		return
+	}
+	for _, svc := range services {
+		if err = c.Client.DeleteService(svc.Name); err != nil {
+			log.WarnE(err, "")
+		}
+	}
+}
+
 func addAnnotationToDuplicate(podTemplate *v1.PodTemplateSpec, uuid string, header map[string]string) {
 	var k, v string
 	for k, v = range header {
@@ -72,15 +72,21 @@ func (r *DuplicateRawPodController) ReplaceImage(ctx context.Context, ops *model
 	originalPod.Labels = labelsMap
 	originalPod.Status = corev1.PodStatus{}
 	originalPod.ResourceVersion = ""
-	originalPod.Annotations = r.getDevContainerAnnotations(ops.Container, originalPod.Annotations)
+	originalPod.Annotations = r.getDevContainerAnnotations(ops, originalPod.Annotations)
 
 	devContainer, sideCarContainer, devModeVolumes, err :=
-		r.genContainersAndVolumes(&originalPod.Spec, ops.Container, ops.DevImage, ops.StorageClass, true)
+		r.genContainersAndVolumes(&originalPod.Spec, ops, true)
+	if err != nil {
+		return err
+	}
+	extraContainers, err := r.genExtraDevContainers(&originalPod.Spec, ops, devContainer, devModeVolumes)
 	if err != nil {
 		return err
 	}
 
-	patchDevContainerToPodSpec(&originalPod.Spec, ops.Container, devContainer, sideCarContainer, devModeVolumes)
+	patchDevContainerToPodSpec(
+		&originalPod.Spec, ops.Container, devContainer, sideCarContainer, devModeVolumes, extraContainers,
+	)
 
 	log.Info("Create duplicate dev pod...")
 	if _, err = r.Client.CreatePod(originalPod); err != nil {
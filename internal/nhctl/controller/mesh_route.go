@@ -0,0 +1,207 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"nocalhost/pkg/nhctl/clientgoutils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const (
+	MeshProviderIstio   = "istio"
+	MeshProviderLinkerd = "linkerd"
+
+	istioVirtualServiceType = "virtualservices.v1beta1.networking.istio.io"
+	linkerdHTTPRouteType    = "httproutes.v1beta2.policy.linkerd.io"
+)
+
+// serviceRoute the service selecting the original pods, and the one of the
+// duplicate created for it
+type serviceRoute struct {
+	Origin    string
+	Duplicate string
+	Ports     []v1.ServicePort
+}
+
+// CheckMeshProvider the provider is supported, the empty one is the envoy
+// sidecar of nocalhost
+func CheckMeshProvider(provider string) error {
+	switch provider {
+	case "", MeshProviderIstio, MeshProviderLinkerd:
+		return nil
+	}
+	return errors.New(
+		fmt.Sprintf("Unsupported mesh provider %s, such as: %s,%s", provider, MeshProviderIstio, MeshProviderLinkerd),
+	)
+}
+
+// createMeshRoutes route the requests carrying all the headers to the
+// duplicate services, the others still go to the original services
+func (c *Controller) createMeshRoutes(provider string, header map[string]string, routes []serviceRoute) error {
+	if len(routes) == 0 {
+		log.Warn("No service selects the workload, no route of mesh is created")
+		return nil
+	}
+	for _, route := range routes {
+		var objs []map[string]interface{}
+		switch provider {
+		case MeshProviderIstio:
+			c.warnIfVirtualServiceExists(route.Origin)
+			objs = []map[string]interface{}{istioVirtualService(route, header, c.getDuplicateLabelsMap())}
+		case MeshProviderLinkerd:
+			objs = linkerdHTTPRoutes(route, header, c.getDuplicateLabelsMap())
+		default:
+			return CheckMeshProvider(provider)
+		}
+
+		for _, obj := range objs {
+			bys, err := json.Marshal(obj)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			infos, err := c.Client.GetResourceInfoFromString(string(bys), true)
+			if err != nil {
+				return err
+			}
+			if len(infos) != 1 {
+				return errors.New(fmt.Sprintf("ResourceInfo' num is %d(not 1?)", len(infos)))
+			}
+			if err = c.Client.ApplyResourceInfo(infos[0], nil); err != nil {
+				return err
+			}
+		}
+		log.Infof("Requests to %s with headers %v are routed to %s", route.Origin, header, route.Duplicate)
+	}
+	return nil
+}
+
+// warnIfVirtualServiceExists the virtual services of the same host are not
+// merged by the sidecars of istio, the route may be ignored
+func (c *Controller) warnIfVirtualServiceExists(host string) {
+	infos, err := c.Client.ListResourceInfo(istioVirtualServiceType)
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		um, ok := info.Object.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if _, ok = um.GetLabels()[IdentifierKey]; ok {
+			continue
+		}
+		hosts, _, _ := unstructured.NestedStringSlice(um.Object, "spec", "hosts")
+		for _, h := range hosts {
+			if h == host {
+				log.Warnf("VirtualService %s of %s exists, the route of DevMode may be ignored by istio", um.GetName(), host)
+			}
+		}
+	}
+}
+
+func (c *Controller) deleteMeshRoutes() {
+	for _, t := range []string{istioVirtualServiceType, linkerdHTTPRouteType} {
+		// the resource types are not found if the mesh is not installed
+		infos, err := c.Client.Labels(c.getDuplicateLabelsMap()).ListResourceInfo(t)
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			if err = clientgoutils.DeleteResourceInfo(info); err != nil {
+				log.WarnE(err, "")
+			}
+		}
+	}
+}
+
+func sortedHeaderKeys(header map[string]string) []string {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func istioVirtualService(route serviceRoute, header map[string]string, labels map[string]string) map[string]interface{} {
+	headers := map[string]interface{}{}
+	for k, v := range header {
+		headers[k] = map[string]interface{}{"exact": v}
+	}
+	return map[string]interface{}{
+		"apiVersion": "networking.istio.io/v1beta1",
+		"kind":       "VirtualService",
+		"metadata":   map[string]interface{}{"name": route.Duplicate, "labels": labels},
+		"spec": map[string]interface{}{
+			"hosts": []string{route.Origin},
+			"http": []interface{}{
+				map[string]interface{}{
+					"match": []interface{}{map[string]interface{}{"headers": headers}},
+					"route": []interface{}{
+						map[string]interface{}{"destination": map[string]interface{}{"host": route.Duplicate}},
+					},
+				},
+				map[string]interface{}{
+					"route": []interface{}{
+						map[string]interface{}{"destination": map[string]interface{}{"host": route.Origin}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// linkerdHTTPRoutes one route for each port of the service, the backends of
+// linkerd are referred by port
+func linkerdHTTPRoutes(route serviceRoute, header map[string]string, labels map[string]string) []map[string]interface{} {
+	headers := make([]interface{}, 0, len(header))
+	for _, k := range sortedHeaderKeys(header) {
+		headers = append(headers, map[string]interface{}{"name": k, "value": header[k]})
+	}
+	objs := make([]map[string]interface{}, 0, len(route.Ports))
+	for _, port := range route.Ports {
+		if port.Protocol != "" && port.Protocol != v1.ProtocolTCP {
+			continue
+		}
+		objs = append(
+			objs, map[string]interface{}{
+				"apiVersion": "policy.linkerd.io/v1beta2",
+				"kind":       "HTTPRoute",
+				"metadata": map[string]interface{}{
+					"name": fmt.Sprintf("%s-%d", route.Duplicate, port.Port), "labels": labels,
+				},
+				"spec": map[string]interface{}{
+					"parentRefs": []interface{}{
+						map[string]interface{}{
+							"name": route.Origin, "kind": "Service", "group": "core", "port": port.Port,
+						},
+					},
+					"rules": []interface{}{
+						map[string]interface{}{
+							"matches": []interface{}{map[string]interface{}{"headers": headers}},
+							"backendRefs": []interface{}{
+								map[string]interface{}{"name": route.Duplicate, "port": port.Port},
+							},
+						},
+						map[string]interface{}{
+							"backendRefs": []interface{}{
+								map[string]interface{}{"name": route.Origin, "port": port.Port},
+							},
+						},
+					},
+				},
+			},
+		)
+	}
+	return objs
+}
@@ -8,6 +8,7 @@ package controller
 import (
 	"fmt"
 	"github.com/pkg/errors"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/daemon_client"
 	"nocalhost/internal/nhctl/model"
 	"nocalhost/internal/nhctl/profile"
@@ -119,6 +120,25 @@ func (c *Controller) UpdatePortForwardStatus(localPort int, remotePort int, port
 	)
 }
 
+// RecordPortForwardReconnect the port-forward is reconnecting for the reason,
+// the times of reconnecting are counted
+func (c *Controller) RecordPortForwardReconnect(localPort int, remotePort int, reason string) error {
+	return c.UpdateSvcProfile(
+		func(svcProfile *profile.SvcProfileV2) error {
+			for _, portForward := range svcProfile.DevPortForwardList {
+				if portForward.LocalPort == localPort && portForward.RemotePort == remotePort {
+					portForward.Status = "RECONNECTING"
+					portForward.Reason = reason
+					portForward.Reconnects++
+					portForward.Updated = time.Now().Format("2006-01-02 15:04:05")
+					break
+				}
+			}
+			return nil
+		},
+	)
+}
+
 // GetPortForward If not found return err
 func (c *Controller) GetPortForward(localPort, remotePort int) (*profile.DevPortForward, error) {
 	svcProfile, err := c.GetProfile()
@@ -147,13 +167,22 @@ func (c *Controller) PortForwardAfterDevStart(podName, containerName string) err
 	}
 
 	for _, pf := range cc.PortForward {
-		lPort, rPort, err := utils.GetPortForwardForString(pf)
+		port, protocol, err := utils.SplitPortProtocol(pf)
 		if err != nil {
 			log.WarnE(err, "")
 			continue
 		}
-		log.Infof("Forwarding %d:%d", lPort, rPort)
-		utils.Should(c.PortForward(podName, lPort, rPort, ""))
+		lPort, rPort, err := utils.GetPortForwardForString(port)
+		if err != nil {
+			log.WarnE(err, "")
+			continue
+		}
+		log.Infof("Forwarding %d:%d/%s", lPort, rPort, protocol)
+		if protocol == _const.UDPProtocol {
+			utils.Should(c.UDPPortForward(podName, lPort, rPort))
+		} else {
+			utils.Should(c.PortForward(podName, lPort, rPort, ""))
+		}
 	}
 	return nil
 }
@@ -181,6 +210,58 @@ func (c *Controller) PortForward(podName string, localPort, remotePort int, role
 	}
 }
 
+// ReversePortForward forward the remotePort in pod to the localPort, so that
+// the services in the cluster reach the server of local, it needs the sshd of
+// sidecar started by DevMode with --ssh
+func (c *Controller) ReversePortForward(podName string, localPort, remotePort int) error {
+	client, err := daemon_client.GetDaemonClient(utils.IsSudoUser())
+	if err != nil {
+		return err
+	}
+	nhResource := &model.NocalHostResource{
+		NameSpace:   c.NameSpace,
+		Application: c.AppName,
+		Service:     c.Name,
+		ServiceType: c.Type.String(),
+		PodName:     podName,
+	}
+	if err = client.SendStartReversePortForwardCommand(nhResource, localPort, remotePort, c.AppMeta.NamespaceId); err != nil {
+		return err
+	}
+	return c.SetPortForwardedStatus(true)
+}
+
+// UDPPortForward forward the udp datagrams of localPort to the remotePort in
+// pod, they are relayed by the sidecar started by DevMode with --ssh
+func (c *Controller) UDPPortForward(podName string, localPort, remotePort int) error {
+	client, err := daemon_client.GetDaemonClient(utils.IsSudoUser())
+	if err != nil {
+		return err
+	}
+	nhResource := &model.NocalHostResource{
+		NameSpace:   c.NameSpace,
+		Application: c.AppName,
+		Service:     c.Name,
+		ServiceType: c.Type.String(),
+		PodName:     podName,
+	}
+	if err = client.SendStartUDPPortForwardCommand(nhResource, localPort, remotePort, c.AppMeta.NamespaceId); err != nil {
+		return err
+	}
+	return c.SetPortForwardedStatus(true)
+}
+
+// RestorePortForward start the port-forward recorded in profile again
+func (c *Controller) RestorePortForward(podName string, pf *profile.DevPortForward) error {
+	if pf.Reverse {
+		return c.ReversePortForward(podName, pf.LocalPort, pf.RemotePort)
+	}
+	if pf.GetProtocol() == _const.UDPProtocol {
+		return c.UDPPortForward(podName, pf.LocalPort, pf.RemotePort)
+	}
+	return c.PortForward(podName, pf.LocalPort, pf.RemotePort, pf.Role)
+}
+
 func (c *Controller) CheckIfPortForwardExists(localPort, remotePort int) (bool, error) {
 	svcProfile, err := c.GetProfile()
 	if err != nil {
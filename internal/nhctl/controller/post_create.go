@@ -0,0 +1,61 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"nocalhost/internal/nhctl/syncthing/network/req"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const postCreateSyncTimeout = 2 * time.Minute
+
+// RunPostCreateCommand runs the post create command of dev config in the dev
+// container, such as installing the dependencies, after the files are synced
+// if waitSync, the output is streamed to out and errOut
+func (c *Controller) RunPostCreateCommand(podName, container string, waitSync bool, out, errOut io.Writer) error {
+	devConfig := c.Config().GetContainerDevConfigOrDefault(container)
+	if devConfig == nil || devConfig.Command == nil || len(devConfig.Command.PostCreate) == 0 {
+		return nil
+	}
+	devContainer, err := c.triggerContainer(podName, container)
+	if err != nil {
+		return err
+	}
+	if waitSync {
+		c.waitForFileSyncIdle(postCreateSyncTimeout)
+	}
+
+	command := strings.Join(devConfig.Command.PostCreate, " ")
+	log.Infof("Running post create command %s", command)
+	start := time.Now()
+	if err = c.Client.ExecStream(
+		podName, devContainer, devConfig.Command.PostCreate, nil, out, errOut, false, nil,
+	); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Post create command %s failed", command))
+	}
+	log.Infof("Post create command finished in %s", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// waitForFileSyncIdle waits for the first sync of files to complete, the post
+// create command runs anyway if it times out
+func (c *Controller) waitForFileSyncIdle(timeout time.Duration) {
+	fileSync := c.NewFileSync()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if status := fileSync.Status(); status.Status == req.Idle {
+			return
+		}
+		time.Sleep(time.Second)
+	}
+	log.Warnf("Files are not synced in %s, running the post create command anyway", timeout)
+}
@@ -138,6 +138,9 @@ func (c *Controller) setSyncthingProfileEndStatus() error {
 			svcProfile.PortForwarded = false
 			svcProfile.Syncing = false
 			svcProfile.LocalAbsoluteSyncDirFromDevStartPlugin = []string{}
+			svcProfile.SyncEngine = ""
+			svcProfile.MutagenSshPort = 0
+			svcProfile.SyncPaused = false
 			return nil
 		},
 	)
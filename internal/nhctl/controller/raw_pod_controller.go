@@ -49,16 +49,24 @@ func (r *RawPodController) ReplaceImage(ctx context.Context, ops *model.DevStart
 		originalPod.Annotations = make(map[string]string, 0)
 	}
 	originalPod.Annotations[_const.OriginWorkloadDefinition] = string(bys)
-	originalPod.Annotations[_const.NocalhostDevContainerAnnotations] =
-		r.GetDevContainerName(ops.Container)
+	originalPod.Annotations = r.getDevContainerAnnotations(ops, originalPod.Annotations)
+	if r.GetSidecarPolicy(ops) == _const.StripSidecarPolicy {
+		disableMeshInjectionLabel(originalPod.Labels)
+	}
 
 	devContainer, sideCarContainer, devModeVolumes, err :=
-		r.genContainersAndVolumes(&originalPod.Spec, ops.Container, ops.DevImage, ops.StorageClass, false)
+		r.genContainersAndVolumes(&originalPod.Spec, ops, false)
+	if err != nil {
+		return err
+	}
+	extraContainers, err := r.genExtraDevContainers(&originalPod.Spec, ops, devContainer, devModeVolumes)
 	if err != nil {
 		return err
 	}
 
-	patchDevContainerToPodSpec(&originalPod.Spec, ops.Container, devContainer, sideCarContainer, devModeVolumes)
+	patchDevContainerToPodSpec(
+		&originalPod.Spec, ops.Container, devContainer, sideCarContainer, devModeVolumes, extraContainers,
+	)
 
 	log.Info("Delete original pod...")
 	if err = r.Client.DeletePodByName(r.Name, 0); err != nil {
@@ -122,7 +130,11 @@ func findDevContainerInPodSpec(pod *corev1.PodSpec, containerName string) (*core
 		}
 		return nil, errors.New(fmt.Sprintf("Container %s not found", containerName))
 	} else {
-		if len(pod.Containers) > 1 {
+		if len(pod.Containers) == 0 {
+			return nil, errors.New("No container defined ???")
+		}
+		index := defaultDevContainerIndex(pod)
+		if index < 0 {
 			return nil, errors.New(
 				fmt.Sprintf(
 					"There are more than one container defined," +
@@ -130,10 +142,26 @@ func findDevContainerInPodSpec(pod *corev1.PodSpec, containerName string) (*core
 				),
 			)
 		}
-		if len(pod.Containers) == 0 {
-			return nil, errors.New("No container defined ???")
-		}
-		devContainer = &pod.Containers[0]
+		devContainer = &pod.Containers[index]
 	}
 	return devContainer, nil
 }
+
+// defaultDevContainerIndex the only container except the well-known sidecars,
+// -1 if there are several of them
+func defaultDevContainerIndex(pod *corev1.PodSpec) int {
+	if len(pod.Containers) == 1 {
+		return 0
+	}
+	index := -1
+	for i, c := range pod.Containers {
+		if sidecarContainerNames.Has(c.Name) {
+			continue
+		}
+		if index >= 0 {
+			return -1
+		}
+		index = i
+	}
+	return index
+}
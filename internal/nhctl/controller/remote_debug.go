@@ -0,0 +1,149 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+
+	"github.com/pkg/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"nocalhost/internal/nhctl/debugger"
+	"nocalhost/pkg/nhctl/clientgoutils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+type RemoteDebugOptions struct {
+	Container string
+	// Language the language of debug config by default
+	Language string
+	// RemotePort the port of debugger in the dev container, the one of debug
+	// config or the default one of language by default
+	RemotePort int
+	// LocalPort the port for the IDE to attach, the remote port by default
+	LocalPort int
+	// Command the program to debug, the run command of dev config by default
+	Command []string
+}
+
+// RemoteDebug runs the program under the debugger of language in the dev
+// container, forwards the debug port to local and waits for the debugger to
+// attach, the program is killed and the port-forward is closed when ctx is
+// done or the program exits
+func (c *Controller) RemoteDebug(ctx context.Context, o *RemoteDebugOptions, out, errOut io.Writer) error {
+	if !c.IsInDevMode() {
+		return errors.New(fmt.Sprintf("%s is not in DevMode, please run nhctl dev start first", c.Name))
+	}
+	if err := c.completeRemoteDebugOptions(o); err != nil {
+		return err
+	}
+	script, err := debugger.Command(o.Language, o.RemotePort, o.Command)
+	if err != nil {
+		return err
+	}
+	podName, err := c.GetDevModePodName()
+	if err != nil {
+		return err
+	}
+	container, err := c.triggerContainer(podName, o.Container)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the debugger connects to the ephemeral port forwarded through the proxy,
+	// which tells when it attaches
+	stopChan, readyChan := make(chan struct{}), make(chan struct{})
+	defer close(stopChan)
+	pf, err := c.Client.CreatePortForwarder(
+		podName, []*clientgoutils.ForwardPort{{RemotePort: o.RemotePort}}, readyChan, stopChan,
+		genericclioptions.IOStreams{Out: ioutil.Discard},
+	)
+	if err != nil {
+		return err
+	}
+	errChan := make(chan error, 3)
+	go func() {
+		errChan <- errors.Wrap(pf.ForwardPorts(), "Port-forward of the debugger is closed")
+	}()
+	select {
+	case <-readyChan:
+	case err = <-errChan:
+		return err
+	}
+	ports, err := pf.GetPorts()
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", o.LocalPort))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Failed to listen on local port %d", o.LocalPort))
+	}
+	go func() {
+		errChan <- debugger.Proxy(
+			ctx, listener, fmt.Sprintf("127.0.0.1:%d", ports[0].Local), func(addr string) {
+				log.Infof("Debugger attached from %s", addr)
+			},
+		)
+	}()
+
+	// the pid of shell is the one of program as it is exec-ed
+	pidFile := fmt.Sprintf("/tmp/nocalhost-debug-%d.pid", o.RemotePort)
+	log.Infof("Running %s in %s/%s", script, podName, container)
+	log.Infof("Waiting for the %s debugger to attach to localhost:%d", o.Language, o.LocalPort)
+	go func() {
+		errChan <- c.Client.ExecStream(
+			podName, container, []string{"sh", "-c", fmt.Sprintf("echo $$ > %s; %s", pidFile, script)},
+			nil, out, errOut, false, nil,
+		)
+	}()
+
+	select {
+	case <-ctx.Done():
+		err = nil
+	case err = <-errChan:
+	}
+
+	log.Info("Stopping the debug session")
+	kill := fmt.Sprintf("kill $(cat %s) 2>/dev/null; rm -f %s", pidFile, pidFile)
+	if killErr := c.Client.ExecStream(
+		podName, container, []string{"sh", "-c", kill}, nil, nil, nil, false, nil,
+	); killErr != nil {
+		log.WarnE(killErr, "Failed to stop the program debugging")
+	}
+	return err
+}
+
+func (c *Controller) completeRemoteDebugOptions(o *RemoteDebugOptions) error {
+	devConfig := c.Config().GetContainerDevConfigOrDefault(o.Container)
+	if devConfig != nil && devConfig.DebugConfig != nil {
+		if o.Language == "" {
+			o.Language = devConfig.DebugConfig.Language
+		}
+		if o.RemotePort == 0 {
+			o.RemotePort = devConfig.DebugConfig.RemoteDebugPort
+		}
+	}
+	if devConfig != nil && devConfig.Command != nil && len(o.Command) == 0 {
+		o.Command = devConfig.Command.Run
+	}
+	if o.Language == "" {
+		return errors.New("The language is neither specified nor defined in the debug config")
+	}
+	if o.RemotePort == 0 {
+		o.RemotePort = debugger.DefaultPort(o.Language)
+	}
+	if o.LocalPort == 0 {
+		o.LocalPort = o.RemotePort
+	}
+	return nil
+}
@@ -231,12 +231,16 @@ func (c *Controller) getDuplicateLabelsMap() map[string]string {
 	return labelsMap
 }
 
-func (c *Controller) getDevContainerAnnotations(devContainer string, originAnnos map[string]string) map[string]string {
+func (c *Controller) getDevContainerAnnotations(
+	ops *model.DevStartOptions, originAnnos map[string]string) map[string]string {
 	if len(originAnnos) == 0 {
 		originAnnos = map[string]string{}
 	}
 
-	originAnnos[_const.NocalhostDevContainerAnnotations] = c.GetDevContainerName(devContainer)
+	originAnnos[_const.NocalhostDevContainerAnnotations] = c.GetDevContainerName(ops.Container)
+	if c.GetSidecarPolicy(ops) == _const.StripSidecarPolicy {
+		disableMeshInjection(originAnnos)
+	}
 	return originAnnos
 }
 
@@ -313,32 +317,40 @@ func (c *Controller) PatchDevModeManifest(ctx context.Context, ops *model.DevSta
 	podSpec := &podTemplate.Spec
 
 	devContainer, sideCarContainer, devModeVolumes, err :=
-		c.genContainersAndVolumes(podSpec, ops.Container, ops.DevImage, ops.StorageClass, false)
+		c.genContainersAndVolumes(podSpec, ops, false)
+	if err != nil {
+		return err
+	}
+	extraContainers, err := c.genExtraDevContainers(podSpec, ops, devContainer, devModeVolumes)
 	if err != nil {
 		return err
 	}
 
-	patchDevContainerToPodSpec(podSpec, ops.Container, devContainer, sideCarContainer, devModeVolumes)
+	patchDevContainerToPodSpec(podSpec, ops.Container, devContainer, sideCarContainer, devModeVolumes, extraContainers)
 
 	if !c.DevModeAction.Create {
 
-		log.Info("Update strategy to RECREATE")
 		specPath := "/spec/strategy"
-		strategy := &appsv1.DeploymentStrategy{
-			Type:          appsv1.RecreateDeploymentStrategyType,
-			RollingUpdate: nil,
-		}
-		jsonPatches := make([]jsonPatch, 0)
-		jsonPatches = append(
-			jsonPatches, jsonPatch{
-				Op:    "replace",
-				Path:  specPath,
-				Value: strategy,
-			},
-		)
-		bys, _ := json.Marshal(jsonPatches)
-		if err = c.Client.Patch(c.Type.String(), c.Name, string(bys), "json"); err != nil {
-			log.WarnE(err, "")
+		var jsonPatches []jsonPatch
+		var bys []byte
+		// StatefulSet has no strategy, its update strategy is patched by ScalePatches
+		if _, ok, _ := unstructured.NestedFieldNoCopy(unstructuredObj.Object, "spec", "strategy"); ok {
+			log.Info("Update strategy to RECREATE")
+			strategy := &appsv1.DeploymentStrategy{
+				Type:          appsv1.RecreateDeploymentStrategyType,
+				RollingUpdate: nil,
+			}
+			jsonPatches = append(
+				jsonPatches, jsonPatch{
+					Op:    "replace",
+					Path:  specPath,
+					Value: strategy,
+				},
+			)
+			bys, _ = json.Marshal(jsonPatches)
+			if err = c.Client.Patch(c.Type.String(), c.Name, string(bys), "json"); err != nil {
+				log.WarnE(err, "")
+			}
 		}
 
 		log.Info("Patching development container...")
@@ -364,9 +376,20 @@ func (c *Controller) PatchDevModeManifest(ctx context.Context, ops *model.DevSta
 			jsonPatches, jsonPatch{
 				Op:    "replace",
 				Path:  specPath,
-				Value: c.getDevContainerAnnotations(ops.Container, map[string]string{}),
+				Value: c.getDevContainerAnnotations(ops, map[string]string{}),
 			},
 		)
+		// the label of istio injection takes precedence over the annotation
+		if c.GetSidecarPolicy(ops) == _const.StripSidecarPolicy && disableMeshInjectionLabel(podTemplate.Labels) {
+			labelPath := c.DevModeAction.PodTemplatePath + "/metadata/labels/" + strings.ReplaceAll(istioInjectKey, "/", "~1")
+			jsonPatches = append(
+				jsonPatches, jsonPatch{
+					Op:    "replace",
+					Path:  labelPath,
+					Value: podTemplate.Labels[istioInjectKey],
+				},
+			)
+		}
 		bys, _ = json.Marshal(jsonPatches)
 		patchContent := string(bys)
 
@@ -375,13 +398,30 @@ func (c *Controller) PatchDevModeManifest(ctx context.Context, ops *model.DevSta
 		}
 
 		c.patchAfterDevContainerReplaced(ops.Container, c.Type.String(), c.Name)
+
+		if isKindOf(unstructuredObj, "apps", "StatefulSet") {
+			c.restartBrokenStatefulSetPods()
+		}
 	} else {
+		if isKindOf(unstructuredObj, "apps", "DaemonSet") {
+			node, err := c.replaceDaemonSetPodOfNode(podTemplate)
+			if err != nil {
+				return err
+			}
+			// bypass the scheduler, the dev pod runs on the node in place of the daemon pod
+			podTemplate.Spec.NodeName = node
+		}
+
+		prepareGeneratedPodTemplate(podTemplate)
 		// Some workload's pod may not have labels, such as cronjob, we need to give it one
 		if len(podTemplate.Labels) == 0 {
 			podTemplate.Labels = c.getGeneratedDeploymentLabels()
 		}
 
-		podTemplate.Annotations = c.getDevContainerAnnotations(ops.Container, podTemplate.Annotations)
+		podTemplate.Annotations = c.getDevContainerAnnotations(ops, podTemplate.Annotations)
+		if c.GetSidecarPolicy(ops) == _const.StripSidecarPolicy {
+			disableMeshInjectionLabel(podTemplate.Labels)
+		}
 		generatedDeployment := &appsv1.Deployment{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:   c.getGeneratedDeploymentName(),
@@ -394,7 +434,6 @@ func (c *Controller) PatchDevModeManifest(ctx context.Context, ops *model.DevSta
 				Template: *podTemplate,
 			},
 		}
-		generatedDeployment.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyAlways
 		generatedDeployment.Spec.Strategy.RollingUpdate = nil
 		generatedDeployment.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
 		if _, err = c.Client.CreateDeployment(generatedDeployment); err != nil {
@@ -541,6 +580,9 @@ func (c *Controller) GetDevModePodName() (string, error) {
 }
 
 func (c *Controller) DuplicateModeRollBack() error {
+	c.deleteMeshRoutes()
+	c.deleteDuplicateServices()
+
 	lmap := c.getDuplicateLabelsMap()
 	t := string(c.Type)
 	if c.DevModeAction.Create {
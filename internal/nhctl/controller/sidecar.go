@@ -0,0 +1,80 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/model"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const (
+	istioInjectKey   = "sidecar.istio.io/inject"
+	linkerdInjectKey = "linkerd.io/inject"
+)
+
+var (
+	// the sidecars and the init containers injected by mesh, such as the ones
+	// of `istioctl kube-inject`
+	meshSidecarNames       = sets.NewString("istio-proxy", "linkerd-proxy")
+	meshInitContainerNames = sets.NewString("istio-init", "istio-validation", "linkerd-init", "linkerd-network-validator")
+)
+
+// GetSidecarPolicy the sidecar policy of dev start, or the one of dev config,
+// the sidecars are kept by default
+func (c *Controller) GetSidecarPolicy(ops *model.DevStartOptions) string {
+	if ops.Sidecars != "" {
+		return ops.Sidecars
+	}
+	devConfig := c.config.GetContainerDevConfigOrDefault(ops.Container)
+	if devConfig != nil && devConfig.Sidecars != "" {
+		return devConfig.Sidecars
+	}
+	return _const.KeepSidecarPolicy
+}
+
+// stripMeshSidecars removes the sidecars and the init containers of mesh in
+// the pod spec, the workload is restored with them by dev end
+func stripMeshSidecars(podSpec *corev1.PodSpec) {
+	containers := make([]corev1.Container, 0, len(podSpec.Containers))
+	for _, c := range podSpec.Containers {
+		if meshSidecarNames.Has(c.Name) {
+			log.Infof("Sidecar %s is stripped from the dev pod", c.Name)
+			continue
+		}
+		containers = append(containers, c)
+	}
+	podSpec.Containers = containers
+
+	initContainers := make([]corev1.Container, 0, len(podSpec.InitContainers))
+	for _, c := range podSpec.InitContainers {
+		if meshInitContainerNames.Has(c.Name) {
+			log.Infof("Init container %s is stripped from the dev pod", c.Name)
+			continue
+		}
+		initContainers = append(initContainers, c)
+	}
+	podSpec.InitContainers = initContainers
+}
+
+// disableMeshInjection the sidecars of istio and linkerd are not injected to
+// the pods with the annotations
+func disableMeshInjection(annotations map[string]string) {
+	annotations[istioInjectKey] = "false"
+	annotations[linkerdInjectKey] = "disabled"
+}
+
+// disableMeshInjectionLabel the label of istio injection takes precedence over
+// the annotation, it is turned off if it exists
+func disableMeshInjectionLabel(labels map[string]string) bool {
+	if _, ok := labels[istioInjectKey]; !ok {
+		return false
+	}
+	labels[istioInjectKey] = "false"
+	return true
+}
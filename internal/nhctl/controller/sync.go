@@ -10,6 +10,7 @@ import (
 	"github.com/mitchellh/go-ps"
 	"github.com/pkg/errors"
 	"io/ioutil"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/profile"
 	"nocalhost/internal/nhctl/syncthing"
 	"nocalhost/internal/nhctl/utils"
@@ -19,6 +20,9 @@ import (
 )
 
 func (c *Controller) StopFileSyncOnly() error {
+	if svcProfile, _ := c.GetProfile(); svcProfile != nil && svcProfile.SyncEngine == _const.MutagenSyncEngine {
+		c.stopMutagenSync()
+	}
 
 	pf, err := c.GetPortForwardForSync()
 	utils.Should(err)
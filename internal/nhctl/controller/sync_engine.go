@@ -0,0 +1,350 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-ps"
+	"github.com/pkg/errors"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/dev_ssh"
+	"nocalhost/internal/nhctl/mutagen"
+	"nocalhost/internal/nhctl/profile"
+	"nocalhost/internal/nhctl/syncthing"
+	"nocalhost/internal/nhctl/syncthing/daemon"
+	"nocalhost/internal/nhctl/syncthing/network/req"
+	"nocalhost/internal/nhctl/syncthing/ports"
+	"nocalhost/internal/nhctl/syncthing/terminate"
+	"nocalhost/internal/nhctl/utils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const mutagenSshPidFile = "mutagen-ssh.pid"
+
+// FileSync the file sync of DevMode, the status and the conflicts are
+// reported in the same way by the sync engines
+type FileSync interface {
+	Engine() string
+	Status() *req.SyncthingStatus
+	// Override resolve the conflicts by the local files
+	Override() error
+	Conflicts() ([]*syncthing.Conflict, error)
+	// Resolve the conflicts by the strategy, the number resolved is returned
+	Resolve(strategy string) (int, error)
+	SetPaused(paused bool) error
+}
+
+type syncthingFileSync struct {
+	client *req.SyncthingHttpClient
+	dir    string
+}
+
+func (s *syncthingFileSync) Engine() string { return _const.SyncthingSyncEngine }
+
+func (s *syncthingFileSync) Status() *req.SyncthingStatus { return s.client.GetSyncthingStatus() }
+
+func (s *syncthingFileSync) Override() error { return s.client.FolderOverride() }
+
+func (s *syncthingFileSync) Conflicts() ([]*syncthing.Conflict, error) {
+	if s.dir == "" {
+		return nil, errors.New("Local directory of sync not found")
+	}
+	return syncthing.FindConflicts(s.dir)
+}
+
+func (s *syncthingFileSync) Resolve(strategy string) (int, error) {
+	conflicts, err := s.Conflicts()
+	if err != nil {
+		return 0, err
+	}
+	resolved := 0
+	for _, conflict := range conflicts {
+		if err = syncthing.ResolveConflict(s.dir, conflict, strategy); err != nil {
+			return resolved, err
+		}
+		log.Logf("Conflict of %s resolved by %s", conflict.Path, strategy)
+		resolved++
+	}
+	return resolved, nil
+}
+
+func (s *syncthingFileSync) SetPaused(paused bool) error { return s.client.FolderPause(paused) }
+
+type mutagenFileSync struct {
+	session string
+}
+
+func (m *mutagenFileSync) Engine() string { return _const.MutagenSyncEngine }
+
+func (m *mutagenFileSync) Status() *req.SyncthingStatus {
+	state, err := mutagen.Get(m.session)
+	if err != nil {
+		return &req.SyncthingStatus{Status: req.Error, Msg: "Error", Tips: req.Identifier + err.Error()}
+	}
+	return state.SyncStatus()
+}
+
+func (m *mutagenFileSync) Override() error {
+	if err := mutagen.Reset(m.session); err != nil {
+		return err
+	}
+	return mutagen.Flush(m.session)
+}
+
+func (m *mutagenFileSync) Conflicts() ([]*syncthing.Conflict, error) {
+	state, err := mutagen.Get(m.session)
+	if err != nil || state == nil {
+		return nil, err
+	}
+	conflicts := make([]*syncthing.Conflict, 0, len(state.Conflicts))
+	for _, root := range state.ConflictRoots() {
+		conflicts = append(conflicts, &syncthing.Conflict{Path: root})
+	}
+	return conflicts, nil
+}
+
+// Resolve the conflicts of mutagen are resolved by the sync mode, see
+// StartMutagenSync
+func (m *mutagenFileSync) Resolve(strategy string) (int, error) {
+	return 0, errors.New(
+		"Conflicts of mutagen are resolved by the sync mode, remove the file of the side lost to resolve it",
+	)
+}
+
+func (m *mutagenFileSync) SetPaused(paused bool) error {
+	if paused {
+		return mutagen.Pause(m.session)
+	}
+	return mutagen.Resume(m.session)
+}
+
+// GetSyncEngine the sync engine configured for the container, syncthing by default
+func (c *Controller) GetSyncEngine(container string) string {
+	if cfg := c.Config().GetContainerDevConfigOrDefault(container); cfg != nil && cfg.Sync != nil &&
+		cfg.Sync.Engine != "" {
+		return cfg.Sync.Engine
+	}
+	return _const.SyncthingSyncEngine
+}
+
+// NewFileSync the file sync by the engine started
+func (c *Controller) NewFileSync() FileSync {
+	if svcProfile, _ := c.GetProfile(); svcProfile != nil && svcProfile.SyncEngine == _const.MutagenSyncEngine {
+		return &mutagenFileSync{session: c.getMutagenSessionName()}
+	}
+	s := &syncthingFileSync{client: c.NewSyncthingHttpClient(2)}
+	if svcProfile, _ := c.GetProfile(); svcProfile != nil && len(svcProfile.LocalAbsoluteSyncDirFromDevStartPlugin) > 0 {
+		s.dir = svcProfile.LocalAbsoluteSyncDirFromDevStartPlugin[0]
+	}
+	return s
+}
+
+// GetConflictStrategy the conflict strategy configured for the container,
+// preferLocal by default
+func (c *Controller) GetConflictStrategy(container string) string {
+	if cfg := c.Config().GetContainerDevConfigOrDefault(container); cfg != nil && cfg.Sync != nil &&
+		cfg.Sync.ConflictStrategy != "" {
+		return cfg.Sync.ConflictStrategy
+	}
+	return _const.PreferLocalConflictStrategy
+}
+
+// ResolveSyncConflicts resolve the conflicts by the strategy configured, the
+// ones of keepBoth are left to be resolved by `nhctl sync-status --conflicts`
+func (c *Controller) ResolveSyncConflicts() error {
+	strategy := c.GetConflictStrategy("")
+	if strategy == _const.KeepBothConflictStrategy {
+		return nil
+	}
+	fileSync := c.NewFileSync()
+	if fileSync.Engine() != _const.SyncthingSyncEngine {
+		return nil
+	}
+	_, err := fileSync.Resolve(strategy)
+	return err
+}
+
+// SetSyncPaused pause or resume the file sync without ending DevMode, the
+// state is kept in profile, so that it is restored while the sync reconnected
+func (c *Controller) SetSyncPaused(paused bool) error {
+	if err := c.UpdateSvcProfile(
+		func(svcProfile *profile.SvcProfileV2) error {
+			svcProfile.SyncPaused = paused
+			return nil
+		},
+	); err != nil {
+		return err
+	}
+	if err := c.NewFileSync().SetPaused(paused); err != nil {
+		log.WarnE(err, "Failed to set the paused state of the running sync, it is applied when the sync reconnected")
+	}
+	return nil
+}
+
+func (c *Controller) getMutagenSessionName() string {
+	return mutagen.SessionName(c.NameSpace, c.AppName, c.Type.String(), c.Name)
+}
+
+// StartMutagenSync sync the local directory to the dev container by mutagen,
+// mutagen reaches the dev container by the ssh endpoint of `nhctl ssh`, so
+// that there is no agent of mutagen to be installed in the cluster
+func (c *Controller) StartMutagenSync(container string, localSyncDir []string, syncDouble bool) error {
+	if len(localSyncDir) == 0 {
+		return errors.New("No local directory to sync")
+	}
+	svcProfile, err := c.GetProfile()
+	if err != nil {
+		return err
+	}
+	port := svcProfile.MutagenSshPort
+	if port == 0 {
+		if port, err = ports.GetAvailablePort(); err != nil {
+			return err
+		}
+	}
+
+	hostKey, err := dev_ssh.LoadHostKey(dev_ssh.HostKeyFile())
+	if err != nil {
+		return err
+	}
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	if err = dev_ssh.AddKnownHost(address, hostKey.PublicKey()); err != nil {
+		return err
+	}
+	if err = c.startMutagenSshEndpoint(container, port); err != nil {
+		return err
+	}
+
+	session := &mutagen.Session{
+		Name:  c.getMutagenSessionName(),
+		Alpha: localSyncDir[0],
+		Beta:  fmt.Sprintf("root@%s:%s", address, c.GetWorkDir(container)),
+		Mode:  mutagen.ModeTwoWayResolved,
+	}
+	switch strategy := c.GetConflictStrategy(container); {
+	case !syncDouble:
+		session.Mode = mutagen.ModeOneWayReplica
+	case strategy == _const.PreferRemoteConflictStrategy:
+		log.Warn("PreferRemote is not supported by mutagen, the conflicts are kept to be resolved manually")
+		session.Mode = mutagen.ModeTwoWaySafe
+	case strategy == _const.KeepBothConflictStrategy:
+		session.Mode = mutagen.ModeTwoWaySafe
+	}
+	if devConfig := c.Config().GetContainerDevConfigOrDefault(container); devConfig != nil && devConfig.Sync != nil {
+		session.Ignores = devConfig.Sync.IgnoreFilePattern
+		if len(devConfig.Sync.FilePattern) > 0 || devConfig.Sync.Mode == _const.GitIgnoreMode {
+			log.Warn("FilePattern and gitIgnore mode are not supported by mutagen, use ignoreFilePattern instead")
+		}
+		if devConfig.Sync.MaxUploadKBps > 0 || devConfig.Sync.MaxDownloadKBps > 0 {
+			log.Warn("Bandwidth limit is not supported by mutagen, use syncthing instead")
+		}
+		if threshold, skip := largeFileThreshold(devConfig.Sync); skip {
+			session.MaxFileSize = threshold
+		} else if threshold > 0 {
+			log.Warn("Large files are only skipped by mutagen, the warn policy is ignored")
+		}
+	}
+	log.Infof("Creating mutagen session %s by %s", session.Name, address)
+	if err = mutagen.Create(session); err != nil {
+		return err
+	}
+	if svcProfile.SyncPaused {
+		if err = mutagen.Pause(session.Name); err != nil {
+			return err
+		}
+	}
+
+	return c.UpdateSvcProfile(
+		func(svcProfile *profile.SvcProfileV2) error {
+			svcProfile.SyncEngine = _const.MutagenSyncEngine
+			svcProfile.MutagenSshPort = port
+			return nil
+		},
+	)
+}
+
+// startMutagenSshEndpoint run `nhctl ssh` in background until it is listening
+func (c *Controller) startMutagenSshEndpoint(container string, port int) error {
+	c.stopMutagenSshEndpoint()
+
+	nhctlPath, err := utils.GetNhctlPath()
+	if err != nil {
+		return err
+	}
+	args := []string{
+		nhctlPath, "ssh", c.AppName, "-d", c.Name, "-t", c.Type.String(), "--port", strconv.Itoa(port),
+		"-n", c.NameSpace, "--kubeconfig", c.Client.KubeConfigFilePath(),
+	}
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	logFile, err := os.OpenFile(
+		filepath.Join(c.GetSyncDir(), "mutagen-ssh.log"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600,
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer logFile.Close()
+	cmd := &exec.Cmd{
+		Path:        nhctlPath,
+		Args:        args,
+		Stdout:      logFile,
+		Stderr:      logFile,
+		SysProcAttr: daemon.NewSysProcAttr(),
+	}
+	if err = cmd.Start(); err != nil {
+		return errors.WithStack(err)
+	}
+	pidFile := filepath.Join(c.GetSyncDir(), mutagenSshPidFile)
+	if err = ioutil.WriteFile(pidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0600); err != nil {
+		return errors.WithStack(err)
+	}
+	go cmd.Wait()
+
+	for i := 0; i < 30; i++ {
+		if conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second); err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return errors.New(fmt.Sprintf("Ssh endpoint for mutagen is not listening on %d, see %s", port, logFile.Name()))
+}
+
+func (c *Controller) stopMutagenSshEndpoint() {
+	pidFile := filepath.Join(c.GetSyncDir(), mutagenSshPidFile)
+	bys, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		return
+	}
+	// the pid may be reused after the endpoint exited
+	if pid, err := strconv.Atoi(string(bys)); err == nil {
+		if process, err := ps.FindProcess(pid); err == nil && process != nil &&
+			strings.HasPrefix(process.Executable(), "nhctl") {
+			if err = terminate.Terminate(pid, true); err != nil {
+				log.Logf("Failed to terminate ssh endpoint of mutagen(pid: %d): %v", pid, err)
+			}
+		}
+	}
+	_ = os.Remove(pidFile)
+}
+
+// stopMutagenSync terminate the session and the ssh endpoint of it
+func (c *Controller) stopMutagenSync() {
+	if err := mutagen.Terminate(c.getMutagenSessionName()); err != nil {
+		log.Logf("Failed to terminate mutagen session: %v", err)
+	}
+	c.stopMutagenSshEndpoint()
+}
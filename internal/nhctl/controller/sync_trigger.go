@@ -0,0 +1,144 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/profile"
+	"nocalhost/internal/nhctl/syncthing"
+	"nocalhost/internal/nhctl/syncthing/network/req"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const defaultTriggerDebounce = 500 * time.Millisecond
+
+type pendingTrigger struct {
+	trigger *profile.SyncTrigger
+	// the last time of the files matched changed
+	changedAt time.Time
+	files     []string
+}
+
+// RunSyncTriggers run the commands of triggers in the dev container after the
+// files matched are synced to the remote, the output is streamed to out and
+// errOut, until ctx done
+func (c *Controller) RunSyncTriggers(ctx context.Context, container string, out, errOut io.Writer) error {
+	devConfig := c.Config().GetContainerDevConfigOrDefault(container)
+	if devConfig == nil || len(devConfig.Triggers) == 0 {
+		return errors.New("No trigger defined in the dev config")
+	}
+	if c.GetSyncEngine(container) != _const.SyncthingSyncEngine {
+		return errors.New("Triggers are only supported by syncthing")
+	}
+	podName, err := c.GetDevModePodName()
+	if err != nil {
+		return err
+	}
+	devContainer, err := c.triggerContainer(podName, container)
+	if err != nil {
+		return err
+	}
+
+	// the timeout of request is longer than the one of waiting for events
+	client := c.NewSyncthingHttpClient(10)
+	lastId := int64(0)
+	if events, err := client.Events(0); err == nil && len(events) > 0 {
+		lastId = events[len(events)-1].Id
+	}
+
+	pending := make(map[int]*pendingTrigger)
+	// the remote has all the changes since the time
+	syncedAt := time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		events, err := client.EventsOf(lastId, 1, req.EventLocalIndexUpdated, req.EventFolderCompletion)
+		if err != nil {
+			log.Logf("Failed to get events of syncthing: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		for _, event := range events {
+			lastId = event.Id
+			switch event.EventType {
+			case req.EventLocalIndexUpdated:
+				for i, trigger := range devConfig.Triggers {
+					for _, file := range event.Data.Filenames {
+						if !syncthing.MatchTrigger(trigger.Patterns, file) {
+							continue
+						}
+						if pending[i] == nil {
+							pending[i] = &pendingTrigger{trigger: trigger}
+						}
+						pending[i].changedAt = time.Now()
+						pending[i].files = append(pending[i].files, file)
+					}
+				}
+			case req.EventFolderCompletion:
+				if event.Data.Completion == 100 {
+					syncedAt = time.Now()
+				}
+			}
+		}
+
+		for i, p := range pending {
+			debounce := defaultTriggerDebounce
+			if p.trigger.Debounce > 0 {
+				debounce = time.Duration(p.trigger.Debounce) * time.Millisecond
+			}
+			if time.Since(p.changedAt) < debounce || syncedAt.Before(p.changedAt) {
+				continue
+			}
+			delete(pending, i)
+			c.runSyncTrigger(podName, devContainer, p, out, errOut)
+		}
+	}
+}
+
+func (c *Controller) runSyncTrigger(podName, container string, p *pendingTrigger, out, errOut io.Writer) {
+	name := p.trigger.Name
+	if name == "" {
+		name = strings.Join(p.trigger.Command, " ")
+	}
+	log.Infof("Trigger %s by %d files changed, such as %s", name, len(p.files), p.files[0])
+	start := time.Now()
+	if err := c.Client.ExecStream(podName, container, p.trigger.Command, nil, out, errOut, false, nil); err != nil {
+		log.WarnE(err, fmt.Sprintf("Trigger %s failed", name))
+		return
+	}
+	log.Infof("Trigger %s finished in %s", name, time.Since(start).Round(time.Millisecond))
+}
+
+// triggerContainer the container to run the triggers, the extra containers
+// are developed by their own names
+func (c *Controller) triggerContainer(podName, container string) (string, error) {
+	pod, err := c.Client.ClientSet.CoreV1().Pods(c.NameSpace).Get(context.TODO(), podName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return "", errors.New(fmt.Sprintf("Pod %s is %s", podName, pod.Status.Phase))
+	}
+	for _, cont := range pod.Spec.Containers {
+		if container != "" && cont.Name == container {
+			return container, nil
+		}
+	}
+	return c.GetDevContainerName(container), nil
+}
@@ -9,9 +9,11 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/nocalhost"
+	"nocalhost/internal/nhctl/profile"
 	"nocalhost/internal/nhctl/syncthing/network/req"
 	"nocalhost/internal/nhctl/syncthing/ports"
 	secret_config "nocalhost/internal/nhctl/syncthing/secret-config"
@@ -104,15 +106,26 @@ func (c *Controller) NewSyncthing(container string, localSyncDir []string, syncD
 		Folders:          []*syncthing.Folder{},
 		RescanInterval:   "300",
 	}
+	s.HonorIgnoreFiles = true
+	s.Paused = svcProfile.SyncPaused
 	svcConfig := c.Config()
 	devConfig := svcConfig.GetContainerDevConfigOrDefault(container)
 	if devConfig != nil && devConfig.Sync != nil {
+		s.HonorIgnoreFiles = devConfig.Sync.HonorIgnoreFiles == nil || *devConfig.Sync.HonorIgnoreFiles
 		// enable delete protection by default
 		// or use the val user specify
 		s.IgnoreDelete = devConfig.Sync.DeleteProtection == nil || *devConfig.Sync.DeleteProtection
 		s.EnableParseFromGitIgnore = devConfig.Sync.Mode == _const.GitIgnoreMode
 		s.SyncedPattern = devConfig.Sync.FilePattern
 		s.IgnoredPattern = devConfig.Sync.IgnoreFilePattern
+		// the conflict copies of sendReceive are kept by both the sides, and
+		// resolved by the conflict strategy, see ResolveSyncConflicts
+		if devConfig.Sync.Type == _const.DefaultSyncType {
+			s.MaxConflicts = -1
+		}
+		s.MaxSendKbps = devConfig.Sync.MaxUploadKBps
+		s.MaxRecvKbps = devConfig.Sync.MaxDownloadKBps
+		s.LargeFileThreshold, s.SkipLargeFiles = largeFileThreshold(devConfig.Sync)
 	}
 
 	// TODO, warn: multi local sync dir is Deprecated, now it's implement by IgnoreFiles
@@ -140,6 +153,20 @@ func (c *Controller) NewSyncthing(container string, localSyncDir []string, syncD
 	return s, nil
 }
 
+// largeFileThreshold the threshold in bytes of the large files, and whether
+// they are skipped to sync
+func largeFileThreshold(sync *profile.SyncConfig) (int64, bool) {
+	if sync.LargeFileThreshold == "" {
+		return 0, false
+	}
+	quantity, err := resource.ParseQuantity(sync.LargeFileThreshold)
+	if err != nil {
+		log.Warnf("Invalid largeFileThreshold %s: %v", sync.LargeFileThreshold, err)
+		return 0, false
+	}
+	return quantity.Value(), sync.LargeFilePolicy != _const.WarnLargeFilePolicy
+}
+
 func (c *Controller) NewSyncthingHttpClient(reqTimeoutSecond int) *req.SyncthingHttpClient {
 	svcProfile, _ := c.GetProfile()
 
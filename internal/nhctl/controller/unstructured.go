@@ -210,7 +210,9 @@ func (c *Controller) RollbackFromAnnotation(reset bool) error {
 		return errors.New(fmt.Sprintf("Original workload is not 1(%d)?", len(originalWorkload)))
 	}
 
-	if !c.DevModeAction.Create {
+	// StatefulSet is updated in place, its PVCs may be deleted with it by
+	// persistentVolumeClaimRetentionPolicy
+	if !c.DevModeAction.Create && !isKindOf(devModeWorkload, "apps", "StatefulSet") {
 		// Recreate
 		if err := clientgoutils.DeleteResourceInfo(originalWorkload[0]); err != nil {
 			return err
@@ -235,7 +237,13 @@ func (c *Controller) RollbackFromAnnotation(reset bool) error {
 		}
 	}
 
-	return c.Client.ApplyResourceInfo(originalWorkload[0], nil)
+	if err = c.Client.ApplyResourceInfo(originalWorkload[0], nil); err != nil {
+		return err
+	}
+	if c.DevModeAction.Create || isKindOf(devModeWorkload, "apps", "StatefulSet") {
+		return c.restoreOriginalSpec(osj)
+	}
+	return nil
 }
 
 // GetUnstructuredMapByPath Path must be like: /spec/template
@@ -0,0 +1,151 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package controller
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// the labels of the pods generated by jobs, they must not be copied to the
+// generated deployment, or the pods of it are selected by the job
+var jobPodLabels = []string{
+	"controller-uid", "job-name", "batch.kubernetes.io/controller-uid", "batch.kubernetes.io/job-name",
+}
+
+func isKindOf(u *unstructured.Unstructured, group, kind string) bool {
+	return u.GroupVersionKind().Group == group && u.GetKind() == kind
+}
+
+// restartBrokenStatefulSetPods delete the pods of statefulset which are not ready,
+// the rolling update of statefulset is blocked by the broken pods
+// (kubernetes/kubernetes#67250), the pods recreated keeps their PVCs
+func (c *Controller) restartBrokenStatefulSetPods() {
+	pods, err := c.Client.ListPodsByStatefulSet(c.Name)
+	if err != nil {
+		log.WarnE(err, "")
+		return
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp != nil || isPodReady(&pod) {
+			continue
+		}
+		log.Infof("Restarting pod %s not ready...", pod.Name)
+		if err = c.Client.DeletePod(pod.Name, false, 30*time.Second); err != nil {
+			log.WarnE(err, "")
+		}
+	}
+}
+
+// replaceDaemonSetPodOfNode keep the daemon pods of the other nodes, only the
+// one of the node is replaced by the dev pod, the node chosen is returned,
+// empty if no daemon pod is scheduled
+func (c *Controller) replaceDaemonSetPodOfNode(podTemplate *v1.PodTemplateSpec) (string, error) {
+	pods, err := c.Client.ListPodsByDaemonSet(c.Name)
+	if err != nil {
+		return "", err
+	}
+	// the node of a ready pod is preferred
+	node := ""
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if isPodReady(&pod) {
+			node = pod.Spec.NodeName
+			break
+		}
+		if node == "" {
+			node = pod.Spec.NodeName
+		}
+	}
+	if node == "" {
+		return "", nil
+	}
+
+	log.Infof("Replacing daemon pod of node %s...", node)
+	bys, _ := json.Marshal(
+		[]jsonPatch{
+			{
+				Op:    "add",
+				Path:  c.DevModeAction.PodTemplatePath + "/spec/affinity",
+				Value: excludeNodeAffinity(podTemplate.Spec.Affinity, node),
+			},
+		},
+	)
+	if err = c.Client.Patch(c.Type.String(), c.Name, string(bys), "json"); err != nil {
+		return "", err
+	}
+	return node, nil
+}
+
+// excludeNodeAffinity the affinity of which the node is excluded, the terms of
+// node selector are ORed, so the node is excluded in each of them
+func excludeNodeAffinity(affinity *v1.Affinity, node string) *v1.Affinity {
+	if affinity == nil {
+		affinity = &v1.Affinity{}
+	} else {
+		affinity = affinity.DeepCopy()
+	}
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &v1.NodeAffinity{}
+	}
+	if affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{}
+	}
+	selector := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []v1.NodeSelectorTerm{{}}
+	}
+	// the name of node is matched by the field, the hostname label may differ
+	excluded := v1.NodeSelectorRequirement{
+		Key: "metadata.name", Operator: v1.NodeSelectorOpNotIn, Values: []string{node},
+	}
+	for i := range selector.NodeSelectorTerms {
+		selector.NodeSelectorTerms[i].MatchFields = append(selector.NodeSelectorTerms[i].MatchFields, excluded)
+	}
+	return affinity
+}
+
+// prepareGeneratedPodTemplate make the pod template of job, daemonset or
+// cronjob valid for the generated deployment
+func prepareGeneratedPodTemplate(podTemplate *v1.PodTemplateSpec) {
+	for _, label := range jobPodLabels {
+		delete(podTemplate.Labels, label)
+	}
+	// activeDeadlineSeconds is not supported by deployment
+	podTemplate.Spec.ActiveDeadlineSeconds = nil
+	podTemplate.Spec.RestartPolicy = v1.RestartPolicyAlways
+}
+
+// restoreOriginalSpec replace the spec by the original one, the fields added in
+// dev mode, e.g. the affinity or suspend, are not removed by applying
+func (c *Controller) restoreOriginalSpec(originalJson string) error {
+	original := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(originalJson), &original); err != nil {
+		return errors.WithStack(err)
+	}
+	spec, ok := original["spec"]
+	if !ok {
+		return errors.New("Spec of original workload not found")
+	}
+	bys, _ := json.Marshal([]jsonPatch{{Op: "replace", Path: "/spec", Value: spec}})
+	return c.Client.Patch(c.Type.String(), c.Name, string(bys), "json")
+}
+
+func isPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
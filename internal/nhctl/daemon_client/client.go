@@ -14,10 +14,10 @@ import (
 	"io/ioutil"
 	v1 "k8s.io/api/core/v1"
 	"net"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/daemon_common"
 	"nocalhost/internal/nhctl/daemon_server/command"
 	"nocalhost/internal/nhctl/model"
-	"nocalhost/internal/nhctl/syncthing/ports"
 	"nocalhost/internal/nhctl/utils"
 	"nocalhost/pkg/nhctl/log"
 	"os"
@@ -27,42 +27,36 @@ import (
 )
 
 type DaemonClient struct {
-	isSudo                 bool
-	daemonServerListenPort int
+	isSudo bool
 }
 
-func waitForTCPPortToBeReady(port int, timeout time.Duration) error {
-	return waitTCPPort(false, port, timeout)
+func waitForDaemonToBeReady(isSudoUser bool, timeout time.Duration) error {
+	return waitDaemon(isSudoUser, true, timeout)
 }
 
-func waitTCPPort(available bool, port int, timeout time.Duration) error {
+func waitDaemon(isSudoUser bool, listening bool, timeout time.Duration) error {
 	ctx, _ := context.WithTimeout(context.TODO(), timeout)
 	for {
 		select {
 		case <-ctx.Done():
-			return errors.New(fmt.Sprintf("Wait for port %d to be ready timeout", port))
+			return errors.New(
+				fmt.Sprintf("Wait for daemon on %s to be ready timeout", daemon_common.DaemonAddress(isSudoUser)),
+			)
 		default:
 			<-time.Tick(1 * time.Second)
-			b := ports.IsTCP4PortAvailable("0.0.0.0", port)
-			if b == available {
+			if daemon_common.IsDaemonListening(isSudoUser) == listening {
 				return nil
 			}
 		}
 	}
 }
 
-func waitForTCPPortToBeDown(port int, timeout time.Duration) error {
-	return waitTCPPort(true, port, timeout)
+func waitForDaemonToBeDown(isSudoUser bool, timeout time.Duration) error {
+	return waitDaemon(isSudoUser, false, timeout)
 }
 
 func CheckIfDaemonServerRunning(isSudoUser bool) bool {
-	var listenPort int
-	if isSudoUser {
-		listenPort = daemon_common.SudoDaemonPort
-	} else {
-		listenPort = daemon_common.DefaultDaemonPort
-	}
-	return !ports.IsTCP4PortAvailable("0.0.0.0", listenPort)
+	return daemon_common.IsDaemonListening(isSudoUser)
 }
 
 var (
@@ -116,13 +110,8 @@ func newDaemonClient(isSudoUser bool) (*DaemonClient, error) {
 	client := &DaemonClient{
 		isSudo: isSudoUser,
 	}
-	if isSudoUser {
-		client.daemonServerListenPort = daemon_common.SudoDaemonPort
-	} else {
-		client.daemonServerListenPort = daemon_common.DefaultDaemonPort
-	}
 
-	if err = startDaemonServerIfNotRunning(isSudoUser, client.daemonServerListenPort); err != nil {
+	if err = startDaemonServerIfNotRunning(isSudoUser); err != nil {
 		return nil, err
 	}
 
@@ -138,39 +127,33 @@ func newDaemonClient(isSudoUser bool) (*DaemonClient, error) {
 
 	nhctlPath, _ := utils.GetNhctlPath()
 
-	// force update earlier version
-	if daemonServerInfo.NhctlPath == "" {
+	switch negotiate(daemonServerInfo, nhctlPath, AutoUpgrade) {
+	case replaceDaemon:
+		// force update earlier version
 		log.Log("Daemon server need to stop and restart")
 		utils.Should(client.SendStopDaemonServerCommand())
-		utils.Should(waitForTCPPortToBeDown(client.daemonServerListenPort, 10*time.Second))
-		if err = startDaemonServerIfNotRunning(isSudoUser, client.daemonServerListenPort); err != nil {
+		utils.Should(waitForDaemonToBeDown(isSudoUser, 10*time.Second))
+		if err = startDaemonServerIfNotRunning(isSudoUser); err != nil {
 			return nil, err
 		}
-	} else if daemonServerInfo.Version != daemon_common.Version || daemonServerInfo.CommitId != daemon_common.CommitId {
-
-		// if from same nhctl
-		if nhctlPath == daemonServerInfo.NhctlPath || utils.IsWindows() {
-			log.Logf("Daemon server [%s] need to upgrade", daemonServerInfo.NhctlPath)
-			utils.Should(client.SendRestartDaemonServerCommand())
-		} else {
-			// else do not update the daemon
-			log.Logf(
-				"Current nhctl [%s] but daemon server use [%s], "+
-					"nocalhost will not update the daemon automatic.",
-				nhctlPath, daemonServerInfo.NhctlPath,
-			)
+	case restartDaemon:
+		log.Logf("Daemon server [%s] need to upgrade", daemonServerInfo.NhctlPath)
+		if err = client.SendRestartDaemonServerCommand(); err != nil {
+			log.WarnE(err, "Failed to restart daemon server")
+			break
 		}
+		utils.Should(waitForDaemonToBeUpgraded(client, daemonServerInfo.Pid, 20*time.Second))
 	}
 	return client, nil
 }
 
-func startDaemonServerIfNotRunning(isSudoUser bool, port int) error {
-	if ports.IsTCP4PortAvailable("0.0.0.0", port) {
+func startDaemonServerIfNotRunning(isSudoUser bool) error {
+	if !daemon_common.IsDaemonListening(isSudoUser) {
 		if err := daemon_common.StartDaemonServerBySubProcess(isSudoUser); err != nil {
 			return err
 		}
 		log.Log("Waiting daemon to start")
-		if err := waitForTCPPortToBeReady(port, 10*time.Second); err != nil {
+		if err := waitForDaemonToBeReady(isSudoUser, 10*time.Second); err != nil {
 			return err
 		}
 		log.Log("Daemon started")
@@ -268,6 +251,21 @@ func (d *DaemonClient) SendGetDaemonServerStatusCommand() (*daemon_common.Daemon
 	return status, nil
 }
 
+// SendGetDaemonDiagnosticsCommand the internals of daemon for debugging
+func (d *DaemonClient) SendGetDaemonDiagnosticsCommand() (*daemon_common.DaemonDiagnostics, error) {
+	cmd := &command.BaseCommand{CommandType: command.GetDaemonDiagnostics, ClientStack: string(debug.Stack())}
+	bys, err := json.Marshal(cmd)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	diagnostics := &daemon_common.DaemonDiagnostics{}
+	if err = d.sendAndWaitForResponse(bys, diagnostics); err != nil {
+		return nil, err
+	}
+	return diagnostics, nil
+}
+
 func (d *DaemonClient) SendAuthCheckCommand(ns, kubeConfigContent string, needChecks ...string) (bool, error) {
 	acCmd := &command.AuthCheckCommand{
 		CommandType: command.AuthCheck,
@@ -331,6 +329,28 @@ func (d *DaemonClient) SendGetApplicationMetasCommand(ns, kubeConfig string) ([]
 func (d *DaemonClient) SendStartPortForwardCommand(
 	nhSvc *model.NocalHostResource, localPort, remotePort int, role, nid string,
 ) error {
+	return d.sendStartPortForwardCommand(nhSvc, localPort, remotePort, role, nid, false, "")
+}
+
+// SendStartReversePortForwardCommand the remote port in pod is forwarded to
+// the local one by the sshd of sidecar
+func (d *DaemonClient) SendStartReversePortForwardCommand(
+	nhSvc *model.NocalHostResource, localPort, remotePort int, nid string,
+) error {
+	return d.sendStartPortForwardCommand(nhSvc, localPort, remotePort, "", nid, true, "")
+}
+
+// SendStartUDPPortForwardCommand the udp datagrams of local port are relayed
+// to the remote port in pod by the relay of sidecar
+func (d *DaemonClient) SendStartUDPPortForwardCommand(
+	nhSvc *model.NocalHostResource, localPort, remotePort int, nid string,
+) error {
+	return d.sendStartPortForwardCommand(nhSvc, localPort, remotePort, "", nid, false, _const.UDPProtocol)
+}
+
+func (d *DaemonClient) sendStartPortForwardCommand(
+	nhSvc *model.NocalHostResource, localPort, remotePort int, role, nid string, reverse bool, protocol string,
+) error {
 
 	startPFCmd := &command.PortForwardCommand{
 		CommandType: command.StartPortForward,
@@ -345,6 +365,8 @@ func (d *DaemonClient) SendStartPortForwardCommand(
 		RemotePort:  remotePort,
 		Role:        role,
 		Nid:         nid,
+		Reverse:     reverse,
+		Protocol:    protocol,
 	}
 
 	bys, err := json.Marshal(startPFCmd)
@@ -543,7 +565,7 @@ func (d *DaemonClient) sendDataToDaemonServer(data []byte) error {
 	if err != nil {
 		return errors.Wrap(err, "Failed to unmarshal command")
 	}
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", "127.0.0.1", d.daemonServerListenPort), time.Second*30)
+	conn, err := daemon_common.DialDaemon(d.isSudo, time.Second*30)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("%s failed to dial to daemon", baseCmd.CommandType))
 	}
@@ -567,7 +589,7 @@ func (d *DaemonClient) sendAndWaitForStream(req []byte, consumer func(io.Reader)
 	if err != nil {
 		return errors.Wrap(err, "Failed to unmarshal command")
 	}
-	conn, err = net.DialTimeout("tcp", fmt.Sprintf("%s:%d", "127.0.0.1", d.daemonServerListenPort), time.Second*30)
+	conn, err = daemon_common.DialDaemon(d.isSudo, time.Second*30)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("%s failed to dial to daemon", baseCmd.CommandType))
 	}
@@ -601,7 +623,7 @@ func (d *DaemonClient) sendAndWaitForResponse(req []byte, resp interface{}) erro
 	if err != nil {
 		return errors.Wrap(err, "Failed to unmarshal command")
 	}
-	conn, err = net.DialTimeout("tcp", fmt.Sprintf("%s:%d", "127.0.0.1", d.daemonServerListenPort), time.Second*30)
+	conn, err = daemon_common.DialDaemon(d.isSudo, time.Second*30)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("%s failed to dial to daemon", baseCmd.CommandType))
 	}
@@ -675,3 +697,15 @@ func (d *DaemonClient) sendAndWaitForResponse(req []byte, resp interface{}) erro
 
 	return nil
 }
+
+// ProbeDaemonServer the info of the daemon running, it is neither started nor
+// upgraded, for diagnosing the daemon as it is
+func ProbeDaemonServer(isSudoUser bool) (*daemon_common.DaemonServerInfo, error) {
+	return (&DaemonClient{isSudo: isSudoUser}).SendGetDaemonServerInfoCommand()
+}
+
+// ProbeDaemonDiagnostics the diagnostics of the daemon running, it is neither
+// started nor upgraded as ProbeDaemonServer
+func ProbeDaemonDiagnostics(isSudoUser bool) (*daemon_common.DaemonDiagnostics, error) {
+	return (&DaemonClient{isSudo: isSudoUser}).SendGetDaemonDiagnosticsCommand()
+}
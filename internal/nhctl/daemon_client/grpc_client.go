@@ -0,0 +1,43 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_client
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"nocalhost/internal/nhctl/daemon_common"
+	pb "nocalhost/internal/nhctl/rpc/daemon/v1"
+)
+
+// DialDaemonService the grpc client of daemon, the daemon is started or
+// upgraded first as GetDaemonClient does. The conn should be closed by caller
+func DialDaemonService(isSudoUser bool) (pb.DaemonServiceClient, *grpc.ClientConn, error) {
+	if _, err := GetDaemonClient(isSudoUser); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(
+		ctx, "daemon", grpc.WithInsecure(), grpc.WithBlock(), grpc.WithContextDialer(
+			func(ctx context.Context, _ string) (net.Conn, error) {
+				timeout := 10 * time.Second
+				if deadline, ok := ctx.Deadline(); ok {
+					timeout = time.Until(deadline)
+				}
+				return daemon_common.DialDaemonGrpc(isSudoUser, timeout)
+			},
+		),
+	)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Failed to dial grpc of daemon")
+	}
+	return pb.NewDaemonServiceClient(conn), conn, nil
+}
@@ -0,0 +1,106 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
+	"nocalhost/internal/nhctl/daemon_common"
+	"nocalhost/internal/nhctl/utils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// AutoUpgrade the daemon of other version is restarted by current nhctl,
+// disabled by --no-auto-upgrade
+var AutoUpgrade = true
+
+type upgradeAction int
+
+const (
+	keepDaemon upgradeAction = iota
+	// restartDaemon the daemon restarts itself by current nhctl, and the new
+	// one recovers its port-forwards
+	restartDaemon
+	// replaceDaemon the earlier daemon is not able to restart itself, it is
+	// stopped and started by current nhctl
+	replaceDaemon
+)
+
+// negotiate what to do with the daemon by its version and the nhctl started it
+func negotiate(info *daemon_common.DaemonServerInfo, nhctlPath string, autoUpgrade bool) upgradeAction {
+	if info.Version == daemon_common.Version && info.CommitId == daemon_common.CommitId {
+		return keepDaemon
+	}
+	if !autoUpgrade {
+		log.Logf(
+			"Daemon server [%s] is %s but nhctl is %s, it is not upgraded as auto upgrade is disabled",
+			info.NhctlPath, info.Version, daemon_common.Version,
+		)
+		return keepDaemon
+	}
+	if info.NhctlPath == "" {
+		return replaceDaemon
+	}
+	// the daemon started by the later nhctl, such as the one of IDE plugin,
+	// is not downgraded if it speaks the same protocol
+	if info.ProtocolVersion == daemon_common.ProtocolVersion && isLaterVersion(info.Version, daemon_common.Version) {
+		return keepDaemon
+	}
+	// if from same nhctl
+	if nhctlPath == info.NhctlPath || utils.IsWindows() {
+		return restartDaemon
+	}
+	// else do not update the daemon
+	log.Logf(
+		"Current nhctl [%s] but daemon server use [%s], "+
+			"nocalhost will not update the daemon automatic.",
+		nhctlPath, info.NhctlPath,
+	)
+	if info.ProtocolVersion != daemon_common.ProtocolVersion {
+		log.Warnf(
+			"Daemon server speaks protocol %d but nhctl speaks %d, run `nhctl daemon restart` "+
+				"if the commands of daemon fail", info.ProtocolVersion, daemon_common.ProtocolVersion,
+		)
+	}
+	return keepDaemon
+}
+
+func isLaterVersion(v, than string) bool {
+	if !strings.HasPrefix(v, "v") {
+		v = "v" + v
+	}
+	if !strings.HasPrefix(than, "v") {
+		than = "v" + than
+	}
+	return semver.IsValid(v) && semver.IsValid(than) && semver.Compare(v, than) > 0
+}
+
+// waitForDaemonToBeUpgraded waits until the daemon restarted serves the
+// version of current nhctl
+func waitForDaemonToBeUpgraded(d *DaemonClient, previousPid int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(time.Second)
+		if !daemon_common.IsDaemonListening(d.isSudo) {
+			continue
+		}
+		info, err := d.SendGetDaemonServerInfoCommand()
+		if err != nil || info.Upgrading || (previousPid != 0 && info.Pid == previousPid) {
+			continue
+		}
+		if info.Version != daemon_common.Version || info.CommitId != daemon_common.CommitId {
+			return errors.New(
+				fmt.Sprintf("Daemon server is restarted as %s but nhctl is %s", info.Version, daemon_common.Version),
+			)
+		}
+		return nil
+	}
+	return errors.New(fmt.Sprintf("Wait for daemon server to be upgraded timeout after %s", timeout))
+}
@@ -0,0 +1,70 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_client
+
+import (
+	"testing"
+
+	"nocalhost/internal/nhctl/daemon_common"
+)
+
+func TestNegotiate(t *testing.T) {
+	version, commitId := daemon_common.Version, daemon_common.CommitId
+	daemon_common.Version, daemon_common.CommitId = "v0.6.10", "abc"
+	defer func() { daemon_common.Version, daemon_common.CommitId = version, commitId }()
+
+	const nhctl = "/usr/local/bin/nhctl"
+	cases := []struct {
+		name        string
+		info        daemon_common.DaemonServerInfo
+		autoUpgrade bool
+		expect      upgradeAction
+	}{
+		{
+			name:        "same version",
+			info:        daemon_common.DaemonServerInfo{Version: "v0.6.10", CommitId: "abc", NhctlPath: nhctl},
+			autoUpgrade: true,
+			expect:      keepDaemon,
+		},
+		{
+			name:        "earlier daemon without nhctl path",
+			info:        daemon_common.DaemonServerInfo{Version: "v0.4.0"},
+			autoUpgrade: true,
+			expect:      replaceDaemon,
+		},
+		{
+			name:        "earlier daemon of same nhctl",
+			info:        daemon_common.DaemonServerInfo{Version: "v0.6.9", NhctlPath: nhctl, ProtocolVersion: 1},
+			autoUpgrade: true,
+			expect:      restartDaemon,
+		},
+		{
+			name: "later daemon of same protocol",
+			info: daemon_common.DaemonServerInfo{
+				Version: "0.6.11", NhctlPath: nhctl, ProtocolVersion: daemon_common.ProtocolVersion,
+			},
+			autoUpgrade: true,
+			expect:      keepDaemon,
+		},
+		{
+			name:        "daemon of other nhctl",
+			info:        daemon_common.DaemonServerInfo{Version: "v0.6.9", NhctlPath: "/opt/ide/nhctl"},
+			autoUpgrade: true,
+			expect:      keepDaemon,
+		},
+		{
+			name:        "auto upgrade disabled",
+			info:        daemon_common.DaemonServerInfo{Version: "v0.4.0"},
+			autoUpgrade: false,
+			expect:      keepDaemon,
+		},
+	}
+	for _, c := range cases {
+		if action := negotiate(&c.info, nhctl, c.autoUpgrade); action != c.expect {
+			t.Errorf("%s: got %d, expect %d", c.name, action, c.expect)
+		}
+	}
+}
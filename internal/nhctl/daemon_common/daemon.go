@@ -19,8 +19,14 @@ const (
 	SudoDaemonPort     = 30124
 	DaemonHttpPort     = 30125
 	SudoDaemonHttpPort = 30126
+	DaemonGrpcPort     = 30127
+	SudoDaemonGrpcPort = 30128
 )
 
+// ProtocolVersion the version of the commands between nhctl and daemon, it
+// should be increased if the commands are changed incompatibly
+const ProtocolVersion = 2
+
 var (
 	Version  = "1.0"
 	CommitId = ""
@@ -31,6 +37,9 @@ type DaemonServerInfo struct {
 	CommitId  string
 	NhctlPath string
 	Upgrading bool
+	// ProtocolVersion zero if the daemon is earlier than the negotiation
+	ProtocolVersion int
+	Pid             int
 }
 
 type CheckClusterStatus struct {
@@ -49,12 +58,52 @@ type PortForwardProfile struct {
 	Role       string             `json:"role"`
 	LocalPort  int                `json:"localPort"`
 	RemotePort int                `json:"remotePort"`
+	Reverse    bool               `json:"reverse,omitempty"`
+	Protocol   string             `json:"protocol"`
+	Reconnects int                `json:"reconnects"`
 }
 
 type DaemonServerStatusResponse struct {
 	PortForwardList []*PortForwardProfile `json:"portForwardList"`
 }
 
+// DaemonDiagnostics the internals of daemon, for debugging the reports of
+// nhctl getting stuck
+type DaemonDiagnostics struct {
+	Version       string `json:"version"`
+	CommitId      string `json:"commitId"`
+	Sudo          bool   `json:"sudo"`
+	UptimeSeconds int64  `json:"uptimeSeconds"`
+	Goroutines    int    `json:"goroutines"`
+	MemAllocBytes uint64 `json:"memAllocBytes"`
+
+	PortForwards    []*PortForwardProfile `json:"portForwards"`
+	SyncSessions    []*SyncSession        `json:"syncSessions"`
+	KubeconfigCache *KubeconfigCache      `json:"kubeconfigCache"`
+	// Errors the counts of errors occurred by the kinds, such as the
+	// commands handled or the port-forwards reconnecting
+	Errors map[string]int `json:"errors"`
+}
+
+// SyncSession the file sync of workload started by the nhctl of this device
+type SyncSession struct {
+	NameSpace  string `json:"nameSpace"`
+	AppName    string `json:"appName"`
+	SvcName    string `json:"svcName"`
+	SvcType    string `json:"svcType"`
+	SyncEngine string `json:"syncEngine"`
+	Paused     bool   `json:"paused"`
+}
+
+// KubeconfigCache the kubeconfig files saved by content, and the searchers and
+// application watchers cached by kubeconfig
+type KubeconfigCache struct {
+	Files           int   `json:"files"`
+	Bytes           int64 `json:"bytes"`
+	Searchers       int   `json:"searchers"`
+	AppMetaWatchers int   `json:"appMetaWatchers"`
+}
+
 // StartDaemonServerBySubProcess
 // Start daemon server from client
 func StartDaemonServerBySubProcess(isSudoUser bool) error {
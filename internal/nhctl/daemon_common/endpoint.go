@@ -0,0 +1,72 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_common
+
+import (
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	daemonSocketName         = "daemon.sock"
+	sudoDaemonSocketName     = "daemon-sudo.sock"
+	daemonGrpcSocketName     = "daemon-grpc.sock"
+	sudoDaemonGrpcSocketName = "daemon-sudo-grpc.sock"
+)
+
+func socketName(isSudoUser, grpc bool) string {
+	switch {
+	case isSudoUser && grpc:
+		return sudoDaemonGrpcSocketName
+	case isSudoUser:
+		return sudoDaemonSocketName
+	case grpc:
+		return daemonGrpcSocketName
+	default:
+		return daemonSocketName
+	}
+}
+
+// DaemonAddress the address the json protocol of daemon listening on, which
+// is a socket of current user, or a tcp port on windows
+func DaemonAddress(isSudoUser bool) string {
+	_, address := daemonEndpoint(isSudoUser, false)
+	return address
+}
+
+// ListenDaemon listens on the endpoint of the json protocol of daemon
+func ListenDaemon(isSudoUser bool) (net.Listener, error) {
+	return listen(daemonEndpoint(isSudoUser, false))
+}
+
+// ListenDaemonGrpc listens on the endpoint of the grpc api of daemon
+func ListenDaemonGrpc(isSudoUser bool) (net.Listener, error) {
+	return listen(daemonEndpoint(isSudoUser, true))
+}
+
+func DialDaemon(isSudoUser bool, timeout time.Duration) (net.Conn, error) {
+	network, address := daemonEndpoint(isSudoUser, false)
+	conn, err := net.DialTimeout(network, address, timeout)
+	return conn, errors.Wrap(err, "")
+}
+
+func DialDaemonGrpc(isSudoUser bool, timeout time.Duration) (net.Conn, error) {
+	network, address := daemonEndpoint(isSudoUser, true)
+	conn, err := net.DialTimeout(network, address, timeout)
+	return conn, errors.Wrap(err, "")
+}
+
+// IsDaemonListening if the daemon of current user is accepting connections
+func IsDaemonListening(isSudoUser bool) bool {
+	conn, err := DialDaemon(isSudoUser, time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
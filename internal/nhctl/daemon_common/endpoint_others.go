@@ -0,0 +1,155 @@
+//go:build !windows
+// +build !windows
+
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// privilegedLockPath the lock shared by the sudo daemons of all users of device
+const privilegedLockPath = "/var/run/nocalhost/privileged.lock"
+
+// runDir the dir of sockets of daemons, replaced by tests
+var runDir = defaultRunDir
+
+// DaemonRunDir the dir of the sockets of the daemons of current user
+func DaemonRunDir() string {
+	return runDir()
+}
+
+// ownerUid the user the daemons serving, who runs sudo if the daemon is
+// started with sudo
+func ownerUid() int {
+	if uid, err := strconv.Atoi(os.Getenv("SUDO_UID")); err == nil {
+		return uid
+	}
+	return os.Getuid()
+}
+
+func ownerGid() int {
+	if gid, err := strconv.Atoi(os.Getenv("SUDO_GID")); err == nil {
+		return gid
+	}
+	return os.Getgid()
+}
+
+// defaultRunDir the runtime dir of user if there is, such as /run/user/1000,
+// otherwise the tmp dir named by uid, as the tmp dir is shared by the users
+// of device. The home dir is not used as it might be on the nfs
+func defaultRunDir() string {
+	uid := ownerUid()
+	if stat, err := os.Stat(fmt.Sprintf("/run/user/%d", uid)); err == nil && stat.IsDir() {
+		return filepath.Join(fmt.Sprintf("/run/user/%d", uid), "nocalhost")
+	}
+	return filepath.Join("/tmp", fmt.Sprintf("nocalhost-%d", uid))
+}
+
+func daemonEndpoint(isSudoUser, grpc bool) (string, string) {
+	return "unix", filepath.Join(runDir(), socketName(isSudoUser, grpc))
+}
+
+// chownToOwner the files created by the sudo daemon, so that the daemon of
+// user is able to connect to the sudo daemon
+func chownToOwner(path string) error {
+	if os.Getuid() != 0 || ownerUid() == 0 {
+		return nil
+	}
+	return errors.Wrap(os.Lchown(path, ownerUid(), ownerGid()), "")
+}
+
+// prepareRunDir creates the run dir only accessible by its owner, and refuses
+// the one owned by others, as the sockets in it accept the commands of daemon
+func prepareRunDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrap(err, "")
+	}
+	if err := chownToOwner(dir); err != nil {
+		return err
+	}
+	stat, err := os.Lstat(dir)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	if st, ok := stat.Sys().(*syscall.Stat_t); !stat.IsDir() || !ok || int(st.Uid) != ownerUid() {
+		return errors.New(fmt.Sprintf("%s is not a dir owned by uid %d", dir, ownerUid()))
+	}
+	if stat.Mode().Perm()&0077 != 0 {
+		return errors.Wrap(os.Chmod(dir, 0700), "")
+	}
+	return nil
+}
+
+func listen(network, address string) (net.Listener, error) {
+	if err := prepareRunDir(filepath.Dir(address)); err != nil {
+		return nil, err
+	}
+	// the socket left by the daemon killed
+	if _, err := os.Lstat(address); err == nil {
+		if conn, err := net.DialTimeout(network, address, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, errors.New(fmt.Sprintf("%s is in use", address))
+		}
+		if err = os.Remove(address); err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	if err = os.Chmod(address, 0600); err == nil {
+		err = chownToOwner(address)
+	}
+	if err != nil {
+		_ = listener.Close()
+		return nil, errors.Wrap(err, "")
+	}
+	return listener, nil
+}
+
+// LockPrivileged waits for the privileged operations, such as changing the
+// routes and dns, of the sudo daemons of other users on the device. The
+// operation holding the lock is passed to waiting, so that the user knows who
+// they are waiting for
+func LockPrivileged(operation string, waiting func(holder string)) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(privilegedLockPath), 0755); err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	f, err := os.OpenFile(privilegedLockPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	fd := int(f.Fd())
+	if err = syscall.Flock(fd, syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if waiting != nil {
+			holder, _ := ioutil.ReadAll(f)
+			waiting(string(holder))
+		}
+		if err = syscall.Flock(fd, syscall.LOCK_EX); err != nil {
+			_ = f.Close()
+			return nil, errors.Wrap(err, "")
+		}
+	}
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(fmt.Sprintf("%s of uid %d, pid %d", operation, ownerUid(), os.Getpid())), 0)
+	return func() {
+		_ = f.Truncate(0)
+		_ = syscall.Flock(fd, syscall.LOCK_UN)
+		_ = f.Close()
+	}, nil
+}
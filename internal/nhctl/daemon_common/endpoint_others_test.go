@@ -0,0 +1,104 @@
+//go:build !windows
+// +build !windows
+
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_common
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func useRunDir(t *testing.T) string {
+	tmp, err := ioutil.TempDir("", "nhctl-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(tmp, "run")
+	runDir = func() string { return dir }
+	t.Cleanup(
+		func() {
+			runDir = defaultRunDir
+			_ = os.RemoveAll(tmp)
+		},
+	)
+	return dir
+}
+
+func TestListenDaemon(t *testing.T) {
+	dir := useRunDir(t)
+
+	listener, err := ListenDaemon(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsDaemonListening(false) || IsDaemonListening(true) {
+		t.Fatal("only the daemon of user should be listening")
+	}
+	if _, err = ListenDaemon(false); err == nil {
+		t.Error("socket in use should not be listened again")
+	}
+
+	for path, perm := range map[string]os.FileMode{dir: 0700, DaemonAddress(false): 0600} {
+		stat, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stat.Mode().Perm() != perm {
+			t.Errorf("permission of %s is %v, expect %v", path, stat.Mode().Perm(), perm)
+		}
+	}
+
+	_ = listener.Close()
+	if IsDaemonListening(false) {
+		t.Error("daemon closed should not be listening")
+	}
+}
+
+func TestListenDaemonLeftSocket(t *testing.T) {
+	useRunDir(t)
+
+	listener, err := ListenDaemon(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the socket left as the daemon killed
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = listener.Close()
+	if _, err = os.Stat(DaemonAddress(true)); err != nil {
+		t.Fatal(err)
+	}
+
+	if listener, err = ListenDaemon(true); err != nil {
+		t.Fatal(err)
+	}
+	_ = listener.Close()
+}
+
+func TestPrepareRunDir(t *testing.T) {
+	dir := useRunDir(t)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := prepareRunDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	if stat, _ := os.Stat(dir); stat.Mode().Perm() != 0700 {
+		t.Errorf("run dir should be only accessible by owner, got %v", stat.Mode().Perm())
+	}
+
+	file := filepath.Join(filepath.Dir(dir), "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := prepareRunDir(file); err == nil {
+		t.Error("file should not be the run dir")
+	}
+}
@@ -0,0 +1,49 @@
+//go:build windows
+// +build windows
+
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_common
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var privilegedLock sync.Mutex
+
+// DaemonRunDir windows serves daemons by tcp ports, there is no dir of sockets
+func DaemonRunDir() string {
+	return ""
+}
+
+func daemonEndpoint(isSudoUser, grpc bool) (string, string) {
+	port := DefaultDaemonPort
+	switch {
+	case isSudoUser && grpc:
+		port = SudoDaemonGrpcPort
+	case isSudoUser:
+		port = SudoDaemonPort
+	case grpc:
+		port = DaemonGrpcPort
+	}
+	return "tcp4", fmt.Sprintf("127.0.0.1:%d", port)
+}
+
+func listen(network, address string) (net.Listener, error) {
+	listener, err := net.Listen(network, address)
+	return listener, errors.Wrap(err, "")
+}
+
+// LockPrivileged there is only one sudo daemon on windows, the privileged
+// operations are locked in process
+func LockPrivileged(operation string, waiting func(holder string)) (func(), error) {
+	privilegedLock.Lock()
+	return privilegedLock.Unlock, nil
+}
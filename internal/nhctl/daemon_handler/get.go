@@ -234,7 +234,9 @@ func HandleGetResourceInfoRequest(request *command.GetResourceInfoCommand) (inte
 			return ParseApplicationsResult(ns, GetAllValidApplicationWithDefaultApp(ns, KubeConfigBytes)), nil
 		} else {
 			meta := appmeta_manager.GetApplicationMeta(ns, request.ResourceName, KubeConfigBytes)
-			return ParseApplicationsResult(ns, []*appmeta.ApplicationMeta{meta}), nil
+			result := ParseApplicationsResult(ns, []*appmeta.ApplicationMeta{meta})
+			result[0].Application[0].Revisions = meta.RevisionInfos()
+			return result, nil
 		}
 	case "crd-list":
 		s, err := resouce_cache.GetSearcherWithLRU(KubeConfigBytes, ns)
@@ -511,8 +513,10 @@ func ParseApplicationsResult(namespace string, metas []*appmeta.ApplicationMeta)
 	for _, meta := range metas {
 		ns.Application = append(
 			ns.Application, &model.ApplicationInfo{
-				Name: meta.Application,
-				Type: string(meta.ApplicationType),
+				Name:      meta.Application,
+				Type:      string(meta.ApplicationType),
+				Revision:  revisionOf(meta),
+				BlockedBy: meta.BlockedBy,
 			},
 		)
 	}
@@ -527,6 +531,13 @@ func ParseApplicationsResult(namespace string, metas []*appmeta.ApplicationMeta)
 	return result
 }
 
+func revisionOf(meta *appmeta.ApplicationMeta) int {
+	if current := meta.CurrentRevision(); current != nil {
+		return current.Revision
+	}
+	return 0
+}
+
 type AppNameAndNid struct {
 	Name string
 	Nid  string
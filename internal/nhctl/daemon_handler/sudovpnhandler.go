@@ -6,6 +6,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"io"
 	"k8s.io/client-go/util/retry"
+	"nocalhost/internal/nhctl/daemon_common"
 	"nocalhost/internal/nhctl/daemon_server/command"
 	"nocalhost/internal/nhctl/vpn/dns"
 	"nocalhost/internal/nhctl/vpn/pkg"
@@ -84,7 +85,13 @@ func HandleSudoVPNOperate(cmd *command.VPNOperateCommand, writer io.WriteCloser)
 			// do until canceled
 			for ctx.Err() == nil && options != nil {
 				func() {
-					errChan, err := options.DoConnect(ctx)
+					var errChan chan error
+					err := lockPrivileged(
+						options.GetLogger(), fmt.Sprintf("connecting to namespace %s", namespace), func() (err error) {
+							errChan, err = options.DoConnect(ctx)
+							return err
+						},
+					)
 					if err != nil {
 						options.GetLogger().Errorln(err)
 						options.GetLogger().Infoln(util.EndSignFailed)
@@ -136,7 +143,34 @@ func HandleSudoVPNOperate(cmd *command.VPNOperateCommand, writer io.WriteCloser)
 	}
 }
 
+// lockPrivileged the tun device, routes and dns are shared by the sudo daemons
+// of all users on the device, so that they are changed one by one
+func lockPrivileged(logger *logrus.Logger, operation string, fn func() error) error {
+	unlock, err := daemon_common.LockPrivileged(
+		operation, func(holder string) {
+			logger.Infof("waiting for the privileged operation: %s", holder)
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fn()
+}
+
 func disconnect(logger *logrus.Logger) {
+	if err := lockPrivileged(
+		logger, "disconnecting", func() error {
+			doDisconnect(logger)
+			return nil
+		},
+	); err != nil {
+		logger.Errorf("failed to lock the privileged operations, err: %v", err)
+		doDisconnect(logger)
+	}
+}
+
+func doDisconnect(logger *logrus.Logger) {
 	for _, function := range remote.CancelFunctions {
 		if function != nil {
 			function()
@@ -382,7 +382,7 @@ func modifyReverseInfo(h *resourceHandler, latest *status) {
 }
 
 func notifySudoDaemonToConnect(uid string, kubeconfigBytes []byte, namespace string) {
-	if !util.IsPortListening(daemon_common.SudoDaemonPort) {
+	if !daemon_common.IsDaemonListening(true) {
 		return
 	}
 	client, err := daemon_client.GetDaemonClient(true)
@@ -417,7 +417,7 @@ func notifySudoDaemonToConnect(uid string, kubeconfigBytes []byte, namespace str
 
 // disconnect from special cluster
 func notifySudoDaemonToDisConnect(uid string, kubeconfigBytes []byte, namespace string) {
-	if !util.IsPortListening(daemon_common.SudoDaemonPort) {
+	if !daemon_common.IsDaemonListening(true) {
 		return
 	}
 	client, err := daemon_client.GetDaemonClient(true)
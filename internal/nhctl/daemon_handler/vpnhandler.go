@@ -240,7 +240,7 @@ func transStreamToWriter(r io.Reader, writer ...io.Writer) bool {
 }
 
 func connectToNamespace(ctx context.Context, writer io.WriteCloser, kubeconfigPath, namespace string) error {
-	if !util.IsPortListening(daemon_common.SudoDaemonPort) {
+	if !daemon_common.IsDaemonListening(true) {
 		return errors.New("sudo daemon is not running")
 	}
 	client, err := daemon_client.GetDaemonClient(true)
@@ -292,7 +292,7 @@ func disconnectedFromNamespace(ctx context.Context, writer io.WriteCloser, kubec
 	if err = updateConnectConfigMap(options.GetClientSet().CoreV1().ConfigMaps(namespace), deleteFunc); err != nil {
 		logger.Infof("error while remove connection info of namespace: %s", namespace)
 	}
-	if !util.IsPortListening(daemon_common.SudoDaemonPort) {
+	if !daemon_common.IsDaemonListening(true) {
 		return errors.New("sudo daemon is not running")
 	}
 	client, err := daemon_client.GetDaemonClient(true)
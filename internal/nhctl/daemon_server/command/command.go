@@ -20,6 +20,7 @@ const (
 	RestartDaemonServer   DaemonCommandType = "RestartDaemonServer"
 	GetDaemonServerInfo   DaemonCommandType = "GetDaemonServerInfo"
 	GetDaemonServerStatus DaemonCommandType = "GetDaemonServerStatus"
+	GetDaemonDiagnostics  DaemonCommandType = "GetDaemonDiagnostics"
 	GetApplicationMeta    DaemonCommandType = "GetApplicationMeta"
 	GetApplicationMetas   DaemonCommandType = "GetApplicationMetas"
 	GetResourceInfo       DaemonCommandType = "GetResourceInfo"
@@ -69,6 +70,10 @@ type PortForwardCommand struct {
 	OwnerKind       string            `json:"ownerKind"`
 	OwnerApiVersion string            `json:"ownerApiVersion"`
 	OwnerName       string            `json:"ownerName"`
+	// Reverse forward the remote port in the pod to the local one
+	Reverse bool `json:"reverse,omitempty"`
+	// Protocol tcp or udp, tcp if empty
+	Protocol string `json:"protocol,omitempty"`
 }
 
 type GetApplicationMetaCommand struct {
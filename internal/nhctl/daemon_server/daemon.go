@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	ps "github.com/mitchellh/go-ps"
 	"github.com/pkg/errors"
 	"io"
 	"io/ioutil"
@@ -30,6 +31,7 @@ import (
 	"nocalhost/pkg/nhctl/clientgoutils"
 	k8sutil "nocalhost/pkg/nhctl/k8sutils"
 	"nocalhost/pkg/nhctl/log"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -50,13 +52,6 @@ func init() {
 	pfManager = NewPortForwardManager()
 }
 
-func daemonListenPort() int {
-	if isSudo {
-		return daemon_common.SudoDaemonPort
-	}
-	return daemon_common.DefaultDaemonPort
-}
-
 func StartDaemon(isSudoUser bool, v string, c string) error {
 	_const.IsDaemon = true
 
@@ -71,11 +66,18 @@ func StartDaemon(isSudoUser bool, v string, c string) error {
 		return errors.New("Failed to start daemon server with sudo")
 	}
 	isSudo = isSudoUser // Mark daemon server if it is run as sudo
-	address := fmt.Sprintf("%s:%d", "0.0.0.0", daemonListenPort())
-	listener, err := net.Listen("tcp4", address)
-	if err != nil {
+	waitForPreviousDaemonToExit(10 * time.Second)
+	if daemon_common.IsDaemonListening(isSudoUser) {
 		return errors.New("Daemon is already running in the background")
 	}
+	listener, err := daemon_common.ListenDaemon(isSudoUser)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Failed to listen on %s", daemon_common.DaemonAddress(isSudoUser)))
+	}
+	log.Infof("Daemon server listening on %s", listener.Addr())
+
+	// serve the versioned grpc api beside the json protocol
+	go startGrpcServer()
 
 	// run the dev event listener
 	if !isSudoUser {
@@ -105,8 +107,9 @@ func StartDaemon(isSudoUser bool, v string, c string) error {
 					return nil
 				}
 
+				sessionLog := log.Session(pack.Ns, pack.AppName, pack.Event.ResourceName)
 				if pack.Event.EventType == appmeta.DEV_END {
-					log.Logf(
+					sessionLog.Infof(
 						"Receive dev end event, stopping sync and pf for %s-%s-%s", pack.Ns, pack.AppName,
 						pack.Event.ResourceName,
 					)
@@ -123,7 +126,7 @@ func StartDaemon(isSudoUser bool, v string, c string) error {
 						return nil
 					}
 
-					log.Logf(
+					sessionLog.Infof(
 						"Receive dev start event, stopping pf for %s-%s-%s", pack.Ns, pack.AppName,
 						pack.Event.ResourceName,
 					)
@@ -140,6 +143,7 @@ func StartDaemon(isSudoUser bool, v string, c string) error {
 		go cronJobForUpdatingHub()
 		// Listen http
 		go func() {
+			registerDiagnosticsHandlers()
 			if !isSudo {
 				startHttpServer()
 			} else {
@@ -168,7 +172,7 @@ func StartDaemon(isSudoUser bool, v string, c string) error {
 			if err != nil {
 				log.Logf("Accept connection error occurs: %s", err.Error())
 				if strings.Contains(strings.ToLower(err.Error()), "use of closed network connection") {
-					log.Logf("Port %d has been closed: %s", daemonListenPort(), err.Error())
+					log.Logf("Listener %s has been closed: %s", listener.Addr(), err.Error())
 					return
 				}
 				log.LogE(errors.Wrap(err, "Failed to accept a connection"))
@@ -338,6 +342,7 @@ func handleCommand(conn net.Conn, bys []byte, cmdType command.DaemonCommandType,
 			conn, func(conn net.Conn) (interface{}, error) {
 				return &daemon_common.DaemonServerInfo{
 					Version: version, CommitId: commitId, NhctlPath: startUpPath, Upgrading: upgrading,
+					ProtocolVersion: daemon_common.ProtocolVersion, Pid: os.Getpid(),
 				}, nil
 			},
 		)
@@ -351,6 +356,13 @@ func handleCommand(conn net.Conn, bys []byte, cmdType command.DaemonCommandType,
 			},
 		)
 
+	case command.GetDaemonDiagnostics:
+		err = Process(
+			conn, func(conn net.Conn) (interface{}, error) {
+				return collectDiagnostics(), nil
+			},
+		)
+
 	case command.AuthCheck:
 		err = Process(
 			conn, func(conn net.Conn) (interface{}, error) {
@@ -491,6 +503,7 @@ func handleCommand(conn net.Conn, bys []byte, cmdType command.DaemonCommandType,
 	}
 
 	if err != nil {
+		errorCounter.inc(string(cmdType))
 		log.WarnE(err, "Processing command occurs error")
 	}
 }
@@ -574,7 +587,26 @@ func handlerRestartDaemonServerCommand(isSudoUser bool, clientPath string) error
 		daemonArgs = append(daemonArgs, "--sudo", "true")
 	}
 	tcpCancelFunc() // Stop listening tcp port
-	return daemon.RunSubProcess(daemonArgs, nil, false)
+	// the new daemon recovers the port-forwards after this one exits
+	env := append(os.Environ(), fmt.Sprintf("%s=%d", _const.PreviousDaemonPidEnvKey, os.Getpid()))
+	return daemon.RunSubProcess(daemonArgs, env, false)
+}
+
+// waitForPreviousDaemonToExit waits for the daemon upgraded to release the
+// local ports of its port-forwards, which are recovered by this one
+func waitForPreviousDaemonToExit(timeout time.Duration) {
+	pid, err := strconv.Atoi(os.Getenv(_const.PreviousDaemonPidEnvKey))
+	if err != nil {
+		return
+	}
+	_ = os.Unsetenv(_const.PreviousDaemonPidEnvKey)
+	log.Infof("Waiting for the previous daemon %d to exit", pid)
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(200 * time.Millisecond) {
+		if process, err := ps.FindProcess(pid); err == nil && process == nil {
+			return
+		}
+	}
+	log.Warnf("Previous daemon %d does not exit in %s", pid, timeout)
 }
 
 func handleStopPortForwardCommand(cmd *command.PortForwardCommand) error {
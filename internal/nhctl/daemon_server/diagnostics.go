@@ -0,0 +1,177 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"nocalhost/internal/nhctl/appmeta_manager"
+	"nocalhost/internal/nhctl/daemon_common"
+	"nocalhost/internal/nhctl/nocalhost"
+	"nocalhost/internal/nhctl/nocalhost_path"
+	"nocalhost/internal/nhctl/resouce_cache"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// portForwardErrorKind the kind of errors counted while port-forwards reconnecting
+const portForwardErrorKind = "PortForwardReconnect"
+
+var (
+	daemonStartTime = time.Now()
+	errorCounter    = &counter{counts: map[string]int{}}
+)
+
+// counter the errors by kinds, such as the command types
+type counter struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+func (c *counter) inc(kind string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.counts[kind]++
+}
+
+func (c *counter) snapshot() map[string]int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	result := make(map[string]int, len(c.counts))
+	for kind, count := range c.counts {
+		result[kind] = count
+	}
+	return result
+}
+
+func collectDiagnostics() *daemon_common.DaemonDiagnostics {
+	memStats := &runtime.MemStats{}
+	runtime.ReadMemStats(memStats)
+	return &daemon_common.DaemonDiagnostics{
+		Version:         version,
+		CommitId:        commitId,
+		Sudo:            isSudo,
+		UptimeSeconds:   int64(time.Since(daemonStartTime).Seconds()),
+		Goroutines:      runtime.NumGoroutine(),
+		MemAllocBytes:   memStats.Alloc,
+		PortForwards:    pfManager.ListAllRunningPFGoRoutineProfile(),
+		SyncSessions:    syncSessions(),
+		KubeconfigCache: kubeconfigCache(),
+		Errors:          errorCounter.snapshot(),
+	}
+}
+
+// syncSessions the file syncs recorded in the profiles of local applications
+func syncSessions() []*daemon_common.SyncSession {
+	sessions := make([]*daemon_common.SyncSession, 0)
+	apps, err := nocalhost.GetNsAndApplicationInfo(false, false)
+	if err != nil {
+		return sessions
+	}
+	for _, a := range apps {
+		appProfile, err := nocalhost.GetProfileV2(a.Namespace, a.Name, a.Nid)
+		if err != nil || appProfile == nil {
+			continue
+		}
+		for _, svcProfile := range appProfile.SvcProfile {
+			if svcProfile == nil || !svcProfile.Syncing {
+				continue
+			}
+			sessions = append(
+				sessions, &daemon_common.SyncSession{
+					NameSpace:  a.Namespace,
+					AppName:    a.Name,
+					SvcName:    svcProfile.GetName(),
+					SvcType:    svcProfile.GetType(),
+					SyncEngine: svcProfile.SyncEngine,
+					Paused:     svcProfile.SyncPaused,
+				},
+			)
+		}
+	}
+	return sessions
+}
+
+func kubeconfigCache() *daemon_common.KubeconfigCache {
+	cache := &daemon_common.KubeconfigCache{
+		Searchers:       resouce_cache.SearcherCount(),
+		AppMetaWatchers: appmeta_manager.WatcherCount(),
+	}
+	files, err := ioutil.ReadDir(nocalhost_path.GetNhctlKubeconfigDir(""))
+	if err != nil {
+		return cache
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			cache.Files++
+			cache.Bytes += file.Size()
+		}
+	}
+	return cache
+}
+
+// registerDiagnosticsHandlers serves the diagnostics by json on
+// /debug/diagnostics, and by the text format of prometheus on /metrics
+func registerDiagnosticsHandlers() {
+	http.HandleFunc(
+		"/debug/diagnostics", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "application/json")
+			if err := json.NewEncoder(w).Encode(collectDiagnostics()); err != nil {
+				log.WarnE(err, "Failed to write diagnostics")
+			}
+		},
+	)
+	http.HandleFunc(
+		"/metrics", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("content-type", "text/plain; version=0.0.4")
+			writeMetrics(w, collectDiagnostics())
+		},
+	)
+}
+
+func writeMetrics(w io.Writer, d *daemon_common.DaemonDiagnostics) {
+	reconnects := 0
+	for _, pf := range d.PortForwards {
+		reconnects += pf.Reconnects
+	}
+	gauge := func(name, help string, value interface{}) {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	gauge("nhctl_daemon_uptime_seconds", "Seconds since the daemon started.", d.UptimeSeconds)
+	gauge("nhctl_daemon_goroutines", "Number of goroutines of the daemon.", d.Goroutines)
+	gauge("nhctl_daemon_mem_alloc_bytes", "Bytes of heap allocated by the daemon.", d.MemAllocBytes)
+	gauge("nhctl_daemon_port_forwards", "Number of port-forwards running.", len(d.PortForwards))
+	gauge("nhctl_daemon_port_forward_reconnects", "Reconnects of the port-forwards running.", reconnects)
+	gauge("nhctl_daemon_sync_sessions", "Number of file syncs started by this device.", len(d.SyncSessions))
+	if d.KubeconfigCache != nil {
+		gauge("nhctl_daemon_kubeconfig_cache_files", "Number of kubeconfig files cached.", d.KubeconfigCache.Files)
+		gauge("nhctl_daemon_kubeconfig_cache_bytes", "Bytes of kubeconfig files cached.", d.KubeconfigCache.Bytes)
+		gauge("nhctl_daemon_searchers", "Number of resource searchers cached.", d.KubeconfigCache.Searchers)
+		gauge(
+			"nhctl_daemon_app_meta_watchers", "Number of application meta watchers.",
+			d.KubeconfigCache.AppMetaWatchers,
+		)
+	}
+
+	kinds := make([]string, 0, len(d.Errors))
+	for kind := range d.Errors {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	_, _ = fmt.Fprint(
+		w, "# HELP nhctl_daemon_errors_total Errors occurred by the kinds.\n# TYPE nhctl_daemon_errors_total counter\n",
+	)
+	for _, kind := range kinds {
+		_, _ = fmt.Fprintf(w, "nhctl_daemon_errors_total{kind=%q} %d\n", kind, d.Errors[kind])
+	}
+}
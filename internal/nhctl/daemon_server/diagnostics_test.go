@@ -0,0 +1,53 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_server
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"nocalhost/internal/nhctl/daemon_common"
+)
+
+func TestCounter(t *testing.T) {
+	c := &counter{counts: map[string]int{}}
+	c.inc("StartPortForward")
+	c.inc("StartPortForward")
+	c.inc(portForwardErrorKind)
+
+	snapshot := c.snapshot()
+	c.inc(portForwardErrorKind)
+	if snapshot["StartPortForward"] != 2 || snapshot[portForwardErrorKind] != 1 {
+		t.Errorf("unexpected snapshot %v", snapshot)
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writeMetrics(
+		buf, &daemon_common.DaemonDiagnostics{
+			Goroutines: 42,
+			PortForwards: []*daemon_common.PortForwardProfile{
+				{LocalPort: 8080, RemotePort: 80, Reconnects: 2}, {LocalPort: 53, RemotePort: 53, Reconnects: 1},
+			},
+			KubeconfigCache: &daemon_common.KubeconfigCache{Files: 3},
+			Errors:          map[string]int{"StopPortForward": 1, "StartPortForward": 4},
+		},
+	)
+	metrics := buf.String()
+	for _, expect := range []string{
+		"nhctl_daemon_goroutines 42\n",
+		"nhctl_daemon_port_forwards 2\n",
+		"nhctl_daemon_port_forward_reconnects 3\n",
+		"nhctl_daemon_kubeconfig_cache_files 3\n",
+		"nhctl_daemon_errors_total{kind=\"StartPortForward\"} 4\nnhctl_daemon_errors_total{kind=\"StopPortForward\"} 1\n",
+	} {
+		if !strings.Contains(metrics, expect) {
+			t.Errorf("%q not found in metrics:\n%s", expect, metrics)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"nocalhost/internal/nhctl/app"
+	"nocalhost/internal/nhctl/common/base"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/controller"
+	"nocalhost/internal/nhctl/daemon_common"
+	"nocalhost/internal/nhctl/daemon_server/command"
+	pb "nocalhost/internal/nhctl/rpc/daemon/v1"
+	"nocalhost/internal/nhctl/syncthing/network/req"
+	k8sutil "nocalhost/pkg/nhctl/k8sutils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const defaultSyncStatusInterval = 2 * time.Second
+
+// startGrpcServer serves the DaemonService until the listener of daemon is
+// stopped, so that the restarted daemon listens on the same socket
+func startGrpcServer() {
+	listener, err := daemon_common.ListenDaemonGrpc(isSudo)
+	if err != nil {
+		log.ErrorE(err, "Failed to listen grpc")
+		return
+	}
+	address := listener.Addr().String()
+	server := grpc.NewServer()
+	pb.RegisterDaemonServiceServer(server, &daemonService{})
+
+	go func() {
+		<-tcpCtx.Done()
+		log.Log("Stop serving grpc for daemon server")
+		server.Stop()
+	}()
+
+	log.Infof("Starting grpc server on %s", address)
+	if err = server.Serve(listener); err != nil {
+		log.ErrorE(errors.Wrap(err, ""), "Grpc server occur errors")
+	}
+}
+
+type daemonService struct{}
+
+func (s *daemonService) GetDaemonInfo(context.Context, *pb.GetDaemonInfoRequest) (*pb.DaemonInfo, error) {
+	return &pb.DaemonInfo{
+		Version: version, CommitId: commitId, NhctlPath: startUpPath, Upgrading: upgrading, Sudo: isSudo,
+	}, nil
+}
+
+func (s *daemonService) ListPortForwards(context.Context, *pb.ListPortForwardsRequest) (
+	*pb.ListPortForwardsResponse, error,
+) {
+	resp := &pb.ListPortForwardsResponse{}
+	for _, pf := range pfManager.ListAllRunningPFGoRoutineProfile() {
+		resp.PortForwards = append(resp.PortForwards, toPortForward(pf))
+	}
+	return resp, nil
+}
+
+func (s *daemonService) StartPortForward(_ context.Context, r *pb.StartPortForwardRequest) (*pb.PortForward, error) {
+	if r.LocalPort <= 0 || r.RemotePort <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "local_port and remote_port must be specified")
+	}
+	protocol := r.Protocol
+	if protocol == "" {
+		protocol = _const.TCPProtocol
+	}
+	if protocol != _const.TCPProtocol && protocol != _const.UDPProtocol {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Unsupported protocol %s", protocol))
+	}
+	nhController, err := workloadController(r.Workload)
+	if err != nil {
+		return nil, err
+	}
+
+	podName := r.PodName
+	if podName == "" {
+		if podName, err = nhController.GetDevModePodName(); err != nil {
+			return nil, status.Error(
+				codes.FailedPrecondition, "pod_name must be specified if the workload is not in DevMode",
+			)
+		}
+	}
+
+	startCmd := &command.PortForwardCommand{
+		CommandType: command.StartPortForward,
+		NameSpace:   nhController.NameSpace,
+		AppName:     nhController.AppName,
+		Service:     nhController.Name,
+		ServiceType: nhController.Type.String(),
+		PodName:     podName,
+		LocalPort:   int(r.LocalPort),
+		RemotePort:  int(r.RemotePort),
+		Nid:         nhController.AppMeta.NamespaceId,
+		Reverse:     r.Reverse,
+		Protocol:    protocol,
+	}
+	if err = pfManager.StartPortForwardGoRoutine(startCmd, true); err != nil {
+		return nil, err
+	}
+	_ = nhController.SetPortForwardedStatus(true)
+
+	return &pb.PortForward{
+		Namespace:   startCmd.NameSpace,
+		Application: startCmd.AppName,
+		Service:     startCmd.Service,
+		ServiceType: startCmd.ServiceType,
+		LocalPort:   r.LocalPort,
+		RemotePort:  r.RemotePort,
+		Reverse:     startCmd.Reverse,
+		Protocol:    startCmd.Protocol,
+	}, nil
+}
+
+func (s *daemonService) StopPortForward(_ context.Context, r *pb.StopPortForwardRequest) (
+	*pb.StopPortForwardResponse, error,
+) {
+	nhController, err := workloadController(r.Workload)
+	if err != nil {
+		return nil, err
+	}
+	err = pfManager.StopPortForwardGoRoutine(
+		&command.PortForwardCommand{
+			CommandType: command.StopPortForward,
+			NameSpace:   nhController.NameSpace,
+			AppName:     nhController.AppName,
+			Service:     nhController.Name,
+			ServiceType: nhController.Type.String(),
+			LocalPort:   int(r.LocalPort),
+			RemotePort:  int(r.RemotePort),
+			Nid:         nhController.AppMeta.NamespaceId,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.StopPortForwardResponse{}, nil
+}
+
+func (s *daemonService) GetServiceProfile(_ context.Context, r *pb.GetServiceProfileRequest) (
+	*pb.ServiceProfile, error,
+) {
+	nhController, err := workloadController(r.Workload)
+	if err != nil {
+		return nil, err
+	}
+	svcProfile := nhController.GetDescription()
+	if svcProfile == nil {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("Profile of %s not found", nhController.Name))
+	}
+
+	raw, err := json.Marshal(svcProfile)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	resp := &pb.ServiceProfile{
+		Name:          svcProfile.GetName(),
+		Type:          svcProfile.GetType(),
+		DevelopStatus: svcProfile.DevelopStatus,
+		DevModeType:   string(svcProfile.DevModeType),
+		Possess:       svcProfile.Possess,
+		Syncing:       svcProfile.Syncing,
+		SyncPaused:    svcProfile.SyncPaused,
+		SyncEngine:    svcProfile.SyncEngine,
+		Associate:     svcProfile.Associate,
+		RawJson:       string(raw),
+	}
+	for _, pf := range svcProfile.DevPortForwardList {
+		resp.DevPortForwards = append(
+			resp.DevPortForwards, &pb.DevPortForward{
+				LocalPort:  int32(pf.LocalPort),
+				RemotePort: int32(pf.RemotePort),
+				Role:       pf.Role,
+				Status:     pf.Status,
+				Reason:     pf.Reason,
+				PodName:    pf.PodName,
+				Reverse:    pf.Reverse,
+				Protocol:   pf.GetProtocol(),
+				Reconnects: int32(pf.Reconnects),
+			},
+		)
+	}
+	return resp, nil
+}
+
+func (s *daemonService) WatchSyncStatus(r *pb.WatchSyncStatusRequest, stream pb.DaemonService_WatchSyncStatusServer) error {
+	nhController, err := workloadController(r.Workload)
+	if err != nil {
+		return err
+	}
+	interval := defaultSyncStatusInterval
+	if r.IntervalSeconds > 0 {
+		interval = time.Duration(r.IntervalSeconds) * time.Second
+	}
+
+	var last *req.SyncthingStatus
+	for {
+		current := syncStatusOf(nhController)
+		if last == nil || *current != *last {
+			if err = stream.Send(toSyncStatus(current)); err != nil {
+				return err
+			}
+			last = current
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *daemonService) StartDevMode(r *pb.StartDevModeRequest, stream pb.DaemonService_StartDevModeServer) error {
+	nhController, err := workloadController(r.Workload)
+	if err != nil {
+		return err
+	}
+	args := append(devStartArgs(nhController, r), "--kubeconfig", k8sutil.GetOrGenKubeConfigPath(r.Workload.Kubeconfig))
+	sessionLog := log.Session(nhController.NameSpace, nhController.AppName, nhController.Name)
+	sessionLog.Infof("Starting DevMode of %s by grpc", nhController.Name)
+
+	cmd := exec.CommandContext(stream.Context(), startUpPath, args...)
+	reader, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	cmd.Stderr = cmd.Stdout
+	if err = cmd.Start(); err != nil {
+		return errors.Wrap(err, "")
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		sessionLog.Infof("%s", scanner.Text())
+		if err = stream.Send(&pb.StartDevModeResponse{Output: scanner.Text()}); err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return err
+		}
+	}
+
+	done := &pb.StartDevModeResponse{Done: true}
+	if err = cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return errors.Wrap(err, "")
+		}
+		done.ExitCode = int32(exitErr.ExitCode())
+	}
+	sessionLog.Infof("DevMode of %s started by grpc exited with %d", nhController.Name, done.ExitCode)
+	return stream.Send(done)
+}
+
+// devStartArgs of nhctl dev start without kubeconfig, the terminal is never
+// entered as no tty is attached to the daemon
+func devStartArgs(c *controller.Controller, r *pb.StartDevModeRequest) []string {
+	args := []string{
+		"dev", "start", c.AppName, "-d", c.Name, "-t", c.Type.String(), "-n", c.NameSpace, "--without-terminal",
+	}
+	if r.Container != "" {
+		args = append(args, "-c", r.Container)
+	}
+	if r.Image != "" {
+		args = append(args, "-i", r.Image)
+	}
+	for _, dir := range r.LocalSync {
+		args = append(args, "-s", dir)
+	}
+	if r.DevModeType != "" {
+		args = append(args, "-m", r.DevModeType)
+	}
+	if r.WithoutSync {
+		args = append(args, "--without-sync")
+	}
+	return args
+}
+
+// workloadController the controller of workload, the kubeconfig content is
+// saved as the one the profile refers to
+func workloadController(w *pb.Workload) (*controller.Controller, error) {
+	if w == nil || w.Kubeconfig == "" || w.Namespace == "" || w.Application == "" || w.Name == "" {
+		return nil, status.Error(
+			codes.InvalidArgument, "kubeconfig, namespace, application and name of workload must be specified",
+		)
+	}
+	svcType := w.Type
+	if svcType == "" {
+		svcType = string(base.Deployment)
+	}
+
+	kubeconfig := k8sutil.GetOrGenKubeConfigPath(w.Kubeconfig)
+	nhApp, err := app.NewApplication(w.Application, w.Namespace, kubeconfig, true)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	nhController, err := nhApp.InitAndCheckIfSvcExist(w.Name, svcType)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return nhController, nil
+}
+
+// syncStatusOf the status as `nhctl sync-status` reports
+func syncStatusOf(c *controller.Controller) *req.SyncthingStatus {
+	if !c.IsInDevMode() {
+		return req.NotInDevModeTemplate
+	}
+	if c.IsInDevModeStarting() {
+		return req.DevModeStarting
+	}
+	if !c.IsProcessor() {
+		return req.NotProcessor
+	}
+	if svcProfile, _ := c.GetProfile(); svcProfile != nil && svcProfile.SyncPaused {
+		return req.SyncPausedTemplate
+	}
+	if fileSync := c.NewFileSync(); fileSync.Engine() != _const.SyncthingSyncEngine {
+		return fileSync.Status()
+	}
+	return c.NewSyncthingHttpClient(2).GetSyncthingStatus()
+}
+
+func toSyncStatus(s *req.SyncthingStatus) *pb.SyncStatus {
+	return &pb.SyncStatus{Status: string(s.Status), Msg: s.Msg, Tips: s.Tips, OutOfSync: s.OutOfSync, Gui: s.Gui}
+}
+
+func toPortForward(pf *daemon_common.PortForwardProfile) *pb.PortForward {
+	return &pb.PortForward{
+		Namespace:   pf.NameSpace,
+		Application: pf.AppName,
+		Service:     pf.SvcName,
+		ServiceType: pf.SvcType,
+		Role:        pf.Role,
+		LocalPort:   int32(pf.LocalPort),
+		RemotePort:  int32(pf.RemotePort),
+		Reverse:     pf.Reverse,
+		Protocol:    pf.Protocol,
+		Reconnects:  int32(pf.Reconnects),
+	}
+}
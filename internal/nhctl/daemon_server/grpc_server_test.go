@@ -0,0 +1,86 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_server
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"nocalhost/internal/nhctl/common/base"
+	"nocalhost/internal/nhctl/controller"
+	pb "nocalhost/internal/nhctl/rpc/daemon/v1"
+)
+
+func dialDaemonService(t *testing.T) pb.DaemonServiceClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	pb.RegisterDaemonServiceServer(server, &daemonService{})
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial(
+		"bufnet", grpc.WithInsecure(), grpc.WithContextDialer(
+			func(context.Context, string) (net.Conn, error) { return listener.Dial() },
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewDaemonServiceClient(conn)
+}
+
+func TestDaemonService(t *testing.T) {
+	client := dialDaemonService(t)
+	ctx := context.Background()
+
+	info, err := client.GetDaemonInfo(ctx, &pb.GetDaemonInfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != version || info.Sudo != isSudo {
+		t.Errorf("unexpected daemon info %v", info)
+	}
+
+	pfs, err := client.ListPortForwards(ctx, &pb.ListPortForwardsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pfs.PortForwards) != len(pfManager.ListAllRunningPFGoRoutineProfile()) {
+		t.Errorf("got %d port-forwards", len(pfs.PortForwards))
+	}
+
+	_, err = client.StartPortForward(ctx, &pb.StartPortForwardRequest{LocalPort: 8080, RemotePort: 80, Protocol: "sctp"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("sctp should be invalid, got %v", err)
+	}
+	_, err = client.GetServiceProfile(ctx, &pb.GetServiceProfileRequest{Workload: &pb.Workload{Name: "details"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("workload without kubeconfig should be invalid, got %v", err)
+	}
+}
+
+func TestDevStartArgs(t *testing.T) {
+	c := &controller.Controller{NameSpace: "dev", AppName: "bookinfo", Name: "details", Type: base.Deployment}
+	args := devStartArgs(
+		c, &pb.StartDevModeRequest{
+			Container: "details", LocalSync: []string{"/src/details"}, DevModeType: "duplicate", WithoutSync: true,
+		},
+	)
+	expect := []string{
+		"dev", "start", "bookinfo", "-d", "details", "-t", "deployment", "-n", "dev", "--without-terminal",
+		"-c", "details", "-s", "/src/details", "-m", "duplicate", "--without-sync",
+	}
+	if !reflect.DeepEqual(args, expect) {
+		t.Errorf("got %v, expect %v", args, expect)
+	}
+}
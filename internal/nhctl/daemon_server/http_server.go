@@ -137,6 +137,11 @@ func handlingConfigSave(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := config_validate.ValidateSchema(bys, config_validate.SchemaOf(&profile.ServiceConfigV2{})); err != nil {
+		fail(w, err.Error())
+		return
+	}
+
 	svcConfig := &profile.ServiceConfigV2{}
 	err = yaml.Unmarshal(bys, svcConfig)
 	if err != nil {
@@ -0,0 +1,109 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_server
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	_const "nocalhost/internal/nhctl/const"
+)
+
+const (
+	minReconnectBackOff = 2 * time.Second
+	maxReconnectBackOff = 60 * time.Second
+)
+
+// the interval of checking the local port of port-forward
+var pfHealthCheckInterval = 10 * time.Second
+
+// nextBackOff double the back off of reconnecting, up to the max one
+func nextBackOff(backOff time.Duration) time.Duration {
+	backOff *= 2
+	if backOff > maxReconnectBackOff {
+		return maxReconnectBackOff
+	}
+	if backOff < minReconnectBackOff {
+		return minReconnectBackOff
+	}
+	return backOff
+}
+
+// sendErrGracefully the first error stops the port-forward, the others are
+// dropped, so that the senders are never blocked
+func sendErrGracefully(errCh chan<- error, err error) {
+	select {
+	case errCh <- err:
+	default:
+	}
+}
+
+// podHealthChecker the port-forward of api server keeps listening after the
+// containers of pod restarted, but the connections of it fail, so the pod is
+// unhealthy once it is not running or restarted since the first checked
+type podHealthChecker struct {
+	checked  bool
+	restarts int32
+}
+
+func (h *podHealthChecker) check(object interface{}) error {
+	us, ok := object.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	var pod corev1.Pod
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(us.UnstructuredContent(), &pod); err != nil {
+		return nil
+	}
+	return h.checkPod(&pod)
+}
+
+func (h *podHealthChecker) checkPod(pod *corev1.Pod) error {
+	if pod.DeletionTimestamp != nil {
+		return errors.New(fmt.Sprintf("Pod %s is terminating", pod.Name))
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return errors.New(fmt.Sprintf("Pod %s is %s", pod.Name, pod.Status.Phase))
+	}
+
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	if !h.checked {
+		h.checked, h.restarts = true, restarts
+		return nil
+	}
+	if restarts > h.restarts {
+		return errors.New(fmt.Sprintf("Containers of pod %s restarted", pod.Name))
+	}
+	return nil
+}
+
+// checkLocalPortListened the port-forward is dead once the local port is
+// able to be listened again, the reverse one is listened in the pod
+func checkLocalPortListened(localPort int, protocol string) error {
+	address := fmt.Sprintf("127.0.0.1:%d", localPort)
+	if protocol == _const.UDPProtocol {
+		conn, err := net.ListenPacket("udp", address)
+		if err != nil {
+			return nil
+		}
+		_ = conn.Close()
+	} else {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil
+		}
+		_ = listener.Close()
+	}
+	return errors.New(fmt.Sprintf("Local port %d is not listened by port-forward any more", localPort))
+}
@@ -0,0 +1,66 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package daemon_server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	_const "nocalhost/internal/nhctl/const"
+)
+
+func TestNextBackOff(t *testing.T) {
+	backOff := minReconnectBackOff
+	for _, expect := range []time.Duration{4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second,
+		maxReconnectBackOff, maxReconnectBackOff} {
+		if backOff = nextBackOff(backOff); backOff != expect {
+			t.Errorf("got %s, expect %s", backOff, expect)
+		}
+	}
+}
+
+func TestPodHealthChecker(t *testing.T) {
+	pod := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{RestartCount: 1}},
+		},
+	}
+	checker := &podHealthChecker{}
+	if err := checker.checkPod(pod); err != nil {
+		t.Error(err)
+	}
+	if err := checker.checkPod(pod); err != nil {
+		t.Error(err)
+	}
+
+	pod.Status.ContainerStatuses[0].RestartCount = 2
+	if err := checker.checkPod(pod); err == nil {
+		t.Error("the restarted pod should be unhealthy")
+	}
+
+	pod.Status.Phase = corev1.PodPending
+	if err := (&podHealthChecker{}).checkPod(pod); err == nil {
+		t.Error("the pending pod should be unhealthy")
+	}
+}
+
+func TestCheckLocalPortListened(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	if err = checkLocalPortListened(port, _const.TCPProtocol); err != nil {
+		t.Error(err)
+	}
+	_ = listener.Close()
+	if err = checkLocalPortListened(port, _const.TCPProtocol); err == nil {
+		t.Error("the port closed should be found")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
+	"io"
 	"io/ioutil"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
@@ -19,13 +20,16 @@ import (
 	"net"
 	"nocalhost/internal/nhctl/app"
 	"nocalhost/internal/nhctl/common/base"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/daemon_common"
 	"nocalhost/internal/nhctl/daemon_server/command"
 	"nocalhost/internal/nhctl/dbutils"
+	"nocalhost/internal/nhctl/dev_ssh"
 	"nocalhost/internal/nhctl/nocalhost"
 	"nocalhost/internal/nhctl/nocalhost/db"
 	"nocalhost/internal/nhctl/nocalhost_path"
 	"nocalhost/internal/nhctl/profile"
+	"nocalhost/internal/nhctl/udp_relay"
 	"nocalhost/internal/nhctl/utils"
 	"nocalhost/internal/nhctl/watcher"
 	"nocalhost/pkg/nhctl/clientgoutils"
@@ -131,6 +135,8 @@ func (p *PortForwardManager) RecoverPortForwardForApplication(ns, appName, nid s
 						OwnerKind:       pf.OwnerKind,
 						OwnerApiVersion: pf.OwnerApiVersion,
 						Labels:          pf.Labels,
+						Reverse:         pf.Reverse,
+						Protocol:        pf.GetProtocol(),
 					}, false,
 				)
 				if err != nil {
@@ -249,12 +255,30 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 		return err
 	}
 
-	address := fmt.Sprintf("0.0.0.0:%d", startCmd.LocalPort)
-	listener, err := net.Listen("tcp", address)
-	if err != nil {
-		return errors.New(fmt.Sprintf("Port %d is unavailable: %s", startCmd.LocalPort, err.Error()))
+	if startCmd.Protocol == "" {
+		startCmd.Protocol = _const.TCPProtocol
+	}
+	if startCmd.Reverse && startCmd.Protocol != _const.TCPProtocol {
+		return errors.New("Reverse port-forward only supports tcp")
+	}
+
+	// the local port of reverse port-forward is listened by the local server
+	if !startCmd.Reverse {
+		address := fmt.Sprintf("0.0.0.0:%d", startCmd.LocalPort)
+		if startCmd.Protocol == _const.UDPProtocol {
+			conn, err := net.ListenPacket("udp", address)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Udp port %d is unavailable: %s", startCmd.LocalPort, err.Error()))
+			}
+			_ = conn.Close()
+		} else {
+			listener, err := net.Listen("tcp", address)
+			if err != nil {
+				return errors.New(fmt.Sprintf("Port %d is unavailable: %s", startCmd.LocalPort, err.Error()))
+			}
+			_ = listener.Close()
+		}
 	}
-	_ = listener.Close()
 
 	nhController, err := nocalhostApp.Controller(startCmd.Service, base.SvcType(startCmd.ServiceType))
 	if err != nil {
@@ -267,9 +291,14 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 		// first find the pod should be port-forward
 		var currentPod *corev1.Pod
 
-		if currentPod, err = nhController.Client.GetPod(startCmd.PodName); err != nil && len(startCmd.Labels) > 0 {
+		// the terminating pod is replaced by the new one of the same labels
+		if currentPod, err = nhController.Client.GetPod(startCmd.PodName); (err != nil ||
+			currentPod.DeletionTimestamp != nil) && len(startCmd.Labels) > 0 {
 			if pods, _ := nhController.Client.Labels(startCmd.Labels).ListPods(); len(pods) > 0 {
 				for _, pod := range pods {
+					if pod.DeletionTimestamp != nil {
+						continue
+					}
 
 					if startCmd.OwnerName != "" {
 						controller := GetTopController(pod.GetOwnerReferences(), nhController.Client)
@@ -314,6 +343,8 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 			Sudo:            isSudo,
 			DaemonServerPid: os.Getpid(),
 			ServiceType:     startCmd.ServiceType,
+			Reverse:         startCmd.Reverse,
+			Protocol:        startCmd.Protocol,
 		}
 
 		if currentPod, err = howToGetCurrentPod(); err != nil {
@@ -345,7 +376,7 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 	startCmd.PodName = currentPod.Name
 
 	ctx, cancel := context.WithCancel(context.TODO())
-	p.pfList[key] = &daemon_common.PortForwardProfile{
+	pfProfile := &daemon_common.PortForwardProfile{
 		Cancel:     cancel,
 		StopCh:     make(chan error, 1),
 		NameSpace:  startCmd.NameSpace,
@@ -355,20 +386,24 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 		AppName:    startCmd.AppName,
 		LocalPort:  startCmd.LocalPort,
 		RemotePort: startCmd.RemotePort,
+		Reverse:    startCmd.Reverse,
+		Protocol:   startCmd.Protocol,
 	}
+	p.pfList[key] = pfProfile
+	sessionLog := log.Session(startCmd.NameSpace, startCmd.AppName, startCmd.Service)
 	go func() {
 		defer utils.RecoverFromPanic()
 
-		log.Logf("Forwarding %d:%d", localPort, remotePort)
+		sessionLog.Infof("Forwarding %d:%d", localPort, remotePort)
 
 		logDir := filepath.Join(nocalhost.GetLogDir(), "port-forward")
 		if _, err = os.Stat(logDir); err != nil {
 			if os.IsNotExist(err) {
 				if err = os.MkdirAll(logDir, 0644); err != nil {
-					log.LogE(errors.Wrap(err, ""))
+					sessionLog.LogE(errors.Wrap(err, ""))
 				}
 			} else {
-				log.LogE(errors.Wrap(err, ""))
+				sessionLog.LogE(errors.Wrap(err, ""))
 			}
 		}
 
@@ -380,10 +415,10 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 			), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0755,
 		)
 		if err != nil {
-			log.LogE(err)
+			sessionLog.LogE(err)
 		}
 
-		sleepBackOff := 15 * time.Second
+		sleepBackOff := minReconnectBackOff
 
 		for {
 			// stopCh control the port forwarding lifecycle. When it gets closed the
@@ -403,40 +438,83 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 				ErrOut: stdout,
 			}
 
-			// if pods is deleted, try to close port-forward
+			// if pods is deleted, not running or restarted, try to reconnect
+			podChecker := &podHealthChecker{}
 			watcher.NewSimpleWatcher(
 				nhController.Client,
 				"pods",
 				v1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", startCmd.PodName).String()},
 				stopCh,
-				nil,
-				func(key string, quitChan <-chan struct{}) {
+				func(key string, object interface{}, quitChan <-chan struct{}) {
 					defer utils.RecoverFromPanic()
-					select {
-					case errCh <- k8serrors.NewNotFound(schema.GroupResource{Resource: "pods"}, startCmd.PodName):
-						close(errCh)
-					default:
+					if err := podChecker.check(object); err != nil {
+						sendErrGracefully(errCh, err)
 					}
 				},
+				func(key string, quitChan <-chan struct{}) {
+					defer utils.RecoverFromPanic()
+					sendErrGracefully(errCh, k8serrors.NewNotFound(schema.GroupResource{Resource: "pods"}, startCmd.PodName))
+				},
 			)
 
 			go func() {
 				defer utils.RecoverFromPanic()
 				select {
 				case <-readyCh:
-					log.Infof("Port forward %d:%d is ready", localPort, remotePort)
+					// readyCh is closed too once the port-forward stopped
+					select {
+					case <-stopCh:
+						return
+					default:
+					}
+					sessionLog.Infof("Port forward %d:%d is ready", localPort, remotePort)
 					p.lock.Lock()
 					_ = nhController.UpdatePortForwardStatus(localPort, remotePort, "LISTEN", "listen")
 					p.lock.Unlock()
 				case <-time.After(60 * time.Second):
-					log.Infof("Waiting Port forward %d:%d timeout", localPort, remotePort)
+					sessionLog.Infof("Waiting Port forward %d:%d timeout", localPort, remotePort)
+					return
+				case <-stopCh:
+					return
+				}
+
+				// the local listener of port-forward may be closed silently
+				if startCmd.Reverse {
+					return
+				}
+				ticker := time.NewTicker(pfHealthCheckInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ticker.C:
+						if err := checkLocalPortListened(localPort, startCmd.Protocol); err != nil {
+							sendErrGracefully(errCh, err)
+							return
+						}
+					case <-stopCh:
+						return
+					}
 				}
 			}()
 
 			go func() {
 				defer utils.RecoverFromPanic()
-				errCh <- nocalhostApp.PortForward(startCmd.PodName, localPort, remotePort, readyCh, stopCh, stream)
-				log.Logf("Port-forward %d:%d occurs errors", localPort, remotePort)
+				var err error
+				if startCmd.Reverse {
+					err = dev_ssh.ReverseForward(
+						nhController.Client, startCmd.PodName, localPort, remotePort, readyCh, stopCh,
+					)
+				} else if startCmd.Protocol == _const.UDPProtocol {
+					err = udp_relay.Forward(
+						localPort, remotePort, func() (io.ReadWriteCloser, error) {
+							return nhController.Client.DialPort(startCmd.PodName, _const.UDPRelayPort)
+						}, readyCh, stopCh,
+					)
+				} else {
+					err = nocalhostApp.PortForward(startCmd.PodName, localPort, remotePort, readyCh, stopCh, stream)
+				}
+				sendErrGracefully(errCh, err)
+				sessionLog.Infof("Port-forward %d:%d occurs errors", localPort, remotePort)
 			}()
 
 			var block = true
@@ -444,75 +522,72 @@ func (p *PortForwardManager) StartPortForwardGoRoutine(startCmd *command.PortFor
 			select {
 			case errs := <-errCh:
 
+				// the back off is reset once the port-forward has been ready
+				select {
+				case <-readyCh:
+					sleepBackOff = minReconnectBackOff
+				default:
+				}
 				closeChanGracefully(stopCh)
 				closeChanGracefully(readyCh)
 
-				reconnectMsg := fmt.Sprintf("Reconnecting after %s seconds...", sleepBackOff.String())
-
-				if errs != nil && k8serrors.IsNotFound(errs) {
+				if errs != nil && strings.Contains(errs.Error(), "failed to find socat") {
 
-					// if pod not found, try to get pod by labels
-					if pod, err := howToGetCurrentPod(); err != nil {
-						p.lock.Lock()
-						err = nhController.UpdatePortForwardStatus(
-							localPort, remotePort, "RECONNECTING",
-							reconnectMsg,
-						)
-						p.lock.Unlock()
-
-						// Avoid overloading the api with multiple requests
-						sleepBackOff += 15 * time.Second
-						if sleepBackOff.Seconds() > 60 {
-							sleepBackOff = 60 * time.Second
-						}
-					} else {
-
-						log.Logf("New pod %s for port-forward found", pod.Name)
-						startCmd.PodName = pod.Name
-						block = false
-					}
-
-				} else if errs != nil && strings.Contains(errs.Error(), "failed to find socat") {
-
-					log.Logf("failed to find socat, err: %v", errs)
+					sessionLog.Infof("failed to find socat, err: %v", errs)
 					p.lock.Lock()
 					err = nhController.UpdatePortForwardStatus(
 						localPort, remotePort, "Socat not found", "failed to find socat",
 					)
 					p.lock.Unlock()
 					if err != nil {
-						log.LogE(err)
+						sessionLog.LogE(err)
 					}
 					delete(p.pfList, key)
 					return
-				} else {
+				}
 
-					log.Warn(reconnectMsg)
-					p.lock.Lock()
-					err = nhController.UpdatePortForwardStatus(
-						localPort, remotePort, "RECONNECTING",
-						reconnectMsg,
-					)
-					p.lock.Unlock()
-					if err != nil {
-						log.LogE(err)
-					}
+				reconnectMsg := fmt.Sprintf("Reconnecting after %s...", sleepBackOff.String())
+				if errs != nil {
+					reconnectMsg = fmt.Sprintf("%s, %s", errs.Error(), reconnectMsg)
+				}
+
+				// if pod not found or restarted, try to get the new pod by labels
+				if pod, err := howToGetCurrentPod(); err == nil && pod.Name != startCmd.PodName {
+					sessionLog.Infof("New pod %s for port-forward found", pod.Name)
+					startCmd.PodName = pod.Name
+					reconnectMsg = fmt.Sprintf("Reconnecting to new pod %s...", pod.Name)
+					block = false
+				}
+
+				sessionLog.Warnf("Port-forward %d:%d: %s", localPort, remotePort, reconnectMsg)
+				p.lock.Lock()
+				pfProfile.Reconnects++
+				errorCounter.inc(portForwardErrorKind)
+				err = nhController.RecordPortForwardReconnect(localPort, remotePort, reconnectMsg)
+				p.lock.Unlock()
+				if err != nil {
+					sessionLog.LogE(err)
 				}
 
 				if block {
-					<-time.After(sleepBackOff)
-					log.Infof("Reconnecting %d:%d...", localPort, remotePort)
+					select {
+					case <-time.After(sleepBackOff):
+					case <-ctx.Done():
+					}
+					// Avoid overloading the api with multiple requests
+					sleepBackOff = nextBackOff(sleepBackOff)
+					sessionLog.Infof("Reconnecting %d:%d...", localPort, remotePort)
 				}
 
 			case <-ctx.Done():
-				log.Logf("Port-forward %d:%d done", localPort, remotePort)
-				log.Log("Stopping pf routine")
+				sessionLog.Infof("Port-forward %d:%d done", localPort, remotePort)
+				sessionLog.Infof("Stopping pf routine")
 				closeChanGracefully(stopCh)
 				//delete(p.pfList, key)
-				log.Logf("Delete port-forward %d:%d record", localPort, remotePort)
+				sessionLog.Infof("Delete port-forward %d:%d record", localPort, remotePort)
 				err = nhController.DeletePortForwardFromDB(localPort, remotePort)
 				if err != nil {
-					log.LogE(err)
+					sessionLog.LogE(err)
 				}
 
 				p.recordPortForward(
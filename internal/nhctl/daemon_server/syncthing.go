@@ -135,6 +135,10 @@ func reconnectedSyncthingIfNeeded() {
 			if svcProfile == nil || appmeta.HasDevStartingSuffix(svcProfile.Name) {
 				continue
 			}
+			// mutagen reconnects the session itself
+			if svcProfile.SyncEngine == _const.MutagenSyncEngine {
+				continue
+			}
 			svcType, err1 := nocalhost.SvcTypeOfMutate(svcProfile.GetType())
 			if err1 != nil {
 				continue
@@ -156,8 +160,14 @@ func reconnectedSyncthingIfNeeded() {
 			// the first time: using old port-forward, just create a new syncthing process
 			//   detect syncthing service is available or not, if it's still not available
 			// the second time: redo port-forward, and create a new syncthing process
-			go func(svc *controller.Controller) {
+			go func(svc *controller.Controller, syncing bool) {
 				defer utils.RecoverFromPanic()
+				sessionLog := log.Session(svc.NameSpace, svc.AppName, svc.Name)
+				if syncing {
+					if err := svc.ResolveSyncConflicts(); err != nil {
+						sessionLog.WarnE(err, "Failed to resolve conflicts of sync")
+					}
+				}
 				var err error
 				for i := 0; i < 2; i++ {
 					if err = retry.OnError(wait.Backoff{
@@ -188,15 +198,13 @@ func reconnectedSyncthingIfNeeded() {
 						v.(*backoff).lastTime = time.Now()
 					}
 
-					log.LogDebugf("prepare to restore syncthing, name: %s", svc.Name)
+					sessionLog.Debugf("prepare to restore syncthing, name: %s", svc.Name)
 					// TODO using developing container, otherwise will using default containerDevConfig
 					if err = doReconnectSyncthing(svc, "", appProfile.Kubeconfig, i == 1); err != nil {
-						log.Errorf(
-							"error while reconnect syncthing, ns: %s, app: %s, svc: %s, type: %s, err: %v",
-							svc.AppMeta.Ns, svc.AppMeta.Application, svc.Name, svc.Type, err)
+						sessionLog.WarnE(err, fmt.Sprintf("error while reconnect syncthing, type: %s", svc.Type))
 					}
 				}
-			}(svc)
+			}(svc, svcProfile.Syncing)
 		}
 	}
 }
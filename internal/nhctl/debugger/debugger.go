@@ -0,0 +1,170 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package debugger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Go     = "go"
+	Java   = "java"
+	Node   = "node"
+	Python = "python"
+)
+
+// Languages the languages whose debugger is set up by nhctl debug start
+var Languages = []string{Go, Java, Node, Python}
+
+var defaultPorts = map[string]int{Go: 2345, Java: 5005, Node: 9229, Python: 5678}
+
+// DefaultPort the port of debugger by convention, such as 2345 of dlv and 5005
+// of jdwp, 0 if the language is unsupported
+func DefaultPort(lang string) int {
+	return defaultPorts[lang]
+}
+
+// Command the shell command running program under the debugger of lang, which
+// listens on port and suspends until the debugger attaches. The program is the
+// run command of dev config, such as `go run ./cmd/app`, `java -jar app.jar`,
+// `node server.js` or `python app.py`
+func Command(lang string, port int, program []string) (string, error) {
+	if len(program) == 0 && lang != Go {
+		return "", errors.New(fmt.Sprintf("The run command is required to debug %s", lang))
+	}
+	switch lang {
+	case Go:
+		dlv := []string{"dlv", "--headless", fmt.Sprintf("--listen=:%d", port), "--api-version=2", "--accept-multiclient"}
+		switch {
+		case len(program) == 0:
+			return shellExec(append(dlv, "debug")), nil
+		case program[0] == "go":
+			if len(program) < 2 || program[1] != "run" {
+				return "", errors.New("Only go run is supported to debug by the go command")
+			}
+			// go run [build flags] [package] [arguments...], the build flags
+			// are in the form of -name=value
+			flags, rest := leadingFlags(program[2:])
+			args := append(dlv, "debug")
+			if len(rest) > 0 {
+				args, rest = append(args, rest[0]), rest[1:]
+			}
+			if len(flags) > 0 {
+				args = append(args, "--build-flags="+strings.Join(flags, " "))
+			}
+			return shellExec(withArgs(args, rest)), nil
+		default:
+			return shellExec(withArgs(append(append(dlv, "exec"), program[0]), program[1:])), nil
+		}
+	case Java:
+		agent := fmt.Sprintf("-agentlib:jdwp=transport=dt_socket,server=y,suspend=y,address=*:%d", port)
+		// the jvm started by mvn, gradle or scripts picks it up as well
+		return "export JAVA_TOOL_OPTIONS=" + quote(agent) + "; " + shellExec(program), nil
+	case Node:
+		inspect := fmt.Sprintf("--inspect-brk=0.0.0.0:%d", port)
+		if program[0] == "node" {
+			return shellExec(append([]string{"node", inspect}, program[1:]...)), nil
+		}
+		return "export NODE_OPTIONS=" + quote(inspect) + "; " + shellExec(program), nil
+	case Python:
+		debugpy := []string{"-m", "debugpy", "--listen", fmt.Sprintf("0.0.0.0:%d", port), "--wait-for-client"}
+		if pythonExecutable.MatchString(program[0]) {
+			return shellExec(append(append([]string{program[0]}, debugpy...), program[1:]...)), nil
+		}
+		return shellExec(append(append([]string{"python"}, debugpy...), program...)), nil
+	}
+	return "", errors.New(
+		fmt.Sprintf("Language %s is unsupported, only %s supported", lang, strings.Join(Languages, ", ")),
+	)
+}
+
+var (
+	pythonExecutable = regexp.MustCompile(`^(.*/)?python[0-9.]*$`)
+	safeArg          = regexp.MustCompile(`^[a-zA-Z0-9_./:=@%+,-]+$`)
+)
+
+func withArgs(args, programArgs []string) []string {
+	if len(programArgs) == 0 {
+		return args
+	}
+	return append(append(args, "--"), programArgs...)
+}
+
+// shellExec the program replaces the shell, so that it is killed by the pid of shell
+func shellExec(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, quote(arg))
+	}
+	return "exec " + strings.Join(quoted, " ")
+}
+
+func quote(arg string) string {
+	if safeArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func leadingFlags(args []string) ([]string, []string) {
+	i := 0
+	for ; i < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+	}
+	return args[:i], args[i:]
+}
+
+// Proxy the connections of debugger accepted by listener are piped to target,
+// attached is called with the address of each debugger connected, until ctx
+// is done or the listener is closed
+func Proxy(ctx context.Context, listener net.Listener, target string, attached func(addr string)) error {
+	go func() {
+		<-ctx.Done()
+		_ = listener.Close()
+	}()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return errors.Wrap(err, "")
+		}
+		go func() {
+			defer func() { _ = conn.Close() }()
+			remote, err := (&net.Dialer{}).DialContext(ctx, "tcp", target)
+			if err != nil {
+				return
+			}
+			defer func() { _ = remote.Close() }()
+			if attached != nil {
+				attached(conn.RemoteAddr().String())
+			}
+			pipe(conn, remote)
+		}()
+	}
+}
+
+// pipe copies in both directions until either side is closed
+func pipe(a, b net.Conn) {
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	copyAndClose := func(dst, src net.Conn) {
+		defer wg.Done()
+		_, _ = io.Copy(dst, src)
+		_ = dst.Close()
+	}
+	go copyAndClose(a, b)
+	go copyAndClose(b, a)
+	wg.Wait()
+}
@@ -0,0 +1,96 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package debugger
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestCommand(t *testing.T) {
+	dlv := "exec dlv --headless --listen=:2345 --api-version=2 --accept-multiclient "
+	cases := []struct {
+		lang    string
+		port    int
+		program []string
+		expect  string
+	}{
+		{Go, 2345, nil, dlv + "debug"},
+		{Go, 2345, []string{"go", "run", "-tags=dev", "./cmd/app", "-v"},
+			dlv + "debug ./cmd/app --build-flags=-tags=dev -- -v"},
+		{Go, 2345, []string{"./bin/app", "serve"}, dlv + "exec ./bin/app -- serve"},
+		{Java, 5005, []string{"java", "-jar", "app.jar"},
+			"export JAVA_TOOL_OPTIONS='-agentlib:jdwp=transport=dt_socket,server=y,suspend=y,address=*:5005'; " +
+				"exec java -jar app.jar"},
+		{Node, 9229, []string{"node", "server.js"}, "exec node --inspect-brk=0.0.0.0:9229 server.js"},
+		{Node, 9229, []string{"npm", "start"}, "export NODE_OPTIONS=--inspect-brk=0.0.0.0:9229; exec npm start"},
+		{Python, 5678, []string{"python3", "app.py", "--name", "it's"},
+			"exec python3 -m debugpy --listen 0.0.0.0:5678 --wait-for-client app.py --name 'it'\\''s'"},
+		{Python, 5678, []string{"manage.py"}, "exec python -m debugpy --listen 0.0.0.0:5678 --wait-for-client manage.py"},
+	}
+	for _, c := range cases {
+		actual, err := Command(c.lang, c.port, c.program)
+		if err != nil {
+			t.Errorf("%s %v: %v", c.lang, c.program, err)
+		} else if actual != c.expect {
+			t.Errorf("%s %v:\n got %s\nexpect %s", c.lang, c.program, actual, c.expect)
+		}
+	}
+
+	for _, lang := range []string{Java, "ruby"} {
+		if _, err := Command(lang, 1, nil); err == nil {
+			t.Errorf("%s without the run command should be invalid", lang)
+		}
+	}
+	if _, err := Command(Go, 2345, []string{"go", "build"}); err == nil {
+		t.Error("go build should be invalid")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = target.Close() }()
+	go func() {
+		conn, err := target.Accept()
+		if err == nil {
+			_, _ = conn.Write([]byte("dlv"))
+			_ = conn.Close()
+		}
+	}()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	attached := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- Proxy(ctx, listener, target.Addr().String(), func(addr string) { attached <- addr })
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bys, _ := ioutil.ReadAll(conn)
+	_ = conn.Close()
+	if string(bys) != "dlv" {
+		t.Errorf("unexpected data piped %q", bys)
+	}
+	if addr := <-attached; addr != conn.LocalAddr().String() {
+		t.Errorf("attached from %s, expect %s", addr, conn.LocalAddr())
+	}
+	cancel()
+	if err = <-done; err != nil {
+		t.Errorf("proxy should stop without error, got %v", err)
+	}
+}
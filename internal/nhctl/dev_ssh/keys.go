@@ -0,0 +1,122 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_ssh
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"nocalhost/internal/nhctl/nocalhost_path"
+	"nocalhost/internal/nhctl/utils"
+)
+
+func HostKeyFile() string {
+	return filepath.Join(nocalhost_path.GetNhctlHomeDir(), "ssh", "host_key")
+}
+
+// LoadHostKey the host key is generated at the first time, and kept in file,
+// so that the known_hosts of client is not changed every time
+func LoadHostKey(file string) (ssh.Signer, error) {
+	if bys, err := ioutil.ReadFile(file); err == nil {
+		return ssh.ParsePrivateKey(bys)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	bys := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	if err = os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = ioutil.WriteFile(file, bys, 0600); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ssh.ParsePrivateKey(bys)
+}
+
+// LoadAuthorizedKeys the keys of the file in format of authorized_keys, if
+// the file is empty, the public keys in ~/.ssh and the ones of ssh agent
+// are authorized
+func LoadAuthorizedKeys(file string) ([]ssh.PublicKey, error) {
+	if file != "" {
+		bys, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return parseAuthorizedKeys(bys), nil
+	}
+
+	keys := make([]ssh.PublicKey, 0)
+	files, _ := filepath.Glob(filepath.Join(utils.GetHomePath(), ".ssh", "*.pub"))
+	for _, f := range files {
+		if bys, err := ioutil.ReadFile(f); err == nil {
+			keys = append(keys, parseAuthorizedKeys(bys)...)
+		}
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			defer conn.Close()
+			signers, _ := agent.NewClient(conn).Signers()
+			for _, s := range signers {
+				keys = append(keys, s.PublicKey())
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("No public key found in ~/.ssh or ssh agent, please specify the authorized keys")
+	}
+	return keys, nil
+}
+
+// AddKnownHost add the host key of address to ~/.ssh/known_hosts, so that the
+// clients connecting without terminal, such as mutagen, are not prompted
+func AddKnownHost(address string, key ssh.PublicKey) error {
+	file := filepath.Join(utils.GetHomePath(), ".ssh", "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(address)}, key)
+	if bys, err := ioutil.ReadFile(file); err == nil && strings.Contains(string(bys), line) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return errors.WithStack(err)
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	_, err = f.WriteString(line + "\n")
+	return errors.WithStack(err)
+}
+
+func parseAuthorizedKeys(bys []byte) []ssh.PublicKey {
+	keys := make([]ssh.PublicKey, 0)
+	for len(bys) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(bys)
+		if err != nil {
+			break
+		}
+		keys = append(keys, key)
+		bys = rest
+	}
+	return keys
+}
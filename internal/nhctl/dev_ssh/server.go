@@ -0,0 +1,437 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	k8sremotecommand "k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/exec"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/pkg/nhctl/clientgoutils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const (
+	shellScript = "if command -v bash >/dev/null 2>&1; then exec bash -l; else exec sh -l; fi"
+	// the paths of sftp-server of the distributions, scp of openssh 9 uses sftp
+	sftpScript = "for p in /usr/lib/openssh/sftp-server /usr/libexec/openssh/sftp-server " +
+		"/usr/lib/ssh/sftp-server /usr/libexec/sftp-server; do [ -x $p ] && exec $p; done; " +
+		"echo 'sftp-server not found in the dev container' >&2; exit 127"
+)
+
+// Target the dev container which the sessions are executed in
+type Target struct {
+	Client    *clientgoutils.ClientGoUtils
+	Pod       string
+	Container string
+	WorkDir   string
+	// Agent whether the sidecar runs sshd, the agent is forwarded by it
+	Agent bool
+}
+
+// Server the ssh server on the host of developer, which executes the sessions
+// in the dev container by the api server, the ports forwarded are the ones of
+// the dev pod, so that there is no sshd required in the dev container
+type Server struct {
+	target Target
+	config *ssh.ServerConfig
+}
+
+func NewServer(target Target, hostKey ssh.Signer, authorizedKeys []ssh.PublicKey) *Server {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, k := range authorizedKeys {
+				if bytes.Equal(k.Marshal(), key.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, errors.New(fmt.Sprintf("unauthorized key of %s", conn.User()))
+		},
+	}
+	config.AddHostKey(hostKey)
+	return &Server{target: target, config: config}
+}
+
+// Serve the connections of listener until it is closed
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	sshConn, channels, requests, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		log.Warnf("Handshake of ssh from %s failed: %v", conn.RemoteAddr(), err)
+		return
+	}
+	defer sshConn.Close()
+	log.Infof("Ssh of %s from %s connected", sshConn.User(), sshConn.RemoteAddr())
+
+	// the remote forwarding is not supported
+	go ssh.DiscardRequests(requests)
+
+	agent := &agentForwarder{target: s.target, conn: sshConn}
+	defer agent.close()
+
+	for newChannel := range channels {
+		switch newChannel.ChannelType() {
+		case "session":
+			go s.handleSession(newChannel, agent)
+		case "direct-tcpip":
+			go s.handleDirectTCPIP(newChannel)
+		default:
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+type ptyRequest struct {
+	Term    string
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+	Modes   string
+}
+
+type windowChange struct {
+	Columns uint32
+	Rows    uint32
+	Width   uint32
+	Height  uint32
+}
+
+type envRequest struct {
+	Name  string
+	Value string
+}
+
+type commandRequest struct {
+	Command string
+}
+
+type exitStatus struct {
+	Status uint32
+}
+
+func (s *Server) handleSession(newChannel ssh.NewChannel, agent *agentForwarder) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+
+	env := map[string]string{}
+	var sizes *sizeQueue
+	var started bool
+	done := make(chan uint32, 1)
+	for {
+		select {
+		case status := <-done:
+			_, _ = channel.SendRequest("exit-status", false, ssh.Marshal(&exitStatus{Status: status}))
+			return
+		case req, ok := <-requests:
+			if !ok {
+				return
+			}
+			reply := false
+			switch req.Type {
+			case "pty-req":
+				var pty ptyRequest
+				if ssh.Unmarshal(req.Payload, &pty) == nil && !started {
+					sizes = newSizeQueue()
+					sizes.push(pty.Columns, pty.Rows)
+					env["TERM"] = pty.Term
+					reply = true
+				}
+			case "window-change":
+				var change windowChange
+				if ssh.Unmarshal(req.Payload, &change) == nil && sizes != nil {
+					sizes.push(change.Columns, change.Rows)
+				}
+			case "env":
+				var e envRequest
+				if ssh.Unmarshal(req.Payload, &e) == nil {
+					env[e.Name] = e.Value
+					reply = true
+				}
+			case "auth-agent-req@openssh.com":
+				sock, err := agent.forward()
+				if err != nil {
+					log.WarnE(err, "Failed to forward ssh agent")
+				} else {
+					env["SSH_AUTH_SOCK"] = sock
+					reply = true
+				}
+			case "shell", "exec", "subsystem":
+				if started {
+					break
+				}
+				script, err := sessionScript(req.Type, req.Payload)
+				if err != nil {
+					log.WarnE(err, "")
+					break
+				}
+				started, reply = true, true
+				go func() {
+					done <- s.exec(channel, s.command(script, env), sizes)
+				}()
+			}
+			if req.WantReply {
+				_ = req.Reply(reply, nil)
+			}
+		}
+	}
+}
+
+func sessionScript(kind string, payload []byte) (string, error) {
+	switch kind {
+	case "shell":
+		return shellScript, nil
+	case "exec":
+		var cmd commandRequest
+		if err := ssh.Unmarshal(payload, &cmd); err != nil {
+			return "", errors.WithStack(err)
+		}
+		return cmd.Command, nil
+	}
+	var subsystem commandRequest
+	if err := ssh.Unmarshal(payload, &subsystem); err != nil {
+		return "", errors.WithStack(err)
+	}
+	if subsystem.Command != "sftp" {
+		return "", errors.New(fmt.Sprintf("Unsupported subsystem %s", subsystem.Command))
+	}
+	return sftpScript, nil
+}
+
+// command the script is run in the work dir with the env of the session
+func (s *Server) command(script string, env map[string]string) []string {
+	var sb strings.Builder
+	if s.target.WorkDir != "" {
+		sb.WriteString(fmt.Sprintf("cd %s 2>/dev/null; ", shellQuote(s.target.WorkDir)))
+	}
+	for k, v := range env {
+		if !isEnvName(k) {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("export %s=%s; ", k, shellQuote(v)))
+	}
+	sb.WriteString(script)
+	return []string{"sh", "-c", sb.String()}
+}
+
+// exec the command in the dev container, the exit status is returned
+func (s *Server) exec(channel ssh.Channel, command []string, sizes *sizeQueue) uint32 {
+	tty := sizes != nil
+	var queue k8sremotecommand.TerminalSizeQueue
+	if tty {
+		queue = sizes
+		defer sizes.close()
+	}
+	err := s.target.Client.ExecStream(
+		s.target.Pod, s.target.Container, command, channel, channel, channel.Stderr(), tty, queue,
+	)
+	// the output is flushed before the exit status is sent
+	_ = channel.CloseWrite()
+	if err == nil {
+		return 0
+	}
+	if e, ok := err.(exec.CodeExitError); ok {
+		return uint32(e.Code)
+	}
+	log.WarnE(err, "Failed to exec in dev container")
+	_, _ = fmt.Fprintf(channel.Stderr(), "%v\n", err)
+	return 255
+}
+
+type directTCPIP struct {
+	Host           string
+	Port           uint32
+	OriginatorHost string
+	OriginatorPort uint32
+}
+
+// handleDirectTCPIP forward the local ports of the dev pod, e.g. the backend of
+// JetBrains Gateway
+func (s *Server) handleDirectTCPIP(newChannel ssh.NewChannel) {
+	var req directTCPIP
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &req); err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, "invalid request of direct-tcpip")
+		return
+	}
+	if req.Host != "localhost" && req.Host != "127.0.0.1" && req.Host != "::1" {
+		_ = newChannel.Reject(ssh.Prohibited, "only the ports of the dev pod are forwarded")
+		return
+	}
+	stream, err := s.target.Client.DialPort(s.target.Pod, int(req.Port))
+	if err != nil {
+		_ = newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		_ = stream.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	pipe(channel, stream)
+}
+
+// agentForwarder forward the agent of the ssh client to the dev container, the
+// sshd of the sidecar listens on the socket in the volume shared with the dev
+// container, and the connections of it are forwarded back to the client
+type agentForwarder struct {
+	target Target
+	conn   *ssh.ServerConn
+
+	lock   sync.Mutex
+	client *ssh.Client
+	sock   string
+}
+
+func (a *agentForwarder) forward() (string, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.client != nil {
+		return a.sock, nil
+	}
+	if !a.target.Agent {
+		return "", errors.New("sshd is not running in the sidecar, start DevMode with --ssh to forward the agent")
+	}
+
+	client, err := DialSidecar(a.target.Client, a.target.Pod)
+	if err != nil {
+		return "", err
+	}
+
+	sock := path.Join(_const.NocalhostSSHAgentDir, fmt.Sprintf("agent.%d", time.Now().UnixNano()))
+	listener, err := client.ListenUnix(sock)
+	if err != nil {
+		_ = client.Close()
+		return "", errors.Wrap(err, "Failed to listen the socket of agent in sidecar")
+	}
+	go func() {
+		for {
+			local, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				channel, requests, err := a.conn.OpenChannel("auth-agent@openssh.com", nil)
+				if err != nil {
+					_ = local.Close()
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				pipe(channel, local)
+			}()
+		}
+	}()
+	a.client, a.sock = client, sock
+	return sock, nil
+}
+
+func (a *agentForwarder) close() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.client != nil {
+		_ = a.client.Close()
+	}
+}
+
+func pipe(a, b io.ReadWriteCloser) {
+	var once sync.Once
+	closeBoth := func() {
+		_ = a.Close()
+		_ = b.Close()
+	}
+	go func() {
+		_, _ = io.Copy(a, b)
+		once.Do(closeBoth)
+	}()
+	_, _ = io.Copy(b, a)
+	once.Do(closeBoth)
+}
+
+// sizeQueue the sizes of terminal changed by the client
+type sizeQueue struct {
+	sizes chan k8sremotecommand.TerminalSize
+	once  sync.Once
+}
+
+func newSizeQueue() *sizeQueue {
+	return &sizeQueue{sizes: make(chan k8sremotecommand.TerminalSize, 8)}
+}
+
+func (q *sizeQueue) push(columns, rows uint32) {
+	select {
+	case q.sizes <- k8sremotecommand.TerminalSize{Width: uint16(columns), Height: uint16(rows)}:
+	default:
+		// the laggard sizes are dropped
+	}
+}
+
+func (q *sizeQueue) Next() *k8sremotecommand.TerminalSize {
+	size, ok := <-q.sizes
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+func (q *sizeQueue) close() {
+	q.once.Do(func() { close(q.sizes) })
+}
+
+// streamConn the stream of port-forward as net.Conn, the deadlines are
+// not supported
+type streamConn struct {
+	io.ReadWriteCloser
+}
+
+type streamAddr struct{}
+
+func (streamAddr) Network() string { return "port-forward" }
+func (streamAddr) String() string  { return "port-forward" }
+
+func (c *streamConn) LocalAddr() net.Addr                { return streamAddr{} }
+func (c *streamConn) RemoteAddr() net.Addr               { return streamAddr{} }
+func (c *streamConn) SetDeadline(t time.Time) error      { return nil }
+func (c *streamConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *streamConn) SetWriteDeadline(t time.Time) error { return nil }
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (i > 0 && r >= '0' && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
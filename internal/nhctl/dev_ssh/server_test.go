@@ -0,0 +1,38 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_ssh
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCommand(t *testing.T) {
+	s := &Server{target: Target{WorkDir: "/home/nocalhost-dev"}}
+	cmd := s.command("ls", map[string]string{"LANG": "it's", "BAD-NAME": "x"})
+	if len(cmd) != 3 || cmd[0] != "sh" || cmd[1] != "-c" {
+		t.Fatalf("unexpected command %v", cmd)
+	}
+	expected := `cd '/home/nocalhost-dev' 2>/dev/null; export LANG='it'\''s'; ls`
+	if cmd[2] != expected {
+		t.Fatalf("expected %s, got %s", expected, cmd[2])
+	}
+}
+
+func TestSessionScript(t *testing.T) {
+	script, err := sessionScript("exec", ssh.Marshal(&commandRequest{Command: "rsync --server"}))
+	if err != nil || script != "rsync --server" {
+		t.Fatalf("unexpected script %s, %v", script, err)
+	}
+	if script, err = sessionScript("subsystem", ssh.Marshal(&commandRequest{Command: "sftp"})); err != nil ||
+		script != sftpScript {
+		t.Fatalf("unexpected script %s, %v", script, err)
+	}
+	if _, err = sessionScript("subsystem", ssh.Marshal(&commandRequest{Command: "netconf"})); err == nil {
+		t.Fatal("netconf should be unsupported")
+	}
+}
@@ -0,0 +1,91 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_ssh
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nhctl/utils"
+	"nocalhost/pkg/nhctl/clientgoutils"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// DialSidecar connect to the sshd of sidecar started by DevMode with --ssh,
+// by the port-forward of the api server
+func DialSidecar(client *clientgoutils.ClientGoUtils, pod string) (*ssh.Client, error) {
+	stream, err := client.DialPort(pod, _const.SSHSideCarPort)
+	if err != nil {
+		return nil, err
+	}
+	conn, channels, requests, err := ssh.NewClientConn(
+		&streamConn{ReadWriteCloser: stream}, "sidecar", &ssh.ClientConfig{
+			User: utils.DefaultRoot.Username,
+			Auth: []ssh.AuthMethod{ssh.Password(utils.DefaultRoot.Password)},
+			// the sidecar is reached by the port-forward of the api server
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout:         30 * time.Second,
+		},
+	)
+	if err != nil {
+		_ = stream.Close()
+		return nil, errors.Wrap(err, "Failed to connect to sshd of sidecar, start DevMode with --ssh to run it")
+	}
+	return ssh.NewClient(conn, channels, requests), nil
+}
+
+// ReverseForward forward the connections of remotePort in the pod to the
+// localPort, which is listened by the sshd of sidecar on all the addresses,
+// so that the other pods reach it too. readyCh is closed once listening, and
+// it returns when stopCh closed or the connection to sidecar lost
+func ReverseForward(client *clientgoutils.ClientGoUtils, pod string, localPort, remotePort int,
+	readyCh chan struct{}, stopCh <-chan struct{}) error {
+	sshClient, err := DialSidecar(client, pod)
+	if err != nil {
+		return err
+	}
+	defer sshClient.Close()
+
+	listener, err := sshClient.ListenTCP(&net.TCPAddr{IP: net.IPv4zero, Port: remotePort})
+	if err != nil {
+		return errors.Wrap(err, "Failed to listen the remote port in sidecar")
+	}
+	defer listener.Close()
+	if readyCh != nil {
+		close(readyCh)
+	}
+
+	go func() {
+		<-stopCh
+		_ = sshClient.Close()
+	}()
+
+	local := net.JoinHostPort("127.0.0.1", fmt.Sprint(localPort))
+	for {
+		remote, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-stopCh:
+				return nil
+			default:
+			}
+			return errors.Wrap(err, "Connection to sidecar lost")
+		}
+		go func() {
+			conn, err := net.Dial("tcp", local)
+			if err != nil {
+				log.Logf("Failed to dial %s for reverse port-forward: %v", local, err)
+				_ = remote.Close()
+				return
+			}
+			pipe(remote, conn)
+		}()
+	}
+}
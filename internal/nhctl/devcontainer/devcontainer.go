@@ -0,0 +1,323 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package devcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"nocalhost/internal/nhctl/profile"
+)
+
+// Paths the paths of devcontainer.json relative to the root of repository,
+// in the order of lookup
+var Paths = []string{
+	filepath.Join(".devcontainer", "devcontainer.json"),
+	".devcontainer.json",
+}
+
+// Config the part of devcontainer.json the dev config is derived from, see
+// https://containers.dev/implementors/json_reference/
+type Config struct {
+	Name            string `json:"name"`
+	Image           string `json:"image"`
+	WorkspaceFolder string `json:"workspaceFolder"`
+	// ForwardPorts such as 3000 or "localhost:3000"
+	ForwardPorts []interface{} `json:"forwardPorts"`
+	// Mounts such as "source=cache,target=/root/.cache,type=volume" or the
+	// object of source, target and type
+	Mounts []interface{} `json:"mounts"`
+	// PostCreateCommand a string run by shell, an array of command and args, or
+	// an object of the commands named
+	PostCreateCommand interface{}       `json:"postCreateCommand"`
+	ContainerEnv      map[string]string `json:"containerEnv"`
+	RemoteEnv         map[string]string `json:"remoteEnv"`
+	Build             interface{}       `json:"build"`
+	DockerComposeFile interface{}       `json:"dockerComposeFile"`
+
+	// dir the root of repository
+	dir string
+}
+
+// Find the devcontainer.json under dir, empty if none
+func Find(dir string) string {
+	for _, p := range Paths {
+		if f := filepath.Join(dir, p); fileExist(f) {
+			return f
+		}
+	}
+	return ""
+}
+
+func fileExist(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// Load the devcontainer.json of repository dir, the comments and the trailing
+// commas are allowed
+func Load(dir string) (*Config, error) {
+	path := Find(dir)
+	if path == "" {
+		return nil, errors.New(fmt.Sprintf("No devcontainer.json found in %s", dir))
+	}
+	bys, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	c := &Config{dir: dir}
+	if err = json.Unmarshal(Standardize(bys), c); err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("Invalid %s", path))
+	}
+	return c, nil
+}
+
+// Standardize strips the comments and the trailing commas of JSON with
+// comments, the strings are kept as is
+func Standardize(bys []byte) []byte {
+	out := make([]byte, 0, len(bys))
+	for i := 0; i < len(bys); i++ {
+		switch {
+		case bys[i] == '"':
+			j := i + 1
+			for ; j < len(bys) && bys[j] != '"'; j++ {
+				if bys[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(bys) {
+				j = len(bys) - 1
+			}
+			out = append(out, bys[i:j+1]...)
+			i = j
+		case bys[i] == '/' && i+1 < len(bys) && bys[i+1] == '/':
+			for i < len(bys) && bys[i] != '\n' {
+				i++
+			}
+			if i < len(bys) {
+				out = append(out, '\n')
+			}
+		case bys[i] == '/' && i+1 < len(bys) && bys[i+1] == '*':
+			end := strings.Index(string(bys[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case bys[i] == ',' && closedNext(bys[i+1:]):
+		default:
+			out = append(out, bys[i])
+		}
+	}
+	return out
+}
+
+var trailingComma = regexp.MustCompile(`^(\s|//[^\n]*\n|/\*(?s:.)*?\*/)*[}\]]`)
+
+// closedNext the object or array is closed next to the comma
+func closedNext(rest []byte) bool {
+	return trailingComma.Match(rest)
+}
+
+// DevConfig the dev config derived from devcontainer.json: the image, the
+// workspace folder as the work dir, the forwarded ports, the volumes mounted
+// as the persistent volume dirs, the env and the post create command. Bind
+// mounts and the ports of other services are skipped as they are local
+func (c *Config) DevConfig() (*profile.ContainerDevConfig, error) {
+	if c.Image == "" {
+		if c.Build != nil || c.DockerComposeFile != nil {
+			return nil, errors.New(
+				"The dev container built by build or dockerComposeFile is unsupported, the image is required",
+			)
+		}
+		return nil, errors.New("The image is required in devcontainer.json")
+	}
+	devConfig := &profile.ContainerDevConfig{
+		Image:   c.Image,
+		WorkDir: c.substitute(c.WorkspaceFolder),
+	}
+
+	for _, port := range c.ForwardPorts {
+		if p := forwardPort(port); p != 0 {
+			devConfig.PortForward = append(devConfig.PortForward, fmt.Sprintf("%d:%d", p, p))
+		}
+	}
+	for _, m := range c.Mounts {
+		if target, ok := volumeTarget(m); ok {
+			devConfig.PersistentVolumeDirs = append(
+				devConfig.PersistentVolumeDirs, &profile.PersistentVolumeDir{Path: c.substitute(target)},
+			)
+		}
+	}
+
+	env := make(map[string]string)
+	for k, v := range c.ContainerEnv {
+		env[k] = v
+	}
+	for k, v := range c.RemoteEnv {
+		env[k] = v
+	}
+	names := make([]string, 0, len(env))
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		devConfig.Env = append(devConfig.Env, &profile.Env{Name: k, Value: c.substitute(env[k])})
+	}
+
+	postCreate, err := command(c.PostCreateCommand)
+	if err != nil {
+		return nil, err
+	}
+	if len(postCreate) > 0 {
+		devConfig.Command = &profile.DevCommands{PostCreate: postCreate}
+	}
+	return devConfig, nil
+}
+
+// ServiceConfig the service config of the workload with the dev config
+func (c *Config) ServiceConfig(svcName, svcType string) (*profile.ServiceConfigV2, error) {
+	devConfig, err := c.DevConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &profile.ServiceConfigV2{
+		Name:             svcName,
+		Type:             svcType,
+		ContainerConfigs: []*profile.ContainerConfig{{Dev: devConfig}},
+	}, nil
+}
+
+// substitute the variables of workspace supported, the local ones are
+// resolved by the directory of repository
+func (c *Config) substitute(s string) string {
+	base := filepath.Base(c.dir)
+	local := strings.NewReplacer(
+		"${localWorkspaceFolderBasename}", base,
+		"${containerWorkspaceFolderBasename}", base,
+		"${localWorkspaceFolder}", c.dir,
+	)
+	workspace := local.Replace(c.WorkspaceFolder)
+	if workspace == "" || strings.Contains(workspace, "${containerWorkspaceFolder}") {
+		workspace = "/workspaces/" + base
+	}
+	return strings.ReplaceAll(local.Replace(s), "${containerWorkspaceFolder}", workspace)
+}
+
+// forwardPort the port of dev container, 0 if it is the one of another service
+func forwardPort(port interface{}) int {
+	switch p := port.(type) {
+	case float64:
+		return int(p)
+	case string:
+		host, portStr := "localhost", p
+		if i := strings.LastIndex(p, ":"); i >= 0 {
+			host, portStr = p[:i], p[i+1:]
+		}
+		if host != "localhost" && host != "127.0.0.1" {
+			return 0
+		}
+		n, _ := strconv.Atoi(portStr)
+		return n
+	}
+	return 0
+}
+
+// volumeTarget the target of the mount of volume
+func volumeTarget(mount interface{}) (string, bool) {
+	fields := make(map[string]string)
+	switch m := mount.(type) {
+	case string:
+		for _, kv := range strings.Split(m, ",") {
+			if i := strings.Index(kv, "="); i > 0 {
+				fields[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+			}
+		}
+	case map[string]interface{}:
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				fields[k] = s
+			}
+		}
+	}
+	target := fields["target"]
+	if target == "" {
+		target = fields["destination"]
+	}
+	if target == "" {
+		target = fields["dst"]
+	}
+	return target, fields["type"] == "volume" && target != ""
+}
+
+// command the command of lifecycle script, the string and the commands of
+// object are run by shell
+func command(cmd interface{}) ([]string, error) {
+	switch c := cmd.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if c == "" {
+			return nil, nil
+		}
+		return []string{"sh", "-c", c}, nil
+	case []interface{}:
+		return toStrings(c)
+	case map[string]interface{}:
+		names := make([]string, 0, len(c))
+		for name := range c {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		scripts := make([]string, 0, len(c))
+		for _, name := range names {
+			switch v := c[name].(type) {
+			case string:
+				scripts = append(scripts, v)
+			case []interface{}:
+				args, err := toStrings(v)
+				if err != nil {
+					return nil, err
+				}
+				for i, arg := range args {
+					args[i] = quote(arg)
+				}
+				scripts = append(scripts, strings.Join(args, " "))
+			default:
+				return nil, errors.New(fmt.Sprintf("Invalid command %s of postCreateCommand", name))
+			}
+		}
+		if len(scripts) == 0 {
+			return nil, nil
+		}
+		return []string{"sh", "-c", strings.Join(scripts, " && ")}, nil
+	}
+	return nil, errors.New("postCreateCommand should be a string, an array or an object")
+}
+
+func toStrings(items []interface{}) ([]string, error) {
+	args := make([]string, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Invalid arg %v of command, it should be a string", item))
+		}
+		args = append(args, s)
+	}
+	return args, nil
+}
+
+func quote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
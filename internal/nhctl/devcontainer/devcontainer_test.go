@@ -0,0 +1,106 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package devcontainer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const devContainerJson = `{
+	// the image of go
+	"name": "app",
+	"image": "mcr.microsoft.com/devcontainers/go:1.16", /* the tag */
+	"workspaceFolder": "/workspaces/${localWorkspaceFolderBasename}",
+	"forwardPorts": [8080, "localhost:9090", "db:5432",],
+	"mounts": [
+		"source=go-mod,target=/go/pkg/mod,type=volume",
+		"source=${localEnv:HOME}/.ssh,target=/root/.ssh,type=bind",
+		{"source": "cache", "target": "${containerWorkspaceFolder}/.cache", "type": "volume"},
+	],
+	"containerEnv": {"GOPROXY": "https://goproxy.cn", "URL": "http://a//b"},
+	"remoteEnv": {"WORKSPACE": "${containerWorkspaceFolder}"},
+	"postCreateCommand": "go mod download",
+}`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if Find(dir) != "" {
+		t.Fatal("devcontainer.json should not be found")
+	}
+	if err = os.MkdirAll(filepath.Join(dir, ".devcontainer"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, Paths[0]), []byte(devContainerJson), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	devConfig, err := c.DevConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	workDir := "/workspaces/" + filepath.Base(dir)
+	if devConfig.Image != "mcr.microsoft.com/devcontainers/go:1.16" || devConfig.WorkDir != workDir {
+		t.Errorf("unexpected image %s or work dir %s", devConfig.Image, devConfig.WorkDir)
+	}
+	if !reflect.DeepEqual(devConfig.PortForward, []string{"8080:8080", "9090:9090"}) {
+		t.Errorf("unexpected ports %v", devConfig.PortForward)
+	}
+	if len(devConfig.PersistentVolumeDirs) != 2 || devConfig.PersistentVolumeDirs[0].Path != "/go/pkg/mod" ||
+		devConfig.PersistentVolumeDirs[1].Path != workDir+"/.cache" {
+		t.Errorf("unexpected persistent volume dirs %v", devConfig.PersistentVolumeDirs)
+	}
+	if len(devConfig.Env) != 3 || devConfig.Env[1].Value != "http://a//b" || devConfig.Env[2].Value != workDir {
+		t.Errorf("unexpected env %v", devConfig.Env)
+	}
+	if !reflect.DeepEqual(devConfig.Command.PostCreate, []string{"sh", "-c", "go mod download"}) {
+		t.Errorf("unexpected post create command %v", devConfig.Command.PostCreate)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	cases := []struct {
+		cmd    interface{}
+		expect []string
+	}{
+		{nil, nil},
+		{"npm install", []string{"sh", "-c", "npm install"}},
+		{[]interface{}{"npm", "ci"}, []string{"npm", "ci"}},
+		{
+			map[string]interface{}{"server": "npm ci", "client": []interface{}{"yarn", "it's"}},
+			[]string{"sh", "-c", `'yarn' 'it'\''s' && npm ci`},
+		},
+	}
+	for _, c := range cases {
+		actual, err := command(c.cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, c.expect) {
+			t.Errorf("%v: got %v, expect %v", c.cmd, actual, c.expect)
+		}
+	}
+	if _, err := command(1.0); err == nil {
+		t.Error("number should be an invalid command")
+	}
+}
+
+func TestDevConfigWithoutImage(t *testing.T) {
+	if _, err := (&Config{Build: map[string]interface{}{"dockerfile": "Dockerfile"}}).DevConfig(); err == nil {
+		t.Error("the dev container built should be unsupported")
+	}
+}
@@ -0,0 +1,139 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package devimage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ConfigMapName the config map of the catalog distributed by nocalhost-api
+	// to the namespace of every dev space
+	ConfigMapName = "nocalhost-dev-images"
+	catalogKey    = "catalog.json"
+)
+
+// Image the dev image approved by the platform team
+type Image struct {
+	Name        string `json:"name"`
+	Language    string `json:"language"`
+	Image       string `json:"image"`
+	Shell       string `json:"shell,omitempty"`
+	DebugPort   int    `json:"debugPort,omitempty"`
+	WorkDir     string `json:"workDir,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Catalog the dev images of dev space, the first image of a language is its
+// default dev image
+type Catalog struct {
+	// Enforce only the images of catalog are allowed to start DevMode
+	Enforce bool     `json:"enforce"`
+	Images  []*Image `json:"images"`
+}
+
+// Allowed the image is in the catalog, or any image is allowed if not enforced.
+// The image of catalog without tag allows all tags of the repository
+func (c *Catalog) Allowed(image string) bool {
+	if !c.Enforce {
+		return true
+	}
+	for _, i := range c.Images {
+		if i.Image == image || (!hasTagOrDigest(i.Image) && repositoryOf(image) == i.Image) {
+			return true
+		}
+	}
+	return false
+}
+
+// Default the default dev image of language, nil if none
+func (c *Catalog) Default(language string) *Image {
+	for _, i := range c.Images {
+		if i.Language == language {
+			return i
+		}
+	}
+	return nil
+}
+
+// ImagesOf the images of the language, all if language is empty
+func (c *Catalog) ImagesOf(language string) []string {
+	images := make([]string, 0, len(c.Images))
+	for _, i := range c.Images {
+		if language == "" || i.Language == language {
+			images = append(images, i.Image)
+		}
+	}
+	return images
+}
+
+func hasTagOrDigest(image string) bool {
+	return repositoryOf(image) != image
+}
+
+// repositoryOf the image without tag or digest, the port of registry such as
+// registry:5000/app is not taken as the tag
+func repositoryOf(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// Get the catalog of namespace, nil if nocalhost-api distributes none
+func Get(client kubernetes.Interface, namespace string) (*Catalog, error) {
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), ConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	catalog := &Catalog{}
+	if err = json.Unmarshal([]byte(cm.Data[catalogKey]), catalog); err != nil {
+		return nil, errors.Wrap(err, "Invalid dev image catalog")
+	}
+	return catalog, nil
+}
+
+// Apply create or update the catalog of namespace
+func Apply(client kubernetes.Interface, namespace string, catalog *Catalog) error {
+	bys, err := json.Marshal(catalog)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	api := client.CoreV1().ConfigMaps(namespace)
+	cm, err := api.Get(context.TODO(), ConfigMapName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		_, err = api.Create(
+			context.TODO(), &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName},
+				Data:       map[string]string{catalogKey: string(bys)},
+			}, metav1.CreateOptions{},
+		)
+		return errors.Wrap(err, "")
+	}
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	if cm.Data[catalogKey] == string(bys) {
+		return nil
+	}
+	cm.Data = map[string]string{catalogKey: string(bys)}
+	_, err = api.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	return errors.Wrap(err, "")
+}
@@ -0,0 +1,74 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package devimage
+
+import (
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var catalog = &Catalog{
+	Enforce: true,
+	Images: []*Image{
+		{Name: "go-1.16", Language: "go", Image: "nocalhost-docker.pkg.coding.net/nocalhost/dev-images/golang:1.16"},
+		{Name: "go-latest", Language: "go", Image: "registry:5000/golang"},
+		{Name: "java-11", Language: "java", Image: "registry:5000/java@sha256:abc"},
+	},
+}
+
+func TestAllowed(t *testing.T) {
+	cases := map[string]bool{
+		"nocalhost-docker.pkg.coding.net/nocalhost/dev-images/golang:1.16": true,
+		"nocalhost-docker.pkg.coding.net/nocalhost/dev-images/golang:1.17": false,
+		"registry:5000/golang":                 true,
+		"registry:5000/golang:1.17":            true,
+		"registry:5000/golang@sha256:def":      true,
+		"registry:5000/java@sha256:abc":        true,
+		"registry:5000/java:11":                false,
+		"registry:5000/golang-tools:1.16":      false,
+		"docker.io/library/golang:1.16-alpine": false,
+	}
+	for image, expect := range cases {
+		if actual := catalog.Allowed(image); actual != expect {
+			t.Errorf("%s: got %v, expect %v", image, actual, expect)
+		}
+	}
+	if !(&Catalog{Images: catalog.Images}).Allowed("busybox") {
+		t.Error("any image should be allowed if not enforced")
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if i := catalog.Default("go"); i == nil || i.Name != "go-1.16" {
+		t.Errorf("the first image of language should be the default, got %v", i)
+	}
+	if i := catalog.Default("python"); i != nil {
+		t.Errorf("python should have no default, got %v", i)
+	}
+	if images := catalog.ImagesOf("java"); len(images) != 1 || images[0] != "registry:5000/java@sha256:abc" {
+		t.Errorf("unexpected images of java %v", images)
+	}
+}
+
+func TestApplyAndGet(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	if c, err := Get(client, "nh"); err != nil || c != nil {
+		t.Fatalf("the catalog should be nil if not distributed, got %v, %v", c, err)
+	}
+	for _, c := range []*Catalog{catalog, {Images: catalog.Images[:1]}} {
+		if err := Apply(client, "nh", c); err != nil {
+			t.Fatal(err)
+		}
+		actual, err := Get(client, "nh")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual.Enforce != c.Enforce || len(actual.Images) != len(c.Images) || actual.Images[0].Name != "go-1.16" {
+			t.Errorf("unexpected catalog %+v", actual)
+		}
+	}
+}
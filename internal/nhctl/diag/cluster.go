@@ -0,0 +1,167 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package diag
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// KubeconfigMeta the kubeconfig without any credentials
+type KubeconfigMeta struct {
+	Path           string         `json:"path"`
+	CurrentContext string         `json:"currentContext"`
+	Contexts       []*ContextMeta `json:"contexts"`
+	Clusters       []*ClusterMeta `json:"clusters"`
+	Users          []*UserMeta    `json:"users"`
+}
+
+type ContextMeta struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace"`
+}
+
+type ClusterMeta struct {
+	Name                  string `json:"name"`
+	Server                string `json:"server"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify"`
+	HasCertificateAuth    bool   `json:"hasCertificateAuthority"`
+}
+
+// UserMeta the kinds of credentials only, such as token, client-certificate,
+// exec or auth-provider
+type UserMeta struct {
+	Name        string   `json:"name"`
+	AuthMethods []string `json:"authMethods"`
+}
+
+// Event the event of namespace, simplified
+type Event struct {
+	LastTimestamp time.Time `json:"lastTimestamp"`
+	Type          string    `json:"type"`
+	Reason        string    `json:"reason"`
+	Object        string    `json:"object"`
+	Message       string    `json:"message"`
+	Count         int32     `json:"count"`
+}
+
+func kubeconfigLoader(o *Options) clientcmd.ClientConfig {
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: o.KubeConfig}, &clientcmd.ConfigOverrides{},
+	)
+}
+
+func collectKubeconfig(b *bundle, o *Options) error {
+	loader := kubeconfigLoader(o)
+	raw, err := loader.RawConfig()
+	if err != nil {
+		b.fail("kubeconfig", err)
+		return nil
+	}
+	if o.Namespace == "" {
+		if o.Namespace, _, _ = loader.Namespace(); o.Namespace == "" {
+			o.Namespace = "default"
+		}
+	}
+	return b.addJSON("kubeconfig.json", kubeconfigMetaOf(o.KubeConfig, &raw))
+}
+
+func kubeconfigMetaOf(path string, raw *clientcmdapi.Config) *KubeconfigMeta {
+	meta := &KubeconfigMeta{
+		Path:           path,
+		CurrentContext: raw.CurrentContext,
+		Contexts:       make([]*ContextMeta, 0),
+		Clusters:       make([]*ClusterMeta, 0),
+		Users:          make([]*UserMeta, 0),
+	}
+	for name, c := range raw.Contexts {
+		meta.Contexts = append(
+			meta.Contexts, &ContextMeta{Name: name, Cluster: c.Cluster, User: c.AuthInfo, Namespace: c.Namespace},
+		)
+	}
+	for name, c := range raw.Clusters {
+		meta.Clusters = append(
+			meta.Clusters, &ClusterMeta{
+				Name: name, Server: c.Server, InsecureSkipTLSVerify: c.InsecureSkipTLSVerify,
+				HasCertificateAuth: c.CertificateAuthority != "" || len(c.CertificateAuthorityData) > 0,
+			},
+		)
+	}
+	for name, u := range raw.AuthInfos {
+		methods := make([]string, 0)
+		if u.Token != "" || u.TokenFile != "" {
+			methods = append(methods, "token")
+		}
+		if len(u.ClientCertificateData) > 0 || u.ClientCertificate != "" {
+			methods = append(methods, "client-certificate")
+		}
+		if u.Username != "" {
+			methods = append(methods, "basic")
+		}
+		if u.Exec != nil {
+			methods = append(methods, "exec:"+u.Exec.Command)
+		}
+		if u.AuthProvider != nil {
+			methods = append(methods, "auth-provider:"+u.AuthProvider.Name)
+		}
+		meta.Users = append(meta.Users, &UserMeta{Name: name, AuthMethods: methods})
+	}
+	sort.Slice(meta.Contexts, func(i, j int) bool { return meta.Contexts[i].Name < meta.Contexts[j].Name })
+	sort.Slice(meta.Clusters, func(i, j int) bool { return meta.Clusters[i].Name < meta.Clusters[j].Name })
+	sort.Slice(meta.Users, func(i, j int) bool { return meta.Users[i].Name < meta.Users[j].Name })
+	return meta
+}
+
+// collectEvents the latest events of namespace, newest first
+func collectEvents(b *bundle, o *Options) error {
+	config, err := kubeconfigLoader(o).ClientConfig()
+	if err != nil {
+		b.fail("events", err)
+		return nil
+	}
+	config.Timeout = 10 * time.Second
+	clientSet, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		b.fail("events", err)
+		return nil
+	}
+	list, err := clientSet.CoreV1().Events(o.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		b.fail("events", err)
+		return nil
+	}
+	return b.addJSON("events.json", latestEvents(list.Items, o.MaxEvents))
+}
+
+func latestEvents(items []corev1.Event, max int) []*Event {
+	events := make([]*Event, 0, len(items))
+	for _, e := range items {
+		last := e.LastTimestamp.Time
+		if last.IsZero() {
+			last = e.EventTime.Time
+		}
+		events = append(
+			events, &Event{
+				LastTimestamp: last, Type: e.Type, Reason: e.Reason, Message: e.Message, Count: e.Count,
+				Object: e.InvolvedObject.Kind + "/" + e.InvolvedObject.Name,
+			},
+		)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].LastTimestamp.After(events[j].LastTimestamp) })
+	if max > 0 && len(events) > max {
+		events = events[:max]
+	}
+	return events
+}
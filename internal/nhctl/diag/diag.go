@@ -0,0 +1,125 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package diag
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"nocalhost/internal/nhctl/daemon_common"
+)
+
+// Options of collecting, the namespace is the one of current context if empty
+type Options struct {
+	KubeConfig string
+	Namespace  string
+	// Output the path of tar.gz
+	Output string
+	// MaxLogBytes only the tail of each log is collected, 0 means no limit
+	MaxLogBytes int64
+	// MaxEvents the latest events of namespace to collect
+	MaxEvents int
+}
+
+// Manifest describes the bundle, the collectors failed are recorded in
+// Errors instead of aborting the others
+type Manifest struct {
+	Version   string    `json:"version"`
+	CommitId  string    `json:"commitId"`
+	Os        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	CreatedAt time.Time `json:"createdAt"`
+	Namespace string    `json:"namespace"`
+	Files     []string  `json:"files"`
+	Errors    []string  `json:"errors"`
+}
+
+type bundle struct {
+	tw       *tar.Writer
+	manifest *Manifest
+	// kubeconfigs the kubeconfig of applications by namespace/application
+	kubeconfigs map[string]string
+}
+
+// add writes the file redacted into the bundle
+func (b *bundle) add(name string, data []byte) error {
+	data = Redact(data)
+	header := &tar.Header{
+		Name: name, Mode: 0600, Size: int64(len(data)), ModTime: b.manifest.CreatedAt, Typeflag: tar.TypeReg,
+	}
+	if err := b.tw.WriteHeader(header); err != nil {
+		return errors.Wrap(err, "")
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		return errors.Wrap(err, "")
+	}
+	b.manifest.Files = append(b.manifest.Files, name)
+	return nil
+}
+
+func (b *bundle) addJSON(name string, v interface{}) error {
+	bys, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	return b.add(name, bys)
+}
+
+func (b *bundle) fail(what string, err error) {
+	b.manifest.Errors = append(b.manifest.Errors, fmt.Sprintf("%s: %v", what, err))
+}
+
+// Collect writes the logs, profiles, the states of daemon, the metadata of
+// kubeconfig and the events of namespace into o.Output
+func Collect(o Options) (*Manifest, error) {
+	f, err := os.OpenFile(o.Output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	defer func() { _ = f.Close() }()
+	gw := gzip.NewWriter(f)
+	b := &bundle{
+		tw:          tar.NewWriter(gw),
+		kubeconfigs: map[string]string{},
+		manifest: &Manifest{
+			Version:   daemon_common.Version,
+			CommitId:  daemon_common.CommitId,
+			Os:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+			CreatedAt: time.Now(),
+			Namespace: o.Namespace,
+			Files:     make([]string, 0),
+			Errors:    make([]string, 0),
+		},
+	}
+
+	collectors := []func(*bundle, *Options) error{
+		collectLogs, collectProfiles, collectDaemon, collectKubeconfig, collectEvents,
+	}
+	for _, collect := range collectors {
+		if err = collect(b, &o); err != nil {
+			return nil, err
+		}
+	}
+	b.manifest.Namespace = o.Namespace
+
+	if err = b.addJSON("manifest.json", b.manifest); err != nil {
+		return nil, err
+	}
+	if err = b.tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	if err = gw.Close(); err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return b.manifest, nil
+}
@@ -0,0 +1,176 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package diag
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"nocalhost/internal/nhctl/daemon_client"
+	"nocalhost/internal/nhctl/daemon_common"
+	"nocalhost/internal/nhctl/nocalhost"
+	pb "nocalhost/internal/nhctl/rpc/daemon/v1"
+)
+
+// SyncStatus the status of file sync of a workload
+type SyncStatus struct {
+	Namespace   string         `json:"namespace"`
+	Application string         `json:"application"`
+	Workload    string         `json:"workload"`
+	Type        string         `json:"type"`
+	Status      *pb.SyncStatus `json:"status,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// collectLogs the logs of nhctl, daemon and the sessions of workloads
+func collectLogs(b *bundle, o *Options) error {
+	logDir := nocalhost.GetLogDir()
+	return filepath.Walk(
+		logDir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				b.fail("logs", err)
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			data, err := readTail(p, o.MaxLogBytes)
+			if err != nil {
+				b.fail("logs", err)
+				return nil
+			}
+			rel, _ := filepath.Rel(logDir, p)
+			return b.add(path.Join("logs", filepath.ToSlash(rel)), data)
+		},
+	)
+}
+
+func readTail(p string, maxBytes int64) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	defer func() { _ = f.Close() }()
+	if info, err := f.Stat(); err == nil && maxBytes > 0 && info.Size() > maxBytes {
+		if _, err = f.Seek(-maxBytes, io.SeekEnd); err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+	}
+	bys, err := ioutil.ReadAll(f)
+	return bys, errors.Wrap(err, "")
+}
+
+func collectProfiles(b *bundle, _ *Options) error {
+	apps, err := nocalhost.GetNsAndApplicationInfo(false, false)
+	if err != nil {
+		b.fail("profiles", err)
+		return nil
+	}
+	for _, a := range apps {
+		p, err := nocalhost.GetProfileV2(a.Namespace, a.Name, a.Nid)
+		if err != nil {
+			b.fail("profile of "+a.Namespace+"/"+a.Name, err)
+			continue
+		}
+		if err = b.addJSON(path.Join("profiles", a.Namespace, a.Name+".json"), p); err != nil {
+			return err
+		}
+		b.kubeconfigs[a.Namespace+"/"+a.Name] = p.Kubeconfig
+	}
+	return nil
+}
+
+// collectDaemon the port-forwards and the file sync of the daemons running,
+// the daemons are neither started nor upgraded
+func collectDaemon(b *bundle, _ *Options) error {
+	for _, isSudo := range []bool{false, true} {
+		name := "daemon/diagnostics.json"
+		if isSudo {
+			name = "daemon/sudo-diagnostics.json"
+		}
+		if !daemon_common.IsDaemonListening(isSudo) {
+			continue
+		}
+		diagnostics, err := daemon_client.ProbeDaemonDiagnostics(isSudo)
+		if err != nil {
+			b.fail(name, err)
+			continue
+		}
+		if err = b.addJSON(name, diagnostics); err != nil {
+			return err
+		}
+		if !isSudo {
+			if err = collectSyncStatus(b, diagnostics.SyncSessions); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func collectSyncStatus(b *bundle, sessions []*daemon_common.SyncSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	// dialing the grpc of a daemon of another version upgrades it
+	info, err := daemon_client.ProbeDaemonServer(false)
+	if err != nil {
+		b.fail("sync status", err)
+		return nil
+	}
+	if info.Version != daemon_common.Version || info.CommitId != daemon_common.CommitId {
+		b.fail("sync status", errors.New("daemon is "+info.Version+", but nhctl is "+daemon_common.Version))
+		return nil
+	}
+	client, conn, err := daemon_client.DialDaemonService(false)
+	if err != nil {
+		b.fail("sync status", err)
+		return nil
+	}
+	defer func() { _ = conn.Close() }()
+
+	statuses := make([]*SyncStatus, 0, len(sessions))
+	for _, s := range sessions {
+		status := &SyncStatus{Namespace: s.NameSpace, Application: s.AppName, Workload: s.SvcName, Type: s.SvcType}
+		if st, err := watchSyncStatusOnce(client, b.kubeconfigs[s.NameSpace+"/"+s.AppName], s); err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Status = st
+		}
+		statuses = append(statuses, status)
+	}
+	return b.addJSON("daemon/sync-status.json", statuses)
+}
+
+func watchSyncStatusOnce(
+	client pb.DaemonServiceClient, kubeconfig string, s *daemon_common.SyncSession,
+) (*pb.SyncStatus, error) {
+	content, err := ioutil.ReadFile(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	stream, err := client.WatchSyncStatus(
+		ctx, &pb.WatchSyncStatusRequest{
+			Workload: &pb.Workload{
+				Kubeconfig: string(content), Namespace: s.NameSpace, Application: s.AppName,
+				Name: s.SvcName, Type: s.SvcType,
+			},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	st, err := stream.Recv()
+	return st, errors.Wrap(err, "")
+}
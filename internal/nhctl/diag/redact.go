@@ -0,0 +1,39 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package diag
+
+import "regexp"
+
+const redacted = "<redacted>"
+
+var redactions = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{
+		regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+		redacted,
+	},
+	{regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`), "${1}" + redacted},
+	// the values of sensitive keys in json, yaml, flags and query strings
+	{
+		regexp.MustCompile(
+			`(?i)("?(?:password|passwd|token|secret|api[_-]?key|access[_-]?key|secret[_-]?key|authorization|` +
+				`client-key-data|client-certificate-data|certificate-authority-data)"?\s*[:=]\s*"?)([^"\s,&}]+)`,
+		),
+		"${1}" + redacted,
+	},
+	// jwt, such as the tokens of service accounts
+	{regexp.MustCompile(`eyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`), redacted},
+}
+
+// Redact replaces the secrets, such as the tokens, passwords and private keys
+func Redact(data []byte) []byte {
+	for _, r := range redactions {
+		data = r.pattern.ReplaceAll(data, []byte(r.replace))
+	}
+	return data
+}
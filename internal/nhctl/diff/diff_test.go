@@ -0,0 +1,56 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	if d := Unified("a\nb\n", "a\nb\n", "live", "upgraded"); d != "" {
+		t.Errorf("the same should have no diff, got %s", d)
+	}
+
+	lines := make([]string, 0, 20)
+	for i := 0; i < 20; i++ {
+		lines = append(lines, string(rune('a'+i)))
+	}
+	a := strings.Join(lines, "\n") + "\n"
+	lines[1], lines[18] = "B", "S"
+	b := strings.Join(lines, "\n") + "\n"
+
+	expect := `--- live
++++ upgraded
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -16,5 +16,5 @@
+ p
+ q
+ r
+-s
++S
+ t
+`
+	if d := Unified(a, b, "live", "upgraded"); d != expect {
+		t.Errorf("got\n%s\nexpect\n%s", d, expect)
+	}
+
+	expect = `--- live
++++ upgraded
+@@ -0,0 +1,2 @@
++kind: Service
++name: web
+`
+	if d := Unified("", "kind: Service\nname: web\n", "live", "upgraded"); d != expect {
+		t.Errorf("got\n%s\nexpect\n%s", d, expect)
+	}
+}
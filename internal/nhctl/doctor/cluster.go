@@ -0,0 +1,190 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package doctor
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	depNamespace  = "nocalhost-reserved"
+	depDeployment = "nocalhost-dep"
+	depWebhook    = "nocalhost-mutating.coding.net"
+)
+
+// devPermissions the permissions of namespace needed by DevMode, port-forward
+// and the application meta saved in secrets
+var devPermissions = []authorizationv1.ResourceAttributes{
+	{Verb: "list", Resource: "pods"},
+	{Verb: "create", Resource: "pods", Subresource: "exec"},
+	{Verb: "create", Resource: "pods", Subresource: "portforward"},
+	{Verb: "patch", Group: "apps", Resource: "deployments"},
+	{Verb: "create", Resource: "secrets"},
+	{Verb: "update", Resource: "secrets"},
+	{Verb: "create", Resource: "configmaps"},
+}
+
+func (d *doctor) checkKubeconfig() *Result {
+	const name = "kubeconfig"
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: d.KubeConfig}, &clientcmd.ConfigOverrides{},
+	)
+	raw, err := loader.RawConfig()
+	if err != nil {
+		return newResult(name, Fail, "Failed to load %s: %v", d.KubeConfig, err).
+			withFix("Make sure %s is a valid kubeconfig, or specify one by --kubeconfig", d.KubeConfig)
+	}
+	ctx, ok := raw.Contexts[raw.CurrentContext]
+	if !ok {
+		return newResult(name, Fail, "Current context '%s' is not found in %s", raw.CurrentContext, d.KubeConfig).
+			withFix("Choose a context by `kubectl config use-context <context> --kubeconfig %s`", d.KubeConfig)
+	}
+	if d.restConfig, err = loader.ClientConfig(); err != nil {
+		return newResult(name, Fail, "Context '%s' is invalid: %v", raw.CurrentContext, err).
+			withFix("Run `nhctl kubeconfig check --kubeconfig %s` for the details", d.KubeConfig)
+	}
+	if d.server, err = url.Parse(d.restConfig.Host); err != nil || d.server.Host == "" {
+		d.restConfig = nil
+		return newResult(name, Fail, "Server '%s' of context '%s' is invalid", ctx.Cluster, raw.CurrentContext).
+			withFix("Fix the server of cluster '%s' in %s", ctx.Cluster, d.KubeConfig)
+	}
+	if d.Namespace == "" {
+		if d.Namespace, _, _ = loader.Namespace(); d.Namespace == "" {
+			d.Namespace = "default"
+		}
+	}
+	d.restConfig.Timeout = d.Timeout
+	if d.clientSet, err = kubernetes.NewForConfig(d.restConfig); err != nil {
+		d.restConfig = nil
+		return newResult(name, Fail, "Failed to create the client of cluster: %v", err)
+	}
+	return newResult(name, Pass, "Context '%s' of %s, namespace %s", raw.CurrentContext, d.KubeConfig, d.Namespace)
+}
+
+// checkApiServer resolves the host of api server and connects to it
+func (d *doctor) checkApiServer() *Result {
+	const name = "api-server"
+	if d.clientSet == nil {
+		return newResult(name, Skip, "Kubeconfig is invalid")
+	}
+
+	host, port := d.server.Hostname(), d.server.Port()
+	if port == "" {
+		port = "443"
+		if d.server.Scheme == "http" {
+			port = "80"
+		}
+	}
+	if net.ParseIP(host) == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), d.Timeout)
+		defer cancel()
+		if _, err := net.DefaultResolver.LookupHost(ctx, host); err != nil {
+			d.clientSet = nil
+			return newResult(name, Fail, "Failed to resolve %s: %v", host, err).
+				withFix("Check the dns, or map %s to the ip of api server in /etc/hosts", host)
+		}
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, port), d.Timeout)
+	if err != nil {
+		d.clientSet = nil
+		return newResult(name, Fail, "Failed to connect to %s: %v", d.server.Host, err).
+			withFix("Check the network, the proxy or the vpn to the cluster")
+	}
+	_ = conn.Close()
+
+	info, err := d.clientSet.Discovery().ServerVersion()
+	if err != nil {
+		d.clientSet = nil
+		return newResult(name, Fail, "Api server %s does not respond: %v", d.server.Host, err).
+			withFix("Check the credentials of kubeconfig are not expired")
+	}
+	return newResult(name, Pass, "Kubernetes %s at %s", info.GitVersion, d.server.Host)
+}
+
+func (d *doctor) checkRBAC() *Result {
+	const name = "rbac"
+	if d.clientSet == nil {
+		return newResult(name, Skip, "Api server is unreachable")
+	}
+
+	denied := make([]string, 0)
+	for _, p := range devPermissions {
+		attributes := p
+		attributes.Namespace = d.Namespace
+		review, err := d.clientSet.AuthorizationV1().SelfSubjectAccessReviews().Create(
+			context.TODO(), &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &attributes},
+			}, metav1.CreateOptions{},
+		)
+		if err != nil {
+			return newResult(name, Warn, "Failed to review the permissions: %v", err)
+		}
+		if !review.Status.Allowed {
+			denied = append(denied, permissionString(p))
+		}
+	}
+	if len(denied) > 0 {
+		return newResult(name, Fail, "Denied in namespace %s: %s", d.Namespace, strings.Join(denied, ", ")).
+			withFix("Ask the admin of cluster to grant them, such as binding the ClusterRole edit in %s", d.Namespace)
+	}
+	return newResult(name, Pass, "Permissions of DevMode are granted in namespace %s", d.Namespace)
+}
+
+func permissionString(p authorizationv1.ResourceAttributes) string {
+	resource := p.Resource
+	if p.Subresource != "" {
+		resource += "/" + p.Subresource
+	}
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	return p.Verb + " " + resource
+}
+
+// checkWebhook nocalhost-dep is optional, it waits for the dependencies of
+// applications by its admission webhook
+func (d *doctor) checkWebhook() *Result {
+	const name = "nocalhost-dep"
+	if d.clientSet == nil {
+		return newResult(name, Skip, "Api server is unreachable")
+	}
+
+	_, err := d.clientSet.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(
+		context.TODO(), depWebhook, metav1.GetOptions{},
+	)
+	switch {
+	case k8serrors.IsNotFound(err):
+		return newResult(name, Warn, "Webhook %s is not installed, dependencies are not waited", depWebhook).
+			withFix("Install it by `nhctl init dep --kubeconfig %s` if applications declare dependencies", d.KubeConfig)
+	case k8serrors.IsForbidden(err):
+		return newResult(name, Skip, "No permission to read the webhooks of cluster")
+	case err != nil:
+		return newResult(name, Warn, "Failed to get webhook %s: %v", depWebhook, err)
+	}
+
+	deployment, err := d.clientSet.AppsV1().Deployments(depNamespace).Get(
+		context.TODO(), depDeployment, metav1.GetOptions{},
+	)
+	if err != nil {
+		return newResult(
+			name, Fail, "Webhook is installed but deployment %s/%s is not: %v", depNamespace, depDeployment, err,
+		).withFix("Reinstall it by `nhctl init dep --kubeconfig %s`", d.KubeConfig)
+	}
+	if deployment.Status.ReadyReplicas == 0 {
+		return newResult(name, Fail, "Deployment %s/%s is not ready", depNamespace, depDeployment).
+			withFix("Check it by `kubectl -n %s describe deployment %s`", depNamespace, depDeployment)
+	}
+	return newResult(name, Pass, "Webhook %s is serving", depWebhook)
+}
@@ -0,0 +1,88 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package doctor
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+type Status string
+
+const (
+	Pass Status = "PASS"
+	Warn Status = "WARN"
+	Fail Status = "FAIL"
+	// Skip the check depends on the one failed
+	Skip Status = "SKIP"
+)
+
+// Result of a check, Fix tells what to do if it does not pass
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+type Options struct {
+	KubeConfig string
+	// Namespace the one of current context if empty
+	Namespace string
+	// Ports the local ports should be available, besides the ones of daemon
+	Ports   []int
+	Timeout time.Duration
+}
+
+// doctor the state shared by the checks, such as the cluster loaded from
+// kubeconfig
+type doctor struct {
+	Options
+	restConfig *rest.Config
+	server     *url.URL
+	clientSet  *kubernetes.Clientset
+}
+
+func newResult(name string, status Status, format string, args ...interface{}) *Result {
+	return &Result{Name: name, Status: status, Message: fmt.Sprintf(format, args...)}
+}
+
+func (r *Result) withFix(format string, args ...interface{}) *Result {
+	r.Fix = fmt.Sprintf(format, args...)
+	return r
+}
+
+// Run checks the environment of nhctl and the connectivity to the cluster in
+// order, the checks of cluster are skipped if kubeconfig is invalid
+func Run(o Options) []*Result {
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+	d := &doctor{Options: o}
+	return []*Result{
+		d.checkKubeconfig(),
+		d.checkApiServer(),
+		d.checkRBAC(),
+		d.checkWebhook(),
+		d.checkSyncthing(),
+		d.checkDaemon(),
+		d.checkPorts(),
+	}
+}
+
+// Passed none of the checks failed, the warnings are tolerated
+func Passed(results []*Result) bool {
+	for _, r := range results {
+		if r.Status == Fail {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package doctor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+)
+
+const kubeconfigTemplate = `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: %s
+  name: fake
+contexts:
+- context:
+    cluster: fake
+    namespace: nocalhost
+  name: fake
+current-context: fake
+`
+
+// fakeApiServer the api server denies exec of pods, and has no webhook
+func fakeApiServer(t *testing.T) string {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				switch {
+				case r.URL.Path == "/version":
+					_, _ = w.Write([]byte(`{"gitVersion": "v1.20.0"}`))
+				case strings.HasSuffix(r.URL.Path, "/selfsubjectaccessreviews"):
+					review := &authorizationv1.SelfSubjectAccessReview{}
+					_ = json.NewDecoder(r.Body).Decode(review)
+					review.Status.Allowed = review.Spec.ResourceAttributes.Subresource != "exec"
+					_ = json.NewEncoder(w).Encode(review)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"kind": "Status", "apiVersion": "v1", "reason": "NotFound", "code": 404}`))
+				}
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func writeKubeconfig(t *testing.T, server string) string {
+	dir, err := ioutil.TempDir("", "nhctl-doctor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "config")
+	if err = ioutil.WriteFile(path, []byte(fmt.Sprintf(kubeconfigTemplate, server)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestClusterChecks(t *testing.T) {
+	d := &doctor{Options: Options{KubeConfig: writeKubeconfig(t, fakeApiServer(t)), Timeout: time.Second}}
+
+	expects := []struct {
+		result *Result
+		status Status
+	}{
+		{d.checkKubeconfig(), Pass},
+		{d.checkApiServer(), Pass},
+		{d.checkRBAC(), Fail},
+		{d.checkWebhook(), Warn},
+	}
+	for _, e := range expects {
+		if e.result.Status != e.status {
+			t.Errorf("%s: got %s(%s), expect %s", e.result.Name, e.result.Status, e.result.Message, e.status)
+		}
+	}
+	if d.Namespace != "nocalhost" {
+		t.Errorf("namespace should be the one of context, got %s", d.Namespace)
+	}
+	if rbac := expects[2].result; !strings.Contains(rbac.Message, "create pods/exec") || rbac.Fix == "" {
+		t.Errorf("unexpected result of rbac %v", rbac)
+	}
+}
+
+func TestClusterChecksSkipped(t *testing.T) {
+	d := &doctor{Options: Options{KubeConfig: writeKubeconfig(t, "http://127.0.0.1:1"), Timeout: time.Second}}
+	if r := d.checkKubeconfig(); r.Status != Pass {
+		t.Fatalf("kubeconfig should be valid, got %v", r)
+	}
+	if r := d.checkApiServer(); r.Status != Fail || r.Fix == "" {
+		t.Errorf("api server should be unreachable, got %v", r)
+	}
+	for _, r := range []*Result{d.checkRBAC(), d.checkWebhook()} {
+		if r.Status != Skip {
+			t.Errorf("%s should be skipped, got %s", r.Name, r.Status)
+		}
+	}
+
+	invalid := &doctor{Options: Options{KubeConfig: filepath.Join(os.TempDir(), "not-exist-kubeconfig")}}
+	if r := invalid.checkKubeconfig(); r.Status != Fail {
+		t.Errorf("kubeconfig not exist should fail, got %v", r)
+	}
+}
+
+func TestPassed(t *testing.T) {
+	if !Passed([]*Result{{Status: Pass}, {Status: Warn}, {Status: Skip}}) {
+		t.Error("warnings should be tolerated")
+	}
+	if Passed([]*Result{{Status: Pass}, {Status: Fail}}) {
+		t.Error("failure should not pass")
+	}
+}
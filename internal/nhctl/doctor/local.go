@@ -0,0 +1,113 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package doctor
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"nocalhost/internal/nhctl/daemon_client"
+	"nocalhost/internal/nhctl/daemon_common"
+	"nocalhost/internal/nhctl/nocalhost"
+	"nocalhost/internal/nhctl/syncthing"
+	"nocalhost/internal/nhctl/syncthing/bin"
+	"nocalhost/internal/nhctl/syncthing/ports"
+	"nocalhost/internal/nhctl/utils"
+)
+
+// checkSyncthing compares the syncthing installed with the one embedded
+func (d *doctor) checkSyncthing() *Result {
+	const name = "syncthing"
+	path := filepath.Join(nocalhost.GetSyncThingBinDir(), syncthing.GetBinaryName())
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return newResult(name, Warn, "%s is not installed", path).
+			withFix("It is installed while entering DevMode")
+	}
+	if err != nil {
+		return newResult(name, Fail, "Failed to open %s: %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return newResult(name, Fail, "Failed to read %s: %v", path, err)
+	}
+	expect, err := bin.Sha256()
+	if err != nil {
+		return newResult(name, Warn, "No syncthing embedded to compare with: %v", err)
+	}
+	if actual := fmt.Sprintf("%x", hash.Sum(nil)); actual != expect {
+		return newResult(name, Fail, "Checksum of %s is %s, expect %s", path, actual[:12], expect[:12]).
+			withFix("Remove %s, it is reinstalled while entering DevMode", path)
+	}
+	return newResult(name, Pass, "%s matches the one of nhctl", path)
+}
+
+func (d *doctor) checkDaemon() *Result {
+	const name = "daemon"
+	if !daemon_common.IsDaemonListening(false) {
+		return newResult(name, Warn, "Daemon is not running on %s", daemon_common.DaemonAddress(false)).
+			withFix("It is started by nhctl on demand, or start it by `nhctl daemon start -d`")
+	}
+	info, err := daemon_client.ProbeDaemonServer(false)
+	if err != nil {
+		return newResult(name, Fail, "Daemon does not respond: %v", err).
+			withFix("Restart it by `nhctl daemon restart`, and see its log by `nhctl daemon logs`")
+	}
+	if info.Upgrading {
+		return newResult(name, Warn, "Daemon %s is upgrading", info.Version).
+			withFix("Run nhctl doctor again later")
+	}
+	if info.Version != daemon_common.Version || info.CommitId != daemon_common.CommitId {
+		return newResult(
+			name, Warn, "Daemon is %s of %s, but nhctl is %s", info.Version, info.NhctlPath, daemon_common.Version,
+		).withFix("Upgrade it by `nhctl daemon restart`")
+	}
+	return newResult(name, Pass, "Daemon %s is serving", info.Version)
+}
+
+// checkPorts the http port of daemon is used by IDE plugins, it is fine if
+// served by the daemon
+func (d *doctor) checkPorts() *Result {
+	const name = "ports"
+	used := make([]string, 0)
+	for _, port := range append([]int{daemon_common.DaemonHttpPort}, d.Ports...) {
+		if ports.IsTCP4PortAvailable("0.0.0.0", port) {
+			continue
+		}
+		if port == daemon_common.DaemonHttpPort && isServedByDaemon(port) {
+			continue
+		}
+		used = append(used, fmt.Sprintf("%d", port))
+	}
+	if len(used) > 0 {
+		fix := "Find the process by `lsof -i :<port>` and stop it"
+		if utils.IsWindows() {
+			fix = "Find the process by `netstat -ano | findstr <port>` and stop it"
+		}
+		return newResult(name, Fail, "Ports are used by other processes: %s", strings.Join(used, ", ")).withFix(fix)
+	}
+	return newResult(name, Pass, "Ports are available")
+}
+
+func isServedByDaemon(port int) bool {
+	client := http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://127.0.0.1:%d/health", port))
+	if err != nil {
+		return false
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := ioutil.ReadAll(resp.Body)
+	return strings.Contains(string(body), "Nocalhost")
+}
@@ -13,4 +13,20 @@ type Namespace struct {
 type ApplicationInfo struct {
 	Name string `json:"name" yaml:"name"`
 	Type string `json:"type" yaml:"type"`
+	// Revision the current revision, 0 if not recorded
+	Revision  int             `json:"revision" yaml:"revision"`
+	Revisions []*RevisionInfo `json:"revisions,omitempty" yaml:"revisions,omitempty"`
+	// BlockedBy the dependency the install is waiting for
+	BlockedBy string `json:"blocked_by,omitempty" yaml:"blockedBy,omitempty"`
+}
+
+// RevisionInfo an install, upgrade or rollback of application
+type RevisionInfo struct {
+	Revision     int    `json:"revision" yaml:"revision"`
+	Action       string `json:"action" yaml:"action"`
+	ChartVersion string `json:"chart_version,omitempty" yaml:"chartVersion,omitempty"`
+	ManifestHash string `json:"manifest_hash" yaml:"manifestHash"`
+	TriggeredBy  string `json:"triggered_by" yaml:"triggeredBy"`
+	RollbackTo   int    `json:"rollback_to,omitempty" yaml:"rollbackTo,omitempty"`
+	Time         int64  `json:"time" yaml:"time"`
 }
@@ -18,6 +18,8 @@ type DevStartOptions struct {
 	SideCarImage string
 	DevImage     string
 	Container    string
+	// ExtraContainers enter DevMode with Container, they share its work dir
+	ExtraContainers []string
 
 	// for debug
 	SyncthingVersion string
@@ -27,9 +29,22 @@ type DevStartOptions struct {
 	StorageClass  string
 	PriorityClass string
 
+	// Sidecars the sidecar policy overriding the one of dev config
+	Sidecars string
+
+	// Cpu and Memory the limits of dev container, override the resources of
+	// dev config and the ones inherited from the workload
+	Cpu    string
+	Memory string
+
 	NoTerminal  bool
 	NoSyncthing bool
+	// SSH runs sshd in the sidecar, which forwards the agent for `nhctl ssh`
+	SSH bool
 
 	DevModeType string
 	MeshHeader  map[string]string
+	// MeshProvider routes by the header with istio or linkerd, instead of the
+	// envoy sidecar of nocalhost
+	MeshProvider string
 }
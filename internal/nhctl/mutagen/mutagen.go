@@ -0,0 +1,211 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package mutagen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"nocalhost/internal/nhctl/syncthing/network/req"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const (
+	// ModeTwoWayResolved the conflicts are resolved by the alpha, the local files
+	ModeTwoWayResolved = "two-way-resolved"
+	// ModeTwoWaySafe the conflicts are kept until resolved manually
+	ModeTwoWaySafe = "two-way-safe"
+	// ModeOneWayReplica the beta is the replica of alpha, as sendonly of syncthing
+	ModeOneWayReplica = "one-way-replica"
+
+	statusWatching = "watching"
+	statusScanning = "scanning"
+
+	labelKey = "io.nocalhost.sync"
+)
+
+// Session the sync session from the local directory to the one of dev container
+type Session struct {
+	Name    string
+	Alpha   string
+	Beta    string
+	Mode    string
+	Ignores []string
+	// MaxFileSize the files larger than it in bytes are not synced, 0 means
+	// no limit
+	MaxFileSize int64
+}
+
+// State the state of session listed by mutagen
+type State struct {
+	Name      string            `json:"name"`
+	Status    string            `json:"status"`
+	Paused    bool              `json:"paused"`
+	LastError string            `json:"lastError"`
+	Conflicts []json.RawMessage `json:"conflicts"`
+	Alpha     Endpoint          `json:"alpha"`
+	Beta      Endpoint          `json:"beta"`
+}
+
+// ConflictRoots the paths of the conflicts
+func (s *State) ConflictRoots() []string {
+	roots := make([]string, 0, len(s.Conflicts))
+	for _, raw := range s.Conflicts {
+		var conflict struct {
+			Root string `json:"root"`
+		}
+		if json.Unmarshal(raw, &conflict) == nil {
+			roots = append(roots, conflict.Root)
+		}
+	}
+	return roots
+}
+
+type Endpoint struct {
+	Connected bool `json:"connected"`
+}
+
+// SessionName the name of session, only the letters, numbers and dashes
+// are allowed by mutagen
+func SessionName(parts ...string) string {
+	name := strings.Join(append([]string{"nocalhost"}, parts...), "-")
+	return strings.Map(
+		func(r rune) rune {
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+				return r
+			}
+			return '-'
+		}, name,
+	)
+}
+
+func binPath() (string, error) {
+	path, err := exec.LookPath("mutagen")
+	if err != nil {
+		return "", errors.New("Mutagen not found in PATH, please install it from https://mutagen.io")
+	}
+	return path, nil
+}
+
+func run(args ...string) ([]byte, error) {
+	path, err := binPath()
+	if err != nil {
+		return nil, err
+	}
+	log.Logf("Running mutagen %s", strings.Join(args, " "))
+	output, err := exec.Command(path, args...).CombinedOutput()
+	if err != nil {
+		return output, errors.Wrap(err, strings.TrimSpace(string(output)))
+	}
+	return output, nil
+}
+
+// Create the session, the one of the same name is terminated first
+func Create(s *Session) error {
+	_ = Terminate(s.Name)
+	args := []string{
+		"sync", "create", "--name", s.Name, "--sync-mode", s.Mode, "--label", labelKey + "=true",
+	}
+	if s.MaxFileSize > 0 {
+		args = append(args, "--max-staging-file-size", strconv.FormatInt(s.MaxFileSize, 10))
+	}
+	for _, ignore := range s.Ignores {
+		args = append(args, "--ignore", ignore)
+	}
+	_, err := run(append(args, s.Alpha, s.Beta)...)
+	return err
+}
+
+func Terminate(name string) error {
+	_, err := run("sync", "terminate", name)
+	return err
+}
+
+func Pause(name string) error {
+	_, err := run("sync", "pause", name)
+	return err
+}
+
+func Resume(name string) error {
+	_, err := run("sync", "resume", name)
+	return err
+}
+
+// Flush wait for the changes synced
+func Flush(name string) error {
+	_, err := run("sync", "flush", name)
+	return err
+}
+
+// Reset the history of session, the changes are reconciled again by the mode,
+// so that the conflicts are resolved by the local files
+func Reset(name string) error {
+	_, err := run("sync", "reset", name)
+	return err
+}
+
+// Get the state of session, nil if not found
+func Get(name string) (*State, error) {
+	output, err := run("sync", "list", "--template", "{{json .}}", name)
+	if err != nil {
+		if strings.Contains(string(output), "unable to locate") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	states := make([]*State, 0)
+	if err = json.Unmarshal(output, &states); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return states[0], nil
+}
+
+// SyncStatus the status of session in the format of syncthing, so that the
+// status is reported in the same way by the sync engines
+func (s *State) SyncStatus() *req.SyncthingStatus {
+	if s == nil {
+		return &req.SyncthingStatus{
+			Status: req.Disconnected,
+			Msg:    "Disconnected",
+			Tips:   req.Identifier + "Mutagen session not found, please resume the file sync",
+		}
+	}
+	if s.Paused {
+		return req.SyncPausedTemplate
+	}
+	if !s.Alpha.Connected || !s.Beta.Connected {
+		status := &req.SyncthingStatus{Status: req.Disconnected, Msg: "Disconnected"}
+		if s.LastError != "" {
+			status.Tips = req.Identifier + s.LastError
+		}
+		return status
+	}
+	if s.LastError != "" {
+		return &req.SyncthingStatus{Status: req.Error, Msg: "Error", Tips: req.Identifier + s.LastError}
+	}
+	if len(s.Conflicts) > 0 {
+		return &req.SyncthingStatus{
+			Status:    req.OutOfSync,
+			Msg:       fmt.Sprintf("%d conflicts", len(s.Conflicts)),
+			Tips:      req.Identifier + "Override the remote changes to resolve the conflicts by the local files",
+			OutOfSync: req.Identifier + fmt.Sprintf("%d conflicts", len(s.Conflicts)),
+		}
+	}
+	switch s.Status {
+	case statusWatching:
+		return &req.SyncthingStatus{Status: req.Idle, Msg: "Synced"}
+	case statusScanning:
+		return &req.SyncthingStatus{Status: req.Scanning, Msg: "Scanning local changed..."}
+	}
+	return &req.SyncthingStatus{Status: req.Syncing, Msg: strings.ReplaceAll(s.Status, "-", " ")}
+}
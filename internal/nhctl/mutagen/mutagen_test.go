@@ -0,0 +1,41 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package mutagen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"nocalhost/internal/nhctl/syncthing/network/req"
+)
+
+func TestSessionName(t *testing.T) {
+	if name := SessionName("default", "bookinfo", "deployment", "details_v1.x"); name !=
+		"nocalhost-default-bookinfo-deployment-details-v1-x" {
+		t.Fatalf("unexpected name %s", name)
+	}
+}
+
+func TestSyncStatus(t *testing.T) {
+	connected := Endpoint{Connected: true}
+	cases := []struct {
+		state  *State
+		status req.StatusEnum
+	}{
+		{nil, req.Disconnected},
+		{&State{Status: "watching", Alpha: connected}, req.Disconnected},
+		{&State{Status: "watching", Alpha: connected, Beta: connected, LastError: "failed"}, req.Error},
+		{&State{Status: "watching", Alpha: connected, Beta: connected, Conflicts: []json.RawMessage{[]byte("{}")}}, req.OutOfSync},
+		{&State{Status: "watching", Alpha: connected, Beta: connected}, req.Idle},
+		{&State{Status: "scanning", Alpha: connected, Beta: connected}, req.Scanning},
+		{&State{Status: "staging-beta", Alpha: connected, Beta: connected}, req.Syncing},
+	}
+	for i, c := range cases {
+		if status := c.state.SyncStatus(); status.Status != c.status {
+			t.Errorf("case %d: expected %s, got %s", i, c.status, status.Status)
+		}
+	}
+}
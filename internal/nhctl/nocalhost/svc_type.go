@@ -37,24 +37,23 @@ func init() {
 	supportedSvcType["deployments.v1beta1.apps"] = DefaultDevModeAction
 	supportedSvcType["deployments.v1beta2.apps"] = DefaultDevModeAction
 	supportedSvcType["deployments.v1beta1.extensions"] = DefaultDevModeAction
-	supportedSvcType[base.StatefulSet] = DefaultDevModeAction
-	supportedSvcType["statefulsets.v1.apps"] = DefaultDevModeAction
-	supportedSvcType["statefulsets.v1beta1.apps"] = DefaultDevModeAction
-	supportedSvcType["statefulsets.v1beta2.apps"] = DefaultDevModeAction
-	supportedSvcType["statefulsets.v1beta1.extensions"] = DefaultDevModeAction
+	supportedSvcType[base.StatefulSet] = StatefulSetDevModeAction
+	supportedSvcType["statefulsets.v1.apps"] = StatefulSetDevModeAction
+	supportedSvcType["statefulsets.v1beta1.apps"] = StatefulSetDevModeAction
+	supportedSvcType["statefulsets.v1beta2.apps"] = StatefulSetDevModeAction
+	supportedSvcType["statefulsets.v1beta1.extensions"] = StatefulSetDevModeAction
 	supportedSvcType[base.DaemonSet] = DaemonSetDevModeAction
 	supportedSvcType[base.Job] = JobDevModeAction
 	supportedSvcType[base.CronJob] = CronJobDevModeAction
-	supportedSvcType[base.CronJob] = CronJobDevModeAction
 	supportedSvcType[base.Pod] = PodDevModeAction   // Todo
 	supportedSvcType["pods.v1."] = PodDevModeAction // Todo
 
 	// Kruise
 	supportedSvcType["clonesets.v1alpha1.apps.kruise.io"] = DefaultDevModeAction
 	supportedSvcType["statefulsets.v1beta1.apps.kruise.io"] = DefaultDevModeAction
-	supportedSvcType["daemonsets.v1alpha1.apps.kruise.io"] = DaemonSetDevModeAction
+	supportedSvcType["daemonsets.v1alpha1.apps.kruise.io"] = KruiseDaemonSetDevModeAction
 	supportedSvcType["advancedcronjobs.v1alpha1.apps.kruise.io"] = KruiseCronJobDevModeAction
-	supportedSvcType["broadcastjobs.v1alpha1.apps.kruise.io"] = JobDevModeAction
+	supportedSvcType["broadcastjobs.v1alpha1.apps.kruise.io"] = BroadcastJobDevModeAction
 
 	buildInGvkList = []*schema.GroupVersionKind{
 		{Group: "apps", Version: "v1", Kind: "Deployment"},
@@ -109,9 +108,27 @@ var (
 		PodTemplatePath: "/spec/template",
 	}
 
+	// StatefulSetDevModeAction the pod is rolled in place, so that it keeps
+	// its identity and PVCs, OnDelete and partition do not block it
+	StatefulSetDevModeAction = base.DevModeAction{
+		ScalePatches: []base.PatchItem{{
+			Patch: `[{"op":"replace","path":"/spec/replicas","value":1},` +
+				`{"op":"replace","path":"/spec/updateStrategy","value":{"type":"RollingUpdate","rollingUpdate":{"partition":0}}}]`,
+			Type: "json",
+		}},
+		PodTemplatePath: "/spec/template",
+	}
+
 	PodDevModeAction = base.DevModeAction{}
 
+	// DaemonSetDevModeAction only the daemon pod of one node is replaced by
+	// the dev pod, the node is excluded by the controller
 	DaemonSetDevModeAction = base.DevModeAction{
+		PodTemplatePath: "/spec/template",
+		Create:          true,
+	}
+
+	KruiseDaemonSetDevModeAction = base.DevModeAction{
 		ScalePatches: []base.PatchItem{{
 			Patch: `[{"op":"replace","path": "/spec/template/spec/nodeName", "value": "nocalhost.unreachable"}]`,
 			Type:  "json",
@@ -120,7 +137,18 @@ var (
 		Create:          true,
 	}
 
+	// JobDevModeAction the job is suspended, its pods are deleted, and the dev
+	// pod runs once by the generated deployment
 	JobDevModeAction = base.DevModeAction{
+		ScalePatches: []base.PatchItem{{
+			Patch: `[{"op":"add","path": "/spec/suspend", "value": true}]`,
+			Type:  "json",
+		}},
+		PodTemplatePath: "/spec/template",
+		Create:          true,
+	}
+
+	BroadcastJobDevModeAction = base.DevModeAction{
 		PodTemplatePath: "/spec/template",
 		Create:          true,
 	}
@@ -0,0 +1,209 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package podlogs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coreV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// colors of the prefixes, chosen by the hash of pod name
+var colors = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+
+type Options struct {
+	// Container the container to get logs, the first one of pod if empty
+	Container     string
+	AllContainers bool
+	Follow        bool
+	// Since only the logs newer than it, all if 0
+	Since time.Duration
+	// Tail the lines of each container, all if negative
+	Tail       int64
+	Timestamps bool
+	// Include only the lines matching it are printed if not nil
+	Include *regexp.Regexp
+	// Exclude the lines matching it are not printed if not nil
+	Exclude *regexp.Regexp
+	Color   bool
+	// Pods lists the pods of the workload, it is called again every
+	// PollInterval while following, to reconnect to the pods restarted or
+	// recreated
+	Pods         func() ([]corev1.Pod, error)
+	PollInterval time.Duration
+}
+
+// aggregator writes the lines of containers with their prefixes, the lines
+// of different containers never interleave
+type aggregator struct {
+	o      *Options
+	client coreV1.PodInterface
+	out    io.Writer
+	lock   sync.Mutex
+
+	// streaming the containers streaming by pod/container, and the time to
+	// get logs since after reconnecting
+	streaming map[string]bool
+	since     map[string]time.Time
+}
+
+// Stream the logs of the containers of all pods aggregated into out, until
+// ctx is done if following, or the logs are all read otherwise
+func Stream(ctx context.Context, client coreV1.PodInterface, o *Options, out io.Writer) error {
+	if o.PollInterval <= 0 {
+		o.PollInterval = 2 * time.Second
+	}
+	a := &aggregator{o: o, client: client, out: out, streaming: map[string]bool{}, since: map[string]time.Time{}}
+	wg := &sync.WaitGroup{}
+	defer wg.Wait()
+
+	for {
+		pods, err := o.Pods()
+		if err != nil {
+			if !o.Follow {
+				return err
+			}
+			log.WarnE(err, "Failed to list pods, retrying")
+		}
+		if !o.Follow && len(pods) == 0 {
+			return errors.New("No pod to get logs")
+		}
+		for _, pod := range pods {
+			for container, running := range a.containersOf(&pod) {
+				a.start(ctx, wg, pod.Name, container, running)
+			}
+		}
+		if !o.Follow {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(o.PollInterval):
+		}
+	}
+}
+
+// containersOf the containers to get logs by whether they are running, the
+// containers waiting have no logs
+func (a *aggregator) containersOf(pod *corev1.Pod) map[string]bool {
+	names := make([]string, 0)
+	switch {
+	case a.o.AllContainers:
+		for _, c := range pod.Spec.InitContainers {
+			names = append(names, c.Name)
+		}
+		for _, c := range pod.Spec.Containers {
+			names = append(names, c.Name)
+		}
+	case a.o.Container != "":
+		names = append(names, a.o.Container)
+	case len(pod.Spec.Containers) > 0:
+		names = append(names, pod.Spec.Containers[0].Name)
+	}
+
+	states := map[string]corev1.ContainerState{}
+	for _, s := range pod.Status.InitContainerStatuses {
+		states[s.Name] = s.State
+	}
+	for _, s := range pod.Status.ContainerStatuses {
+		states[s.Name] = s.State
+	}
+	containers := map[string]bool{}
+	for _, name := range names {
+		if state := states[name]; state.Running != nil || state.Terminated != nil {
+			containers[name] = state.Running != nil
+		}
+	}
+	return containers
+}
+
+func (a *aggregator) start(ctx context.Context, wg *sync.WaitGroup, pod, container string, running bool) {
+	key := pod + "/" + container
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	since, reconnecting := a.since[key]
+	// the logs of container terminated are all read
+	if a.streaming[key] || (reconnecting && !running) {
+		return
+	}
+	a.streaming[key] = true
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := a.stream(ctx, pod, container, since, reconnecting)
+		if err != nil && ctx.Err() == nil {
+			log.WarnE(err, fmt.Sprintf("Failed to get logs of %s", key))
+		}
+		a.lock.Lock()
+		delete(a.streaming, key)
+		a.since[key] = time.Now()
+		a.lock.Unlock()
+	}()
+}
+
+func (a *aggregator) stream(ctx context.Context, pod, container string, since time.Time, reconnecting bool) error {
+	opts := &corev1.PodLogOptions{Container: container, Follow: a.o.Follow, Timestamps: a.o.Timestamps}
+	if reconnecting {
+		// the lines printed before disconnecting are not printed again
+		opts.SinceTime = &metav1.Time{Time: since}
+	} else {
+		if a.o.Since > 0 {
+			seconds := int64(a.o.Since.Seconds())
+			opts.SinceSeconds = &seconds
+		}
+		if a.o.Tail >= 0 {
+			opts.TailLines = &a.o.Tail
+		}
+	}
+
+	reader, err := a.client.GetLogs(pod, opts).Stream(ctx)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	defer func() { _ = reader.Close() }()
+
+	prefix := a.prefix(pod, container)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if (a.o.Include != nil && !a.o.Include.MatchString(line)) ||
+			(a.o.Exclude != nil && a.o.Exclude.MatchString(line)) {
+			continue
+		}
+		a.lock.Lock()
+		_, err = fmt.Fprintf(a.out, "%s %s\n", prefix, line)
+		a.lock.Unlock()
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+	}
+	return errors.Wrap(scanner.Err(), "")
+}
+
+func (a *aggregator) prefix(pod, container string) string {
+	prefix := "[" + pod + "/" + container + "]"
+	if !a.o.Color {
+		return prefix
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(pod))
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", colors[h.Sum32()%uint32(len(colors))], prefix)
+}
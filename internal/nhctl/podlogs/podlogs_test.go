@@ -0,0 +1,106 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package podlogs
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPod(name string, running bool) corev1.Pod {
+	state := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{}}
+	if running {
+		state = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{{Name: "init", State: state}},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", State: state},
+				{Name: "sidecar", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{}}},
+			},
+		},
+	}
+}
+
+func stream(t *testing.T, o *Options, pods ...corev1.Pod) []string {
+	o.Pods = func() ([]corev1.Pod, error) { return pods, nil }
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out := &bytes.Buffer{}
+	if err := Stream(ctx, fake.NewSimpleClientset().CoreV1().Pods("nocalhost"), o, out); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+func TestStream(t *testing.T) {
+	lines := stream(t, &Options{Tail: -1}, newPod("p1", true), newPod("p2", true))
+	if len(lines) != 2 || !contains(lines, "[p1/app] fake logs") || !contains(lines, "[p2/app] fake logs") {
+		t.Errorf("unexpected logs %v", lines)
+	}
+
+	// the containers waiting have no logs
+	lines = stream(t, &Options{AllContainers: true, Tail: -1}, newPod("p1", true))
+	if len(lines) != 2 || !contains(lines, "[p1/init] fake logs") || contains(lines, "[p1/sidecar] fake logs") {
+		t.Errorf("unexpected logs of all containers %v", lines)
+	}
+
+	if lines = stream(t, &Options{Include: regexp.MustCompile("^error")}, newPod("p1", true)); lines[0] != "" {
+		t.Errorf("logs should be included by the regex only, got %v", lines)
+	}
+	if lines = stream(t, &Options{Exclude: regexp.MustCompile("fake")}, newPod("p1", true)); lines[0] != "" {
+		t.Errorf("logs should be excluded by the regex, got %v", lines)
+	}
+}
+
+func TestStreamFollow(t *testing.T) {
+	lines := stream(
+		t, &Options{Follow: true, PollInterval: 10 * time.Millisecond}, newPod("running", true),
+		newPod("terminated", false),
+	)
+	running, terminated := 0, 0
+	for _, line := range lines {
+		switch line {
+		case "[running/app] fake logs":
+			running++
+		case "[terminated/app] fake logs":
+			terminated++
+		}
+	}
+	if running < 2 || terminated != 1 {
+		t.Errorf("running should be reconnected and terminated should not, got %v", lines)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	a := &aggregator{o: &Options{Color: true}}
+	if prefix := a.prefix("p1", "app"); prefix != a.prefix("p1", "sidecar")[:5]+"[p1/app]\x1b[0m" {
+		t.Errorf("the containers of the pod should have the same color, got %q", prefix)
+	}
+}
+
+func contains(lines []string, line string) bool {
+	for _, l := range lines {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,262 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+const (
+	Default       = ""
+	Wide          = "wide"
+	JSON          = "json"
+	YAML          = "yaml"
+	CustomColumns = "custom-columns"
+	GoTemplate    = "go-template"
+	JSONPath      = "jsonpath"
+)
+
+// Formats the formats of -o, the ones with '=' take an expression and the
+// ones with '-file=' read it from a file, such as custom-columns-file=<path>
+var Formats = []string{
+	JSON, YAML, Wide, CustomColumns + "=", CustomColumns + "-file=", GoTemplate + "=", GoTemplate + "-file=",
+	JSONPath + "=", JSONPath + "-file=",
+}
+
+// Column of table, the path is a jsonpath expression evaluated on each row
+// such as .metadata.name or {.kind}/{.metadata.name}
+type Column struct {
+	Header string
+	Path   string
+}
+
+// Table the rows and columns to print as table, the wide columns are
+// appended to Columns by -o wide
+type Table struct {
+	Rows    []interface{}
+	Columns []Column
+	Wide    []Column
+}
+
+type Printer struct {
+	format   string
+	columns  []Column
+	template *template.Template
+	jsonPath *jsonpath.JSONPath
+}
+
+// New the printer of the output format, see Formats
+func New(output string) (*Printer, error) {
+	format, expr := output, ""
+	if i := strings.Index(output, "="); i >= 0 {
+		format, expr = output[:i], output[i+1:]
+	}
+	if strings.HasSuffix(format, "-file") {
+		format = strings.TrimSuffix(format, "-file")
+		bys, err := ioutil.ReadFile(expr)
+		if err != nil {
+			return nil, errors.Wrap(err, "")
+		}
+		expr = string(bys)
+		if format == CustomColumns {
+			return newCustomColumnsFromFile(expr)
+		}
+	}
+
+	p := &Printer{format: format}
+	switch format {
+	case Default, Wide, JSON, YAML:
+		if expr != "" {
+			return nil, errors.New(fmt.Sprintf("Format %s takes no expression", format))
+		}
+	case CustomColumns:
+		columns, err := parseCustomColumns(expr)
+		if err != nil {
+			return nil, err
+		}
+		p.columns = columns
+	case GoTemplate:
+		t, err := template.New("output").Parse(expr)
+		if err != nil {
+			return nil, errors.Wrap(err, "Invalid go-template")
+		}
+		p.template = t
+	case JSONPath:
+		j := jsonpath.New("output").AllowMissingKeys(true)
+		if err := j.Parse(relaxedPath(expr)); err != nil {
+			return nil, errors.Wrap(err, "Invalid jsonpath")
+		}
+		p.jsonPath = j
+	default:
+		return nil, errors.New(
+			fmt.Sprintf("Unsupported output format %s, allowed: %s", output, strings.Join(Formats, ", ")),
+		)
+	}
+	return p, nil
+}
+
+// IsTable the table is printed by the default format, wide or custom-columns
+func (p *Printer) IsTable() bool {
+	return p.format == Default || p.format == Wide || p.format == CustomColumns
+}
+
+// Print prints data by json, yaml, go-template and jsonpath, and table by
+// the others
+func (p *Printer) Print(w io.Writer, data interface{}, table *Table) error {
+	switch p.format {
+	case JSON:
+		bys, err := json.Marshal(data)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+		_, err = w.Write(bys)
+		return errors.Wrap(err, "")
+	case YAML:
+		bys, err := yaml.Marshal(data)
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+		_, err = w.Write(bys)
+		return errors.Wrap(err, "")
+	case GoTemplate:
+		generic, err := toGeneric(data)
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(p.template.Execute(w, generic), "Failed to execute go-template")
+	case JSONPath:
+		generic, err := toGeneric(data)
+		if err != nil {
+			return err
+		}
+		return errors.Wrap(p.jsonPath.Execute(w, generic), "Failed to execute jsonpath")
+	}
+
+	columns := table.Columns
+	switch p.format {
+	case Wide:
+		columns = append(append([]Column{}, table.Columns...), table.Wide...)
+	case CustomColumns:
+		columns = p.columns
+	}
+	return printTable(w, table.Rows, columns)
+}
+
+func printTable(w io.Writer, rows []interface{}, columns []Column) error {
+	headers := make([]string, 0, len(columns))
+	paths := make([]*jsonpath.JSONPath, 0, len(columns))
+	for _, c := range columns {
+		j := jsonpath.New(c.Header).AllowMissingKeys(true)
+		if err := j.Parse(relaxedPath(c.Path)); err != nil {
+			return errors.Wrap(err, fmt.Sprintf("Invalid path %s of column %s", c.Path, c.Header))
+		}
+		headers = append(headers, c.Header)
+		paths = append(paths, j)
+	}
+
+	cells := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		generic, err := toGeneric(row)
+		if err != nil {
+			return err
+		}
+		line := make([]string, 0, len(paths))
+		for _, j := range paths {
+			buf := &bytes.Buffer{}
+			if err = j.Execute(buf, generic); err != nil {
+				return errors.Wrap(err, "")
+			}
+			line = append(line, buf.String())
+		}
+		cells = append(cells, line)
+	}
+
+	writer := tablewriter.NewWriter(w)
+	writer.SetBorder(false)
+	writer.SetColumnSeparator("")
+	writer.SetRowSeparator("")
+	writer.SetCenterSeparator("")
+	writer.SetHeaderLine(false)
+	writer.SetHeader(headers)
+	writer.AppendBulk(cells)
+	writer.Render()
+	return nil
+}
+
+// parseCustomColumns such as NAME:.metadata.name,KIND:.kind
+func parseCustomColumns(spec string) ([]Column, error) {
+	columns := make([]Column, 0)
+	for _, part := range strings.Split(spec, ",") {
+		kv := strings.SplitN(part, ":", 2)
+		if len(kv) != 2 || kv[0] == "" || kv[1] == "" {
+			return nil, errors.New(fmt.Sprintf("Invalid custom column %s, expect <header>:<path>", part))
+		}
+		columns = append(columns, Column{Header: kv[0], Path: kv[1]})
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("custom-columns requires at least one column")
+	}
+	return columns, nil
+}
+
+// newCustomColumnsFromFile the headers are in the first line and the paths
+// are in the second as kubectl does
+func newCustomColumnsFromFile(content string) (*Printer, error) {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	if len(lines) != 2 {
+		return nil, errors.New("custom-columns-file expects the line of headers and the line of paths")
+	}
+	headers, paths := strings.Fields(lines[0]), strings.Fields(lines[1])
+	if len(headers) == 0 || len(headers) != len(paths) {
+		return nil, errors.New(
+			fmt.Sprintf("custom-columns-file has %d headers but %d paths", len(headers), len(paths)),
+		)
+	}
+	columns := make([]Column, 0, len(headers))
+	for i := range headers {
+		columns = append(columns, Column{Header: headers[i], Path: paths[i]})
+	}
+	return &Printer{format: CustomColumns, columns: columns}, nil
+}
+
+// relaxedPath .metadata.name and metadata.name are taken as {.metadata.name}
+func relaxedPath(path string) string {
+	path = strings.TrimSpace(path)
+	if strings.Contains(path, "{") {
+		return path
+	}
+	if !strings.HasPrefix(path, ".") && !strings.HasPrefix(path, "[") {
+		path = "." + path
+	}
+	return "{" + path + "}"
+}
+
+// toGeneric data in maps and slices as decoded from json, the numbers are
+// kept as they are instead of the float64
+func toGeneric(data interface{}) (interface{}, error) {
+	bys, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(bys))
+	decoder.UseNumber()
+	if err = decoder.Decode(&generic); err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return generic, nil
+}
@@ -0,0 +1,97 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package printer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var (
+	apps = []interface{}{
+		map[string]interface{}{"namespace": "nh", "application": []interface{}{
+			map[string]interface{}{"name": "bookinfo", "revision": 1634567890},
+			map[string]interface{}{"name": "coding", "revision": 2},
+		}},
+	}
+	table = &Table{
+		Rows: []interface{}{
+			map[string]interface{}{"name": "bookinfo", "revision": 1634567890, "type": "helmGit"},
+			map[string]interface{}{"name": "coding", "revision": 2},
+		},
+		Columns: []Column{{Header: "name", Path: ".name"}, {Header: "revision", Path: "revision"}},
+		Wide:    []Column{{Header: "type", Path: "{.type}"}},
+	}
+)
+
+func printOutput(t *testing.T, output string) string {
+	p, err := New(output)
+	if err != nil {
+		t.Fatalf("%s: %v", output, err)
+	}
+	buf := &bytes.Buffer{}
+	if err = p.Print(buf, apps, table); err != nil {
+		t.Fatalf("%s: %v", output, err)
+	}
+	return buf.String()
+}
+
+func fields(s string) []string {
+	return strings.Fields(strings.Join(strings.Split(s, "\n"), " "))
+}
+
+func TestPrintTable(t *testing.T) {
+	cases := map[string]string{
+		"":                                    "NAME REVISION bookinfo 1634567890 coding 2",
+		"wide":                                "NAME REVISION TYPE bookinfo 1634567890 helmGit coding 2",
+		"custom-columns=T:.type,N:.name":      "T N helmGit bookinfo coding",
+		"custom-columns=NAME:{.name}-{.type}": "NAME bookinfo-helmGit coding-",
+	}
+	for output, expect := range cases {
+		if actual := strings.Join(fields(printOutput(t, output)), " "); actual != expect {
+			t.Errorf("%q: got %q, expect %q", output, actual, expect)
+		}
+	}
+}
+
+func TestPrintData(t *testing.T) {
+	cases := map[string]string{
+		"json":                                 `[{"application":[{"name":"bookinfo","revision":1634567890},`,
+		"jsonpath={[*].application[*].name}":   "bookinfo coding",
+		"jsonpath=[0].application[0].revision": "1634567890",
+		"go-template={{range .}}{{range .application}}{{.name}},{{end}}{{end}}": "bookinfo,coding,",
+	}
+	for output, expect := range cases {
+		if actual := printOutput(t, output); !strings.HasPrefix(actual, expect) {
+			t.Errorf("%q: got %q, expect %q", output, actual, expect)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, output := range []string{"xml", "json=.name", "custom-columns=", "custom-columns=name", "go-template={{"} {
+		if _, err := New(output); err == nil {
+			t.Errorf("%q should be invalid", output)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "nhctl-printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	file := filepath.Join(dir, "columns")
+	if err = ioutil.WriteFile(file, []byte("NAME  TYPE\n.name .type\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if actual := fields(printOutput(t, "custom-columns-file="+file)); len(actual) != 5 || actual[3] != "helmGit" {
+		t.Errorf("unexpected output of custom-columns-file %v", actual)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package profile
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultDevHome the home of dev container ~ stands for, the dev images run as root
+const DefaultDevHome = "/root"
+
+// Caches the dirs of the caches of tools by name, the caches of dev config
+// are either the names or the dirs themselves, such as node_modules
+var Caches = map[string][]string{
+	"maven":  {"~/.m2"},
+	"gradle": {"~/.gradle"},
+	"go":     {"/go/pkg/mod", "~/.cache/go-build"},
+	"npm":    {"~/.npm"},
+	"yarn":   {"/usr/local/share/.cache/yarn"},
+	"pip":    {"~/.cache/pip"},
+	"cargo":  {"~/.cargo/registry"},
+}
+
+// GetPersistentVolumeDirs the persistent volume dirs and the dirs of caches,
+// which are mounted by the pvc of the service reused across dev sessions. The
+// relative dirs are resolved against workDir
+func (c *ContainerDevConfig) GetPersistentVolumeDirs(workDir string) []*PersistentVolumeDir {
+	if c == nil {
+		return nil
+	}
+	dirs := make([]*PersistentVolumeDir, 0, len(c.PersistentVolumeDirs)+len(c.Caches))
+	seen := make(map[string]bool)
+	for _, dir := range c.PersistentVolumeDirs {
+		if dir == nil {
+			continue
+		}
+		resolved := *dir
+		resolved.Path = resolveDevPath(dir.Path, workDir)
+		seen[resolved.Path] = true
+		dirs = append(dirs, &resolved)
+	}
+	for _, cache := range c.Caches {
+		paths, ok := Caches[cache]
+		if !ok {
+			paths = []string{cache}
+		}
+		for _, p := range paths {
+			if p = resolveDevPath(p, workDir); p != "" && !seen[p] {
+				seen[p] = true
+				dirs = append(dirs, &PersistentVolumeDir{Path: p})
+			}
+		}
+	}
+	return dirs
+}
+
+// resolveDevPath the absolute path in dev container, ~ is DefaultDevHome. The
+// absolute path is kept as is, as the pvc of it is found by its hash
+func resolveDevPath(p, workDir string) string {
+	switch {
+	case p == "" || path.IsAbs(p):
+		return p
+	case p == "~" || strings.HasPrefix(p, "~/"):
+		return path.Join(DefaultDevHome, strings.TrimPrefix(p, "~"))
+	}
+	return path.Join(workDir, p)
+}
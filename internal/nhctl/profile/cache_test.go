@@ -0,0 +1,40 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package profile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPersistentVolumeDirs(t *testing.T) {
+	devConfig := &ContainerDevConfig{
+		PersistentVolumeDirs: []*PersistentVolumeDir{
+			{Path: "/root/.m2", Capacity: "20Gi"},
+			{Path: "~/.cache"},
+			{Path: "/data/"},
+		},
+		Caches: []string{"maven", "go", "node_modules", "/opt/cache"},
+	}
+	dirs := devConfig.GetPersistentVolumeDirs("/home/nocalhost-dev")
+	paths := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		paths = append(paths, dir.Path)
+	}
+	expect := []string{
+		"/root/.m2", "/root/.cache", "/data/", "/go/pkg/mod", "/root/.cache/go-build",
+		"/home/nocalhost-dev/node_modules", "/opt/cache",
+	}
+	if !reflect.DeepEqual(paths, expect) {
+		t.Errorf("got %v, expect %v", paths, expect)
+	}
+	if dirs[0].Capacity != "20Gi" || devConfig.PersistentVolumeDirs[1].Path != "~/.cache" {
+		t.Error("the persistent volume dirs should be kept and not modified")
+	}
+	if (*ContainerDevConfig)(nil).GetPersistentVolumeDirs("/") != nil {
+		t.Error("nil dev config should have no dirs")
+	}
+}
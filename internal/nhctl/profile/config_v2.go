@@ -34,6 +34,12 @@ type ApplicationConfig struct {
 	ResourcePath RelPath `json:"resourcePath" yaml:"resourcePath"`
 	IgnoredPath  RelPath `json:"ignoredPath" yaml:"ignoredPath"`
 
+	// KustomizeOverlay the overlay of kustomize to build, relative to the
+	// resources dir, e.g. overlays/dev
+	KustomizeOverlay string `json:"kustomizeOverlay" yaml:"kustomizeOverlay,omitempty"`
+	// KustomizeImages override the images of kustomization, e.g. nginx=registry.io/nginx:1.21
+	KustomizeImages []string `json:"kustomizeImages" yaml:"kustomizeImages,omitempty"`
+
 	PreInstall  SortedRelPath `json:"onPreInstall" yaml:"onPreInstall"`
 	PostInstall SortedRelPath `json:"onPostInstall" yaml:"onPostInstall"`
 	PreUpgrade  SortedRelPath `json:"onPreUpgrade" yaml:"onPreUpgrade"`
@@ -47,6 +53,20 @@ type ApplicationConfig struct {
 	Env            []*Env             `json:"env" yaml:"env"`
 	EnvFrom        EnvFrom            `json:"envFrom,omitempty" yaml:"envFrom,omitempty"`
 	ServiceConfigs []*ServiceConfigV2 `json:"services" yaml:"services,omitempty"`
+
+	// Dependencies the workloads or jobs must be ready before installing
+	Dependencies []*Dependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// Dependency the workload or job waited to be ready, the one of other
+// namespace is external to the application
+type Dependency struct {
+	Name string `json:"name" yaml:"name"`
+	// Type deployment, statefulset, daemonset, job or pod
+	Type      string `validate:"oneof=deployment statefulset daemonset job pod" json:"type" yaml:"type"`
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Timeout seconds waiting for the dependency, 600 if not set
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 type HubConfig struct {
@@ -79,6 +99,25 @@ type ContainerDevConfig struct {
 	PortForward           []string               `validate:"dive,PortForward" json:"portForward" yaml:"portForward"`
 	SidecarImage          string                 `json:"sidecarImage,omitempty" yaml:"sidecarImage,omitempty"`
 	Patches               []base.PatchItem       `json:"patches,omitempty" yaml:"patches,omitempty"`
+	Triggers              []*SyncTrigger         `json:"triggers,omitempty" yaml:"triggers,omitempty"`
+	// Caches the dirs persisted like persistentVolumeDirs by the names of tools,
+	// such as maven or go, or by the dirs, such as node_modules, see Caches
+	Caches []string `json:"caches,omitempty" yaml:"caches,omitempty"`
+	// Sidecars keep or strip the sidecars injected by mesh, such as istio-proxy,
+	// the dev pod stripped leaves the mesh. Default: keep
+	Sidecars string `validate:"SidecarPolicy" json:"sidecars,omitempty" yaml:"sidecars,omitempty"`
+}
+
+// SyncTrigger the command run in the dev container after the files matched
+// are synced, such as rebuilding and reloading the app
+type SyncTrigger struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// the patterns of the files relative to the sync dir, such as **/*.go,
+	// the ones without slash match the file name in any directory
+	Patterns []string `json:"patterns" yaml:"patterns"`
+	Command  []string `json:"command" yaml:"command"`
+	// the milliseconds to wait for more changes before running, 500 by default
+	Debounce int `json:"debounce,omitempty" yaml:"debounce,omitempty"`
 }
 
 type DevCommands struct {
@@ -87,14 +126,27 @@ type DevCommands struct {
 	Debug          []string `json:"debug" yaml:"debug"`
 	HotReloadRun   []string `json:"hotReloadRun,omitempty" yaml:"hotReloadRun,omitempty"`
 	HotReloadDebug []string `json:"hotReloadDebug,omitempty" yaml:"hotReloadDebug,omitempty"`
+	// PostCreate run in the dev container once by dev start, after the files are synced
+	PostCreate []string `json:"postCreate,omitempty" yaml:"postCreate,omitempty"`
 }
 
 type SyncConfig struct {
 	Type              string   `validate:"SyncType" json:"type" yaml:"type"`
 	Mode              string   `validate:"SyncMode" json:"mode,omitempty" yaml:"mode,omitempty"`
+	Engine            string   `validate:"SyncEngine" json:"engine,omitempty" yaml:"engine,omitempty"`
+	ConflictStrategy  string   `validate:"ConflictStrategy" json:"conflictStrategy,omitempty" yaml:"conflictStrategy,omitempty"`
 	DeleteProtection  *bool    `json:"deleteProtection,omitempty" yaml:"deleteProtection,omitempty"`
 	FilePattern       []string `json:"filePattern" yaml:"filePattern"`
 	IgnoreFilePattern []string `json:"ignoreFilePattern" yaml:"ignoreFilePattern"`
+	// honor the .gitignore and .nhignore files in the sync dir, default is true
+	HonorIgnoreFiles *bool `json:"honorIgnoreFiles,omitempty" yaml:"honorIgnoreFiles,omitempty"`
+	// the bandwidth of sync in KB/s, 0 means no limit
+	MaxUploadKBps   int `json:"maxUploadKBps,omitempty" yaml:"maxUploadKBps,omitempty"`
+	MaxDownloadKBps int `json:"maxDownloadKBps,omitempty" yaml:"maxDownloadKBps,omitempty"`
+	// the files larger than the threshold, such as 100Mi, are skipped or
+	// warned by the policy, skip by default
+	LargeFileThreshold string `validate:"Quantity" json:"largeFileThreshold,omitempty" yaml:"largeFileThreshold,omitempty"`
+	LargeFilePolicy    string `validate:"LargeFilePolicy" json:"largeFilePolicy,omitempty" yaml:"largeFilePolicy,omitempty"`
 }
 
 type DebugConfig struct {
@@ -72,6 +72,29 @@ func TestParseRandom(t *testing.T) {
 	}
 }
 
+func TestSplitPortProtocol(t *testing.T) {
+	for portStr, expect := range map[string][2]string{
+		"8080":        {"8080", "tcp"},
+		"5353:53/udp": {"5353:53", "udp"},
+		":53/UDP":     {":53", "udp"},
+		"8080:80/tcp": {"8080:80", "tcp"},
+	} {
+		port, protocol, err := utils.SplitPortProtocol(portStr)
+		if err != nil {
+			t.Error(err)
+		}
+		if port != expect[0] || protocol != expect[1] {
+			t.Errorf("%s: got %s %s", portStr, port, protocol)
+		}
+	}
+
+	for _, portStr := range []string{"9090/sctp", "53/icmp"} {
+		if _, _, err := utils.SplitPortProtocol(portStr); err == nil {
+			t.Errorf("%s should not be supported", portStr)
+		}
+	}
+}
+
 func TestMacAddress(t *testing.T) {
 	s := getMacAddress().String()
 	all := strings.ReplaceAll(s, ":", "")
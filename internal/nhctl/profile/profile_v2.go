@@ -13,6 +13,7 @@ import (
 	"gopkg.in/yaml.v3"
 	"io/ioutil"
 	"net"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/dbutils"
 	"nocalhost/internal/nhctl/nocalhost_path"
 	"os"
@@ -264,6 +265,13 @@ type SvcProfileV2 struct {
 	LocalAbsoluteSyncDirFromDevStartPlugin []string          `json:"localAbsoluteSyncDirFromDevStartPlugin" yaml:"localAbsoluteSyncDirFromDevStartPlugin"`
 	DevPortForwardList                     []*DevPortForward `json:"devPortForwardList" yaml:"devPortForwardList"` // combine DevPortList,PortForwardStatusList and PortForwardPidList
 
+	// the engine of the file sync started, empty is syncthing
+	SyncEngine string `json:"syncEngine,omitempty" yaml:"syncEngine,omitempty"`
+	// local port of the ssh endpoint mutagen syncs by
+	MutagenSshPort int `json:"mutagenSshPort,omitempty" yaml:"mutagenSshPort,omitempty"`
+	// the file sync is paused by `nhctl sync pause`, kept across reconnects
+	SyncPaused bool `json:"syncPaused,omitempty" yaml:"syncPaused,omitempty"`
+
 	// nocalhost supports config from local dir under "Associate" Path, it's priority is highest
 	LocalConfigLoaded bool `json:"localconfigloaded" yaml:"localconfigloaded"`
 
@@ -273,6 +281,9 @@ type SvcProfileV2 struct {
 	// nocalhost also supports config from cm, lowest priority
 	CmConfigLoaded bool `json:"cmconfigloaded" yaml:"cmconfigloaded"`
 
+	// nocalhost derives the dev config from devcontainer.json under "Associate" Path if no config found above
+	DevContainerConfigLoaded bool `json:"devcontainerconfigloaded" yaml:"devcontainerconfigloaded"`
+
 	// deprecated, read only, but actually store in
 	// [SvcPack internal/nhctl/nocalhost/dev_dir_mapping_db.go:165]
 	// associate for the local dir
@@ -317,6 +328,20 @@ type DevPortForward struct {
 	Sudo            bool              `json:"sudo" yaml:"sudo"`
 	DaemonServerPid int               `json:"daemonserverpid" yaml:"daemonserverpid"`
 	ServiceType     string            `json:"servicetype" yaml:"servicetype"`
+	// Reverse the remote port in the pod is forwarded to the local one
+	Reverse bool `json:"reverse,omitempty" yaml:"reverse,omitempty"`
+	// Protocol tcp or udp, the udp datagrams are relayed by the sidecar
+	Protocol string `json:"protocol,omitempty" yaml:"protocol,omitempty"`
+	// Reconnects the times of reconnecting since the port-forward started
+	Reconnects int `json:"reconnects,omitempty" yaml:"reconnects,omitempty"`
+}
+
+// GetProtocol tcp if not specified, as the ones recorded before udp supported
+func (d *DevPortForward) GetProtocol() string {
+	if d.Protocol == "" {
+		return _const.TCPProtocol
+	}
+	return d.Protocol
 }
 
 func (s *SvcProfileV2) GetName() string {
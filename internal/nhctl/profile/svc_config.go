@@ -11,6 +11,9 @@ type ServiceConfigV2 struct {
 	PriorityClass       string               `json:"priorityClass,omitempty" yaml:"priorityClass,omitempty"`
 	DependLabelSelector *DependLabelSelector `json:"dependLabelSelector,omitempty" yaml:"dependLabelSelector,omitempty"`
 	ContainerConfigs    []*ContainerConfig   `validate:"dive" json:"containers" yaml:"containers"`
+	// Dependencies the workloads or jobs must be ready before the workload
+	// of service is applied
+	Dependencies []*Dependency `validate:"dive" json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
 }
 
 type ContainerConfig struct {
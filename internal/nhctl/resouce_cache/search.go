@@ -44,6 +44,13 @@ var searchMap, _ = simplelru.NewLRU(20, func(_ interface{}, value interface{}) {
 })
 var searchMapLock = &sync.Mutex{}
 
+// SearcherCount the searchers cached for the kubeconfigs
+func SearcherCount() int {
+	searchMapLock.Lock()
+	defer searchMapLock.Unlock()
+	return searchMap.Len()
+}
+
 var isClusterAdminClusterMap = sync.Map{}
 
 // key: generateKey(kubeconfigBytes, namespace) value: []*restmapper.APIGroupResources
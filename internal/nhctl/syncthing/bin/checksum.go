@@ -0,0 +1,16 @@
+package bin
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// Sha256 the checksum of the syncthing embedded, for checking the one
+// installed is not broken
+func Sha256() (string, error) {
+	file, err := f.ReadFile(binName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(file)), nil
+}
@@ -0,0 +1,121 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package syncthing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	_const "nocalhost/internal/nhctl/const"
+)
+
+const (
+	ConflictSideLocal  = "local"
+	ConflictSideRemote = "remote"
+)
+
+// the copy of the version lost is named as
+// <name>.sync-conflict-<date>-<time>-<short id of device modified it><ext>
+var conflictCopyPattern = regexp.MustCompile(`^(.*)\.sync-conflict-(\d{8}-\d{6})-([A-Z0-9]{7})(\.[^.]*)?$`)
+
+// Conflict the file modified by both the sides, the version lost is kept
+// in the conflict copy by syncthing
+type Conflict struct {
+	Path         string `json:"path"`
+	ConflictPath string `json:"conflictPath"`
+	// ModifiedBy the side of the version in the conflict copy
+	ModifiedBy string `json:"modifiedBy"`
+	Time       string `json:"time"`
+}
+
+// ParseConflictCopy the conflict of the copy, nil if it is not a conflict copy
+func ParseConflictCopy(path string) *Conflict {
+	dir, name := filepath.Split(path)
+	match := conflictCopyPattern.FindStringSubmatch(name)
+	if match == nil {
+		return nil
+	}
+	side := ""
+	switch match[3] {
+	case shortDeviceID(localDeviceID):
+		side = ConflictSideLocal
+	case shortDeviceID(DefaultRemoteDeviceID):
+		side = ConflictSideRemote
+	default:
+		return nil
+	}
+	return &Conflict{
+		Path:         filepath.Join(dir, match[1]+match[4]),
+		ConflictPath: path,
+		ModifiedBy:   side,
+		Time:         match[2],
+	}
+}
+
+func shortDeviceID(id string) string {
+	return strings.SplitN(id, "-", 2)[0]
+}
+
+// FindConflicts the conflicts in the local dir, the paths are relative to it
+func FindConflicts(dir string) ([]*Conflict, error) {
+	conflicts := make([]*Conflict, 0)
+	err := filepath.Walk(
+		dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if info.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.Contains(info.Name(), ".sync-conflict-") {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			if c := ParseConflictCopy(rel); c != nil {
+				conflicts = append(conflicts, c)
+			}
+			return nil
+		},
+	)
+	return conflicts, errors.WithStack(err)
+}
+
+// ResolveConflict resolve the conflict in the local dir by the strategy, the
+// changes are synced to the remote as the other changes
+func ResolveConflict(dir string, c *Conflict, strategy string) error {
+	copyPath := filepath.Join(dir, c.ConflictPath)
+	switch strategy {
+	case _const.PreferLocalConflictStrategy, _const.PreferRemoteConflictStrategy:
+		preferred := ConflictSideLocal
+		if strategy == _const.PreferRemoteConflictStrategy {
+			preferred = ConflictSideRemote
+		}
+		if c.ModifiedBy == preferred {
+			return errors.WithStack(os.Rename(copyPath, filepath.Join(dir, c.Path)))
+		}
+		return errors.WithStack(os.Remove(copyPath))
+	case _const.KeepBothConflictStrategy:
+		return errors.WithStack(os.Rename(copyPath, filepath.Join(dir, keepBothPath(c))))
+	}
+	return errors.New(fmt.Sprintf("Unsupported conflict strategy %s", strategy))
+}
+
+// keepBothPath the copy is renamed with the suffix of the side, so that it is
+// not found as a conflict any more
+func keepBothPath(c *Conflict) string {
+	ext := filepath.Ext(c.Path)
+	return fmt.Sprintf("%s.%s-%s%s", strings.TrimSuffix(c.Path, ext), c.ModifiedBy, c.Time, ext)
+}
@@ -0,0 +1,78 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package syncthing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_const "nocalhost/internal/nhctl/const"
+)
+
+func TestParseConflictCopy(t *testing.T) {
+	c := ParseConflictCopy(filepath.Join("pkg", "main.sync-conflict-20211014-101010-MDPJNTF.go"))
+	if c == nil || c.Path != filepath.Join("pkg", "main.go") || c.ModifiedBy != ConflictSideRemote ||
+		c.Time != "20211014-101010" {
+		t.Fatalf("unexpected conflict %+v", c)
+	}
+	if c = ParseConflictCopy("Makefile.sync-conflict-20211014-101010-SJTYMUE"); c == nil ||
+		c.Path != "Makefile" || c.ModifiedBy != ConflictSideLocal {
+		t.Fatalf("unexpected conflict %+v", c)
+	}
+	if c = ParseConflictCopy("main.sync-conflict-20211014-101010-ABCDEFG.go"); c != nil {
+		t.Fatalf("the copy of unknown device should be ignored")
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conflict")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "remote")
+	write("a.sync-conflict-20211014-101010-SJTYMUE.go", "local")
+	write("b.go", "local")
+	write("b.sync-conflict-20211014-101010-MDPJNTF.go", "remote")
+	write("c.go", "remote")
+	write("c.sync-conflict-20211014-101010-SJTYMUE.go", "local")
+
+	conflicts, err := FindConflicts(dir)
+	if err != nil || len(conflicts) != 3 {
+		t.Fatalf("expected 3 conflicts, got %d, %v", len(conflicts), err)
+	}
+	strategies := map[string]string{
+		"a.go": _const.PreferLocalConflictStrategy,
+		"b.go": _const.PreferLocalConflictStrategy,
+		"c.go": _const.KeepBothConflictStrategy,
+	}
+	for _, c := range conflicts {
+		if err = ResolveConflict(dir, c, strategies[c.Path]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		"a.go": "local", "b.go": "local", "c.go": "remote", "c.local-20211014-101010.go": "local",
+	}
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(files))
+	}
+	for name, content := range expected {
+		if bys, err := ioutil.ReadFile(filepath.Join(dir, name)); err != nil || string(bys) != content {
+			t.Errorf("expected %s of %s, got %s", content, name, string(bys))
+		}
+	}
+}
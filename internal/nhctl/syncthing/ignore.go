@@ -0,0 +1,130 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package syncthing
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// the ignore files honored in the sync folder, in the order of loading, the
+// latter has the priority in the same directory
+var ignoreFileNames = []string{".gitignore", IgnoredFIle}
+
+// LoadIgnoreFiles the patterns of syncthing translated from the ignore files
+// in the root and its sub directories. The first pattern matched wins in
+// syncthing but the last one wins in gitignore, so the patterns of each file
+// are reversed, and the ones of the deeper directories come first
+func LoadIgnoreFiles(root string) ([]string, error) {
+	layers := make([][]string, 0)
+	// the directories ignored by name in the root are not walked into, such
+	// as node_modules
+	ignoredNames := map[string]bool{".git": true}
+
+	err := filepath.Walk(
+		root, func(p string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
+				return nil
+			}
+			if p != root && ignoredNames[info.Name()] {
+				return filepath.SkipDir
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if rel == "." {
+				rel = ""
+			}
+			for _, name := range ignoreFileNames {
+				lines, err := readIgnoreFile(filepath.Join(p, name))
+				if err != nil {
+					continue
+				}
+				layers = append(layers, translateIgnorePatterns(rel, lines))
+				for _, line := range lines {
+					if rel == "" && isPlainName(line) {
+						ignoredNames[strings.TrimSuffix(line, "/")] = true
+					}
+				}
+			}
+			return nil
+		},
+	)
+
+	patterns := make([]string, 0)
+	for i := len(layers) - 1; i >= 0; i-- {
+		patterns = append(patterns, layers[i]...)
+	}
+	return patterns, err
+}
+
+func readIgnoreFile(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// translateIgnorePatterns translate the patterns of gitignore in the dir to
+// the ones of syncthing relative to the root of folder, in reverse order
+func translateIgnorePatterns(dir string, lines []string) []string {
+	patterns := make([]string, 0, len(lines))
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		negate := ""
+		if strings.HasPrefix(line, "!") {
+			negate, line = "!", line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		// the dirs only patterns ignore the files of the name too in syncthing
+		line = strings.TrimSuffix(line, "/")
+		if line == "" {
+			continue
+		}
+
+		prefix := "/"
+		if dir != "" {
+			prefix = "/" + dir + "/"
+		}
+		if strings.Contains(line, "/") {
+			// anchored to the dir of the ignore file
+			patterns = append(patterns, negate+prefix+strings.TrimPrefix(line, "/"))
+			continue
+		}
+		if dir == "" {
+			// matched in any directory by syncthing
+			patterns = append(patterns, negate+line)
+			continue
+		}
+		patterns = append(patterns, negate+prefix+line, negate+prefix+path.Join("**", line))
+	}
+	return patterns
+}
+
+// isPlainName the pattern ignore the files or directories of the name in any
+// directory, without wildcards
+func isPlainName(line string) bool {
+	name := strings.TrimSuffix(line, "/")
+	return name != "" && !strings.ContainsAny(name, `/*?[]!\`)
+}
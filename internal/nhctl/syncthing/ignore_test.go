@@ -0,0 +1,65 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package syncthing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTranslateIgnorePatterns(t *testing.T) {
+	patterns := translateIgnorePatterns("web", []string{"node_modules/", "/dist", "*.log", "!keep.log", "src/*.tmp"})
+	expected := []string{
+		"/web/src/*.tmp",
+		"!/web/keep.log", "!/web/**/keep.log",
+		"/web/*.log", "/web/**/*.log",
+		"/web/dist",
+		"/web/node_modules", "/web/**/node_modules",
+	}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Fatalf("expected %v, got %v", expected, patterns)
+	}
+	if patterns = translateIgnorePatterns("", []string{"build", "/vendor/"}); !reflect.DeepEqual(
+		patterns, []string{"/vendor", "build"},
+	) {
+		t.Fatalf("unexpected patterns %v", patterns)
+	}
+}
+
+func TestLoadIgnoreFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	write := func(name, content string) {
+		file := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".gitignore", "# comment\nnode_modules\n*.log\n")
+	write(".nhignore", "!debug.log\n")
+	write("web/.gitignore", "/dist\n")
+	// not walked into as node_modules is ignored
+	write("node_modules/pkg/.gitignore", "*\n")
+
+	patterns, err := LoadIgnoreFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/web/dist", "!debug.log", "*.log", "node_modules"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Fatalf("expected %v, got %v", expected, patterns)
+	}
+}
@@ -0,0 +1,50 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package syncthing
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// FindLargeFiles the files larger than the threshold in the root, the paths
+// are relative to it in slash. The files are found when the sync started, the
+// ones created later are not found until the sync resumed
+func FindLargeFiles(root string, threshold int64) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(
+		root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if info.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() || info.Size() <= threshold {
+				return nil
+			}
+			if rel, err := filepath.Rel(root, path); err == nil {
+				files = append(files, filepath.ToSlash(rel))
+			}
+			return nil
+		},
+	)
+	return files, errors.WithStack(err)
+}
+
+// LargeFilePatterns the ignore patterns of syncthing anchored to the files
+func LargeFilePatterns(files []string) []string {
+	patterns := make([]string, 0, len(files))
+	for _, f := range files {
+		patterns = append(patterns, "/"+f)
+	}
+	return patterns
+}
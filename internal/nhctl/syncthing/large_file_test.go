@@ -0,0 +1,43 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package syncthing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindLargeFiles(t *testing.T) {
+	root, err := ioutil.TempDir("", "large")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for name, size := range map[string]int{"small": 10, "core/dump": 1024, ".git/pack": 2048, "limit": 100} {
+		file := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := FindLargeFiles(root, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"core/dump"}) {
+		t.Fatalf("unexpected large files %v", files)
+	}
+	if patterns := LargeFilePatterns(files); !reflect.DeepEqual(patterns, []string{"/core/dump"}) {
+		t.Fatalf("unexpected patterns %v", patterns)
+	}
+}
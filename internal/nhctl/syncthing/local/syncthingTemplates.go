@@ -23,10 +23,10 @@ fsWatcherDelayS="1" ignorePerms="false" autoNormalize="true">
 	<ignoreDelete>{{ $.IgnoreDelete }}</ignoreDelete>
 	<scanProgressIntervalS>2</scanProgressIntervalS>
 	<pullerPauseS>0</pullerPauseS>
-	<maxConflicts>0</maxConflicts>
+	<maxConflicts>{{ $.MaxConflicts }}</maxConflicts>
 	<disableSparseFiles>false</disableSparseFiles>
 	<disableTempIndexes>false</disableTempIndexes>
-	<paused>false</paused>
+	<paused>{{ $.Paused }}</paused>
 	<weakHashThresholdPct>25</weakHashThresholdPct>
 	<markerName>.</markerName>
 	<useLargeBlocks>false</useLargeBlocks>
@@ -47,8 +47,8 @@ introducer="false" skipIntroductionRemovals="false" introducedBy="">
 	<address>tcp://{{.RemoteAddress}}</address>
 	<paused>false</paused>
 	<autoAcceptFolders>false</autoAcceptFolders>
-	<maxSendKbps>0</maxSendKbps>
-	<maxRecvKbps>0</maxRecvKbps>
+	<maxSendKbps>{{.MaxSendKbps}}</maxSendKbps>
+	<maxRecvKbps>{{.MaxRecvKbps}}</maxRecvKbps>
 	<maxRequestKiB>0</maxRequestKiB>
 </device>
 <gui enabled="true" tls="false" debugging="false">
@@ -85,7 +85,7 @@ introducer="false" skipIntroductionRemovals="false" introducedBy="">
 	<keepTemporariesH>24</keepTemporariesH>
 	<cacheIgnoredFiles>false</cacheIgnoredFiles>
 	<progressUpdateIntervalS>2</progressUpdateIntervalS>
-	<limitBandwidthInLan>false</limitBandwidthInLan>
+	<limitBandwidthInLan>{{.LimitBandwidthInLan}}</limitBandwidthInLan>
 	<minHomeDiskFree unit="%">1</minHomeDiskFree>
 	<releasesURL></releasesURL>
 	<overwriteRemoteDeviceNamesOnConnect>false</overwriteRemoteDeviceNamesOnConnect>
@@ -116,7 +116,7 @@ fsWatcherDelayS="1" ignorePerms="false" autoNormalize="true">
 	<ignoreDelete>false</ignoreDelete>
 	<scanProgressIntervalS>2</scanProgressIntervalS>
 	<pullerPauseS>0</pullerPauseS>
-	<maxConflicts>0</maxConflicts>
+	<maxConflicts>{{ $.MaxConflicts }}</maxConflicts>
 	<disableSparseFiles>false</disableSparseFiles>
 	<disableTempIndexes>false</disableTempIndexes>
 	<paused>false</paused>
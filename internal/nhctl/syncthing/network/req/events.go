@@ -2,11 +2,29 @@ package req
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 )
 
 func (p *SyncthingHttpClient) Events(since int64) ([]event, error) {
-	resp, err := p.get("rest/events?since=" + strconv.FormatInt(since, 10))
+	return p.events("rest/events?since=" + strconv.FormatInt(since, 10))
+}
+
+// EventsOf the events of the types since the id, waiting for them at most
+// timeoutSecond, which should be less than the timeout of request
+func (p *SyncthingHttpClient) EventsOf(since int64, timeoutSecond int, types ...EventType) ([]event, error) {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, string(t))
+	}
+	return p.events(
+		fmt.Sprintf("rest/events?since=%d&timeout=%d&events=%s", since, timeoutSecond, strings.Join(names, ",")),
+	)
+}
+
+func (p *SyncthingHttpClient) events(path string) ([]event, error) {
+	resp, err := p.get(path)
 	if err != nil {
 		return nil, err
 	}
@@ -20,7 +38,8 @@ func (p *SyncthingHttpClient) Events(since int64) ([]event, error) {
 type EventType string
 
 const (
-	EventFolderCompletion EventType = "FolderCompletion"
+	EventFolderCompletion  EventType = "FolderCompletion"
+	EventLocalIndexUpdated EventType = "LocalIndexUpdated"
 )
 
 type event struct {
@@ -35,4 +54,6 @@ type data struct {
 	Completion float64 `json:"completion"`
 	Device     string  `json:"device"`
 	Folder     string  `json:"folder"`
+	// the files changed of LocalIndexUpdated
+	Filenames []string `json:"filenames"`
 }
@@ -0,0 +1,15 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package req
+
+import "fmt"
+
+// FolderPause pause or resume the folder, the connection to the remote device
+// is kept, so that the syncthing is not reconnected by daemon while paused
+func (p *SyncthingHttpClient) FolderPause(paused bool) error {
+	_, err := p.patch("rest/config/folders/"+p.folderName, fmt.Sprintf(`{"paused":%t}`, paused))
+	return err
+}
@@ -128,6 +128,7 @@ const (
 	Error        StatusEnum = "error"
 	Idle         StatusEnum = "idle"
 	End          StatusEnum = "end"
+	Paused       StatusEnum = "paused"
 
 	Identifier = "(Nocalhost): "
 )
@@ -163,6 +164,12 @@ var NotProcessor = &SyncthingStatus{
 		"you should end the dev mode and re enter again.",
 }
 
+var SyncPausedTemplate = &SyncthingStatus{
+	Status: Paused,
+	Msg:    "Sync paused",
+	Tips:   Identifier + "File sync is paused, run `nhctl sync resume` to resume it.",
+}
+
 var NotSyncthingProcessFound = &SyncthingStatus{
 	Status: Disconnected,
 	Msg:    "No syncthing process found",
@@ -55,6 +55,14 @@ func (s *SyncthingHttpClient) Post(path, body string) ([]byte, error) {
 	return s.do(req, s.reqTimeoutSecond)
 }
 
+func (s *SyncthingHttpClient) patch(path, body string) ([]byte, error) {
+	req, err := http.NewRequest("PATCH", fmt.Sprintf("http://%s/%s", s.guiHost, path), bytes.NewBufferString(body))
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req, s.reqTimeoutSecond)
+}
+
 func (s *SyncthingHttpClient) do(req *http.Request, reqTimeoutSecond int) ([]byte, error) {
 	req.Header.Add("X-API-Key", s.apiKey)
 
@@ -107,6 +107,7 @@ type Syncthing struct {
 	LocalPort                int          `yaml:"-"`
 	Type                     string       `yaml:"-"`
 	IgnoreDelete             bool         `yaml:"-"`
+	MaxConflicts             int          `yaml:"-"`
 	PortForwardBackGroundPid int          `yaml:"-"`
 	SyncthingBackGroundPid   int          `yaml:"-"`
 	pid                      int          `yaml:"-"`
@@ -118,6 +119,27 @@ type Syncthing struct {
 
 	// resolve ignore/sync from gitignore
 	EnableParseFromGitIgnore bool `yaml:"-"`
+
+	// honor the .gitignore and .nhignore files in the folder
+	HonorIgnoreFiles bool `yaml:"-"`
+
+	// the bandwidth to the remote device in KB/s, 0 means no limit
+	MaxSendKbps int `yaml:"-"`
+	MaxRecvKbps int `yaml:"-"`
+
+	// the files larger than the threshold in bytes are ignored if
+	// SkipLargeFiles, or only warned, 0 means no threshold
+	LargeFileThreshold int64 `yaml:"-"`
+	SkipLargeFiles     bool  `yaml:"-"`
+
+	// the folders are paused by `nhctl sync pause`
+	Paused bool `yaml:"-"`
+}
+
+// LimitBandwidthInLan the remote device is connected by port-forward of
+// localhost, which is considered as LAN by syncthing
+func (s *Syncthing) LimitBandwidthInLan() bool {
+	return s.MaxSendKbps > 0 || s.MaxRecvKbps > 0
 }
 
 //IsSubPathFolder checks if a sync folder is a subpath of another sync folder
@@ -236,6 +258,33 @@ func (s *Syncthing) generateIgnoredFileConfig() (string, error) {
 		ignoredPatternAdaption[i] = afterAdapt
 	}
 
+	if s.HonorIgnoreFiles && len(s.Folders) > 0 {
+		layered, err := LoadIgnoreFiles(s.Folders[0].LocalPath)
+		if err != nil {
+			log.Warnf("Failed to load the ignore files of %s: %v", s.Folders[0].LocalPath, err)
+		}
+		// the patterns configured have the priority
+		ignoredPatternAdaption = append(ignoredPatternAdaption, layered...)
+	}
+
+	if s.LargeFileThreshold > 0 && len(s.Folders) > 0 {
+		largeFiles, err := FindLargeFiles(s.Folders[0].LocalPath, s.LargeFileThreshold)
+		if err != nil {
+			log.Warnf("Failed to find the large files of %s: %v", s.Folders[0].LocalPath, err)
+		}
+		for _, f := range largeFiles {
+			if s.SkipLargeFiles {
+				log.Warnf("%s is larger than %d bytes, skipped to sync", f, s.LargeFileThreshold)
+			} else {
+				log.Warnf("%s is larger than %d bytes, syncing it may take a long time", f, s.LargeFileThreshold)
+			}
+		}
+		// the large files are skipped even if negated by the ignore files
+		if s.SkipLargeFiles {
+			ignoredPatternAdaption = append(LargeFilePatterns(largeFiles), ignoredPatternAdaption...)
+		}
+	}
+
 	if len(syncedPatternAdaption) == 0 {
 		syncedPatternAdaption = []string{"!**"}
 	}
@@ -0,0 +1,31 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package syncthing
+
+import "testing"
+
+func TestMatchTrigger(t *testing.T) {
+	cases := []struct {
+		patterns []string
+		file     string
+		match    bool
+	}{
+		{[]string{"*.go"}, "cmd/main.go", true},
+		{[]string{"*.go"}, "README.md", false},
+		{[]string{"**/*.go"}, "main.go", true},
+		{[]string{"**/*.go"}, "pkg/a/b.go", true},
+		{[]string{"pkg/**"}, "pkg/a/b.go", true},
+		{[]string{"/pkg/*.go"}, "pkg/a/b.go", false},
+		{[]string{"./pkg/*.go"}, "pkg/b.go", true},
+		{[]string{"*.md", "web/*.js"}, "web/app.js", true},
+		{nil, "main.go", false},
+	}
+	for _, c := range cases {
+		if MatchTrigger(c.patterns, c.file) != c.match {
+			t.Errorf("MatchTrigger(%v, %s) should be %t", c.patterns, c.file, c.match)
+		}
+	}
+}
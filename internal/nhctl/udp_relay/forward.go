@@ -0,0 +1,145 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package udp_relay
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// the sessions without datagrams in the time are closed
+var idleTimeout = 2 * time.Minute
+
+// DialFunc open a stream to the relay in the sidecar
+type DialFunc func() (io.ReadWriteCloser, error)
+
+type session struct {
+	stream     io.ReadWriteCloser
+	lastActive time.Time
+}
+
+// Forward the datagrams of localPort to the remotePort of pod by the relay,
+// each address of the local clients has its own stream, so that the replies
+// are sent back to it. readyCh is closed once listening, and it returns when
+// stopCh closed or the relay unreachable
+func Forward(localPort, remotePort int, dial DialFunc, readyCh chan struct{}, stopCh <-chan struct{}) error {
+	conn, err := net.ListenPacket("udp", net.JoinHostPort("127.0.0.1", fmt.Sprint(localPort)))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("Failed to listen udp port %d", localPort))
+	}
+	if readyCh != nil {
+		close(readyCh)
+	}
+
+	var (
+		lock     sync.Mutex
+		sessions = map[string]*session{}
+		stopped  = make(chan struct{})
+	)
+	closeSession := func(addr string, s *session) {
+		lock.Lock()
+		if sessions[addr] == s {
+			delete(sessions, addr)
+		}
+		lock.Unlock()
+		_ = s.stream.Close()
+	}
+	defer func() {
+		close(stopped)
+		_ = conn.Close()
+		lock.Lock()
+		for _, s := range sessions {
+			_ = s.stream.Close()
+		}
+		lock.Unlock()
+	}()
+
+	go func() {
+		select {
+		case <-stopCh:
+			_ = conn.Close()
+		case <-stopped:
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(idleTimeout / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				lock.Lock()
+				for addr, s := range sessions {
+					if time.Since(s.lastActive) > idleTimeout {
+						delete(sessions, addr)
+						_ = s.stream.Close()
+					}
+				}
+				lock.Unlock()
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-stopCh:
+				return nil
+			default:
+			}
+			return errors.WithStack(err)
+		}
+
+		lock.Lock()
+		s, ok := sessions[addr.String()]
+		lock.Unlock()
+		if !ok {
+			stream, err := dial()
+			if err != nil {
+				return errors.Wrap(err, "Failed to connect to udp relay of sidecar, start DevMode with --ssh to run it")
+			}
+			if err = writeHeader(stream, remotePort); err != nil {
+				_ = stream.Close()
+				return errors.WithStack(err)
+			}
+			s = &session{stream: stream, lastActive: time.Now()}
+			lock.Lock()
+			sessions[addr.String()] = s
+			lock.Unlock()
+			go func(addr net.Addr, s *session) {
+				defer closeSession(addr.String(), s)
+				replyBuf := make([]byte, maxDatagramSize)
+				for {
+					payload, err := readFrame(s.stream, replyBuf)
+					if err != nil {
+						return
+					}
+					if _, err = conn.WriteTo(payload, addr); err != nil {
+						return
+					}
+				}
+			}(addr, s)
+		}
+
+		lock.Lock()
+		s.lastActive = time.Now()
+		lock.Unlock()
+		if err = writeFrame(s.stream, buf[:n]); err != nil {
+			log.Logf("Failed to relay datagram of %s: %s", addr, err.Error())
+			closeSession(addr.String(), s)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+// Package udp_relay relays the datagrams of udp over the streams of the
+// port-forward of api server, which only forwards tcp. The stream starts with
+// the target udp port, followed by the datagrams framed by their lengths
+package udp_relay
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/pkg/errors"
+	"nocalhost/pkg/nhctl/log"
+)
+
+const maxDatagramSize = 65535
+
+func writeFrame(w io.Writer, payload []byte) error {
+	frame := make([]byte, 2+len(payload))
+	binary.BigEndian.PutUint16(frame, uint16(len(payload)))
+	copy(frame[2:], payload)
+	_, err := w.Write(frame)
+	return err
+}
+
+func readFrame(r io.Reader, buf []byte) ([]byte, error) {
+	if _, err := io.ReadFull(r, buf[:2]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint16(buf[:2])
+	if _, err := io.ReadFull(r, buf[:size]); err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+func writeHeader(w io.Writer, port int) error {
+	header := make([]byte, 2)
+	binary.BigEndian.PutUint16(header, uint16(port))
+	_, err := w.Write(header)
+	return err
+}
+
+func readHeader(r io.Reader) (int, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(header)), nil
+}
+
+// Serve relay the streams accepted to the udp ports of localhost, it runs
+// in the sidecar, which shares the network of the dev container
+func Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		go func() {
+			if err := serveStream(conn); err != nil && err != io.EOF {
+				log.Logf("Relay stream from %s closed: %s", conn.RemoteAddr(), err.Error())
+			}
+		}()
+	}
+}
+
+func serveStream(stream net.Conn) error {
+	defer stream.Close()
+
+	port, err := readHeader(stream)
+	if err != nil {
+		return err
+	}
+	udpConn, err := net.Dial("udp", net.JoinHostPort("127.0.0.1", fmt.Sprint(port)))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer udpConn.Close()
+
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, err := udpConn.Read(buf)
+			if err != nil {
+				_ = stream.Close()
+				return
+			}
+			if err = writeFrame(stream, buf[:n]); err != nil {
+				_ = udpConn.Close()
+				return
+			}
+		}
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	for {
+		payload, err := readFrame(stream, buf)
+		if err != nil {
+			return err
+		}
+		if _, err = udpConn.Write(payload); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package udp_relay
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func freeUDPPort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestForward(t *testing.T) {
+	// the udp echo server in the pod
+	echo, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, addr, err := echo.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = echo.WriteTo(append([]byte("echo "), buf[:n]...), addr)
+		}
+	}()
+
+	relay, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer relay.Close()
+	go func() { _ = Serve(relay) }()
+
+	localPort := freeUDPPort(t)
+	readyCh := make(chan struct{})
+	stopCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- Forward(
+			localPort, echo.LocalAddr().(*net.UDPAddr).Port, func() (io.ReadWriteCloser, error) {
+				return net.Dial("tcp", relay.Addr().String())
+			}, readyCh, stopCh,
+		)
+	}()
+
+	select {
+	case <-readyCh:
+	case err = <-errCh:
+		t.Fatal(err)
+	}
+
+	client, err := net.Dial("udp", (&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: localPort}).String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	buf := make([]byte, 64)
+	for _, msg := range []string{"dns", "statsd"} {
+		if _, err = client.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		_ = client.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := client.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(buf[:n]) != "echo "+msg {
+			t.Errorf("got %q, expect %q", buf[:n], "echo "+msg)
+		}
+	}
+
+	close(stopCh)
+	select {
+	case err = <-errCh:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Forward is not stopped")
+	}
+}
+
+func TestFrame(t *testing.T) {
+	r, w := net.Pipe()
+	defer r.Close()
+	go func() {
+		_ = writeFrame(w, []byte("hello"))
+		_ = writeFrame(w, []byte{})
+	}()
+
+	buf := make([]byte, maxDatagramSize)
+	payload, err := readFrame(r, buf)
+	if err != nil || string(payload) != "hello" {
+		t.Errorf("got %q, %v", payload, err)
+	}
+	payload, err = readFrame(r, buf)
+	if err != nil || len(payload) != 0 {
+		t.Errorf("got %q, %v", payload, err)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package picker
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/derailed/tview"
+	"github.com/gdamore/tcell/v2"
+	"github.com/moby/term"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNotInteractive the stdin or stdout is not a terminal, such as the
+	// nhctl called by IDE plugins
+	ErrNotInteractive = errors.New("not an interactive terminal")
+	ErrCanceled       = errors.New("canceled")
+)
+
+// IsInteractive true if both stdin and stdout are terminals
+func IsInteractive() bool {
+	return term.IsTerminal(os.Stdin.Fd()) && term.IsTerminal(os.Stdout.Fd())
+}
+
+// Pick lets the user pick one of items by typing a fuzzy pattern, the only
+// one is picked directly
+func Pick(title string, items []string) (string, error) {
+	if len(items) == 0 {
+		return "", errors.New("No " + title + " to pick")
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	if !IsInteractive() {
+		return "", ErrNotInteractive
+	}
+
+	var picked string
+	app := tview.NewApplication()
+	list := tview.NewList().ShowSecondaryText(false).SetHighlightFullLine(true)
+	input := tview.NewInputField().SetLabel(title + "> ").SetFieldBackgroundColor(tcell.ColorDefault)
+	refresh := func(pattern string) {
+		list.Clear()
+		for _, item := range Filter(pattern, items) {
+			list.AddItem(item, "", 0, nil)
+		}
+	}
+	input.SetChangedFunc(refresh)
+	input.SetInputCapture(
+		func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyUp, tcell.KeyCtrlP:
+				if current := list.GetCurrentItem(); current > 0 {
+					list.SetCurrentItem(current - 1)
+				}
+				return nil
+			case tcell.KeyDown, tcell.KeyCtrlN:
+				list.SetCurrentItem(list.GetCurrentItem() + 1)
+				return nil
+			}
+			return event
+		},
+	)
+	input.SetDoneFunc(
+		func(key tcell.Key) {
+			if key == tcell.KeyEnter && list.GetItemCount() > 0 {
+				picked, _ = list.GetItemText(list.GetCurrentItem())
+			}
+			app.Stop()
+		},
+	)
+	refresh("")
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(input, 1, 0, true).
+		AddItem(list, 0, 1, false)
+	if err := app.SetRoot(layout, true).SetFocus(input).Run(); err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	if picked == "" {
+		return "", ErrCanceled
+	}
+	return picked, nil
+}
+
+// Filter the items matching pattern, the better matched first
+func Filter(pattern string, items []string) []string {
+	type scored struct {
+		item  string
+		score int
+	}
+	matched := make([]scored, 0, len(items))
+	for _, item := range items {
+		if score, ok := Match(pattern, item); ok {
+			matched = append(matched, scored{item: item, score: score})
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].score > matched[j].score })
+
+	result := make([]string, 0, len(matched))
+	for _, m := range matched {
+		result = append(result, m.item)
+	}
+	return result
+}
+
+// Match the runes of pattern appear in s in order, case-insensitive. The
+// matches of consecutive runes or at the beginning of words score higher
+func Match(pattern, s string) (int, bool) {
+	p, r := []rune(strings.ToLower(pattern)), []rune(strings.ToLower(s))
+	if len(p) == 0 {
+		return 0, true
+	}
+	best, ok := 0, false
+	// the greedy match from each occurrence of the first rune
+	for start := range r {
+		if r[start] != p[0] {
+			continue
+		}
+		if score, matched := matchFrom(p, r, start); matched && (!ok || score > best) {
+			best, ok = score, true
+		}
+	}
+	return best, ok
+}
+
+func matchFrom(p, r []rune, start int) (int, bool) {
+	score, pi, last := 0, 0, -2
+	for i := start; i < len(r) && pi < len(p); i++ {
+		if r[i] != p[pi] {
+			continue
+		}
+		score++
+		if i == last+1 {
+			score += 2
+		}
+		if i == 0 || strings.ContainsRune("-_./ ", r[i-1]) {
+			score += 3
+		}
+		last = i
+		pi++
+	}
+	return score, pi == len(p)
+}
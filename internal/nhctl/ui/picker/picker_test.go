@@ -0,0 +1,37 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package picker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	items := []string{"productpage", "ratings", "reviews", "details", "reviews-v2"}
+	cases := map[string][]string{
+		"":    items,
+		"rev": {"reviews", "reviews-v2"},
+		"rv2": {"reviews-v2"},
+		"tls": {"details"},
+		"RAT": {"ratings"},
+		"xyz": {},
+	}
+	for pattern, expect := range cases {
+		if actual := Filter(pattern, items); !reflect.DeepEqual(actual, expect) {
+			t.Errorf("filter %q: got %v, expect %v", pattern, actual, expect)
+		}
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	prefix, _ := Match("de", "details")
+	middle, _ := Match("de", "productpage-debug")
+	scattered, _ := Match("de", "productpage")
+	if !(prefix > scattered && middle > scattered) {
+		t.Errorf("consecutive or word beginning should score higher: %d, %d, %d", prefix, middle, scattered)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/pkg/errors"
 	"io"
 	"io/ioutil"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/syncthing/ports"
 	"nocalhost/pkg/nhctl/log"
 	"nocalhost/pkg/nhctl/tools"
@@ -281,6 +282,25 @@ func GetPortForwardForString(portStr string) (int, int, error) {
 	}
 }
 
+// SplitPortProtocol split the protocol from the port of port-forward, such as
+// 5353:53/udp, the protocol is tcp if not specified
+func SplitPortProtocol(portStr string) (string, string, error) {
+	i := strings.LastIndex(portStr, "/")
+	if i < 0 {
+		return portStr, _const.TCPProtocol, nil
+	}
+	protocol := strings.ToLower(portStr[i+1:])
+	switch protocol {
+	case _const.TCPProtocol, _const.UDPProtocol:
+		return portStr[:i], protocol, nil
+	case _const.SCTPProtocol:
+		return "", "", errors.New(
+			fmt.Sprintf("SCTP port-forward is not supported, wrong defined of port: %s.", portStr),
+		)
+	}
+	return "", "", errors.New(fmt.Sprintf("Unknown protocol %s of port: %s.", protocol, portStr))
+}
+
 func RecoverFromPanic() {
 	if r := recover(); r != nil {
 		log.Errorf("DAEMON-RECOVER: %s", string(debug.Stack()))
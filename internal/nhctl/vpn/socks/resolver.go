@@ -0,0 +1,177 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package socks
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"nocalhost/pkg/nhctl/clientgoutils"
+)
+
+// target the pods of service, or the one of hostname in the headless service
+type target struct {
+	hostname  string
+	service   string
+	namespace string
+}
+
+// parseHost the candidates of the host in the dns of cluster, such as
+// <service>, <service>.<namespace>, <hostname>.<service>.<namespace>.svc and
+// the ones with the cluster domain
+func parseHost(host, namespace string) []target {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if i := strings.Index(host, ".svc."); i >= 0 {
+		host = host[:i+len(".svc")]
+	}
+	parts := strings.Split(host, ".")
+	if parts[len(parts)-1] == "svc" {
+		parts = parts[:len(parts)-1]
+		switch len(parts) {
+		case 2:
+			return []target{{service: parts[0], namespace: parts[1]}}
+		case 3:
+			return []target{{hostname: parts[0], service: parts[1], namespace: parts[2]}}
+		}
+		return nil
+	}
+	switch len(parts) {
+	case 1:
+		return []target{{service: parts[0], namespace: namespace}}
+	case 2:
+		return []target{
+			{service: parts[0], namespace: parts[1]},
+			{hostname: parts[0], service: parts[1], namespace: namespace},
+		}
+	case 3:
+		return []target{{hostname: parts[0], service: parts[1], namespace: parts[2]}}
+	}
+	return nil
+}
+
+// Resolver dial the services and pods of cluster by the port-forward of api
+// server to their pods, as the ClusterIP is not reachable from local
+type Resolver struct {
+	client *clientgoutils.ClientGoUtils
+}
+
+// NewResolver the names without namespace are of the namespace of client
+func NewResolver(client *clientgoutils.ClientGoUtils) *Resolver {
+	return &Resolver{client: client}
+}
+
+// Dial the port of host by one of the ready pods
+func (r *Resolver) Dial(host string, port int) (io.ReadWriteCloser, error) {
+	pod, namespace, podPort, err := r.resolve(host, port)
+	if err != nil {
+		return nil, err
+	}
+	return r.client.GetCopy().NameSpace(namespace).DialPort(pod, podPort)
+}
+
+func (r *Resolver) resolve(host string, port int) (string, string, int, error) {
+	namespace := r.client.GetNameSpace()
+	if ip := net.ParseIP(host); ip != nil {
+		return r.resolveIP(ip.String(), port)
+	}
+	for _, t := range parseHost(host, namespace) {
+		if pod, podPort, err := r.resolveTarget(t, port); err == nil {
+			return pod, t.namespace, podPort, nil
+		}
+	}
+	return "", "", 0, errors.New(fmt.Sprintf("Service %s not found", host))
+}
+
+// resolveIP the ip of pod or the ClusterIP of service in the namespace
+func (r *Resolver) resolveIP(ip string, port int) (string, string, int, error) {
+	namespace := r.client.GetNameSpace()
+	pods, err := r.client.ClientSet.CoreV1().Pods(namespace).List(
+		r.client.GetContext(), metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("status.podIP", ip).String()},
+	)
+	if err != nil {
+		return "", "", 0, errors.WithStack(err)
+	}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == corev1.PodRunning {
+			return pod.Name, namespace, port, nil
+		}
+	}
+
+	services, err := r.client.ClientSet.CoreV1().Services(namespace).List(r.client.GetContext(), metav1.ListOptions{})
+	if err != nil {
+		return "", "", 0, errors.WithStack(err)
+	}
+	for _, svc := range services.Items {
+		if svc.Spec.ClusterIP == ip {
+			pod, podPort, err := r.resolveTarget(target{service: svc.Name, namespace: namespace}, port)
+			return pod, namespace, podPort, err
+		}
+	}
+	return "", "", 0, errors.New(fmt.Sprintf("No pod or service of %s found in namespace %s", ip, namespace))
+}
+
+func (r *Resolver) resolveTarget(t target, port int) (string, int, error) {
+	svc, err := r.client.ClientSet.CoreV1().Services(t.namespace).Get(
+		r.client.GetContext(), t.service, metav1.GetOptions{},
+	)
+	if err != nil {
+		return "", 0, errors.WithStack(err)
+	}
+	endpoints, err := r.client.ClientSet.CoreV1().Endpoints(t.namespace).Get(
+		r.client.GetContext(), t.service, metav1.GetOptions{},
+	)
+	if err != nil {
+		return "", 0, errors.WithStack(err)
+	}
+	return pickEndpoint(svc, endpoints, t.hostname, port)
+}
+
+// pickEndpoint the first ready pod of service, and the port of pod mapped from
+// the port of service. The ports not exposed are dialed directly for the
+// headless service, as it is in the cluster
+func pickEndpoint(svc *corev1.Service, endpoints *corev1.Endpoints, hostname string, port int) (string, int, error) {
+	portName, exposed := "", false
+	for _, p := range svc.Spec.Ports {
+		if int(p.Port) == port {
+			portName, exposed = p.Name, true
+			break
+		}
+	}
+	if !exposed && svc.Spec.ClusterIP != corev1.ClusterIPNone {
+		return "", 0, errors.New(fmt.Sprintf("Port %d is not exposed by service %s", port, svc.Name))
+	}
+
+	for _, subset := range endpoints.Subsets {
+		podPort := port
+		if exposed {
+			podPort = 0
+			for _, p := range subset.Ports {
+				if p.Name == portName {
+					podPort = int(p.Port)
+				}
+			}
+			if podPort == 0 {
+				continue
+			}
+		}
+		for _, address := range subset.Addresses {
+			if address.TargetRef == nil || address.TargetRef.Kind != "Pod" {
+				continue
+			}
+			if hostname != "" && address.Hostname != hostname && address.TargetRef.Name != hostname {
+				continue
+			}
+			return address.TargetRef.Name, podPort, nil
+		}
+	}
+	return "", 0, errors.New(fmt.Sprintf("No ready pod of service %s found", svc.Name))
+}
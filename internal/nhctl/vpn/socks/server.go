@@ -0,0 +1,161 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+// Package socks a SOCKS5 proxy to the services of cluster, the hosts are
+// resolved by the proxy, so no TUN device or dns config needed, which needs
+// no root privilege
+package socks
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	socksVersion = 5
+
+	methodNoAuth       = 0
+	methodNoAcceptable = 0xff
+
+	cmdConnect = 1
+
+	atypIPv4   = 1
+	atypDomain = 3
+	atypIPv6   = 4
+
+	replySucceeded           = 0
+	replyHostUnreachable     = 4
+	replyCommandNotSupported = 7
+	replyAddressNotSupported = 8
+)
+
+// DialFunc dial the port of host, the host is an ip or the name of service
+type DialFunc func(host string, port int) (io.ReadWriteCloser, error)
+
+// Serve the SOCKS5 connections accepted by CONNECT of no authentication
+func Serve(listener net.Listener, dial DialFunc) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		go func() {
+			defer conn.Close()
+			if err := serveConn(conn, dial); err != nil {
+				log.Debugf("SOCKS connection from %s closed: %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+func serveConn(conn net.Conn, dial DialFunc) error {
+	if err := handshake(conn); err != nil {
+		return err
+	}
+	host, port, err := readRequest(conn)
+	if err != nil {
+		return err
+	}
+
+	remote, err := dial(host, port)
+	if err != nil {
+		_ = writeReply(conn, replyHostUnreachable)
+		return errors.Wrap(err, fmt.Sprintf("Failed to dial %s", net.JoinHostPort(host, strconv.Itoa(port))))
+	}
+	defer remote.Close()
+	if err = writeReply(conn, replySucceeded); err != nil {
+		return err
+	}
+	log.Infof("Proxying %s to %s", conn.RemoteAddr(), net.JoinHostPort(host, strconv.Itoa(port)))
+
+	errCh := make(chan error, 2)
+	go func() {
+		_, err := io.Copy(remote, conn)
+		errCh <- err
+	}()
+	go func() {
+		_, err := io.Copy(conn, remote)
+		errCh <- err
+	}()
+	return <-errCh
+}
+
+func handshake(conn net.Conn) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	if header[0] != socksVersion {
+		return errors.New(fmt.Sprintf("Unsupported SOCKS version %d", header[0]))
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return err
+	}
+	for _, method := range methods {
+		if method == methodNoAuth {
+			_, err := conn.Write([]byte{socksVersion, methodNoAuth})
+			return err
+		}
+	}
+	_, _ = conn.Write([]byte{socksVersion, methodNoAcceptable})
+	return errors.New("Only the SOCKS5 without authentication is supported")
+}
+
+func readRequest(conn net.Conn) (string, int, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", 0, err
+	}
+	if header[1] != cmdConnect {
+		_ = writeReply(conn, replyCommandNotSupported)
+		return "", 0, errors.New(fmt.Sprintf("Unsupported SOCKS command %d", header[1]))
+	}
+
+	var host string
+	switch header[3] {
+	case atypIPv4, atypIPv6:
+		size := net.IPv4len
+		if header[3] == atypIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", 0, err
+		}
+		host = net.IP(ip).String()
+	case atypDomain:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return "", 0, err
+		}
+		domain := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", 0, err
+		}
+		host = string(domain)
+	default:
+		_ = writeReply(conn, replyAddressNotSupported)
+		return "", 0, errors.New(fmt.Sprintf("Unsupported SOCKS address type %d", header[3]))
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", 0, err
+	}
+	return host, int(binary.BigEndian.Uint16(port)), nil
+}
+
+// writeReply the bound address is not used by the clients of CONNECT
+func writeReply(conn net.Conn, reply byte) error {
+	_, err := conn.Write([]byte{socksVersion, reply, 0, atypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,141 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package socks
+
+import (
+	"io"
+	"net"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestServe(t *testing.T) {
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	var dialed string
+	go func() {
+		_ = Serve(
+			proxy, func(host string, port int) (io.ReadWriteCloser, error) {
+				dialed = host
+				return net.Dial("tcp", echo.Addr().String())
+			},
+		)
+	}()
+
+	conn, err := net.Dial("tcp", proxy.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	host := "details.default"
+	request := []byte{socksVersion, 1, methodNoAuth, socksVersion, cmdConnect, 0, atypDomain, byte(len(host))}
+	request = append(append(request, host...), 0, 80)
+	if _, err = conn.Write(request); err != nil {
+		t.Fatal(err)
+	}
+	reply := make([]byte, 12)
+	if _, err = io.ReadFull(conn, reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply[1] != methodNoAuth || reply[3] != replySucceeded {
+		t.Fatalf("unexpected reply %v", reply)
+	}
+	if dialed != host {
+		t.Errorf("got host %s, expect %s", dialed, host)
+	}
+
+	if _, err = conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4)
+	if _, err = io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("got %q, %v", buf, err)
+	}
+}
+
+func TestParseHost(t *testing.T) {
+	for host, expect := range map[string][]target{
+		"details":                        {{service: "details", namespace: "dev"}},
+		"details.test.":                  {{service: "details", namespace: "test"}, {hostname: "details", service: "test", namespace: "dev"}},
+		"details.test.svc":               {{service: "details", namespace: "test"}},
+		"details.test.svc.cluster.local": {{service: "details", namespace: "test"}},
+		"mysql-0.mysql.test":             {{hostname: "mysql-0", service: "mysql", namespace: "test"}},
+		"mysql-0.mysql.test.svc.cluster.local": {
+			{hostname: "mysql-0", service: "mysql", namespace: "test"},
+		},
+		"a.b.c.d": nil,
+	} {
+		if got := parseHost(host, "dev"); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%s: got %v, expect %v", host, got, expect)
+		}
+	}
+}
+
+func TestPickEndpoint(t *testing.T) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "mysql"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Ports:     []corev1.ServicePort{{Name: "mysql", Port: 3306, TargetPort: intstr.FromInt(13306)}},
+		},
+	}
+	endpoints := &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{
+					{Hostname: "mysql-0", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "mysql-0"}},
+					{Hostname: "mysql-1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "mysql-1"}},
+				},
+				Ports: []corev1.EndpointPort{{Name: "mysql", Port: 13306}},
+			},
+		},
+	}
+
+	if pod, port, err := pickEndpoint(svc, endpoints, "", 3306); err != nil || pod != "mysql-0" || port != 13306 {
+		t.Errorf("got %s %d %v", pod, port, err)
+	}
+	if pod, port, err := pickEndpoint(svc, endpoints, "mysql-1", 3306); err != nil || pod != "mysql-1" || port != 13306 {
+		t.Errorf("got %s %d %v", pod, port, err)
+	}
+	// the ports not exposed by the headless service are dialed directly
+	if pod, port, err := pickEndpoint(svc, endpoints, "", 9104); err != nil || pod != "mysql-0" || port != 9104 {
+		t.Errorf("got %s %d %v", pod, port, err)
+	}
+	if _, _, err := pickEndpoint(svc, endpoints, "mysql-2", 3306); err == nil {
+		t.Error("mysql-2 should not be found")
+	}
+
+	svc.Spec.ClusterIP = "10.0.0.1"
+	if _, _, err := pickEndpoint(svc, endpoints, "", 9104); err == nil {
+		t.Error("the port not exposed by service should not be dialed")
+	}
+}
@@ -398,7 +398,7 @@ func IsPortListening(port int) bool {
 }
 
 func IsSudoDaemonServing() bool {
-	if !IsPortListening(daemon_common.SudoDaemonPort) {
+	if !daemon_common.IsDaemonListening(true) {
 		return false
 	}
 	if _, err := daemon_client.GetDaemonClient(true); err != nil {
@@ -0,0 +1,357 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package backup
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/migration"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/encryption"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Format the version of the archive, bump it if the layout changes
+const Format = 1
+
+// the text sealed in the archive to verify the passphrase
+const checkText = "nocalhost-backup"
+
+// ErrNotEmpty the instance restored into has the data already
+var ErrNotEmpty = errors.New("database is not empty, restore into a fresh instance")
+
+// Table backed up, the secrets are encrypted by the passphrase in the archive
+type Table struct {
+	Name    string
+	Secrets []string
+}
+
+// Tables backed up in order, the referenced ones go first, so that the rows
+// are restored in the same order, the secrets are the columns encrypted at rest
+var Tables = []Table{
+	{Name: "users"},
+	{Name: "clusters", Secrets: []string{"kubeconfig"}},
+	{Name: "applications"},
+	{Name: "applications_users"},
+	{Name: "clusters_users", Secrets: []string{"kubeconfig"}},
+}
+
+// Archive the data of nocalhost-api, the rows are in the schema of the
+// version of migration
+type Archive struct {
+	Format    int       `json:"format"`
+	Schema    uint64    `json:"schema"`
+	CreatedAt time.Time `json:"created_at"`
+	Salt      string    `json:"salt"`
+	Check     string    `json:"check"`
+	Tables    []Rows    `json:"tables"`
+}
+
+// Rows of a table, the values are in the order of columns
+type Rows struct {
+	Name    string          `json:"name"`
+	Columns []string        `json:"columns"`
+	Rows    [][]interface{} `json:"rows"`
+}
+
+// Backup write the tables to w as gzipped json, the secrets are encrypted by
+// passphrase, the soft deleted rows are included
+func Backup(db *gorm.DB, w io.Writer, passphrase string) (*Archive, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required to encrypt the kubeconfigs")
+	}
+	schema, err := migration.Current(db)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := newSalt()
+	if err != nil {
+		return nil, err
+	}
+	s, err := newSealer(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	check, err := s.seal(checkText)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &Archive{
+		Format:    Format,
+		Schema:    schema,
+		CreatedAt: time.Now(),
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		Check:     check,
+	}
+	for _, table := range Tables {
+		rows, err := dump(db, table, s)
+		if err != nil {
+			return nil, err
+		}
+		archive.Tables = append(archive.Tables, *rows)
+	}
+
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(archive); err != nil {
+		return nil, errors.Wrap(err, "write archive")
+	}
+	if err := gz.Close(); err != nil {
+		return nil, errors.Wrap(err, "write archive")
+	}
+	return archive, nil
+}
+
+func dump(db *gorm.DB, table Table, s *sealer) (*Rows, error) {
+	rows, err := db.Raw(fmt.Sprintf("SELECT * FROM %s", db.Dialect().Quote(table.Name))).Rows()
+	if err != nil {
+		return nil, errors.Wrapf(err, "select %s", table.Name)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errors.Wrapf(err, "columns of %s", table.Name)
+	}
+	secrets := secretIndexes(table, columns)
+
+	result := &Rows{Name: table.Name, Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, errors.Wrapf(err, "scan %s", table.Name)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+			if str, ok := values[i].(string); ok && secrets[i] && str != "" {
+				// the secrets encrypted at rest are sealed by the passphrase
+				// instead, so that they are restored by the keys of another instance
+				if str, _, err = encryption.Decrypt(str); err != nil {
+					return nil, errors.Wrapf(err, "decrypt %s of %s", columns[i], table.Name)
+				}
+				if values[i], err = s.seal(str); err != nil {
+					return nil, err
+				}
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, errors.Wrapf(rows.Err(), "read %s", table.Name)
+}
+
+// Read the archive written by Backup
+func Read(r io.Reader) (*Archive, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read archive")
+	}
+	defer gz.Close()
+
+	archive := &Archive{}
+	decoder := json.NewDecoder(gz)
+	decoder.UseNumber()
+	if err := decoder.Decode(archive); err != nil {
+		return nil, errors.Wrap(err, "read archive")
+	}
+	if archive.Format != Format {
+		return nil, errors.New(fmt.Sprintf("unsupported format %d of archive", archive.Format))
+	}
+	return archive, nil
+}
+
+// Restore the archive into the fresh instance, the schema is migrated to
+// the one of archive before restoring, and to the latest after
+func Restore(db *gorm.DB, archive *Archive, passphrase string) error {
+	salt, err := base64.StdEncoding.DecodeString(archive.Salt)
+	if err != nil {
+		return errors.New("malformed salt of archive")
+	}
+	s, err := newSealer(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	if check, err := s.open(archive.Check); err != nil || check != checkText {
+		return ErrPassphrase
+	}
+
+	if archive.Schema == 0 || archive.Schema > migration.Latest() {
+		return errors.Wrapf(
+			migration.ErrNewerSchema, "schema %d of archive, latest known %d", archive.Schema, migration.Latest(),
+		)
+	}
+	current, err := migration.Current(db)
+	if err != nil {
+		return err
+	}
+	if current > archive.Schema {
+		return errors.New(
+			fmt.Sprintf("database is migrated to %d, newer than %d of archive", current, archive.Schema),
+		)
+	}
+	if _, err := migration.Up(db, archive.Schema); err != nil {
+		return err
+	}
+
+	if err := db.Transaction(
+		func(tx *gorm.DB) error {
+			for _, rows := range archive.Tables {
+				if err := restore(tx, rows, s); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	); err != nil {
+		return err
+	}
+
+	done, err := migration.Up(db, 0)
+	for _, m := range done {
+		log.Infof("applied migration %s after restoring", m)
+	}
+	return err
+}
+
+func restore(tx *gorm.DB, rows Rows, s *sealer) error {
+	table := Table{Name: rows.Name}
+	for _, t := range Tables {
+		if t.Name == rows.Name {
+			table = t
+		}
+	}
+
+	name := tx.Dialect().Quote(rows.Name)
+	var count int
+	if err := tx.Table(rows.Name).Count(&count).Error; err != nil {
+		return errors.Wrapf(err, "count %s", rows.Name)
+	}
+	if count > 0 {
+		return errors.Wrapf(ErrNotEmpty, "%d rows in %s", count, rows.Name)
+	}
+
+	types, err := columnTypes(tx, name)
+	if err != nil {
+		return errors.Wrapf(err, "columns of %s", rows.Name)
+	}
+	quoted := make([]string, len(rows.Columns))
+	for i, column := range rows.Columns {
+		if _, ok := types[column]; !ok {
+			return errors.New(fmt.Sprintf("unknown column %s of %s", column, rows.Name))
+		}
+		quoted[i] = tx.Dialect().Quote(column)
+	}
+	statement := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)", name, strings.Join(quoted, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(quoted)), ", "),
+	)
+
+	secrets := secretIndexes(table, rows.Columns)
+	for _, row := range rows.Rows {
+		if len(row) != len(rows.Columns) {
+			return errors.New(fmt.Sprintf("malformed row of %s", rows.Name))
+		}
+		values := make([]interface{}, len(row))
+		for i, v := range row {
+			if values[i], err = convert(v, types[rows.Columns[i]]); err != nil {
+				return errors.Wrapf(err, "column %s of %s", rows.Columns[i], rows.Name)
+			}
+			if str, ok := values[i].(string); ok && secrets[i] {
+				if str, err = s.open(str); err != nil {
+					return err
+				}
+				if values[i], err = encryption.Encrypt(str); err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Exec(statement, values...).Error; err != nil {
+			return errors.Wrapf(err, "insert into %s", rows.Name)
+		}
+	}
+
+	// the sequence of postgres is not advanced by the ids inserted
+	if model.IsPostgres(tx) && len(rows.Rows) > 0 {
+		if _, ok := types["id"]; ok {
+			if err := tx.Exec(
+				fmt.Sprintf(
+					"SELECT setval(pg_get_serial_sequence('%s', 'id'), (SELECT MAX(id) FROM %s))", rows.Name, name,
+				),
+			).Error; err != nil {
+				return errors.Wrapf(err, "reset sequence of %s", rows.Name)
+			}
+		}
+	}
+	log.Infof("restored %d rows of %s", len(rows.Rows), rows.Name)
+	return nil
+}
+
+// columnTypes the database types of the columns of table, in upper case
+func columnTypes(tx *gorm.DB, name string) (map[string]string, error) {
+	rows, err := tx.Raw(fmt.Sprintf("SELECT * FROM %s WHERE 1 = 0", name)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(columns))
+	for _, c := range columns {
+		result[c.Name()] = strings.ToUpper(c.DatabaseTypeName())
+	}
+	return result, nil
+}
+
+// convert the value decoded from json to the type of column, so that the
+// archive of one driver is restored into another, e.g. from mysql to postgres
+func convert(v interface{}, columnType string) (interface{}, error) {
+	switch value := v.(type) {
+	case json.Number:
+		if strings.Contains(columnType, "BOOL") {
+			i, err := value.Int64()
+			return i != 0, err
+		}
+		if i, err := value.Int64(); err == nil {
+			return i, nil
+		}
+		return value.Float64()
+	case string:
+		if strings.Contains(columnType, "TIME") || strings.Contains(columnType, "DATE") {
+			return time.Parse(time.RFC3339Nano, value)
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
+
+func secretIndexes(table Table, columns []string) map[int]bool {
+	result := map[int]bool{}
+	for i, column := range columns {
+		for _, secret := range table.Secrets {
+			if column == secret {
+				result[i] = true
+			}
+		}
+	}
+	return result
+}
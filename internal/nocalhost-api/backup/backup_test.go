@@ -0,0 +1,63 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSeal(t *testing.T) {
+	salt, _ := newSalt()
+	s, err := newSealer("passphrase", salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := s.seal("apiVersion: v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := s.open(sealed); err != nil || plain != "apiVersion: v1" {
+		t.Fatalf("unexpected secret opened %s %v", plain, err)
+	}
+	if plain, _ := s.open("not sealed"); plain != "not sealed" {
+		t.Fatal("expect the value not sealed returned as is")
+	}
+
+	wrong, _ := newSealer("wrong", salt)
+	if _, err := wrong.open(sealed); err != ErrPassphrase {
+		t.Fatalf("expect wrong passphrase refused, got %v", err)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	if v, _ := convert(json.Number("1"), "BOOL"); v != true {
+		t.Fatalf("expect tinyint converted to bool, got %v", v)
+	}
+	if v, _ := convert(json.Number("12"), "BIGINT"); v != int64(12) {
+		t.Fatalf("unexpected int %v", v)
+	}
+	now := time.Now().UTC()
+	if v, _ := convert(now.Format(time.RFC3339Nano), "TIMESTAMPTZ"); !v.(time.Time).Equal(now) {
+		t.Fatalf("unexpected time %v", v)
+	}
+	if v, _ := convert("2021", "VARCHAR"); v != "2021" {
+		t.Fatalf("unexpected string %v", v)
+	}
+}
+
+func TestRead(t *testing.T) {
+	buf := bytes.Buffer{}
+	gz := gzip.NewWriter(&buf)
+	_ = json.NewEncoder(gz).Encode(Archive{Format: Format + 1})
+	_ = gz.Close()
+	if _, err := Read(&buf); err == nil {
+		t.Fatal("expect the archive of newer format refused")
+	}
+}
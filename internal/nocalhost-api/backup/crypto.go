@@ -0,0 +1,97 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package backup
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// the prefix of the secrets encrypted in the archive
+	encryptedPrefix = "enc:v1:"
+	keyIterations   = 100000
+	saltSize        = 16
+)
+
+// ErrPassphrase the passphrase can not decrypt the secrets
+var ErrPassphrase = errors.New("wrong passphrase of backup")
+
+// deriveKey the aes-256 key of passphrase by pbkdf2 with hmac-sha256
+func deriveKey(passphrase string, salt []byte) []byte {
+	prf := hmac.New(sha256.New, []byte(passphrase))
+	prf.Write(salt)
+	prf.Write([]byte{0, 0, 0, 1})
+	u := prf.Sum(nil)
+	key := append([]byte(nil), u...)
+	for i := 1; i < keyIterations; i++ {
+		prf.Reset()
+		prf.Write(u)
+		u = prf.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "generate salt")
+	}
+	return salt, nil
+}
+
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(passphrase string, salt []byte) (*sealer, error) {
+	block, err := aes.NewCipher(deriveKey(passphrase, salt))
+	if err != nil {
+		return nil, errors.Wrap(err, "new cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "new gcm")
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// seal encrypt the secret by aes-gcm, the nonce is prepended
+func (s *sealer) seal(plain string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Wrap(err, "generate nonce")
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypt the secret sealed, the values not sealed are returned as is
+func (s *sealer) open(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", errors.New("malformed secret in backup")
+	}
+	size := s.aead.NonceSize()
+	plain, err := s.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", ErrPassphrase
+	}
+	return string(plain), nil
+}
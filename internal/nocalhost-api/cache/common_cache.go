@@ -9,6 +9,8 @@ const (
 	CLUSTER      CacheModule = "CLUSTER"
 	USER         CacheModule = "USER"
 	CLUSTER_USER CacheModule = "CLUSTER_USER"
+	ROLE         CacheModule = "ROLE"
+	SESSION      CacheModule = "SESSION"
 
 	OUT_OF_DATE = time.Minute * 5
 )
@@ -0,0 +1,196 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis"
+
+	driver "nocalhost/pkg/nocalhost-api/pkg/cache"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+)
+
+// Layers of the cache
+const (
+	LayerLocal  = "local"
+	LayerRemote = "remote"
+)
+
+// evictChannel the channel of keys evicted, appended to the prefix
+const evictChannel = "evict"
+
+// the values are encoded by msgpack, so that the fields hidden from json are
+// kept, and the pointers to zero values are not decoded as nil as gob does
+var encoding = driver.MsgPackEncoding{}
+
+var remote struct {
+	sync.RWMutex
+	client *redis.Client
+	prefix string
+}
+
+// EnableRemote cache the values in redis as well as the local cache, so that
+// the replicas of nocalhost-api share the values loaded from the database,
+// the keys deleted by any replica are evicted from the local caches of all
+// the replicas by the messages published
+func EnableRemote(client *redis.Client, prefix string) {
+	remote.Lock()
+	remote.client = client
+	remote.prefix = prefix
+	remote.Unlock()
+
+	if client != nil {
+		go subscribe(client, prefix+evictChannel)
+	}
+}
+
+func remoteClient() (*redis.Client, string) {
+	remote.RLock()
+	defer remote.RUnlock()
+	return remote.client, remote.prefix
+}
+
+// Value the value of key in the local cache of module
+func Value(module CacheModule, key interface{}) (interface{}, bool) {
+	item, err := Module(module).Value(key)
+	metrics.CacheLookup(string(module), LayerLocal, err == nil)
+	if err != nil {
+		return nil, false
+	}
+	return item.Data(), true
+}
+
+// GetRemote decode the value of key in the remote cache of module to out,
+// false if missed, the remote cache is disabled or unavailable
+func GetRemote(module CacheModule, key interface{}, out interface{}) bool {
+	client, prefix := remoteClient()
+	if client == nil {
+		return false
+	}
+	data, err := client.Get(remoteKey(prefix, module, key)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Warnf("get %s %v from redis err: %v", module, key, err)
+		}
+		metrics.CacheLookup(string(module), LayerRemote, false)
+		return false
+	}
+	if err := driver.Unmarshal(encoding, data, out); err != nil {
+		log.Warnf("decode %s %v from redis err: %v", module, key, err)
+		metrics.CacheLookup(string(module), LayerRemote, false)
+		return false
+	}
+	metrics.CacheLookup(string(module), LayerRemote, true)
+	return true
+}
+
+// SetRemote the value of keys in the remote cache of module, the errors are
+// logged only, the value is loaded from the database again if missed
+func SetRemote(module CacheModule, value interface{}, keys ...interface{}) {
+	client, prefix := remoteClient()
+	if client == nil {
+		return
+	}
+	data, err := driver.Marshal(encoding, value)
+	if err != nil {
+		log.Warnf("encode %s %v err: %v", module, keys, err)
+		return
+	}
+	pipe := client.Pipeline()
+	for _, key := range keys {
+		pipe.Set(remoteKey(prefix, module, key), data, OUT_OF_DATE)
+	}
+	if _, err := pipe.Exec(); err != nil {
+		log.Warnf("set %s %v to redis err: %v", module, keys, err)
+	}
+}
+
+// Delete the keys of module from the local cache and the remote cache, the
+// local caches of the other replicas are evicted as well
+func Delete(module CacheModule, keys ...interface{}) {
+	c := Module(module)
+	for _, key := range keys {
+		_, _ = c.Delete(key)
+	}
+
+	client, prefix := remoteClient()
+	if client == nil || len(keys) == 0 {
+		return
+	}
+	remoteKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		remoteKeys = append(remoteKeys, remoteKey(prefix, module, key))
+	}
+	if err := client.Del(remoteKeys...).Err(); err != nil {
+		log.Warnf("delete %s %v from redis err: %v", module, keys, err)
+	}
+	for _, key := range keys {
+		if err := client.Publish(prefix+evictChannel, evictMessage(module, key)).Err(); err != nil {
+			log.Warnf("publish eviction of %s %v err: %v", module, key, err)
+		}
+	}
+}
+
+// subscribe the keys evicted by the replicas, the client reconnects if the
+// connection is lost, the evictions published in the meantime are missed and
+// the local values expire in OUT_OF_DATE
+func subscribe(client *redis.Client, channel string) {
+	pubsub := client.Subscribe(channel)
+	defer pubsub.Close()
+
+	for message := range pubsub.Channel() {
+		evictLocal(message.Payload)
+	}
+}
+
+// the keys of the local caches are either uint64 or string
+func keyString(key interface{}) string {
+	switch k := key.(type) {
+	case uint64:
+		return "u:" + strconv.FormatUint(k, 10)
+	case string:
+		return "s:" + k
+	default:
+		return fmt.Sprintf("s:%v", k)
+	}
+}
+
+func parseKey(s string) (interface{}, bool) {
+	switch {
+	case strings.HasPrefix(s, "u:"):
+		id, err := strconv.ParseUint(s[2:], 10, 64)
+		return id, err == nil
+	case strings.HasPrefix(s, "s:"):
+		return s[2:], true
+	default:
+		return nil, false
+	}
+}
+
+func remoteKey(prefix string, module CacheModule, key interface{}) string {
+	return prefix + string(module) + ":" + keyString(key)
+}
+
+func evictMessage(module CacheModule, key interface{}) string {
+	return string(module) + " " + keyString(key)
+}
+
+func evictLocal(message string) {
+	i := strings.IndexByte(message, ' ')
+	if i < 0 {
+		return
+	}
+	key, ok := parseKey(message[i+1:])
+	if !ok {
+		return
+	}
+	_, _ = Module(CacheModule(message[:i])).Delete(key)
+}
@@ -0,0 +1,89 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+func enableTestRemote(t *testing.T) *miniredis.Miniredis {
+	log.NewLogger(&log.Config{}, log.InstanceZapLogger)
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote.Lock()
+	remote.client = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	remote.prefix = "nocalhost:"
+	remote.Unlock()
+	t.Cleanup(
+		func() {
+			EnableRemote(nil, "")
+			mr.Close()
+		},
+	)
+	return mr
+}
+
+func TestRemote(t *testing.T) {
+	mr := enableTestRemote(t)
+
+	zero := uint64(0)
+	user := &model.UserBaseModel{ID: 1, SaName: "sa", Password: "hash", TokenGeneration: 10, IsAdmin: &zero}
+	SetRemote(USER, user, user.ID, user.SaName)
+	if !mr.Exists("nocalhost:USER:u:1") || !mr.Exists("nocalhost:USER:s:sa") {
+		t.Fatalf("expect both keys cached, got %v", mr.Keys())
+	}
+
+	var result model.UserBaseModel
+	if !GetRemote(USER, "sa", &result) {
+		t.Fatal("expect hit")
+	}
+	if result.ID != 1 || result.Password != "hash" || result.TokenGeneration != 10 ||
+		result.IsAdmin == nil || *result.IsAdmin != 0 {
+		t.Fatalf("unexpected user %+v", result)
+	}
+
+	cluster := &model.ClusterModel{ID: 2, KubeConfig: "kubeconfig", Labels: model.Labels{"env": "staging"}}
+	SetRemote(CLUSTER, cluster, cluster.ID)
+	var clusterResult model.ClusterModel
+	if !GetRemote(CLUSTER, uint64(2), &clusterResult) ||
+		clusterResult.KubeConfig != "kubeconfig" || clusterResult.Labels["env"] != "staging" {
+		t.Fatalf("unexpected cluster %+v", clusterResult)
+	}
+
+	Delete(USER, user.ID, user.SaName)
+	if GetRemote(USER, uint64(1), &result) || GetRemote(USER, "sa", &result) {
+		t.Fatal("expect deleted")
+	}
+}
+
+func TestRemoteDisabled(t *testing.T) {
+	var result model.ClusterModel
+	SetRemote(CLUSTER, &model.ClusterModel{ID: 1}, uint64(1))
+	if GetRemote(CLUSTER, uint64(1), &result) {
+		t.Fatal("expect miss if remote is disabled")
+	}
+}
+
+func TestEvictLocal(t *testing.T) {
+	c := Module(CLUSTER_USER)
+	c.Add(uint64(2), OUT_OF_DATE, "by id")
+	c.Add("A:1-ns", OUT_OF_DATE, "by namespace")
+
+	evictLocal(evictMessage(CLUSTER_USER, uint64(2)))
+	evictLocal(evictMessage(CLUSTER_USER, "A:1-ns"))
+	evictLocal("malformed")
+	if c.Exists(uint64(2)) || c.Exists("A:1-ns") {
+		t.Fatal("expect evicted")
+	}
+}
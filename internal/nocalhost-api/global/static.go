@@ -25,6 +25,9 @@ const (
 	NocalhostRegistry                         = "nocalhost-docker.pkg.coding.net"
 	Nocalhostrepository                       = "nocalhost/public/nocalhost-api"
 	NocalhostSaTokenSuffix                    = "-token-gen-by-nocalhost"
+	// annotations of namespace adopted as dev space
+	NocalhostDevSpaceIdAnnotation    = "nocalhost.dev/devspace-id"
+	NocalhostDevSpaceOwnerAnnotation = "nocalhost.dev/owner"
 )
 
 var (
@@ -0,0 +1,295 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// ErrNewerSchema the schema is migrated by a newer nocalhost-api
+var ErrNewerSchema = errors.New("database schema is newer than nocalhost-api")
+
+// Migration a version of the schema, the statements are executed in order if
+// they are portable for the drivers, otherwise the funcs are used. The
+// checksum of statements and revision is recorded and verified.
+//
+// The funcs can not be checksummed, so the migrations applied with funcs are
+// immutable as the statements, add a new version instead of changing them. If
+// a func must be fixed, bump the revision to have the databases applied the
+// previous one refused
+type Migration struct {
+	Version  uint64
+	Name     string
+	Up       []string
+	Down     []string
+	Migrate  func(tx *gorm.DB) error
+	Rollback func(tx *gorm.DB) error
+
+	// Revision the revision of Migrate and Rollback, zero for the first one
+	Revision uint64
+}
+
+// Checksum the checksum of version, name, statements and revision, the
+// revision zero is not in the checksum to keep the ones recorded before
+func (m Migration) Checksum() string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%d\n%s\n", m.Version, m.Name)
+	if m.Revision != 0 {
+		_, _ = fmt.Fprintf(h, "revision:%d\n", m.Revision)
+	}
+	for _, statement := range m.Up {
+		_, _ = fmt.Fprintf(h, "up:%s\n", strings.TrimSpace(statement))
+	}
+	for _, statement := range m.Down {
+		_, _ = fmt.Fprintf(h, "down:%s\n", strings.TrimSpace(statement))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+func (m Migration) up(tx *gorm.DB) error {
+	for _, statement := range m.Up {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	if m.Migrate != nil {
+		return m.Migrate(tx)
+	}
+	return nil
+}
+
+func (m Migration) down(tx *gorm.DB) error {
+	if len(m.Down) == 0 && m.Rollback == nil {
+		return errors.New(fmt.Sprintf("migration %s can not be rolled back", m))
+	}
+	for _, statement := range m.Down {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	if m.Rollback != nil {
+		return m.Rollback(tx)
+	}
+	return nil
+}
+
+// record the migration applied
+type record struct {
+	Version   uint64    `gorm:"primary_key;column:version;auto_increment:false"`
+	Name      string    `gorm:"column:name;type:VARCHAR(100);not null"`
+	Checksum  string    `gorm:"column:checksum;type:VARCHAR(64);not null"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (record) TableName() string {
+	return "schema_migrations"
+}
+
+// Status the status of migration
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Latest the latest version known
+func Latest() uint64 {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+func applied(db *gorm.DB) (map[uint64]record, error) {
+	if err := db.AutoMigrate(&record{}).Error; err != nil {
+		return nil, errors.Wrap(err, "create schema_migrations err")
+	}
+	var records []record
+	if err := db.Order("version").Find(&records).Error; err != nil {
+		return nil, errors.Wrap(err, "list schema_migrations err")
+	}
+	result := make(map[uint64]record, len(records))
+	for _, r := range records {
+		result[r.Version] = r
+	}
+	return result, nil
+}
+
+// verify the migrations applied are known and not changed
+func verify(known []Migration, applied map[uint64]record) error {
+	var latest uint64
+	versions := make(map[uint64]Migration, len(known))
+	for _, m := range known {
+		versions[m.Version] = m
+		latest = m.Version
+	}
+	for version, r := range applied {
+		m, ok := versions[version]
+		if !ok {
+			if version > latest {
+				return errors.Wrapf(ErrNewerSchema, "version %d applied, latest known %d", version, latest)
+			}
+			return errors.New(fmt.Sprintf("unknown migration %d_%s applied", version, r.Name))
+		}
+		if r.Checksum != m.Checksum() {
+			return errors.New(fmt.Sprintf("checksum of migration %s mismatch", m))
+		}
+	}
+	return nil
+}
+
+// pending the migrations not applied up to version, a migration older than
+// the ones applied is pending as well
+func pending(known []Migration, applied map[uint64]record, to uint64) []Migration {
+	var result []Migration
+	for _, m := range known {
+		if _, ok := applied[m.Version]; !ok && m.Version <= to {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// Check refuse the schema migrated by a newer nocalhost-api, or the migrations
+// applied are changed, the migrations pending are returned
+func Check(db *gorm.DB) ([]Migration, error) {
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(migrations, records); err != nil {
+		return nil, err
+	}
+	return pending(migrations, records, Latest()), nil
+}
+
+// Up apply the migrations pending up to version in order, each in a
+// transaction, zero means the latest
+func Up(db *gorm.DB, to uint64) ([]Migration, error) {
+	if to == 0 {
+		to = Latest()
+	}
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(migrations, records); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range pending(migrations, records, to) {
+		log.Infof("apply migration %s", m)
+		if err := db.Transaction(
+			func(tx *gorm.DB) error {
+				if err := m.up(tx); err != nil {
+					return err
+				}
+				return tx.Create(
+					&record{Version: m.Version, Name: m.Name, Checksum: m.Checksum(), AppliedAt: time.Now()},
+				).Error
+			},
+		); err != nil {
+			return done, errors.Wrapf(err, "apply migration %s err", m)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down roll back the migrations applied after version in reverse order
+func Down(db *gorm.DB, to uint64) ([]Migration, error) {
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := verify(migrations, records); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := records[m.Version]; !ok || m.Version <= to {
+			continue
+		}
+		log.Infof("roll back migration %s", m)
+		if err := db.Transaction(
+			func(tx *gorm.DB) error {
+				if err := m.down(tx); err != nil {
+					return err
+				}
+				return tx.Delete(&record{Version: m.Version}).Error
+			},
+		); err != nil {
+			return done, errors.Wrapf(err, "roll back migration %s err", m)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Current the latest version applied, zero if none
+func Current(db *gorm.DB) (uint64, error) {
+	records, err := applied(db)
+	if err != nil {
+		return 0, err
+	}
+	var current uint64
+	for version := range records {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// List the status of the migrations known
+func List(db *gorm.DB) ([]Status, error) {
+	records, err := applied(db)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		r, ok := records[m.Version]
+		result = append(result, Status{Migration: m, Applied: ok, AppliedAt: r.AppliedAt})
+	}
+	return result, nil
+}
+
+// validate the migrations are in order of version
+func validate(known []Migration) error {
+	if !sort.SliceIsSorted(known, func(i, j int) bool { return known[i].Version < known[j].Version }) {
+		return errors.New("migrations are not in order of version")
+	}
+	for i := 1; i < len(known); i++ {
+		if known[i].Version == known[i-1].Version {
+			return errors.New(fmt.Sprintf("duplicate migration version %d", known[i].Version))
+		}
+	}
+	return nil
+}
+
+func init() {
+	if err := validate(migrations); err != nil {
+		panic(err)
+	}
+}
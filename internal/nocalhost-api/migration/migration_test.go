@@ -0,0 +1,82 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package migration
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+var known = []Migration{
+	{Version: 1, Name: "baseline"},
+	{Version: 2, Name: "rename_user_name", Up: []string{"ALTER TABLE users RENAME COLUMN name TO nickname"}},
+	{Version: 3, Name: "backfill_nickname", Up: []string{"UPDATE users SET nickname = username WHERE nickname = ''"}},
+}
+
+func recorded(migrations ...Migration) map[uint64]record {
+	result := map[uint64]record{}
+	for _, m := range migrations {
+		result[m.Version] = record{Version: m.Version, Name: m.Name, Checksum: m.Checksum()}
+	}
+	return result
+}
+
+func TestVerify(t *testing.T) {
+	if err := verify(known, recorded(known...)); err != nil {
+		t.Fatal(err)
+	}
+
+	newer := recorded(known...)
+	newer[4] = record{Version: 4, Name: "future"}
+	if err := verify(known, newer); errors.Cause(err) != ErrNewerSchema {
+		t.Fatalf("expect newer schema refused, got %v", err)
+	}
+
+	changed := known[1]
+	changed.Up = []string{"ALTER TABLE users RENAME COLUMN name TO display_name"}
+	if err := verify(known, recorded(known[0], changed)); err == nil {
+		t.Fatal("expect changed migration refused")
+	}
+
+	revised := known[0]
+	revised.Revision = 1
+	if err := verify([]Migration{revised}, recorded(known[0])); err == nil {
+		t.Fatal("expect migration applied with the previous funcs refused")
+	}
+}
+
+func TestChecksumRevision(t *testing.T) {
+	// the checksum recorded before the revision is introduced
+	expected := "4867b4c1271a2bd6b61fc933462afa52b360b421c683c77d8df7e373be518a9e"
+	if checksum := known[0].Checksum(); checksum != expected {
+		t.Fatalf("expect checksum of revision zero unchanged, got %s", checksum)
+	}
+}
+
+func TestPending(t *testing.T) {
+	if result := pending(known, recorded(known[0], known[2]), 3); len(result) != 1 || result[0].Version != 2 {
+		t.Fatalf("unexpected pending %v", result)
+	}
+	if result := pending(known, recorded(known[0]), 2); len(result) != 1 || result[0].Version != 2 {
+		t.Fatalf("expect pending up to version 2, got %v", result)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := validate(migrations); err != nil {
+		t.Fatal(err)
+	}
+	if validate([]Migration{known[1], known[0]}) == nil {
+		t.Fatal("expect migrations out of order invalid")
+	}
+	if validate([]Migration{known[0], known[0]}) == nil {
+		t.Fatal("expect duplicate versions invalid")
+	}
+	if known[1].Checksum() == known[2].Checksum() {
+		t.Fatal("expect checksums of migrations differ")
+	}
+}
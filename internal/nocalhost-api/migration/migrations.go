@@ -0,0 +1,102 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package migration
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+// migrations in order of version, the applied ones must not be changed, add
+// a new version to rename, backfill or drop instead, this applies to the
+// Migrate and Rollback funcs as well, see Migration
+var migrations = []Migration{
+	{
+		// the tables created by AutoMigrate before the versioned migrations,
+		// existing databases are migrated by it as well.
+		//
+		// It is the only one not frozen, a new database is created with the
+		// current models, so the columns must still be added by a new version
+		// for the existing ones, and later versions must not expect a column
+		// dropped from the models to exist
+		Version: 1,
+		Name:    "baseline",
+		Migrate: model.MigrateDB,
+	},
+	{
+		Version: 2,
+		Name:    "leader_leases",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.LeaderLeaseModel{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists(&model.LeaderLeaseModel{}).Error
+		},
+	},
+	{
+		Version: 3,
+		Name:    "dev_space_activity",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.ClusterUserModel{}).Error
+		},
+		Down: []string{
+			"ALTER TABLE clusters_users DROP COLUMN sync_at",
+			"ALTER TABLE clusters_users DROP COLUMN port_forward_at",
+			"ALTER TABLE clusters_users DROP COLUMN kubectl_at",
+		},
+	},
+	{
+		Version: 4,
+		Name:    "cost_samples",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.CostSampleModel{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists(&model.CostSampleModel{}).Error
+		},
+	},
+	{
+		Version: 5,
+		Name:    "dev_images",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.DevImageModel{}).Error
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.DropTableIfExists(&model.DevImageModel{}).Error
+		},
+	},
+	{
+		Version: 6,
+		Name:    "totp_last_step",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&model.UserBaseModel{}).Error
+		},
+		Down: []string{
+			"ALTER TABLE users DROP COLUMN totp_last_step",
+		},
+	},
+	{
+		// the tokens were revoked by the unix seconds of token_revoked_at, the
+		// tokens signed before have no generation, so the ones of the users
+		// revoked are revoked again. The baseline of a new database does not
+		// create token_revoked_at since the model changed, nothing to backfill
+		Version: 7,
+		Name:    "token_generation",
+		Migrate: func(tx *gorm.DB) error {
+			if err := tx.AutoMigrate(&model.UserBaseModel{}).Error; err != nil {
+				return err
+			}
+			if !tx.Dialect().HasColumn("users", "token_revoked_at") {
+				return nil
+			}
+			return tx.Exec("UPDATE users SET token_generation = 1 WHERE token_revoked_at > 0").Error
+		},
+		Down: []string{
+			"ALTER TABLE users DROP COLUMN token_generation",
+		},
+	},
+}
@@ -0,0 +1,54 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// AccessTokenScopeRead only allow the read-only requests
+	AccessTokenScopeRead = "read"
+	// AccessTokenScopeWrite allow all requests
+	AccessTokenScopeWrite = "write"
+)
+
+// AccessTokenModel personal access token for CI and scripting,
+// only the sha256 hash of the token is stored
+type AccessTokenModel struct {
+	ID         uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	UserId     uint64     `gorm:"column:user_id;index:idx_access_token_user;not null" json:"user_id"`
+	Name       string     `gorm:"column:name;not null" json:"name"`
+	TokenHash  string     `gorm:"column:token_hash;UNIQUE_INDEX:uidx_access_token_hash;not null" json:"-"`
+	Prefix     string     `gorm:"column:prefix" json:"prefix"`
+	Scopes     string     `gorm:"column:scopes" json:"scopes"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at" json:"-"`
+	DeletedAt  *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+// IsExpired
+func (t *AccessTokenModel) IsExpired() bool {
+	return t.ExpiresAt != nil && t.ExpiresAt.Before(time.Now())
+}
+
+// HasScope
+func (t *AccessTokenModel) HasScope(scope string) bool {
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if s == scope || s == AccessTokenScopeWrite {
+			return true
+		}
+	}
+	return false
+}
+
+// TableName
+func (t *AccessTokenModel) TableName() string {
+	return "access_tokens"
+}
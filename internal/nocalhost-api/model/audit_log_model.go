@@ -0,0 +1,62 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// AuditLogModel records a mutation made through the api
+type AuditLogModel struct {
+	ID uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+
+	// UserId is zero if the request is not authenticated
+	UserId uint64 `gorm:"column:user_id;index:idx_audit_log_user" json:"user_id"`
+
+	// ActorId is the admin impersonating the user, zero if not impersonated
+	ActorId uint64 `gorm:"column:actor_id" json:"actor_id"`
+
+	// Action is the method with the route, such as 'DELETE /v1/users/:id'
+	Action     string `gorm:"column:action;index:idx_audit_log_action;not null" json:"action"`
+	Path       string `gorm:"column:path;not null" json:"path"`
+	ResourceId string `gorm:"column:resource_id" json:"resource_id"`
+	Ip         string `gorm:"column:ip" json:"ip"`
+	UserAgent  string `gorm:"column:user_agent" json:"user_agent"`
+	RequestId  string `gorm:"column:request_id" json:"request_id"`
+
+	// Code is the errno code responded, zero means succeeded
+	Code int `gorm:"column:code" json:"code"`
+
+	// Payload is the request body with the sensitive fields redacted
+	Payload string `gorm:"column:payload;type:text" json:"payload"`
+
+	// Diff is the changed fields of the payload against the resource before
+	Diff string `gorm:"column:diff;type:text" json:"diff"`
+
+	CreatedAt time.Time `gorm:"column:created_at;index:idx_audit_log_created" json:"created_at"`
+}
+
+// AuditLogQuery filters the audit logs, zero value matches all
+type AuditLogQuery struct {
+	UserId   uint64
+	ActorId  uint64
+	Action   string
+	Resource string
+	Ip       string
+	From     *time.Time
+	To       *time.Time
+
+	// Failed only returns the failed requests if true
+	Failed bool
+
+	Page int
+	Size int
+}
+
+// TableName
+func (a *AuditLogModel) TableName() string {
+	return "audit_logs"
+}
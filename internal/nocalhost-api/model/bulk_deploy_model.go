@@ -0,0 +1,42 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// Action and status of bulk deployment
+const (
+	BulkDeployInstall = "install"
+	BulkDeployUpgrade = "upgrade"
+
+	BulkDeployRunning  = "running"
+	BulkDeployFinished = "finished"
+)
+
+// BulkDeployModel the application installed or upgraded in many dev spaces
+// by admin, the result of each dev space is recorded as DevSpaceAppInstallModel
+type BulkDeployModel struct {
+	ID            uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	ApplicationId uint64 `gorm:"column:application_id;not null" json:"application_id"`
+	// Action install or upgrade
+	Action string `gorm:"column:action;type:VARCHAR(16);not null" json:"action"`
+	// Concurrency the max number of dev spaces deploying at the same time
+	Concurrency int    `gorm:"column:concurrency;not null" json:"concurrency"`
+	Overlay     string `gorm:"column:overlay;type:VARCHAR(100)" json:"overlay"`
+	// json array of image overrides
+	Images    string    `gorm:"column:images;type:VARCHAR(1024)" json:"images"`
+	Status    string    `gorm:"column:status;type:VARCHAR(16)" json:"status"`
+	UserId    uint64    `gorm:"column:user_id;not null" json:"user_id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName
+func (b *BulkDeployModel) TableName() string {
+	return "bulk_deploys"
+}
@@ -0,0 +1,39 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// ClusterAgentModel is the registration of an agent deployed in the
+// cluster which nocalhost-api can not reach, the cluster is created
+// when the agent connects at the first time, only the sha256 hash of
+// the registration token is stored
+type ClusterAgentModel struct {
+	ID           uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Name         string `gorm:"column:name;not null" json:"name"`
+	StorageClass string `gorm:"column:storage_class" json:"storage_class"`
+	// ExtraApiServer is required for the users to access the cluster
+	ExtraApiServer string     `gorm:"column:extra_api_server" json:"extra_api_server"`
+	UserId         uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	ClusterId      uint64     `gorm:"column:cluster_id;default:0" json:"cluster_id"`
+	TokenHash      string     `gorm:"column:token_hash;UNIQUE_INDEX:uidx_cluster_agent_token;not null" json:"-"`
+	Prefix         string     `gorm:"column:prefix" json:"prefix"`
+	ConnectedAt    *time.Time `gorm:"column:connected_at" json:"connected_at"`
+	Message        string     `gorm:"column:message" json:"message"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"column:updated_at" json:"-"`
+	DeletedAt      *time.Time `gorm:"column:deleted_at" json:"-"`
+
+	// whether the tunnel is connected to this replica of api
+	Connected bool `gorm:"-" json:"connected"`
+}
+
+// TableName
+func (a *ClusterAgentModel) TableName() string {
+	return "cluster_agents"
+}
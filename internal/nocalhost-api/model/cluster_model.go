@@ -6,10 +6,13 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	v1 "k8s.io/api/core/v1"
 	"time"
 
 	validator "github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
 )
 
 type ClusterPack interface {
@@ -68,11 +71,27 @@ type ClusterModel struct {
 	UserId         uint64     `gorm:"column:user_id;not null" json:"user_id"`
 	Server         string     `gorm:"column:server;not null" json:"server"`
 	ExtraApiServer string     `gorm:"column:extra_api_server" json:"extra_api_server"`
-	KubeConfig     string     `json:"kubeconfig" gorm:"column:kubeconfig;not null" binding:"required"`
+	KubeConfig     string     `json:"kubeconfig" gorm:"column:kubeconfig;not null" binding:"required" encrypted:"true"`
 	StorageClass   string     `json:"storage_class" gorm:"column:storage_class;not null;type:VARCHAR(100);comment:'empty means use default storage class'"`
 	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt      time.Time  `gorm:"column:updated_at" json:"-"`
 	DeletedAt      *time.Time `gorm:"column:deleted_at" json:"-"`
+
+	// expiry of the credential in kubeconfig, checked in background
+	KubeConfigExpireAt *time.Time `gorm:"column:kubeconfig_expire_at" json:"kubeconfig_expire_at"`
+	KubeConfigStatus   string     `gorm:"column:kubeconfig_status;type:VARCHAR(32)" json:"kubeconfig_status"`
+	KubeConfigMessage  string     `gorm:"column:kubeconfig_message" json:"kubeconfig_message"`
+
+	// result of the background health probe
+	HealthStatus    string     `gorm:"column:health_status;type:VARCHAR(32)" json:"health_status"`
+	HealthMessage   string     `gorm:"column:health_message" json:"health_message"`
+	ServerVersion   string     `gorm:"column:server_version;type:VARCHAR(64)" json:"server_version"`
+	HealthCheckedAt *time.Time `gorm:"column:health_checked_at" json:"health_checked_at"`
+
+	// labels classify the cluster such as env=staging or region=eu, they
+	// can be used to filter clusters and referenced by dev space policies
+	Labels      Labels `gorm:"column:labels;type:TEXT" json:"labels"`
+	Annotations Labels `gorm:"column:annotations;type:TEXT" json:"annotations"`
 }
 
 type ClusterList struct {
@@ -90,6 +109,18 @@ type ClusterList struct {
 	Server          string    `gorm:"column:server;not null" json:"server"`
 	ExtraApiServer  string    `gorm:"column:extra_api_server" json:"extra_api_server"`
 	Modifiable      bool      `json:"modifiable"`
+
+	KubeConfigExpireAt *time.Time `gorm:"column:kubeconfig_expire_at" json:"kubeconfig_expire_at"`
+	KubeConfigStatus   string     `gorm:"column:kubeconfig_status" json:"kubeconfig_status"`
+	KubeConfigMessage  string     `gorm:"column:kubeconfig_message" json:"kubeconfig_message"`
+
+	HealthStatus    string     `gorm:"column:health_status" json:"health_status"`
+	HealthMessage   string     `gorm:"column:health_message" json:"health_message"`
+	ServerVersion   string     `gorm:"column:server_version" json:"server_version"`
+	HealthCheckedAt *time.Time `gorm:"column:health_checked_at" json:"health_checked_at"`
+
+	Labels      Labels `gorm:"column:labels" json:"labels"`
+	Annotations Labels `gorm:"column:annotations" json:"annotations"`
 }
 
 type ClusterListVo struct {
@@ -114,6 +145,40 @@ func (receiver Resource) Equals(resource Resource) bool {
 		receiver.Percentage == resource.Percentage
 }
 
+// Labels is the key value pairs stored as json
+type Labels map[string]string
+
+func (l *Labels) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return errors.Errorf("value is not []byte, value: %v", value)
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, l)
+}
+
+func (l Labels) Value() (driver.Value, error) {
+	if l == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
 // Validate the fields.
 func (cm *ClusterModel) Validate() error {
 	validate := validator.New()
@@ -19,6 +19,14 @@ const (
 	MeshSpace    SpaceType = "MeshSpace"
 )
 
+// Activities of dev space, ActiveAt of dev space is the latest of them
+const (
+	ActivityIDE         = "ide"
+	ActivitySync        = "sync"
+	ActivityPortForward = "port_forward"
+	ActivityKubectl     = "kubectl"
+)
+
 var DevSpaceOwnTypeOwner SpaceOwnType = SpaceOwnType{"Owner", 1000}
 var DevSpaceOwnTypeCooperator SpaceOwnType = SpaceOwnType{"Cooperator", 100}
 var DevSpaceOwnTypeViewer SpaceOwnType = SpaceOwnType{"Viewer", 10}
@@ -27,17 +35,22 @@ var None SpaceOwnType = SpaceOwnType{"None", 1}
 type ClusterUserV2 struct {
 
 	// Intrinsic field
-	ID                 uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
-	UserId             uint64    `gorm:"column:user_id;not null" json:"user_id"`
-	ClusterAdmin       *uint64   `gorm:"column:cluster_admin;default:0" json:"cluster_admin"`
-	Namespace          string    `gorm:"column:namespace;not null" json:"namespace"`
-	SpaceName          string    `gorm:"column:space_name;not null;type:VARCHAR(100);comment:'default is application[username]'" json:"space_name"`
-	ClusterId          uint64    `gorm:"column:cluster_id;not null" json:"cluster_id"`
-	IsBaseSpace        bool      `gorm:"column:is_base_space;default:false" json:"is_base_space"`
-	BaseDevSpaceId     uint64    `gorm:"column:base_dev_space_id;default:0" json:"base_dev_space_id"`
-	TraceHeader        Header    `gorm:"cloumn:trace_header;type:VARCHAR(256);" json:"trace_header"`
-	SpaceResourceLimit string    `gorm:"column:space_resource_limit;type:VARCHAR(1024);" json:"space_resource_limit"`
-	CreatedAt          time.Time `gorm:"column:created_at" json:"created_at"`
+	ID                 uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	UserId             uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	ClusterAdmin       *uint64    `gorm:"column:cluster_admin;default:0" json:"cluster_admin"`
+	Namespace          string     `gorm:"column:namespace;not null" json:"namespace"`
+	SpaceName          string     `gorm:"column:space_name;not null;type:VARCHAR(100);comment:'default is application[username]'" json:"space_name"`
+	ClusterId          uint64     `gorm:"column:cluster_id;not null" json:"cluster_id"`
+	IsBaseSpace        bool       `gorm:"column:is_base_space;default:false" json:"is_base_space"`
+	Isolated           bool       `gorm:"column:isolated;default:false" json:"isolated"`
+	BaseDevSpaceId     uint64     `gorm:"column:base_dev_space_id;default:0" json:"base_dev_space_id"`
+	TraceHeader        Header     `gorm:"cloumn:trace_header;type:VARCHAR(256);" json:"trace_header"`
+	SpaceResourceLimit string     `gorm:"column:space_resource_limit;type:VARCHAR(1024);" json:"space_resource_limit"`
+	SleepConfig        string     `gorm:"column:sleep_config;type:VARCHAR(1024);" json:"sleep_config"`
+	SleepReason        string     `gorm:"column:sleep_reason;type:VARCHAR(16);" json:"sleep_reason"`
+	SleepAt            *time.Time `gorm:"column:sleep_at" json:"sleep_at"`
+	ExpireAt           *time.Time `gorm:"column:expire_at" json:"expire_at"`
+	CreatedAt          time.Time  `gorm:"column:created_at" json:"created_at"`
 
 	// ext field
 	*ClusterUserExt
@@ -73,7 +86,7 @@ type ClusterUserModel struct {
 	UserId             uint64     `gorm:"column:user_id;not null" json:"user_id"`
 	SpaceName          string     `gorm:"column:space_name;not null;type:VARCHAR(100);comment:'default is application[username]'" json:"space_name"`
 	ClusterId          uint64     `gorm:"column:cluster_id;not null" json:"cluster_id"`
-	KubeConfig         string     `gorm:"column:kubeconfig;not null" json:"kubeconfig"`
+	KubeConfig         string     `gorm:"column:kubeconfig;not null" json:"kubeconfig" encrypted:"true"`
 	Memory             uint64     `gorm:"column:memory;not null" json:"memory"`
 	Cpu                uint64     `gorm:"column:cpu;not null" json:"cpu"`
 	SpaceResourceLimit string     `gorm:"column:space_resource_limit;type:VARCHAR(1024);" json:"space_resource_limit"`
@@ -81,14 +94,41 @@ type ClusterUserModel struct {
 	Status             *uint64    `gorm:"column:status;default:0" json:"status"`
 	ClusterAdmin       *uint64    `gorm:"column:cluster_admin;default:0" json:"cluster_admin"`
 	Protected          bool       `gorm:"column:protected;default:false" json:"protected"`
+	Isolated           bool       `gorm:"column:isolated;default:false" json:"isolated"`
 	IsBaseSpace        bool       `gorm:"column:is_base_space;default:false" json:"is_base_space"`
 	BaseDevSpaceId     uint64     `gorm:"column:base_dev_space_id;default:0" json:"base_dev_space_id"`
 	TraceHeader        Header     `gorm:"cloumn:trace_header;type:VARCHAR(256);" json:"trace_header"`
+	SleepConfig        string     `gorm:"column:sleep_config;type:VARCHAR(1024);" json:"sleep_config"`
+	SleepReason        string     `gorm:"column:sleep_reason;type:VARCHAR(16);" json:"sleep_reason"`
+	SleepAt            *time.Time `gorm:"column:sleep_at" json:"sleep_at"`
+	WakeAt             *time.Time `gorm:"column:wake_at" json:"wake_at"`
+	ActiveAt           *time.Time `gorm:"column:active_at" json:"active_at"`
+	SyncAt             *time.Time `gorm:"column:sync_at" json:"sync_at"`
+	PortForwardAt      *time.Time `gorm:"column:port_forward_at" json:"port_forward_at"`
+	KubectlAt          *time.Time `gorm:"column:kubectl_at" json:"kubectl_at"`
+	ExpireAt           *time.Time `gorm:"column:expire_at" json:"expire_at"`
+	ExpiryWarnedAt     *time.Time `gorm:"column:expiry_warned_at" json:"-"`
 	CreatedAt          time.Time  `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt          time.Time  `gorm:"column:updated_at" json:"-"`
 	DeletedAt          *time.Time `gorm:"column:deleted_at" json:"-"`
 }
 
+// LastActiveAt the latest activity of dev space, the creation if none
+func (cu *ClusterUserModel) LastActiveAt() time.Time {
+	last := cu.CreatedAt
+	for _, t := range []*time.Time{cu.ActiveAt, cu.WakeAt, cu.SyncAt, cu.PortForwardAt, cu.KubectlAt} {
+		if t != nil && t.After(last) {
+			last = *t
+		}
+	}
+	return last
+}
+
+// IsAsleep the workloads of dev space are scaled to zero
+func (cu *ClusterUserModel) IsAsleep() bool {
+	return cu != nil && cu.SleepAt != nil
+}
+
 func (cu *ClusterUserModel) IsClusterAdmin() bool {
 	return cu != nil && cu.ClusterAdmin != nil && *cu.ClusterAdmin != uint64(0)
 }
@@ -0,0 +1,36 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import "time"
+
+// CostSampleModel the resources requested by the pods of a dev space in a
+// sample interval, in core hours and GiB hours
+type CostSampleModel struct {
+	ID             uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	DevSpaceId     uint64    `gorm:"column:dev_space_id;not null" json:"dev_space_id"`
+	ClusterId      uint64    `gorm:"column:cluster_id;not null" json:"cluster_id"`
+	UserId         uint64    `gorm:"column:user_id;not null" json:"user_id"`
+	Namespace      string    `gorm:"column:namespace;not null" json:"namespace"`
+	CpuCoreHours   float64   `gorm:"column:cpu_core_hours;not null" json:"cpu_core_hours"`
+	MemoryGibHours float64   `gorm:"column:memory_gib_hours;not null" json:"memory_gib_hours"`
+	SampledAt      time.Time `gorm:"column:sampled_at;index:idx_cost_sample_time" json:"sampled_at"`
+}
+
+// TableName
+func (s *CostSampleModel) TableName() string {
+	return "cost_samples"
+}
+
+// CostSum the sum of samples of a dev space in a period
+type CostSum struct {
+	DevSpaceId     uint64  `gorm:"column:dev_space_id" json:"dev_space_id"`
+	ClusterId      uint64  `gorm:"column:cluster_id" json:"cluster_id"`
+	UserId         uint64  `gorm:"column:user_id" json:"user_id"`
+	Namespace      string  `gorm:"column:namespace" json:"namespace"`
+	CpuCoreHours   float64 `gorm:"column:cpu_core_hours" json:"cpu_core_hours"`
+	MemoryGibHours float64 `gorm:"column:memory_gib_hours" json:"memory_gib_hours"`
+}
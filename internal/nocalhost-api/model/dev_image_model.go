@@ -0,0 +1,34 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// DevImageModel the dev image approved by platform teams, the catalog of them
+// is distributed to the namespace of every dev space for nhctl dev start
+type DevImageModel struct {
+	ID          uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Name        string `gorm:"column:name;not null;type:VARCHAR(100)" json:"name"`
+	Language    string `gorm:"column:language;not null;type:VARCHAR(32);index:idx_dev_image_language" json:"language"`
+	Image       string `gorm:"column:image;not null;type:VARCHAR(512)" json:"image"`
+	Shell       string `gorm:"column:shell;type:VARCHAR(100)" json:"shell"`
+	DebugPort   int    `gorm:"column:debug_port" json:"debug_port"`
+	WorkDir     string `gorm:"column:work_dir;type:VARCHAR(255)" json:"work_dir"`
+	Description string `gorm:"column:description;type:VARCHAR(512)" json:"description"`
+	// Sort the images of a language are sorted by it, the first is the default
+	Sort      int        `gorm:"column:sort;default:0" json:"sort"`
+	UserId    uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+// TableName
+func (i *DevImageModel) TableName() string {
+	return "dev_images"
+}
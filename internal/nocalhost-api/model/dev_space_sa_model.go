@@ -0,0 +1,47 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// DevSpaceSaRoleEditor has full access to the namespace of dev space
+	DevSpaceSaRoleEditor = "editor"
+	// DevSpaceSaRoleViewer can only read the resources and port-forward
+	DevSpaceSaRoleViewer = "viewer"
+)
+
+// DevSpaceSaModel is a service account created in the namespace of a dev space,
+// the kubeconfig of it can only access the resources of that namespace
+type DevSpaceSaModel struct {
+	ID          uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	DevSpaceId  uint64     `gorm:"column:dev_space_id;index:idx_dev_space_sa_space;not null" json:"dev_space_id"`
+	ClusterId   uint64     `gorm:"column:cluster_id;not null" json:"cluster_id"`
+	Namespace   string     `gorm:"column:namespace;not null" json:"namespace"`
+	Name        string     `gorm:"column:name;not null" json:"name"`
+	Description string     `gorm:"column:description" json:"description"`
+	Role        string     `gorm:"column:role;default:'editor'" json:"role"`
+	UserId      uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	RotatedAt   *time.Time `gorm:"column:rotated_at" json:"rotated_at"`
+	RevokedAt   *time.Time `gorm:"column:revoked_at" json:"-"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName
+func (s *DevSpaceSaModel) TableName() string {
+	return "dev_space_service_accounts"
+}
+
+// GenerateDevSpaceSaName returns a random service account name for dev space
+func GenerateDevSpaceSaName() string {
+	return fmt.Sprintf("nocalhost-scoped-%s", strings.Split(uuid.NewV4().String(), "-")[0])
+}
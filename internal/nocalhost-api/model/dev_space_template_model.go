@@ -0,0 +1,57 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// DevSpaceTemplateModel dev spaces created from the template have its resource
+// limit, env, secrets and applications installed
+type DevSpaceTemplateModel struct {
+	ID          uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Name        string `gorm:"column:name;not null;type:VARCHAR(100)" json:"name"`
+	Description string `gorm:"column:description;type:VARCHAR(512)" json:"description"`
+	// json of cluster_user.SpaceResourceLimit
+	SpaceResourceLimit string `gorm:"column:space_resource_limit;type:VARCHAR(1024);" json:"space_resource_limit"`
+	// json array of application ids
+	Applications string `gorm:"column:applications;type:VARCHAR(1024);" json:"applications"`
+	// json object of env vars
+	Env string `gorm:"column:env;type:TEXT;" json:"env"`
+	// json array of secrets to copy
+	Secrets   string     `gorm:"column:secrets;type:TEXT;" json:"secrets"`
+	UserId    uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+// TableName
+func (t *DevSpaceTemplateModel) TableName() string {
+	return "dev_space_templates"
+}
+
+// DevSpaceAppInstallModel the application installed in dev space by the job
+// of nhctl when the dev space is created from template or deployed in bulk,
+// the status is queued, pending, installing, installed or failed
+type DevSpaceAppInstallModel struct {
+	ID              uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	DevSpaceId      uint64    `gorm:"column:dev_space_id;index:idx_dev_space_app_install_space;not null" json:"dev_space_id"`
+	TemplateId      uint64    `gorm:"column:template_id;not null" json:"template_id"`
+	BulkDeployId    uint64    `gorm:"column:bulk_deploy_id;index:idx_dev_space_app_install_bulk" json:"bulk_deploy_id"`
+	ApplicationId   uint64    `gorm:"column:application_id;not null" json:"application_id"`
+	ApplicationName string    `gorm:"column:application_name;type:VARCHAR(100)" json:"application_name"`
+	JobName         string    `gorm:"column:job_name;type:VARCHAR(100)" json:"job_name"`
+	Status          string    `gorm:"column:status;type:VARCHAR(16)" json:"status"`
+	Message         string    `gorm:"column:message;type:VARCHAR(1024)" json:"message"`
+	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName
+func (i *DevSpaceAppInstallModel) TableName() string {
+	return "dev_space_app_installs"
+}
@@ -0,0 +1,148 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+)
+
+// Driver the database driver of config database.driver, mysql by default, the
+// connection is configured in the section of the driver, e.g. postgres.addr
+// or sqlite3.path
+func Driver() string {
+	switch driver := strings.ToLower(viper.GetString("database.driver")); driver {
+	case "":
+		return DriverMySQL
+	case "sqlite":
+		return DriverSQLite
+	default:
+		return driver
+	}
+}
+
+// dataSource the dsn of driver
+func dataSource(driver, username, password, addr, name string) (string, error) {
+	switch driver {
+	case DriverMySQL:
+		return fmt.Sprintf(
+			"%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=%t&loc=%s",
+			username,
+			password,
+			addr,
+			name,
+			true,
+			//"Asia/Shanghai"),
+			"Local",
+		), nil
+	case DriverPostgres:
+		sslMode := viper.GetString("postgres.sslmode")
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(username, password),
+			Host:     addr,
+			Path:     "/" + name,
+			RawQuery: url.Values{"sslmode": []string{sslMode}}.Encode(),
+		}
+		return u.String(), nil
+	case DriverSQLite:
+		// the file of database, the writers wait for the lock instead of failing
+		path := viper.GetString("sqlite3.path")
+		if path == "" {
+			path = "data/nocalhost.db"
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return "", errors.Wrap(err, "")
+		}
+		return fmt.Sprintf("file:%s?_journal_mode=WAL&_busy_timeout=5000", path), nil
+	default:
+		return "", errors.New(fmt.Sprintf("unsupported database driver %s", driver))
+	}
+}
+
+// the build tags of drivers, mysql is always built in
+var driverTags = map[string]string{
+	DriverPostgres: "postgres",
+	DriverSQLite:   "sqlite",
+}
+
+// driverBuiltIn whether the sql driver is registered, the drivers other than
+// mysql are built with the tags of them
+func driverBuiltIn(driver string) bool {
+	for _, d := range sql.Drivers() {
+		if d == driver {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPostgres whether db is postgres
+func IsPostgres(db *gorm.DB) bool {
+	return db.Dialect().GetName() == DriverPostgres
+}
+
+// IsSQLite whether db is sqlite
+func IsSQLite(db *gorm.DB) bool {
+	return db.Dialect().GetName() == DriverSQLite
+}
+
+// ForUpdate lock the rows queried until the transaction ends, sqlite locks
+// the database on writing and does not support it
+func ForUpdate(db *gorm.DB) *gorm.DB {
+	if IsSQLite(db) {
+		return db
+	}
+	return db.Set("gorm:query_option", "FOR UPDATE")
+}
+
+// OnConflictKeep insert the values if not exists by the unique columns, or
+// keep the existing row
+func OnConflictKeep(db *gorm.DB, columns ...string) *gorm.DB {
+	if IsPostgres(db) || IsSQLite(db) {
+		quoted := make([]string, 0, len(columns))
+		for _, column := range columns {
+			quoted = append(quoted, db.Dialect().Quote(column))
+		}
+		// do nothing returns no row, update the conflict column as it is
+		return db.Set(
+			"gorm:insert_option", fmt.Sprintf(
+				"ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s",
+				strings.Join(quoted, ","), quoted[0], quoted[0],
+			),
+		)
+	}
+	return db.Set("gorm:insert_option", "ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)")
+}
+
+// JSONContains the condition of the json object in column contains the field
+// of value bound, the column is in type text
+func JSONContains(db *gorm.DB, column, field string) string {
+	if IsSQLite(db) {
+		return fmt.Sprintf("json_extract(%s, '$.%s') = ?", column, field)
+	}
+	if IsPostgres(db) {
+		return fmt.Sprintf("%s::jsonb @> jsonb_build_object('%s', ?::text)", column, field)
+	}
+	return fmt.Sprintf("JSON_CONTAINS(%s,JSON_OBJECT('%s', ?))", column, field)
+}
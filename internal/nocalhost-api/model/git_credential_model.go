@@ -0,0 +1,37 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	// GitCredentialSsh the secret is a private key of ssh
+	GitCredentialSsh = "ssh"
+	// GitCredentialHttps the secret is a token or password of https
+	GitCredentialHttps = "https"
+)
+
+// GitCredentialModel the credential of private git repos, applications refer
+// to it by credential_id of context, the secret is never returned by list or get
+type GitCredentialModel struct {
+	ID          uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Name        string     `gorm:"column:name;not null;type:VARCHAR(100)" json:"name"`
+	Description string     `gorm:"column:description;type:VARCHAR(512)" json:"description"`
+	Type        string     `gorm:"column:type;not null;type:VARCHAR(16)" json:"type"`
+	Username    string     `gorm:"column:username;type:VARCHAR(100)" json:"username"`
+	Secret      string     `gorm:"column:secret;type:TEXT" json:"-" encrypted:"true"`
+	UserId      uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt   *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+// TableName
+func (g *GitCredentialModel) TableName() string {
+	return "git_credentials"
+}
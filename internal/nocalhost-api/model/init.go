@@ -11,57 +11,67 @@ import (
 
 	"github.com/spf13/viper"
 
-	// MySQL driver.
 	"github.com/jinzhu/gorm"
-	// GORM MySQL
-	_ "github.com/jinzhu/gorm/dialects/mysql"
+
+	"nocalhost/pkg/nocalhost-api/pkg/encryption"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 )
 
 var DB *gorm.DB
 
 // InitDir
 func Init() *gorm.DB {
-	return openDB(
-		viper.GetString("mysql.username"),
-		viper.GetString("mysql.password"),
-		viper.GetString("mysql.addr"),
-		viper.GetString("mysql.name"),
+	driver := Driver()
+	prefix := driver + "."
+	source, err := dataSource(
+		driver,
+		viper.GetString(prefix+"username"),
+		viper.GetString(prefix+"password"),
+		viper.GetString(prefix+"addr"),
+		viper.GetString(prefix+"name"),
 	)
+	if err != nil {
+		panic(err)
+	}
+	return openDB(driver, source, prefix)
 }
 
 // openDB
-func openDB(username, password, addr, name string) *gorm.DB {
-	config := fmt.Sprintf(
-		"%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=%t&loc=%s",
-		username,
-		password,
-		addr,
-		name,
-		true,
-		//"Asia/Shanghai"),
-		"Local",
-	)
+func openDB(driver, source, prefix string) *gorm.DB {
+	if !driverBuiltIn(driver) {
+		panic(fmt.Sprintf("database driver %s is not built in, build with tags %s", driver, driverTags[driver]))
+	}
 
-	db, err := gorm.Open("mysql", config)
+	db, err := gorm.Open(driver, source)
 	if err != nil {
-		println("fuck")
-		//log.Errorf("Database connection failed. Database name: %s, err: %+v", name, err)
+		log.Errorf("Database connection failed. Database driver: %s, err: %+v", driver, err)
 		panic(err)
 	}
 
-	db.Set("gorm:table_options", "CHARSET=utf8mb4")
+	if driver == DriverMySQL {
+		db.Set("gorm:table_options", "CHARSET=utf8mb4")
+	}
 
 	// set for db connection
-	db.LogMode(viper.GetBool("mysql.show_log"))
+	db.LogMode(viper.GetBool(prefix + "show_log"))
 	// To set the maximum number of open connections, replace with 0 to indicate unlimited.
 	// Setting the maximum number of connections can avoid too high concurrency
 	// leading to too many connection errors when connecting to mysql.
-	db.DB().SetMaxOpenConns(viper.GetInt("mysql.max_open_conn"))
+	db.DB().SetMaxOpenConns(viper.GetInt(prefix + "max_open_conn"))
 	// Used to set the number of idle connections. When the number of idle connections
 	// is set, the opened connection can be placed in the pool for the next use.
-	db.DB().SetMaxIdleConns(viper.GetInt("mysql.max_idle_conn"))
-	db.DB().SetConnMaxLifetime(time.Minute * viper.GetDuration("mysql.conn_max_life_time"))
+	db.DB().SetMaxIdleConns(viper.GetInt(prefix + "max_idle_conn"))
+	db.DB().SetConnMaxLifetime(time.Minute * viper.GetDuration(prefix+"conn_max_life_time"))
 
+	// the secrets are encrypted at rest by the keys of encryption.keys
+	if err := encryption.Init(); err != nil {
+		panic(err)
+	}
+	encryption.RegisterGormCallbacks(db)
+	metrics.RegisterGormCallbacks(db)
+	trace.RegisterGormCallbacks(db)
 	DB = db
 
 	return db
@@ -72,10 +82,36 @@ func GetDB() *gorm.DB {
 	return DB
 }
 
-// MigrateDB
-func MigrateDB() {
-	DB.AutoMigrate(
+// MigrateDB create the tables of models and add the missing columns, it is the
+// baseline of the versioned migrations
+func MigrateDB(db *gorm.DB) error {
+	if err := db.AutoMigrate(
 		&ApplicationModel{}, &ClusterModel{}, &ClusterUserModel{}, &PrePullModel{}, &UserBaseModel{},
-		&ApplicationUserModel{}, &LdapModel{},
-	)
+		&ApplicationUserModel{}, &LdapModel{}, &AccessTokenModel{},
+		&PasswordResetModel{}, &PasswordHistoryModel{}, &LoginAttemptModel{}, &RoleModel{}, &RoleBindingModel{},
+		&TeamModel{}, &TeamMemberModel{}, &TeamGrantModel{}, &AuditLogModel{}, &SessionModel{}, &QuotaModel{},
+		&DevSpaceSaModel{},
+		&ClusterAgentModel{},
+		&DevSpaceTemplateModel{},
+		&DevSpaceAppInstallModel{},
+		&GitCredentialModel{},
+		&BulkDeployModel{},
+		&RegistryCredentialModel{},
+	).Error; err != nil {
+		return err
+	}
+
+	// argon2id hash is longer than the bcrypt one, sqlite can not alter the
+	// columns, and the strings are varchar(255) since created
+	if IsSQLite(db) {
+		return nil
+	}
+	if IsPostgres(db) {
+		// postgres alters the type only, and the uuid is stored in the native type
+		if err := db.Model(&UserBaseModel{}).ModifyColumn("password", "varchar(255)").Error; err != nil {
+			return err
+		}
+		return db.Model(&UserBaseModel{}).ModifyColumn("uuid", "uuid USING uuid::uuid").Error
+	}
+	return db.Model(&UserBaseModel{}).ModifyColumn("password", "varchar(255) NOT NULL DEFAULT ''").Error
 }
@@ -19,6 +19,11 @@ type LdapModel struct {
 	EmailAttr    string `gorm:"column:email_attr" json:"email_attr"`
 	UserNameAttr string `gorm:"column:user_name_attr" json:"user_name_attr"`
 
+	// group attr of user entry, such as memberOf, user in any of the
+	// admin groups (comma-separated group DNs) will be marked as admin
+	GroupAttr   string `gorm:"column:group_attr" json:"group_attr"`
+	AdminGroups string `gorm:"column:admin_groups;type:text" json:"admin_groups"`
+
 	Enable *uint64 `gorm:"column:enable" json:"enable"`
 
 	CreatedAt time.Time  `gorm:"column:created_at" json:"created_at"`
@@ -0,0 +1,22 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import "time"
+
+// LeaderLeaseModel the lease of leader held by a replica of nocalhost-api,
+// the replica holding it runs the background jobs until it expires
+type LeaderLeaseModel struct {
+	Name      string    `gorm:"primary_key;column:name;type:VARCHAR(64)" json:"name"`
+	Holder    string    `gorm:"column:holder;type:VARCHAR(128);not null" json:"holder"`
+	ExpireAt  time.Time `gorm:"column:expire_at" json:"expire_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// TableName
+func (l *LeaderLeaseModel) TableName() string {
+	return "leader_leases"
+}
@@ -0,0 +1,59 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginAttemptModel record the continuous login failures of user or ip
+type LoginAttemptModel struct {
+	ID          uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Key         string     `gorm:"column:key;UNIQUE_INDEX:uidx_login_attempt_key;not null" json:"key"`
+	Failures    int        `gorm:"column:failures" json:"failures"`
+	LockedUntil *time.Time `gorm:"column:locked_until" json:"locked_until"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"-"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func LoginAttemptUserKey(userId uint64) string {
+	return fmt.Sprintf("user:%d", userId)
+}
+
+func LoginAttemptIpKey(ip string) string {
+	return fmt.Sprintf("ip:%s", ip)
+}
+
+// IsLocked
+func (a *LoginAttemptModel) IsLocked() bool {
+	return a.LockedUntil != nil && a.LockedUntil.After(time.Now())
+}
+
+// Fail increase the failures, lock it with exponential backoff
+// if the failures reach the threshold
+func (a *LoginAttemptModel) Fail(threshold int, lock, maxLock time.Duration) {
+	a.Failures++
+	if threshold <= 0 || a.Failures < threshold {
+		return
+	}
+
+	duration := lock
+	for i := threshold; i < a.Failures && duration < maxLock; i++ {
+		duration *= 2
+	}
+	if duration > maxLock {
+		duration = maxLock
+	}
+
+	lockedUntil := time.Now().Add(duration)
+	a.LockedUntil = &lockedUntil
+}
+
+// TableName
+func (a *LoginAttemptModel) TableName() string {
+	return "login_attempts"
+}
@@ -0,0 +1,11 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	// GORM MySQL
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+)
@@ -0,0 +1,22 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import "time"
+
+// PasswordHistoryModel the bcrypt hash of the passwords used before,
+// to prevent users from reusing the recent passwords
+type PasswordHistoryModel struct {
+	ID        uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	UserId    uint64    `gorm:"column:user_id;index:idx_password_history_user;not null" json:"user_id"`
+	Password  string    `gorm:"column:password;not null" json:"-"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName
+func (p *PasswordHistoryModel) TableName() string {
+	return "password_histories"
+}
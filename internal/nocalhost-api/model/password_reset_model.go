@@ -0,0 +1,29 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import "time"
+
+// PasswordResetModel one-time token for resetting password, only the
+// sha256 hash of the token is stored
+type PasswordResetModel struct {
+	ID        uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	UserId    uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	TokenHash string     `gorm:"column:token_hash;UNIQUE_INDEX:uidx_password_reset_hash;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"-"`
+}
+
+// IsValid
+func (p *PasswordResetModel) IsValid() bool {
+	return p.UsedAt == nil && p.ExpiresAt.After(time.Now())
+}
+
+// TableName
+func (p *PasswordResetModel) TableName() string {
+	return "password_resets"
+}
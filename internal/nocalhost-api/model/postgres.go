@@ -0,0 +1,14 @@
+//go:build postgres
+// +build postgres
+
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	// GORM PostgreSQL
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+)
@@ -0,0 +1,66 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	QuotaSubjectUser = "user"
+	QuotaSubjectTeam = "team"
+)
+
+// QuotaModel limits the resources can be created by the user or the members
+// of team, zero value of the fields means unlimited
+type QuotaModel struct {
+	ID          uint64 `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	SubjectType string `gorm:"column:subject_type;UNIQUE_INDEX:uidx_quota_subject;not null" json:"subject_type"`
+	SubjectId   uint64 `gorm:"column:subject_id;UNIQUE_INDEX:uidx_quota_subject;not null" json:"subject_id"`
+
+	MaxDevSpaces uint64 `gorm:"column:max_dev_spaces" json:"max_dev_spaces"`
+	MaxClusters  uint64 `gorm:"column:max_clusters" json:"max_clusters"`
+
+	// MaxSpaceCpu is in cores and MaxSpaceMemory is in Mi, they limit
+	// the space_limits_cpu and space_limits_mem of each dev space
+	MaxSpaceCpu    float64 `gorm:"column:max_space_cpu" json:"max_space_cpu"`
+	MaxSpaceMemory uint64  `gorm:"column:max_space_memory" json:"max_space_memory"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"-"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// QuotaUsage is the resources created by the user
+type QuotaUsage struct {
+	DevSpaces uint64 `json:"dev_spaces"`
+	Clusters  uint64 `json:"clusters"`
+}
+
+// Merge returns the most permissive quota of both
+func (q QuotaModel) Merge(other QuotaModel) QuotaModel {
+	q.MaxDevSpaces = mostPermissive(q.MaxDevSpaces, other.MaxDevSpaces)
+	q.MaxClusters = mostPermissive(q.MaxClusters, other.MaxClusters)
+	q.MaxSpaceMemory = mostPermissive(q.MaxSpaceMemory, other.MaxSpaceMemory)
+	if q.MaxSpaceCpu != 0 && (other.MaxSpaceCpu == 0 || other.MaxSpaceCpu > q.MaxSpaceCpu) {
+		q.MaxSpaceCpu = other.MaxSpaceCpu
+	}
+	return q
+}
+
+func mostPermissive(a, b uint64) uint64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// TableName
+func (q *QuotaModel) TableName() string {
+	return "quotas"
+}
@@ -0,0 +1,32 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// RegistryCredentialModel the credential of private image registry, it is
+// distributed to the namespace of every dev space as image pull secret, the
+// password is never returned by list or get
+type RegistryCredentialModel struct {
+	ID          uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Name        string     `gorm:"column:name;not null;type:VARCHAR(50)" json:"name"`
+	Description string     `gorm:"column:description;type:VARCHAR(512)" json:"description"`
+	Server      string     `gorm:"column:server;not null;type:VARCHAR(255)" json:"server"`
+	Username    string     `gorm:"column:username;type:VARCHAR(100)" json:"username"`
+	Password    string     `gorm:"column:password;type:TEXT" json:"-" encrypted:"true"`
+	Email       string     `gorm:"column:email;type:VARCHAR(100)" json:"email"`
+	UserId      uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt   *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+// TableName
+func (r *RegistryCredentialModel) TableName() string {
+	return "registry_credentials"
+}
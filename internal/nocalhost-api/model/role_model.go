@@ -0,0 +1,112 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// RoleScopeGlobal role binding take effect on all resources
+	RoleScopeGlobal = "global"
+	// RoleScopeDevSpace role binding only take effect on the dev space
+	RoleScopeDevSpace = "dev_space"
+
+	// PermissionAll can do anything
+	PermissionAll = "*"
+)
+
+// Permissions are in format of 'resource:verb', verb can be 'read' or 'write',
+// and 'resource:*' means all verbs of the resource
+const (
+	PermissionUsersRead         = "users:read"
+	PermissionUsersWrite        = "users:write"
+	PermissionClustersRead      = "clusters:read"
+	PermissionClustersWrite     = "clusters:write"
+	PermissionDevSpacesRead     = "dev_spaces:read"
+	PermissionDevSpacesWrite    = "dev_spaces:write"
+	PermissionApplicationsRead  = "applications:read"
+	PermissionApplicationsWrite = "applications:write"
+	PermissionBillingRead       = "billing:read"
+	PermissionBillingWrite      = "billing:write"
+)
+
+// RoleModel a named set of permissions
+type RoleModel struct {
+	ID          uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Name        string     `gorm:"column:name;UNIQUE_INDEX:uidx_role_name;not null" json:"name"`
+	Description string     `gorm:"column:description" json:"description"`
+	Permissions string     `gorm:"column:permissions;type:text" json:"permissions"`
+	BuiltIn     bool       `gorm:"column:built_in;default:false" json:"built_in"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"-"`
+	DeletedAt   *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+// PermissionList
+func (r *RoleModel) PermissionList() []string {
+	result := make([]string, 0)
+	for _, p := range strings.Split(r.Permissions, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+// Allow returns true if the role contains the permission
+func (r *RoleModel) Allow(permission string) bool {
+	resource := strings.Split(permission, ":")[0]
+	for _, p := range r.PermissionList() {
+		if p == PermissionAll || p == permission || p == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// TableName
+func (r *RoleModel) TableName() string {
+	return "roles"
+}
+
+// RoleBindingModel attach the role to user, globally or on the dev space
+type RoleBindingModel struct {
+	ID        uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	RoleId    uint64    `gorm:"column:role_id;UNIQUE_INDEX:uidx_role_binding;not null" json:"role_id"`
+	UserId    uint64    `gorm:"column:user_id;UNIQUE_INDEX:uidx_role_binding;not null" json:"user_id"`
+	Scope     string    `gorm:"column:scope;UNIQUE_INDEX:uidx_role_binding;not null" json:"scope"`
+	ScopeId   uint64    `gorm:"column:scope_id;UNIQUE_INDEX:uidx_role_binding;default:0" json:"scope_id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName
+func (r *RoleBindingModel) TableName() string {
+	return "role_bindings"
+}
+
+// BuiltInRoles initialized at startup
+var BuiltInRoles = []RoleModel{
+	{
+		Name:        "space-viewer",
+		Description: "View dev spaces and applications",
+		Permissions: strings.Join([]string{PermissionDevSpacesRead, PermissionApplicationsRead}, ","),
+		BuiltIn:     true,
+	},
+	{
+		Name:        "cluster-operator",
+		Description: "Manage clusters and the dev spaces in them",
+		Permissions: strings.Join([]string{"clusters:*", "dev_spaces:*", PermissionApplicationsRead}, ","),
+		BuiltIn:     true,
+	},
+	{
+		Name:        "billing-admin",
+		Description: "Manage billing and view the resource usage",
+		Permissions: strings.Join([]string{"billing:*", PermissionClustersRead, PermissionDevSpacesRead}, ","),
+		BuiltIn:     true,
+	},
+}
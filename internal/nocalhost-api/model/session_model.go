@@ -0,0 +1,37 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+// SessionModel is a login session, the tokens issued by login
+// and refreshed from them are belong to the same session
+type SessionModel struct {
+	ID         uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Sid        string     `gorm:"column:sid;UNIQUE_INDEX:uidx_session_sid;not null" json:"-"`
+	UserId     uint64     `gorm:"column:user_id;index:idx_session_user;not null" json:"user_id"`
+	Device     string     `gorm:"column:device" json:"device"`
+	Ip         string     `gorm:"column:ip" json:"ip"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"-"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+
+	// Current marks the session of the request, not stored
+	Current bool `gorm:"-" json:"current"`
+}
+
+// IsActive
+func (s *SessionModel) IsActive() bool {
+	return s.RevokedAt == nil && s.ExpiresAt.After(time.Now())
+}
+
+// TableName
+func (s *SessionModel) TableName() string {
+	return "sessions"
+}
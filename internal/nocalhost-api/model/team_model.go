@@ -0,0 +1,66 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package model
+
+import (
+	"time"
+)
+
+const (
+	// TeamGrantApplication grant the application to all members of team
+	TeamGrantApplication = "application"
+	// TeamGrantDevSpace share the dev space to all members of team
+	TeamGrantDevSpace = "dev_space"
+
+	// TeamGrantCooperator members can modify the dev space
+	TeamGrantCooperator = "cooperator"
+	// TeamGrantViewer members can only view the dev space
+	TeamGrantViewer = "viewer"
+)
+
+// TeamModel group of users
+type TeamModel struct {
+	ID          uint64     `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	Name        string     `gorm:"column:name;UNIQUE_INDEX:uidx_team_name;not null" json:"name"`
+	Description string     `gorm:"column:description" json:"description"`
+	UserId      uint64     `gorm:"column:user_id;not null" json:"user_id"`
+	CreatedAt   time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"column:updated_at" json:"-"`
+	DeletedAt   *time.Time `gorm:"column:deleted_at" json:"-"`
+}
+
+// TableName
+func (t *TeamModel) TableName() string {
+	return "teams"
+}
+
+// TeamMemberModel
+type TeamMemberModel struct {
+	ID        uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	TeamId    uint64    `gorm:"column:team_id;UNIQUE_INDEX:uidx_team_member;not null" json:"team_id"`
+	UserId    uint64    `gorm:"column:user_id;UNIQUE_INDEX:uidx_team_member;not null" json:"user_id"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName
+func (t *TeamMemberModel) TableName() string {
+	return "team_members"
+}
+
+// TeamGrantModel the application or dev space granted to the team
+type TeamGrantModel struct {
+	ID           uint64    `gorm:"primary_key;AUTO_INCREMENT;column:id" json:"id"`
+	TeamId       uint64    `gorm:"column:team_id;UNIQUE_INDEX:uidx_team_grant;not null" json:"team_id"`
+	ResourceType string    `gorm:"column:resource_type;UNIQUE_INDEX:uidx_team_grant;not null" json:"resource_type"`
+	ResourceId   uint64    `gorm:"column:resource_id;UNIQUE_INDEX:uidx_team_grant;not null" json:"resource_id"`
+	Role         string    `gorm:"column:role" json:"role"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+// TableName
+func (t *TeamGrantModel) TableName() string {
+	return "team_grants"
+}
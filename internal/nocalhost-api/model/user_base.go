@@ -30,6 +30,22 @@ type UserBaseModel struct {
 	LdapDN  string `gorm:"column:ldap_dn" json:"ldap_dn"`
 	LdapGen uint64 `gorm:"column:ldap_gen" json:"ldap_gen"`
 
+	// the tokens signed with a generation before it are revoked, increased by
+	// revoking the tokens of the user
+	TokenGeneration uint64 `gorm:"column:token_generation;default:0" json:"-"`
+
+	// two-factor authentication, secret is set when enrolling
+	// and take effect after activated
+	TotpSecret        string  `gorm:"column:totp_secret" json:"-"`
+	TotpEnabled       *uint64 `gorm:"column:totp_enabled;default:0" json:"totp_enabled"`
+	TotpRecoveryCodes string  `gorm:"column:totp_recovery_codes;type:text" json:"-"`
+	// the time step of the totp code accepted last, the codes at or before it
+	// are rejected to prevent replaying
+	TotpLastStep int64 `gorm:"column:totp_last_step;default:0" json:"-"`
+
+	// max number of clusters the user can add, zero means unlimited
+	ClusterQuota uint64 `gorm:"column:cluster_quota;default:0" json:"cluster_quota"`
+
 	IsAdmin      *uint64    `gorm:"column:is_admin" json:"is_admin"`
 	Status       *uint64    `gorm:"column:status" json:"status"`
 	ClusterAdmin *uint64    `gorm:"column:cluster_admin" json:"cluster_admin"`
@@ -63,6 +79,11 @@ func (u *UserBaseModel) NeedToUpdateProfileInLdap(userName, ldapDN string, admin
 	return bool
 }
 
+// IsTotpEnabled
+func (u *UserBaseModel) IsTotpEnabled() bool {
+	return u.TotpEnabled != nil && *u.TotpEnabled == 1 && u.TotpSecret != ""
+}
+
 // Validate the fields.
 func (u *UserBaseModel) Validate() error {
 	validate := validator.New()
@@ -118,6 +139,30 @@ type UserList struct {
 	IsAdmin      uint64 `gorm:"column:is_admin" json:"is_admin"`
 }
 
+// UserListQuery filters, sorts and pages the user list, zero value lists all users
+type UserListQuery struct {
+	Page int
+	Size int
+
+	// Search matches the email or name fuzzily
+	Search string
+	Status *uint64
+
+	// Sort is one of UserListSortFields, default to id
+	Sort string
+	Desc bool
+}
+
+// UserListSortFields are the fields user list can be sorted by
+var UserListSortFields = map[string]string{
+	"id":            "u.id",
+	"name":          "u.name",
+	"email":         "u.email",
+	"status":        "u.status",
+	"created_at":    "u.created_at",
+	"cluster_count": "cluster_count",
+}
+
 // TableName
 func (u *UserBaseModel) TableName() string {
 	return "users"
@@ -0,0 +1,70 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package access_token
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type AccessTokenRepo struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenRepo(db *gorm.DB) *AccessTokenRepo {
+	return &AccessTokenRepo{
+		db: db,
+	}
+}
+
+func (repo *AccessTokenRepo) Create(ctx context.Context, token model.AccessTokenModel) (model.AccessTokenModel, error) {
+	if err := trace.DB(ctx, repo.db).Create(&token).Error; err != nil {
+		return token, errors.Wrap(err, "[access_token_repo] create access token err")
+	}
+	return token, nil
+}
+
+func (repo *AccessTokenRepo) ListByUserId(ctx context.Context, userId uint64) ([]*model.AccessTokenModel, error) {
+	var result []*model.AccessTokenModel
+	if err := trace.DB(ctx, repo.db).Where("user_id = ?", userId).Order("id desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[access_token_repo] list access token err")
+	}
+	return result, nil
+}
+
+func (repo *AccessTokenRepo) GetByHash(ctx context.Context, hash string) (*model.AccessTokenModel, error) {
+	result := model.AccessTokenModel{}
+	if err := trace.DB(ctx, repo.db).Where("token_hash = ?", hash).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (repo *AccessTokenRepo) Delete(ctx context.Context, userId, id uint64) error {
+	if result := trace.DB(ctx, repo.db).Where("id = ? and user_id = ?", id, userId).
+		Delete(&model.AccessTokenModel{}); result.RowsAffected > 0 {
+		return nil
+	}
+	return errors.New("access token delete fail")
+}
+
+func (repo *AccessTokenRepo) DeleteByUserId(ctx context.Context, userId uint64) error {
+	return trace.DB(ctx, repo.db).Where("user_id = ?", userId).Delete(&model.AccessTokenModel{}).Error
+}
+
+func (repo *AccessTokenRepo) UpdateLastUsed(ctx context.Context, id uint64, lastUsedAt time.Time) {
+	trace.DB(ctx, repo.db).Exec("UPDATE access_tokens SET last_used_at = ? WHERE id = ?", lastUsedAt, id)
+}
+
+func (repo *AccessTokenRepo) Close() {
+	repo.db.Close()
+}
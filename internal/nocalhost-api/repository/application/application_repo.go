@@ -9,6 +9,7 @@ import (
 	"context"
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
@@ -25,7 +26,7 @@ func NewClusterRepo(db *gorm.DB) *ApplicationRepo {
 }
 
 func (repo *ApplicationRepo) PublicSwitch(ctx context.Context, applicationId uint64, public uint8) error {
-	if err := repo.db.Exec(
+	if err := trace.DB(ctx, repo.db).Exec(
 		"UPDATE applications SET public = ? "+
 			"WHERE id = ?", public, applicationId,
 	).Error; err != nil {
@@ -37,7 +38,7 @@ func (repo *ApplicationRepo) PublicSwitch(ctx context.Context, applicationId uin
 
 func (repo *ApplicationRepo) GetByName(ctx context.Context, name string) (model.ApplicationModel, error) {
 	var record model.ApplicationModel
-	result := repo.db.Where("JSON_CONTAINS(context,JSON_OBJECT('application_name', ?))", name).
+	result := trace.DB(ctx, repo.db).Where(model.JSONContains(repo.db, "context", "application_name"), name).
 		First(&record)
 	if result.Error != nil {
 		return record, nil
@@ -49,7 +50,7 @@ func (repo *ApplicationRepo) PluginGetList(ctx context.Context, userId uint64) (
 	[]*model.PluginApplicationModel, error,
 ) {
 	var result []*model.PluginApplicationModel
-	repo.db.Table("applications").
+	trace.DB(ctx, repo.db).Table("applications").
 		Select(
 			"clusters.storage_class,applications.id,applications.context,applications.user_id,"+
 				"applications.status,clusters_users.cluster_id,clusters_users.space_name,clusters_users.kubeconfig,"+
@@ -66,7 +67,7 @@ func (repo *ApplicationRepo) PluginGetList(ctx context.Context, userId uint64) (
 func (repo *ApplicationRepo) Create(ctx context.Context, application model.ApplicationModel) (
 	model.ApplicationModel, error,
 ) {
-	err := repo.db.Create(&application).Error
+	err := trace.DB(ctx, repo.db).Create(&application).Error
 	if err != nil {
 		return application, errors.Wrap(err, "[application_repo] create application err")
 	}
@@ -79,7 +80,7 @@ func (repo *ApplicationRepo) Get(ctx context.Context, id uint64) (model.Applicat
 	// If the input is of the make([]*model.ApplicationModel,0)
 	// Slice type, then Error will never be thrown if no data is available
 	application := model.ApplicationModel{}
-	result := repo.db.Where("status=1 and id=?", id).First(&application)
+	result := trace.DB(ctx, repo.db).Where("status=1 and id=?", id).First(&application)
 	if err := result.Error; err != nil {
 		log.Warnf("[application_repo] get application id: %v error", id)
 		return application, err
@@ -90,7 +91,7 @@ func (repo *ApplicationRepo) Get(ctx context.Context, id uint64) (model.Applicat
 func (repo *ApplicationRepo) GetList(ctx context.Context, userId *uint64) ([]*model.ApplicationModel, error) {
 	applicationList := make([]*model.ApplicationModel, 0)
 
-	query := repo.db.Where("status = 1")
+	query := trace.DB(ctx, repo.db).Where("status = 1")
 
 	if userId != nil {
 		query.Where("user_id = ?", &userId)
@@ -110,7 +111,7 @@ func (repo *ApplicationRepo) Delete(ctx context.Context, id uint64) error {
 	application := model.ApplicationModel{
 		ID: id,
 	}
-	if result := repo.db.Unscoped().Delete(&application); result.RowsAffected > 0 {
+	if result := trace.DB(ctx, repo.db).Unscoped().Delete(&application); result.RowsAffected > 0 {
 		return nil
 	}
 	return errors.New("application delete denied")
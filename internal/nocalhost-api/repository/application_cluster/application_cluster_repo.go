@@ -8,6 +8,7 @@ package application_cluster
 import (
 	"context"
 	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
@@ -29,7 +30,7 @@ func (repo *ApplicationClusterRepoBase) GetJoinCluster(
 	// TODO group by in mysql 5.7 require full select cols
 	// https://stackoverflow.com/questions/36207042/error-code-1055-incompatible-with-sql-mode-only-full-group-by
 	var result []*model.ApplicationClusterJoinModel
-	err := repo.db.Table("applications_clusters as ac").
+	err := trace.DB(ctx, repo.db).Table("applications_clusters as ac").
 		Select(
 			"count(ac.id) as dev_space_count,ac.cluster_id,ac.application_id,c.name as cluster_name,"+
 				"c.info as "+
@@ -50,7 +51,7 @@ func (repo *ApplicationClusterRepoBase) GetJoinCluster(
 
 func (repo *ApplicationClusterRepoBase) GetList(ctx context.Context, id uint64) ([]*model.ApplicationClusterModel, error) {
 	var result []*model.ApplicationClusterModel
-	err := repo.db.Where("application_id=?", id).Find(&result)
+	err := trace.DB(ctx, repo.db).Where("application_id=?", id).Find(&result)
 	if err.Error != nil {
 		return result, err.Error
 	}
@@ -59,7 +60,7 @@ func (repo *ApplicationClusterRepoBase) GetList(ctx context.Context, id uint64)
 
 func (repo *ApplicationClusterRepoBase) GetFirst(ctx context.Context, id uint64) (model.ApplicationClusterModel, error) {
 	result := model.ApplicationClusterModel{}
-	err := repo.db.First("applciation_id=?", id)
+	err := trace.DB(ctx, repo.db).First("applciation_id=?", id)
 	if err.Error != nil {
 		return result, err.Error
 	}
@@ -69,7 +70,7 @@ func (repo *ApplicationClusterRepoBase) GetFirst(ctx context.Context, id uint64)
 func (repo *ApplicationClusterRepoBase) Create(
 	ctx context.Context, clusterModel model.ApplicationClusterModel,
 ) (model.ApplicationClusterModel, error) {
-	err := repo.db.Create(&clusterModel).Error
+	err := trace.DB(ctx, repo.db).Create(&clusterModel).Error
 	if err != nil {
 		return clusterModel, errors.Wrap(err, "[application_cluster_repo] create application_cluster error")
 	}
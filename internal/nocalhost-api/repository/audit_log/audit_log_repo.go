@@ -0,0 +1,83 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package audit_log
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type AuditLogRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepo(db *gorm.DB) *AuditLogRepo {
+	return &AuditLogRepo{
+		db: db,
+	}
+}
+
+func (repo *AuditLogRepo) Create(ctx context.Context, log *model.AuditLogModel) error {
+	if err := trace.DB(ctx, repo.db).Create(log).Error; err != nil {
+		return errors.Wrap(err, "[audit_log_repo] create audit log err")
+	}
+	return nil
+}
+
+// List returns the audit logs matches the query, newest first, and the total count
+func (repo *AuditLogRepo) List(ctx context.Context, query model.AuditLogQuery) ([]*model.AuditLogModel, uint64, error) {
+	db := trace.DB(ctx, repo.db).Model(&model.AuditLogModel{})
+	if query.UserId != 0 {
+		db = db.Where("user_id = ?", query.UserId)
+	}
+	if query.ActorId != 0 {
+		db = db.Where("actor_id = ?", query.ActorId)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.Resource != "" {
+		db = db.Where("path like ?", query.Resource+"%")
+	}
+	if query.Ip != "" {
+		db = db.Where("ip = ?", query.Ip)
+	}
+	if query.From != nil {
+		db = db.Where("created_at >= ?", *query.From)
+	}
+	if query.To != nil {
+		db = db.Where("created_at < ?", *query.To)
+	}
+	if query.Failed {
+		db = db.Where("code != 0")
+	}
+
+	var total uint64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "[audit_log_repo] count audit logs err")
+	}
+
+	db = db.Order("id desc")
+	if query.Page > 0 && query.Size > 0 {
+		db = db.Offset((query.Page - 1) * query.Size).Limit(query.Size)
+	}
+
+	var result []*model.AuditLogModel
+	if err := db.Find(&result).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "[audit_log_repo] list audit logs err")
+	}
+	return result, total, nil
+}
+
+// Close close db
+func (repo *AuditLogRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,62 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package bulk_deploy
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type BulkDeployRepo struct {
+	db *gorm.DB
+}
+
+func NewBulkDeployRepo(db *gorm.DB) *BulkDeployRepo {
+	return &BulkDeployRepo{
+		db: db,
+	}
+}
+
+func (repo *BulkDeployRepo) Create(ctx context.Context, b *model.BulkDeployModel) error {
+	if err := trace.DB(ctx, repo.db).Create(b).Error; err != nil {
+		return errors.Wrap(err, "[bulk_deploy_repo] create bulk deploy err")
+	}
+	return nil
+}
+
+func (repo *BulkDeployRepo) Get(ctx context.Context, id uint64) (*model.BulkDeployModel, error) {
+	result := model.BulkDeployModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[bulk_deploy_repo] get bulk deploy err")
+	}
+	return &result, nil
+}
+
+func (repo *BulkDeployRepo) List(ctx context.Context) ([]*model.BulkDeployModel, error) {
+	var result []*model.BulkDeployModel
+	if err := trace.DB(ctx, repo.db).Order("id desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[bulk_deploy_repo] list bulk deploy err")
+	}
+	return result, nil
+}
+
+func (repo *BulkDeployRepo) UpdateStatus(ctx context.Context, id uint64, status string) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.BulkDeployModel{}).Where("id = ?", id).
+		Update("status", status).Error; err != nil {
+		return errors.Wrap(err, "[bulk_deploy_repo] update bulk deploy err")
+	}
+	return nil
+}
+
+// Close close db
+func (repo *BulkDeployRepo) Close() {
+	repo.db.Close()
+}
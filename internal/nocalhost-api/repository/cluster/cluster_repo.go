@@ -9,6 +9,7 @@ import (
 	"context"
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 
 	"github.com/jinzhu/gorm"
 	"github.com/pkg/errors"
@@ -28,11 +29,11 @@ func (repo *ClusterBaseRepo) Update(
 	ctx context.Context, update map[string]interface{}, clusterId uint64,
 ) (*model.ClusterModel, error) {
 	clusterModel := model.ClusterModel{}
-	clusterResult := repo.db.Where("id = ?", clusterId).First(&clusterModel)
+	clusterResult := trace.DB(ctx, repo.db).Where("id = ?", clusterId).First(&clusterModel)
 	if clusterResult.Error != nil {
 		return &clusterModel, clusterResult.Error
 	}
-	result := repo.db.Model(&clusterModel).Update(update)
+	result := trace.DB(ctx, repo.db).Model(&clusterModel).Update(update)
 	if result.RowsAffected > 0 {
 		return &clusterModel, nil
 	}
@@ -40,7 +41,7 @@ func (repo *ClusterBaseRepo) Update(
 }
 
 func (repo *ClusterBaseRepo) Delete(ctx context.Context, clusterId uint64) error {
-	result := repo.db.Unscoped().Delete(&model.ClusterModel{}, clusterId)
+	result := trace.DB(ctx, repo.db).Unscoped().Delete(&model.ClusterModel{}, clusterId)
 	if result.RowsAffected > 0 {
 		return nil
 	}
@@ -48,7 +49,7 @@ func (repo *ClusterBaseRepo) Delete(ctx context.Context, clusterId uint64) error
 }
 
 func (repo *ClusterBaseRepo) DeleteByCreator(ctx context.Context, userId uint64) error {
-	result := repo.db.Exec("delete from clusters where user_id = ? and deleted_at is null", userId)
+	result := trace.DB(ctx, repo.db).Exec("delete from clusters where user_id = ? and deleted_at is null", userId)
 	if result.RowsAffected > 0 {
 		return nil
 	}
@@ -57,7 +58,7 @@ func (repo *ClusterBaseRepo) DeleteByCreator(ctx context.Context, userId uint64)
 
 func (repo *ClusterBaseRepo) GetAny(ctx context.Context, where map[string]interface{}) ([]*model.ClusterModel, error) {
 	cluster := make([]*model.ClusterModel, 0)
-	result := repo.db.Where(where).Find(&cluster)
+	result := trace.DB(ctx, repo.db).Where(where).Find(&cluster)
 	if result.Error != nil {
 		return cluster, result.Error
 	}
@@ -69,8 +70,10 @@ func (repo *ClusterBaseRepo) GetAny(ctx context.Context, where map[string]interf
 
 func (repo *ClusterBaseRepo) GetList(ctx context.Context) ([]*model.ClusterList, error) {
 	var result []*model.ClusterList
-	repo.db.Raw(
-		"select c.id,c.kubeconfig,c.name,c.server,c.extra_api_server,c.storage_class,c.info,c.user_id,c.created_at,count" +
+	trace.DB(ctx, repo.db).Raw(
+		"select c.id,c.kubeconfig,c.name,c.server,c.extra_api_server,c.storage_class,c.info,c.user_id,c.created_at," +
+			"c.kubeconfig_expire_at,c.kubeconfig_status,c.kubeconfig_message," +
+			"c.health_status,c.health_message,c.server_version,c.health_checked_at,c.labels,c.annotations,count" +
 			"(distinct cu.id) as users_count from clusters as c left join clusters_users as cu on c.id=cu.cluster_id" +
 			" where c.deleted_at is null and cu.deleted_at is null group by c.id",
 	).
@@ -79,7 +82,7 @@ func (repo *ClusterBaseRepo) GetList(ctx context.Context) ([]*model.ClusterList,
 }
 
 func (repo *ClusterBaseRepo) Create(ctx context.Context, cluster model.ClusterModel) (model.ClusterModel, error) {
-	err := repo.db.Create(&cluster).Error
+	err := trace.DB(ctx, repo.db).Create(&cluster).Error
 	if err != nil {
 		return cluster, errors.Wrap(err, "[cluster_repo] create user err")
 	}
@@ -89,7 +92,7 @@ func (repo *ClusterBaseRepo) Create(ctx context.Context, cluster model.ClusterMo
 
 func (repo *ClusterBaseRepo) Get(ctx context.Context, clusterId uint64) (model.ClusterModel, error) {
 	cluster := model.ClusterModel{}
-	if result := repo.db.Where("id=?", clusterId).First(&cluster); result.Error != nil {
+	if result := trace.DB(ctx, repo.db).Where("id=?", clusterId).First(&cluster); result.Error != nil {
 		log.Warnf("[cluster_repo] get cluster for id: %v error", clusterId)
 		return cluster, result.Error
 	}
@@ -0,0 +1,80 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_agent
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type ClusterAgentRepo struct {
+	db *gorm.DB
+}
+
+func NewClusterAgentRepo(db *gorm.DB) *ClusterAgentRepo {
+	return &ClusterAgentRepo{
+		db: db,
+	}
+}
+
+func (repo *ClusterAgentRepo) Create(ctx context.Context, agent model.ClusterAgentModel) (model.ClusterAgentModel, error) {
+	if err := trace.DB(ctx, repo.db).Create(&agent).Error; err != nil {
+		return agent, errors.Wrap(err, "[cluster_agent_repo] create cluster agent err")
+	}
+	return agent, nil
+}
+
+func (repo *ClusterAgentRepo) Get(ctx context.Context, id uint64) (*model.ClusterAgentModel, error) {
+	result := model.ClusterAgentModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[cluster_agent_repo] get cluster agent err")
+	}
+	return &result, nil
+}
+
+func (repo *ClusterAgentRepo) GetByHash(ctx context.Context, hash string) (*model.ClusterAgentModel, error) {
+	result := model.ClusterAgentModel{}
+	if err := trace.DB(ctx, repo.db).Where("token_hash = ?", hash).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// List all the agents if userId is zero
+func (repo *ClusterAgentRepo) List(ctx context.Context, userId uint64) ([]*model.ClusterAgentModel, error) {
+	var result []*model.ClusterAgentModel
+	db := trace.DB(ctx, repo.db)
+	if userId > 0 {
+		db = db.Where("user_id = ?", userId)
+	}
+	if err := db.Order("id desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[cluster_agent_repo] list cluster agent err")
+	}
+	return result, nil
+}
+
+func (repo *ClusterAgentRepo) Update(ctx context.Context, id uint64, update map[string]interface{}) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.ClusterAgentModel{ID: id}).Update(update).Error; err != nil {
+		return errors.Wrap(err, "[cluster_agent_repo] update cluster agent err")
+	}
+	return nil
+}
+
+func (repo *ClusterAgentRepo) Delete(ctx context.Context, id uint64) error {
+	if result := trace.DB(ctx, repo.db).Where("id = ?", id).Delete(&model.ClusterAgentModel{}); result.RowsAffected > 0 {
+		return nil
+	}
+	return errors.New("cluster agent delete fail")
+}
+
+func (repo *ClusterAgentRepo) Close() {
+	repo.db.Close()
+}
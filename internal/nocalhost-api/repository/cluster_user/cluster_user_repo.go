@@ -98,6 +98,15 @@ func (repo *ClusterUserRepoBase) Update(models *model.ClusterUserModel) (
 	return models, errors.New("update dev space err")
 }
 
+// UpdateColumns
+func (repo *ClusterUserRepoBase) UpdateColumns(id uint64, columns map[string]interface{}) error {
+	err := repo.db.Model(&model.ClusterUserModel{}).Where("id=?", id).Updates(columns).Error
+	if err != nil {
+		return errors.Wrap(err, "[cluster_user_repo] update dev space columns error")
+	}
+	return nil
+}
+
 // GetList
 func (repo *ClusterUserRepoBase) GetList(models model.ClusterUserModel) (
 	[]*model.ClusterUserModel, error,
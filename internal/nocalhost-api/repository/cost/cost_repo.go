@@ -0,0 +1,65 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cost
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type CostRepo struct {
+	db *gorm.DB
+}
+
+func NewCostRepo(db *gorm.DB) *CostRepo {
+	return &CostRepo{
+		db: db,
+	}
+}
+
+func (repo *CostRepo) Create(ctx context.Context, sample *model.CostSampleModel) error {
+	if err := trace.DB(ctx, repo.db).Create(sample).Error; err != nil {
+		return errors.Wrap(err, "[cost_repo] create cost sample err")
+	}
+	return nil
+}
+
+// Sum the samples of each dev space sampled in [from, to)
+func (repo *CostRepo) Sum(ctx context.Context, from, to time.Time) ([]*model.CostSum, error) {
+	var result []*model.CostSum
+	if err := trace.DB(ctx, repo.db).Model(&model.CostSampleModel{}).
+		Select(
+			"dev_space_id, cluster_id, user_id, namespace, "+
+				"SUM(cpu_core_hours) AS cpu_core_hours, SUM(memory_gib_hours) AS memory_gib_hours",
+		).
+		Where("sampled_at >= ? and sampled_at < ?", from, to).
+		Group("dev_space_id, cluster_id, user_id, namespace").
+		Order("dev_space_id").Scan(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[cost_repo] sum cost samples err")
+	}
+	return result, nil
+}
+
+// DeleteBefore delete the samples sampled before, they are kept for the
+// reports of previous months
+func (repo *CostRepo) DeleteBefore(ctx context.Context, before time.Time) error {
+	if err := trace.DB(ctx, repo.db).Where("sampled_at < ?", before).
+		Delete(&model.CostSampleModel{}).Error; err != nil {
+		return errors.Wrap(err, "[cost_repo] delete cost samples err")
+	}
+	return nil
+}
+
+// Close close db
+func (repo *CostRepo) Close() {
+	repo.db.Close()
+}
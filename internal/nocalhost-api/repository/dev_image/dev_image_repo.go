@@ -0,0 +1,83 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_image
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type DevImageRepo struct {
+	db *gorm.DB
+}
+
+func NewDevImageRepo(db *gorm.DB) *DevImageRepo {
+	return &DevImageRepo{
+		db: db,
+	}
+}
+
+func (repo *DevImageRepo) Create(ctx context.Context, i *model.DevImageModel) error {
+	if err := trace.DB(ctx, repo.db).Create(i).Error; err != nil {
+		return errors.Wrap(err, "[dev_image_repo] create dev image err")
+	}
+	return nil
+}
+
+func (repo *DevImageRepo) Get(ctx context.Context, id uint64) (*model.DevImageModel, error) {
+	result := model.DevImageModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_image_repo] get dev image err")
+	}
+	return &result, nil
+}
+
+func (repo *DevImageRepo) GetByName(ctx context.Context, name string) (*model.DevImageModel, error) {
+	result := model.DevImageModel{}
+	if err := trace.DB(ctx, repo.db).Where("name = ?", name).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_image_repo] get dev image err")
+	}
+	return &result, nil
+}
+
+// List the images of language sorted, all if language is empty
+func (repo *DevImageRepo) List(ctx context.Context, language string) ([]*model.DevImageModel, error) {
+	var result []*model.DevImageModel
+	db := trace.DB(ctx, repo.db)
+	if language != "" {
+		db = db.Where("language = ?", language)
+	}
+	if err := db.Order("language").Order("sort").Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_image_repo] list dev image err")
+	}
+	return result, nil
+}
+
+// Update update the columns given, zero values are updated too
+func (repo *DevImageRepo) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.DevImageModel{}).Where("id = ?", id).
+		Updates(columns).Error; err != nil {
+		return errors.Wrap(err, "[dev_image_repo] update dev image err")
+	}
+	return nil
+}
+
+func (repo *DevImageRepo) Delete(ctx context.Context, id uint64) error {
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).Delete(&model.DevImageModel{}).Error; err != nil {
+		return errors.Wrap(err, "[dev_image_repo] delete dev image err")
+	}
+	return nil
+}
+
+// Close close db
+func (repo *DevImageRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,104 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_space_sa
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type DevSpaceSaRepo struct {
+	db *gorm.DB
+}
+
+func NewDevSpaceSaRepo(db *gorm.DB) *DevSpaceSaRepo {
+	return &DevSpaceSaRepo{
+		db: db,
+	}
+}
+
+func (repo *DevSpaceSaRepo) Create(ctx context.Context, sa *model.DevSpaceSaModel) error {
+	if err := trace.DB(ctx, repo.db).Create(sa).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_sa_repo] create service account err")
+	}
+	return nil
+}
+
+// Get get the service account of dev space which is not revoked
+func (repo *DevSpaceSaRepo) Get(ctx context.Context, devSpaceId, id uint64) (*model.DevSpaceSaModel, error) {
+	result := model.DevSpaceSaModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ? and dev_space_id = ? and revoked_at is null", id, devSpaceId).
+		First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_sa_repo] get service account err")
+	}
+	return &result, nil
+}
+
+func (repo *DevSpaceSaRepo) List(ctx context.Context, devSpaceId uint64) ([]*model.DevSpaceSaModel, error) {
+	var result []*model.DevSpaceSaModel
+	if err := trace.DB(ctx, repo.db).Where("dev_space_id = ? and revoked_at is null", devSpaceId).
+		Order("id desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_sa_repo] list service account err")
+	}
+	return result, nil
+}
+
+func (repo *DevSpaceSaRepo) Rotated(ctx context.Context, id uint64) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.DevSpaceSaModel{}).Where("id = ?", id).
+		Update("rotated_at", time.Now()).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_sa_repo] rotate service account err")
+	}
+	return nil
+}
+
+func (repo *DevSpaceSaRepo) Revoke(ctx context.Context, id uint64) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.DevSpaceSaModel{}).Where("id = ?", id).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_sa_repo] revoke service account err")
+	}
+	return nil
+}
+
+// RevokeAll revoke all the service accounts of dev space, used while the namespace is deleted
+func (repo *DevSpaceSaRepo) RevokeAll(ctx context.Context, devSpaceId uint64) error {
+	db := trace.DB(ctx, repo.db)
+	if err := db.Model(&model.DevSpaceSaModel{}).Where("dev_space_id = ? and revoked_at is null", devSpaceId).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_sa_repo] revoke service accounts err")
+	}
+	return nil
+}
+
+// ListAll the service accounts not revoked of all the dev spaces
+func (repo *DevSpaceSaRepo) ListAll(ctx context.Context) ([]*model.DevSpaceSaModel, error) {
+	var result []*model.DevSpaceSaModel
+	if err := trace.DB(ctx, repo.db).Where("revoked_at is null").Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_sa_repo] list all service account err")
+	}
+	return result, nil
+}
+
+// ListOrphaned the service accounts not revoked of which the dev space is removed
+func (repo *DevSpaceSaRepo) ListOrphaned(ctx context.Context) ([]*model.DevSpaceSaModel, error) {
+	var result []*model.DevSpaceSaModel
+	if err := trace.DB(ctx, repo.db).Where(
+		"revoked_at is null and dev_space_id NOT IN (SELECT id FROM clusters_users WHERE deleted_at IS NULL)",
+	).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_sa_repo] list orphaned service account err")
+	}
+	return result, nil
+}
+
+// Close close db
+func (repo *DevSpaceSaRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,155 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_space_template
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type DevSpaceTemplateRepo struct {
+	db *gorm.DB
+}
+
+func NewDevSpaceTemplateRepo(db *gorm.DB) *DevSpaceTemplateRepo {
+	return &DevSpaceTemplateRepo{
+		db: db,
+	}
+}
+
+func (repo *DevSpaceTemplateRepo) Create(ctx context.Context, t *model.DevSpaceTemplateModel) error {
+	if err := trace.DB(ctx, repo.db).Create(t).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_template_repo] create template err")
+	}
+	return nil
+}
+
+func (repo *DevSpaceTemplateRepo) Get(ctx context.Context, id uint64) (*model.DevSpaceTemplateModel, error) {
+	result := model.DevSpaceTemplateModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_template_repo] get template err")
+	}
+	return &result, nil
+}
+
+func (repo *DevSpaceTemplateRepo) GetByName(ctx context.Context, name string) (*model.DevSpaceTemplateModel, error) {
+	result := model.DevSpaceTemplateModel{}
+	if err := trace.DB(ctx, repo.db).Where("name = ?", name).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_template_repo] get template err")
+	}
+	return &result, nil
+}
+
+func (repo *DevSpaceTemplateRepo) List(ctx context.Context) ([]*model.DevSpaceTemplateModel, error) {
+	var result []*model.DevSpaceTemplateModel
+	if err := trace.DB(ctx, repo.db).Order("id desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_template_repo] list template err")
+	}
+	return result, nil
+}
+
+// Update update the columns given, zero values are updated too
+func (repo *DevSpaceTemplateRepo) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.DevSpaceTemplateModel{}).Where("id = ?", id).
+		Updates(columns).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_template_repo] update template err")
+	}
+	return nil
+}
+
+func (repo *DevSpaceTemplateRepo) Delete(ctx context.Context, id uint64) error {
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).Delete(&model.DevSpaceTemplateModel{}).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_template_repo] delete template err")
+	}
+	return nil
+}
+
+func (repo *DevSpaceTemplateRepo) CreateInstall(ctx context.Context, install *model.DevSpaceAppInstallModel) error {
+	if err := trace.DB(ctx, repo.db).Create(install).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_template_repo] create app install err")
+	}
+	return nil
+}
+
+func (repo *DevSpaceTemplateRepo) GetInstall(ctx context.Context, id uint64) (*model.DevSpaceAppInstallModel, error) {
+	result := model.DevSpaceAppInstallModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_template_repo] get app install err")
+	}
+	return &result, nil
+}
+
+func (repo *DevSpaceTemplateRepo) ListInstalls(ctx context.Context, devSpaceId uint64) (
+	[]*model.DevSpaceAppInstallModel, error,
+) {
+	var result []*model.DevSpaceAppInstallModel
+	if err := trace.DB(ctx, repo.db).Where("dev_space_id = ?", devSpaceId).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_template_repo] list app installs err")
+	}
+	return result, nil
+}
+
+func (repo *DevSpaceTemplateRepo) UpdateInstall(ctx context.Context, id uint64, status, message string) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.DevSpaceAppInstallModel{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"status": status, "message": message}).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_template_repo] update app install err")
+	}
+	return nil
+}
+
+// ListBulkInstalls the installs of dev spaces deployed in bulk
+func (repo *DevSpaceTemplateRepo) ListBulkInstalls(ctx context.Context, bulkDeployId uint64) (
+	[]*model.DevSpaceAppInstallModel, error,
+) {
+	var result []*model.DevSpaceAppInstallModel
+	db := trace.DB(ctx, repo.db)
+	if err := db.Where("bulk_deploy_id = ?", bulkDeployId).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_template_repo] list bulk app installs err")
+	}
+	return result, nil
+}
+
+// UpdateInstallJob update the job of install started or restarted, with its status
+func (repo *DevSpaceTemplateRepo) UpdateInstallJob(
+	ctx context.Context, id uint64, jobName, status, message string,
+) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.DevSpaceAppInstallModel{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"job_name": jobName, "status": status, "message": message}).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_template_repo] update app install job err")
+	}
+	return nil
+}
+
+// DeleteInstalls delete the installs of dev space, used while the dev space is deleted
+func (repo *DevSpaceTemplateRepo) DeleteInstalls(ctx context.Context, devSpaceId uint64) error {
+	db := trace.DB(ctx, repo.db)
+	if err := db.Where("dev_space_id = ?", devSpaceId).Delete(&model.DevSpaceAppInstallModel{}).Error; err != nil {
+		return errors.Wrap(err, "[dev_space_template_repo] delete app installs err")
+	}
+	return nil
+}
+
+// ListOrphanedInstalls the installs of which the dev space is removed
+func (repo *DevSpaceTemplateRepo) ListOrphanedInstalls(ctx context.Context) ([]*model.DevSpaceAppInstallModel, error) {
+	var result []*model.DevSpaceAppInstallModel
+	db := trace.DB(ctx, repo.db)
+	if err := db.Where(
+		"dev_space_id NOT IN (SELECT id FROM clusters_users WHERE deleted_at IS NULL)",
+	).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[dev_space_template_repo] list orphaned app installs err")
+	}
+	return result, nil
+}
+
+// Close close db
+func (repo *DevSpaceTemplateRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,78 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package git_credential
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type GitCredentialRepo struct {
+	db *gorm.DB
+}
+
+func NewGitCredentialRepo(db *gorm.DB) *GitCredentialRepo {
+	return &GitCredentialRepo{
+		db: db,
+	}
+}
+
+func (repo *GitCredentialRepo) Create(ctx context.Context, g *model.GitCredentialModel) error {
+	if err := trace.DB(ctx, repo.db).Create(g).Error; err != nil {
+		return errors.Wrap(err, "[git_credential_repo] create credential err")
+	}
+	return nil
+}
+
+func (repo *GitCredentialRepo) Get(ctx context.Context, id uint64) (*model.GitCredentialModel, error) {
+	result := model.GitCredentialModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[git_credential_repo] get credential err")
+	}
+	return &result, nil
+}
+
+func (repo *GitCredentialRepo) GetByName(ctx context.Context, name string) (*model.GitCredentialModel, error) {
+	result := model.GitCredentialModel{}
+	if err := trace.DB(ctx, repo.db).Where("name = ?", name).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[git_credential_repo] get credential err")
+	}
+	return &result, nil
+}
+
+func (repo *GitCredentialRepo) List(ctx context.Context) ([]*model.GitCredentialModel, error) {
+	var result []*model.GitCredentialModel
+	if err := trace.DB(ctx, repo.db).Order("id desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[git_credential_repo] list credential err")
+	}
+	return result, nil
+}
+
+// Update update the columns given, zero values are updated too
+func (repo *GitCredentialRepo) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.GitCredentialModel{}).Where("id = ?", id).
+		Updates(columns).Error; err != nil {
+		return errors.Wrap(err, "[git_credential_repo] update credential err")
+	}
+	return nil
+}
+
+func (repo *GitCredentialRepo) Delete(ctx context.Context, id uint64) error {
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).Delete(&model.GitCredentialModel{}).Error; err != nil {
+		return errors.Wrap(err, "[git_credential_repo] delete credential err")
+	}
+	return nil
+}
+
+// Close close db
+func (repo *GitCredentialRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,67 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package leader_lease
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+type LeaderLeaseRepo struct {
+	db *gorm.DB
+}
+
+func NewLeaderLeaseRepo(db *gorm.DB) *LeaderLeaseRepo {
+	return &LeaderLeaseRepo{
+		db: db,
+	}
+}
+
+// Acquire the lease of name for holder until now plus ttl, the lease is
+// renewed if holder holds it, or taken over if it expired, false if it is
+// held by another holder
+func (repo *LeaderLeaseRepo) Acquire(name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	result := repo.db.Model(&model.LeaderLeaseModel{}).
+		Where("name = ? AND (holder = ? OR expire_at < ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expire_at": now.Add(ttl), "updated_at": now})
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "[leader_lease_repo] renew lease err")
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// the first holder of lease, the others fail by the primary key
+	lease := &model.LeaderLeaseModel{}
+	err := repo.db.Where(&model.LeaderLeaseModel{Name: name}).First(lease).Error
+	if err == nil {
+		return false, nil
+	}
+	if !gorm.IsRecordNotFoundError(err) {
+		return false, errors.Wrap(err, "[leader_lease_repo] get lease err")
+	}
+	lease = &model.LeaderLeaseModel{Name: name, Holder: holder, ExpireAt: now.Add(ttl), UpdatedAt: now}
+	if err := repo.db.Create(lease).Error; err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// Release the lease of name if holder holds it, so that the others take it
+// over without waiting for the expiry
+func (repo *LeaderLeaseRepo) Release(name, holder string) error {
+	return repo.db.Model(&model.LeaderLeaseModel{}).
+		Where("name = ? AND holder = ?", name, holder).
+		Update("expire_at", time.Now()).Error
+}
+
+func (repo *LeaderLeaseRepo) Close() {
+	repo.db.Close()
+}
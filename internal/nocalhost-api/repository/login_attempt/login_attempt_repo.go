@@ -0,0 +1,69 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package login_attempt
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type LoginAttemptRepo struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepo(db *gorm.DB) *LoginAttemptRepo {
+	return &LoginAttemptRepo{
+		db: db,
+	}
+}
+
+func (repo *LoginAttemptRepo) Get(ctx context.Context, key string) (*model.LoginAttemptModel, error) {
+	result := model.LoginAttemptModel{}
+	if err := trace.DB(ctx, repo.db).Where(&model.LoginAttemptModel{Key: key}).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Fail record a failure of the key, fun decide how to lock it
+func (repo *LoginAttemptRepo) Fail(
+	ctx context.Context, key string, fun func(attempt *model.LoginAttemptModel),
+) (*model.LoginAttemptModel, error) {
+	attempt := &model.LoginAttemptModel{}
+	err := trace.DB(ctx, repo.db).Transaction(
+		func(tx *gorm.DB) error {
+			// the first failures of the key may be concurrent
+			if err := model.OnConflictKeep(tx, "key").Create(&model.LoginAttemptModel{Key: key}).Error; err != nil {
+				return err
+			}
+			err := model.ForUpdate(tx).Where(&model.LoginAttemptModel{Key: key}).First(attempt).Error
+			if err != nil {
+				return err
+			}
+
+			fun(attempt)
+			return tx.Save(attempt).Error
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "[login_attempt_repo] record login failure err")
+	}
+	return attempt, nil
+}
+
+// Reset clear the failures and unlock the key
+func (repo *LoginAttemptRepo) Reset(ctx context.Context, key string) error {
+	return trace.DB(ctx, repo.db).Where(&model.LoginAttemptModel{Key: key}).Delete(&model.LoginAttemptModel{}).Error
+}
+
+func (repo *LoginAttemptRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,60 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package password_history
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type PasswordHistoryRepo struct {
+	db *gorm.DB
+}
+
+func NewPasswordHistoryRepo(db *gorm.DB) *PasswordHistoryRepo {
+	return &PasswordHistoryRepo{
+		db: db,
+	}
+}
+
+// Add save the password and remove the ones older than the last keep
+func (repo *PasswordHistoryRepo) Add(ctx context.Context, userId uint64, password string, keep int) error {
+	db := trace.DB(ctx, repo.db)
+	if err := db.Create(&model.PasswordHistoryModel{UserId: userId, Password: password}).Error; err != nil {
+		return errors.Wrap(err, "[password_history_repo] create password history err")
+	}
+
+	latest, err := repo.ListLatest(ctx, userId, keep)
+	if err != nil || len(latest) < keep {
+		return err
+	}
+	return trace.DB(ctx, repo.db).Where("user_id = ? and id < ?", userId, latest[len(latest)-1].ID).
+		Delete(&model.PasswordHistoryModel{}).Error
+}
+
+func (repo *PasswordHistoryRepo) ListLatest(ctx context.Context, userId uint64, limit int) (
+	[]*model.PasswordHistoryModel, error,
+) {
+	var result []*model.PasswordHistoryModel
+	db := trace.DB(ctx, repo.db)
+	if err := db.Where("user_id = ?", userId).Order("id desc").Limit(limit).Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[password_history_repo] list password history err")
+	}
+	return result, nil
+}
+
+func (repo *PasswordHistoryRepo) DeleteByUserId(ctx context.Context, userId uint64) error {
+	return trace.DB(ctx, repo.db).Where("user_id = ?", userId).Delete(&model.PasswordHistoryModel{}).Error
+}
+
+func (repo *PasswordHistoryRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,60 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package password_reset
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type PasswordResetRepo struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepo(db *gorm.DB) *PasswordResetRepo {
+	return &PasswordResetRepo{
+		db: db,
+	}
+}
+
+func (repo *PasswordResetRepo) Create(ctx context.Context, reset model.PasswordResetModel) error {
+	if err := trace.DB(ctx, repo.db).Create(&reset).Error; err != nil {
+		return errors.Wrap(err, "[password_reset_repo] create password reset err")
+	}
+	return nil
+}
+
+func (repo *PasswordResetRepo) GetByHash(ctx context.Context, hash string) (*model.PasswordResetModel, error) {
+	result := model.PasswordResetModel{}
+	if err := trace.DB(ctx, repo.db).Where("token_hash = ?", hash).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// MarkUsed returns false if the token has been used concurrently
+func (repo *PasswordResetRepo) MarkUsed(ctx context.Context, id uint64) bool {
+	return trace.DB(ctx, repo.db).Exec(
+		"UPDATE password_resets SET used_at = ? WHERE id = ? and used_at is null", time.Now(), id,
+	).RowsAffected > 0
+}
+
+// InvalidateByUserId mark all unused tokens of the user as used
+func (repo *PasswordResetRepo) InvalidateByUserId(ctx context.Context, userId uint64) {
+	trace.DB(ctx, repo.db).Exec(
+		"UPDATE password_resets SET used_at = ? WHERE user_id = ? and used_at is null", time.Now(), userId,
+	)
+}
+
+func (repo *PasswordResetRepo) Close() {
+	repo.db.Close()
+}
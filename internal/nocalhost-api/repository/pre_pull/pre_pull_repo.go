@@ -10,6 +10,7 @@ import (
 	"errors"
 	"github.com/jinzhu/gorm"
 	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 )
 
 type PrePullRepoRepoBase struct {
@@ -24,7 +25,7 @@ func NewPrePullRepoRepo(db *gorm.DB) *PrePullRepoRepoBase {
 
 func (repo *PrePullRepoRepoBase) GetAll(ctx context.Context) ([]model.PrePullModel, error) {
 	var images []model.PrePullModel
-	result := repo.db.Find(&images)
+	result := trace.DB(ctx, repo.db).Find(&images)
 	if result.RowsAffected > 0 {
 		return images, nil
 	}
@@ -0,0 +1,97 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package quota
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type QuotaRepo struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepo(db *gorm.DB) *QuotaRepo {
+	return &QuotaRepo{
+		db: db,
+	}
+}
+
+// Get returns nil if no quota of the subject
+func (repo *QuotaRepo) Get(ctx context.Context, subjectType string, subjectId uint64) (*model.QuotaModel, error) {
+	result := model.QuotaModel{}
+	err := trace.DB(ctx, repo.db).Where("subject_type = ? and subject_id = ?", subjectType, subjectId).First(&result).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "[quota_repo] get quota err")
+	}
+	return &result, nil
+}
+
+// ListTeamQuotas list the quotas of the teams the user belongs to
+func (repo *QuotaRepo) ListTeamQuotas(ctx context.Context, userId uint64) ([]*model.QuotaModel, error) {
+	var result []*model.QuotaModel
+	if err := trace.DB(ctx, repo.db).Where(
+		"subject_type = ? and subject_id in (?)", model.QuotaSubjectTeam,
+		trace.DB(ctx, repo.db).Table("team_members").Select("team_id").Where("user_id = ?", userId).SubQuery(),
+	).Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[quota_repo] list team quotas err")
+	}
+	return result, nil
+}
+
+// Save create or update the quota of the subject
+func (repo *QuotaRepo) Save(ctx context.Context, quota model.QuotaModel) (model.QuotaModel, error) {
+	result := model.QuotaModel{}
+	if err := trace.DB(ctx, repo.db).Where(
+		model.QuotaModel{SubjectType: quota.SubjectType, SubjectId: quota.SubjectId},
+	).Assign(
+		map[string]interface{}{
+			"max_dev_spaces":   quota.MaxDevSpaces,
+			"max_clusters":     quota.MaxClusters,
+			"max_space_cpu":    quota.MaxSpaceCpu,
+			"max_space_memory": quota.MaxSpaceMemory,
+		},
+	).FirstOrCreate(&result).Error; err != nil {
+		return result, errors.Wrap(err, "[quota_repo] save quota err")
+	}
+	return result, nil
+}
+
+func (repo *QuotaRepo) Delete(ctx context.Context, subjectType string, subjectId uint64) error {
+	if err := trace.DB(ctx, repo.db).Where("subject_type = ? and subject_id = ?", subjectType, subjectId).
+		Delete(&model.QuotaModel{}).Error; err != nil {
+		return errors.Wrap(err, "[quota_repo] delete quota err")
+	}
+	return nil
+}
+
+// Usage count the dev spaces and clusters owned by the user
+func (repo *QuotaRepo) Usage(ctx context.Context, userId uint64) (model.QuotaUsage, error) {
+	usage := model.QuotaUsage{}
+	if err := trace.DB(ctx, repo.db).Model(&model.ClusterUserModel{}).Where("user_id = ?", userId).
+		Count(&usage.DevSpaces).Error; err != nil {
+		return usage, errors.Wrap(err, "[quota_repo] count dev spaces err")
+	}
+
+	if err := trace.DB(ctx, repo.db).Model(&model.ClusterModel{}).Where("user_id = ?", userId).
+		Count(&usage.Clusters).Error; err != nil {
+		return usage, errors.Wrap(err, "[quota_repo] count clusters err")
+	}
+	return usage, nil
+}
+
+// Close close db
+func (repo *QuotaRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,78 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package registry_credential
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type RegistryCredentialRepo struct {
+	db *gorm.DB
+}
+
+func NewRegistryCredentialRepo(db *gorm.DB) *RegistryCredentialRepo {
+	return &RegistryCredentialRepo{
+		db: db,
+	}
+}
+
+func (repo *RegistryCredentialRepo) Create(ctx context.Context, r *model.RegistryCredentialModel) error {
+	if err := trace.DB(ctx, repo.db).Create(r).Error; err != nil {
+		return errors.Wrap(err, "[registry_credential_repo] create credential err")
+	}
+	return nil
+}
+
+func (repo *RegistryCredentialRepo) Get(ctx context.Context, id uint64) (*model.RegistryCredentialModel, error) {
+	result := model.RegistryCredentialModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[registry_credential_repo] get credential err")
+	}
+	return &result, nil
+}
+
+func (repo *RegistryCredentialRepo) GetByName(ctx context.Context, name string) (*model.RegistryCredentialModel, error) {
+	result := model.RegistryCredentialModel{}
+	if err := trace.DB(ctx, repo.db).Where("name = ?", name).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[registry_credential_repo] get credential err")
+	}
+	return &result, nil
+}
+
+func (repo *RegistryCredentialRepo) List(ctx context.Context) ([]*model.RegistryCredentialModel, error) {
+	var result []*model.RegistryCredentialModel
+	if err := trace.DB(ctx, repo.db).Order("id desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[registry_credential_repo] list credential err")
+	}
+	return result, nil
+}
+
+// Update update the columns given, zero values are updated too
+func (repo *RegistryCredentialRepo) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.RegistryCredentialModel{}).Where("id = ?", id).
+		Updates(columns).Error; err != nil {
+		return errors.Wrap(err, "[registry_credential_repo] update credential err")
+	}
+	return nil
+}
+
+func (repo *RegistryCredentialRepo) Delete(ctx context.Context, id uint64) error {
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).Delete(&model.RegistryCredentialModel{}).Error; err != nil {
+		return errors.Wrap(err, "[registry_credential_repo] delete credential err")
+	}
+	return nil
+}
+
+// Close close db
+func (repo *RegistryCredentialRepo) Close() {
+	repo.db.Close()
+}
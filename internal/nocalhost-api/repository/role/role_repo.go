@@ -0,0 +1,104 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package role
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type RoleRepo struct {
+	db *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB) *RoleRepo {
+	return &RoleRepo{
+		db: db,
+	}
+}
+
+func (repo *RoleRepo) Create(ctx context.Context, role model.RoleModel) (model.RoleModel, error) {
+	if err := trace.DB(ctx, repo.db).Create(&role).Error; err != nil {
+		return role, errors.Wrap(err, "[role_repo] create role err")
+	}
+	return role, nil
+}
+
+func (repo *RoleRepo) Update(ctx context.Context, id uint64, description, permissions string) error {
+	return trace.DB(ctx, repo.db).Model(&model.RoleModel{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"description": description, "permissions": permissions}).Error
+}
+
+func (repo *RoleRepo) Get(ctx context.Context, id uint64) (*model.RoleModel, error) {
+	result := model.RoleModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (repo *RoleRepo) GetByName(ctx context.Context, name string) (*model.RoleModel, error) {
+	result := model.RoleModel{}
+	if err := trace.DB(ctx, repo.db).Where("name = ?", name).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (repo *RoleRepo) List(ctx context.Context) ([]*model.RoleModel, error) {
+	var result []*model.RoleModel
+	if err := trace.DB(ctx, repo.db).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[role_repo] list role err")
+	}
+	return result, nil
+}
+
+// Delete delete the role and all its bindings
+func (repo *RoleRepo) Delete(ctx context.Context, id uint64) error {
+	return trace.DB(ctx, repo.db).Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Where("role_id = ?", id).Delete(&model.RoleBindingModel{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id = ?", id).Delete(&model.RoleModel{}).Error
+		},
+	)
+}
+
+func (repo *RoleRepo) CreateBinding(ctx context.Context, binding model.RoleBindingModel) (model.RoleBindingModel, error) {
+	if err := trace.DB(ctx, repo.db).Create(&binding).Error; err != nil {
+		return binding, errors.Wrap(err, "[role_repo] create role binding err")
+	}
+	return binding, nil
+}
+
+func (repo *RoleRepo) DeleteBinding(ctx context.Context, binding model.RoleBindingModel) error {
+	return trace.DB(ctx, repo.db).Where(
+		"role_id = ? and user_id = ? and scope = ? and scope_id = ?",
+		binding.RoleId, binding.UserId, binding.Scope, binding.ScopeId,
+	).Delete(&model.RoleBindingModel{}).Error
+}
+
+func (repo *RoleRepo) DeleteBindingsByUserId(ctx context.Context, userId uint64) error {
+	return trace.DB(ctx, repo.db).Where("user_id = ?", userId).Delete(&model.RoleBindingModel{}).Error
+}
+
+func (repo *RoleRepo) ListBindings(ctx context.Context, condition model.RoleBindingModel) ([]*model.RoleBindingModel, error) {
+	var result []*model.RoleBindingModel
+	if err := trace.DB(ctx, repo.db).Where(&condition).Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[role_repo] list role binding err")
+	}
+	return result, nil
+}
+
+func (repo *RoleRepo) Close() {
+	repo.db.Close()
+}
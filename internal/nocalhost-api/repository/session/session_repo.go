@@ -0,0 +1,110 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type SessionRepo struct {
+	db *gorm.DB
+}
+
+func NewSessionRepo(db *gorm.DB) *SessionRepo {
+	return &SessionRepo{
+		db: db,
+	}
+}
+
+func (repo *SessionRepo) Create(ctx context.Context, session *model.SessionModel) error {
+	if err := trace.DB(ctx, repo.db).Create(session).Error; err != nil {
+		return errors.Wrap(err, "[session_repo] create session err")
+	}
+	return nil
+}
+
+func (repo *SessionRepo) GetBySid(ctx context.Context, sid string) (*model.SessionModel, error) {
+	result := model.SessionModel{}
+	if err := trace.DB(ctx, repo.db).Where("sid = ?", sid).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[session_repo] get session err")
+	}
+	return &result, nil
+}
+
+func (repo *SessionRepo) Get(ctx context.Context, userId, id uint64) (*model.SessionModel, error) {
+	result := model.SessionModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ? and user_id = ?", id, userId).First(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[session_repo] get session err")
+	}
+	return &result, nil
+}
+
+// ListActive list the sessions not revoked and not expired, the last seen first
+func (repo *SessionRepo) ListActive(ctx context.Context, userId uint64) ([]*model.SessionModel, error) {
+	var result []*model.SessionModel
+	if err := trace.DB(ctx, repo.db).Where("user_id = ? and revoked_at is null and expires_at > ?", userId, time.Now()).
+		Order("last_seen_at desc").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[session_repo] list session err")
+	}
+	return result, nil
+}
+
+// Touch update the last seen of the session
+func (repo *SessionRepo) Touch(ctx context.Context, sid, ip string, lastSeen time.Time) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.SessionModel{}).Where("sid = ?", sid).
+		Updates(map[string]interface{}{"ip": ip, "last_seen_at": lastSeen}).Error; err != nil {
+		return errors.Wrap(err, "[session_repo] touch session err")
+	}
+	return nil
+}
+
+func (repo *SessionRepo) Extend(ctx context.Context, sid string, expiresAt time.Time) error {
+	if err := trace.DB(ctx, repo.db).Model(&model.SessionModel{}).Where("sid = ?", sid).
+		Update("expires_at", expiresAt).Error; err != nil {
+		return errors.Wrap(err, "[session_repo] extend session err")
+	}
+	return nil
+}
+
+// Revoke revoke the session of user, returns the sid of it
+func (repo *SessionRepo) Revoke(ctx context.Context, userId, id uint64) (string, error) {
+	session, err := repo.Get(ctx, userId, id)
+	if err != nil {
+		return "", err
+	}
+
+	if err := trace.DB(ctx, repo.db).Model(session).Update("revoked_at", time.Now()).Error; err != nil {
+		return "", errors.Wrap(err, "[session_repo] revoke session err")
+	}
+	return session.Sid, nil
+}
+
+// RevokeAll revoke all the active sessions of user, returns the sid of them
+func (repo *SessionRepo) RevokeAll(ctx context.Context, userId uint64) ([]string, error) {
+	var sids []string
+	if err := trace.DB(ctx, repo.db).Model(&model.SessionModel{}).Where("user_id = ? and revoked_at is null", userId).
+		Pluck("sid", &sids).Error; err != nil {
+		return nil, errors.Wrap(err, "[session_repo] list session err")
+	}
+
+	if err := trace.DB(ctx, repo.db).Model(&model.SessionModel{}).Where("user_id = ? and revoked_at is null", userId).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return nil, errors.Wrap(err, "[session_repo] revoke sessions err")
+	}
+	return sids, nil
+}
+
+// Close close db
+func (repo *SessionRepo) Close() {
+	repo.db.Close()
+}
@@ -0,0 +1,152 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+type TeamRepo struct {
+	db *gorm.DB
+}
+
+func NewTeamRepo(db *gorm.DB) *TeamRepo {
+	return &TeamRepo{
+		db: db,
+	}
+}
+
+func (repo *TeamRepo) Create(ctx context.Context, team model.TeamModel) (model.TeamModel, error) {
+	if err := trace.DB(ctx, repo.db).Create(&team).Error; err != nil {
+		return team, errors.Wrap(err, "[team_repo] create team err")
+	}
+	return team, nil
+}
+
+func (repo *TeamRepo) Update(ctx context.Context, id uint64, name, description string) error {
+	return trace.DB(ctx, repo.db).Model(&model.TeamModel{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"name": name, "description": description}).Error
+}
+
+func (repo *TeamRepo) Get(ctx context.Context, id uint64) (*model.TeamModel, error) {
+	result := model.TeamModel{}
+	if err := trace.DB(ctx, repo.db).Where("id = ?", id).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (repo *TeamRepo) List(ctx context.Context) ([]*model.TeamModel, error) {
+	var result []*model.TeamModel
+	if err := trace.DB(ctx, repo.db).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[team_repo] list team err")
+	}
+	return result, nil
+}
+
+func (repo *TeamRepo) ListByUserId(ctx context.Context, userId uint64) ([]*model.TeamModel, error) {
+	var result []*model.TeamModel
+	if err := trace.DB(ctx, repo.db).Where(
+		"id in (?)", trace.DB(ctx, repo.db).Table("team_members").Select("team_id").Where("user_id = ?", userId).SubQuery(),
+	).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[team_repo] list team by user err")
+	}
+	return result, nil
+}
+
+// Delete delete the team with its members and grants
+func (repo *TeamRepo) Delete(ctx context.Context, id uint64) error {
+	return trace.DB(ctx, repo.db).Transaction(
+		func(tx *gorm.DB) error {
+			if err := tx.Where("team_id = ?", id).Delete(&model.TeamMemberModel{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("team_id = ?", id).Delete(&model.TeamGrantModel{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id = ?", id).Delete(&model.TeamModel{}).Error
+		},
+	)
+}
+
+func (repo *TeamRepo) AddMembers(ctx context.Context, teamId uint64, userIds []uint64) error {
+	return trace.DB(ctx, repo.db).Transaction(
+		func(tx *gorm.DB) error {
+			for _, userId := range userIds {
+				if err := tx.Where(model.TeamMemberModel{TeamId: teamId, UserId: userId}).
+					FirstOrCreate(&model.TeamMemberModel{}).Error; err != nil {
+					return errors.Wrap(err, "[team_repo] add team member err")
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (repo *TeamRepo) RemoveMembers(ctx context.Context, teamId uint64, userIds []uint64) error {
+	return trace.DB(ctx, repo.db).Where("team_id = ? and user_id in (?)", teamId, userIds).
+		Delete(&model.TeamMemberModel{}).Error
+}
+
+func (repo *TeamRepo) RemoveMemberFromAll(ctx context.Context, userId uint64) error {
+	return trace.DB(ctx, repo.db).Where("user_id = ?", userId).Delete(&model.TeamMemberModel{}).Error
+}
+
+func (repo *TeamRepo) ListMembers(ctx context.Context, teamId uint64) ([]*model.TeamMemberModel, error) {
+	var result []*model.TeamMemberModel
+	if err := trace.DB(ctx, repo.db).Where("team_id = ?", teamId).Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[team_repo] list team member err")
+	}
+	return result, nil
+}
+
+// SaveGrant create the grant or update the role if exists
+func (repo *TeamRepo) SaveGrant(ctx context.Context, grant model.TeamGrantModel) (model.TeamGrantModel, error) {
+	result := model.TeamGrantModel{}
+	err := trace.DB(ctx, repo.db).Where(
+		model.TeamGrantModel{TeamId: grant.TeamId, ResourceType: grant.ResourceType, ResourceId: grant.ResourceId},
+	).Assign(model.TeamGrantModel{Role: grant.Role}).FirstOrCreate(&result).Error
+	if err != nil {
+		return result, errors.Wrap(err, "[team_repo] save team grant err")
+	}
+	return result, nil
+}
+
+func (repo *TeamRepo) GetGrant(ctx context.Context, teamId uint64, resourceType string, resourceId uint64) (
+	*model.TeamGrantModel, error,
+) {
+	result := model.TeamGrantModel{}
+	if err := trace.DB(ctx, repo.db).Where(
+		"team_id = ? and resource_type = ? and resource_id = ?", teamId, resourceType, resourceId,
+	).First(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (repo *TeamRepo) DeleteGrant(ctx context.Context, teamId uint64, resourceType string, resourceId uint64) error {
+	return trace.DB(ctx, repo.db).Where(
+		"team_id = ? and resource_type = ? and resource_id = ?", teamId, resourceType, resourceId,
+	).Delete(&model.TeamGrantModel{}).Error
+}
+
+func (repo *TeamRepo) ListGrants(ctx context.Context, condition model.TeamGrantModel) ([]*model.TeamGrantModel, error) {
+	var result []*model.TeamGrantModel
+	if err := trace.DB(ctx, repo.db).Where(&condition).Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[team_repo] list team grant err")
+	}
+	return result, nil
+}
+
+func (repo *TeamRepo) Close() {
+	repo.db.Close()
+}
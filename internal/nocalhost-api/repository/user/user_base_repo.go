@@ -13,6 +13,7 @@ import (
 
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 )
 
 // BaseRepo
@@ -35,8 +36,7 @@ import (
 //	DeleteOutOfSyncLdapUser(ldapGen uint64) (int64, error)
 //	UpdateUsersLdapGen(list []*model.UserBaseModel, gen uint64) bool
 //
-//	// deprecated
-//	GetUserList(ctx context.Context) ([]*model.UserList, error)
+//	GetUserList(ctx context.Context, query model.UserListQuery) ([]*model.UserList, uint64, error)
 //}
 
 // UserBaseRepo
@@ -72,22 +72,57 @@ func (repo *UserBaseRepo) DeleteOutOfSyncLdapUser(ldapGen uint64) (int64, error)
 	return exec.RowsAffected, exec.Error
 }
 
-// deprecated
-func (repo *UserBaseRepo) GetUserList(ctx context.Context) ([]*model.UserList, error) {
+// GetUserList list users with the count of dev spaces, and the total count matches the query
+func (repo *UserBaseRepo) GetUserList(ctx context.Context, query model.UserListQuery) (
+	[]*model.UserList, uint64, error,
+) {
+	where := "u.deleted_at is null"
+	var args []interface{}
+	if query.Search != "" {
+		where += " and (u.email like ? or u.name like ?)"
+		like := "%" + query.Search + "%"
+		args = append(args, like, like)
+	}
+	if query.Status != nil {
+		where += " and u.status = ?"
+		args = append(args, *query.Status)
+	}
+
+	var total struct {
+		Count uint64 `gorm:"column:count"`
+	}
+	if err := trace.DB(ctx, repo.db).Raw("select count(*) as count from users as u where "+where, args...).
+		Scan(&total).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "[user_repo] count user list err")
+	}
+
+	order, ok := model.UserListSortFields[query.Sort]
+	if !ok {
+		order = "u.id"
+	}
+	if query.Desc {
+		order += " desc"
+	}
+
+	sql := "select u.id as id,u.name as name,u.sa_name as sa_name,u.email as email," +
+		"count(distinct cu.id) as cluster_count,u.status as status," +
+		" u.is_admin as is_admin from users as u left join clusters_users as cu on cu.user_id=u.id " +
+		"and cu.deleted_at is null where " + where + " group by u.id order by " + order
+	if query.Page > 0 && query.Size > 0 {
+		sql += " limit ? offset ?"
+		args = append(args, query.Size, (query.Page-1)*query.Size)
+	}
+
 	var result []*model.UserList
-	repo.db.Raw(
-		"select u.id as id,u.name as name,u.sa_name as sa_name,u.email as email," +
-			"count(distinct cu.id) as cluster_count,u.status as status," +
-			" u.is_admin as is_admin from users as u left join clusters_users as cu on cu.user_id=u.id " +
-			"where u.deleted_at is null and cu.deleted_at is null group by u.id",
-	).
-		Scan(&result)
-	return result, nil
+	if err := trace.DB(ctx, repo.db).Raw(sql, args...).Scan(&result).Error; err != nil {
+		return nil, 0, errors.Wrap(err, "[user_repo] get user list err")
+	}
+	return result, total.Count, nil
 }
 
 func (repo *UserBaseRepo) GetUserHasNotSa(ctx context.Context) ([]*model.UserBaseModel, error) {
 	var result []*model.UserBaseModel
-	repo.db.
+	trace.DB(ctx, repo.db).
 		Raw("select * from users where sa_name is null").
 		Scan(&result)
 	return result, nil
@@ -96,7 +131,7 @@ func (repo *UserBaseRepo) GetUserHasNotSa(ctx context.Context) ([]*model.UserBas
 func (repo *UserBaseRepo) GetUserPageable(ctx context.Context, page, limit int) ([]*model.UserBaseModel, error) {
 	var result []*model.UserBaseModel
 
-	raw := repo.db.
+	raw := trace.DB(ctx, repo.db).
 		Raw("select * from users where deleted_at is null")
 
 	if page > 0 && limit > 0 {
@@ -110,7 +145,7 @@ func (repo *UserBaseRepo) GetUserPageable(ctx context.Context, page, limit int)
 
 func (repo *UserBaseRepo) ListStartById(ctx context.Context, idStart uint64, limit uint64) ([]*model.UserBaseModel, error) {
 	var result []*model.UserBaseModel
-	repo.db.Raw(
+	trace.DB(ctx, repo.db).Raw(
 		"SELECT * FROM users "+
 			"WHERE id > ? ORDER BY id LIMIT ?", idStart, limit,
 	).Scan(&result)
@@ -122,7 +157,7 @@ func (repo *UserBaseRepo) Delete(ctx context.Context, id uint64) error {
 	users := model.UserBaseModel{
 		ID: id,
 	}
-	if result := repo.db.Where("id=?", id).Unscoped().Delete(&users); result.RowsAffected > 0 {
+	if result := trace.DB(ctx, repo.db).Where("id=?", id).Unscoped().Delete(&users); result.RowsAffected > 0 {
 		return nil
 	}
 	return errors.New("user delete fail")
@@ -130,7 +165,7 @@ func (repo *UserBaseRepo) Delete(ctx context.Context, id uint64) error {
 
 // Create
 func (repo *UserBaseRepo) Create(ctx context.Context, user model.UserBaseModel) (model.UserBaseModel, error) {
-	err := repo.db.Create(&user).Error
+	err := trace.DB(ctx, repo.db).Create(&user).Error
 	if err != nil {
 		return user, errors.Wrap(err, "[user_repo] create user err")
 	}
@@ -138,6 +173,27 @@ func (repo *UserBaseRepo) Create(ctx context.Context, user model.UserBaseModel)
 	return user, nil
 }
 
+// CreateInTransaction create all users or none of them, the index
+// of the failed user is returned with the error
+func (repo *UserBaseRepo) CreateInTransaction(ctx context.Context, users []*model.UserBaseModel) (int, error) {
+	failed := -1
+	err := trace.DB(ctx, repo.db).Transaction(
+		func(tx *gorm.DB) error {
+			for i, user := range users {
+				if err := tx.Create(user).Error; err != nil {
+					failed = i
+					return err
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return failed, errors.Wrap(err, "[user_repo] create users err")
+	}
+	return failed, nil
+}
+
 // Creates
 func (repo *UserBaseRepo) Creates(ctx context.Context, users []*model.UserBaseModel) error {
 	if len(users) == 0 {
@@ -169,7 +225,7 @@ func (repo *UserBaseRepo) Creates(ctx context.Context, users []*model.UserBaseMo
 
 	sql = sql[:len(sql)-1]
 
-	return repo.db.Exec(sql, args...).Error
+	return trace.DB(ctx, repo.db).Exec(sql, args...).Error
 }
 
 // Update
@@ -183,22 +239,63 @@ func (repo *UserBaseRepo) Update(ctx context.Context, id uint64, userMap *model.
 	if err != nil {
 		return user, errors.Wrap(err, "[user_repo] update user data err")
 	}
-	err = repo.db.Model(&user).Updates(&userMap).Where("id=?", id).Error
+	err = trace.DB(ctx, repo.db).Model(&user).Updates(&userMap).Where("id=?", id).Error
 	if err != nil {
 		return user, errors.Wrap(err, "[user_repo] update user data error")
 	}
 	return user, nil
 }
 
+// UpdateClusterQuota
+func (repo *UserBaseRepo) UpdateClusterQuota(ctx context.Context, id uint64, quota uint64) error {
+	return trace.DB(ctx, repo.db).Model(&model.UserBaseModel{}).Where("id = ?", id).Update("cluster_quota", quota).Error
+}
+
 // Update
 func (repo *UserBaseRepo) UpdateServiceAccountName(ctx context.Context, id uint64, saName string) error {
-	if err := repo.db.Exec("UPDATE users SET sa_name = ? WHERE id = ?", saName, id).Error; err != nil {
+	if err := trace.DB(ctx, repo.db).Exec("UPDATE users SET sa_name = ? WHERE id = ?", saName, id).Error; err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// RevokeTokens
+func (repo *UserBaseRepo) RevokeTokens(ctx context.Context, id uint64) error {
+	db := trace.DB(ctx, repo.db)
+	if err := db.Exec("UPDATE users SET token_generation = token_generation + 1 WHERE id = ?", id).Error; err != nil {
+		return errors.Wrap(err, "[user_repo] revoke user tokens err")
+	}
+
+	return nil
+}
+
+// UpdateTotp
+func (repo *UserBaseRepo) UpdateTotp(ctx context.Context, id uint64, secret string, enabled uint64,
+	recoveryCodes string) error {
+	if err := trace.DB(ctx, repo.db).Exec(
+		"UPDATE users SET totp_secret = ?, totp_enabled = ?, totp_recovery_codes = ? WHERE id = ?",
+		secret, enabled, recoveryCodes, id,
+	).Error; err != nil {
+		return errors.Wrap(err, "[user_repo] update user totp err")
+	}
+
+	return nil
+}
+
+// AcceptTotpStep set the time step of the totp code accepted last, returns
+// false if the step is not after the one accepted before
+func (repo *UserBaseRepo) AcceptTotpStep(ctx context.Context, id uint64, step int64) (bool, error) {
+	db := trace.DB(ctx, repo.db).Exec(
+		"UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?", step, id, step,
+	)
+	if db.Error != nil {
+		return false, errors.Wrap(db.Error, "[user_repo] accept user totp step err")
+	}
+
+	return db.RowsAffected > 0, nil
+}
+
 // GetUserByID
 func (repo *UserBaseRepo) GetUserByID(ctx context.Context, uid uint64) (userBase *model.UserBaseModel, err error) {
 	start := time.Now()
@@ -208,7 +305,7 @@ func (repo *UserBaseRepo) GetUserByID(ctx context.Context, uid uint64) (userBase
 
 	data := new(model.UserBaseModel)
 
-	err = repo.db.First(data, uid).Error
+	err = trace.DB(ctx, repo.db).First(data, uid).Error
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, errors.Wrap(err, "[repo.user_base] get user data err")
 	}
@@ -218,7 +315,7 @@ func (repo *UserBaseRepo) GetUserByID(ctx context.Context, uid uint64) (userBase
 // GetUserBySa
 func (repo *UserBaseRepo) GetUserBySa(ctx context.Context, sa string) (*model.UserBaseModel, error) {
 	user := model.UserBaseModel{}
-	err := repo.db.Where("sa_name = ?", sa).First(&user).Error
+	err := trace.DB(ctx, repo.db).Where("sa_name = ?", sa).First(&user).Error
 	if err != nil {
 		return nil, errors.Wrap(err, "[user_repo] get user err by sa_name")
 	}
@@ -229,7 +326,7 @@ func (repo *UserBaseRepo) GetUserBySa(ctx context.Context, sa string) (*model.Us
 // GetUserByPhone
 func (repo *UserBaseRepo) GetUserByPhone(ctx context.Context, phone int64) (*model.UserBaseModel, error) {
 	user := model.UserBaseModel{}
-	err := repo.db.Where("phone = ?", phone).First(&user).Error
+	err := trace.DB(ctx, repo.db).Where("phone = ?", phone).First(&user).Error
 	if err != nil {
 		return nil, errors.Wrap(err, "[user_repo] get user err by phone")
 	}
@@ -237,10 +334,23 @@ func (repo *UserBaseRepo) GetUserByPhone(ctx context.Context, phone int64) (*mod
 	return &user, nil
 }
 
+// ListDeprovisioned the users disabled by the sync of ldap as they are not
+// found in ldap any more, and not updated since before
+func (repo *UserBaseRepo) ListDeprovisioned(ctx context.Context, before time.Time) ([]*model.UserBaseModel, error) {
+	var result []*model.UserBaseModel
+	if err := trace.DB(ctx, repo.db).Where(
+		"ldap_dn is not null and ldap_dn != '' and status = 0 and updated_at < ? and "+
+			"ldap_gen < (SELECT MAX(ldap_gen) FROM ldap)", before,
+	).Order("id").Find(&result).Error; err != nil {
+		return nil, errors.Wrap(err, "[user_repo] list deprovisioned users err")
+	}
+	return result, nil
+}
+
 // GetUserByEmail
 func (repo *UserBaseRepo) GetUserByEmail(ctx context.Context, phone string) (*model.UserBaseModel, error) {
 	user := model.UserBaseModel{}
-	err := repo.db.Where("email = ?", phone).First(&user).Error
+	err := trace.DB(ctx, repo.db).Where("email = ?", phone).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
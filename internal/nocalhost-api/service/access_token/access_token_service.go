@@ -0,0 +1,110 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package access_token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/access_token"
+)
+
+// Prefix all the personal access tokens start with, use to
+// distinguish from the jwt in Authorization header
+const Prefix = "nhp_"
+
+type AccessToken struct {
+	accessTokenRepo *access_token.AccessTokenRepo
+}
+
+func NewAccessTokenService() *AccessToken {
+	db := model.GetDB()
+	return &AccessToken{accessTokenRepo: access_token.NewAccessTokenRepo(db)}
+}
+
+// IsAccessToken
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, Prefix)
+}
+
+// Create generate a new token for user, the plain token is only
+// returned here and can not be retrieved afterwards
+func (srv *AccessToken) Create(
+	ctx context.Context, userId uint64, name string, scopes []string, expiresAt *time.Time,
+) (string, model.AccessTokenModel, error) {
+	for _, scope := range scopes {
+		if scope != model.AccessTokenScopeRead && scope != model.AccessTokenScopeWrite {
+			return "", model.AccessTokenModel{}, errors.Errorf("unsupported scope %s", scope)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes = []string{model.AccessTokenScopeRead}
+	}
+
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", model.AccessTokenModel{}, errors.Wrap(err, "generate access token err")
+	}
+	plain := Prefix + hex.EncodeToString(b)
+
+	result, err := srv.accessTokenRepo.Create(
+		ctx, model.AccessTokenModel{
+			UserId:    userId,
+			Name:      name,
+			TokenHash: hash(plain),
+			Prefix:    plain[:len(Prefix)+6],
+			Scopes:    strings.Join(scopes, ","),
+			ExpiresAt: expiresAt,
+		},
+	)
+	if err != nil {
+		return "", result, err
+	}
+	return plain, result, nil
+}
+
+func (srv *AccessToken) ListByUserId(ctx context.Context, userId uint64) ([]*model.AccessTokenModel, error) {
+	return srv.accessTokenRepo.ListByUserId(ctx, userId)
+}
+
+func (srv *AccessToken) Revoke(ctx context.Context, userId, id uint64) error {
+	return srv.accessTokenRepo.Delete(ctx, userId, id)
+}
+
+func (srv *AccessToken) RevokeAll(ctx context.Context, userId uint64) error {
+	return srv.accessTokenRepo.DeleteByUserId(ctx, userId)
+}
+
+// Authenticate returns the token record if the plain token is valid
+func (srv *AccessToken) Authenticate(ctx context.Context, plain string) (*model.AccessTokenModel, error) {
+	token, err := srv.accessTokenRepo.GetByHash(ctx, hash(plain))
+	if err != nil {
+		return nil, errors.Wrap(err, "access token not found")
+	}
+
+	if token.IsExpired() {
+		return nil, errors.New("access token is expired")
+	}
+
+	srv.accessTokenRepo.UpdateLastUsed(ctx, token.ID, time.Now())
+	return token, nil
+}
+
+func (srv *AccessToken) Close() {
+	srv.accessTokenRepo.Close()
+}
+
+func hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
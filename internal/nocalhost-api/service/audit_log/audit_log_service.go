@@ -0,0 +1,35 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package audit_log
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/audit_log"
+)
+
+type AuditLog struct {
+	auditLogRepo *audit_log.AuditLogRepo
+}
+
+func NewAuditLogService() *AuditLog {
+	db := model.GetDB()
+	return &AuditLog{auditLogRepo: audit_log.NewAuditLogRepo(db)}
+}
+
+func (srv *AuditLog) Record(ctx context.Context, log *model.AuditLogModel) error {
+	return srv.auditLogRepo.Create(ctx, log)
+}
+
+func (srv *AuditLog) List(ctx context.Context, query model.AuditLogQuery) ([]*model.AuditLogModel, uint64, error) {
+	return srv.auditLogRepo.List(ctx, query)
+}
+
+// Close close all repo
+func (srv *AuditLog) Close() {
+	srv.auditLogRepo.Close()
+}
@@ -0,0 +1,43 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package bulk_deploy
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/bulk_deploy"
+)
+
+type BulkDeploy struct {
+	bulkDeployRepo *bulk_deploy.BulkDeployRepo
+}
+
+func NewBulkDeployService() *BulkDeploy {
+	db := model.GetDB()
+	return &BulkDeploy{bulkDeployRepo: bulk_deploy.NewBulkDeployRepo(db)}
+}
+
+func (srv *BulkDeploy) Create(ctx context.Context, b *model.BulkDeployModel) error {
+	return srv.bulkDeployRepo.Create(ctx, b)
+}
+
+func (srv *BulkDeploy) Get(ctx context.Context, id uint64) (*model.BulkDeployModel, error) {
+	return srv.bulkDeployRepo.Get(ctx, id)
+}
+
+func (srv *BulkDeploy) List(ctx context.Context) ([]*model.BulkDeployModel, error) {
+	return srv.bulkDeployRepo.List(ctx)
+}
+
+func (srv *BulkDeploy) UpdateStatus(ctx context.Context, id uint64, status string) error {
+	return srv.bulkDeployRepo.UpdateStatus(ctx, id, status)
+}
+
+// Close close db
+func (srv *BulkDeploy) Close() {
+	srv.bulkDeployRepo.Close()
+}
@@ -25,24 +25,24 @@ func NewClusterService() *Cluster {
 }
 
 func (srv *Cluster) Evict(id uint64) {
-	c := cache.Module(cache.CLUSTER)
-	_, _ = c.Delete(id)
+	cache.Delete(cache.CLUSTER, id)
 }
 
 func (srv *Cluster) GetCache(id uint64) (model.ClusterModel, error) {
-	c := cache.Module(cache.CLUSTER)
-	value, err := c.Value(id)
-	if err == nil {
-		clusterModel := value.Data().(*model.ClusterModel)
-		return *clusterModel, nil
+	if value, ok := cache.Value(cache.CLUSTER, id); ok {
+		return *value.(*model.ClusterModel), nil
 	}
 
-	result, err := srv.Get(context.TODO(), id)
-	if err != nil {
-		return result, errors.Wrapf(err, "get cluster")
+	var result model.ClusterModel
+	if !cache.GetRemote(cache.CLUSTER, id, &result) {
+		var err error
+		if result, err = srv.Get(context.TODO(), id); err != nil {
+			return result, errors.Wrapf(err, "get cluster")
+		}
+		cache.SetRemote(cache.CLUSTER, &result, result.ID)
 	}
 
-	c.Add(result.ID, cache.OUT_OF_DATE, &result)
+	cache.Module(cache.CLUSTER).Add(result.ID, cache.OUT_OF_DATE, &result)
 	return result, nil
 }
 
@@ -62,7 +62,7 @@ func (srv *Cluster) GetAny(ctx context.Context, where map[string]interface{}) ([
 	return srv.clusterRepo.GetAny(ctx, where)
 }
 
-func (srv *Cluster) Create(ctx context.Context, name, kubeconfig, storageClass, server, extraApiServer, clusterInfo string, userId uint64) (model.ClusterModel, error) {
+func (srv *Cluster) Create(ctx context.Context, name, kubeconfig, storageClass, server, extraApiServer, clusterInfo string, labels, annotations model.Labels, userId uint64) (model.ClusterModel, error) {
 	c := model.ClusterModel{
 		Name:           name,
 		UserId:         userId,
@@ -71,6 +71,8 @@ func (srv *Cluster) Create(ctx context.Context, name, kubeconfig, storageClass,
 		Info:           clusterInfo,
 		StorageClass:   storageClass,
 		ExtraApiServer: extraApiServer,
+		Labels:         labels,
+		Annotations:    annotations,
 	}
 	result, err := srv.clusterRepo.Create(ctx, c)
 	if err != nil {
@@ -0,0 +1,121 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_agent
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/cluster_agent"
+	"nocalhost/pkg/nocalhost-api/pkg/agent"
+)
+
+// Prefix all the registration tokens of agent start with
+const Prefix = "nha_"
+
+type ClusterAgent struct {
+	clusterAgentRepo *cluster_agent.ClusterAgentRepo
+}
+
+func NewClusterAgentService() *ClusterAgent {
+	db := model.GetDB()
+	return &ClusterAgent{clusterAgentRepo: cluster_agent.NewClusterAgentRepo(db)}
+}
+
+// Create generate the registration token of agent, the plain token is
+// only returned here and can not be retrieved afterwards
+func (srv *ClusterAgent) Create(
+	ctx context.Context, userId uint64, name, storageClass, extraApiServer string,
+) (string, model.ClusterAgentModel, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", model.ClusterAgentModel{}, errors.Wrap(err, "generate agent token err")
+	}
+	plain := Prefix + hex.EncodeToString(b)
+
+	result, err := srv.clusterAgentRepo.Create(
+		ctx, model.ClusterAgentModel{
+			Name:           name,
+			StorageClass:   storageClass,
+			ExtraApiServer: extraApiServer,
+			UserId:         userId,
+			TokenHash:      hash(plain),
+			Prefix:         plain[:len(Prefix)+6],
+		},
+	)
+	if err != nil {
+		return "", result, err
+	}
+	return plain, result, nil
+}
+
+// Authenticate returns the agent if the registration token is valid
+func (srv *ClusterAgent) Authenticate(ctx context.Context, plain string) (*model.ClusterAgentModel, error) {
+	result, err := srv.clusterAgentRepo.GetByHash(ctx, hash(plain))
+	if err != nil {
+		return nil, errors.Wrap(err, "agent token not found")
+	}
+	return result, nil
+}
+
+func (srv *ClusterAgent) Get(ctx context.Context, id uint64) (*model.ClusterAgentModel, error) {
+	result, err := srv.clusterAgentRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	result.Connected = agent.Connected(result.ID)
+	return result, nil
+}
+
+// List the agents created by user, all if userId is zero
+func (srv *ClusterAgent) List(ctx context.Context, userId uint64) ([]*model.ClusterAgentModel, error) {
+	result, err := srv.clusterAgentRepo.List(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range result {
+		a.Connected = agent.Connected(a.ID)
+	}
+	return result, nil
+}
+
+// Connected records the cluster registered by the agent, and the
+// message of registering if failed
+func (srv *ClusterAgent) Connected(ctx context.Context, id, clusterId uint64, message string) error {
+	update := map[string]interface{}{
+		"connected_at": time.Now(),
+		"message":      message,
+	}
+	if clusterId > 0 {
+		update["cluster_id"] = clusterId
+	}
+	return srv.clusterAgentRepo.Update(ctx, id, update)
+}
+
+// Delete the agent and close its tunnel, the cluster registered is
+// not deleted
+func (srv *ClusterAgent) Delete(ctx context.Context, id uint64) error {
+	if err := srv.clusterAgentRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	agent.Disconnect(id)
+	return nil
+}
+
+func (srv *ClusterAgent) Close() {
+	srv.clusterAgentRepo.Close()
+}
+
+func hash(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,121 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service/leader"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/notify"
+	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+)
+
+// at most probe so many clusters at the same time
+const probeConcurrency = 8
+
+var clusterProberOnce = sync.Once{}
+
+// StartClusterProber probe the health of all the clusters periodically
+func StartClusterProber() {
+	go clusterProberOnce.Do(
+		func() {
+			tick := time.NewTicker(setupcluster.ProbeInterval())
+			defer tick.Stop()
+
+			for shutdown.Run(leader.Only(ProbeClusters)) {
+				select {
+				case <-tick.C:
+				case <-shutdown.Stopping():
+					return
+				}
+			}
+		},
+	)
+}
+
+// ProbeClusters probe and store the health of all the clusters
+func ProbeClusters() {
+	defer metrics.ObserveJob("cluster_probe")()
+
+	clusters, _ := Svc.ClusterSvc.GetList(context.TODO())
+
+	wg := sync.WaitGroup{}
+	limit := make(chan struct{}, probeConcurrency)
+	for _, cluster := range clusters {
+		wg.Add(1)
+		limit <- struct{}{}
+
+		go func(cluster *model.ClusterList) {
+			defer func() {
+				if err := recover(); err != nil {
+					log.Errorf("Panic while probing cluster %d: %v", cluster.ID, err)
+				}
+				<-limit
+				wg.Done()
+			}()
+			ProbeCluster(cluster.ID, cluster.HealthStatus, []byte(cluster.KubeConfig))
+		}(cluster)
+	}
+	wg.Wait()
+}
+
+// ProbeCluster probe the health of cluster and store it, the status change is logged,
+// and notified if the cluster becomes unreachable
+func ProbeCluster(clusterId uint64, lastStatus string, kubeConfig []byte) *setupcluster.Health {
+	health := setupcluster.Probe(kubeConfig)
+	if health.Status != lastStatus {
+		log.Infof(
+			"Health of cluster %d changes from '%s' to '%s' %s", clusterId, lastStatus, health.Status, health.Message,
+		)
+		if health.Status == setupcluster.HealthUnreachable {
+			notify.Notify(
+				notify.ClusterUnreachable, map[string]interface{}{"cluster_id": clusterId},
+				"Cluster %d is unreachable: %s", clusterId, health.Message,
+			)
+		}
+	}
+
+	now := time.Now()
+	update := map[string]interface{}{
+		"health_status":     health.Status,
+		"health_message":    health.Message,
+		"health_checked_at": &now,
+	}
+	if health.ServerVersion != "" {
+		update["server_version"] = health.ServerVersion
+	}
+
+	if _, err := Svc.ClusterSvc.Update(context.TODO(), update, clusterId); err != nil {
+		log.Errorf("Failed to update health of cluster %d: %v", clusterId, err)
+	}
+	return health
+}
+
+// CheckClusterReachable the check of health, healthy if any of the clusters is
+// not unreachable by the last probe, or no cluster is added yet
+func CheckClusterReachable(ctx context.Context) error {
+	clusters, err := Svc.ClusterSvc.GetList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		if cluster.HealthStatus != setupcluster.HealthUnreachable {
+			return nil
+		}
+	}
+	if len(clusters) == 0 {
+		return nil
+	}
+	return errors.Errorf("all the %d clusters are unreachable", len(clusters))
+}
@@ -24,23 +24,24 @@ func NewClusterUserService() *ClusterUser {
 }
 
 func (srv *ClusterUser) Evict(id uint64) {
-	c := cache.Module(cache.CLUSTER_USER)
-	value, err := c.Value(id)
-	if err == nil {
+	keys := []interface{}{id, "*"}
+	remote := model.ClusterUserModel{}
+	if value, err := cache.Module(cache.CLUSTER_USER).Value(id); err == nil {
 		cu := value.Data().(*model.ClusterUserModel)
-		_, _ = c.Delete(keyForClusterAndNameSpace(cu.ClusterId, cu.Namespace))
-		_, _ = c.Delete(id)
+		keys = append(keys, keyForClusterAndNameSpace(cu.ClusterId, cu.Namespace))
+	} else if cache.GetRemote(cache.CLUSTER_USER, id, &remote) {
+		keys = append(keys, keyForClusterAndNameSpace(remote.ClusterId, remote.Namespace))
 	}
-	_, _ = c.Delete("*")
+	cache.Delete(cache.CLUSTER_USER, keys...)
 }
 
 func (srv *ClusterUser) GetAllCache() []model.ClusterUserModel {
 	c := cache.Module(cache.CLUSTER_USER)
-	value, err := c.Value("*")
+	value, ok := cache.Value(cache.CLUSTER_USER, "*")
 
 	resultList := []model.ClusterUserModel{}
-	if err == nil {
-		clusterUserModels := value.Data().([]*model.ClusterUserModel)
+	if ok {
+		clusterUserModels := value.([]*model.ClusterUserModel)
 		for _, userModel := range clusterUserModels {
 			resultList = append(resultList, *userModel)
 		}
@@ -64,20 +65,24 @@ func (srv *ClusterUser) GetAllCache() []model.ClusterUserModel {
 func (srv *ClusterUser) GetCache(id uint64) (
 	model.ClusterUserModel, error,
 ) {
-	c := cache.Module(cache.CLUSTER_USER)
-	value, err := c.Value(id)
-	if err == nil {
-		clusterUserModel := value.Data().(*model.ClusterUserModel)
-		return *clusterUserModel, nil
+	if value, ok := cache.Value(cache.CLUSTER_USER, id); ok {
+		return *value.(*model.ClusterUserModel), nil
 	}
 
-	result, err := srv.clusterUserRepo.GetFirst(
-		model.ClusterUserModel{ID: id},
-	)
-	if err != nil {
-		return model.ClusterUserModel{}, errors.Wrapf(err, "GetCache users_cluster error")
+	result := &model.ClusterUserModel{}
+	if !cache.GetRemote(cache.CLUSTER_USER, id, result) {
+		var err error
+		if result, err = srv.clusterUserRepo.GetFirst(
+			model.ClusterUserModel{ID: id},
+		); err != nil {
+			return model.ClusterUserModel{}, errors.Wrapf(err, "GetCache users_cluster error")
+		}
+		cache.SetRemote(
+			cache.CLUSTER_USER, result, result.ID, keyForClusterAndNameSpace(result.ClusterId, result.Namespace),
+		)
 	}
 
+	c := cache.Module(cache.CLUSTER_USER)
 	c.Add(keyForClusterAndNameSpace(result.ClusterId, result.Namespace), cache.OUT_OF_DATE, result)
 	c.Add(result.ID, cache.OUT_OF_DATE, result)
 	return *result, nil
@@ -86,20 +91,24 @@ func (srv *ClusterUser) GetCache(id uint64) (
 func (srv *ClusterUser) GetCacheByClusterAndNameSpace(clusterId uint64, namespace string) (
 	model.ClusterUserModel, error,
 ) {
-	c := cache.Module(cache.CLUSTER_USER)
-	value, err := c.Value(keyForClusterAndNameSpace(clusterId, namespace))
-	if err == nil {
-		clusterUserModel := value.Data().(*model.ClusterUserModel)
-		return *clusterUserModel, nil
+	if value, ok := cache.Value(cache.CLUSTER_USER, keyForClusterAndNameSpace(clusterId, namespace)); ok {
+		return *value.(*model.ClusterUserModel), nil
 	}
 
-	result, err := srv.clusterUserRepo.GetFirst(
-		model.ClusterUserModel{ClusterId: clusterId, Namespace: namespace},
-	)
-	if err != nil {
-		return model.ClusterUserModel{}, errors.Wrapf(err, "GetCache users_cluster error")
+	result := &model.ClusterUserModel{}
+	if !cache.GetRemote(cache.CLUSTER_USER, keyForClusterAndNameSpace(clusterId, namespace), result) {
+		var err error
+		if result, err = srv.clusterUserRepo.GetFirst(
+			model.ClusterUserModel{ClusterId: clusterId, Namespace: namespace},
+		); err != nil {
+			return model.ClusterUserModel{}, errors.Wrapf(err, "GetCache users_cluster error")
+		}
+		cache.SetRemote(
+			cache.CLUSTER_USER, result, result.ID, keyForClusterAndNameSpace(result.ClusterId, result.Namespace),
+		)
 	}
 
+	c := cache.Module(cache.CLUSTER_USER)
 	c.Add(keyForClusterAndNameSpace(result.ClusterId, result.Namespace), cache.OUT_OF_DATE, result)
 	c.Add(result.ID, cache.OUT_OF_DATE, result)
 	return *result, nil
@@ -131,7 +140,12 @@ func (srv *ClusterUser) ListV2(models model.ClusterUserModel) (
 		item.CreatedAt = userModel.CreatedAt
 		item.BaseDevSpaceId = userModel.BaseDevSpaceId
 		item.IsBaseSpace = userModel.IsBaseSpace
+		item.Isolated = userModel.Isolated
 		item.TraceHeader = userModel.TraceHeader
+		item.SleepConfig = userModel.SleepConfig
+		item.SleepReason = userModel.SleepReason
+		item.SleepAt = userModel.SleepAt
+		item.ExpireAt = userModel.ExpireAt
 		result = append(result, item)
 	}
 	return result, nil
@@ -175,6 +189,12 @@ func (srv *ClusterUser) Update(ctx context.Context, models *model.ClusterUserMod
 	return models, nil
 }
 
+// UpdateColumns update the columns of dev space, zero values are updated as well
+func (srv *ClusterUser) UpdateColumns(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	defer srv.Evict(id)
+	return srv.clusterUserRepo.UpdateColumns(id, columns)
+}
+
 func (srv *ClusterUser) GetList(ctx context.Context, models model.ClusterUserModel) (
 	[]*model.ClusterUserModel, error,
 ) {
@@ -198,7 +218,7 @@ func (srv *ClusterUser) GetFirst(ctx context.Context, models model.ClusterUserMo
 
 func (srv *ClusterUser) Create(
 	ctx context.Context, clusterId, userId, memory, cpu uint64, kubeConfig, devNameSpace, spaceName string,
-	spaceResourceLimit string, isBaseName bool, protected bool,
+	spaceResourceLimit string, isBaseName bool, protected bool, isolated bool,
 ) (model.ClusterUserModel, error) {
 	c := model.ClusterUserModel{
 
@@ -212,6 +232,7 @@ func (srv *ClusterUser) Create(
 		SpaceResourceLimit: spaceResourceLimit,
 		IsBaseSpace:        isBaseName,
 		Protected:          protected,
+		Isolated:           isolated,
 	}
 	result, err := srv.clusterUserRepo.Create(c)
 	if err != nil {
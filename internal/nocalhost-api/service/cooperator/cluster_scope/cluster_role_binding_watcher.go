@@ -16,6 +16,8 @@ import (
 	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/watcher"
 	"nocalhost/internal/nocalhost-api/service/cooperator/util"
+	"nocalhost/pkg/nocalhost-api/pkg/agent"
+	"nocalhost/pkg/nocalhost-api/pkg/cloudauth"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
 	"strings"
 	"sync"
@@ -199,6 +201,8 @@ func (crbw *clusterRoleBindingWatcher) Prepare() error {
 	if err != nil {
 		return err
 	}
+	cloudauth.Configure(c)
+	agent.Configure(c)
 
 	// creates the clientset
 	clientset, err := kubernetes.NewForConfig(c)
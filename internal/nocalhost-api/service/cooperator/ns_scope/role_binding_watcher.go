@@ -15,6 +15,8 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nhctl/watcher"
+	"nocalhost/pkg/nocalhost-api/pkg/agent"
+	"nocalhost/pkg/nocalhost-api/pkg/cloudauth"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
 	"strings"
 	"sync"
@@ -176,6 +178,8 @@ func (rbw *roleBindingWatcher) Prepare() error {
 	if err != nil {
 		return err
 	}
+	cloudauth.Configure(c)
+	agent.Configure(c)
 
 	// creates the clientset
 	clientset, err := kubernetes.NewForConfig(c)
@@ -0,0 +1,41 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cost
+
+import (
+	"context"
+	"time"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/cost"
+)
+
+type Cost struct {
+	costRepo *cost.CostRepo
+}
+
+func NewCostService() *Cost {
+	db := model.GetDB()
+	return &Cost{costRepo: cost.NewCostRepo(db)}
+}
+
+func (srv *Cost) Record(ctx context.Context, sample *model.CostSampleModel) error {
+	return srv.costRepo.Create(ctx, sample)
+}
+
+// Sum the samples of each dev space sampled in [from, to)
+func (srv *Cost) Sum(ctx context.Context, from, to time.Time) ([]*model.CostSum, error) {
+	return srv.costRepo.Sum(ctx, from, to)
+}
+
+func (srv *Cost) DeleteBefore(ctx context.Context, before time.Time) error {
+	return srv.costRepo.DeleteBefore(ctx, before)
+}
+
+// Close close all repo
+func (srv *Cost) Close() {
+	srv.costRepo.Close()
+}
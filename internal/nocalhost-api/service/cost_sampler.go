@@ -0,0 +1,179 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service/leader"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/cost"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+	"nocalhost/pkg/nocalhost-api/pkg/sleep"
+	"nocalhost/pkg/nocalhost-api/pkg/usage"
+)
+
+var costSamplerOnce = sync.Once{}
+
+// StartCostSampler sample the resources requested by the dev spaces
+// periodically, the cost reports are summed by the samples
+func StartCostSampler() {
+	go costSamplerOnce.Do(
+		func() {
+			tick := time.NewTicker(cost.SampleInterval())
+			defer tick.Stop()
+
+			for {
+				select {
+				case <-tick.C:
+				case <-shutdown.Stopping():
+					return
+				}
+				if !shutdown.Run(leader.Only(SampleCost)) {
+					return
+				}
+			}
+		},
+	)
+}
+
+// SampleCost record the cpu and memory requested by the pods running in
+// each dev space for the sample interval, the samples older than the
+// retention are deleted
+func SampleCost() {
+	defer metrics.ObserveJob("cost_sample")()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while sampling cost: %v", err)
+		}
+	}()
+
+	ctx := context.TODO()
+	hours := cost.SampleInterval().Hours()
+	now := time.Now()
+
+	devSpaces, _ := Svc.ClusterUserSvc.GetList(ctx, model.ClusterUserModel{})
+	byCluster := map[uint64][]*model.ClusterUserModel{}
+	for _, cu := range devSpaces {
+		if !cu.IsClusterAdmin() && cu.Namespace != "" {
+			byCluster[cu.ClusterId] = append(byCluster[cu.ClusterId], cu)
+		}
+	}
+	clusters, _ := Svc.ClusterSvc.GetList(ctx)
+	for _, cluster := range clusters {
+		if len(byCluster[cluster.ID]) == 0 || cluster.HealthStatus == setupcluster.HealthUnreachable {
+			continue
+		}
+		client, err := clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+		if err != nil {
+			log.Warnf("Failed to sample cost of cluster %d: %v", cluster.ID, err)
+			continue
+		}
+		// the pods of all the namespaces are listed once
+		pods, err := client.ListPods("")
+		if err != nil {
+			log.Warnf("Failed to sample cost of cluster %d: %v", cluster.ID, err)
+			continue
+		}
+		requested := map[string]usage.Usage{}
+		for _, ns := range usage.Aggregate(nil, pods.Items, nil, nil).Namespaces {
+			requested[ns.Namespace] = ns.Usage
+		}
+
+		for _, cu := range byCluster[cluster.ID] {
+			u, ok := requested[cu.Namespace]
+			if !ok || (u.Cpu.Requests == 0 && u.Memory.Requests == 0) {
+				continue
+			}
+			if err := Svc.CostSvc.Record(
+				ctx, &model.CostSampleModel{
+					DevSpaceId:     cu.ID,
+					ClusterId:      cu.ClusterId,
+					UserId:         cu.UserId,
+					Namespace:      cu.Namespace,
+					CpuCoreHours:   u.Cpu.Requests * hours,
+					MemoryGibHours: u.Memory.Requests / 1024 * hours,
+					SampledAt:      now,
+				},
+			); err != nil {
+				log.Errorf("Failed to record cost of dev space %d: %v", cu.ID, err)
+			}
+		}
+	}
+
+	before := now.In(sleep.Location()).AddDate(0, -cost.Retention(), 0)
+	if err := Svc.CostSvc.DeleteBefore(ctx, before); err != nil {
+		log.Errorf("Failed to delete cost samples: %v", err)
+	}
+}
+
+// CostReport the cost of month grouped by the user, team, cluster or dev
+// space, the cost of a user in several teams is shared by the teams evenly,
+// the ones of no team are reported as team 0
+func CostReport(ctx context.Context, month, groupBy string) (*cost.Report, error) {
+	month, from, to, err := cost.Month(month, time.Now(), sleep.Location())
+	if err != nil {
+		return nil, err
+	}
+	sums, err := Svc.CostSvc.Sum(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[uint64]string{}
+	if groupBy == cost.GroupByCluster {
+		clusters, _ := Svc.ClusterSvc.GetList(ctx)
+		for _, c := range clusters {
+			names[c.ID] = c.ClusterName
+		}
+	}
+	userName := func(id uint64) string {
+		if u, err := Svc.UserSvc.GetCache(id); err == nil {
+			return u.Name
+		}
+		return fmt.Sprintf("deleted user %d", id)
+	}
+	teamsOf := map[uint64][]cost.Owner{}
+
+	items := make([]cost.Item, 0, len(sums))
+	for _, sum := range sums {
+		item := cost.Item{CpuCoreHours: sum.CpuCoreHours, MemoryGibHours: sum.MemoryGibHours}
+		switch groupBy {
+		case cost.GroupByUser:
+			item.Owners = []cost.Owner{{ID: sum.UserId, Name: userName(sum.UserId)}}
+		case cost.GroupByCluster:
+			item.Owners = []cost.Owner{{ID: sum.ClusterId, Name: names[sum.ClusterId]}}
+		case cost.GroupByDevSpace:
+			name := sum.Namespace
+			if cu, err := Svc.ClusterUserSvc.GetCache(sum.DevSpaceId); err == nil && cu.SpaceName != "" {
+				name = cu.SpaceName
+			}
+			item.Owners = []cost.Owner{{ID: sum.DevSpaceId, Name: name}}
+		case cost.GroupByTeam:
+			teams, ok := teamsOf[sum.UserId]
+			if !ok {
+				list, _ := Svc.TeamSvc.ListByUserId(ctx, sum.UserId)
+				for _, t := range list {
+					teams = append(teams, cost.Owner{ID: t.ID, Name: t.Name})
+				}
+				if len(teams) == 0 {
+					teams = []cost.Owner{{ID: 0, Name: "no team"}}
+				}
+				teamsOf[sum.UserId] = teams
+			}
+			item.Owners = teams
+		}
+		items = append(items, item)
+	}
+	return cost.Build(month, groupBy, items, cost.CurrentPrices()), nil
+}
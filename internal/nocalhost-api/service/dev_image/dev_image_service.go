@@ -0,0 +1,51 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_image
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/dev_image"
+)
+
+type DevImage struct {
+	devImageRepo *dev_image.DevImageRepo
+}
+
+func NewDevImageService() *DevImage {
+	db := model.GetDB()
+	return &DevImage{devImageRepo: dev_image.NewDevImageRepo(db)}
+}
+
+func (srv *DevImage) Create(ctx context.Context, i *model.DevImageModel) error {
+	return srv.devImageRepo.Create(ctx, i)
+}
+
+func (srv *DevImage) Get(ctx context.Context, id uint64) (*model.DevImageModel, error) {
+	return srv.devImageRepo.Get(ctx, id)
+}
+
+func (srv *DevImage) GetByName(ctx context.Context, name string) (*model.DevImageModel, error) {
+	return srv.devImageRepo.GetByName(ctx, name)
+}
+
+func (srv *DevImage) List(ctx context.Context, language string) ([]*model.DevImageModel, error) {
+	return srv.devImageRepo.List(ctx, language)
+}
+
+func (srv *DevImage) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	return srv.devImageRepo.Update(ctx, id, columns)
+}
+
+func (srv *DevImage) Delete(ctx context.Context, id uint64) error {
+	return srv.devImageRepo.Delete(ctx, id)
+}
+
+// Close close db
+func (srv *DevImage) Close() {
+	srv.devImageRepo.Close()
+}
@@ -0,0 +1,94 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nhctl/devimage"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+)
+
+// DEV_IMAGE_ENFORCE only the dev images of catalog are allowed by nhctl dev start
+const DEV_IMAGE_ENFORCE = "dev_image.enforce"
+
+// syncs triggered by the changes of dev images never run together
+var devImageLock = sync.Mutex{}
+
+// SyncDevImages apply the catalog of dev images to the namespaces of all dev
+// spaces, a cluster not accessible is skipped
+func SyncDevImages() {
+	defer metrics.ObserveJob("dev_image_sync")()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while syncing dev images: %v", err)
+		}
+	}()
+	devImageLock.Lock()
+	defer devImageLock.Unlock()
+
+	catalog, err := DevImageCatalog(context.TODO())
+	if err != nil {
+		log.Errorf("Failed to list dev images: %v", err)
+		return
+	}
+	devSpaces, _ := Svc.ClusterUserSvc.GetList(context.TODO(), model.ClusterUserModel{})
+
+	clients := map[uint64]*clientgo.GoClient{}
+	for _, cu := range devSpaces {
+		if cu.IsClusterAdmin() || cu.Namespace == "" {
+			continue
+		}
+		goClient, ok := clients[cu.ClusterId]
+		if !ok {
+			if cluster, err := Svc.ClusterSvc.GetCache(cu.ClusterId); err == nil {
+				goClient, _ = clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+			}
+			// nil if not accessible
+			clients[cu.ClusterId] = goClient
+		}
+		if goClient == nil {
+			continue
+		}
+		if err := devimage.Apply(goClient.GetClientSet(), cu.Namespace, catalog); err != nil {
+			log.Errorf("Failed to apply dev images to dev space %d: %v", cu.ID, err)
+		}
+	}
+}
+
+// ApplyDevImages apply the catalog of dev images to the namespace of dev
+// space, used while the dev space is created
+func (s *Service) ApplyDevImages(client *clientgo.GoClient, namespace string) error {
+	catalog, err := DevImageCatalog(context.TODO())
+	if err != nil {
+		return err
+	}
+	return devimage.Apply(client.GetClientSet(), namespace, catalog)
+}
+
+// DevImageCatalog the catalog of dev images in the format of nhctl
+func DevImageCatalog(ctx context.Context) (*devimage.Catalog, error) {
+	list, err := Svc.DevImageSvc.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	catalog := &devimage.Catalog{Enforce: viper.GetBool(DEV_IMAGE_ENFORCE), Images: make([]*devimage.Image, 0)}
+	for _, i := range list {
+		catalog.Images = append(
+			catalog.Images, &devimage.Image{
+				Name: i.Name, Language: i.Language, Image: i.Image, Shell: i.Shell, DebugPort: i.DebugPort,
+				WorkDir: i.WorkDir, Description: i.Description,
+			},
+		)
+	}
+	return catalog, nil
+}
@@ -0,0 +1,152 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/global"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+const (
+	// legacyTokenLastUsedLabel the date the token secret is used last, labeled
+	// by kubernetes 1.29+ of LegacyServiceAccountTokenTracking
+	legacyTokenLastUsedLabel = "kubernetes.io/legacy-token-last-used"
+
+	// the label is of date, there is no need to check more often
+	kubectlTrackInterval = time.Hour
+	// the clients report frequently, do not record every time
+	activityThrottle = time.Minute
+)
+
+var activityColumns = map[string]string{
+	model.ActivityIDE:         "",
+	model.ActivitySync:        "sync_at",
+	model.ActivityPortForward: "port_forward_at",
+	model.ActivityKubectl:     "kubectl_at",
+}
+
+var kubectlTrackedAt time.Time
+
+// RecordDevSpaceActivity record the activity of kind at the time, active_at
+// is the latest of all the activities, the earlier ones are ignored
+func RecordDevSpaceActivity(ctx context.Context, cu *model.ClusterUserModel, kind string, at time.Time) error {
+	column, ok := activityColumns[kind]
+	if !ok {
+		return errors.Errorf("unknown activity %s of dev space", kind)
+	}
+
+	columns := map[string]interface{}{}
+	if last := activityOf(cu, kind); column != "" && (last == nil || at.Sub(*last) >= activityThrottle) {
+		columns[column] = &at
+	}
+	if cu.ActiveAt == nil || at.Sub(*cu.ActiveAt) >= activityThrottle {
+		columns["active_at"] = &at
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	return Svc.ClusterUserSvc.UpdateColumns(ctx, cu.ID, columns)
+}
+
+func activityOf(cu *model.ClusterUserModel, kind string) *time.Time {
+	switch kind {
+	case model.ActivitySync:
+		return cu.SyncAt
+	case model.ActivityPortForward:
+		return cu.PortForwardAt
+	case model.ActivityKubectl:
+		return cu.KubectlAt
+	}
+	return cu.ActiveAt
+}
+
+// TrackKubectlActivity record the kubectl activity of dev spaces by the token
+// secrets of their service accounts, the date the token is used last is
+// labeled by kubernetes 1.29+, the older clusters are not tracked
+func TrackKubectlActivity(ctx context.Context) {
+	if time.Since(kubectlTrackedAt) < kubectlTrackInterval {
+		return
+	}
+	kubectlTrackedAt = time.Now()
+
+	sas, err := Svc.DevSpaceSaSvc.ListAll(ctx)
+	if err != nil {
+		log.Errorf("Failed to list service accounts to track kubectl: %v", err)
+		return
+	}
+
+	// the latest of the service accounts of each dev space
+	usedAt := map[uint64]time.Time{}
+	clients := map[uint64]*clientgo.GoClient{}
+	for _, sa := range sas {
+		client, ok := clients[sa.ClusterId]
+		if !ok {
+			cluster, err := Svc.ClusterSvc.GetCache(sa.ClusterId)
+			if err == nil {
+				client, err = clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+			}
+			if err != nil {
+				log.Warnf("Failed to track kubectl in cluster %d: %v", sa.ClusterId, err)
+			}
+			clients[sa.ClusterId] = client
+		}
+		if client == nil {
+			continue
+		}
+
+		secret, err := client.GetSecret(sa.Namespace, sa.Name+global.NocalhostSaTokenSuffix)
+		if err != nil {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", secret.Labels[legacyTokenLastUsedLabel])
+		if err != nil {
+			continue
+		}
+		if date.After(usedAt[sa.DevSpaceId]) {
+			usedAt[sa.DevSpaceId] = date
+		}
+	}
+
+	for id, date := range usedAt {
+		cu, err := Svc.ClusterUserSvc.GetCache(id)
+		if err != nil {
+			continue
+		}
+		if err := RecordDevSpaceActivity(ctx, &cu, model.ActivityKubectl, date); err != nil {
+			log.Errorf("Failed to record kubectl of dev space %d: %v", id, err)
+		}
+	}
+}
+
+// ListIdleDevSpaces the dev spaces inactive for idle at least, the longest
+// idle first, the ones of cluster admin are not listed
+func ListIdleDevSpaces(ctx context.Context, idle time.Duration) ([]*model.ClusterUserModel, error) {
+	devSpaces, err := Svc.ClusterUserSvc.GetList(ctx, model.ClusterUserModel{})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	result := make([]*model.ClusterUserModel, 0)
+	for _, cu := range devSpaces {
+		if !cu.IsClusterAdmin() && now.Sub(cu.LastActiveAt()) >= idle {
+			result = append(result, cu)
+		}
+	}
+	sort.SliceStable(
+		result, func(i, j int) bool {
+			return result[i].LastActiveAt().Before(result[j].LastActiveAt())
+		},
+	)
+	return result, nil
+}
@@ -0,0 +1,77 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_space_sa
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/dev_space_sa"
+)
+
+type DevSpaceSa struct {
+	devSpaceSaRepo *dev_space_sa.DevSpaceSaRepo
+}
+
+func NewDevSpaceSaService() *DevSpaceSa {
+	db := model.GetDB()
+	return &DevSpaceSa{devSpaceSaRepo: dev_space_sa.NewDevSpaceSaRepo(db)}
+}
+
+// Create record the service account created in the namespace of dev space
+func (srv *DevSpaceSa) Create(
+	ctx context.Context, devSpace *model.ClusterUserModel, userId uint64, name, description, role string,
+) (*model.DevSpaceSaModel, error) {
+	sa := &model.DevSpaceSaModel{
+		DevSpaceId:  devSpace.ID,
+		ClusterId:   devSpace.ClusterId,
+		Namespace:   devSpace.Namespace,
+		Name:        name,
+		Description: description,
+		Role:        role,
+		UserId:      userId,
+	}
+
+	if err := srv.devSpaceSaRepo.Create(ctx, sa); err != nil {
+		return nil, err
+	}
+	return sa, nil
+}
+
+func (srv *DevSpaceSa) Get(ctx context.Context, devSpaceId, id uint64) (*model.DevSpaceSaModel, error) {
+	return srv.devSpaceSaRepo.Get(ctx, devSpaceId, id)
+}
+
+func (srv *DevSpaceSa) List(ctx context.Context, devSpaceId uint64) ([]*model.DevSpaceSaModel, error) {
+	return srv.devSpaceSaRepo.List(ctx, devSpaceId)
+}
+
+func (srv *DevSpaceSa) Rotated(ctx context.Context, id uint64) error {
+	return srv.devSpaceSaRepo.Rotated(ctx, id)
+}
+
+func (srv *DevSpaceSa) Revoke(ctx context.Context, id uint64) error {
+	return srv.devSpaceSaRepo.Revoke(ctx, id)
+}
+
+func (srv *DevSpaceSa) RevokeAll(ctx context.Context, devSpaceId uint64) error {
+	return srv.devSpaceSaRepo.RevokeAll(ctx, devSpaceId)
+}
+
+// ListAll the service accounts not revoked of all the dev spaces
+func (srv *DevSpaceSa) ListAll(ctx context.Context) ([]*model.DevSpaceSaModel, error) {
+	return srv.devSpaceSaRepo.ListAll(ctx)
+}
+
+// ListOrphaned the service accounts not revoked of which the dev space is removed
+func (srv *DevSpaceSa) ListOrphaned(ctx context.Context) ([]*model.DevSpaceSaModel, error) {
+	return srv.devSpaceSaRepo.ListOrphaned(ctx)
+}
+
+// Close close db
+func (srv *DevSpaceSa) Close() {
+	srv.devSpaceSaRepo.Close()
+}
@@ -0,0 +1,180 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service/leader"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+	"nocalhost/pkg/nocalhost-api/pkg/sleep"
+)
+
+var devSpaceSleeperOnce = sync.Once{}
+
+// StartDevSpaceSleeper sleep and wake the dev spaces periodically by
+// their schedules and inactivity
+func StartDevSpaceSleeper() {
+	go devSpaceSleeperOnce.Do(
+		func() {
+			tick := time.NewTicker(sleep.Interval())
+			defer tick.Stop()
+
+			for shutdown.Run(leader.Only(CheckDevSpacesSleep)) {
+				select {
+				case <-tick.C:
+				case <-shutdown.Stopping():
+					return
+				}
+			}
+		},
+	)
+}
+
+// CheckDevSpacesSleep sleep the dev spaces in the schedule window or
+// inactive, and wake the ones slept by schedule out of the window
+func CheckDevSpacesSleep() {
+	defer metrics.ObserveJob("dev_space_sleep")()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while checking dev spaces to sleep: %v", err)
+		}
+	}()
+
+	// kubectl is not seen by nocalhost-api, it is tracked by the token secrets
+	TrackKubectlActivity(context.TODO())
+
+	devSpaces, _ := Svc.ClusterUserSvc.GetList(context.TODO(), model.ClusterUserModel{})
+	now := time.Now().In(sleep.Location())
+	for _, cu := range devSpaces {
+		if cu.IsClusterAdmin() || cu.SleepConfig == "" {
+			continue
+		}
+		config := &sleep.Config{}
+		if err := json.Unmarshal([]byte(cu.SleepConfig), config); err != nil || config.IsEmpty() {
+			continue
+		}
+
+		start, inWindow := config.Window(now)
+		switch {
+		case cu.IsAsleep():
+			if cu.SleepReason == sleep.ReasonSchedule && !inWindow {
+				if err := WakeDevSpace(context.TODO(), cu); err != nil {
+					log.Errorf("Failed to wake dev space %d: %v", cu.ID, err)
+				}
+			}
+		// waking on demand in the window keeps it awake until the next window
+		case inWindow && (cu.WakeAt == nil || cu.WakeAt.Before(start)):
+			if err := SleepDevSpace(context.TODO(), cu, sleep.ReasonSchedule); err != nil {
+				log.Errorf("Failed to sleep dev space %d: %v", cu.ID, err)
+			}
+		case config.Inactive(now, cu.LastActiveAt()):
+			if err := SleepDevSpace(context.TODO(), cu, sleep.ReasonInactive); err != nil {
+				log.Errorf("Failed to sleep dev space %d: %v", cu.ID, err)
+			}
+		}
+	}
+}
+
+// SleepDevSpace scale the workloads of dev space to zero
+// CountDevSpaces the number of dev spaces asleep and awake, the ones of
+// cluster admin are not counted
+func CountDevSpaces() (map[string]int, error) {
+	devSpaces, err := Svc.ClusterUserSvc.GetList(context.TODO(), model.ClusterUserModel{})
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{"asleep": 0, "awake": 0}
+	for _, cu := range devSpaces {
+		switch {
+		case cu.IsClusterAdmin():
+		case cu.IsAsleep():
+			counts["asleep"]++
+		default:
+			counts["awake"]++
+		}
+	}
+	return counts, nil
+}
+
+func SleepDevSpace(ctx context.Context, cu *model.ClusterUserModel, reason string) error {
+	client, err := devSpaceClient(cu)
+	if err != nil {
+		return err
+	}
+	if err := sleep.Sleep(client.GetClientSet(), cu.Namespace); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	log.Infof("Dev space %d(%s) sleeps for %s", cu.ID, cu.Namespace, reason)
+	return Svc.ClusterUserSvc.UpdateColumns(
+		ctx, cu.ID, map[string]interface{}{
+			"sleep_reason": reason,
+			"sleep_at":     &now,
+		},
+	)
+}
+
+// WakeDevSpace restore the workloads of dev space
+func WakeDevSpace(ctx context.Context, cu *model.ClusterUserModel) error {
+	client, err := devSpaceClient(cu)
+	if err != nil {
+		return err
+	}
+	if err := sleep.Wake(client.GetClientSet(), cu.Namespace); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	log.Infof("Dev space %d(%s) wakes up", cu.ID, cu.Namespace)
+	return Svc.ClusterUserSvc.UpdateColumns(
+		ctx, cu.ID, map[string]interface{}{
+			"sleep_reason": "",
+			"sleep_at":     nil,
+			"wake_at":      &now,
+			"active_at":    &now,
+		},
+	)
+}
+
+// ActivateDevSpaces record the activity of user's dev spaces connected by
+// IDE, the ones asleep are waked up
+func ActivateDevSpaces(ctx context.Context, userId uint64) {
+	devSpaces, _ := Svc.ClusterUserSvc.GetList(ctx, model.ClusterUserModel{UserId: userId})
+	now := time.Now()
+	for _, cu := range devSpaces {
+		if cu.IsClusterAdmin() {
+			continue
+		}
+		if cu.IsAsleep() {
+			if err := WakeDevSpace(ctx, cu); err != nil {
+				log.Errorf("Failed to wake dev space %d: %v", cu.ID, err)
+			}
+			continue
+		}
+		if err := RecordDevSpaceActivity(ctx, cu, model.ActivityIDE, now); err != nil {
+			log.Errorf("Failed to record activity of dev space %d: %v", cu.ID, err)
+		}
+	}
+}
+
+func devSpaceClient(cu *model.ClusterUserModel) (*clientgo.GoClient, error) {
+	cluster, err := Svc.ClusterSvc.GetCache(cu.ClusterId)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get cluster %d", cu.ClusterId)
+	}
+	return clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+}
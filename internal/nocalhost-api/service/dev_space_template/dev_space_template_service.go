@@ -0,0 +1,129 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_space_template
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/dev_space_template"
+	"nocalhost/pkg/nocalhost-api/pkg/notify"
+	"nocalhost/pkg/nocalhost-api/pkg/spacetemplate"
+)
+
+type DevSpaceTemplate struct {
+	devSpaceTemplateRepo *dev_space_template.DevSpaceTemplateRepo
+}
+
+func NewDevSpaceTemplateService() *DevSpaceTemplate {
+	db := model.GetDB()
+	return &DevSpaceTemplate{devSpaceTemplateRepo: dev_space_template.NewDevSpaceTemplateRepo(db)}
+}
+
+func (srv *DevSpaceTemplate) Create(ctx context.Context, t *model.DevSpaceTemplateModel) error {
+	return srv.devSpaceTemplateRepo.Create(ctx, t)
+}
+
+func (srv *DevSpaceTemplate) Get(ctx context.Context, id uint64) (*model.DevSpaceTemplateModel, error) {
+	return srv.devSpaceTemplateRepo.Get(ctx, id)
+}
+
+func (srv *DevSpaceTemplate) GetByName(ctx context.Context, name string) (*model.DevSpaceTemplateModel, error) {
+	return srv.devSpaceTemplateRepo.GetByName(ctx, name)
+}
+
+func (srv *DevSpaceTemplate) List(ctx context.Context) ([]*model.DevSpaceTemplateModel, error) {
+	return srv.devSpaceTemplateRepo.List(ctx)
+}
+
+func (srv *DevSpaceTemplate) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	return srv.devSpaceTemplateRepo.Update(ctx, id, columns)
+}
+
+func (srv *DevSpaceTemplate) Delete(ctx context.Context, id uint64) error {
+	return srv.devSpaceTemplateRepo.Delete(ctx, id)
+}
+
+// CreateInstall record the application to install in dev space
+func (srv *DevSpaceTemplate) CreateInstall(ctx context.Context, install *model.DevSpaceAppInstallModel) error {
+	if err := srv.devSpaceTemplateRepo.CreateInstall(ctx, install); err != nil {
+		return err
+	}
+	if install.Status == spacetemplate.StatusFailed {
+		notifyInstallFailed(install)
+	}
+	return nil
+}
+
+func (srv *DevSpaceTemplate) GetInstall(ctx context.Context, id uint64) (*model.DevSpaceAppInstallModel, error) {
+	return srv.devSpaceTemplateRepo.GetInstall(ctx, id)
+}
+
+func (srv *DevSpaceTemplate) ListInstalls(ctx context.Context, devSpaceId uint64) (
+	[]*model.DevSpaceAppInstallModel, error,
+) {
+	return srv.devSpaceTemplateRepo.ListInstalls(ctx, devSpaceId)
+}
+
+// UpdateInstall update the status of installation, the callers update it
+// only if changed, so that the failure is notified once
+func (srv *DevSpaceTemplate) UpdateInstall(ctx context.Context, id uint64, status, message string) error {
+	if err := srv.devSpaceTemplateRepo.UpdateInstall(ctx, id, status, message); err != nil {
+		return err
+	}
+	srv.installUpdated(ctx, id, status)
+	return nil
+}
+
+// ListBulkInstalls the result of each dev space deployed in bulk
+func (srv *DevSpaceTemplate) ListBulkInstalls(ctx context.Context, bulkDeployId uint64) (
+	[]*model.DevSpaceAppInstallModel, error,
+) {
+	return srv.devSpaceTemplateRepo.ListBulkInstalls(ctx, bulkDeployId)
+}
+
+func (srv *DevSpaceTemplate) UpdateInstallJob(ctx context.Context, id uint64, jobName, status, message string) error {
+	if err := srv.devSpaceTemplateRepo.UpdateInstallJob(ctx, id, jobName, status, message); err != nil {
+		return err
+	}
+	srv.installUpdated(ctx, id, status)
+	return nil
+}
+
+func (srv *DevSpaceTemplate) installUpdated(ctx context.Context, id uint64, status string) {
+	if status != spacetemplate.StatusFailed {
+		return
+	}
+	if install, err := srv.devSpaceTemplateRepo.GetInstall(ctx, id); err == nil {
+		notifyInstallFailed(install)
+	}
+}
+
+func notifyInstallFailed(install *model.DevSpaceAppInstallModel) {
+	notify.Notify(
+		notify.ApplicationInstallFailed, map[string]interface{}{
+			"dev_space_id":   install.DevSpaceId,
+			"application_id": install.ApplicationId,
+			"application":    install.ApplicationName,
+			"bulk_deploy_id": install.BulkDeployId,
+		}, "Installing application %s in dev space %d failed: %s",
+		install.ApplicationName, install.DevSpaceId, install.Message,
+	)
+}
+
+func (srv *DevSpaceTemplate) DeleteInstalls(ctx context.Context, devSpaceId uint64) error {
+	return srv.devSpaceTemplateRepo.DeleteInstalls(ctx, devSpaceId)
+}
+
+// ListOrphanedInstalls the installs of which the dev space is removed
+func (srv *DevSpaceTemplate) ListOrphanedInstalls(ctx context.Context) ([]*model.DevSpaceAppInstallModel, error) {
+	return srv.devSpaceTemplateRepo.ListOrphanedInstalls(ctx)
+}
+
+// Close close db
+func (srv *DevSpaceTemplate) Close() {
+	srv.devSpaceTemplateRepo.Close()
+}
@@ -0,0 +1,263 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service/leader"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+)
+
+const (
+	GC_INTERVAL   = "gc.interval"
+	GC_DRY_RUN    = "gc.dry_run"
+	GC_USER_GRACE = "gc.user_grace"
+
+	defaultGCInterval = 6 * time.Hour
+	defaultUserGrace  = 30 * 24 * time.Hour
+)
+
+// Kinds of the orphans
+const (
+	OrphanDevSpace       = "dev_space"
+	OrphanAppInstall     = "app_install"
+	OrphanServiceAccount = "service_account"
+	OrphanUser           = "user"
+)
+
+// Orphan the row of which the resource or the owner is gone
+type Orphan struct {
+	Kind   string `json:"kind"`
+	ID     uint64 `json:"id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	// Cleaned whether it is removed, false in dry run or if it is kept for
+	// the admins, e.g. the dev spaces of which the namespaces exist
+	Cleaned bool   `json:"cleaned"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GarbageReport of a collection
+type GarbageReport struct {
+	DryRun     bool      `json:"dry_run"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Orphans    []Orphan  `json:"orphans"`
+}
+
+var garbageCollectorOnce = sync.Once{}
+
+// StartGarbageCollector collect the orphaned rows periodically, they are
+// reported only if gc.dry_run is true, which is the default
+func StartGarbageCollector() {
+	go garbageCollectorOnce.Do(
+		func() {
+			interval := viper.GetDuration(GC_INTERVAL)
+			if interval <= 0 {
+				interval = defaultGCInterval
+			}
+			tick := time.NewTicker(interval)
+			defer tick.Stop()
+
+			collect := func() {
+				dryRun := !viper.IsSet(GC_DRY_RUN) || viper.GetBool(GC_DRY_RUN)
+				report := CollectGarbage(context.TODO(), dryRun)
+				for _, o := range report.Orphans {
+					log.Infof(
+						"[gc] orphaned %s %d(%s): %s, cleaned %t %s", o.Kind, o.ID, o.Name, o.Reason, o.Cleaned, o.Error,
+					)
+				}
+			}
+			for {
+				select {
+				case <-tick.C:
+				case <-shutdown.Stopping():
+					return
+				}
+				if !shutdown.Run(leader.Only(collect)) {
+					return
+				}
+			}
+		},
+	)
+}
+
+// CollectGarbage detect the orphaned rows, and clean them unless dryRun:
+// the dev spaces of which the cluster or the namespace is gone, the app
+// installs and the service accounts of the dev spaces removed, and the users
+// removed from ldap for gc.user_grace. The port-forwards are recorded by
+// nhctl on the hosts of the developers, not by nocalhost-api
+func CollectGarbage(ctx context.Context, dryRun bool) *GarbageReport {
+	defer metrics.ObserveJob("gc")()
+	report := &GarbageReport{DryRun: dryRun, StartedAt: time.Now()}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while collecting garbage: %v", err)
+		}
+		report.FinishedAt = time.Now()
+	}()
+
+	// the dev spaces go first, so that their installs and service accounts
+	// are collected in the same run
+	orphanedUsers(ctx, report)
+	orphanedDevSpaces(ctx, report)
+	orphanedInstalls(ctx, report)
+	orphanedServiceAccounts(ctx, report)
+	return report
+}
+
+// add the orphan to report, it is cleaned by clean unless dry run
+func (r *GarbageReport) add(o Orphan, clean func() error) {
+	if !r.DryRun && clean != nil {
+		if err := clean(); err != nil {
+			o.Error = err.Error()
+		} else {
+			o.Cleaned = true
+		}
+	}
+	r.Orphans = append(r.Orphans, o)
+}
+
+func orphanedUsers(ctx context.Context, report *GarbageReport) {
+	grace := viper.GetDuration(GC_USER_GRACE)
+	if grace <= 0 {
+		grace = defaultUserGrace
+	}
+	users, err := Svc.UserSvc.ListDeprovisioned(ctx, time.Now().Add(-grace))
+	if err != nil {
+		log.Errorf("[gc] list deprovisioned users err: %v", err)
+		return
+	}
+	for _, u := range users {
+		id := u.ID
+		report.add(
+			Orphan{
+				Kind: OrphanUser, ID: id, Name: u.Email,
+				Reason: fmt.Sprintf("removed from ldap and disabled since %s", u.UpdatedAt.Format(time.RFC3339)),
+			}, func() error { return Svc.UserSvc.Delete(ctx, id) },
+		)
+	}
+}
+
+func orphanedDevSpaces(ctx context.Context, report *GarbageReport) {
+	devSpaces, err := Svc.ClusterUserSvc.GetList(ctx, model.ClusterUserModel{})
+	if err != nil {
+		log.Errorf("[gc] list dev spaces err: %v", err)
+		return
+	}
+	clusters, err := Svc.ClusterSvc.GetList(ctx)
+	if err != nil {
+		log.Errorf("[gc] list clusters err: %v", err)
+		return
+	}
+	clusterOf := map[uint64]*model.ClusterList{}
+	for _, c := range clusters {
+		clusterOf[c.ID] = c
+	}
+	clients := map[uint64]*clientgo.GoClient{}
+
+	for _, cu := range devSpaces {
+		if cu.IsClusterAdmin() {
+			continue
+		}
+		id := cu.ID
+		remove := func() error {
+			if err := Svc.ClusterUserSvc.Delete(ctx, id); err != nil {
+				return err
+			}
+			_ = Svc.DevSpaceTemplateSvc.DeleteInstalls(ctx, id)
+			return Svc.DevSpaceSaSvc.RevokeAll(ctx, id)
+		}
+		orphan := Orphan{Kind: OrphanDevSpace, ID: id, Name: cu.Namespace}
+
+		if _, err := Svc.UserSvc.GetCache(cu.UserId); err != nil {
+			// the namespace may be in use, it is left to the admins
+			orphan.Reason = fmt.Sprintf("user %d is deleted, the namespace is kept", cu.UserId)
+			report.add(orphan, nil)
+			continue
+		}
+
+		cluster, ok := clusterOf[cu.ClusterId]
+		if !ok {
+			orphan.Reason = fmt.Sprintf("cluster %d is deleted", cu.ClusterId)
+			report.add(orphan, remove)
+			continue
+		}
+		// the namespaces of the clusters unreachable are unknown
+		if cluster.HealthStatus == setupcluster.HealthUnreachable {
+			continue
+		}
+		client, ok := clients[cluster.ID]
+		if !ok {
+			if client, err = clientgo.NewAdminGoClient([]byte(cluster.KubeConfig)); err != nil {
+				log.Warnf("[gc] client of cluster %d err: %v", cluster.ID, err)
+			}
+			clients[cluster.ID] = client
+		}
+		if client == nil {
+			continue
+		}
+		exist, err := client.IsNamespaceExist(cu.Namespace)
+		if err != nil || exist {
+			continue
+		}
+		orphan.Reason = fmt.Sprintf("namespace is not found in cluster %d", cu.ClusterId)
+		report.add(orphan, remove)
+	}
+}
+
+func orphanedInstalls(ctx context.Context, report *GarbageReport) {
+	installs, err := Svc.DevSpaceTemplateSvc.ListOrphanedInstalls(ctx)
+	if err != nil {
+		log.Errorf("[gc] list orphaned app installs err: %v", err)
+		return
+	}
+	removed := map[uint64]bool{}
+	for _, install := range installs {
+		devSpaceId := install.DevSpaceId
+		report.add(
+			Orphan{
+				Kind: OrphanAppInstall, ID: install.ID, Name: install.ApplicationName,
+				Reason: fmt.Sprintf("dev space %d is removed", devSpaceId),
+			}, func() error {
+				// the installs of a dev space are removed at once
+				if removed[devSpaceId] {
+					return nil
+				}
+				removed[devSpaceId] = true
+				return Svc.DevSpaceTemplateSvc.DeleteInstalls(ctx, devSpaceId)
+			},
+		)
+	}
+}
+
+func orphanedServiceAccounts(ctx context.Context, report *GarbageReport) {
+	sas, err := Svc.DevSpaceSaSvc.ListOrphaned(ctx)
+	if err != nil {
+		log.Errorf("[gc] list orphaned service accounts err: %v", err)
+		return
+	}
+	for _, sa := range sas {
+		id := sa.ID
+		report.add(
+			Orphan{
+				Kind: OrphanServiceAccount, ID: id, Name: sa.Name,
+				Reason: fmt.Sprintf("dev space %d is removed", sa.DevSpaceId),
+			}, func() error { return Svc.DevSpaceSaSvc.Revoke(ctx, id) },
+		)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package git_credential
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/git_credential"
+)
+
+type GitCredential struct {
+	gitCredentialRepo *git_credential.GitCredentialRepo
+}
+
+func NewGitCredentialService() *GitCredential {
+	db := model.GetDB()
+	return &GitCredential{gitCredentialRepo: git_credential.NewGitCredentialRepo(db)}
+}
+
+func (srv *GitCredential) Create(ctx context.Context, g *model.GitCredentialModel) error {
+	return srv.gitCredentialRepo.Create(ctx, g)
+}
+
+func (srv *GitCredential) Get(ctx context.Context, id uint64) (*model.GitCredentialModel, error) {
+	return srv.gitCredentialRepo.Get(ctx, id)
+}
+
+func (srv *GitCredential) GetByName(ctx context.Context, name string) (*model.GitCredentialModel, error) {
+	return srv.gitCredentialRepo.GetByName(ctx, name)
+}
+
+func (srv *GitCredential) List(ctx context.Context) ([]*model.GitCredentialModel, error) {
+	return srv.gitCredentialRepo.List(ctx)
+}
+
+func (srv *GitCredential) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	return srv.gitCredentialRepo.Update(ctx, id, columns)
+}
+
+func (srv *GitCredential) Delete(ctx context.Context, id uint64) error {
+	return srv.gitCredentialRepo.Delete(ctx, id)
+}
+
+// Close close db
+func (srv *GitCredential) Close() {
+	srv.gitCredentialRepo.Close()
+}
@@ -11,7 +11,10 @@ import (
 	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nocalhost-api/model"
 	ldapsrv "nocalhost/internal/nocalhost-api/service/ldap"
+	"nocalhost/internal/nocalhost-api/service/leader"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
 	"nocalhost/pkg/nocalhost-api/pkg/utils"
 	"strings"
 	"sync"
@@ -36,10 +39,14 @@ func StartJob() {
 					if err := recover(); err != nil {
 						log.Error("Panic while exec cron job ")
 					}
-					CronJobTrigger()
+					if leader.IsLeader() {
+						CronJobTrigger()
+					}
 
 					select {
 					case <-tickC:
+					case <-shutdown.Stopping():
+						return
 					}
 				}
 			},
@@ -48,7 +55,7 @@ func StartJob() {
 }
 
 func CronJobTrigger() {
-	go cronJobTrigger()
+	go shutdown.Run(cronJobTrigger)
 }
 
 func cronJobTrigger() {
@@ -66,6 +73,7 @@ func cronJobTrigger() {
 	running.Store(RUNNING)
 	lock.Unlock()
 	defer running.Store(IDLE)
+	defer metrics.ObserveJob("ldap_sync")()
 
 	var err error
 	var ldapModel *model.LdapModel
@@ -75,11 +83,11 @@ func cronJobTrigger() {
 	ldapModel, err = Svc.LdapSvc.Get()
 	if err == nil {
 		newProtectedTs := time.Now().Add(time.Minute * 10).UnixNano()
-		if _const.Uint64PointerToBool(ldapModel.Enable) ||
+		if _const.Uint64PointerToBool(ldapModel.Enable) &&
 			ldapModel.SyncProtectionTs < time.Now().UnixNano() &&
-				Svc.LdapSvc.TryGetLock(
-					ldapModel.ID, newProtectedTs, ldapModel.SyncProtectionTs,
-				) {
+			Svc.LdapSvc.TryGetLock(
+				ldapModel.ID, newProtectedTs, ldapModel.SyncProtectionTs,
+			) {
 
 			newGen := ldapModel.SyncGen + 1
 			report, err := doCronJob(ldapModel)
@@ -123,6 +131,7 @@ func doCronJob(model *model.LdapModel) (*Report, error) {
 		model.BaseDn, model.Filter,
 		model.AdminBaseDn, model.AdminFilter,
 		model.EmailAttr, model.UserNameAttr,
+		model.GroupAttr, model.AdminGroups,
 	)
 
 	if err != nil {
@@ -135,7 +144,8 @@ func doCronJob(model *model.LdapModel) (*Report, error) {
 func DoSyncFromLDAP(ctx context.Context, ldapServer string, tls, md5 bool, ldapGen uint64, username, password string,
 	baseDN, filter,
 	adminBaseDn, adminFilter,
-	emailAttr, userNameAttr string) (*Report, error) {
+	emailAttr, userNameAttr,
+	groupAttr, adminGroups string) (*Report, error) {
 	var report *Report = nil
 
 	err := ldapsrv.ConnectAndThen(
@@ -150,13 +160,23 @@ func DoSyncFromLDAP(ctx context.Context, ldapServer string, tls, md5 bool, ldapG
 			}
 
 			adminSet := make(sets.String)
-			entriesMapping, err := doSearch(baseDN, filter, emailAttr, userNameAttr, conn)
+			entriesMapping, err := doSearch(baseDN, filter, emailAttr, userNameAttr, groupAttr, conn)
 			if err != nil {
 				return err
 			}
 
+			// group-to-role mapping, user belongs to admin groups will be admin
+			adminGroupSet := toGroupSet(adminGroups)
+			if groupAttr != "" && adminGroupSet.Len() > 0 {
+				for email, entry := range entriesMapping {
+					if adminGroupSet.HasAny(toLowerSlice(entry.GetAttributeValues(groupAttr))...) {
+						adminSet.Insert(email)
+					}
+				}
+			}
+
 			if adminBaseDn != "" {
-				adminEntriesMapping, err := doSearch(adminBaseDn, adminFilter, emailAttr, userNameAttr, conn)
+				adminEntriesMapping, err := doSearch(adminBaseDn, adminFilter, emailAttr, userNameAttr, groupAttr, conn)
 				if err != nil {
 					return err
 				}
@@ -335,14 +355,19 @@ func CreateUsers(ctx context.Context, list []*model.UserBaseModel) int {
 	return len(list)
 }
 
-func doSearch(baseDN, filter, emailAttr, userNameAttr string, conn *ldap.Conn) (map[string]*ldap.Entry, error) {
+func doSearch(baseDN, filter, emailAttr, userNameAttr, groupAttr string, conn *ldap.Conn) (map[string]*ldap.Entry, error) {
+	attributes := []string{
+		"dn", emailAttr, userNameAttr,
+	}
+	if groupAttr != "" {
+		attributes = append(attributes, groupAttr)
+	}
+
 	searchRequest := ldap.NewSearchRequest(
 		baseDN,
 		ldapsrv.ScopeWholeSubtree, ldapsrv.DerefAlways, 0, 0, false,
 		filter,
-		[]string{
-			"dn", emailAttr, userNameAttr,
-		},
+		attributes,
 		nil,
 	)
 
@@ -354,6 +379,25 @@ func doSearch(baseDN, filter, emailAttr, userNameAttr string, conn *ldap.Conn) (
 	return ToMailMapping(sr.Entries, emailAttr), nil
 }
 
+// group DNs are case-insensitive
+func toGroupSet(groups string) sets.String {
+	set := make(sets.String)
+	for _, group := range strings.Split(groups, ",") {
+		if group = strings.TrimSpace(group); group != "" {
+			set.Insert(strings.ToLower(group))
+		}
+	}
+	return set
+}
+
+func toLowerSlice(list []string) []string {
+	result := make([]string, 0, len(list))
+	for _, s := range list {
+		result = append(result, strings.ToLower(strings.TrimSpace(s)))
+	}
+	return result
+}
+
 type Report struct {
 	Entries int
 	Inserts int
@@ -0,0 +1,121 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"nocalhost/internal/nocalhost-api/service/leader"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/kubeconfig"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+)
+
+var kubeConfigCheckerOnce = sync.Once{}
+
+// StartKubeConfigChecker check the expiry of cluster kubeconfig periodically,
+// the credential provided by exec plugin is refreshed if auto refresh is enabled
+func StartKubeConfigChecker() {
+	go kubeConfigCheckerOnce.Do(
+		func() {
+			tick := time.NewTicker(kubeconfig.CheckInterval())
+			defer tick.Stop()
+
+			for shutdown.Run(leader.Only(CheckKubeConfigs)) {
+				select {
+				case <-tick.C:
+				case <-shutdown.Stopping():
+					return
+				}
+			}
+		},
+	)
+}
+
+// CheckKubeConfigs check and store the expiry status of all the clusters
+func CheckKubeConfigs() {
+	defer metrics.ObserveJob("kubeconfig_check")()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while checking cluster kubeconfig: %v", err)
+		}
+	}()
+
+	clusters, _ := Svc.ClusterSvc.GetList(context.TODO())
+	for _, cluster := range clusters {
+		update := CheckKubeConfig(cluster.ID, []byte(cluster.KubeConfig))
+		if update["kubeconfig"] == nil &&
+			update["kubeconfig_status"] == cluster.KubeConfigStatus &&
+			update["kubeconfig_message"] == cluster.KubeConfigMessage &&
+			sameTime(update["kubeconfig_expire_at"].(*time.Time), cluster.KubeConfigExpireAt) {
+			continue
+		}
+
+		if _, err := Svc.ClusterSvc.Update(context.TODO(), update, cluster.ID); err != nil {
+			log.Errorf("Failed to update kubeconfig status of cluster %d: %v", cluster.ID, err)
+		}
+	}
+}
+
+// CheckKubeConfig returns the columns of cluster to update for the expiry of kubeconfig,
+// 'kubeconfig' is included if it is refreshed by the exec plugin
+func CheckKubeConfig(clusterId uint64, kubeConfig []byte) map[string]interface{} {
+	update := map[string]interface{}{"kubeconfig_message": ""}
+
+	expireAt, err := kubeconfig.Expiry(kubeConfig)
+	if err != nil {
+		log.Warnf("Failed to parse kubeconfig expiry of cluster %d: %v", clusterId, err)
+		update["kubeconfig_message"] = err.Error()
+	}
+	status := kubeconfig.Status(expireAt)
+
+	if status != kubeconfig.StatusValid && kubeconfig.AutoRefreshEnabled() && kubeconfig.HasExec(kubeConfig) {
+		if refreshed, refreshedExpireAt, err := refreshKubeConfig(kubeConfig); err != nil {
+			log.Warnf("Failed to refresh kubeconfig of cluster %d: %v", clusterId, err)
+			status = kubeconfig.StatusRefreshFailed
+			update["kubeconfig_message"] = err.Error()
+		} else {
+			log.Infof("Kubeconfig of cluster %d is refreshed by exec plugin", clusterId)
+			update["kubeconfig"] = string(refreshed)
+			expireAt, status = refreshedExpireAt, kubeconfig.Status(refreshedExpireAt)
+		}
+	}
+
+	switch status {
+	case kubeconfig.StatusExpiring:
+		log.Warnf("Kubeconfig of cluster %d will expire at %s", clusterId, expireAt.Format(time.RFC3339))
+	case kubeconfig.StatusExpired:
+		log.Warnf("Kubeconfig of cluster %d has expired at %s", clusterId, expireAt.Format(time.RFC3339))
+	}
+
+	update["kubeconfig_expire_at"] = expireAt
+	update["kubeconfig_status"] = status
+	return update
+}
+
+// refreshKubeConfig refresh the credential and make sure it still works
+func refreshKubeConfig(kubeConfig []byte) ([]byte, *time.Time, error) {
+	refreshed, expireAt, err := kubeconfig.RefreshExec(kubeConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if _, err := clientgo.NewAdminGoClient(refreshed); err != nil {
+		return nil, nil, err
+	}
+	return refreshed, expireAt, nil
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -1,6 +1,8 @@
 package ldap
 
 import (
+	"github.com/pkg/errors"
+	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/repository/ldap"
 )
@@ -26,6 +28,8 @@ func (srv *Ldap) Configuration(
 	AdminFilter string,
 	EmailAttr string,
 	UserNameAttr string,
+	GroupAttr string,
+	AdminGroups string,
 	Enable *uint64) error {
 	mapping := make(map[string]interface{}, 0)
 
@@ -40,6 +44,8 @@ func (srv *Ldap) Configuration(
 	AppendToMapIfNotEmpty(mapping, "adminFilter", AdminFilter)
 	AppendToMapIfNotEmpty(mapping, "emailAttr", EmailAttr)
 	AppendToMapIfNotEmpty(mapping, "userNameAttr", UserNameAttr)
+	AppendToMapIfNotEmpty(mapping, "groupAttr", GroupAttr)
+	AppendToMapIfNotEmpty(mapping, "adminGroups", AdminGroups)
 	AppendToMapIfNotEmpty(mapping, "enable", Enable)
 
 	return srv.ldapRepo.CreateOrUpdate(mapping)
@@ -65,6 +71,26 @@ func (srv *Ldap) UpdateGen(id, syncGen uint64, entries, inserts, updates, delete
 	return srv.ldapRepo.UpdateGen(id, syncGen, entries, inserts, updates, deletes, fails, costs)
 }
 
+// DoBindForConfiguredLDAP bind with the dn and password to the ldap server
+// configured, use to authenticate the ldap user
+func (srv *Ldap) DoBindForConfiguredLDAP(dn, password string) error {
+	configuration, err := srv.Get()
+	if err != nil {
+		return err
+	}
+
+	if !_const.Uint64PointerToBool(configuration.Enable) {
+		return errors.New("Ldap is not enabled")
+	}
+
+	return DoBindForLDAP(
+		configuration.Server,
+		_const.Uint64PointerToBool(configuration.Tls),
+		_const.Uint64PointerToBool(configuration.Md5),
+		dn, password,
+	)
+}
+
 func AppendToMapIfNotEmpty(m map[string]interface{}, k string, v interface{}) {
 	if v != "" {
 		m[k] = v
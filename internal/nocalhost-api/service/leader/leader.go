@@ -0,0 +1,166 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package leader
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nocalhost-api/repository/leader_lease"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+)
+
+// Lease the name of lease held by the leader running the background jobs
+const Lease = "background-jobs"
+
+const defaultLeaseDuration = 30 * time.Second
+
+type leases interface {
+	Acquire(name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	Release(name, holder string) error
+}
+
+// Elector elects one of the replicas of nocalhost-api as the leader by the
+// lease in database, the leader renews the lease in a third of its duration,
+// and steps down as soon as a renewal fails
+type Elector struct {
+	leases   leases
+	identity string
+	ttl      time.Duration
+
+	leader int32
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newElector(leases leases, identity string, ttl time.Duration) *Elector {
+	return &Elector{
+		leases:   leases,
+		identity: identity,
+		ttl:      ttl,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// IsLeader whether the replica holds the lease
+func (e *Elector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leader) == 1
+}
+
+func (e *Elector) try() {
+	acquired, err := e.leases.Acquire(Lease, e.identity, time.Now(), e.ttl)
+	if err != nil {
+		log.Warnf("acquire leader lease err: %v", err)
+	}
+	leader := acquired && err == nil
+	if was := atomic.SwapInt32(&e.leader, boolInt32(leader)) == 1; was != leader {
+		if leader {
+			log.Infof("%s became the leader of background jobs", e.identity)
+		} else {
+			log.Infof("%s is not the leader of background jobs anymore", e.identity)
+		}
+	}
+	metrics.SetLeader(leader)
+}
+
+func (e *Elector) run() {
+	defer close(e.done)
+
+	tick := time.NewTicker(e.ttl / 3)
+	defer tick.Stop()
+	for {
+		select {
+		case <-tick.C:
+			e.try()
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Resign stop renewing and release the lease, so that another replica takes
+// over without waiting for the expiry
+func (e *Elector) Resign() {
+	e.once.Do(
+		func() {
+			close(e.stop)
+			<-e.done
+			if atomic.SwapInt32(&e.leader, 0) == 1 {
+				if err := e.leases.Release(Lease, e.identity); err != nil {
+					log.Warnf("release leader lease err: %v", err)
+				}
+			}
+			metrics.SetLeader(false)
+		},
+	)
+}
+
+var elector *Elector
+
+// Start the election unless leader_election.enabled is false, in which case
+// every replica runs the background jobs, the first attempt is made before
+// returning, so that the jobs started after it see the result
+func Start(db *gorm.DB) {
+	if viper.IsSet("leader_election.enabled") && !viper.GetBool("leader_election.enabled") {
+		return
+	}
+	ttl := viper.GetDuration("leader_election.lease_duration")
+	if ttl <= 0 {
+		ttl = defaultLeaseDuration
+	}
+
+	elector = newElector(leader_lease.NewLeaderLeaseRepo(db), identity(), ttl)
+	elector.try()
+	go elector.run()
+}
+
+// Resign the lease held, e.g. after the background jobs are drained
+func Resign() {
+	if elector != nil {
+		elector.Resign()
+	}
+}
+
+// IsLeader whether the background jobs run on this replica, always true if
+// the election is disabled
+func IsLeader() bool {
+	return elector == nil || elector.IsLeader()
+}
+
+// Only wrap the job to run on the leader, it is skipped by the others
+func Only(job func()) func() {
+	return func() {
+		if IsLeader() {
+			job()
+		}
+	}
+}
+
+// identity the hostname, which is the pod name in Kubernetes, with a random
+// suffix in case of the same hostname of containers
+func identity() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return hostname + "-" + uuid.NewV4().String()[:8]
+}
+
+func boolInt32(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,92 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package leader
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// memoryLeases the leases in memory with the same semantics as the repo
+type memoryLeases struct {
+	lock     sync.Mutex
+	holder   string
+	expireAt time.Time
+	err      error
+}
+
+func (m *memoryLeases) Acquire(name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.err != nil {
+		return false, m.err
+	}
+	if m.holder != "" && m.holder != holder && !m.expireAt.Before(now) {
+		return false, nil
+	}
+	m.holder, m.expireAt = holder, now.Add(ttl)
+	return true, nil
+}
+
+func (m *memoryLeases) Release(name, holder string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.holder == holder {
+		m.expireAt = time.Now()
+	}
+	return nil
+}
+
+func TestElector(t *testing.T) {
+	log.NewLogger(&log.Config{}, log.InstanceZapLogger)
+
+	leases := &memoryLeases{}
+	a := newElector(leases, "a", time.Minute)
+	b := newElector(leases, "b", time.Minute)
+	a.try()
+	b.try()
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatal("expect a elected only")
+	}
+
+	// a renewal failed, a steps down even if the lease not expired
+	leases.err = errors.New("database is down")
+	a.try()
+	if a.IsLeader() {
+		t.Fatal("expect a stepped down")
+	}
+	leases.err = nil
+	a.try()
+
+	go a.run()
+	a.Resign()
+	b.try()
+	if a.IsLeader() || !b.IsLeader() {
+		t.Fatal("expect b took over after a resigned")
+	}
+}
+
+func TestOnly(t *testing.T) {
+	defer func() { elector = nil }()
+
+	runs := 0
+	job := Only(func() { runs++ })
+	job()
+	if runs != 1 {
+		t.Fatal("expect the job run if the election is disabled")
+	}
+
+	elector = newElector(&memoryLeases{holder: "other", expireAt: time.Now().Add(time.Minute)}, "me", time.Minute)
+	elector.try()
+	job()
+	if runs != 1 {
+		t.Fatal("expect the job skipped by the replica not leader")
+	}
+}
@@ -0,0 +1,152 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package quota
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/quota"
+	"nocalhost/internal/nocalhost-api/repository/user"
+)
+
+var (
+	ErrDevSpaceQuotaExceed = errors.New("The number of dev spaces exceeds the quota")
+	ErrSpaceResourceExceed = errors.New("The resource limit of dev space exceeds the quota")
+	ErrClusterQuotaExceed  = errors.New("The number of clusters exceeds the quota")
+)
+
+type Quota struct {
+	quotaRepo *quota.QuotaRepo
+	userRepo  *user.UserBaseRepo
+}
+
+func NewQuotaService() *Quota {
+	db := model.GetDB()
+	return &Quota{
+		quotaRepo: quota.NewQuotaRepo(db),
+		userRepo:  user.NewUserRepo(db),
+	}
+}
+
+// Get returns nil if no quota attached to the subject
+func (srv *Quota) Get(ctx context.Context, subjectType string, subjectId uint64) (*model.QuotaModel, error) {
+	return srv.quotaRepo.Get(ctx, subjectType, subjectId)
+}
+
+func (srv *Quota) Save(ctx context.Context, q model.QuotaModel) (model.QuotaModel, error) {
+	return srv.quotaRepo.Save(ctx, q)
+}
+
+func (srv *Quota) Delete(ctx context.Context, subjectType string, subjectId uint64) error {
+	return srv.quotaRepo.Delete(ctx, subjectType, subjectId)
+}
+
+func (srv *Quota) Usage(ctx context.Context, userId uint64) (model.QuotaUsage, error) {
+	return srv.quotaRepo.Usage(ctx, userId)
+}
+
+// Effective returns the quota applied to the user, the quota attached to the user
+// takes precedence, otherwise the most permissive one of the teams is applied.
+// The cluster quota of user is kept for compatibility
+func (srv *Quota) Effective(ctx context.Context, userId uint64) (model.QuotaModel, error) {
+	own, err := srv.quotaRepo.Get(ctx, model.QuotaSubjectUser, userId)
+	if err != nil {
+		return model.QuotaModel{}, err
+	}
+	if own != nil {
+		return *own, nil
+	}
+
+	teams, err := srv.quotaRepo.ListTeamQuotas(ctx, userId)
+	if err != nil {
+		return model.QuotaModel{}, err
+	}
+
+	result := model.QuotaModel{}
+	for i, q := range teams {
+		if i == 0 {
+			result = *q
+			continue
+		}
+		result = result.Merge(*q)
+	}
+	result.ID, result.SubjectType, result.SubjectId = 0, model.QuotaSubjectUser, userId
+
+	u, err := srv.userRepo.GetUserByID(ctx, userId)
+	if err != nil {
+		return result, errors.Wrap(err, "")
+	}
+	if u.ClusterQuota > 0 {
+		result.MaxClusters = u.ClusterQuota
+	}
+	return result, nil
+}
+
+// CheckDevSpace check the quota before creating a dev space for the user,
+// cpu in cores and memory in Mi are the limits of it, zero means not limited
+func (srv *Quota) CheckDevSpace(ctx context.Context, userId uint64, cpu float64, memory uint64) error {
+	q, err := srv.Effective(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := CheckSpaceResource(q, cpu, memory); err != nil {
+		return err
+	}
+
+	if q.MaxDevSpaces == 0 {
+		return nil
+	}
+
+	usage, err := srv.quotaRepo.Usage(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if usage.DevSpaces >= q.MaxDevSpaces {
+		return ErrDevSpaceQuotaExceed
+	}
+	return nil
+}
+
+// CheckSpaceResource the limits of dev space are required if the quota limit them
+func CheckSpaceResource(q model.QuotaModel, cpu float64, memory uint64) error {
+	if q.MaxSpaceCpu > 0 && (cpu == 0 || cpu > q.MaxSpaceCpu) {
+		return ErrSpaceResourceExceed
+	}
+	if q.MaxSpaceMemory > 0 && (memory == 0 || memory > q.MaxSpaceMemory) {
+		return ErrSpaceResourceExceed
+	}
+	return nil
+}
+
+// CheckCluster check the quota before creating a cluster for the user
+func (srv *Quota) CheckCluster(ctx context.Context, userId uint64) error {
+	q, err := srv.Effective(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if q.MaxClusters == 0 {
+		return nil
+	}
+
+	usage, err := srv.quotaRepo.Usage(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if usage.Clusters >= q.MaxClusters {
+		return ErrClusterQuotaExceed
+	}
+	return nil
+}
+
+// Close close all repo
+func (srv *Quota) Close() {
+	srv.quotaRepo.Close()
+}
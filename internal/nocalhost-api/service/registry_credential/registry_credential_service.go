@@ -0,0 +1,51 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package registry_credential
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/registry_credential"
+)
+
+type RegistryCredential struct {
+	registryCredentialRepo *registry_credential.RegistryCredentialRepo
+}
+
+func NewRegistryCredentialService() *RegistryCredential {
+	db := model.GetDB()
+	return &RegistryCredential{registryCredentialRepo: registry_credential.NewRegistryCredentialRepo(db)}
+}
+
+func (srv *RegistryCredential) Create(ctx context.Context, r *model.RegistryCredentialModel) error {
+	return srv.registryCredentialRepo.Create(ctx, r)
+}
+
+func (srv *RegistryCredential) Get(ctx context.Context, id uint64) (*model.RegistryCredentialModel, error) {
+	return srv.registryCredentialRepo.Get(ctx, id)
+}
+
+func (srv *RegistryCredential) GetByName(ctx context.Context, name string) (*model.RegistryCredentialModel, error) {
+	return srv.registryCredentialRepo.GetByName(ctx, name)
+}
+
+func (srv *RegistryCredential) List(ctx context.Context) ([]*model.RegistryCredentialModel, error) {
+	return srv.registryCredentialRepo.List(ctx)
+}
+
+func (srv *RegistryCredential) Update(ctx context.Context, id uint64, columns map[string]interface{}) error {
+	return srv.registryCredentialRepo.Update(ctx, id, columns)
+}
+
+func (srv *RegistryCredential) Delete(ctx context.Context, id uint64) error {
+	return srv.registryCredentialRepo.Delete(ctx, id)
+}
+
+// Close close db
+func (srv *RegistryCredential) Close() {
+	srv.registryCredentialRepo.Close()
+}
@@ -0,0 +1,113 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service/leader"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/registry"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+)
+
+var (
+	registryCredentialSyncerOnce = sync.Once{}
+	// syncs triggered by the changes of credentials never run together
+	registryCredentialLock = sync.Mutex{}
+)
+
+// StartRegistryCredentialSyncer refresh the image pull secrets of registry
+// credentials in all dev spaces periodically, the ones deleted by users or
+// missing in namespaces recreated are created again
+func StartRegistryCredentialSyncer() {
+	go registryCredentialSyncerOnce.Do(
+		func() {
+			tick := time.NewTicker(registry.SyncInterval())
+			defer tick.Stop()
+
+			for shutdown.Run(leader.Only(SyncRegistryCredentials)) {
+				select {
+				case <-tick.C:
+				case <-shutdown.Stopping():
+					return
+				}
+			}
+		},
+	)
+}
+
+// SyncRegistryCredentials apply the registry credentials to the namespaces
+// of all dev spaces, a cluster not accessible is skipped
+func SyncRegistryCredentials() {
+	defer metrics.ObserveJob("registry_credential_sync")()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while syncing registry credentials: %v", err)
+		}
+	}()
+	registryCredentialLock.Lock()
+	defer registryCredentialLock.Unlock()
+
+	credentials, err := registryCredentials(context.TODO())
+	if err != nil {
+		log.Errorf("Failed to list registry credentials: %v", err)
+		return
+	}
+	devSpaces, _ := Svc.ClusterUserSvc.GetList(context.TODO(), model.ClusterUserModel{})
+
+	clients := map[uint64]*clientgo.GoClient{}
+	for _, cu := range devSpaces {
+		if cu.IsClusterAdmin() || cu.Namespace == "" {
+			continue
+		}
+		goClient, ok := clients[cu.ClusterId]
+		if !ok {
+			if cluster, err := Svc.ClusterSvc.GetCache(cu.ClusterId); err == nil {
+				goClient, _ = clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+			}
+			// nil if not accessible
+			clients[cu.ClusterId] = goClient
+		}
+		if goClient == nil {
+			continue
+		}
+		if err := registry.ApplyPullSecrets(goClient.GetClientSet(), cu.Namespace, credentials); err != nil {
+			log.Errorf("Failed to apply registry credentials to dev space %d: %v", cu.ID, err)
+		}
+	}
+}
+
+// ApplyRegistryCredentials apply the registry credentials to the namespace
+// of dev space, used while the dev space is created
+func (s *Service) ApplyRegistryCredentials(client *clientgo.GoClient, namespace string) error {
+	credentials, err := registryCredentials(context.TODO())
+	if err != nil {
+		return err
+	}
+	return registry.ApplyPullSecrets(client.GetClientSet(), namespace, credentials)
+}
+
+func registryCredentials(ctx context.Context) ([]*registry.Credential, error) {
+	list, err := Svc.RegistryCredentialSvc.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*registry.Credential, 0, len(list))
+	for _, r := range list {
+		result = append(
+			result, &registry.Credential{
+				Name: r.Name, Server: r.Server, Username: r.Username, Password: r.Password, Email: r.Email,
+			},
+		)
+	}
+	return result, nil
+}
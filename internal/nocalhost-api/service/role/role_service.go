@@ -0,0 +1,219 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package role
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/cache"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/role"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+var permissionRegexp = regexp.MustCompile(`^(\*|[a-z_]+:(\*|read|write))$`)
+
+type Role struct {
+	roleRepo *role.RoleRepo
+}
+
+func NewRoleService() *Role {
+	db := model.GetDB()
+	return &Role{roleRepo: role.NewRoleRepo(db)}
+}
+
+// InitBuiltInRoles create the built-in roles if not exist
+func (srv *Role) InitBuiltInRoles(ctx context.Context) {
+	for _, r := range model.BuiltInRoles {
+		if _, err := srv.roleRepo.GetByName(ctx, r.Name); err == nil {
+			continue
+		}
+		if _, err := srv.roleRepo.Create(ctx, r); err != nil {
+			log.Warnf("Error while init built-in role %s: %v", r.Name, err)
+		}
+	}
+}
+
+// ValidatePermissions
+func ValidatePermissions(permissions []string) error {
+	for _, p := range permissions {
+		if !permissionRegexp.MatchString(p) {
+			return errors.Errorf("invalid permission %s, should be in format of 'resource:read|write|*'", p)
+		}
+	}
+	return nil
+}
+
+func (srv *Role) Create(ctx context.Context, name, description string, permissions []string) (model.RoleModel, error) {
+	if err := ValidatePermissions(permissions); err != nil {
+		return model.RoleModel{}, err
+	}
+	return srv.roleRepo.Create(
+		ctx, model.RoleModel{
+			Name:        name,
+			Description: description,
+			Permissions: strings.Join(permissions, ","),
+		},
+	)
+}
+
+func (srv *Role) Update(ctx context.Context, id uint64, description string, permissions []string) error {
+	if err := ValidatePermissions(permissions); err != nil {
+		return err
+	}
+	r, err := srv.roleRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if r.BuiltIn {
+		return errors.New("built-in role can not be modified")
+	}
+	defer srv.evictAll()
+	return srv.roleRepo.Update(ctx, id, description, strings.Join(permissions, ","))
+}
+
+func (srv *Role) Get(ctx context.Context, id uint64) (*model.RoleModel, error) {
+	return srv.roleRepo.Get(ctx, id)
+}
+
+func (srv *Role) GetByName(ctx context.Context, name string) (*model.RoleModel, error) {
+	return srv.roleRepo.GetByName(ctx, name)
+}
+
+func (srv *Role) List(ctx context.Context) ([]*model.RoleModel, error) {
+	return srv.roleRepo.List(ctx)
+}
+
+func (srv *Role) Delete(ctx context.Context, id uint64) error {
+	r, err := srv.roleRepo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if r.BuiltIn {
+		return errors.New("built-in role can not be deleted")
+	}
+	defer srv.evictAll()
+	return srv.roleRepo.Delete(ctx, id)
+}
+
+// Bind attach the role to user, scopeId is the dev space id when scope is dev_space
+func (srv *Role) Bind(ctx context.Context, roleId, userId uint64, scope string, scopeId uint64) (
+	model.RoleBindingModel, error,
+) {
+	binding, err := newBinding(roleId, userId, scope, scopeId)
+	if err != nil {
+		return binding, err
+	}
+	if _, err := srv.roleRepo.Get(ctx, roleId); err != nil {
+		return binding, errors.Wrap(err, "role not found")
+	}
+	defer srv.evict(userId)
+	return srv.roleRepo.CreateBinding(ctx, binding)
+}
+
+func (srv *Role) Unbind(ctx context.Context, roleId, userId uint64, scope string, scopeId uint64) error {
+	binding, err := newBinding(roleId, userId, scope, scopeId)
+	if err != nil {
+		return err
+	}
+	defer srv.evict(userId)
+	return srv.roleRepo.DeleteBinding(ctx, binding)
+}
+
+// UnbindAll remove all the bindings of user, e.g. while the user is deleted
+func (srv *Role) UnbindAll(ctx context.Context, userId uint64) error {
+	defer srv.evict(userId)
+	return srv.roleRepo.DeleteBindingsByUserId(ctx, userId)
+}
+
+func (srv *Role) ListBindings(ctx context.Context, condition model.RoleBindingModel) ([]*model.RoleBindingModel, error) {
+	return srv.roleRepo.ListBindings(ctx, condition)
+}
+
+// HasPermission returns true if any role bound to the user globally, or on the
+// dev space when devSpaceId is not zero, contains the permission
+func (srv *Role) HasPermission(ctx context.Context, userId uint64, permission string, devSpaceId uint64) bool {
+	grants, err := srv.grants(ctx, userId)
+	if err != nil {
+		log.Warnf("Error while get role bindings of user %d: %v", userId, err)
+		return false
+	}
+
+	for _, g := range grants {
+		if g.binding.Scope == model.RoleScopeDevSpace && g.binding.ScopeId != devSpaceId {
+			continue
+		}
+		if g.role.Allow(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+type grant struct {
+	binding *model.RoleBindingModel
+	role    *model.RoleModel
+}
+
+func (srv *Role) grants(ctx context.Context, userId uint64) ([]grant, error) {
+	c := cache.Module(cache.ROLE)
+	if data, err := c.Value(userId); err == nil {
+		return data.Data().([]grant), nil
+	}
+
+	bindings, err := srv.roleRepo.ListBindings(ctx, model.RoleBindingModel{UserId: userId})
+	if err != nil {
+		return nil, err
+	}
+
+	roles := map[uint64]*model.RoleModel{}
+	result := make([]grant, 0, len(bindings))
+	for _, b := range bindings {
+		r, ok := roles[b.RoleId]
+		if !ok {
+			if r, err = srv.roleRepo.Get(ctx, b.RoleId); err != nil {
+				continue
+			}
+			roles[b.RoleId] = r
+		}
+		result = append(result, grant{binding: b, role: r})
+	}
+
+	c.Add(userId, cache.OUT_OF_DATE, result)
+	return result, nil
+}
+
+func (srv *Role) evict(userId uint64) {
+	_, _ = cache.Module(cache.ROLE).Delete(userId)
+}
+
+func (srv *Role) evictAll() {
+	cache.Module(cache.ROLE).Flush()
+}
+
+func newBinding(roleId, userId uint64, scope string, scopeId uint64) (model.RoleBindingModel, error) {
+	binding := model.RoleBindingModel{RoleId: roleId, UserId: userId, Scope: scope, ScopeId: scopeId}
+	switch scope {
+	case "", model.RoleScopeGlobal:
+		binding.Scope = model.RoleScopeGlobal
+		binding.ScopeId = 0
+	case model.RoleScopeDevSpace:
+		if scopeId == 0 {
+			return binding, errors.New("dev space id is required for dev_space scope")
+		}
+	default:
+		return binding, errors.Errorf("unsupported role binding scope %s", scope)
+	}
+	return binding, nil
+}
+
+func (srv *Role) Close() {
+	srv.roleRepo.Close()
+}
@@ -13,13 +13,27 @@ import (
 	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nocalhost-api/global"
 	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service/access_token"
 	"nocalhost/internal/nocalhost-api/service/application"
+	"nocalhost/internal/nocalhost-api/service/audit_log"
+	"nocalhost/internal/nocalhost-api/service/bulk_deploy"
 	"nocalhost/internal/nocalhost-api/service/application_cluster"
 	"nocalhost/internal/nocalhost-api/service/application_user"
 	"nocalhost/internal/nocalhost-api/service/cluster"
+	"nocalhost/internal/nocalhost-api/service/cluster_agent"
 	"nocalhost/internal/nocalhost-api/service/cluster_user"
+	"nocalhost/internal/nocalhost-api/service/cost"
+	"nocalhost/internal/nocalhost-api/service/dev_image"
+	"nocalhost/internal/nocalhost-api/service/dev_space_sa"
+	"nocalhost/internal/nocalhost-api/service/dev_space_template"
+	"nocalhost/internal/nocalhost-api/service/git_credential"
 	"nocalhost/internal/nocalhost-api/service/ldap"
 	"nocalhost/internal/nocalhost-api/service/pre_pull"
+	"nocalhost/internal/nocalhost-api/service/quota"
+	"nocalhost/internal/nocalhost-api/service/registry_credential"
+	"nocalhost/internal/nocalhost-api/service/role"
+	"nocalhost/internal/nocalhost-api/service/session"
+	"nocalhost/internal/nocalhost-api/service/team"
 	"nocalhost/internal/nocalhost-api/service/user"
 	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
@@ -44,6 +58,20 @@ type Service struct {
 	PrePullSvc            *pre_pull.PrePull
 	ApplicationUserSvc    *application_user.ApplicationUser
 	LdapSvc               *ldap.Ldap
+	TokenSvc              *access_token.AccessToken
+	RoleSvc               *role.Role
+	TeamSvc               *team.Team
+	AuditLogSvc           *audit_log.AuditLog
+	SessionSvc            *session.Session
+	QuotaSvc              *quota.Quota
+	DevSpaceSaSvc         *dev_space_sa.DevSpaceSa
+	ClusterAgentSvc       *cluster_agent.ClusterAgent
+	DevSpaceTemplateSvc   *dev_space_template.DevSpaceTemplate
+	GitCredentialSvc      *git_credential.GitCredential
+	BulkDeploySvc         *bulk_deploy.BulkDeploy
+	RegistryCredentialSvc *registry_credential.RegistryCredential
+	CostSvc               *cost.Cost
+	DevImageSvc           *dev_image.DevImage
 }
 
 func Init() {
@@ -61,6 +89,20 @@ func New() (s *Service) {
 		PrePullSvc:            pre_pull.NewPrePullService(),
 		ApplicationUserSvc:    application_user.NewApplicationUserService(),
 		LdapSvc:               ldap.NewLdapService(),
+		TokenSvc:              access_token.NewAccessTokenService(),
+		RoleSvc:               role.NewRoleService(),
+		TeamSvc:               team.NewTeamService(),
+		AuditLogSvc:           audit_log.NewAuditLogService(),
+		SessionSvc:            session.NewSessionService(),
+		QuotaSvc:              quota.NewQuotaService(),
+		DevSpaceSaSvc:         dev_space_sa.NewDevSpaceSaService(),
+		ClusterAgentSvc:       cluster_agent.NewClusterAgentService(),
+		DevSpaceTemplateSvc:   dev_space_template.NewDevSpaceTemplateService(),
+		GitCredentialSvc:      git_credential.NewGitCredentialService(),
+		BulkDeploySvc:         bulk_deploy.NewBulkDeployService(),
+		RegistryCredentialSvc: registry_credential.NewRegistryCredentialService(),
+		CostSvc:               cost.NewCostService(),
+		DevImageSvc:           dev_image.NewDevImageService(),
 	}
 
 	if global.ServiceInitial == "true" {
@@ -94,6 +136,9 @@ func (s *Service) dataMigrate() {
 
 	// adapt devSpace to Sa -> RoleBinding
 	s.migrateClusterUseToRoleBinding()
+
+	// built-in roles for rbac
+	s.RoleSvc.InitBuiltInRoles(context.TODO())
 }
 
 func (s *Service) generateServiceAccountNameForUser() {
@@ -376,6 +421,14 @@ func (s *Service) AuthorizeNsToDefaultSa(clusterId, userId uint64, ns string) er
 		return errno.ErrRoleBindingCreate
 	}
 
+	// the default service account pulls images of private registries
+	if err := s.ApplyRegistryCredentials(clientGo, ns); err != nil {
+		log.Error(err)
+	}
+	if err := s.ApplyDevImages(clientGo, ns); err != nil {
+		log.Error(err)
+	}
+
 	return nil
 }
 
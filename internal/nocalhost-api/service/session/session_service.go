@@ -0,0 +1,125 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package session
+
+import (
+	"context"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+
+	"nocalhost/internal/nocalhost-api/cache"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/session"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/token"
+)
+
+// last seen is updated at most once in the interval
+const touchInterval = time.Minute
+
+type Session struct {
+	sessionRepo *session.SessionRepo
+}
+
+func NewSessionService() *Session {
+	db := model.GetDB()
+	return &Session{sessionRepo: session.NewSessionRepo(db)}
+}
+
+// Create start a session of user logged in from the device and ip
+func (srv *Session) Create(ctx context.Context, userId uint64, device, ip string) (*model.SessionModel, error) {
+	now := time.Now()
+	s := &model.SessionModel{
+		Sid:        uuid.NewV4().String(),
+		UserId:     userId,
+		Device:     device,
+		Ip:         ip,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(token.RefreshExpire()),
+	}
+
+	if err := srv.sessionRepo.Create(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate returns false if the session is revoked or expired,
+// otherwise the last seen of it is updated
+func (srv *Session) Validate(ctx context.Context, sid string, userId uint64, ip string) bool {
+	s, err := srv.getCache(ctx, sid)
+	if err != nil || s.UserId != userId || !s.IsActive() {
+		return false
+	}
+
+	if time.Since(s.LastSeenAt) > touchInterval || s.Ip != ip {
+		now := time.Now()
+		if err := srv.sessionRepo.Touch(ctx, sid, ip, now); err != nil {
+			log.Warnf("touch session err: %v", err)
+		}
+
+		touched := *s
+		touched.LastSeenAt, touched.Ip = now, ip
+		cache.Module(cache.SESSION).Add(sid, cache.OUT_OF_DATE, &touched)
+	}
+	return true
+}
+
+// Extend renew the expiry of session while the tokens refreshed
+func (srv *Session) Extend(ctx context.Context, sid string) error {
+	defer srv.evict(sid)
+	return srv.sessionRepo.Extend(ctx, sid, time.Now().Add(token.RefreshExpire()))
+}
+
+// List list the active sessions of user
+func (srv *Session) List(ctx context.Context, userId uint64) ([]*model.SessionModel, error) {
+	return srv.sessionRepo.ListActive(ctx, userId)
+}
+
+// Revoke revoke the session of user, the tokens of it are invalid immediately
+func (srv *Session) Revoke(ctx context.Context, userId, id uint64) error {
+	sid, err := srv.sessionRepo.Revoke(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+
+	srv.evict(sid)
+	return nil
+}
+
+// RevokeAll revoke all the sessions of user
+func (srv *Session) RevokeAll(ctx context.Context, userId uint64) error {
+	sids, err := srv.sessionRepo.RevokeAll(ctx, userId)
+	for _, sid := range sids {
+		srv.evict(sid)
+	}
+	return err
+}
+
+func (srv *Session) getCache(ctx context.Context, sid string) (*model.SessionModel, error) {
+	c := cache.Module(cache.SESSION)
+	if value, err := c.Value(sid); err == nil {
+		return value.Data().(*model.SessionModel), nil
+	}
+
+	s, err := srv.sessionRepo.GetBySid(ctx, sid)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Add(sid, cache.OUT_OF_DATE, s)
+	return s, nil
+}
+
+func (srv *Session) evict(sid string) {
+	_, _ = cache.Module(cache.SESSION).Delete(sid)
+}
+
+// Close close all repo
+func (srv *Session) Close() {
+	srv.sessionRepo.Close()
+}
@@ -0,0 +1,143 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/team"
+)
+
+type Team struct {
+	teamRepo *team.TeamRepo
+}
+
+func NewTeamService() *Team {
+	db := model.GetDB()
+	return &Team{teamRepo: team.NewTeamRepo(db)}
+}
+
+func (srv *Team) Create(ctx context.Context, name, description string, userId uint64) (model.TeamModel, error) {
+	return srv.teamRepo.Create(ctx, model.TeamModel{Name: name, Description: description, UserId: userId})
+}
+
+func (srv *Team) Update(ctx context.Context, id uint64, name, description string) error {
+	return srv.teamRepo.Update(ctx, id, name, description)
+}
+
+func (srv *Team) Get(ctx context.Context, id uint64) (*model.TeamModel, error) {
+	return srv.teamRepo.Get(ctx, id)
+}
+
+func (srv *Team) List(ctx context.Context) ([]*model.TeamModel, error) {
+	return srv.teamRepo.List(ctx)
+}
+
+// ListByUserId list the teams the user belongs to
+func (srv *Team) ListByUserId(ctx context.Context, userId uint64) ([]*model.TeamModel, error) {
+	return srv.teamRepo.ListByUserId(ctx, userId)
+}
+
+func (srv *Team) Delete(ctx context.Context, id uint64) error {
+	return srv.teamRepo.Delete(ctx, id)
+}
+
+func (srv *Team) AddMembers(ctx context.Context, teamId uint64, userIds []uint64) error {
+	return srv.teamRepo.AddMembers(ctx, teamId, userIds)
+}
+
+func (srv *Team) RemoveMembers(ctx context.Context, teamId uint64, userIds []uint64) error {
+	if len(userIds) == 0 {
+		return nil
+	}
+	return srv.teamRepo.RemoveMembers(ctx, teamId, userIds)
+}
+
+// RemoveMemberFromAll remove the user from all teams, e.g. while the user is deleted
+func (srv *Team) RemoveMemberFromAll(ctx context.Context, userId uint64) error {
+	return srv.teamRepo.RemoveMemberFromAll(ctx, userId)
+}
+
+// MemberIds returns the user ids of the team members
+func (srv *Team) MemberIds(ctx context.Context, teamId uint64) ([]uint64, error) {
+	members, err := srv.teamRepo.ListMembers(ctx, teamId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]uint64, 0, len(members))
+	for _, m := range members {
+		result = append(result, m.UserId)
+	}
+	return result, nil
+}
+
+// Grant grant the application or dev space to the team, role is only
+// meaningful for dev space and defaults to viewer
+func (srv *Team) Grant(ctx context.Context, teamId uint64, resourceType string, resourceId uint64, role string) (
+	model.TeamGrantModel, error,
+) {
+	switch resourceType {
+	case model.TeamGrantApplication:
+		role = ""
+	case model.TeamGrantDevSpace:
+		if role == "" {
+			role = model.TeamGrantViewer
+		}
+		if role != model.TeamGrantViewer && role != model.TeamGrantCooperator {
+			return model.TeamGrantModel{}, errors.Errorf("unsupported dev space role %s", role)
+		}
+	default:
+		return model.TeamGrantModel{}, errors.Errorf("unsupported resource type %s", resourceType)
+	}
+
+	return srv.teamRepo.SaveGrant(
+		ctx, model.TeamGrantModel{TeamId: teamId, ResourceType: resourceType, ResourceId: resourceId, Role: role},
+	)
+}
+
+func (srv *Team) GetGrant(ctx context.Context, teamId uint64, resourceType string, resourceId uint64) (
+	*model.TeamGrantModel, error,
+) {
+	return srv.teamRepo.GetGrant(ctx, teamId, resourceType, resourceId)
+}
+
+func (srv *Team) Revoke(ctx context.Context, teamId uint64, resourceType string, resourceId uint64) error {
+	return srv.teamRepo.DeleteGrant(ctx, teamId, resourceType, resourceId)
+}
+
+// ListGrants list the resources visible to the team
+func (srv *Team) ListGrants(ctx context.Context, teamId uint64) ([]*model.TeamGrantModel, error) {
+	return srv.teamRepo.ListGrants(ctx, model.TeamGrantModel{TeamId: teamId})
+}
+
+// IsGrantedByOtherTeam returns true if the resource is also granted to the
+// user by any team other than the excluded one
+func (srv *Team) IsGrantedByOtherTeam(
+	ctx context.Context, userId uint64, resourceType string, resourceId, excludeTeamId uint64,
+) bool {
+	teams, err := srv.teamRepo.ListByUserId(ctx, userId)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range teams {
+		if t.ID == excludeTeamId {
+			continue
+		}
+		if _, err := srv.teamRepo.GetGrant(ctx, t.ID, resourceType, resourceId); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (srv *Team) Close() {
+	srv.teamRepo.Close()
+}
@@ -0,0 +1,136 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/auth"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/utils"
+)
+
+// ImportRow one user to create by bulk import
+type ImportRow struct {
+	Line         int
+	Email        string
+	Name         string
+	IsAdmin      bool
+	ClusterQuota uint64
+
+	// error found before importing, e.g. invalid format
+	Err string
+}
+
+// ImportResult the result of each row
+type ImportResult struct {
+	Line    int    `json:"line"`
+	Email   string `json:"email"`
+	Success bool   `json:"success"`
+	UserId  uint64 `json:"user_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkCreate create the users in a single transaction, nothing is created if
+// any row is invalid. The users are created with random password, and the
+// password reset mail is sent to them if the mail server is configured
+func (srv *User) BulkCreate(ctx context.Context, rows []ImportRow) ([]ImportResult, bool) {
+	results := make([]ImportResult, len(rows))
+	emails := map[string]bool{}
+	valid := true
+
+	for i, row := range rows {
+		results[i] = ImportResult{Line: row.Line, Email: row.Email}
+
+		switch {
+		case row.Err != "":
+			results[i].Error = row.Err
+		case !utils.IsEmail(row.Email):
+			results[i].Error = "email is incorrect"
+		case emails[strings.ToLower(row.Email)]:
+			results[i].Error = "email is duplicated in the file"
+		default:
+			if _, err := srv.userRepo.GetUserByEmail(ctx, row.Email); err == nil {
+				results[i].Error = "user of the email already exists"
+			} else if !gorm.IsRecordNotFoundError(err) {
+				results[i].Error = err.Error()
+			}
+		}
+
+		emails[strings.ToLower(row.Email)] = true
+		if results[i].Error != "" {
+			valid = false
+		}
+	}
+
+	if !valid {
+		return skipRest(results), false
+	}
+
+	users := make([]*model.UserBaseModel, 0, len(rows))
+	for i, row := range rows {
+		name := row.Name
+		if name == "" {
+			name = row.Email[:strings.Index(row.Email, "@")]
+		}
+
+		pwd, err := auth.Encrypt(uuid.NewV4().String())
+		if err != nil {
+			results[i].Error = err.Error()
+			return skipRest(results), false
+		}
+
+		users = append(
+			users, &model.UserBaseModel{
+				SaName:       model.GenerateSaName(),
+				Password:     pwd,
+				Email:        row.Email,
+				Name:         name,
+				Username:     name,
+				Status:       _const.BoolToUint64Pointer(true),
+				IsAdmin:      _const.BoolToUint64Pointer(row.IsAdmin),
+				ClusterQuota: row.ClusterQuota,
+				Uuid:         uuid.NewV4().String(),
+			},
+		)
+	}
+
+	if failed, err := srv.userRepo.CreateInTransaction(ctx, users); err != nil {
+		if failed >= 0 {
+			results[failed].Error = err.Error()
+		}
+		return skipRest(results), false
+	}
+
+	for i, u := range users {
+		results[i].Success = true
+		results[i].UserId = u.ID
+
+		if srv.mailer.Enabled() {
+			if err := srv.RequestPasswordReset(ctx, u.Email); err != nil {
+				log.Warnf("Fail to send password reset mail to imported user %s: %v", u.Email, err)
+			}
+		}
+	}
+	return results, true
+}
+
+func skipRest(results []ImportResult) []ImportResult {
+	for i := range results {
+		results[i].Success = false
+		results[i].UserId = 0
+		if results[i].Error == "" {
+			results[i].Error = "skipped because other rows failed"
+		}
+	}
+	return results
+}
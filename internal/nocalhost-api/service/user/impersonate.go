@@ -0,0 +1,68 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/token"
+)
+
+var (
+	ErrImpersonateNotAdmin = errors.New("Only admin can impersonate other users")
+	ErrImpersonateTarget   = errors.New("The user can not be impersonated")
+)
+
+// Impersonate issues a short-lived token for the admin to act as the target user,
+// the token contains both identities so that every request made with it can be
+// traced back to the admin. Admins can not be impersonated to avoid hiding the actor
+func (srv *User) Impersonate(ctx context.Context, adminID, targetUserID uint64) (string, time.Time, error) {
+	admin, err := srv.GetCache(adminID)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "")
+	}
+
+	if admin.IsAdmin == nil || *admin.IsAdmin != 1 || admin.Status == nil || *admin.Status == 0 {
+		return "", time.Time{}, ErrImpersonateNotAdmin
+	}
+
+	if adminID == targetUserID {
+		return "", time.Time{}, ErrImpersonateTarget
+	}
+
+	target, err := srv.GetCache(targetUserID)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "")
+	}
+
+	if target.Status == nil || *target.Status == 0 || (target.IsAdmin != nil && *target.IsAdmin == 1) {
+		return "", time.Time{}, ErrImpersonateTarget
+	}
+
+	sign, expiresAt, err := token.SignImpersonation(
+		token.Context{
+			UserID:   target.ID,
+			Username: target.Username,
+			Uuid:     target.Uuid,
+			Email:    target.Email,
+			IsAdmin:  0,
+			ActorID:  admin.ID,
+
+			Generation:      target.TokenGeneration,
+			ActorGeneration: admin.TokenGeneration,
+		},
+	)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	log.Infof("[impersonation] admin %d(%s) start impersonating user %d(%s)", admin.ID, admin.Email, target.ID, target.Email)
+	return sign, expiresAt, nil
+}
@@ -0,0 +1,112 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/notify"
+)
+
+const (
+	LOGIN_MAX_FAILURES     = "login.max_failures"
+	LOGIN_MAX_IP_FAILURES  = "login.max_ip_failures"
+	LOGIN_LOCK_DURATION    = "login.lock_duration"
+	LOGIN_MAX_LOCK_DURATON = "login.max_lock_duration"
+
+	defaultMaxFailures     = 5
+	defaultMaxIpFailures   = 20
+	defaultLockDuration    = time.Minute
+	defaultMaxLockDuration = time.Hour
+)
+
+var ErrLoginLocked = errors.New("login is locked because of too many failures")
+
+// CheckLoginLocked returns ErrLoginLocked if the user or the ip is locked
+func (srv *User) CheckLoginLocked(ctx context.Context, userId uint64, ip string) error {
+	for _, key := range loginAttemptKeys(userId, ip) {
+		if attempt, err := srv.loginAttemptRepo.Get(ctx, key); err == nil && attempt.IsLocked() {
+			return ErrLoginLocked
+		}
+	}
+	return nil
+}
+
+// UnlockLogin clear the login failures of the user
+func (srv *User) UnlockLogin(ctx context.Context, userId uint64) error {
+	return srv.loginAttemptRepo.Reset(ctx, model.LoginAttemptUserKey(userId))
+}
+
+func (srv *User) loginFailed(ctx context.Context, userId uint64, ip string) {
+	lock := durationOrDefault(LOGIN_LOCK_DURATION, defaultLockDuration)
+	maxLock := durationOrDefault(LOGIN_MAX_LOCK_DURATON, defaultMaxLockDuration)
+
+	thresholds := map[string]int{}
+	if userId > 0 {
+		thresholds[model.LoginAttemptUserKey(userId)] = intOrDefault(LOGIN_MAX_FAILURES, defaultMaxFailures)
+	}
+	if ip != "" {
+		thresholds[model.LoginAttemptIpKey(ip)] = intOrDefault(LOGIN_MAX_IP_FAILURES, defaultMaxIpFailures)
+	}
+
+	for key, threshold := range thresholds {
+		threshold := threshold
+		attempt, err := srv.loginAttemptRepo.Fail(
+			ctx, key, func(attempt *model.LoginAttemptModel) {
+				attempt.Fail(threshold, lock, maxLock)
+			},
+		)
+		if err != nil {
+			log.Warnf("record login failure err: %v", err)
+			continue
+		}
+
+		if attempt.IsLocked() {
+			log.Warnf("login of %s is locked until %s after %d failures", key, attempt.LockedUntil, attempt.Failures)
+			notify.Notify(
+				notify.UserLocked, map[string]interface{}{"key": key, "user_id": userId, "ip": ip},
+				"Login of %s is locked until %s after %d failures", key, attempt.LockedUntil, attempt.Failures,
+			)
+		}
+	}
+}
+
+func (srv *User) loginSucceeded(ctx context.Context, userId uint64) {
+	if err := srv.loginAttemptRepo.Reset(ctx, model.LoginAttemptUserKey(userId)); err != nil {
+		log.Warnf("reset login failures err: %v", err)
+	}
+}
+
+func loginAttemptKeys(userId uint64, ip string) []string {
+	keys := make([]string, 0, 2)
+	if userId > 0 {
+		keys = append(keys, model.LoginAttemptUserKey(userId))
+	}
+	if ip != "" {
+		keys = append(keys, model.LoginAttemptIpKey(ip))
+	}
+	return keys
+}
+
+func durationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	if v := viper.GetDuration(key); v > 0 {
+		return v
+	}
+	return defaultValue
+}
+
+func intOrDefault(key string, defaultValue int) int {
+	if v := viper.GetInt(key); v > 0 {
+		return v
+	}
+	return defaultValue
+}
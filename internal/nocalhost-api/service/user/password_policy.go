@@ -0,0 +1,66 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/pkg/nocalhost-api/pkg/auth"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/password"
+)
+
+// PasswordPolicyError the password violates the policy
+type PasswordPolicyError struct {
+	error
+}
+
+// IsPasswordPolicyError
+func IsPasswordPolicyError(err error) bool {
+	_, ok := errors.Cause(err).(*PasswordPolicyError)
+	return ok
+}
+
+// CheckPassword validate the password against the policy, and the recent
+// passwords of the user if userId is not zero
+func (srv *User) CheckPassword(ctx context.Context, userId uint64, plain string) error {
+	policy := password.Current()
+	if err := policy.Validate(plain); err != nil {
+		return &PasswordPolicyError{err}
+	}
+
+	if userId == 0 || policy.History <= 0 {
+		return nil
+	}
+
+	histories, err := srv.passwordHistoryRepo.ListLatest(ctx, userId, policy.History)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range histories {
+		if auth.Compare(h.Password, plain) == nil {
+			return &PasswordPolicyError{
+				errors.Errorf("Password can not be the same as the last %d passwords", policy.History),
+			}
+		}
+	}
+	return nil
+}
+
+// recordPassword keep the hash of password for the reuse check
+func (srv *User) recordPassword(ctx context.Context, userId uint64, hash string) {
+	history := password.Current().History
+	if history <= 0 || userId == 0 || hash == "" {
+		return
+	}
+
+	if err := srv.passwordHistoryRepo.Add(ctx, userId, hash, history); err != nil {
+		log.Warnf("Fail to record password history of user %d: %v", userId, err)
+	}
+}
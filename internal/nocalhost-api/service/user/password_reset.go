@@ -0,0 +1,127 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/auth"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+const (
+	// PASSWORD_RESET_URL the link in mail, %s will be replaced by the token
+	PASSWORD_RESET_URL = "mail.password_reset_url"
+
+	passwordResetExpire = 30 * time.Minute
+)
+
+var ErrPasswordResetTokenInvalid = errors.New("password reset token is invalid or expired")
+
+// RequestPasswordReset send the password reset mail to the user,
+// nothing happen if the email does not exist
+func (srv *User) RequestPasswordReset(ctx context.Context, email string) error {
+	if !srv.mailer.Enabled() {
+		return errors.New("Mail server is not configured")
+	}
+
+	u, err := srv.GetUserByEmail(ctx, email)
+	if err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			log.Infof("password reset requested for non-exist email %s", email)
+			return nil
+		}
+		return err
+	}
+
+	// ldap user should reset password in the directory
+	if u.LdapDN != "" || u.Status == nil || *u.Status == 0 {
+		log.Infof("password reset requested for user %d is not allowed", u.ID)
+		return nil
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return errors.Wrap(err, "generate password reset token err")
+	}
+	plain := hex.EncodeToString(b)
+
+	// only the latest token is valid
+	srv.passwordResetRepo.InvalidateByUserId(ctx, u.ID)
+	if err := srv.passwordResetRepo.Create(
+		ctx, model.PasswordResetModel{
+			UserId:    u.ID,
+			TokenHash: hashResetToken(plain),
+			ExpiresAt: time.Now().Add(passwordResetExpire),
+		},
+	); err != nil {
+		return err
+	}
+
+	return srv.sendPasswordReset(u, plain)
+}
+
+func (srv *User) sendPasswordReset(u *model.UserBaseModel, token string) error {
+	return srv.mailer.Send(
+		u.Email, "Reset your Nocalhost password",
+		fmt.Sprintf(
+			"Hi %s,\r\n\r\nWe received a request to reset your password, "+
+				"please visit the link below in %d minutes to reset it:\r\n\r\n%s\r\n\r\n"+
+				"If you did not request this, please ignore this mail.",
+			u.Name, int(passwordResetExpire.Minutes()), passwordResetUrl(token),
+		),
+	)
+}
+
+// ResetPassword reset the password by the token from the mail, returns the
+// user reset, all tokens issued to the user will be revoked
+func (srv *User) ResetPassword(ctx context.Context, plain, password string) (uint64, error) {
+	reset, err := srv.passwordResetRepo.GetByHash(ctx, hashResetToken(plain))
+	if err != nil || !reset.IsValid() {
+		return 0, ErrPasswordResetTokenInvalid
+	}
+
+	if err := srv.CheckPassword(ctx, reset.UserId, password); err != nil {
+		return 0, err
+	}
+
+	if !srv.passwordResetRepo.MarkUsed(ctx, reset.ID) {
+		return 0, ErrPasswordResetTokenInvalid
+	}
+
+	pwd, err := auth.Encrypt(password)
+	if err != nil {
+		return 0, errors.Wrapf(err, "encrypt password err")
+	}
+
+	if _, err := srv.UpdateUser(ctx, reset.UserId, &model.UserBaseModel{Password: pwd}); err != nil {
+		return 0, err
+	}
+
+	return reset.UserId, srv.RevokeTokens(ctx, reset.UserId)
+}
+
+func passwordResetUrl(token string) string {
+	if resetUrl := viper.GetString(PASSWORD_RESET_URL); resetUrl != "" {
+		return fmt.Sprintf(resetUrl, token)
+	}
+	return fmt.Sprintf("%s/reset_password?token=%s", viper.GetString("app.url"), token)
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
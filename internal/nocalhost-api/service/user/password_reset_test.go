@@ -0,0 +1,68 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+type sentMail struct {
+	to, subject, body string
+}
+
+// fakeMailer keeps the mails instead of sending
+type fakeMailer struct {
+	disabled bool
+	sent     []sentMail
+}
+
+func (m *fakeMailer) Enabled() bool {
+	return !m.disabled
+}
+
+func (m *fakeMailer) Send(to, subject, body string) error {
+	m.sent = append(m.sent, sentMail{to, subject, body})
+	return nil
+}
+
+func TestSendPasswordReset(t *testing.T) {
+	viper.Set(PASSWORD_RESET_URL, "https://nocalhost.dev/reset?token=%s")
+	defer viper.Set(PASSWORD_RESET_URL, "")
+
+	mailer := &fakeMailer{}
+	srv := &User{mailer: mailer}
+
+	if err := srv.sendPasswordReset(&model.UserBaseModel{Name: "Anur", Email: "anur@nocalhost.com"}, "t0ken"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mailer.sent) != 1 {
+		t.Fatalf("expect one mail sent, got %d", len(mailer.sent))
+	}
+	if m := mailer.sent[0]; m.to != "anur@nocalhost.com" ||
+		!strings.Contains(m.body, "https://nocalhost.dev/reset?token=t0ken") {
+		t.Errorf("expect the reset link mailed to the user, got %+v", m)
+	}
+}
+
+func TestRequestPasswordResetMailDisabled(t *testing.T) {
+	mailer := &fakeMailer{disabled: true}
+	srv := &User{mailer: mailer}
+
+	// returns before looking up the user
+	if err := srv.RequestPasswordReset(context.TODO(), "anur@nocalhost.com"); err == nil {
+		t.Error("expect error if the mailer is disabled")
+	}
+	if len(mailer.sent) != 0 {
+		t.Errorf("expect no mail sent, got %d", len(mailer.sent))
+	}
+}
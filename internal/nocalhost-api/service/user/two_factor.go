@@ -0,0 +1,197 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/totp"
+)
+
+const (
+	TWO_FACTOR_ISSUER        = "two_factor.issuer"
+	TWO_FACTOR_ENFORCE_ADMIN = "two_factor.enforce_admin"
+
+	recoveryCodesCount = 8
+)
+
+var (
+	ErrSecondFactorRequired = errors.New("second factor required")
+	ErrSecondFactorInvalid  = errors.New("second factor invalid")
+)
+
+// EnrollTotp generate a new totp secret and recovery codes for user,
+// the secret take effect after ActivateTotp
+func (srv *User) EnrollTotp(ctx context.Context, id uint64) (string, string, []string, error) {
+	u, err := srv.GetUserByID(ctx, id)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if u.IsTotpEnabled() {
+		return "", "", nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", nil, errors.Wrap(err, "generate totp secret err")
+	}
+
+	codes, err := totp.GenerateRecoveryCodes(recoveryCodesCount)
+	if err != nil {
+		return "", "", nil, errors.Wrap(err, "generate recovery codes err")
+	}
+
+	hashed := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashed = append(hashed, hashRecoveryCode(code))
+	}
+
+	defer srv.Evict(id)
+	if err := srv.userRepo.UpdateTotp(ctx, id, secret, 0, strings.Join(hashed, ",")); err != nil {
+		return "", "", nil, err
+	}
+
+	issuer := viper.GetString(TWO_FACTOR_ISSUER)
+	if issuer == "" {
+		issuer = "Nocalhost"
+	}
+	return secret, totp.ProvisioningURI(issuer, u.Email, secret), codes, nil
+}
+
+// ActivateTotp enable two-factor authentication after the code verified
+func (srv *User) ActivateTotp(ctx context.Context, id uint64, code string) error {
+	u, err := srv.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if u.TotpSecret == "" {
+		return errors.New("two-factor authentication is not enrolled")
+	}
+
+	defer srv.Evict(id)
+	if err := srv.validateTotp(ctx, u, code); err != nil {
+		return err
+	}
+
+	return srv.userRepo.UpdateTotp(ctx, id, u.TotpSecret, 1, u.TotpRecoveryCodes)
+}
+
+// DisableTotp disable two-factor authentication, code or recovery code is required
+func (srv *User) DisableTotp(ctx context.Context, id uint64, code string) error {
+	u, err := srv.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := srv.VerifySecondFactor(ctx, u, code); err != nil {
+		return err
+	}
+
+	defer srv.Evict(id)
+	return srv.userRepo.UpdateTotp(ctx, id, "", 0, "")
+}
+
+// ResetTotp use by admin when user lost the authenticator and recovery codes
+func (srv *User) ResetTotp(ctx context.Context, id uint64) error {
+	defer srv.Evict(id)
+	return srv.userRepo.UpdateTotp(ctx, id, "", 0, "")
+}
+
+// VerifySecondFactor check the totp code or recovery code if
+// two-factor authentication is enabled, recovery code can only be used once
+func (srv *User) VerifySecondFactor(ctx context.Context, u *model.UserBaseModel, code string) error {
+	if !u.IsTotpEnabled() {
+		return nil
+	}
+
+	if code == "" {
+		return ErrSecondFactorRequired
+	}
+
+	if err := srv.validateTotp(ctx, u, code); err != ErrSecondFactorInvalid {
+		return err
+	}
+
+	hashed := hashRecoveryCode(code)
+	codes := strings.Split(u.TotpRecoveryCodes, ",")
+	for i, c := range codes {
+		if c != "" && c == hashed {
+			left := append(codes[:i:i], codes[i+1:]...)
+
+			defer srv.Evict(u.ID)
+			return srv.userRepo.UpdateTotp(ctx, u.ID, u.TotpSecret, 1, strings.Join(left, ","))
+		}
+	}
+
+	return ErrSecondFactorInvalid
+}
+
+// SecondFactorLogin verify the second factor of the user logged in by the
+// identity provider, failures are counted as the ones of password login
+func (srv *User) SecondFactorLogin(ctx context.Context, u *model.UserBaseModel, code, ip string) error {
+	if err := srv.CheckLoginLocked(ctx, u.ID, ip); err != nil {
+		return err
+	}
+
+	if err := srv.VerifySecondFactor(ctx, u, code); err != nil {
+		if err == ErrSecondFactorInvalid {
+			srv.loginFailed(ctx, u.ID, ip)
+		}
+		return err
+	}
+
+	srv.loginSucceeded(ctx, u.ID)
+	return nil
+}
+
+// TwoFactorEnrollRequired returns true if two-factor authentication is enforced
+// for admin users and the admin has not enabled it yet
+func (srv *User) TwoFactorEnrollRequired(id uint64) bool {
+	if !viper.GetBool(TWO_FACTOR_ENFORCE_ADMIN) {
+		return false
+	}
+
+	u, err := srv.GetCache(id)
+	if err != nil {
+		return false
+	}
+
+	return u.IsAdmin != nil && *u.IsAdmin == 1 && !u.IsTotpEnabled()
+}
+
+// validateTotp check the totp code, the code of the time step accepted before
+// is invalid, so it can not be replayed within the skew
+func (srv *User) validateTotp(ctx context.Context, u *model.UserBaseModel, code string) error {
+	step, ok := totp.ValidateStep(u.TotpSecret, code, time.Now())
+	if !ok || step <= u.TotpLastStep {
+		return ErrSecondFactorInvalid
+	}
+
+	defer srv.Evict(u.ID)
+	accepted, err := srv.userRepo.AcceptTotpStep(ctx, u.ID, step)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrSecondFactorInvalid
+	}
+	return nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
@@ -20,18 +20,38 @@ import (
 	"github.com/jinzhu/gorm"
 
 	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/repository/login_attempt"
+	"nocalhost/internal/nocalhost-api/repository/password_history"
+	"nocalhost/internal/nocalhost-api/repository/password_reset"
 	"nocalhost/internal/nocalhost-api/repository/user"
 	"nocalhost/pkg/nocalhost-api/pkg/auth"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/mail"
+	"nocalhost/pkg/nocalhost-api/pkg/oauth"
+	"nocalhost/pkg/nocalhost-api/pkg/oidc"
+	"nocalhost/pkg/nocalhost-api/pkg/token"
 )
 
 type User struct {
-	userRepo *user.UserBaseRepo
+	userRepo          *user.UserBaseRepo
+	passwordResetRepo *password_reset.PasswordResetRepo
+	loginAttemptRepo  *login_attempt.LoginAttemptRepo
+
+	passwordHistoryRepo *password_history.PasswordHistoryRepo
+
+	mailer mail.Mailer
 }
 
 func NewUserService() *User {
 	db := model.GetDB()
 	return &User{
-		userRepo: user.NewUserRepo(db),
+		userRepo:          user.NewUserRepo(db),
+		passwordResetRepo: password_reset.NewPasswordResetRepo(db),
+		loginAttemptRepo:  login_attempt.NewLoginAttemptRepo(db),
+
+		passwordHistoryRepo: password_history.NewPasswordHistoryRepo(db),
+
+		mailer: mail.NewSMTP(),
 	}
 }
 
@@ -40,49 +60,51 @@ func (srv *User) UpdateUsersLdapGen(list []*model.UserBaseModel, ldapGen uint64)
 }
 
 func (srv *User) Evict(id uint64) {
-	c := cache.Module(cache.USER)
-
-	value, err := c.Value(id)
-	if err != nil {
-		return
+	keys := []interface{}{id}
+	remote := model.UserBaseModel{}
+	if value, err := cache.Module(cache.USER).Value(id); err == nil {
+		keys = append(keys, value.Data().(*model.UserBaseModel).SaName)
+	} else if cache.GetRemote(cache.USER, id, &remote) {
+		keys = append(keys, remote.SaName)
 	}
-
-	clusterModel := value.Data().(*model.UserBaseModel)
-	_, _ = c.Delete(id)
-	_, _ = c.Delete(clusterModel.SaName)
+	cache.Delete(cache.USER, keys...)
 }
 
 func (srv *User) GetCacheBySa(sa string) (model.UserBaseModel, error) {
-	c := cache.Module(cache.USER)
-	value, err := c.Value(sa)
-	if err == nil {
-		clusterModel := value.Data().(*model.UserBaseModel)
-		return *clusterModel, nil
+	if value, ok := cache.Value(cache.USER, sa); ok {
+		return *value.(*model.UserBaseModel), nil
 	}
 
-	result, err := srv.userRepo.GetUserBySa(context.TODO(), sa)
-	if err != nil {
-		return model.UserBaseModel{}, errors.Wrapf(err, "get user")
+	result := &model.UserBaseModel{}
+	if !cache.GetRemote(cache.USER, sa, result) {
+		var err error
+		if result, err = srv.userRepo.GetUserBySa(context.TODO(), sa); err != nil {
+			return model.UserBaseModel{}, errors.Wrapf(err, "get user")
+		}
+		cache.SetRemote(cache.USER, result, result.ID, result.SaName)
 	}
 
+	c := cache.Module(cache.USER)
 	c.Add(result.ID, cache.OUT_OF_DATE, result)
 	c.Add(result.SaName, cache.OUT_OF_DATE, result)
 	return *result, nil
 }
 
 func (srv *User) GetCache(id uint64) (model.UserBaseModel, error) {
-	c := cache.Module(cache.USER)
-	value, err := c.Value(id)
-	if err == nil {
-		clusterModel := value.Data().(*model.UserBaseModel)
-		return *clusterModel, nil
+	if value, ok := cache.Value(cache.USER, id); ok {
+		return *value.(*model.UserBaseModel), nil
 	}
 
-	result, err := srv.GetUserByID(context.TODO(), id)
-	if err != nil {
-		return model.UserBaseModel{}, errors.Wrapf(err, "get user")
+	result := &model.UserBaseModel{}
+	if !cache.GetRemote(cache.USER, id, result) {
+		var err error
+		if result, err = srv.GetUserByID(context.TODO(), id); err != nil {
+			return model.UserBaseModel{}, errors.Wrapf(err, "get user")
+		}
+		cache.SetRemote(cache.USER, result, result.ID, result.SaName)
 	}
 
+	c := cache.Module(cache.USER)
 	c.Add(result.ID, cache.OUT_OF_DATE, result)
 	c.Add(result.SaName, cache.OUT_OF_DATE, result)
 	return *result, nil
@@ -96,9 +118,9 @@ func (srv *User) GetUserHasNotSa(ctx context.Context) ([]*model.UserBaseModel, e
 	return srv.userRepo.GetUserHasNotSa(ctx)
 }
 
-// deprecated
-func (srv *User) GetUserList(ctx context.Context) ([]*model.UserList, error) {
-	return srv.userRepo.GetUserList(ctx)
+// GetUserList returns the users matches the query and the total count of them
+func (srv *User) GetUserList(ctx context.Context, query model.UserListQuery) ([]*model.UserList, uint64, error) {
+	return srv.userRepo.GetUserList(ctx, query)
 }
 
 func (srv *User) DeleteOutOfSyncLdapUser(ldapGen uint64) (int64, error) {
@@ -111,6 +133,7 @@ func (srv *User) Delete(ctx context.Context, id uint64) error {
 	if err != nil {
 		return errors.Wrapf(err, "delete user fail")
 	}
+	_ = srv.passwordHistoryRepo.DeleteByUserId(ctx, id)
 	srv.Evict(id)
 	return nil
 }
@@ -141,6 +164,7 @@ func (srv *User) Create(
 		return result, errors.Wrapf(err, "create user")
 	}
 
+	srv.recordPassword(ctx, result.ID, result.Password)
 	srv.Evict(result.ID)
 	return result, nil
 }
@@ -159,6 +183,10 @@ func (srv *User) Creates(
 
 // Register
 func (srv *User) Register(ctx context.Context, email, password string) error {
+	if err := srv.CheckPassword(ctx, 0, password); err != nil {
+		return err
+	}
+
 	pwd, err := auth.Encrypt(password)
 	if err != nil {
 		return errors.Wrapf(err, "encrypt password err")
@@ -175,20 +203,28 @@ func (srv *User) Register(ctx context.Context, email, password string) error {
 	if err != nil {
 		return errors.Wrapf(err, "create user")
 	}
+	srv.recordPassword(ctx, result.ID, result.Password)
 	srv.Evict(result.ID)
 	return nil
 }
 
-// EmailLogin
-func (srv *User) EmailLogin(ctx context.Context, email, password string) (err error) {
+// EmailLogin the otp is required if two-factor authentication is enabled,
+// user and ip will be locked after continuous failures
+func (srv *User) EmailLogin(ctx context.Context, email, password, otp, ip string) (err error) {
 	u, err := srv.GetUserByEmail(ctx, email)
 	if err != nil {
+		srv.loginFailed(ctx, 0, ip)
 		return errors.Wrapf(err, "get user info err by email")
 	}
 
+	if err := srv.CheckLoginLocked(ctx, u.ID, ip); err != nil {
+		return err
+	}
+
 	// Compare the login password with the user password.
 	err = auth.Compare(u.Password, password)
 	if err != nil {
+		srv.loginFailed(ctx, u.ID, ip)
 		return errors.Wrapf(err, "password compare err")
 	}
 
@@ -196,9 +232,123 @@ func (srv *User) EmailLogin(ctx context.Context, email, password string) (err er
 		return errors.New("user not allow")
 	}
 
+	if err := srv.VerifySecondFactor(ctx, u, otp); err != nil {
+		if err == ErrSecondFactorInvalid {
+			srv.loginFailed(ctx, u.ID, ip)
+		}
+		return err
+	}
+
+	srv.loginSucceeded(ctx, u.ID)
+	srv.rehashPassword(ctx, u, password)
 	return nil
 }
 
+// LdapLogin the user is bound by the configured LDAP, the password is never
+// compared, the other checks are the same as EmailLogin
+func (srv *User) LdapLogin(ctx context.Context, u *model.UserBaseModel, otp, ip string) error {
+	if *u.Status == 0 {
+		return errors.New("user not allow")
+	}
+	return srv.SecondFactorLogin(ctx, u, otp, ip)
+}
+
+// rehashPassword migrates the legacy bcrypt hash or the hash with
+// old parameters to argon2id with current parameters
+func (srv *User) rehashPassword(ctx context.Context, u *model.UserBaseModel, password string) {
+	if !auth.NeedsRehash(u.Password) {
+		return
+	}
+
+	pwd, err := auth.Encrypt(password)
+	if err != nil {
+		log.Warnf("rehash password of user %d err: %v", u.ID, err)
+		return
+	}
+
+	if _, err := srv.userRepo.Update(ctx, u.ID, &model.UserBaseModel{Password: pwd}); err != nil {
+		log.Warnf("rehash password of user %d err: %v", u.ID, err)
+		return
+	}
+	srv.Evict(u.ID)
+}
+
+// OidcLogin verify the id_token issued by oidc provider, user will be
+// provisioned automatically at first login
+func (srv *User) OidcLogin(ctx context.Context, idToken string) (*model.UserBaseModel, error) {
+	provider, err := oidc.Default()
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := provider.Verify(idToken)
+	if err != nil {
+		return nil, err
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+	return srv.externalLogin(ctx, claims.Email, name)
+}
+
+// OauthLogin login with GitHub or GitLab, user will be created at first login
+func (srv *User) OauthLogin(ctx context.Context, provider, code string) (*model.UserBaseModel, error) {
+	p, err := oauth.Get(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := p.Identity(code)
+	if err != nil {
+		return nil, err
+	}
+
+	name := identity.Name
+	if name == "" {
+		name = identity.Login
+	}
+	return srv.externalLogin(ctx, identity.Email, name)
+}
+
+// externalLogin find the user by the verified email from external identity
+// provider, or create it if not exist
+func (srv *User) externalLogin(ctx context.Context, email, name string) (*model.UserBaseModel, error) {
+	at := strings.Index(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return nil, errors.New(fmt.Sprintf("Invalid email %q of the identity provider", email))
+	}
+
+	u, err := srv.GetUserByEmail(ctx, email)
+	if err != nil {
+		if !gorm.IsRecordNotFoundError(err) {
+			return nil, errors.Wrapf(err, "get user info err by email")
+		}
+
+		if name == "" {
+			name = email[:at]
+		}
+
+		// the password will never be used, user login through the identity provider
+		created, err := srv.Create(
+			ctx, email, uuid.NewV4().String(), name, "", 0,
+			_const.BoolToUint64Pointer(true),
+			_const.BoolToUint64Pointer(false),
+		)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Fail to create user %s", email)
+		}
+		return &created, nil
+	}
+
+	if *u.Status == 0 {
+		return nil, errors.New("user not allow")
+	}
+
+	return u, nil
+}
+
 // UpdateUser update user info
 func (srv *User) UpdateUser(ctx context.Context, id uint64, user *model.UserBaseModel) (
 	*model.UserBaseModel, error,
@@ -209,6 +359,7 @@ func (srv *User) UpdateUser(ctx context.Context, id uint64, user *model.UserBase
 		return user, err
 	}
 
+	srv.recordPassword(ctx, id, user.Password)
 	srv.Evict(id)
 	return user, nil
 }
@@ -304,6 +455,11 @@ func (srv *User) BatchListByUserId(ctx context.Context, userIdStart uint64) ([]*
 	return srv.userRepo.ListStartById(ctx, userIdStart, 500)
 }
 
+// ListDeprovisioned the users removed from ldap, disabled since before
+func (srv *User) ListDeprovisioned(ctx context.Context, before time.Time) ([]*model.UserBaseModel, error) {
+	return srv.userRepo.ListDeprovisioned(ctx, before)
+}
+
 func (srv *User) GetUserByEmail(ctx context.Context, email string) (*model.UserBaseModel, error) {
 	userModel, err := srv.userRepo.GetUserByEmail(ctx, email)
 	if err != nil || gorm.IsRecordNotFoundError(err) {
@@ -318,6 +474,38 @@ func (srv *User) UpdateServiceAccountName(ctx context.Context, id uint64, saName
 	return srv.userRepo.UpdateServiceAccountName(ctx, id, saName)
 }
 
+// UpdateClusterQuota
+func (srv *User) UpdateClusterQuota(ctx context.Context, id uint64, quota uint64) error {
+	defer srv.Evict(id)
+	return srv.userRepo.UpdateClusterQuota(ctx, id, quota)
+}
+
+// RevokeTokens invalidate all the tokens issued to the user before, by the
+// next token generation of the user, use to force-logout the user or after
+// the password changed
+func (srv *User) RevokeTokens(ctx context.Context, id uint64) error {
+	defer srv.Evict(id)
+	return srv.userRepo.RevokeTokens(ctx, id)
+}
+
+// IsTokenRevoked check the token context against the revocation of the user
+func (srv *User) IsTokenRevoked(tokenCtx *token.Context) bool {
+	u, err := srv.GetCache(tokenCtx.UserID)
+	if err != nil {
+		return true
+	}
+
+	// impersonation ends once the admin's tokens are revoked as well
+	if tokenCtx.IsImpersonated() {
+		actor, err := srv.GetCache(tokenCtx.ActorID)
+		if err != nil || tokenCtx.IsActorRevoked(actor.TokenGeneration) {
+			return true
+		}
+	}
+
+	return tokenCtx.IsRevoked(u.TokenGeneration)
+}
+
 // Close close all user repo
 func (srv *User) Close() {
 	srv.userRepo.Close()
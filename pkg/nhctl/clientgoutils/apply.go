@@ -45,6 +45,22 @@ type ApplyFlags struct {
 	// apply if set to true
 	DoApply     bool
 	BeforeApply func(string) error
+
+	// Sort reorder the resources before applying, e.g. by dependencies
+	Sort func([]*resource.Info) ([]*resource.Info, error)
+	// BeforeApplyInfo is called before each resource applied, applying is
+	// aborted if it fails, e.g. the dependencies are not ready in time
+	BeforeApplyInfo func(*resource.Info) error
+}
+
+func (a *ApplyFlags) SetSort(fun func([]*resource.Info) ([]*resource.Info, error)) *ApplyFlags {
+	a.Sort = fun
+	return a
+}
+
+func (a *ApplyFlags) SetBeforeApplyInfo(fun func(*resource.Info) error) *ApplyFlags {
+	a.BeforeApplyInfo = fun
+	return a
 }
 
 func (a *ApplyFlags) SetBeforeApply(fun func(string) error) *ApplyFlags {
@@ -0,0 +1,82 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgoutils
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	k8sportforward "k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"nocalhost/pkg/nhctl/log"
+)
+
+// portStream the data stream of a port forwarded, the connection of spdy is
+// closed with it
+type portStream struct {
+	httpstream.Stream
+	conn httpstream.Connection
+}
+
+func (p *portStream) Close() error {
+	_ = p.Stream.Close()
+	return p.conn.Close()
+}
+
+// DialPort connect to the port of pod by the api server, without listening a
+// local port as port-forward does
+func (c *ClientGoUtils) DialPort(pod string, port int) (io.ReadWriteCloser, error) {
+	url := c.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(c.namespace).
+		Name(pod).
+		SubResource("portforward").URL()
+
+	transport, upgrader, err := spdy.RoundTripperFor(c.restConfig)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, "POST", url)
+	conn, protocol, err := dialer.Dial(k8sportforward.PortForwardProtocolV1Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	if protocol != k8sportforward.PortForwardProtocolV1Name {
+		_ = conn.Close()
+		return nil, errors.New(fmt.Sprintf("unsupported protocol of port-forward %s", protocol))
+	}
+
+	headers := http.Header{}
+	headers.Set(corev1.StreamType, corev1.StreamTypeError)
+	headers.Set(corev1.PortHeader, strconv.Itoa(port))
+	headers.Set(corev1.PortForwardRequestIDHeader, "0")
+	errorStream, err := conn.CreateStream(headers)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "")
+	}
+	// the error stream is read only
+	_ = errorStream.Close()
+	go func() {
+		if message, err := ioutil.ReadAll(errorStream); err == nil && len(message) > 0 {
+			log.Warnf("Error of port-forward %s:%d: %s", pod, port, string(message))
+		}
+	}()
+
+	headers.Set(corev1.StreamType, corev1.StreamTypeData)
+	dataStream, err := conn.CreateStream(headers)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.Wrap(err, "")
+	}
+	return &portStream{Stream: dataStream, conn: conn}, nil
+}
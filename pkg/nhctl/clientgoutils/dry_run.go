@@ -0,0 +1,59 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgoutils
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// DryRunApplyResourceInfo applies the resource info the same as ApplyResourceInfo
+// by the server-side dry run, so nothing is persisted. It returns the live object,
+// nil if it does not exist, and the object rendered by the server after applying
+func (c *ClientGoUtils) DryRunApplyResourceInfo(
+	info *resource.Info, af *ApplyFlags) (runtime.Object, runtime.Object, error) {
+	live, err := resource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, nil, errors.WithStack(err)
+		}
+		live = nil
+	}
+
+	if af == nil {
+		af = &ApplyFlags{}
+	}
+	o, err := c.generateCompletedApplyOption(af)
+	if err != nil {
+		return nil, nil, err
+	}
+	o.DryRunStrategy = cmdutil.DryRunServer
+	o.SetObjects([]*resource.Info{info})
+	printer := &dryRunPrinter{}
+	o.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
+		return printer, nil
+	}
+	if err = o.Run(); err != nil {
+		return nil, nil, errors.Wrap(err, "")
+	}
+	return live, printer.obj, nil
+}
+
+// dryRunPrinter keeps the object applied instead of printing it
+type dryRunPrinter struct {
+	obj runtime.Object
+}
+
+func (p *dryRunPrinter) PrintObj(obj runtime.Object, _ io.Writer) error {
+	p.obj = obj.DeepCopyObject()
+	return nil
+}
@@ -60,18 +60,21 @@ func (c *ClientGoUtils) ExecShell(podName string, containerName string, shell, b
 				first = false
 			}
 			err = c.Exec(podName, containerName, []string{"sh", "-c", cmd})
-			if err == nil {
-				return
-			}
-			if e, ok := err.(exec.CodeExitError); ok && e.Code == 0 {
-				//os.Exit(0)
+			if !shouldReconnect(err) {
+				// the shell exited by the user
+				if _, ok := err.(exec.CodeExitError); ok {
+					err = nil
+				}
 				return
 			}
 			time.Sleep(time.Second * 1)
-			_, err = c.ClientSet.CoreV1().Pods(c.namespace).Get(context.Background(), podName, metav1.GetOptions{})
-			if k8serrors.IsNotFound(err) {
+			pod, getErr := c.ClientSet.CoreV1().Pods(c.namespace).Get(
+				context.Background(), podName, metav1.GetOptions{},
+			)
+			if k8serrors.IsNotFound(getErr) || (pod != nil && pod.DeletionTimestamp != nil) {
 				return
 			}
+			_, _ = fmt.Fprintf(os.Stderr, "\r\nConnection to %s lost: %v, reconnecting...\r\n", podName, err)
 		}
 	}()
 
@@ -80,6 +83,37 @@ func (c *ClientGoUtils) ExecShell(podName string, containerName string, shell, b
 	})
 }
 
+// shouldReconnect the shell exited by itself is not reconnected, except the
+// one killed by SIGKILL(137) as the container restarted, the others are the
+// connection to api server dropped
+func shouldReconnect(err error) bool {
+	if err == nil {
+		return false
+	}
+	if e, ok := err.(exec.CodeExitError); ok {
+		return e.Code == 137
+	}
+	return true
+}
+
+// ExecInteractive exec the command in the container with the stdin, stdout and
+// stderr of nhctl, the tty is allocated and resized with the terminal only if
+// stdin is a terminal, so that the output piped is not mangled. The exit code
+// of command is returned as exec.CodeExitError
+func (c *ClientGoUtils) ExecInteractive(podName, containerName string, command []string) error {
+	in, out, errOut := dockerterm.StdStreams()
+	t := term.TTY{In: in, Out: out}
+	t.Raw = t.IsTerminalIn()
+
+	var sizeQueue k8sremotecommand.TerminalSizeQueue
+	if t.Raw {
+		sizeQueue = t.MonitorSize(t.GetSize())
+	}
+	return t.Safe(func() error {
+		return c.ExecStream(podName, containerName, command, t.In, t.Out, errOut, t.Raw, sizeQueue)
+	})
+}
+
 func (c *ClientGoUtils) Exec(podName string, containerName string, command []string) error {
 	f := c.NewFactory()
 
@@ -150,6 +184,31 @@ func (c *ClientGoUtils) Exec(podName string, containerName string, command []str
 	return nil
 }
 
+// ExecStream exec the command in the container with the streams given, the
+// terminal is resized by sizeQueue if tty
+func (c *ClientGoUtils) ExecStream(podName, containerName string, command []string, stdin io.Reader,
+	stdout, stderr io.Writer, tty bool, sizeQueue k8sremotecommand.TerminalSizeQueue) error {
+	req := c.ClientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(c.namespace).
+		SubResource("exec")
+	req.VersionedParams(
+		&corev1.PodExecOptions{
+			Container: containerName,
+			Command:   command,
+			Stdin:     stdin != nil,
+			Stdout:    stdout != nil,
+			Stderr:    stderr != nil && !tty,
+			TTY:       tty,
+		}, scheme.ParameterCodec,
+	)
+	if tty {
+		stderr = nil
+	}
+	return Execute("POST", req.URL(), c.restConfig, stdin, stdout, stderr, tty, sizeQueue)
+}
+
 func Execute(
 	method string, url *url.URL, config *restclient.Config,
 	stdin io.Reader, stdout, stderr io.Writer, tty bool, terminalSizeQueue k8sremotecommand.TerminalSizeQueue,
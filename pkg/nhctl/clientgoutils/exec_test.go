@@ -0,0 +1,28 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgoutils
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/util/exec"
+)
+
+func TestShouldReconnect(t *testing.T) {
+	cases := map[error]bool{
+		nil:                                    false,
+		exec.CodeExitError{Code: 0}:            false,
+		exec.CodeExitError{Code: 130}:          false,
+		exec.CodeExitError{Code: 137}:          true,
+		errors.New("connection reset by peer"): true,
+	}
+	for err, expect := range cases {
+		if actual := shouldReconnect(err); actual != expect {
+			t.Errorf("%v: got %v, expect %v", err, actual, expect)
+		}
+	}
+}
@@ -6,16 +6,22 @@
 package clientgoutils
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// PvcLastUsedAnnotation the time the pvc is mounted by dev start last time
+const PvcLastUsedAnnotation = "nocalhost.dev/last-used"
+
 // quantityStr: 10Gi, 10Mi ...
 // storageClassName: nil to use default storageClassName
 func (c *ClientGoUtils) CreatePVC(
@@ -85,3 +91,40 @@ func (c *ClientGoUtils) GetPvcByName(name string) (*v1.PersistentVolumeClaim, er
 	}
 	return pvc, nil
 }
+
+// AnnotatePVC merges the annotations into the ones of pvc
+func (c *ClientGoUtils) AnnotatePVC(name string, annotations map[string]string) error {
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": annotations}})
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	_, err = c.ClientSet.CoreV1().PersistentVolumeClaims(c.namespace).Patch(
+		c.ctx, name, types.MergePatchType, patch, metav1.PatchOptions{},
+	)
+	return errors.Wrap(err, "")
+}
+
+// PvcLastUsed the time pvc is used last time, the creation time if it is
+// never marked by PvcLastUsedAnnotation
+func PvcLastUsed(pvc *v1.PersistentVolumeClaim) time.Time {
+	if t, err := time.Parse(time.RFC3339, pvc.Annotations[PvcLastUsedAnnotation]); err == nil {
+		return t
+	}
+	return pvc.CreationTimestamp.Time
+}
+
+// PvcsInUse the names of the pvcs mounted by the pods not terminated
+func PvcsInUse(pods []v1.Pod) map[string]bool {
+	inUse := make(map[string]bool)
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil {
+				inUse[volume.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+	return inUse
+}
@@ -9,6 +9,10 @@ import (
 	"encoding/base64"
 	"fmt"
 	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestClientGoUtils_CreatePVC(t *testing.T) {
@@ -61,3 +65,36 @@ func TestClientGoUtils_DeletePVC(t *testing.T) {
 	fmt.Printf("pvc deleted\n")
 
 }
+
+func TestPvcLastUsed(t *testing.T) {
+	created := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}}
+	if !PvcLastUsed(pvc).Equal(created) {
+		t.Errorf("the creation time should be the last used if not marked, got %v", PvcLastUsed(pvc))
+	}
+	pvc.Annotations = map[string]string{PvcLastUsedAnnotation: "2021-07-01T08:00:00Z"}
+	if expect := time.Date(2021, 7, 1, 8, 0, 0, 0, time.UTC); !PvcLastUsed(pvc).Equal(expect) {
+		t.Errorf("got %v, expect %v", PvcLastUsed(pvc), expect)
+	}
+}
+
+func TestPvcsInUse(t *testing.T) {
+	pod := func(phase v1.PodPhase, claims ...string) v1.Pod {
+		p := v1.Pod{Status: v1.PodStatus{Phase: phase}}
+		for _, claim := range claims {
+			p.Spec.Volumes = append(p.Spec.Volumes, v1.Volume{
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+				},
+			})
+		}
+		p.Spec.Volumes = append(p.Spec.Volumes, v1.Volume{
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		})
+		return p
+	}
+	inUse := PvcsInUse([]v1.Pod{pod(v1.PodRunning, "m2", "go"), pod(v1.PodPending, "npm"), pod(v1.PodSucceeded, "pip")})
+	if len(inUse) != 3 || !inUse["m2"] || !inUse["go"] || !inUse["npm"] || inUse["pip"] {
+		t.Errorf("unexpected pvcs in use %v", inUse)
+	}
+}
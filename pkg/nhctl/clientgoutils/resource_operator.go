@@ -136,7 +136,17 @@ func (c *ClientGoUtils) renderManifestAndThen(
 	}
 
 	if flags != nil && flags.DoApply {
+		if flags.Sort != nil {
+			if infos, err = flags.Sort(infos); err != nil {
+				return err
+			}
+		}
 		for _, info := range infos {
+			if flags.BeforeApplyInfo != nil {
+				if err := flags.BeforeApplyInfo(info); err != nil {
+					return err
+				}
+			}
 			if err := doForResourceInfo(c, info); err != nil && !continueOnError {
 				return errors.Wrap(err, "Error while apply resourceInfo")
 			}
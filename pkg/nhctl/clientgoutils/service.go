@@ -0,0 +1,34 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgoutils
+
+import (
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func (c *ClientGoUtils) ListServices() ([]corev1.Service, error) {
+	ops := metav1.ListOptions{}
+	if len(c.labels) > 0 {
+		ops.LabelSelector = labels.Set(c.labels).String()
+	}
+	services, err := c.ClientSet.CoreV1().Services(c.namespace).List(c.ctx, ops)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+	return services.Items, nil
+}
+
+func (c *ClientGoUtils) CreateService(service *corev1.Service) (*corev1.Service, error) {
+	service, err := c.ClientSet.CoreV1().Services(c.namespace).Create(c.ctx, service, metav1.CreateOptions{})
+	return service, errors.Wrap(err, "")
+}
+
+func (c *ClientGoUtils) DeleteService(name string) error {
+	return errors.Wrap(c.ClientSet.CoreV1().Services(c.namespace).Delete(c.ctx, name, metav1.DeleteOptions{}), "")
+}
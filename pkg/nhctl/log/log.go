@@ -30,6 +30,31 @@ var fileEntry *zap.SugaredLogger
 var fields = make(map[string]string, 0)
 var fileLogsConfig zapcore.Core
 
+// logDir the dir of log file initialized, the logs of sessions are under it
+var logDir string
+
+// Rotation of log file by size and age, the rotated ones are compressed
+type Rotation struct {
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+}
+
+// DefaultRotation of nhctl.log
+var DefaultRotation = Rotation{MaxSizeMB: 100, MaxBackups: 60, MaxAgeDays: 120}
+
+func newRollingLog(path string, rotation Rotation) *logWriter {
+	return &logWriter{
+		rollingLog: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    rotation.MaxSizeMB, // megabytes
+			MaxBackups: rotation.MaxBackups,
+			MaxAge:     rotation.MaxAgeDays, //days
+			Compress:   true,
+		},
+	}
+}
+
 func init() {
 	// if log is not be initiated explicitly (use log.Init()),
 	// the default out logger will be used.
@@ -56,6 +81,12 @@ func getDefaultOutLogger(w zapcore.WriteSyncer) *zap.SugaredLogger {
 }
 
 func Init(level zapcore.Level, dir, fileName string) error {
+	return InitWithRotation(level, dir, fileName, DefaultRotation)
+}
+
+// InitWithRotation the log file is rotated by the rotation, such as the one of
+// daemon which keeps running for days
+func InitWithRotation(level zapcore.Level, dir, fileName string, rotation Rotation) error {
 
 	// stdout logger cfg
 	cfg := zap.NewProductionEncoderConfig()
@@ -72,23 +103,9 @@ func Init(level zapcore.Level, dir, fileName string) error {
 	unFormatStderrConfig := zapcore.NewCore(unFormatEncoder, zapcore.AddSync(os.Stderr), level)
 
 	// file logger cfg
-	logPath := filepath.Join(dir, fileName)
-	rolling := &lumberjack.Logger{
-		Filename:   logPath,
-		MaxSize:    100, // megabytes
-		MaxBackups: 60,
-		MaxAge:     120, //days
-		Compress:   true,
-	}
-	rollingLog := &logWriter{rollingLog: rolling}
-	writeSyncer := zapcore.AddSync(rollingLog)
-	encoderConfig := zap.NewProductionEncoderConfig()
-	encoderConfig.EncodeTime = CustomTimeEncoder
-	encoderConfig.EncodeLevel = CustomLevelEncoder
-	encoderConfig.EncodeDuration = CustomDurationEncoder
-
-	encoder := zapcore.NewConsoleEncoder(encoderConfig)
-	fileLogsConfig = zapcore.NewCore(encoder, writeSyncer, zapcore.DebugLevel)
+	logDir = dir
+	writeSyncer := zapcore.AddSync(newRollingLog(filepath.Join(dir, fileName), rotation))
+	fileLogsConfig = zapcore.NewCore(newFileEncoder(), writeSyncer, zapcore.DebugLevel)
 
 	// init
 	initOrReInitStdout(unFormatStdoutConfig)
@@ -97,6 +114,14 @@ func Init(level zapcore.Level, dir, fileName string) error {
 	return nil
 }
 
+func newFileEncoder() zapcore.Encoder {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = CustomTimeEncoder
+	encoderConfig.EncodeLevel = CustomLevelEncoder
+	encoderConfig.EncodeDuration = CustomDurationEncoder
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
 func fullLog() bool {
 	return os.Getenv(_const.EnableFullLogEnvKey) != ""
 }
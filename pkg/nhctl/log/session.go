@@ -0,0 +1,127 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const sessionLogDirName = "sessions"
+
+// SessionRotation of the log file of each workload, smaller than the one of
+// daemon as there are lots of them
+var SessionRotation = Rotation{MaxSizeMB: 10, MaxBackups: 3, MaxAgeDays: 7}
+
+var (
+	sessionEntries = map[string]*zap.SugaredLogger{}
+	sessionLock    sync.Mutex
+)
+
+// SessionLogDir the dir of the log files of sessions, empty if log is not
+// initialized with a file
+func SessionLogDir() string {
+	if logDir == "" {
+		return ""
+	}
+	return filepath.Join(logDir, sessionLogDirName)
+}
+
+// SessionLogPath the log file of workload in the application of namespace
+func SessionLogPath(namespace, app, workload string) string {
+	if logDir == "" {
+		return ""
+	}
+	return filepath.Join(SessionLogDir(), namespace, app, workload+".log")
+}
+
+// SessionLogger logs of the workload, such as its port-forwards and file
+// sync. The logs are written to both the log initialized, with the fields of
+// workload, and the log file of the session
+type SessionLogger struct {
+	fields []interface{}
+	path   string
+}
+
+// Session the logger of workload in the application of namespace
+func Session(namespace, app, workload string) *SessionLogger {
+	return &SessionLogger{
+		fields: []interface{}{"namespace", namespace, "app", app, "workload", workload},
+		path:   SessionLogPath(namespace, app, workload),
+	}
+}
+
+func (s *SessionLogger) sessionEntry() *zap.SugaredLogger {
+	if s.path == "" {
+		return nil
+	}
+	sessionLock.Lock()
+	defer sessionLock.Unlock()
+	entry, ok := sessionEntries[s.path]
+	if !ok {
+		core := zapcore.NewCore(
+			newFileEncoder(), zapcore.AddSync(newRollingLog(s.path, SessionRotation)), zapcore.DebugLevel,
+		)
+		entry = zap.New(core).Sugar()
+		sessionEntries[s.path] = entry
+	}
+	return entry
+}
+
+func (s *SessionLogger) write(level zapcore.Level, fn string, line int, msg string) {
+	if fileEntry != nil {
+		write(fileEntry.With("fn", fn, "line", line).With(s.fields...), level, msg)
+	}
+	if entry := s.sessionEntry(); entry != nil {
+		write(entry.With("fn", fn, "line", line), level, msg)
+	}
+}
+
+func write(entry *zap.SugaredLogger, level zapcore.Level, msg string) {
+	switch level {
+	case zapcore.DebugLevel:
+		entry.Debug(msg)
+	case zapcore.WarnLevel:
+		entry.Warn(msg)
+	case zapcore.ErrorLevel:
+		entry.Error(msg)
+	default:
+		entry.Info(msg)
+	}
+}
+
+func (s *SessionLogger) Debugf(format string, args ...interface{}) {
+	_, fn, line, _ := runtime.Caller(1)
+	s.write(zapcore.DebugLevel, fn, line, fmt.Sprintf(format, args...))
+}
+
+func (s *SessionLogger) Infof(format string, args ...interface{}) {
+	_, fn, line, _ := runtime.Caller(1)
+	s.write(zapcore.InfoLevel, fn, line, fmt.Sprintf(format, args...))
+}
+
+func (s *SessionLogger) Warnf(format string, args ...interface{}) {
+	_, fn, line, _ := runtime.Caller(1)
+	s.write(zapcore.WarnLevel, fn, line, fmt.Sprintf(format, args...))
+}
+
+func (s *SessionLogger) WarnE(err error, message string) {
+	_, fn, line, _ := runtime.Caller(1)
+	s.write(zapcore.WarnLevel, fn, line, fmt.Sprintf("%s, err: %+v", message, err))
+}
+
+func (s *SessionLogger) LogE(err error) {
+	if err == nil {
+		return
+	}
+	_, fn, line, _ := runtime.Caller(1)
+	s.write(zapcore.ErrorLevel, fn, line, fmt.Sprintf("%+v", err))
+}
@@ -0,0 +1,117 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package log
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	tailChunkSize    = 4096
+	tailPollInterval = 500 * time.Millisecond
+)
+
+// Tail writes the last lines of the log file to w, all of them if lines is
+// negative. If follow, the lines appended are written until stopCh is closed,
+// and the file is read from the beginning again after rotated
+func Tail(path string, lines int, follow bool, w io.Writer, stopCh <-chan struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	defer func() { _ = f.Close() }()
+
+	offset, err := lastLinesOffset(f, lines)
+	if err != nil {
+		return err
+	}
+	if offset, err = copyFrom(f, offset, w); err != nil || !follow {
+		return err
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			return nil
+		case <-time.After(tailPollInterval):
+		}
+
+		current, err := f.Stat()
+		if err != nil {
+			return errors.Wrap(err, "")
+		}
+		// rotated by renaming, or truncated
+		if latest, err := os.Stat(path); err == nil && (!os.SameFile(current, latest) || latest.Size() < offset) {
+			reopened, err := os.Open(path)
+			if err != nil {
+				continue
+			}
+			_ = f.Close()
+			f, offset = reopened, 0
+		}
+		if offset, err = copyFrom(f, offset, w); err != nil {
+			return err
+		}
+	}
+}
+
+func copyFrom(f *os.File, offset int64, w io.Writer) (int64, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return offset, errors.Wrap(err, "")
+	}
+	n, err := io.Copy(w, f)
+	return offset + n, errors.Wrap(err, "")
+}
+
+// lastLinesOffset the offset of the last lines of file, the file is read
+// backwards by chunks
+func lastLinesOffset(f *os.File, lines int) (int64, error) {
+	if lines < 0 {
+		return 0, nil
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, errors.Wrap(err, "")
+	}
+	end := stat.Size()
+	if end == 0 {
+		return 0, nil
+	}
+	buf := make([]byte, tailChunkSize)
+	// the line break at the end of file does not start a line
+	found := 0
+	if _, err = f.ReadAt(buf[:1], end-1); err != nil {
+		return 0, errors.Wrap(err, "")
+	}
+	if buf[0] == '\n' {
+		found = -1
+	}
+	for end > 0 {
+		size := int64(len(buf))
+		if end < size {
+			size = end
+		}
+		start := end - size
+		if _, err = f.ReadAt(buf[:size], start); err != nil && err != io.EOF {
+			return 0, errors.Wrap(err, "")
+		}
+		chunk := buf[:size]
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' {
+				continue
+			}
+			if found++; found == lines {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
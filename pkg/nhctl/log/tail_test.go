@@ -0,0 +1,125 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLastLinesOffset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	long := strings.Repeat("x", tailChunkSize+10)
+	for _, c := range []struct {
+		content string
+		lines   int
+		expect  string
+	}{
+		{"a\nb\nc\n", 2, "b\nc\n"},
+		{"a\nb\nc", 2, "b\nc"},
+		{"a\nb\nc\n", 0, ""},
+		{"a\nb\nc\n", 5, "a\nb\nc\n"},
+		{"a\nb\nc\n", -1, "a\nb\nc\n"},
+		{"", 3, ""},
+		{long + "\n" + long + "\nc\n", 2, long + "\nc\n"},
+	} {
+		path := filepath.Join(dir, "test.log")
+		if err = ioutil.WriteFile(path, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		buf := &bytes.Buffer{}
+		if err = Tail(path, c.lines, false, buf, nil); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != c.expect {
+			t.Errorf("%d lines of %q: got %q, expect %q", c.lines, c.content, buf.String(), c.expect)
+		}
+	}
+}
+
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+func TestTailFollowRotated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "daemon.log")
+	if err = ioutil.WriteFile(path, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &syncBuffer{}
+	stopCh := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- Tail(path, 1, true, buf, stopCh) }()
+
+	time.Sleep(tailPollInterval)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("appended\n")
+	_ = f.Close()
+	time.Sleep(2 * tailPollInterval)
+
+	// rotated as lumberjack does
+	if err = os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(path, []byte("rotated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(3 * tailPollInterval)
+	close(stopCh)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "old\nappended\nrotated\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSessionLogPath(t *testing.T) {
+	defer func(dir string) { logDir = dir }(logDir)
+
+	logDir = ""
+	if SessionLogPath("dev", "bookinfo", "details") != "" {
+		t.Error("no session log if log is not initialized")
+	}
+	logDir = filepath.Join("home", "logs")
+	expect := filepath.Join("home", "logs", "sessions", "dev", "bookinfo", "details.log")
+	if got := SessionLogPath("dev", "bookinfo", "details"); got != expect {
+		t.Errorf("got %s, expect %s", got, expect)
+	}
+}
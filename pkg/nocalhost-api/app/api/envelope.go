@@ -0,0 +1,171 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package api
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/utils"
+)
+
+const (
+	envelopeKey = "nocalhost:envelope_v2"
+	// ResponseCodeKey the errno code responded, for the middlewares after the
+	// handlers, e.g. audit
+	ResponseCodeKey = "nocalhost:response_code"
+	// ResponseMessageKey the message of error responded
+	ResponseMessageKey = "nocalhost:response_message"
+
+	DefaultPageSize = 20
+	MaxPageSize     = 200
+)
+
+// DataResponse the envelope of /v2, pagination is responded for lists
+type DataResponse struct {
+	Data       interface{} `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination of lists in /v2, page starts from 1
+type Pagination struct {
+	Page     int `json:"page"`
+	PageSize int `json:"page_size"`
+	Total    int `json:"total"`
+}
+
+// ErrorResponse the envelope of errors in /v2, responded with the http
+// status of the error
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	RequestId string `json:"request_id,omitempty"`
+}
+
+// Envelope switch the responses of SendResponse to the envelope of /v2, it
+// must be used before the middlewares of authorization, so that their errors
+// are in the envelope too
+func Envelope(c *gin.Context) {
+	c.Set(envelopeKey, true)
+	c.Next()
+}
+
+// PageQuery the page and page size requested, the invalid ones are replaced
+// by defaults
+func PageQuery(c *gin.Context) (int, int) {
+	page := cast.ToInt(c.Query("page"))
+	if page <= 0 {
+		page = 1
+	}
+	size := cast.ToInt(c.Query("page_size"))
+	if size <= 0 || size > MaxPageSize {
+		size = DefaultPageSize
+	}
+	return page, size
+}
+
+// SendList respond the page of list paginated by the handler
+func SendList(c *gin.Context, data interface{}, page, pageSize, total int) {
+	if !c.GetBool(envelopeKey) {
+		SendResponse(c, nil, data)
+		return
+	}
+	c.Set(ResponseCodeKey, errno.OK.Code)
+	c.JSON(
+		http.StatusOK, DataResponse{
+			Data:       data,
+			Pagination: &Pagination{Page: page, PageSize: pageSize, Total: total},
+		},
+	)
+}
+
+func sendEnvelope(c *gin.Context, err error, data interface{}) {
+	code, message := errno.DecodeErr(err)
+	if code != errno.OK.Code {
+		c.JSON(
+			HttpStatus(err), ErrorResponse{
+				Error: ErrorDetail{Code: code, Message: message, RequestId: c.GetString(utils.XRequestID)},
+			},
+		)
+		return
+	}
+
+	// lists of the handlers shared with /v1 are paginated here
+	if value := reflect.ValueOf(data); value.Kind() == reflect.Slice {
+		page, size := PageQuery(c)
+		total := value.Len()
+		start, end := (page-1)*size, page*size
+		if start > total {
+			start = total
+		}
+		if end > total {
+			end = total
+		}
+		c.JSON(
+			http.StatusOK, DataResponse{
+				Data:       value.Slice(start, end).Interface(),
+				Pagination: &Pagination{Page: page, PageSize: size, Total: total},
+			},
+		)
+		return
+	}
+	c.JSON(http.StatusOK, DataResponse{Data: data})
+}
+
+var httpStatus = map[int]int{
+	errno.InternalServerError.Code:        http.StatusInternalServerError,
+	errno.InternalServerTimeoutError.Code: http.StatusGatewayTimeout,
+	errno.ErrTooManyRequests.Code:         http.StatusTooManyRequests,
+	errno.RouterNotFound.Code:             http.StatusNotFound,
+	errno.ErrLoginRequired.Code:           http.StatusUnauthorized,
+	errno.ErrTokenInvalid.Code:            http.StatusUnauthorized,
+	errno.ErrLostPermissionFlag.Code:      http.StatusUnauthorized,
+	errno.ErrPermissionDenied.Code:        http.StatusForbidden,
+	errno.ErrAccessTokenScope.Code:        http.StatusForbidden,
+	errno.ErrUserNotAllow.Code:            http.StatusForbidden,
+	errno.ErrLoginLocked.Code:             http.StatusTooManyRequests,
+
+	errno.ErrUserNotFound.Code:               http.StatusNotFound,
+	errno.ErrSessionNotFound.Code:            http.StatusNotFound,
+	errno.ErrClusterNotFound.Code:            http.StatusNotFound,
+	errno.ErrClusterAgentNotFound.Code:       http.StatusNotFound,
+	errno.ErrClusterUserNotFound.Code:        http.StatusNotFound,
+	errno.ErrDevSpaceSaNotFound.Code:         http.StatusNotFound,
+	errno.ErrDevSpaceTemplateNotFound.Code:   http.StatusNotFound,
+	errno.ErrGitCredentialNotFound.Code:      http.StatusNotFound,
+	errno.ErrRegistryCredentialNotFound.Code: http.StatusNotFound,
+	errno.ErrRoleNotFound.Code:               http.StatusNotFound,
+	errno.ErrTeamNotFound.Code:               http.StatusNotFound,
+	errno.ErrBulkDeployNotFound.Code:         http.StatusNotFound,
+	errno.ErrAppInstallNotFound.Code:         http.StatusNotFound,
+}
+
+// HttpStatus the http status of error in /v2, the errors not coded by errno
+// are internal errors, and the others are bad requests if not specified
+func HttpStatus(err error) int {
+	code, _ := errno.DecodeErr(err)
+	return CodeStatus(code)
+}
+
+// CodeStatus the http status of errno code, for the responses of /v1 which
+// are always responded with http.StatusOK
+func CodeStatus(code int) int {
+	if code == errno.OK.Code {
+		return http.StatusOK
+	}
+	if status, ok := httpStatus[code]; ok {
+		return status
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,69 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+)
+
+func respond(envelope bool, query string, err error, data interface{}) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/v2/clusters"+query, nil)
+	if envelope {
+		c.Set(envelopeKey, true)
+	}
+	SendResponse(c, err, data)
+	return w
+}
+
+func TestSendResponseEnvelope(t *testing.T) {
+	for _, c := range []struct {
+		envelope bool
+		query    string
+		err      error
+		data     interface{}
+		status   int
+		body     string
+	}{
+		{false, "", errno.ErrClusterNotFound, nil, 200, `{"code":30107,"message":"Cluster has not found","data":null}`},
+		{true, "", errno.ErrClusterNotFound, nil, 404, `{"error":{"code":30107,"message":"Cluster has not found"}}`},
+		{true, "", errno.ErrPermissionDenied, nil, 403, `{"error":{"code":20104,"message":"permission denied"}}`},
+		{true, "", errno.ErrBind, nil, 400, `{"error":{"code":10002,"message":"Request fail, Please check request parameters"}}`},
+		{true, "", errors.New("db is down"), nil, 500, `{"error":{"code":10001,"message":"db is down"}}`},
+		{true, "", nil, map[string]int{"id": 1}, 200, `{"data":{"id":1}}`},
+		{
+			true, "?page=2&page_size=2", nil, []int{1, 2, 3, 4, 5}, 200,
+			`{"data":[3,4],"pagination":{"page":2,"page_size":2,"total":5}}`,
+		},
+		{
+			true, "?page=9&page_size=1000", nil, []int{1, 2, 3}, 200,
+			`{"data":[],"pagination":{"page":9,"page_size":20,"total":3}}`,
+		},
+	} {
+		w := respond(c.envelope, c.query, c.err, c.data)
+		if w.Code != c.status || w.Body.String() != c.body {
+			t.Errorf("unexpected response %d %s, expected %d %s", w.Code, w.Body.String(), c.status, c.body)
+		}
+	}
+}
+
+func TestHttpStatus(t *testing.T) {
+	if status := HttpStatus(errno.ErrLoginRequired); status != http.StatusUnauthorized {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if status := HttpStatus(errno.ErrTeamNotFound); status != http.StatusNotFound {
+		t.Fatalf("unexpected status %d", status)
+	}
+}
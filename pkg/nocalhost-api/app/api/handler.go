@@ -17,6 +17,8 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/health"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
 )
 
 // Response api
@@ -29,6 +31,14 @@ type Response struct {
 // SendResponse
 func SendResponse(c *gin.Context, err error, data interface{}) {
 	code, message := errno.DecodeErr(err)
+	c.Set(ResponseCodeKey, code)
+	if code != errno.OK.Code {
+		c.Set(ResponseMessageKey, message)
+	}
+	if c.GetBool(envelopeKey) {
+		sendEnvelope(c, err, data)
+		return
+	}
 
 	// always return http.StatusOK
 	c.JSON(
@@ -40,6 +50,19 @@ func SendResponse(c *gin.Context, err error, data interface{}) {
 	)
 }
 
+// SendStatusResponse respond err with its http status in /v1 as well as /v2,
+// for the errors the clients have to tell by status, e.g. 429 of rate limiting
+func SendStatusResponse(c *gin.Context, err error) {
+	if c.GetBool(envelopeKey) {
+		SendResponse(c, err, nil)
+		return
+	}
+	code, message := errno.DecodeErr(err)
+	c.Set(ResponseCodeKey, code)
+	c.Set(ResponseMessageKey, message)
+	c.JSON(HttpStatus(err), Response{Code: code, Message: message})
+}
+
 // GetUserID
 func GetUserID(c *gin.Context) uint64 {
 	if c == nil {
@@ -81,10 +104,52 @@ type healthCheckResponse struct {
 }
 
 // HealthCheck will return OK if the underlying BoltDB is healthy. At least healthy enough for demoing purposes.
+// The server shutting down is reported unavailable, so that no more requests are routed to it
 func HealthCheck(c *gin.Context) {
+	if shutdown.IsStopping() {
+		c.JSON(http.StatusServiceUnavailable, healthCheckResponse{Status: "DOWN", Hostname: getHostname()})
+		return
+	}
 	c.JSON(http.StatusOK, healthCheckResponse{Status: "UP", Hostname: getHostname()})
 }
 
+// healthReport of the dependencies
+type healthReport struct {
+	health.Report
+	Hostname string `json:"hostname"`
+}
+
+// Livez reports the process is alive, the dependencies are not checked, so
+// that the replica is not restarted for the failures of them
+func Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, healthCheckResponse{Status: health.StatusUp, Hostname: getHostname()})
+}
+
+// Readyz reports the status of the dependencies, unavailable if any of them
+// is down or the server is shutting down, so that no more requests are
+// routed to the replica
+func Readyz(c *gin.Context) {
+	report := healthReport{Report: health.Run(c), Hostname: getHostname()}
+	if shutdown.IsStopping() {
+		report.Status = health.StatusDown
+	}
+	sendHealth(c, report)
+}
+
+// Healthz reports the status of the dependencies, also for the startup
+// probe, the shutdown is not reported
+func Healthz(c *gin.Context) {
+	sendHealth(c, healthReport{Report: health.Run(c), Hostname: getHostname()})
+}
+
+func sendHealth(c *gin.Context, report healthReport) {
+	if report.Status != health.StatusUp {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
 // global handle 500 error
 func Recover(c *gin.Context) {
 	defer func() {
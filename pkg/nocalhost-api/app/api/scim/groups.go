@@ -0,0 +1,285 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/team"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// e.g. members[value eq "2"]
+var memberPathRegexp = regexp.MustCompile(`^members\[value eq "([^"]*)"\]$`)
+
+// Group the scim group resource, backed by nocalhost team
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	Id          string   `json:"id"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// Member
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+func memberIds(members []Member) []uint64 {
+	result := make([]uint64, 0, len(members))
+	for _, m := range members {
+		if id := cast.ToUint64(m.Value); id > 0 {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func toScimGroup(c *gin.Context, t *model.TeamModel) Group {
+	sid := cast.ToString(t.ID)
+	members, _ := service.Svc.TeamSvc.MemberIds(c, t.ID)
+
+	result := Group{
+		Schemas:     []string{schemaGroup},
+		Id:          sid,
+		DisplayName: t.Name,
+		Members:     make([]Member, 0, len(members)),
+		Meta:        &Meta{ResourceType: "Group", Location: location(c, "Groups", sid)},
+	}
+	for _, m := range members {
+		result.Members = append(result.Members, Member{Value: cast.ToString(m)})
+	}
+	return result
+}
+
+// ListGroups
+func ListGroups(c *gin.Context) {
+	attr, value, ok := parseFilter(c.Query("filter"))
+	if !ok {
+		sendError(c, http.StatusBadRequest, "Unsupported filter")
+		return
+	}
+
+	teams, err := service.Svc.TeamSvc.List(c)
+	if err != nil {
+		log.Warnf("scim list groups err: %v", err)
+		sendError(c, http.StatusInternalServerError, "Fail to list groups")
+		return
+	}
+
+	resources := make([]interface{}, 0, len(teams))
+	for _, t := range teams {
+		switch {
+		case attr == "":
+		case equalPath(attr, "displayName"):
+			if !strings.EqualFold(t.Name, value) {
+				continue
+			}
+		case equalPath(attr, "id"):
+			if cast.ToString(t.ID) != value {
+				continue
+			}
+		default:
+			continue
+		}
+		resources = append(resources, toScimGroup(c, t))
+	}
+
+	send(c, http.StatusOK, paginate(c, resources))
+}
+
+// GetGroup
+func GetGroup(c *gin.Context) {
+	t, err := service.Svc.TeamSvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Group not found")
+		return
+	}
+
+	send(c, http.StatusOK, toScimGroup(c, t))
+}
+
+// CreateGroup
+func CreateGroup(c *gin.Context) {
+	var req Group
+	if err := c.ShouldBindJSON(&req); err != nil || req.DisplayName == "" {
+		sendError(c, http.StatusBadRequest, "displayName is required")
+		return
+	}
+
+	t, err := service.Svc.TeamSvc.Create(c, req.DisplayName, "Provisioned by SCIM", 0)
+	if err != nil {
+		log.Warnf("scim create group err: %v", err)
+		sendError(c, http.StatusConflict, "Fail to create group")
+		return
+	}
+
+	if err := team.JoinTeam(c, t.ID, memberIds(req.Members)); err != nil {
+		log.Warnf("scim add group members err: %v", err)
+	}
+
+	send(c, http.StatusCreated, toScimGroup(c, &t))
+}
+
+// ReplaceGroup replace the name and all members of the group
+func ReplaceGroup(c *gin.Context) {
+	var req Group
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t, err := service.Svc.TeamSvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Group not found")
+		return
+	}
+
+	if req.DisplayName != "" && req.DisplayName != t.Name {
+		if err := service.Svc.TeamSvc.Update(c, t.ID, req.DisplayName, t.Description); err != nil {
+			sendError(c, http.StatusConflict, "Fail to rename group")
+			return
+		}
+		t.Name = req.DisplayName
+	}
+
+	syncMembers(c, t.ID, memberIds(req.Members))
+	send(c, http.StatusOK, toScimGroup(c, t))
+}
+
+// PatchGroup support add, remove and replace members, and rename the group
+func PatchGroup(c *gin.Context) {
+	var req PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	t, err := service.Svc.TeamSvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		sendError(c, http.StatusNotFound, "Group not found")
+		return
+	}
+
+	for _, op := range req.Operations {
+		path := strings.TrimSpace(op.Path)
+		var members []Member
+
+		switch {
+		case equalPath(path, "displayName") || (path == "" && strings.EqualFold(op.Op, "replace")):
+			name := ""
+			if path == "" {
+				var value struct {
+					DisplayName string `json:"displayName"`
+				}
+				_ = json.Unmarshal(op.Value, &value)
+				name = value.DisplayName
+			} else {
+				_ = json.Unmarshal(op.Value, &name)
+			}
+			if name != "" {
+				if err := service.Svc.TeamSvc.Update(c, t.ID, name, t.Description); err != nil {
+					sendError(c, http.StatusConflict, "Fail to rename group")
+					return
+				}
+				t.Name = name
+			}
+		case equalPath(path, "members"):
+			_ = json.Unmarshal(op.Value, &members)
+			err = patchMembers(c, t.ID, op.Op, memberIds(members))
+		case memberPathRegexp.MatchString(path) && strings.EqualFold(op.Op, "remove"):
+			id := cast.ToUint64(memberPathRegexp.FindStringSubmatch(path)[1])
+			err = team.LeaveTeam(c, t.ID, []uint64{id})
+		}
+
+		if err != nil {
+			log.Warnf("scim patch group members err: %v", err)
+			sendError(c, http.StatusInternalServerError, "Fail to update group members")
+			return
+		}
+	}
+
+	send(c, http.StatusOK, toScimGroup(c, t))
+}
+
+// DeleteGroup
+func DeleteGroup(c *gin.Context) {
+	teamId := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.TeamSvc.Get(c, teamId); err != nil {
+		sendError(c, http.StatusNotFound, "Group not found")
+		return
+	}
+
+	members, _ := service.Svc.TeamSvc.MemberIds(c, teamId)
+	if err := team.LeaveTeam(c, teamId, members); err != nil {
+		log.Warnf("scim remove group members err: %v", err)
+	}
+
+	if err := service.Svc.TeamSvc.Delete(c, teamId); err != nil {
+		sendError(c, http.StatusInternalServerError, "Fail to delete group")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func patchMembers(c *gin.Context, teamId uint64, op string, ids []uint64) error {
+	switch strings.ToLower(op) {
+	case "add":
+		return team.JoinTeam(c, teamId, ids)
+	case "remove":
+		// remove all members if no value
+		if len(ids) == 0 {
+			ids, _ = service.Svc.TeamSvc.MemberIds(c, teamId)
+		}
+		return team.LeaveTeam(c, teamId, ids)
+	case "replace":
+		syncMembers(c, teamId, ids)
+	}
+	return nil
+}
+
+// syncMembers make the members of team exactly the same as ids
+func syncMembers(c *gin.Context, teamId uint64, ids []uint64) {
+	current, _ := service.Svc.TeamSvc.MemberIds(c, teamId)
+
+	expect := map[uint64]bool{}
+	for _, id := range ids {
+		expect[id] = true
+	}
+
+	var remove []uint64
+	for _, id := range current {
+		if !expect[id] {
+			remove = append(remove, id)
+		}
+		delete(expect, id)
+	}
+
+	var add []uint64
+	for id := range expect {
+		add = append(add, id)
+	}
+
+	if err := team.LeaveTeam(c, teamId, remove); err != nil {
+		log.Warnf("scim remove group members err: %v", err)
+	}
+	if len(add) > 0 {
+		if err := team.JoinTeam(c, teamId, add); err != nil {
+			log.Warnf("scim add group members err: %v", err)
+		}
+	}
+}
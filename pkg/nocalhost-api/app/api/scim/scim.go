@@ -0,0 +1,176 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+// Package scim implements the subset of SCIM 2.0 (RFC 7643/7644) required by
+// Okta and Azure AD to provision users and groups, groups are nocalhost teams
+package scim
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"nocalhost/pkg/nocalhost-api/pkg/token"
+)
+
+const (
+	// SCIM_TOKEN the bearer token of the identity provider, scim is disabled if empty
+	SCIM_TOKEN = "scim.token"
+
+	contentType = "application/scim+json"
+
+	schemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	defaultCount = 100
+)
+
+var filterRegexp = regexp.MustCompile(`^\s*([\w.]+)\s+eq\s+"([^"]*)"\s*$`)
+
+// AuthMiddleware check the bearer token configured for scim
+func AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expect := viper.GetString(SCIM_TOKEN)
+		if expect == "" {
+			sendError(c, http.StatusNotFound, "SCIM provisioning is not enabled")
+			c.Abort()
+			return
+		}
+
+		actual, _ := token.BearerFromRequest(c)
+		if subtle.ConstantTimeCompare([]byte(actual), []byte(expect)) != 1 {
+			sendError(c, http.StatusUnauthorized, "Invalid SCIM token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListResponse
+type ListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// PatchRequest
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+// PatchOperation
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Meta
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+}
+
+// ServiceProviderConfig tell the identity provider which features are supported
+func ServiceProviderConfig(c *gin.Context) {
+	supported := func(b bool) map[string]bool { return map[string]bool{"supported": b} }
+	send(
+		c, http.StatusOK, map[string]interface{}{
+			"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+			"patch":          supported(true),
+			"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+			"filter":         map[string]interface{}{"supported": true, "maxResults": defaultCount},
+			"changePassword": supported(false),
+			"sort":           supported(false),
+			"etag":           supported(false),
+			"authenticationSchemes": []map[string]string{
+				{"type": "oauthbearertoken", "name": "OAuth Bearer Token", "description": "Configured by scim.token"},
+			},
+		},
+	)
+}
+
+type errorResponse struct {
+	Schemas []string `json:"schemas"`
+	Status  string   `json:"status"`
+	Detail  string   `json:"detail"`
+}
+
+func send(c *gin.Context, status int, data interface{}) {
+	c.Header("Content-Type", contentType)
+	c.JSON(status, data)
+}
+
+func sendError(c *gin.Context, status int, detail string) {
+	send(c, status, errorResponse{Schemas: []string{schemaError}, Status: strconv.Itoa(status), Detail: detail})
+}
+
+// parseFilter only the 'attribute eq "value"' filter is supported
+func parseFilter(filter string) (attr, value string, ok bool) {
+	if filter == "" {
+		return "", "", true
+	}
+
+	m := filterRegexp.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// paginate slice the resources by startIndex and count, startIndex is 1-based
+func paginate(c *gin.Context, resources []interface{}) ListResponse {
+	start, _ := strconv.Atoi(c.DefaultQuery("startIndex", "1"))
+	if start < 1 {
+		start = 1
+	}
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(defaultCount)))
+	if err != nil || count < 0 {
+		count = defaultCount
+	}
+
+	total := len(resources)
+	from := start - 1
+	if from > total {
+		from = total
+	}
+	to := from + count
+	if to > total {
+		to = total
+	}
+
+	return ListResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: total,
+		StartIndex:   start,
+		ItemsPerPage: to - from,
+		Resources:    resources[from:to],
+	}
+}
+
+func location(c *gin.Context, resource, id string) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/scim/v2/" + resource + "/" + id
+}
+
+func equalPath(path, expect string) bool {
+	return strings.EqualFold(strings.TrimSpace(path), expect)
+}
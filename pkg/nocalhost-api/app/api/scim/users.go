@@ -0,0 +1,268 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/cast"
+
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// User the scim user resource, userName is the email of nocalhost user
+type User struct {
+	Schemas     []string `json:"schemas"`
+	Id          string   `json:"id"`
+	ExternalId  string   `json:"externalId,omitempty"`
+	UserName    string   `json:"userName"`
+	Name        *Name    `json:"name,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Emails      []Email  `json:"emails,omitempty"`
+	Active      *bool    `json:"active,omitempty"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// Name
+type Name struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// Email
+type Email struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+func (u *User) email() string {
+	if strings.Contains(u.UserName, "@") {
+		return u.UserName
+	}
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return u.UserName
+}
+
+func (u *User) name() string {
+	switch {
+	case u.DisplayName != "":
+		return u.DisplayName
+	case u.Name != nil && u.Name.Formatted != "":
+		return u.Name.Formatted
+	case u.Name != nil && (u.Name.GivenName != "" || u.Name.FamilyName != ""):
+		return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+	}
+	return ""
+}
+
+func toScimUser(c *gin.Context, id uint64, email, name string, status uint64) User {
+	active := status == 1
+	sid := cast.ToString(id)
+	return User{
+		Schemas:     []string{schemaUser},
+		Id:          sid,
+		UserName:    email,
+		Name:        &Name{Formatted: name},
+		DisplayName: name,
+		Emails:      []Email{{Value: email, Primary: true}},
+		Active:      &active,
+		Meta:        &Meta{ResourceType: "User", Location: location(c, "Users", sid)},
+	}
+}
+
+// ListUsers
+func ListUsers(c *gin.Context) {
+	attr, value, ok := parseFilter(c.Query("filter"))
+	if !ok {
+		sendError(c, http.StatusBadRequest, "Unsupported filter")
+		return
+	}
+
+	list, _, err := service.Svc.UserSvc.GetUserList(c, model.UserListQuery{})
+	if err != nil {
+		log.Warnf("scim list users err: %v", err)
+		sendError(c, http.StatusInternalServerError, "Fail to list users")
+		return
+	}
+
+	resources := make([]interface{}, 0, len(list))
+	for _, u := range list {
+		switch {
+		case attr == "":
+		case equalPath(attr, "userName") || equalPath(attr, "emails.value"):
+			if !strings.EqualFold(u.Email, value) {
+				continue
+			}
+		case equalPath(attr, "id"):
+			if cast.ToString(u.ID) != value {
+				continue
+			}
+		default:
+			continue
+		}
+		resources = append(resources, toScimUser(c, u.ID, u.Email, u.Name, u.Status))
+	}
+
+	send(c, http.StatusOK, paginate(c, resources))
+}
+
+// GetUser
+func GetUser(c *gin.Context) {
+	u, err := service.Svc.UserSvc.GetUserByID(c, cast.ToUint64(c.Param("id")))
+	if err != nil || u.ID == 0 {
+		sendError(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	send(c, http.StatusOK, toScimUser(c, u.ID, u.Email, u.Name, cast.ToUint64(u.Status)))
+}
+
+// CreateUser
+func CreateUser(c *gin.Context) {
+	var req User
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	email := req.email()
+	if _, err := service.Svc.UserSvc.GetUserByEmail(c, email); err == nil {
+		sendError(c, http.StatusConflict, "User already exists")
+		return
+	} else if !gorm.IsRecordNotFoundError(err) {
+		sendError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	name := req.name()
+	if name == "" && strings.Contains(email, "@") {
+		name = email[:strings.Index(email, "@")]
+	}
+
+	// the password will never be used, scim user login through sso
+	u, err := service.Svc.UserSvc.Create(
+		c, email, uuid.NewV4().String(), name, "", 0,
+		_const.BoolToUint64Pointer(req.Active == nil || *req.Active),
+		_const.BoolToUint64Pointer(false),
+	)
+	if err != nil {
+		log.Warnf("scim create user err: %v", err)
+		sendError(c, http.StatusBadRequest, "Fail to create user")
+		return
+	}
+
+	send(c, http.StatusCreated, toScimUser(c, u.ID, u.Email, u.Name, cast.ToUint64(u.Status)))
+}
+
+// ReplaceUser
+func ReplaceUser(c *gin.Context) {
+	var req User
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	update := model.UserBaseModel{Email: req.email(), Name: req.name()}
+	if req.Active != nil {
+		update.Status = _const.BoolToUint64Pointer(*req.Active)
+	}
+	updateUser(c, cast.ToUint64(c.Param("id")), &update)
+}
+
+// PatchUser only active, userName and the name attributes can be modified
+func PatchUser(c *gin.Context) {
+	var req PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	update := model.UserBaseModel{}
+	for _, op := range req.Operations {
+		if !strings.EqualFold(op.Op, "replace") && !strings.EqualFold(op.Op, "add") {
+			continue
+		}
+
+		// Azure AD send the attributes as value without path
+		values := map[string]json.RawMessage{}
+		if op.Path == "" {
+			_ = json.Unmarshal(op.Value, &values)
+		} else {
+			values[op.Path] = op.Value
+		}
+
+		for path, value := range values {
+			switch {
+			case equalPath(path, "active"):
+				var active interface{}
+				_ = json.Unmarshal(value, &active)
+				update.Status = _const.BoolToUint64Pointer(cast.ToBool(active))
+			case equalPath(path, "userName"):
+				_ = json.Unmarshal(value, &update.Email)
+			case equalPath(path, "displayName") || equalPath(path, "name.formatted"):
+				_ = json.Unmarshal(value, &update.Name)
+			}
+		}
+	}
+
+	updateUser(c, cast.ToUint64(c.Param("id")), &update)
+}
+
+// DeleteUser users are deactivated rather than deleted, so that
+// the dev spaces and applications of them are reserved
+func DeleteUser(c *gin.Context) {
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.UserSvc.UpdateUser(
+		c, id, &model.UserBaseModel{Status: _const.BoolToUint64Pointer(false)},
+	); err != nil {
+		sendError(c, http.StatusNotFound, "User not found")
+		return
+	}
+	revokeIfDeactivated(c, id, _const.BoolToUint64Pointer(false))
+
+	c.Status(http.StatusNoContent)
+}
+
+func updateUser(c *gin.Context, id uint64, update *model.UserBaseModel) {
+	if _, err := service.Svc.UserSvc.UpdateUser(c, id, update); err != nil {
+		log.Warnf("scim update user err: %v", err)
+		sendError(c, http.StatusNotFound, "User not found")
+		return
+	}
+	service.Svc.UserSvc.Evict(id)
+	revokeIfDeactivated(c, id, update.Status)
+
+	GetUser(c)
+}
+
+func revokeIfDeactivated(c *gin.Context, id uint64, status *uint64) {
+	if status == nil || *status != 0 {
+		return
+	}
+
+	if err := service.Svc.UserSvc.RevokeTokens(c, id); err != nil {
+		log.Warnf("scim revoke tokens of user %d err: %v", id, err)
+	}
+	if err := service.Svc.TokenSvc.RevokeAll(c, id); err != nil {
+		log.Warnf("scim revoke access tokens of user %d err: %v", id, err)
+	}
+}
@@ -0,0 +1,21 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package access_token
+
+import "nocalhost/internal/nocalhost-api/model"
+
+// CreateRequest
+type CreateRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Scopes    []string `json:"scopes"`
+	ExpiresIn int64    `json:"expires_in" example:"days to expire, never expire if zero"`
+}
+
+// CreateResponse the plain token is only visible in this response
+type CreateResponse struct {
+	*model.AccessTokenModel
+	Token string `json:"token"`
+}
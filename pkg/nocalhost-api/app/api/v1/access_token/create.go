@@ -0,0 +1,58 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package access_token
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Create Create personal access token
+// @Summary Create personal access token
+// @Description Create a named, scoped and expiring personal access token for CI and scripting
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param createRequest body access_token.CreateRequest true "The access token info"
+// @Success 200 {object} access_token.CreateResponse
+// @Router /v1/me/tokens [post]
+func Create(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrLoginRequired, nil)
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("create access token bind param err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresIn > 0 {
+		t := time.Now().AddDate(0, 0, int(req.ExpiresIn))
+		expiresAt = &t
+	}
+
+	plain, result, err := service.Svc.TokenSvc.Create(c, userId, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		log.Warnf("create access token err: %v", err)
+		api.SendResponse(c, errno.ErrAccessTokenCreate, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, CreateResponse{AccessTokenModel: &result, Token: plain})
+}
@@ -0,0 +1,48 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package access_token
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Revoke Revoke personal access token
+// @Summary Revoke personal access token
+// @Description Revoke personal access token of current user
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Access token ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/me/tokens/{id} [delete]
+func Revoke(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrLoginRequired, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if id == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if err := service.Svc.TokenSvc.Revoke(c, userId, id); err != nil {
+		log.Warnf("revoke access token err: %v", err)
+		api.SendResponse(c, errno.ErrAccessTokenRevoke, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
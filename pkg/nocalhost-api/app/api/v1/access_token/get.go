@@ -0,0 +1,41 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package access_token
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// List List personal access tokens
+// @Summary List personal access tokens
+// @Description List personal access tokens of current user, the token itself is invisible
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} model.AccessTokenModel
+// @Router /v1/me/tokens [get]
+func List(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrLoginRequired, nil)
+		return
+	}
+
+	result, err := service.Svc.TokenSvc.ListByUserId(c, userId)
+	if err != nil {
+		log.Warnf("list access token err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
@@ -53,6 +53,22 @@ type ApplicationJsonContext struct {
 	ApplicationSourceDir   []string `json:"resource_dir" validate:"required"`
 	NocalhostRawConfig     string   `json:"nocalhost_config_raw"`
 	NocalhostConfigPath    string   `json:"nocalhost_config_path"`
+	// CredentialId the git credential of private repo, 0 if public
+	CredentialId uint64 `json:"credential_id"`
+	// HelmChartVersion the version of chart from helm repo, the latest if empty
+	HelmChartVersion string `json:"helm_chart_version"`
+	// HelmChartDigest pin the digest of chart from OCI registry, e.g. sha256:...
+	HelmChartDigest string `json:"helm_chart_digest"`
+	// KustomizeOverlays the overlays of kustomize application, one of them is
+	// selected when installing in dev space
+	KustomizeOverlays []KustomizeOverlay `json:"kustomize_overlays"`
+}
+
+type KustomizeOverlay struct {
+	// Name e.g. dev, staging or pr-123
+	Name string `json:"name"`
+	// Path relative to the repo, e.g. overlays/dev
+	Path string `json:"path"`
 }
 
 type ApplicationListQuery struct {
@@ -0,0 +1,78 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package applications
+
+import (
+	"encoding/base64"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nhctl/config_validate"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+type ValidateConfigRequest struct {
+	// Config the content of .nocalhost/config.yaml, base64 encoded
+	Config string `json:"config" binding:"required" example:"base64encode(config.yaml)"`
+}
+
+// GetConfigSchema Get the JSON schema of nocalhost config
+// @Summary Get the JSON schema of nocalhost config
+// @Description Get the JSON schema (draft-07) of .nocalhost/config.yaml, which can be used by editors for completion and validation
+// @Tags Application
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} config_validate.Schema
+// @Router /v1/nocalhost/config_schema [get]
+func GetConfigSchema(c *gin.Context) {
+	api.SendResponse(c, nil, config_validate.AppConfigSchema())
+}
+
+// ValidateConfig Validate nocalhost config against the schema
+// @Summary Validate nocalhost config against the schema
+// @Description Validate .nocalhost/config.yaml against the schema, returns the errors with their lines, e.g. line 14: unknown field syncDirs
+// @Tags Application
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param config body applications.ValidateConfigRequest true "The config to validate"
+// @Success 200 {array} config_validate.SchemaError
+// @Router /v1/nocalhost/config/validate [post]
+func ValidateConfig(c *gin.Context) {
+	var req ValidateConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind validate config params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	schemaErrors, err := validateNocalhostConfig(req.Config)
+	api.SendResponse(c, err, schemaErrors)
+}
+
+// validateNocalhostConfig validate the base64 encoded config, the errors of
+// fields are returned with ErrNocalhostConfigSchema, the placeholders of env
+// are not rendered so that they are accepted by any scalar field
+func validateNocalhostConfig(raw string) (config_validate.SchemaErrors, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	content, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errno.ErrNocalhostConfigEncoding
+	}
+
+	err = config_validate.ValidateAppConfig(content)
+	if err == nil {
+		return nil, nil
+	}
+	if schemaErrors, ok := err.(config_validate.SchemaErrors); ok {
+		return schemaErrors, errno.ErrNocalhostConfigSchema
+	}
+	return nil, &errno.Errno{Code: errno.ErrNocalhostConfigSchema.Code, Message: err.Error()}
+}
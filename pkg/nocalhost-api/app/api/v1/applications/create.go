@@ -68,6 +68,22 @@ func Create(c *gin.Context) {
 		api.SendResponse(c, &errno.Errno{Code: 40110, Message: errs[0]}, nil)
 		return
 	}
+	if err := validateCredential(c, &applicationContext); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if err := validateHelmChart(&applicationContext); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if err := validateKustomizeOverlays(&applicationContext); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if schemaErrors, err := validateNocalhostConfig(applicationContext.NocalhostRawConfig); err != nil {
+		api.SendResponse(c, err, schemaErrors)
+		return
+	}
 	existApplication, _ := service.Svc.ApplicationSvc.GetByName(c, applicationContext.ApplicationName)
 	if existApplication.ID != 0 {
 		api.SendResponse(c, errno.ErrApplicationNameExist, nil)
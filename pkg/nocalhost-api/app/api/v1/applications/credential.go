@@ -0,0 +1,101 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package applications
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+)
+
+// GitCredential the credential pulling the resources of application, the
+// secret is the private key of ssh or the token of https
+type GitCredential struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Secret   string `json:"secret"`
+}
+
+// GetGitCredential Get git credential of application
+// @Summary Get git credential of application
+// @Description Get the credential pulling the resources of application from private git repo, for users permitted to the application, null if the repo is public
+// @Tags Application
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Application ID"
+// @Success 200 {object} applications.GitCredential
+// @Router /v1/application/{id}/git_credential [get]
+func GetGitCredential(c *gin.Context) {
+	app, err := service.Svc.ApplicationSvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrPermissionApplication, nil)
+		return
+	}
+
+	if !HasApplicationPermission(c, &app) {
+		api.SendResponse(c, errno.ErrPermissionApplication, nil)
+		return
+	}
+
+	var applicationContext ApplicationJsonContext
+	if err := json.Unmarshal([]byte(app.Context), &applicationContext); err != nil {
+		api.SendResponse(c, errno.ErrApplicationJsonContext, nil)
+		return
+	}
+	if applicationContext.CredentialId == 0 {
+		api.SendResponse(c, nil, nil)
+		return
+	}
+
+	credential, err := service.Svc.GitCredentialSvc.Get(c, applicationContext.CredentialId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrGitCredentialNotFound, nil)
+		return
+	}
+	api.SendResponse(c, nil, GitCredential{Type: credential.Type, Username: credential.Username, Secret: credential.Secret})
+}
+
+// HasApplicationPermission the application is public, or owned by or shared
+// to the login user
+func HasApplicationPermission(c *gin.Context, app *model.ApplicationModel) bool {
+	loginUser, err := ginbase.LoginUser(c)
+	if err != nil {
+		return false
+	}
+	if ginbase.IsAdmin(c) || app.Public == 1 || app.UserId == loginUser {
+		return true
+	}
+	au, err := service.Svc.ApplicationUserSvc.GetByApplicationIdAndUserId(c, app.ID, loginUser)
+	return err == nil && au.ID > 0
+}
+
+// validateCredential the credential referred by application context must
+// exist, it is used by git source, or by OCI registry if it is https
+func validateCredential(ctx context.Context, applicationContext *ApplicationJsonContext) error {
+	if applicationContext.CredentialId == 0 {
+		return nil
+	}
+	isOci := applicationContext.ApplicationSource == SourceHelmRepo && IsOciUrl(applicationContext.ApplicationURL)
+	if applicationContext.ApplicationSource != SourceGit && !isOci {
+		return errno.ErrGitCredentialSource
+	}
+	credential, err := service.Svc.GitCredentialSvc.Get(ctx, applicationContext.CredentialId)
+	if err != nil {
+		return errno.ErrGitCredentialNotFound
+	}
+	if isOci && credential.Type != model.GitCredentialHttps {
+		return errno.ErrGitCredentialSource
+	}
+	return nil
+}
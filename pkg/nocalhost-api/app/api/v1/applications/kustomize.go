@@ -0,0 +1,42 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package applications
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+)
+
+var overlayNameRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// validateKustomizeOverlays the overlays are named like dev, staging or
+// pr-123, and the paths must stay in the repo
+func validateKustomizeOverlays(applicationContext *ApplicationJsonContext) error {
+	if len(applicationContext.KustomizeOverlays) == 0 {
+		return nil
+	}
+	if applicationContext.ApplicationInstallType != ITKustomize &&
+		applicationContext.ApplicationInstallType != ITKustomizeLocal {
+		return errno.ErrKustomizeOverlay
+	}
+
+	names := map[string]bool{}
+	for _, overlay := range applicationContext.KustomizeOverlays {
+		if !overlayNameRegexp.MatchString(overlay.Name) || names[overlay.Name] {
+			return errno.ErrKustomizeOverlay
+		}
+		names[overlay.Name] = true
+
+		clean := path.Clean(overlay.Path)
+		if overlay.Path == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			return errno.ErrKustomizeOverlay
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package applications
+
+import (
+	"regexp"
+	"strings"
+
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+)
+
+var chartDigestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// IsOciUrl the chart of helm repo is published to OCI registry, such as Harbor or ECR
+func IsOciUrl(url string) bool {
+	return strings.HasPrefix(url, "oci://")
+}
+
+// validateHelmChart the digest can be pinned only for the chart from OCI registry
+func validateHelmChart(applicationContext *ApplicationJsonContext) error {
+	if applicationContext.HelmChartDigest == "" {
+		return nil
+	}
+	if applicationContext.ApplicationSource != SourceHelmRepo || !IsOciUrl(applicationContext.ApplicationURL) {
+		return errno.ErrHelmChartDigestSource
+	}
+	if !chartDigestRegexp.MatchString(applicationContext.HelmChartDigest) {
+		return errno.ErrHelmChartDigest
+	}
+	return nil
+}
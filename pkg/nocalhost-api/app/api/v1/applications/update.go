@@ -6,6 +6,8 @@
 package applications
 
 import (
+	"encoding/json"
+
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
 	"nocalhost/pkg/nocalhost-api/app/api"
@@ -66,6 +68,28 @@ func Update(c *gin.Context) {
 		return
 	}
 
+	var applicationContext ApplicationJsonContext
+	if err := json.Unmarshal([]byte(req.Context), &applicationContext); err != nil {
+		api.SendResponse(c, errno.ErrApplicationJsonContext, nil)
+		return
+	}
+	if err := validateCredential(c, &applicationContext); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if err := validateHelmChart(&applicationContext); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if err := validateKustomizeOverlays(&applicationContext); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if schemaErrors, err := validateNocalhostConfig(applicationContext.NocalhostRawConfig); err != nil {
+		api.SendResponse(c, err, schemaErrors)
+		return
+	}
+
 	// adapt earlier version
 	if req.Public == nil {
 		u := uint8(1)
@@ -0,0 +1,71 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package audit_log
+
+import (
+	"time"
+
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 200
+
+	// max rows can be exported at a time
+	maxExport = 10000
+)
+
+// ListRequest filters the audit logs, from and to are in RFC3339
+type ListRequest struct {
+	UserId   uint64 `form:"user_id"`
+	ActorId  uint64 `form:"actor_id"`
+	Action   string `form:"action" example:"DELETE /v1/users/:id"`
+	Resource string `form:"resource" example:"/v1/cluster"`
+	Ip       string `form:"ip"`
+	From     string `form:"from" example:"2021-01-01T00:00:00Z"`
+	To       string `form:"to" example:"2021-02-01T00:00:00Z"`
+	Failed   bool   `form:"failed"`
+	Page     int    `form:"page"`
+	Size     int    `form:"size"`
+	Format   string `form:"format" example:"csv or json"`
+}
+
+// ListResponse
+type ListResponse struct {
+	TotalCount uint64                 `json:"total_count"`
+	Items      []*model.AuditLogModel `json:"items"`
+}
+
+func (r ListRequest) query() (model.AuditLogQuery, error) {
+	q := model.AuditLogQuery{
+		UserId:   r.UserId,
+		ActorId:  r.ActorId,
+		Action:   r.Action,
+		Resource: r.Resource,
+		Ip:       r.Ip,
+		Failed:   r.Failed,
+		Page:     r.Page,
+		Size:     r.Size,
+	}
+
+	if r.From != "" {
+		from, err := time.Parse(time.RFC3339, r.From)
+		if err != nil {
+			return q, err
+		}
+		q.From = &from
+	}
+
+	if r.To != "" {
+		to, err := time.Parse(time.RFC3339, r.To)
+		if err != nil {
+			return q, err
+		}
+		q.To = &to
+	}
+	return q, nil
+}
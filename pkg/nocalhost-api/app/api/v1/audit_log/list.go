@@ -0,0 +1,131 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package audit_log
+
+import (
+	"encoding/csv"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// List List audit logs
+// @Summary List audit logs
+// @Description Admin list the audit logs of api mutations, newest first
+// @Tags AuditLogs
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param query query audit_log.ListRequest false "filters"
+// @Success 200 {object} audit_log.ListResponse
+// @Router /v1/audit_logs [get]
+func List(c *gin.Context) {
+	// the white list of permission middleware matches the query string too
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	query, err := req.query()
+	if err != nil {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.Size <= 0 || query.Size > maxPageSize {
+		query.Size = defaultPageSize
+	}
+
+	result, total, err := service.Svc.AuditLogSvc.List(c, query)
+	if err != nil {
+		log.Warnf("list audit logs err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, ListResponse{TotalCount: total, Items: result})
+}
+
+// Export Export audit logs
+// @Summary Export audit logs
+// @Description Admin export the audit logs matches the filters as csv or json, at most 10000 rows
+// @Tags AuditLogs
+// @Produce  text/csv
+// @param Authorization header string true "Authorization"
+// @Param query query audit_log.ListRequest false "filters"
+// @Success 200 {string} string "audit logs"
+// @Router /v1/audit_logs/export [get]
+func Export(c *gin.Context) {
+	// the white list of permission middleware matches the query string too
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	query, err := req.query()
+	if err != nil {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+	query.Page, query.Size = 1, maxExport
+
+	result, _, err := service.Svc.AuditLogSvc.List(c, query)
+	if err != nil {
+		log.Warnf("export audit logs err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	filename := fmt.Sprintf("audit-logs-%s", time.Now().Format("20060102150405"))
+	if req.Format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.json", filename))
+		c.JSON(200, result)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+	c.Header("Content-Type", "text/csv")
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(
+		[]string{
+			"id", "created_at", "user_id", "actor_id", "action", "path",
+			"resource_id", "ip", "user_agent", "request_id", "code", "payload", "diff",
+		},
+	)
+	for _, l := range result {
+		_ = w.Write(
+			[]string{
+				cast.ToString(l.ID), l.CreatedAt.Format(time.RFC3339), cast.ToString(l.UserId),
+				cast.ToString(l.ActorId), l.Action, l.Path, l.ResourceId, l.Ip, l.UserAgent,
+				l.RequestId, cast.ToString(l.Code), l.Payload, l.Diff,
+			},
+		)
+	}
+	w.Flush()
+}
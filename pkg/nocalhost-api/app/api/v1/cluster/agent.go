@@ -0,0 +1,262 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	corev1 "k8s.io/api/core/v1"
+
+	"nocalhost/internal/nocalhost-api/global"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/agent"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+)
+
+// CreateAgent Create the registration of cluster agent
+// @Summary Create the registration of cluster agent
+// @Description Create the registration token and the manifest of agent for the cluster nocalhost-api can not reach, the cluster is added when the agent connects
+// @Tags Cluster
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param createAgent body cluster.CreateAgentRequest true "The agent info"
+// @Success 200 {object} cluster.CreateAgentResponse "token is only returned once"
+// @Router /v1/cluster/agents [post]
+func CreateAgent(c *gin.Context) {
+	var req CreateAgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("create cluster agent bind params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if errn := checkClusterQuota(c); errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	token, result, err := service.Svc.ClusterAgentSvc.Create(
+		c, c.GetUint64("userId"), req.Name, req.StorageClass, req.ExtraApiServer,
+	)
+	if err != nil {
+		log.Warnf("create cluster agent err: %v", err)
+		api.SendResponse(c, errno.ErrClusterAgentCreate, nil)
+		return
+	}
+
+	manifest, err := agent.Manifest(requestServer(c), token)
+	if err != nil {
+		log.Warnf("render manifest of cluster agent err: %v", err)
+		api.SendResponse(c, errno.ErrClusterAgentCreate, nil)
+		return
+	}
+
+	api.SendResponse(
+		c, nil, CreateAgentResponse{
+			Agent:    result,
+			Token:    token,
+			Manifest: manifest,
+		},
+	)
+}
+
+// ListAgents Get the cluster agent list
+// @Summary Get the cluster agent list
+// @Description Get the cluster agent list, normal user can only see the agents created by himself
+// @Tags Cluster
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} model.ClusterAgentModel
+// @Router /v1/cluster/agents [get]
+func ListAgents(c *gin.Context) {
+	userId := c.GetUint64("userId")
+	if ginbase.IsAdmin(c) {
+		userId = 0
+	}
+
+	result, err := service.Svc.ClusterAgentSvc.List(c, userId)
+	if err != nil {
+		log.Warnf("list cluster agent err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// DeleteAgent Delete the cluster agent
+// @Summary Delete the cluster agent
+// @Description Delete the cluster agent and close its tunnel, the cluster registered by the agent is kept
+// @Tags Cluster
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "Agent ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/cluster/agents/{id} [delete]
+func DeleteAgent(c *gin.Context) {
+	id := cast.ToUint64(c.Param("id"))
+	result, err := service.Svc.ClusterAgentSvc.Get(c, id)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterAgentNotFound, nil)
+		return
+	}
+
+	if !ginbase.IsAdmin(c) && result.UserId != c.GetUint64("userId") {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	audit.SetBefore(c, result)
+	if err := service.Svc.ClusterAgentSvc.Delete(c, id); err != nil {
+		log.Warnf("delete cluster agent err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, nil)
+}
+
+// ConnectAgent Connect the tunnel of cluster agent
+// @Summary Connect the tunnel of cluster agent
+// @Description Upgrade the connection dialed by agent as the tunnel to the api server of cluster, the cluster is added at the first connection
+// @Tags Cluster
+// @param Authorization header string true "Bearer registration token"
+// @Success 101 {string} string "Switching Protocols"
+// @Router /v1/agent/connect [get]
+func ConnectAgent(c *gin.Context) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	result, err := service.Svc.ClusterAgentSvc.Authenticate(c, token)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterAgentToken, nil)
+		return
+	}
+
+	ca, err := base64.StdEncoding.DecodeString(c.GetHeader(agent.HeaderAgentCa))
+	if err != nil || len(ca) == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+	saToken, err := base64.StdEncoding.DecodeString(c.GetHeader(agent.HeaderAgentToken))
+	if err != nil || len(saToken) == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if _, err := agent.Upgrade(c.Writer, result.ID); err != nil {
+		log.Warnf("connect tunnel of cluster agent %d err: %v", result.ID, err)
+		api.SendResponse(c, errno.ErrClusterAgentConnect, nil)
+		return
+	}
+	c.Abort()
+
+	log.Infof("Tunnel of cluster agent %d connected", result.ID)
+	go registerAgentCluster(result, ca, saToken)
+}
+
+// registerAgentCluster add the cluster through the tunnel at the first
+// connection, or update the kubeconfig if the token of agent changed
+func registerAgentCluster(a *model.ClusterAgentModel, ca, token []byte) {
+	ctx := context.TODO()
+	kubeConfig, err := agentKubeConfig(a, ca, token)
+	if err != nil {
+		log.Warnf("assemble kubeconfig of cluster agent %d err: %v", a.ID, err)
+		_ = service.Svc.ClusterAgentSvc.Connected(ctx, a.ID, 0, err.Error())
+		return
+	}
+
+	if a.ClusterId > 0 {
+		if cluster, err := service.Svc.ClusterSvc.Get(ctx, a.ClusterId); err == nil {
+			if cluster.KubeConfig != kubeConfig {
+				Remove(cluster.KubeConfig)
+				update := service.CheckKubeConfig(cluster.ID, []byte(kubeConfig))
+				update["kubeconfig"] = kubeConfig
+				if _, err := service.Svc.ClusterSvc.Update(ctx, update, cluster.ID); err != nil {
+					log.Warnf("update kubeconfig of cluster %d err: %v", cluster.ID, err)
+				}
+				Add(kubeConfig)
+			}
+			_ = service.Svc.ClusterAgentSvc.Connected(ctx, a.ID, 0, "")
+			return
+		}
+		// the cluster is deleted, register again
+	}
+
+	goClient, err := clientgo.NewAdminGoClient([]byte(kubeConfig))
+	if err != nil {
+		log.Warnf("access cluster through agent %d err: %v", a.ID, err)
+		_ = service.Svc.ClusterAgentSvc.Connected(ctx, a.ID, 0, err.Error())
+		return
+	}
+
+	clusterInfo, err, _ := setupcluster.NewSetUpCluster(goClient).InitCluster("")
+	if err != nil {
+		log.Warnf("init cluster through agent %d err: %v", a.ID, err)
+		_ = service.Svc.ClusterAgentSvc.Connected(ctx, a.ID, 0, err.Error())
+		return
+	}
+
+	prePullImages, _ := service.Svc.PrePullSvc.GetAll(ctx)
+	if _, err := goClient.DeployPrePullImages(prePullImages, ""); err != nil {
+		log.Warnf("deploy pre pull images err: %v", err)
+	}
+
+	cluster, err := service.Svc.ClusterSvc.Create(
+		ctx, a.Name, kubeConfig, a.StorageClass, agent.Server(a.ID), a.ExtraApiServer, clusterInfo,
+		nil, nil, a.UserId,
+	)
+	if err != nil {
+		log.Warnf("create cluster of agent %d err: %v", a.ID, err)
+		_ = service.Svc.ClusterAgentSvc.Connected(ctx, a.ID, 0, err.Error())
+		return
+	}
+
+	if _, err := service.Svc.ClusterSvc.Update(
+		ctx, service.CheckKubeConfig(cluster.ID, []byte(kubeConfig)), cluster.ID,
+	); err != nil {
+		log.Warnf("update kubeconfig status of cluster err: %v", err)
+	}
+	_ = service.Svc.ClusterAgentSvc.Connected(ctx, a.ID, cluster.ID, "")
+	log.Infof("Cluster %d is registered by agent %d", cluster.ID, a.ID)
+}
+
+// agentKubeConfig use the service account of agent to access the api
+// server through the tunnel
+func agentKubeConfig(a *model.ClusterAgentModel, ca, token []byte) (string, error) {
+	secret := &corev1.Secret{
+		Data: map[string][]byte{
+			global.NocalhostDevServiceAccountSecretCaKey: ca,
+			global.NocalhostDevServiceAccountTokenKey:    token,
+		},
+	}
+
+	reader := setupcluster.NewDevKubeConfigReader(secret, agent.Server(a.ID), global.NocalhostSystemNamespace)
+	kubeConfig, err, _ := reader.GetCA().GetToken().AssembleDevKubeConfig().ToYamlString()
+	return kubeConfig, err
+}
+
+// requestServer the url of nocalhost-api requested by the user
+func requestServer(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -5,13 +5,20 @@
 
 package cluster
 
-import "time"
+import (
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/pkg/nocalhost-api/pkg/usage"
+	"time"
+)
 
 type CreateClusterRequest struct {
 	Name           string `json:"name" binding:"required"`
 	KubeConfig     string `json:"kubeconfig" binding:"required" example:"base64encode(value)"`
 	StorageClass   string `json:"storage_class"`
 	ExtraApiServer string `json:"extra_api_server" binding:"omitempty,url"`
+
+	Labels      model.Labels `json:"labels"`
+	Annotations model.Labels `json:"annotations"`
 }
 
 type KubeConfig struct {
@@ -38,6 +45,39 @@ type StorageClassResponse struct {
 
 type UpdateClusterRequest struct {
 	StorageClass string `json:"storage_class"`
+
+	// labels and annotations are replaced if not null
+	Labels      model.Labels `json:"labels"`
+	Annotations model.Labels `json:"annotations"`
+}
+
+type RotateKubeConfigRequest struct {
+	KubeConfig string `json:"kubeconfig" binding:"required" example:"base64encode(value)"`
+}
+
+type CreateAgentRequest struct {
+	Name           string `json:"name" binding:"required"`
+	StorageClass   string `json:"storage_class"`
+	ExtraApiServer string `json:"extra_api_server" binding:"omitempty,url"`
+}
+
+type CreateAgentResponse struct {
+	Agent    model.ClusterAgentModel `json:"agent"`
+	Token    string                  `json:"token"`
+	Manifest string                  `json:"manifest"`
+}
+
+type ClusterUsageResponse struct {
+	usage.Usage
+	MetricsAvailable bool            `json:"metrics_available"`
+	Nodes            []usage.Node    `json:"nodes"`
+	Namespaces       []DevSpaceUsage `json:"namespaces"`
+}
+
+type DevSpaceUsage struct {
+	usage.Namespace
+	DevSpaceId uint64 `json:"dev_space_id,omitempty"`
+	SpaceName  string `json:"space_name,omitempty"`
 }
 
 type ClusterDetailResponse struct {
@@ -6,11 +6,15 @@
 package cluster
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
 	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/clusterlabel"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"strings"
 )
 
 // HasPrivilegeToSomeCluster
@@ -33,3 +37,37 @@ func HasPrivilegeToSomeCluster(c *gin.Context, clusterId uint64) (*model.Cluster
 	}
 	return nil, errno.ErrPermissionDenied
 }
+
+func validateLabels(labels, annotations model.Labels) error {
+	if err := clusterlabel.Validate(labels); err != nil {
+		return err
+	}
+	// annotations only require a qualified key
+	for k := range annotations {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return errors.New(strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// filterByLabel filters the cluster list by the query label_selector,
+// the syntax is the same as kubectl, such as env=staging,region in (eu,us)
+func filterByLabel(c *gin.Context, list []*model.ClusterList) ([]*model.ClusterList, error) {
+	selector := c.Query("label_selector")
+	if selector == "" {
+		return list, nil
+	}
+
+	result := make([]*model.ClusterList, 0, len(list))
+	for _, cluster := range list {
+		matched, err := clusterlabel.Match(selector, cluster.Labels)
+		if err != nil {
+			return nil, errno.ErrClusterSelector
+		}
+		if matched {
+			result = append(result, cluster)
+		}
+	}
+	return result, nil
+}
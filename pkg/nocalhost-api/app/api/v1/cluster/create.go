@@ -6,13 +6,15 @@
 package cluster
 
 import (
-	"encoding/base64"
 	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/quota"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
 	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
 	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+	"nocalhost/pkg/nocalhost-api/pkg/vault"
 
 	"gopkg.in/yaml.v3"
 
@@ -36,14 +38,27 @@ func Create(c *gin.Context) {
 		api.SendResponse(c, errno.ErrBind, nil)
 		return
 	}
+
+	if err := validateLabels(req.Labels, req.Annotations); err != nil {
+		log.Warnf("createCluster labels err: %v", err)
+		api.SendResponse(c, errno.ErrClusterLabelInvalid, nil)
+		return
+	}
+
+	if errn := checkClusterQuota(c); errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
 	// decode kubeconfig
-	DecKubeconfig, err := base64.StdEncoding.DecodeString(req.KubeConfig)
+	DecKubeconfig, storedKubeconfig, err := decodeKubeConfig(req.KubeConfig)
 	if err != nil {
+		log.Warnf("createCluster kubeconfig err: %v", err)
 		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
 		return
 	}
 
-	goClient, err := clientgo.NewAdminGoClient(DecKubeconfig)
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, DecKubeconfig)
 
 	// get client go and check if is admin Kubeconfig
 	if err != nil {
@@ -100,11 +115,13 @@ func Create(c *gin.Context) {
 	cluster, err := service.Svc.ClusterSvc.Create(
 		c,
 		req.Name,
-		string(DecKubeconfig),
+		storedKubeconfig,
 		req.StorageClass,
 		t.Clusters[0].Cluster.Server,
 		req.ExtraApiServer,
 		clusterInfo,
+		req.Labels,
+		req.Annotations,
 		userId.(uint64),
 	)
 	if err != nil {
@@ -113,5 +130,36 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	// the kubeconfig refreshed is not stored over the reference of vault
+	update := service.CheckKubeConfig(cluster.ID, DecKubeconfig)
+	if vault.IsReference(storedKubeconfig) {
+		delete(update, "kubeconfig")
+	}
+	if _, err := service.Svc.ClusterSvc.Update(c, update, cluster.ID); err != nil {
+		log.Warnf("update kubeconfig status of cluster err: %v", err)
+	}
+
 	api.SendResponse(c, nil, cluster)
 }
+
+// checkClusterQuota admin is not limited by the quota
+func checkClusterQuota(c *gin.Context) error {
+	if ginbase.IsAdmin(c) {
+		return nil
+	}
+
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		return errno.ErrPermissionDenied
+	}
+
+	switch err := service.Svc.QuotaSvc.CheckCluster(c, userId); err {
+	case nil:
+		return nil
+	case quota.ErrClusterQuotaExceed:
+		return errno.ErrClusterQuotaExceed
+	default:
+		log.Warnf("check cluster quota err: %v", err)
+		return errno.InternalServerError
+	}
+}
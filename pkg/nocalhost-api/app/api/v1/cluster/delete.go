@@ -45,7 +45,7 @@ func Delete(c *gin.Context) {
 
 	deleteMeshManager(cluster.KubeConfig)
 
-	goClient, err := clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
 	if err != nil {
 		log.Warnf("Cannot connect to this kubernetes cluster, err %s", err.Error())
 	}
@@ -42,10 +42,16 @@ type ClusterSafeList struct {
 // @Accept  json
 // @Produce  json
 // @param Authorization header string true "Authorization"
+// @Param label_selector query string false "filter by labels, such as env=staging,region in (eu,us)"
 // @Success 200 {object} model.ClusterListVo "{"code":0,"message":"OK","data":model.ClusterListVo}"
 // @Router /v1/cluster [get]
 func GetList(c *gin.Context) {
 	result, _ := service.Svc.ClusterSvc.GetList(c)
+	result, err := filterByLabel(c, result)
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
 	tempResult := make([]*model.ClusterList, 0, 0)
 	userId := c.GetUint64("userId")
 	// normal user can only see clusters they created, or devSpace's cluster
@@ -102,10 +108,16 @@ func GetList(c *gin.Context) {
 // @Accept  json
 // @Produce  json
 // @param Authorization header string true "Authorization"
+// @Param label_selector query string false "filter by labels, such as env=staging,region in (eu,us)"
 // @Success 200 {object} model.ClusterList "{"code":0,"message":"OK","data":model.ClusterList}"
 // @Router /v2/dev_space/cluster [get]
 func GetDevSpaceClusterList(c *gin.Context) {
 	result, _ := service.Svc.ClusterSvc.GetList(c)
+	result, err := filterByLabel(c, result)
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
 	tempResult := make([]*model.ClusterList, 0, 0)
 	userId := c.GetUint64("userId")
 	// normal user can only see clusters they created, or devSpace's cluster
@@ -317,7 +329,7 @@ func GetStorageClass(c *gin.Context) {
 	}
 
 	// new client go
-	clientGo, err := clientgo.NewAdminGoClient(kubeConfig)
+	clientGo, err := clientgo.NewAdminGoClientWithContext(c, kubeConfig)
 
 	// get client go and check if is admin Kubeconfig
 	if err != nil {
@@ -409,7 +421,7 @@ func GenNamespace(c *gin.Context) {
 		return
 	}
 	cluster.GetKubeConfig()
-	client, err := clientgo.NewAdminGoClient([]byte(cluster.GetKubeConfig()))
+	client, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.GetKubeConfig()))
 	if err != nil {
 		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
 		return
@@ -0,0 +1,114 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster
+
+import (
+	"encoding/base64"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/vault"
+)
+
+// decodeKubeConfig decode the kubeconfig in base64, it is resolved if it
+// refers to the secret in vault, e.g. vault:secret/clusters/prod#kubeconfig,
+// the reference is stored instead of the content
+func decodeKubeConfig(encoded string) (kubeConfig []byte, stored string, err error) {
+	if kubeConfig, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+		return nil, "", err
+	}
+	stored = string(kubeConfig)
+	if vault.IsReference(stored) {
+		resolved, err := vault.Resolve(stored)
+		if err != nil {
+			return nil, "", err
+		}
+		kubeConfig = []byte(resolved)
+	}
+	return kubeConfig, stored, nil
+}
+
+// RotateKubeConfig Rotate the kubeconfig of cluster
+// @Summary Rotate the kubeconfig of cluster
+// @Description Replace the credential of cluster, the server of the new kubeconfig must be the same
+// @Tags Cluster
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "Cluster ID"
+// @Param rotateKubeConfig body cluster.RotateKubeConfigRequest true "The new kubeconfig"
+// @Success 200 {object} model.ClusterModel "include kubeconfig"
+// @Router /v1/cluster/{id}/kubeconfig [put]
+func RotateKubeConfig(c *gin.Context) {
+	var req RotateKubeConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("rotate kubeconfig bind params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	clusterId := cast.ToUint64(c.Param("id"))
+	cluster, errn := HasPrivilegeToSomeCluster(c, clusterId)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	decKubeConfig, stored, err := decodeKubeConfig(req.KubeConfig)
+	if err != nil {
+		log.Warnf("rotate kubeconfig of cluster %d err: %v", clusterId, err)
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	if _, err := clientgo.NewAdminGoClientWithContext(c, decKubeConfig); err != nil {
+		switch err.(type) {
+		case *errno.Errno:
+			api.SendResponse(c, err, nil)
+		default:
+			api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		}
+		return
+	}
+
+	t := KubeConfig{}
+	if err := yaml.Unmarshal(decKubeConfig, &t); err != nil || len(t.Clusters) != 1 {
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	// only the credential is rotated, the cluster it points to can not be changed
+	if t.Clusters[0].Cluster.Server != cluster.Server {
+		api.SendResponse(c, errno.ErrClusterServerDiffer, nil)
+		return
+	}
+
+	update := service.CheckKubeConfig(clusterId, decKubeConfig)
+	if update["kubeconfig"] == nil || vault.IsReference(stored) {
+		update["kubeconfig"] = stored
+	}
+
+	audit.SetBefore(c, cluster)
+	result, err := service.Svc.ClusterSvc.Update(c, update, clusterId)
+	if err != nil {
+		log.Warnf("rotate kubeconfig of cluster %d err: %v", clusterId, err)
+		api.SendResponse(c, errno.ErrUpdateCluster, nil)
+		return
+	}
+
+	// restart the resource collecting with the new kubeconfig
+	Remove(cluster.KubeConfig)
+	Add(result.KubeConfig)
+	api.SendResponse(c, nil, result)
+}
@@ -10,8 +10,10 @@ import (
 	"github.com/spf13/cast"
 	"nocalhost/internal/nocalhost-api/service"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
 	"nocalhost/pkg/nocalhost-api/app/router/middleware"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
 )
 
 // @Summary Update cluster
@@ -30,10 +32,21 @@ func Update(c *gin.Context) {
 		api.SendResponse(c, errno.ErrBind, nil)
 		return
 	}
+	if err := validateLabels(req.Labels, req.Annotations); err != nil {
+		log.Warnf("update cluster labels err: %v", err)
+		api.SendResponse(c, errno.ErrClusterLabelInvalid, nil)
+		return
+	}
 	clusterId := cast.ToUint64(c.Param("id"))
 	updateCol := map[string]interface{}{
 		"storage_class": req.StorageClass,
 	}
+	if req.Labels != nil {
+		updateCol["labels"] = req.Labels
+	}
+	if req.Annotations != nil {
+		updateCol["annotations"] = req.Annotations
+	}
 	cluster, err2 := service.Svc.ClusterSvc.Get(c, clusterId)
 	if err2 != nil {
 		api.SendResponse(c, errno.ErrUpdateCluster, nil)
@@ -43,6 +56,7 @@ func Update(c *gin.Context) {
 		api.SendResponse(c, errno.ErrPermissionDenied, nil)
 		return
 	}
+	audit.SetBefore(c, cluster)
 	result, err := service.Svc.ClusterSvc.Update(c, updateCol, clusterId)
 	if err != nil {
 		api.SendResponse(c, errno.ErrUpdateCluster, nil)
@@ -0,0 +1,92 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/usage"
+)
+
+// GetUsage Get the resource usage of cluster
+// @Summary Get the resource usage of cluster
+// @Description Aggregate the cpu/memory requests, limits and real usage (via metrics-server) of nodes and namespaces, namespaces of dev spaces are marked
+// @Tags Cluster
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "Cluster ID"
+// @Success 200 {object} cluster.ClusterUsageResponse
+// @Router /v1/cluster/{id}/usage [get]
+func GetUsage(c *gin.Context) {
+	clusterId := cast.ToUint64(c.Param("id"))
+	cluster, err := HasPrivilegeToSomeCluster(c, clusterId)
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	nodes, err := goClient.GetClusterNode()
+	if err != nil {
+		log.Warnf("get nodes of cluster %d err: %v", clusterId, err)
+		api.SendResponse(c, errno.ErrClusterUsage, nil)
+		return
+	}
+	pods, err := goClient.ListPods("")
+	if err != nil {
+		log.Warnf("get pods of cluster %d err: %v", clusterId, err)
+		api.SendResponse(c, errno.ErrClusterUsage, nil)
+		return
+	}
+
+	// real usage is left zero if metrics-server is unavailable
+	nodeMetrics, err := goClient.ListNodeMetrics()
+	if err != nil {
+		log.Infof("get node metrics of cluster %d err: %v", clusterId, err)
+	}
+	podMetrics, err := goClient.ListPodMetrics("")
+	if err != nil {
+		log.Infof("get pod metrics of cluster %d err: %v", clusterId, err)
+	}
+
+	result := usage.Aggregate(nodes.Items, pods.Items, nodeMetrics, podMetrics)
+
+	devSpaces := map[string]*model.ClusterUserModel{}
+	if list, err := service.Svc.ClusterUserSvc.GetList(c, model.ClusterUserModel{ClusterId: clusterId}); err == nil {
+		for _, devSpace := range list {
+			devSpaces[devSpace.Namespace] = devSpace
+		}
+	}
+
+	response := ClusterUsageResponse{
+		Usage:            result.Usage,
+		MetricsAvailable: result.MetricsAvailable,
+		Nodes:            result.Nodes,
+		Namespaces:       make([]DevSpaceUsage, 0, len(result.Namespaces)),
+	}
+	for _, ns := range result.Namespaces {
+		u := DevSpaceUsage{Namespace: ns}
+		if devSpace, ok := devSpaces[ns.Namespace]; ok {
+			u.DevSpaceId = devSpace.ID
+			u.SpaceName = devSpace.SpaceName
+		}
+		response.Namespaces = append(response.Namespaces, u)
+	}
+	api.SendResponse(c, nil, response)
+}
@@ -0,0 +1,155 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/ttl"
+)
+
+const defaultIdleFor = 7 * 24 * time.Hour
+
+type ActivityRequest struct {
+	// Kind of the activity reported by the clients, sync or port_forward,
+	// kubectl is tracked by nocalhost-api
+	Kind string `json:"kind" binding:"required" example:"sync"`
+}
+
+// Activity the last activities of dev space
+type Activity struct {
+	DevSpaceId    uint64     `json:"dev_space_id"`
+	SpaceName     string     `json:"space_name"`
+	Namespace     string     `json:"namespace"`
+	ClusterId     uint64     `json:"cluster_id"`
+	UserId        uint64     `json:"user_id"`
+	Asleep        bool       `json:"asleep"`
+	LastActiveAt  time.Time  `json:"last_active_at"`
+	IdleFor       string     `json:"idle_for"`
+	IdeAt         *time.Time `json:"ide_at"`
+	SyncAt        *time.Time `json:"sync_at"`
+	PortForwardAt *time.Time `json:"port_forward_at"`
+	KubectlAt     *time.Time `json:"kubectl_at"`
+}
+
+func newActivity(cu *model.ClusterUserModel, now time.Time) Activity {
+	last := cu.LastActiveAt()
+	return Activity{
+		DevSpaceId:    cu.ID,
+		SpaceName:     cu.SpaceName,
+		Namespace:     cu.Namespace,
+		ClusterId:     cu.ClusterId,
+		UserId:        cu.UserId,
+		Asleep:        cu.IsAsleep(),
+		LastActiveAt:  last,
+		IdleFor:       now.Sub(last).Round(time.Minute).String(),
+		IdeAt:         cu.ActiveAt,
+		SyncAt:        cu.SyncAt,
+		PortForwardAt: cu.PortForwardAt,
+		KubectlAt:     cu.KubectlAt,
+	}
+}
+
+// RecordActivity Record the activity of dev space
+// @Summary Record the activity of dev space
+// @Description Report the file sync or port-forward of dev space, the activities keep it from sleeping for inactive and expiring for idle
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Param activity body cluster_user.ActivityRequest true "The activity"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_space/{id}/activity [post]
+func RecordActivity(c *gin.Context) {
+	var req ActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind activity params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if req.Kind != model.ActivitySync && req.Kind != model.ActivityPortForward {
+		api.SendResponse(c, errno.ErrDevSpaceActivity, nil)
+		return
+	}
+
+	devSpace, err := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if err := service.RecordDevSpaceActivity(c, devSpace, req.Kind, time.Now()); err != nil {
+		log.Warnf("record activity of dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, nil)
+}
+
+// GetActivity Get the activities of dev space
+// @Summary Get the activities of dev space
+// @Description Get the last IDE connection, file sync, port-forward and kubectl of dev space, kubectl is tracked by the date on kubernetes 1.29+ only
+// @Tags DevSpace
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Success 200 {object} cluster_user.Activity
+// @Router /v1/dev_space/{id}/activity [get]
+func GetActivity(c *gin.Context) {
+	devSpace, err := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	api.SendResponse(c, nil, newActivity(devSpace, time.Now()))
+}
+
+// ListIdle List the idle dev spaces
+// @Summary List the idle dev spaces
+// @Description Admin find the dev spaces inactive for idle_for at least, the longest idle first
+// @Tags DevSpace
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param idle_for query string false "Such as 72h or 7d, default 7d"
+// @Success 200 {object} []cluster_user.Activity
+// @Router /v1/dev_space/idle [get]
+func ListIdle(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	idle := defaultIdleFor
+	if s := c.Query("idle_for"); s != "" {
+		var err error
+		if idle, err = ttl.Parse(s); err != nil {
+			api.SendResponse(c, errno.ErrBind, err.Error())
+			return
+		}
+	}
+
+	devSpaces, err := service.ListIdleDevSpaces(c, idle)
+	if err != nil {
+		log.Warnf("list idle dev spaces err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	now := time.Now()
+	result := make([]Activity, 0, len(devSpaces))
+	for _, cu := range devSpaces {
+		result = append(result, newActivity(cu, now))
+	}
+	api.SendResponse(c, nil, result)
+}
@@ -0,0 +1,190 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/applications"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/spacetemplate"
+)
+
+var installProgressInterval = 2 * time.Second
+
+type AppInstallRequest struct {
+	ApplicationId *uint64 `json:"application_id" binding:"required"`
+	// Overlay the name of kustomize overlay declared by application
+	Overlay string `json:"overlay" example:"dev"`
+	// Images override the images of kustomization
+	Images []string `json:"images" example:"nginx=registry.io/nginx:1.21"`
+}
+
+// InstallApp Install application in dev space
+// @Summary Install application in dev space
+// @Description Start a job of nhctl installing the application in dev space, the kustomize application can select one of its overlays and override images, the namespace of dev space is injected, the progress can be queried by /v1/dev_space/{id}/app_installs
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param install body cluster_user.AppInstallRequest true "The application to install"
+// @Success 200 {object} model.DevSpaceAppInstallModel
+// @Router /v1/dev_space/{id}/app_installs [post]
+func InstallApp(c *gin.Context) {
+	var req AppInstallRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind app install params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	devSpace, goClient, errn := devSpaceAdminClient(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	application, err := service.Svc.ApplicationSvc.Get(c, *req.ApplicationId)
+	if err != nil || !applications.HasApplicationPermission(c, &application) {
+		api.SendResponse(c, errno.ErrPermissionApplication, nil)
+		return
+	}
+	app, err := spacetemplate.ParseApplication(application.ID, application.Context)
+	if err != nil {
+		api.SendResponse(c, errno.ErrApplicationJsonContext, nil)
+		return
+	}
+	if err := app.Customize(req.Overlay, req.Images); err != nil {
+		api.SendResponse(c, errno.ErrKustomizeOverlay, nil)
+		return
+	}
+
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterNotFound, nil)
+		return
+	}
+	if err := ensureInstaller(goClient, &cluster, devSpace.Namespace); err != nil {
+		log.Warnf("create installer of dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceAppInstall, nil)
+		return
+	}
+
+	install := &model.DevSpaceAppInstallModel{
+		DevSpaceId:      devSpace.ID,
+		ApplicationId:   application.ID,
+		ApplicationName: app.Name,
+		Status:          spacetemplate.StatusPending,
+	}
+	if install.JobName, err = startInstall(c, goClient.GetClientSet(), devSpace.Namespace, app); err != nil {
+		install.Status, install.Message = spacetemplate.StatusFailed, err.Error()
+	}
+	if err := service.Svc.DevSpaceTemplateSvc.CreateInstall(c, install); err != nil {
+		log.Warnf("create app install of dev space %d err: %v", devSpace.ID, err)
+	}
+	api.SendResponse(c, nil, install)
+}
+
+// StreamAppInstall Stream the progress of application installing
+// @Summary Stream the progress of application installing
+// @Description Stream the events of installation in dev space by server-sent events until it is finished, the event progress is the change of the installation, the state of manifests or a pod, e.g. {"type":"pod","name":"productpage-0","status":"image_pulling"}, the event done carries the installation finished
+// @Tags DevSpace
+// @Produce  text/event-stream
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param install_id path uint64 true "Installation ID"
+// @Success 200 {object} spacetemplate.ProgressEvent
+// @Router /v1/dev_space/{id}/app_installs/{install_id}/events [get]
+func StreamAppInstall(c *gin.Context) {
+	devSpace, errn := HasPrivilegeToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+	install, err := service.Svc.DevSpaceTemplateSvc.GetInstall(c, cast.ToUint64(c.Param("install_id")))
+	if err != nil || install.DevSpaceId != devSpace.ID {
+		api.SendResponse(c, errno.ErrAppInstallNotFound, nil)
+		return
+	}
+
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterNotFound, nil)
+		return
+	}
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	// not buffered by nginx ingress
+	c.Header("X-Accel-Buffering", "no")
+
+	var tracker *spacetemplate.ProgressTracker
+	ticker := time.NewTicker(installProgressInterval)
+	defer ticker.Stop()
+	c.Stream(
+		func(w io.Writer) bool {
+			if install.Status == spacetemplate.StatusFailed && install.JobName == "" {
+				c.SSEvent("done", install)
+				return false
+			}
+			// the queued one of bulk deployment has no job yet
+			if install.JobName == "" {
+				if latest, err := service.Svc.DevSpaceTemplateSvc.GetInstall(c, install.ID); err == nil {
+					install = latest
+				}
+			} else {
+				if tracker == nil {
+					tracker = spacetemplate.NewProgressTracker(
+						goClient.GetClientSet(), devSpace.Namespace, install.JobName, install.ApplicationName,
+					)
+				}
+				events, finished := tracker.Poll()
+				for _, event := range events {
+					c.SSEvent("progress", event)
+					if event.Type == spacetemplate.EventInstall {
+						updateInstall(c, install, event.Status, event.Message)
+					}
+				}
+				if finished {
+					c.SSEvent("done", install)
+					return false
+				}
+			}
+
+			select {
+			case <-c.Request.Context().Done():
+				return false
+			case <-ticker.C:
+				return true
+			}
+		},
+	)
+}
+
+// updateInstall persist the status of installation if changed
+func updateInstall(c *gin.Context, install *model.DevSpaceAppInstallModel, status, message string) {
+	if status == install.Status && message == install.Message {
+		return
+	}
+	if err := service.Svc.DevSpaceTemplateSvc.UpdateInstall(c, install.ID, status, message); err != nil {
+		log.Warnf("update app install %d err: %v", install.ID, err)
+	}
+	install.Status, install.Message = status, message
+}
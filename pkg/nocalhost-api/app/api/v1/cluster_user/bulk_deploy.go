@@ -0,0 +1,498 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/spacetemplate"
+)
+
+const defaultBulkDeployConcurrency = 5
+
+var (
+	bulkDeployInterval = 5 * time.Second
+	// bulkDeploying the bulk deployments running, a retry and the end of
+	// running are serialized by bulkDeployLock
+	bulkDeploying  = map[uint64]bool{}
+	bulkDeployLock = sync.Mutex{}
+)
+
+type BulkDeployRequest struct {
+	ApplicationId *uint64 `json:"application_id" binding:"required"`
+	// Action install or upgrade, default install
+	Action string `json:"action" binding:"omitempty,oneof=install upgrade" example:"install"`
+	// ClusterId deploy in all dev spaces of the cluster if DevSpaceIds are not given
+	ClusterId   uint64   `json:"cluster_id"`
+	DevSpaceIds []uint64 `json:"dev_space_ids"`
+	// Concurrency the max number of dev spaces deploying at the same time, default 5
+	Concurrency int      `json:"concurrency" binding:"omitempty,min=1,max=50" example:"5"`
+	Overlay     string   `json:"overlay" example:"dev"`
+	Images      []string `json:"images" example:"nginx=registry.io/nginx:1.21"`
+}
+
+type BulkDeployRetryRequest struct {
+	// DevSpaceIds retry the failed dev spaces given only, all failed if empty
+	DevSpaceIds []uint64 `json:"dev_space_ids"`
+}
+
+// BulkDeployReport the result of each dev space, Summary counts the dev
+// spaces by status
+type BulkDeployReport struct {
+	*model.BulkDeployModel
+	Summary  map[string]int                   `json:"summary"`
+	Installs []*model.DevSpaceAppInstallModel `json:"installs"`
+}
+
+// CreateBulkDeploy Deploy application in many dev spaces
+// @Summary Deploy application in many dev spaces
+// @Description Admin installs or upgrades the application in the dev spaces given, or all dev spaces of the cluster, the dev spaces are deployed in parallel up to the concurrency, the result of each dev space is reported by /v1/bulk_deploy/{id}
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param deploy body cluster_user.BulkDeployRequest true "The application and dev spaces to deploy"
+// @Success 200 {object} cluster_user.BulkDeployReport
+// @Router /v1/bulk_deploy [post]
+func CreateBulkDeploy(c *gin.Context) {
+	var req BulkDeployRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind bulk deploy params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if req.Action == "" {
+		req.Action = model.BulkDeployInstall
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = defaultBulkDeployConcurrency
+	}
+
+	application, err := service.Svc.ApplicationSvc.Get(c, *req.ApplicationId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrApplicationGet, nil)
+		return
+	}
+	app, err := spacetemplate.ParseApplication(application.ID, application.Context)
+	if err != nil {
+		api.SendResponse(c, errno.ErrApplicationJsonContext, nil)
+		return
+	}
+	if err := app.Customize(req.Overlay, req.Images); err != nil {
+		api.SendResponse(c, errno.ErrKustomizeOverlay, nil)
+		return
+	}
+
+	devSpaces, err := bulkDeploySpaces(c, req.ClusterId, req.DevSpaceIds)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterUserNotFound, nil)
+		return
+	}
+	if len(devSpaces) == 0 {
+		api.SendResponse(c, errno.ErrBulkDeployNoDevSpace, nil)
+		return
+	}
+
+	userId, _ := ginbase.LoginUser(c)
+	images, _ := json.Marshal(req.Images)
+	bulk := &model.BulkDeployModel{
+		ApplicationId: application.ID,
+		Action:        req.Action,
+		Concurrency:   req.Concurrency,
+		Overlay:       req.Overlay,
+		Images:        string(images),
+		Status:        model.BulkDeployRunning,
+		UserId:        userId,
+	}
+	if err := service.Svc.BulkDeploySvc.Create(c, bulk); err != nil {
+		log.Warnf("create bulk deploy err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	installs := make([]*model.DevSpaceAppInstallModel, 0, len(devSpaces))
+	for _, devSpace := range devSpaces {
+		install := &model.DevSpaceAppInstallModel{
+			DevSpaceId:      devSpace.ID,
+			BulkDeployId:    bulk.ID,
+			ApplicationId:   application.ID,
+			ApplicationName: app.Name,
+			Status:          spacetemplate.StatusQueued,
+		}
+		if err := service.Svc.DevSpaceTemplateSvc.CreateInstall(c, install); err != nil {
+			log.Warnf("create app install of dev space %d err: %v", devSpace.ID, err)
+			continue
+		}
+		installs = append(installs, install)
+	}
+
+	runBulkDeploy(bulk.ID)
+	api.SendResponse(c, nil, newBulkDeployReport(bulk, installs))
+}
+
+// ListBulkDeploy List bulk deployments
+// @Summary List bulk deployments
+// @Description List the bulk deployments, the latest first
+// @Tags DevSpace
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} []model.BulkDeployModel
+// @Router /v1/bulk_deploy [get]
+func ListBulkDeploy(c *gin.Context) {
+	result, err := service.Svc.BulkDeploySvc.List(c)
+	if err != nil {
+		log.Warnf("list bulk deploy err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// GetBulkDeploy Get the report of bulk deployment
+// @Summary Get the report of bulk deployment
+// @Description Get the status of bulk deployment with the result of each dev space, queued, pending, installing, installed or failed with the reason
+// @Tags DevSpace
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Bulk deployment ID"
+// @Success 200 {object} cluster_user.BulkDeployReport
+// @Router /v1/bulk_deploy/{id} [get]
+func GetBulkDeploy(c *gin.Context) {
+	bulk, err := service.Svc.BulkDeploySvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrBulkDeployNotFound, nil)
+		return
+	}
+	installs, err := service.Svc.DevSpaceTemplateSvc.ListBulkInstalls(c, bulk.ID)
+	if err != nil {
+		log.Warnf("list app installs of bulk deploy %d err: %v", bulk.ID, err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	// resumed if nocalhost-api restarted while running
+	if bulk.Status == model.BulkDeployRunning {
+		runBulkDeploy(bulk.ID)
+	}
+	api.SendResponse(c, nil, newBulkDeployReport(bulk, installs))
+}
+
+// RetryBulkDeploy Retry the failed dev spaces of bulk deployment
+// @Summary Retry the failed dev spaces of bulk deployment
+// @Description The failed dev spaces are queued to deploy again, all failed ones if dev spaces are not given
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Bulk deployment ID"
+// @Param retry body cluster_user.BulkDeployRetryRequest false "The dev spaces to retry"
+// @Success 200 {object} cluster_user.BulkDeployReport
+// @Router /v1/bulk_deploy/{id}/retry [post]
+func RetryBulkDeploy(c *gin.Context) {
+	var req BulkDeployRetryRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Warnf("bind bulk deploy retry params err: %v", err)
+			api.SendResponse(c, errno.ErrBind, nil)
+			return
+		}
+	}
+
+	bulk, err := service.Svc.BulkDeploySvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrBulkDeployNotFound, nil)
+		return
+	}
+
+	bulkDeployLock.Lock()
+	defer bulkDeployLock.Unlock()
+
+	installs, err := service.Svc.DevSpaceTemplateSvc.ListBulkInstalls(c, bulk.ID)
+	if err != nil {
+		log.Warnf("list app installs of bulk deploy %d err: %v", bulk.ID, err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	retry := map[uint64]bool{}
+	for _, id := range req.DevSpaceIds {
+		retry[id] = true
+	}
+
+	retried := false
+	for _, install := range installs {
+		if install.Status != spacetemplate.StatusFailed || (len(retry) > 0 && !retry[install.DevSpaceId]) {
+			continue
+		}
+		if err := service.Svc.DevSpaceTemplateSvc.UpdateInstallJob(
+			c, install.ID, install.JobName, spacetemplate.StatusQueued, "",
+		); err != nil {
+			log.Warnf("queue app install %d err: %v", install.ID, err)
+			continue
+		}
+		install.Status, install.Message, retried = spacetemplate.StatusQueued, "", true
+	}
+
+	if retried {
+		if bulk.Status != model.BulkDeployRunning {
+			if err := service.Svc.BulkDeploySvc.UpdateStatus(c, bulk.ID, model.BulkDeployRunning); err != nil {
+				log.Warnf("update bulk deploy %d err: %v", bulk.ID, err)
+			}
+			bulk.Status = model.BulkDeployRunning
+		}
+		startBulkDeploy(bulk.ID)
+	}
+	api.SendResponse(c, nil, newBulkDeployReport(bulk, installs))
+}
+
+// bulkDeploySpaces the dev spaces given, or all dev spaces of the cluster,
+// the spaces of cluster admin are not deployed
+func bulkDeploySpaces(c context.Context, clusterId uint64, ids []uint64) ([]*model.ClusterUserModel, error) {
+	var devSpaces []*model.ClusterUserModel
+	if len(ids) > 0 {
+		for _, id := range ids {
+			devSpace, err := service.Svc.ClusterUserSvc.GetCache(id)
+			if err != nil {
+				return nil, err
+			}
+			devSpaces = append(devSpaces, &devSpace)
+		}
+	} else if clusterId > 0 {
+		list, err := service.Svc.ClusterUserSvc.GetList(c, model.ClusterUserModel{ClusterId: clusterId})
+		if err != nil {
+			return nil, err
+		}
+		devSpaces = list
+	}
+
+	result := make([]*model.ClusterUserModel, 0, len(devSpaces))
+	for _, devSpace := range devSpaces {
+		if !devSpace.IsClusterAdmin() && devSpace.Namespace != "" {
+			result = append(result, devSpace)
+		}
+	}
+	return result, nil
+}
+
+func newBulkDeployReport(bulk *model.BulkDeployModel, installs []*model.DevSpaceAppInstallModel) *BulkDeployReport {
+	report := &BulkDeployReport{BulkDeployModel: bulk, Summary: map[string]int{}, Installs: installs}
+	for _, install := range installs {
+		report.Summary[install.Status]++
+	}
+	return report
+}
+
+// runBulkDeploy start running the bulk deployment if not running
+func runBulkDeploy(id uint64) {
+	bulkDeployLock.Lock()
+	defer bulkDeployLock.Unlock()
+	startBulkDeploy(id)
+}
+
+// startBulkDeploy bulkDeployLock must be held
+func startBulkDeploy(id uint64) {
+	if bulkDeploying[id] {
+		return
+	}
+	bulkDeploying[id] = true
+
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorf("Panic while running bulk deploy %d: %v", id, err)
+				bulkDeployLock.Lock()
+				delete(bulkDeploying, id)
+				bulkDeployLock.Unlock()
+			}
+		}()
+
+		d := &bulkDeployer{id: id, clients: map[uint64]*clientgo.GoClient{}}
+		for d.round() {
+			time.Sleep(bulkDeployInterval)
+		}
+	}()
+}
+
+// bulkDeployer refresh the status of dev spaces deploying and start the
+// queued ones round by round, the clients of clusters are reused
+type bulkDeployer struct {
+	id      uint64
+	app     *spacetemplate.Application
+	clients map[uint64]*clientgo.GoClient
+}
+
+// round returns false if the bulk deployment is finished
+func (d *bulkDeployer) round() bool {
+	ctx := context.TODO()
+	bulk, err := service.Svc.BulkDeploySvc.Get(ctx, d.id)
+	if err != nil {
+		log.Warnf("get bulk deploy %d err: %v", d.id, err)
+		return d.finish(ctx, false)
+	}
+	installs, err := service.Svc.DevSpaceTemplateSvc.ListBulkInstalls(ctx, d.id)
+	if err != nil {
+		log.Warnf("list app installs of bulk deploy %d err: %v", d.id, err)
+		return true
+	}
+
+	active := 0
+	var queued []*model.DevSpaceAppInstallModel
+	for _, install := range installs {
+		switch install.Status {
+		case spacetemplate.StatusQueued:
+			queued = append(queued, install)
+		case spacetemplate.StatusPending, spacetemplate.StatusInstalling:
+			if d.refresh(ctx, install) {
+				active++
+			}
+		}
+	}
+
+	for _, install := range queued {
+		if active >= bulk.Concurrency {
+			break
+		}
+		if d.start(ctx, bulk, install) {
+			active++
+		}
+	}
+
+	// the queued ones left are started in next rounds
+	if active > 0 {
+		return true
+	}
+	return d.finish(ctx, true)
+}
+
+// finish mark the bulk deployment finished unless requeued by retry
+func (d *bulkDeployer) finish(ctx context.Context, update bool) bool {
+	bulkDeployLock.Lock()
+	defer bulkDeployLock.Unlock()
+
+	if update {
+		installs, err := service.Svc.DevSpaceTemplateSvc.ListBulkInstalls(ctx, d.id)
+		if err != nil {
+			return true
+		}
+		for _, install := range installs {
+			if install.Status != spacetemplate.StatusInstalled && install.Status != spacetemplate.StatusFailed {
+				return true
+			}
+		}
+		if err := service.Svc.BulkDeploySvc.UpdateStatus(ctx, d.id, model.BulkDeployFinished); err != nil {
+			log.Warnf("update bulk deploy %d err: %v", d.id, err)
+			return true
+		}
+	}
+	delete(bulkDeploying, d.id)
+	return false
+}
+
+// refresh update the status of install by its job, returns whether it is
+// still deploying
+func (d *bulkDeployer) refresh(ctx context.Context, install *model.DevSpaceAppInstallModel) bool {
+	devSpace, goClient, err := d.client(install.DevSpaceId)
+	if err != nil {
+		return true
+	}
+	status, message := spacetemplate.InstallStatus(
+		goClient.GetClientSet(), devSpace.Namespace, install.JobName, install.ApplicationName,
+	)
+	if status != install.Status || message != install.Message {
+		if err := service.Svc.DevSpaceTemplateSvc.UpdateInstall(ctx, install.ID, status, message); err != nil {
+			log.Warnf("update app install %d err: %v", install.ID, err)
+		}
+	}
+	return status == spacetemplate.StatusPending || status == spacetemplate.StatusInstalling
+}
+
+// start the job installing or upgrading the application in dev space, the
+// install failed to start is recorded, returns whether the job is started
+func (d *bulkDeployer) start(
+	ctx context.Context, bulk *model.BulkDeployModel, install *model.DevSpaceAppInstallModel,
+) bool {
+	jobName, err := d.startJob(ctx, bulk, install)
+	status, message := spacetemplate.StatusPending, ""
+	if err != nil {
+		log.Warnf("start bulk deploy %d in dev space %d err: %v", bulk.ID, install.DevSpaceId, err)
+		status, message = spacetemplate.StatusFailed, err.Error()
+	}
+	if err := service.Svc.DevSpaceTemplateSvc.UpdateInstallJob(ctx, install.ID, jobName, status, message); err != nil {
+		log.Warnf("update app install %d err: %v", install.ID, err)
+	}
+	return status == spacetemplate.StatusPending
+}
+
+func (d *bulkDeployer) startJob(
+	ctx context.Context, bulk *model.BulkDeployModel, install *model.DevSpaceAppInstallModel,
+) (string, error) {
+	if d.app == nil {
+		application, err := service.Svc.ApplicationSvc.Get(ctx, bulk.ApplicationId)
+		if err != nil {
+			return "", err
+		}
+		app, err := spacetemplate.ParseApplication(application.ID, application.Context)
+		if err != nil {
+			return "", err
+		}
+		var images []string
+		_ = json.Unmarshal([]byte(bulk.Images), &images)
+		if err := app.Customize(bulk.Overlay, images); err != nil {
+			return "", err
+		}
+		d.app = app
+	}
+
+	devSpace, goClient, err := d.client(install.DevSpaceId)
+	if err != nil {
+		return "", err
+	}
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		return "", err
+	}
+	if err := ensureInstaller(goClient, &cluster, devSpace.Namespace); err != nil {
+		return "", err
+	}
+
+	if bulk.Action == model.BulkDeployUpgrade {
+		return spacetemplate.Upgrade(goClient.GetClientSet(), devSpace.Namespace, d.app, appCredential(ctx, d.app))
+	}
+	return startInstall(ctx, goClient.GetClientSet(), devSpace.Namespace, d.app)
+}
+
+// client the dev space with the admin client of its cluster
+func (d *bulkDeployer) client(devSpaceId uint64) (*model.ClusterUserModel, *clientgo.GoClient, error) {
+	devSpace, err := service.Svc.ClusterUserSvc.GetCache(devSpaceId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if goClient, ok := d.clients[devSpace.ClusterId]; ok {
+		return &devSpace, goClient, nil
+	}
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		return nil, nil, err
+	}
+	goClient, err := clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+	if err != nil {
+		return nil, nil, err
+	}
+	d.clients[devSpace.ClusterId] = goClient
+	return &devSpace, goClient, nil
+}
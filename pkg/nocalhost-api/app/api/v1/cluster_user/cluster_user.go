@@ -7,6 +7,8 @@ package cluster_user
 
 import (
 	"context"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cast"
 
@@ -32,6 +34,11 @@ type ClusterUserCreateRequest struct {
 	MeshDevInfo        *setupcluster.MeshDevInfo `json:"mesh_dev_info"`
 	IsBaseSpace        bool                      `json:"is_base_space"`
 	Protected          bool                      `json:"protected"`
+	// Isolated deny the traffic from and to other namespaces by network policies
+	Isolated bool `json:"isolated"`
+	// Ttl the dev space is deleted after, such as 72h or 7d, only admin can set it,
+	// default to the annotation nocalhost.dev/dev-space-ttl of cluster
+	Ttl string `json:"ttl"`
 }
 
 func (cu *ClusterUserCreateRequest) Validate() (bool, error) {
@@ -61,6 +68,12 @@ func (cu *ClusterUserCreateRequest) Validate() (bool, error) {
 		}
 	}
 
+	// the traffic of mesh space is routed across namespaces
+	if cu.Isolated && (cu.BaseDevSpaceId > 0 || cu.IsBaseSpace ||
+		(cu.ClusterAdmin != nil && *cu.ClusterAdmin != 0)) {
+		return false, errno.ErrDevSpaceIsolationNotSupported
+	}
+
 	// Validate Istio CRD
 	if cu.IsBaseSpace {
 		cluster, err := service.Svc.ClusterSvc.Get(context.TODO(), cast.ToUint64(cu.ClusterId))
@@ -125,6 +138,17 @@ type SpaceResourceLimit struct {
 	ContainerEphemeralStorage string `json:"container_ephemeral_storage"`
 }
 
+// Limits returns the space limits of cpu in cores and memory in Mi, zero if not set
+func (srl *SpaceResourceLimit) Limits() (float64, uint64) {
+	if srl == nil {
+		return 0, 0
+	}
+
+	cpu, _ := strconv.ParseFloat(srl.SpaceLimitsCpu, 64)
+	memory, _ := strconv.ParseFloat(strings.TrimSuffix(srl.SpaceLimitsMem, "Mi"), 64)
+	return cpu, uint64(memory)
+}
+
 func (srl *SpaceResourceLimit) ResourceLimitIsSet() bool {
 
 	return srl != nil && (srl.SpaceReqMem != "" ||
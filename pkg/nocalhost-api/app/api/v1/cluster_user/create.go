@@ -8,10 +8,13 @@ package cluster_user
 import (
 	"github.com/gin-gonic/gin"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/ttl"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Create Create dev space
@@ -41,6 +44,20 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	var ttlDuration time.Duration
+	if req.Ttl != "" {
+		if !ginbase.IsAdmin(c) {
+			api.SendResponse(c, errno.ErrPermissionDenied, nil)
+			return
+		}
+		d, err := ttl.Parse(req.Ttl)
+		if err != nil {
+			api.SendResponse(c, errno.ErrDevSpaceTtl, err.Error())
+			return
+		}
+		ttlDuration = d
+	}
+
 	applicationId := uint64(0)
 	req.ApplicationId = &applicationId
 	devSpace := NewDevSpace(req, c, []byte{})
@@ -50,6 +67,12 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	if ttlDuration == 0 {
+		setDefaultDevSpaceTtl(c, result)
+	} else if err := setDevSpaceTtl(c, result, ttlDuration); err != nil {
+		log.Warnf("set ttl of dev space %d err: %v", result.ID, err)
+	}
+
 	api.SendResponse(c, nil, result)
 }
 
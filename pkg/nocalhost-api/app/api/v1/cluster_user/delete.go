@@ -44,37 +44,40 @@ func Delete(c *gin.Context) {
 		return
 	}
 
+	if err := deleteDevSpace(c, clusterUser); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	api.SendResponse(c, errno.OK, nil)
+}
+
+// deleteDevSpace delete the namespace, the authorization and the record of
+// dev space, the share spaces are deleted with the base space
+func deleteDevSpace(c *gin.Context, clusterUser *model.ClusterUserModel) error {
 	if clusterUser.IsClusterAdmin() {
 
 		if err := cluster_scope.RemoveAllFromViewer(clusterUser.ClusterId, clusterUser.UserId); err != nil {
-			api.SendResponse(c, err, nil)
-			return
+			return err
 		}
 
 		if err := cluster_scope.RemoveAllFromCooperator(clusterUser.ClusterId, clusterUser.UserId); err != nil {
-			api.SendResponse(c, err, nil)
-			return
+			return err
 		}
 
 		if err := service.Svc.UnAuthorizeClusterToUser(clusterUser.ClusterId, clusterUser.UserId); err != nil {
-			api.SendResponse(c, err, nil)
-			return
+			return err
 		}
 
 		// delete database cluster-user dev space
-		if err := service.Svc.ClusterUserSvc.Delete(c, devSpaceId); err != nil {
-			api.SendResponse(c, errno.ErrDeletedClusterButDatabaseFail, nil)
-			return
+		if err := service.Svc.ClusterUserSvc.Delete(c, clusterUser.ID); err != nil {
+			return errno.ErrDeletedClusterButDatabaseFail
 		}
-
-		api.SendResponse(c, errno.OK, nil)
-		return
+		return nil
 	}
 
 	clusterData, err := service.Svc.ClusterSvc.Get(c, clusterUser.ClusterId)
 	if err != nil {
-		api.SendResponse(c, errno.ErrClusterNotFound, nil)
-		return
+		return errno.ErrClusterNotFound
 	}
 
 	meshDevInfo := &setupcluster.MeshDevInfo{
@@ -90,16 +93,18 @@ func Delete(c *gin.Context) {
 	devSpace := NewDevSpace(req, c, []byte(clusterData.KubeConfig))
 
 	if err := devSpace.Delete(); err != nil {
-		api.SendResponse(c, err, nil)
-		return
+		return err
+	}
+
+	if err := service.Svc.DevSpaceTemplateSvc.DeleteInstalls(c, clusterUser.ID); err != nil {
+		log.Warnf("delete app installs of dev space %d err: %v", clusterUser.ID, err)
 	}
 
 	// delete share space when deleting base space
 	if clusterUser.IsBaseSpace {
-		deleteShareSpaces(c, devSpaceId)
+		deleteShareSpaces(c, clusterUser.ID)
 	}
-
-	api.SendResponse(c, errno.OK, nil)
+	return nil
 }
 
 // ReCreate ReCreate devSpace
@@ -159,6 +164,7 @@ func ReCreate(c *gin.Context) {
 		BaseDevSpaceId:     clusterUser.BaseDevSpaceId,
 		MeshDevInfo:        meshDevInfo,
 		IsBaseSpace:        clusterUser.IsBaseSpace,
+		Isolated:           clusterUser.Isolated,
 	}
 
 	cluster, err := service.Svc.ClusterSvc.GetCache(clusterUser.ClusterId)
@@ -16,10 +16,15 @@ import (
 	"math/rand"
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/quota"
 	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/clusterlabel"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/isolation"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/notify"
 	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 	"time"
 )
 
@@ -60,6 +65,11 @@ func (d *DevSpace) Delete() error {
 
 	_, _ = goClient.DeleteNS(d.DevSpaceParams.NameSpace)
 
+	// service accounts are deleted with the namespace
+	if err := service.Svc.DevSpaceSaSvc.RevokeAll(d.c, *d.DevSpaceParams.ID); err != nil {
+		log.Error(err)
+	}
+
 	// delete database cluster-user dev space
 	dErr := service.Svc.ClusterUserSvc.Delete(d.c, *d.DevSpaceParams.ID)
 	if dErr != nil {
@@ -84,6 +94,26 @@ func (d *DevSpace) Create() (*model.ClusterUserModel, error) {
 		return nil, errno.ErrClusterNotFound
 	}
 
+	isAdmin := d.c.GetUint64("isAdmin") == 1
+	if allowed, reason := clusterlabel.AllowCreateDevSpace(
+		clusterlabel.DevSpacePolicies(), clusterRecord.Labels, isAdmin,
+		clusterRecord.UserId == d.c.GetUint64("userId"),
+	); !allowed {
+		log.Warnf("create dev space on cluster %d denied: %s", clusterId, reason)
+		return nil, errno.ErrDevSpacePolicyDenied
+	}
+
+	// admin is not limited by the quota
+	if !isAdmin {
+		cpu, memory := d.DevSpaceParams.SpaceResourceLimit.Limits()
+		ctx, span := trace.StartSpan(d.c, "check quota")
+		err := quotaErr(service.Svc.QuotaSvc.CheckDevSpace(ctx, userId, cpu, memory))
+		span.End(err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if d.DevSpaceParams.SpaceName == "" {
 		if genName, err := getUnDuplicateName(
 			0, fmt.Sprintf("%s[%s]", clusterRecord.Name, usersRecord.Name),
@@ -153,6 +183,16 @@ func (d *DevSpace) Create() (*model.ClusterUserModel, error) {
 		}
 	}
 
+	notify.Notify(
+		notify.DevSpaceCreated, map[string]interface{}{
+			"id":         clusterUserModel.ID,
+			"space_name": clusterUserModel.SpaceName,
+			"namespace":  clusterUserModel.Namespace,
+			"cluster":    clusterRecord.Name,
+			"user":       usersRecord.Name,
+		}, "Dev space %s (namespace %s) is created on cluster %s for %s",
+		clusterUserModel.SpaceName, clusterUserModel.Namespace, clusterRecord.Name, usersRecord.Name,
+	)
 	return clusterUserModel, nil
 }
 
@@ -228,7 +268,7 @@ func (d *DevSpace) createDevSpace(
 
 	// create namespace
 	var KubeConfig = []byte(clusterRecord.KubeConfig)
-	goClient, err := clientgo.NewAdminGoClient(KubeConfig)
+	goClient, err := clientgo.NewAdminGoClientWithContext(d.c, KubeConfig)
 
 	// get client go and check if is admin Kubeconfig
 	if err != nil {
@@ -290,7 +330,10 @@ func (d *DevSpace) createDevSpace(
 	// (3) create the devspace
 	if needCreateNamespace {
 		// create namespace
-		_, err = goClient.CreateNS(devNamespace, labels)
+		ctx, span := trace.StartSpan(d.c, "create namespace")
+		_, err = goClient.WithContext(ctx).CreateNS(devNamespace, labels)
+		span.Set("k8s.namespace.name", devNamespace).End(err)
+		goClient.WithContext(d.c)
 		if err != nil {
 			return nil, errno.ErrNameSpaceCreate
 		}
@@ -303,6 +346,8 @@ func (d *DevSpace) createDevSpace(
 		res = &SpaceResourceLimit{}
 	}
 
+	ctx, span := trace.StartSpan(d.c, "create resource quota")
+	goClient.WithContext(ctx)
 	clusterDevsSetUp.CreateResourceQuota(
 		"rq-"+devNamespace, devNamespace, res.SpaceReqMem,
 		res.SpaceReqCpu, res.SpaceLimitsMem, res.SpaceLimitsCpu, res.SpaceStorageCapacity, res.SpaceEphemeralStorage,
@@ -312,6 +357,15 @@ func (d *DevSpace) createDevSpace(
 		res.ContainerReqMem, res.ContainerLimitsMem, res.ContainerReqCpu, res.ContainerLimitsCpu,
 		res.ContainerEphemeralStorage,
 	)
+	span.End(nil)
+	goClient.WithContext(d.c)
+
+	if d.DevSpaceParams.Isolated {
+		if err := isolation.Isolate(goClient.GetClientSet(), devNamespace); err != nil {
+			log.Error(err)
+			return nil, errno.ErrDevSpaceIsolate
+		}
+	}
 
 	var result model.ClusterUserModel
 
@@ -326,7 +380,7 @@ func (d *DevSpace) createDevSpace(
 		result, err = service.Svc.ClusterUserSvc.Create(
 			d.c, *d.DevSpaceParams.ClusterId, usersRecord.ID, *d.DevSpaceParams.Memory, *d.DevSpaceParams.Cpu,
 			"", devNamespace, d.DevSpaceParams.SpaceName, string(resString), d.DevSpaceParams.IsBaseSpace,
-			d.DevSpaceParams.Protected,
+			d.DevSpaceParams.Protected, d.DevSpaceParams.Isolated,
 		)
 		if err != nil {
 			return nil, errno.ErrBindApplicationClsuter
@@ -337,11 +391,13 @@ func (d *DevSpace) createDevSpace(
 	_ = service.Svc.ApplicationUserSvc.BatchInsert(d.c, applicationId, []uint64{usersRecord.ID})
 
 	// authorize namespace to user
-	if err := service.Svc.AuthorizeNsToUser(clusterRecord.ID, usersRecord.ID, result.Namespace); err != nil {
-		return nil, err
+	_, span = trace.StartSpan(d.c, "authorize namespace")
+	err = service.Svc.AuthorizeNsToUser(clusterRecord.ID, usersRecord.ID, result.Namespace)
+	if err == nil {
+		err = service.Svc.AuthorizeNsToDefaultSa(clusterRecord.ID, usersRecord.ID, result.Namespace)
 	}
-
-	if err := service.Svc.AuthorizeNsToDefaultSa(clusterRecord.ID, usersRecord.ID, result.Namespace); err != nil {
+	span.Set("k8s.namespace.name", result.Namespace).End(err)
+	if err != nil {
 		return nil, err
 	}
 
@@ -406,3 +462,17 @@ func (d *DevSpace) deleteTracingHeader() error {
 	}
 	return nil
 }
+
+func quotaErr(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case quota.ErrDevSpaceQuotaExceed:
+		return errno.ErrDevSpaceQuotaExceed
+	case quota.ErrSpaceResourceExceed:
+		return errno.ErrSpaceResourceExceed
+	default:
+		log.Warnf("check dev space quota err: %v", err)
+		return errno.InternalServerError
+	}
+}
@@ -13,9 +13,11 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/spf13/cast"
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"nocalhost/internal/nocalhost-api/global"
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
 	"nocalhost/internal/nocalhost-api/service/cooperator/cluster_scope"
@@ -26,8 +28,10 @@ import (
 	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
 	"strings"
 	"sync"
+	"time"
 )
 
 type NamespaceInfo struct {
@@ -78,7 +82,7 @@ func GetNsInfo(c *gin.Context) {
 
 	nsList := make([]*NamespaceInfo, 0)
 	for _, list := range allClusters {
-		goClient, err := clientgo.NewAdminGoClient([]byte(list.KubeConfig))
+		goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(list.KubeConfig))
 		if err != nil {
 			log.Error(err.Error())
 			continue
@@ -117,12 +121,100 @@ func GetNsInfo(c *gin.Context) {
 	api.SendResponse(c, nil, nsList)
 }
 
+// systemNamespaces can not be adopted as dev space
+var systemNamespaces = map[string]bool{
+	"kube-system":                   true,
+	"kube-public":                   true,
+	"kube-node-lease":               true,
+	global.NocalhostSystemNamespace: true,
+}
+
+type ScannedNamespace struct {
+	Name               string              `json:"name"`
+	Labels             map[string]string   `json:"labels"`
+	CreatedAt          time.Time           `json:"created_at"`
+	SpaceResourceLimit *SpaceResourceLimit `json:"space_resource_limit"`
+}
+
+// ScanNs Scan the namespaces not managed as dev space
+// @Summary Scan the namespaces not managed as dev space
+// @Description Scan the existing namespaces of cluster which are not dev spaces, with the resource limit read from their resource quota, they can be adopted by ns_import
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "Cluster ID"
+// @Success 200 {object} []cluster_user.ScannedNamespace
+// @Router /v2/dev_space/ns_scan/{id} [get]
+func ScanNs(c *gin.Context) {
+	clusterId := cast.ToUint64(c.Param("id"))
+	cluster, err := service.Svc.ClusterSvc.Get(c, clusterId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterNotFound, nil)
+		return
+	}
+
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		switch err.(type) {
+		case *errno.Errno:
+			api.SendResponse(c, err, nil)
+		default:
+			api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		}
+		return
+	}
+
+	nss, err := goClient.GetClientSet().CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.Warnf("list namespaces of cluster %d err: %v", clusterId, err)
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	devSpaces, err := service.Svc.ClusterUserSvc.GetList(c, model.ClusterUserModel{ClusterId: clusterId})
+	if err != nil {
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	managed := make(map[string]bool, len(devSpaces))
+	for _, space := range devSpaces {
+		managed[space.Namespace] = true
+	}
+
+	result := make([]*ScannedNamespace, 0)
+	for _, item := range nss.Items {
+		if systemNamespaces[item.Name] || managed[item.Name] || item.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+
+		srl, err := namespaceResourceLimit(goClient, item.Name)
+		if err != nil {
+			log.Warnf("read resource limit of namespace %s err: %v", item.Name, err)
+		}
+		result = append(
+			result, &ScannedNamespace{
+				Name:               item.Name,
+				Labels:             item.Labels,
+				CreatedAt:          item.CreationTimestamp.Time,
+				SpaceResourceLimit: srl,
+			},
+		)
+	}
+	api.SendResponse(c, nil, result)
+}
+
 type NsImportItem struct {
 	ClusterName  string   `json:"cluster_name"`
 	Namespace    string   `json:"namespace"`
 	Owner        *uint64  `json:"owner"`
 	IsBaseSpace  int      `json:"is_basespace"`
 	Collaborator []uint64 `json:"collaborator"`
+	// SpaceName default to the namespace
+	SpaceName string `json:"space_name"`
+	// SpaceResourceLimit replace the resource quota of namespace if specified,
+	// otherwise the existing resource quota is kept
+	SpaceResourceLimit *SpaceResourceLimit `json:"space_resource_limit"`
 }
 
 func importNsToDevSpace(ctx *gin.Context, devSpace []*BatchImportItem, uuid string) {
@@ -218,6 +310,20 @@ func importNs(c1 *gin.Context, req *NsImportItem) error {
 		return errors.New("owner can not be nil")
 	}
 
+	owner, err := service.Svc.UserSvc.GetUserByID(context.TODO(), *req.Owner)
+	if err != nil {
+		return errors.New(fmt.Sprintf("owner %d not found", *req.Owner))
+	}
+
+	if req.SpaceResourceLimit != nil {
+		if flag, message := ValidSpaceResourceLimit(*req.SpaceResourceLimit); !flag {
+			return errors.New(fmt.Sprintf("Incorrect resource limit parameter [ %v ] format", message))
+		}
+		if !req.SpaceResourceLimit.Validate() {
+			return errno.ErrValidateResourceQuota
+		}
+	}
+
 	goClient, err := clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
 	if err != nil {
 		return err
@@ -241,6 +347,9 @@ func importNs(c1 *gin.Context, req *NsImportItem) error {
 	cucr.ApplicationId = &applicationId
 	cucr.NameSpace = req.Namespace
 	cucr.SpaceName = req.Namespace
+	if req.SpaceName != "" {
+		cucr.SpaceName = req.SpaceName
+	}
 	cucr.ClusterId = &clusterId
 	cucr.UserId = req.Owner
 	clusterAdmin := uint64(0)
@@ -262,11 +371,72 @@ func importNs(c1 *gin.Context, req *NsImportItem) error {
 		return err
 	}
 
-	// resource limit
+	// resource limit, apply the specified one or keep the existing quota
+	if req.SpaceResourceLimit != nil {
+		clusterDevsSetUp := setupcluster.NewClusterDevsSetUp(goClient)
+		res := req.SpaceResourceLimit
+		clusterDevsSetUp.DeleteResourceQuota("rq-"+req.Namespace, req.Namespace).CreateResourceQuota(
+			"rq-"+req.Namespace, req.Namespace, res.SpaceReqMem,
+			res.SpaceReqCpu, res.SpaceLimitsMem, res.SpaceLimitsCpu, res.SpaceStorageCapacity, res.SpaceEphemeralStorage,
+			res.SpacePvcCount, res.SpaceLbCount,
+		)
+		clusterDevsSetUp.DeleteLimitRange("lr-"+req.Namespace, req.Namespace).CreateLimitRange(
+			"lr-"+req.Namespace, req.Namespace,
+			res.ContainerReqMem, res.ContainerLimitsMem, res.ContainerReqCpu, res.ContainerLimitsCpu,
+			res.ContainerEphemeralStorage,
+		)
+	}
+	srl := req.SpaceResourceLimit
+	if srl == nil {
+		if srl, err = namespaceResourceLimit(goClient, req.Namespace); err != nil {
+			return err
+		}
+	}
+
+	if srl != nil {
+		resSting, _ := json.Marshal(srl)
+		cu.SpaceResourceLimit = string(resSting)
+		_, err = service.Svc.ClusterUserSvc.Update(c1, cu)
+		if err != nil {
+			return err
+		}
+	}
+
+	// mark the namespace as managed by nocalhost
+	if err = goClient.AdoptNamespace(
+		req.Namespace, map[string]string{"env": global.NocalhostDevNamespaceLabel},
+		map[string]string{
+			global.NocalhostDevSpaceIdAnnotation:    cast.ToString(cu.ID),
+			global.NocalhostDevSpaceOwnerAnnotation: owner.Email,
+		},
+	); err != nil {
+		return err
+	}
+
+	for _, i := range req.Collaborator {
+		if cu.IsClusterAdmin() {
+			if err := cluster_scope.AsCooperator(cu.ClusterId, cu.UserId, i); err != nil {
+				errStr = errStr + fmt.Sprintf(",Error while adding %d as cluster cooperator", i)
+			}
+		} else if err := ns_scope.AsCooperator(cu.ClusterId, i, cu.Namespace); err != nil {
+			errStr = errStr + fmt.Sprintf(",Error while adding %d as cluster cooperator", i)
+		}
+	}
+
+	if errStr != "" {
+		errStr = strings.TrimLeft(errStr, ",")
+		return errors.New(errStr)
+	}
+	return nil
+}
+
+// namespaceResourceLimit read the resource limit from the resource quotas
+// and limit ranges already exist in the namespace, nil if not limited
+func namespaceResourceLimit(goClient *clientgo.GoClient, namespace string) (*SpaceResourceLimit, error) {
 	var srl *SpaceResourceLimit
-	rqs, err := goClient.GetClientSet().CoreV1().ResourceQuotas(req.Namespace).List(context.TODO(), metav1.ListOptions{})
+	rqs, err := goClient.GetClientSet().CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, item := range rqs.Items {
@@ -305,9 +475,9 @@ func importNs(c1 *gin.Context, req *NsImportItem) error {
 		}
 	}
 
-	lrs, err := goClient.GetClientSet().CoreV1().LimitRanges(req.Namespace).List(context.TODO(), metav1.ListOptions{})
+	lrs, err := goClient.GetClientSet().CoreV1().LimitRanges(namespace).List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, item := range lrs.Items {
@@ -341,30 +511,7 @@ func importNs(c1 *gin.Context, req *NsImportItem) error {
 		}
 	}
 
-	if srl != nil {
-		resSting, _ := json.Marshal(srl)
-		cu.SpaceResourceLimit = string(resSting)
-		_, err = service.Svc.ClusterUserSvc.Update(c1, cu)
-		if err != nil {
-			return err
-		}
-	}
-
-	for _, i := range req.Collaborator {
-		if cu.IsClusterAdmin() {
-			if err := cluster_scope.AsCooperator(cu.ClusterId, cu.UserId, i); err != nil {
-				errStr = errStr + fmt.Sprintf(",Error while adding %d as cluster cooperator", i)
-			}
-		} else if err := ns_scope.AsCooperator(cu.ClusterId, i, cu.Namespace); err != nil {
-			errStr = errStr + fmt.Sprintf(",Error while adding %d as cluster cooperator", i)
-		}
-	}
-
-	if errStr != "" {
-		errStr = strings.TrimLeft(errStr, ",")
-		return errors.New(errStr)
-	}
-	return nil
+	return srl, nil
 }
 
 func NsImport(c *gin.Context) {
@@ -0,0 +1,114 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+)
+
+// MeshRoute the traffic with the header is routed to the workloads
+// replaced by the mesh space
+type MeshRoute struct {
+	DevSpaceId uint64                         `json:"dev_space_id"`
+	SpaceName  string                         `json:"space_name"`
+	Namespace  string                         `json:"namespace"`
+	UserId     uint64                         `json:"user_id"`
+	Header     model.Header                   `json:"header"`
+	Workloads  []setupcluster.MeshDevWorkload `json:"workloads"`
+}
+
+// GetMeshRoutes Get the routing rules of base space
+// @Summary Get the routing rules of base space
+// @Description Get the header of each mesh space sharing the base space and the workloads it replaces, the rest of traffic goes to the base space
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "Base DevSpace ID"
+// @Success 200 {object} []cluster_user.MeshRoute
+// @Router /v1/dev_space/{id}/mesh_routes [get]
+func GetMeshRoutes(c *gin.Context) {
+	baseSpace, err := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if !baseSpace.IsBaseSpace {
+		api.SendResponse(c, errno.ErrNotBaseSpace, nil)
+		return
+	}
+
+	result := make([]*MeshRoute, 0)
+	shareSpaces, err := service.Svc.ClusterUserSvc.GetList(c, model.ClusterUserModel{BaseDevSpaceId: baseSpace.ID})
+	if err != nil {
+		// no mesh space shares the base space
+		api.SendResponse(c, nil, result)
+		return
+	}
+
+	cluster, err := service.Svc.ClusterSvc.GetCache(baseSpace.ClusterId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterNotFound, nil)
+		return
+	}
+	meshManager, err := setupcluster.GetSharedMeshManagerFactory().Manager(cluster.KubeConfig)
+	if err != nil {
+		log.Error(err)
+		api.SendResponse(c, errno.ErrGetDevSpaceAppInfo, nil)
+		return
+	}
+
+	for _, space := range shareSpaces {
+		route := &MeshRoute{
+			DevSpaceId: space.ID,
+			SpaceName:  space.SpaceName,
+			Namespace:  space.Namespace,
+			UserId:     space.UserId,
+			Header:     space.TraceHeader,
+			Workloads:  make([]setupcluster.MeshDevWorkload, 0),
+		}
+
+		apps, err := meshManager.GetAPPInfo(
+			&setupcluster.MeshDevInfo{BaseNamespace: baseSpace.Namespace, MeshDevNamespace: space.Namespace},
+		)
+		if err != nil {
+			log.Warnf("get apps of mesh space %d err: %v", space.ID, err)
+		}
+		for _, app := range apps {
+			for _, w := range app.Workloads {
+				if w.Status == setupcluster.Installed {
+					route.Workloads = append(route.Workloads, w)
+				}
+			}
+		}
+		result = append(result, route)
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// meshHeaderConflict returns true if another mesh space of the base space
+// routes the traffic with the same header
+func meshHeaderConflict(c *gin.Context, baseSpaceId, devSpaceId uint64, header model.Header) bool {
+	shareSpaces, err := service.Svc.ClusterUserSvc.GetList(c, model.ClusterUserModel{BaseDevSpaceId: baseSpaceId})
+	if err != nil {
+		return false
+	}
+	for _, space := range shareSpaces {
+		if space.ID != devSpaceId &&
+			space.TraceHeader.TraceKey == header.TraceKey && space.TraceHeader.TraceValue == header.TraceValue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/spacetemplate"
+)
+
+type RollbackRequest struct {
+	// Revision the revision of application to roll back to
+	Revision *int `json:"revision" binding:"required"`
+}
+
+// AppRollback the job rolling back the application, its status can be
+// queried as the installations of template
+type AppRollback struct {
+	JobName string `json:"job_name"`
+}
+
+// ListAppRevisions List revisions of the application in dev space
+// @Summary List revisions of the application in dev space
+// @Description List the installs, upgrades and rollbacks of the application recorded by nhctl, the latest first, with the chart version, manifest hash and who triggered it
+// @Tags DevSpace
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param name path string true "Application name"
+// @Success 200 {object} []model.RevisionInfo
+// @Router /v1/dev_space/{id}/applications/{name}/revisions [get]
+func ListAppRevisions(c *gin.Context) {
+	devSpace, goClient, errn := devSpaceAdminClient(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	revisions, err := spacetemplate.Revisions(goClient.GetClientSet(), devSpace.Namespace, c.Param("name"))
+	if err != nil {
+		log.Warnf("list revisions of %s in dev space %d err: %v", c.Param("name"), devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceAppRevision, nil)
+		return
+	}
+	api.SendResponse(c, nil, revisions)
+}
+
+// RollbackApp Rollback the application in dev space
+// @Summary Rollback the application in dev space
+// @Description Start a job of nhctl rolling back the application to a previous revision, the rollback is recorded as a new revision
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param name path string true "Application name"
+// @Param rollback body cluster_user.RollbackRequest true "The revision to roll back to"
+// @Success 200 {object} cluster_user.AppRollback
+// @Router /v1/dev_space/{id}/applications/{name}/rollback [post]
+func RollbackApp(c *gin.Context) {
+	var req RollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind rollback params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	devSpace, goClient, errn := devSpaceAdminClient(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	name := c.Param("name")
+	revisions, err := spacetemplate.Revisions(goClient.GetClientSet(), devSpace.Namespace, name)
+	if err != nil {
+		log.Warnf("list revisions of %s in dev space %d err: %v", name, devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceAppRevision, nil)
+		return
+	}
+	found := false
+	for _, revision := range revisions {
+		found = found || revision.Revision == *req.Revision
+	}
+	if !found {
+		api.SendResponse(c, errno.ErrDevSpaceAppRevision, nil)
+		return
+	}
+
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterNotFound, nil)
+		return
+	}
+	if err := ensureInstaller(goClient, &cluster, devSpace.Namespace); err != nil {
+		log.Warnf("create installer of dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceAppRollback, nil)
+		return
+	}
+
+	triggeredBy := ""
+	if loginUser, err := ginbase.LoginUser(c); err == nil {
+		if user, err := service.Svc.UserSvc.GetCache(loginUser); err == nil {
+			triggeredBy = user.Email
+		}
+	}
+	jobName, err := spacetemplate.Rollback(
+		goClient.GetClientSet(), devSpace.Namespace, name, *req.Revision, triggeredBy,
+	)
+	if err != nil {
+		log.Warnf("rollback %s in dev space %d err: %v", name, devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceAppRollback, nil)
+		return
+	}
+	api.SendResponse(c, nil, AppRollback{JobName: jobName})
+}
+
+// devSpaceAdminClient the dev space modifiable by login user, with the admin
+// client of its cluster
+func devSpaceAdminClient(c *gin.Context) (*model.ClusterUserModel, *clientgo.GoClient, error) {
+	devSpace, err := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		return nil, nil, err
+	}
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		return nil, nil, errno.ErrClusterNotFound
+	}
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		return nil, nil, errno.ErrClusterKubeErr
+	}
+	return devSpace, goClient, nil
+}
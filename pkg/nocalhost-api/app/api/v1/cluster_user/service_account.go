@@ -0,0 +1,287 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+
+	_const "nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/setupcluster"
+)
+
+type ServiceAccountCreateRequest struct {
+	Description string `json:"description" validate:"max=128"`
+	// Role of the service account, editor or viewer, default to editor
+	Role string `json:"role"`
+}
+
+// devSpaceSaClusterRoles the cluster role bound in namespace of each role,
+// the same as the ones bound to cooperators and viewers of dev space
+var devSpaceSaClusterRoles = map[string]string{
+	model.DevSpaceSaRoleEditor: _const.NocalhostDevRoleName,
+	model.DevSpaceSaRoleViewer: _const.NocalhostViewerRoleName,
+}
+
+type ServiceAccountKubeConfig struct {
+	ServiceAccount *model.DevSpaceSaModel `json:"service_account"`
+	KubeConfig     string                 `json:"kubeconfig"`
+}
+
+// ListServiceAccount List the service accounts of dev space
+// @Summary List the service accounts of dev space
+// @Description List the service accounts scoped to the namespace of dev space
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Success 200 {object} []model.DevSpaceSaModel
+// @Router /v1/dev_space/{id}/service_accounts [get]
+func ListServiceAccount(c *gin.Context) {
+	devSpace, errn := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	result, err := service.Svc.DevSpaceSaSvc.List(c, devSpace.ID)
+	if err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceSaNotFound, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// CreateServiceAccount Create a service account for dev space
+// @Summary Create a service account for dev space
+// @Description Create a service account bound to the namespace of dev space, returns the kubeconfig of it, viewer can only read the resources and port-forward
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param createRequest body cluster_user.ServiceAccountCreateRequest false "Service account info"
+// @Success 200 {object} cluster_user.ServiceAccountKubeConfig
+// @Router /v1/dev_space/{id}/service_accounts [post]
+func CreateServiceAccount(c *gin.Context) {
+	var req ServiceAccountCreateRequest
+	if err := c.ShouldBind(&req); err != nil && c.Request.ContentLength > 0 {
+		log.Warnf("create service account bind params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = model.DevSpaceSaRoleEditor
+	}
+	clusterRole, ok := devSpaceSaClusterRoles[req.Role]
+	if !ok {
+		api.SendResponse(c, errno.ErrDevSpaceSaRole, nil)
+		return
+	}
+
+	devSpace, cluster, errn := namespacedDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	loginUser, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		log.Error(err)
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	name := model.GenerateDevSpaceSaName()
+	if err := goClient.CreateNamespacedServiceAccount(name, devSpace.Namespace, clusterRole); err != nil {
+		log.Errorf("create service account %s/%s err: %v", devSpace.Namespace, name, err)
+		_ = goClient.DeleteNamespacedServiceAccount(name, devSpace.Namespace)
+		api.SendResponse(c, errno.ErrDevSpaceSaCreate, nil)
+		return
+	}
+
+	sa, err := service.Svc.DevSpaceSaSvc.Create(c, devSpace, loginUser, name, req.Description, req.Role)
+	if err != nil {
+		log.Error(err)
+		_ = goClient.DeleteNamespacedServiceAccount(name, devSpace.Namespace)
+		api.SendResponse(c, errno.ErrDevSpaceSaCreate, nil)
+		return
+	}
+
+	kubeConfig, err := serviceAccountKubeConfig(goClient, cluster, sa)
+	if err != nil {
+		log.Error(err)
+		api.SendResponse(c, errno.ErrDevSpaceSaCreate, nil)
+		return
+	}
+	api.SendResponse(c, nil, ServiceAccountKubeConfig{ServiceAccount: sa, KubeConfig: kubeConfig})
+}
+
+// RotateServiceAccount Rotate the token of service account
+// @Summary Rotate the token of service account
+// @Description Issue a new token for the service account, the kubeconfig issued before is invalid at once
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param sa_id path uint64 true "Service account ID"
+// @Success 200 {object} cluster_user.ServiceAccountKubeConfig
+// @Router /v1/dev_space/{id}/service_accounts/{sa_id}/rotate [post]
+func RotateServiceAccount(c *gin.Context) {
+	devSpace, cluster, errn := namespacedDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	sa, err := service.Svc.DevSpaceSaSvc.Get(c, devSpace.ID, cast.ToUint64(c.Param("sa_id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceSaNotFound, nil)
+		return
+	}
+
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		log.Error(err)
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	if err := goClient.RotateServiceAccountToken(sa.Name, sa.Namespace); err != nil {
+		log.Errorf("rotate service account %s/%s err: %v", sa.Namespace, sa.Name, err)
+		api.SendResponse(c, errno.ErrDevSpaceSaRotate, nil)
+		return
+	}
+
+	if err := service.Svc.DevSpaceSaSvc.Rotated(c, sa.ID); err != nil {
+		log.Error(err)
+	}
+
+	kubeConfig, err := serviceAccountKubeConfig(goClient, cluster, sa)
+	if err != nil {
+		log.Error(err)
+		api.SendResponse(c, errno.ErrDevSpaceSaRotate, nil)
+		return
+	}
+	api.SendResponse(c, nil, ServiceAccountKubeConfig{ServiceAccount: sa, KubeConfig: kubeConfig})
+}
+
+// RevokeServiceAccount Revoke the service account of dev space
+// @Summary Revoke the service account of dev space
+// @Description Delete the service account with its token and role binding
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param sa_id path uint64 true "Service account ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_space/{id}/service_accounts/{sa_id} [delete]
+func RevokeServiceAccount(c *gin.Context) {
+	devSpace, cluster, errn := namespacedDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	sa, err := service.Svc.DevSpaceSaSvc.Get(c, devSpace.ID, cast.ToUint64(c.Param("sa_id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceSaNotFound, nil)
+		return
+	}
+
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		log.Error(err)
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	if err := goClient.DeleteNamespacedServiceAccount(sa.Name, sa.Namespace); err != nil {
+		log.Errorf("delete service account %s/%s err: %v", sa.Namespace, sa.Name, err)
+		api.SendResponse(c, errno.ErrDevSpaceSaRevoke, nil)
+		return
+	}
+
+	if err := service.Svc.DevSpaceSaSvc.Revoke(c, sa.ID); err != nil {
+		log.Error(err)
+		api.SendResponse(c, errno.ErrDevSpaceSaRevoke, nil)
+		return
+	}
+	api.SendResponse(c, errno.OK, nil)
+}
+
+// namespacedDevSpace returns the dev space the login user can modify, and the cluster of it
+func namespacedDevSpace(c *gin.Context) (*model.ClusterUserModel, *model.ClusterModel, error) {
+	devSpace, errn := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if errn != nil {
+		return nil, nil, errn
+	}
+
+	if devSpace.IsClusterAdmin() || devSpace.Namespace == "" {
+		return nil, nil, errno.ErrDevSpaceSaClusterScope
+	}
+
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		return nil, nil, errno.ErrClusterNotFound
+	}
+	return devSpace, &cluster, nil
+}
+
+// serviceAccountKubeConfig assemble the kubeconfig of service account, the cluster
+// part is taken from the kubeconfig of cluster like the one of user's service account
+func serviceAccountKubeConfig(
+	goClient *clientgo.GoClient, cluster *model.ClusterModel, sa *model.DevSpaceSaModel,
+) (string, error) {
+	secret, err := goClient.WaitServiceAccountToken(sa.Name, sa.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	reader := setupcluster.NewDevKubeConfigReader(secret, cluster.GetClusterServer(), sa.Namespace)
+	reader.GetCA().GetToken().AssembleDevKubeConfig()
+	kubeConfigStruct, err, _ := reader.ToStruct()
+	if err != nil {
+		return "", err
+	}
+
+	if hostConfig, err := clientcmd.Load([]byte(cluster.GetKubeConfig())); err == nil {
+		if hostCtx := hostConfig.Contexts[hostConfig.CurrentContext]; hostCtx != nil {
+			if hostCluster := hostConfig.Clusters[hostCtx.Cluster]; hostCluster != nil {
+				v1Cluster := clientcmdapiv1.Cluster{}
+				if err := clientcmdapiv1.Convert_api_Cluster_To_v1_Cluster(hostCluster, &v1Cluster, nil); err == nil {
+					kubeConfigStruct.Clusters[0].Cluster = v1Cluster
+				}
+			}
+		}
+	}
+
+	if extraApiServer := cluster.GetExtraApiServer(); extraApiServer != "" {
+		kubeConfigStruct.Clusters[0].Cluster.Server = extraApiServer
+	}
+
+	kubeConfig, err, _ := reader.ToYamlString()
+	return kubeConfig, err
+}
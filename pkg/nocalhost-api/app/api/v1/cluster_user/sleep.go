@@ -0,0 +1,126 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/sleep"
+)
+
+// UpdateSleepConfig Update the sleep config of dev space
+// @Summary Update the sleep config of dev space
+// @Description Update the schedules and inactive hours to sleep, the workloads of dev space are scaled to zero when sleeping, empty config disables sleeping
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Param sleepConfig body sleep.Config true "The sleep config"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_space/{id}/sleep_config [put]
+func UpdateSleepConfig(c *gin.Context) {
+	var req sleep.Config
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind sleep config params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceSleepConfig, err.Error())
+		return
+	}
+
+	devSpace, errn := sleepableDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	config := ""
+	if !req.IsEmpty() {
+		b, _ := json.Marshal(req)
+		config = string(b)
+	}
+	if err := service.Svc.ClusterUserSvc.UpdateColumns(
+		c, devSpace.ID, map[string]interface{}{"sleep_config": config},
+	); err != nil {
+		log.Warnf("update sleep config of dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, nil)
+}
+
+// Sleep Sleep the dev space
+// @Summary Sleep the dev space
+// @Description Scale the deployments and statefulsets of dev space to zero and record their replicas
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_space/{id}/sleep [post]
+func Sleep(c *gin.Context) {
+	devSpace, errn := sleepableDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	if err := service.SleepDevSpace(c, devSpace, sleep.ReasonManual); err != nil {
+		log.Warnf("sleep dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceSleep, nil)
+		return
+	}
+	api.SendResponse(c, nil, nil)
+}
+
+// Wakeup Wake up the dev space
+// @Summary Wake up the dev space
+// @Description Restore the replicas of workloads recorded when the dev space sleeps, it keeps awake until the next schedule window
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_space/{id}/wakeup [post]
+func Wakeup(c *gin.Context) {
+	devSpace, errn := sleepableDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	if err := service.WakeDevSpace(c, devSpace); err != nil {
+		log.Warnf("wake up dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceWakeup, nil)
+		return
+	}
+	api.SendResponse(c, nil, nil)
+}
+
+// sleepableDevSpace cluster scope dev space can not sleep
+func sleepableDevSpace(c *gin.Context) (*model.ClusterUserModel, error) {
+	devSpace, err := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		return nil, err
+	}
+	if devSpace.IsClusterAdmin() || devSpace.Namespace == "" || devSpace.Namespace == "*" {
+		return nil, errno.ErrDevSpaceSleepConfig
+	}
+	return devSpace, nil
+}
@@ -0,0 +1,238 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/sleep"
+	"nocalhost/pkg/nocalhost-api/pkg/snapshot"
+)
+
+// DevSpaceDefinition the definition of dev space, it can be recreated
+// later or on another cluster
+type DevSpaceDefinition struct {
+	SpaceName          string              `json:"space_name"`
+	ClusterId          uint64              `json:"cluster_id"`
+	SpaceResourceLimit *SpaceResourceLimit `json:"space_resource_limit"`
+	SleepConfig        *sleep.Config       `json:"sleep_config"`
+	Isolated           bool                `json:"isolated"`
+	Snapshot           *snapshot.Snapshot  `json:"snapshot"`
+}
+
+type CloneRequest struct {
+	// UserId the owner of new dev space
+	UserId *uint64 `json:"user_id" binding:"required"`
+	// ClusterId default to the cluster of dev space cloned
+	ClusterId *uint64 `json:"cluster_id"`
+	SpaceName string  `json:"space_name"`
+}
+
+type RestoreRequest struct {
+	ClusterId  *uint64             `json:"cluster_id" binding:"required"`
+	UserId     *uint64             `json:"user_id" binding:"required"`
+	SpaceName  string              `json:"space_name"`
+	Definition *DevSpaceDefinition `json:"definition" binding:"required"`
+}
+
+// GetSnapshot Snapshot the dev space
+// @Summary Snapshot the dev space
+// @Description Export the definition of dev space and the resources in its namespace, which can be restored later or on another cluster
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Success 200 {object} cluster_user.DevSpaceDefinition
+// @Router /v1/dev_space/{id}/snapshot [get]
+func GetSnapshot(c *gin.Context) {
+	devSpace, errn := snapshotableDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	definition, err := snapshotDevSpace(devSpace)
+	if err != nil {
+		log.Warnf("snapshot dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceSnapshot, nil)
+		return
+	}
+	api.SendResponse(c, nil, definition)
+}
+
+// Clone Clone the dev space
+// @Summary Clone the dev space
+// @Description Create a new dev space for the user with the resource limit, sleep config and resources of the dev space, mesh settings are not cloned
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Param clone body cluster_user.CloneRequest true "The new dev space"
+// @Success 200 {object} model.ClusterUserModel
+// @Router /v1/dev_space/{id}/clone [post]
+func Clone(c *gin.Context) {
+	var req CloneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind clone params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	devSpace, errn := snapshotableDevSpace(c)
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	definition, err := snapshotDevSpace(devSpace)
+	if err != nil {
+		log.Warnf("snapshot dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceSnapshot, nil)
+		return
+	}
+
+	clusterId := devSpace.ClusterId
+	if req.ClusterId != nil {
+		clusterId = *req.ClusterId
+	}
+	result, err := restoreDevSpace(c, clusterId, *req.UserId, req.SpaceName, definition)
+	api.SendResponse(c, err, result)
+}
+
+// Restore Restore the dev space from snapshot
+// @Summary Restore the dev space from snapshot
+// @Description Create a new dev space for the user from the definition exported by snapshot
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param restore body cluster_user.RestoreRequest true "The snapshot and new dev space"
+// @Success 200 {object} model.ClusterUserModel
+// @Router /v1/dev_space/restore [post]
+func Restore(c *gin.Context) {
+	var req RestoreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind restore params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	result, err := restoreDevSpace(c, *req.ClusterId, *req.UserId, req.SpaceName, req.Definition)
+	api.SendResponse(c, err, result)
+}
+
+// snapshotableDevSpace cluster scope dev space can not be snapshot
+func snapshotableDevSpace(c *gin.Context) (*model.ClusterUserModel, error) {
+	devSpace, err := LoginUserHasModifyPermissionToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		return nil, err
+	}
+	if devSpace.IsClusterAdmin() || devSpace.Namespace == "" || devSpace.Namespace == "*" {
+		return nil, errno.ErrDevSpaceSnapshot
+	}
+	return devSpace, nil
+}
+
+func snapshotDevSpace(devSpace *model.ClusterUserModel) (*DevSpaceDefinition, error) {
+	definition := &DevSpaceDefinition{
+		SpaceName: devSpace.SpaceName,
+		ClusterId: devSpace.ClusterId,
+		Isolated:  devSpace.Isolated,
+	}
+	if devSpace.SpaceResourceLimit != "" {
+		definition.SpaceResourceLimit = &SpaceResourceLimit{}
+		_ = json.Unmarshal([]byte(devSpace.SpaceResourceLimit), definition.SpaceResourceLimit)
+	}
+	if devSpace.SleepConfig != "" {
+		definition.SleepConfig = &sleep.Config{}
+		_ = json.Unmarshal([]byte(devSpace.SleepConfig), definition.SleepConfig)
+	}
+
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+	goClient, err := clientgo.NewAdminGoClient([]byte(cluster.KubeConfig))
+	if err != nil {
+		return nil, err
+	}
+	if definition.Snapshot, err = snapshot.Export(goClient.DynamicClient, devSpace.Namespace); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// restoreDevSpace create the dev space as Create does, then apply the
+// settings and resources of definition, the dev space is kept and returned
+// even if some resources failed to apply
+func restoreDevSpace(
+	c *gin.Context, clusterId, userId uint64, spaceName string, definition *DevSpaceDefinition,
+) (*model.ClusterUserModel, error) {
+	if definition.SleepConfig != nil {
+		if err := definition.SleepConfig.Validate(); err != nil {
+			return nil, errno.ErrDevSpaceSleepConfig
+		}
+	}
+
+	zero := uint64(0)
+	req := ClusterUserCreateRequest{
+		ClusterId:          &clusterId,
+		UserId:             &userId,
+		SpaceName:          spaceName,
+		Memory:             &zero,
+		Cpu:                &zero,
+		ApplicationId:      &zero,
+		SpaceResourceLimit: definition.SpaceResourceLimit,
+		Isolated:           definition.Isolated,
+	}
+	if _, errn := req.Validate(); errn != nil {
+		return nil, errn
+	}
+
+	result, err := NewDevSpace(req, c, []byte{}).Create()
+	if err != nil {
+		return nil, err
+	}
+	setDefaultDevSpaceTtl(c, result)
+
+	if definition.SleepConfig != nil && !definition.SleepConfig.IsEmpty() {
+		b, _ := json.Marshal(definition.SleepConfig)
+		if err := service.Svc.ClusterUserSvc.UpdateColumns(
+			c, result.ID, map[string]interface{}{"sleep_config": string(b)},
+		); err != nil {
+			log.Warnf("restore sleep config of dev space %d err: %v", result.ID, err)
+		}
+		result.SleepConfig = string(b)
+	}
+
+	if definition.Snapshot == nil {
+		return result, nil
+	}
+	cluster, err := service.Svc.ClusterSvc.GetCache(result.ClusterId)
+	if err != nil {
+		return result, errno.ErrDevSpaceRestore
+	}
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		return result, errno.ErrDevSpaceRestore
+	}
+	if err := snapshot.Apply(goClient.DynamicClient, result.Namespace, definition.Snapshot); err != nil {
+		log.Warnf("restore resources of dev space %d err: %v", result.ID, err)
+		return result, errno.ErrDevSpaceRestore
+	}
+	return result, nil
+}
@@ -0,0 +1,255 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nhctl/const"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/spacetemplate"
+)
+
+type CreateFromTemplateRequest struct {
+	TemplateId *uint64 `json:"template_id" binding:"required"`
+	ClusterId  *uint64 `json:"cluster_id" binding:"required"`
+	UserId     *uint64 `json:"user_id" binding:"required"`
+	SpaceName  string  `json:"space_name"`
+}
+
+// DevSpaceFromTemplate the dev space created and the installations of the
+// applications of template
+type DevSpaceFromTemplate struct {
+	DevSpace *model.ClusterUserModel          `json:"dev_space"`
+	Installs []*model.DevSpaceAppInstallModel `json:"installs"`
+}
+
+// CreateFromTemplate Create dev space from template
+// @Summary Create dev space from template
+// @Description Create dev space with the resource limit, env vars and secrets of template, then install the applications of template in it, the progress can be queried by /v1/dev_space/{id}/app_installs
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param template body cluster_user.CreateFromTemplateRequest true "The template and new dev space"
+// @Success 200 {object} cluster_user.DevSpaceFromTemplate
+// @Router /v1/dev_space/from_template [post]
+func CreateFromTemplate(c *gin.Context) {
+	var req CreateFromTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind create from template params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	template, err := service.Svc.DevSpaceTemplateSvc.Get(c, *req.TemplateId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceTemplateNotFound, nil)
+		return
+	}
+
+	zero := uint64(0)
+	createReq := ClusterUserCreateRequest{
+		ClusterId:     req.ClusterId,
+		UserId:        req.UserId,
+		SpaceName:     req.SpaceName,
+		Memory:        &zero,
+		Cpu:           &zero,
+		ApplicationId: &zero,
+	}
+	if template.SpaceResourceLimit != "" {
+		createReq.SpaceResourceLimit = &SpaceResourceLimit{}
+		_ = json.Unmarshal([]byte(template.SpaceResourceLimit), createReq.SpaceResourceLimit)
+	}
+	if _, errn := createReq.Validate(); errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	devSpace, err := NewDevSpace(createReq, c, []byte{}).Create()
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	setDefaultDevSpaceTtl(c, devSpace)
+
+	// the dev space is kept and returned if the template failed to apply,
+	// so that user can fix it by hand
+	installs, err := applyTemplate(c, devSpace, template)
+	if err != nil {
+		log.Warnf("apply template %d to dev space %d err: %v", template.ID, devSpace.ID, err)
+		api.SendResponse(c, errno.ErrDevSpaceTemplateApply, DevSpaceFromTemplate{DevSpace: devSpace, Installs: installs})
+		return
+	}
+	api.SendResponse(c, nil, DevSpaceFromTemplate{DevSpace: devSpace, Installs: installs})
+}
+
+// ListAppInstalls List installations of the applications of template
+// @Summary List installations of the applications of template
+// @Description List the applications of template installing in dev space, with the status of pending, installing, installed or failed
+// @Tags DevSpace
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Success 200 {object} []model.DevSpaceAppInstallModel
+// @Router /v1/dev_space/{id}/app_installs [get]
+func ListAppInstalls(c *gin.Context) {
+	devSpace, errn := HasPrivilegeToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+
+	installs, err := service.Svc.DevSpaceTemplateSvc.ListInstalls(c, devSpace.ID)
+	if err != nil {
+		log.Warnf("list app installs of dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	var goClient *clientgo.GoClient
+	for _, install := range installs {
+		// the queued ones are started by bulk deployment
+		if install.Status == spacetemplate.StatusInstalled || install.Status == spacetemplate.StatusFailed ||
+			install.Status == spacetemplate.StatusQueued {
+			continue
+		}
+		if goClient == nil {
+			cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+			if err != nil {
+				api.SendResponse(c, errno.ErrClusterNotFound, nil)
+				return
+			}
+			if goClient, err = clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig)); err != nil {
+				api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+				return
+			}
+		}
+
+		status, message := spacetemplate.InstallStatus(
+			goClient.GetClientSet(), devSpace.Namespace, install.JobName, install.ApplicationName,
+		)
+		updateInstall(c, install, status, message)
+	}
+	api.SendResponse(c, nil, installs)
+}
+
+// applyTemplate save the env vars, copy the secrets and start the jobs
+// installing the applications of template in the namespace of dev space,
+// an application failed to start is recorded and the others go on
+func applyTemplate(
+	c *gin.Context, devSpace *model.ClusterUserModel, template *model.DevSpaceTemplateModel,
+) ([]*model.DevSpaceAppInstallModel, error) {
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		return nil, err
+	}
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		return nil, err
+	}
+	client := goClient.GetClientSet()
+
+	if template.Env != "" {
+		env := map[string]string{}
+		_ = json.Unmarshal([]byte(template.Env), &env)
+		if err := spacetemplate.ApplyEnv(client, devSpace.Namespace, env); err != nil {
+			return nil, err
+		}
+	}
+
+	if template.Secrets != "" {
+		var secrets []spacetemplate.SecretRef
+		_ = json.Unmarshal([]byte(template.Secrets), &secrets)
+		if err := spacetemplate.CopySecrets(client, devSpace.Namespace, secrets); err != nil {
+			return nil, err
+		}
+	}
+
+	var applications []uint64
+	if template.Applications != "" {
+		_ = json.Unmarshal([]byte(template.Applications), &applications)
+	}
+	if len(applications) == 0 {
+		return nil, nil
+	}
+
+	if err := ensureInstaller(goClient, &cluster, devSpace.Namespace); err != nil {
+		return nil, err
+	}
+
+	installs := make([]*model.DevSpaceAppInstallModel, 0, len(applications))
+	for _, id := range applications {
+		install := &model.DevSpaceAppInstallModel{
+			DevSpaceId:    devSpace.ID,
+			TemplateId:    template.ID,
+			ApplicationId: id,
+			Status:        spacetemplate.StatusPending,
+		}
+		installs = append(installs, install)
+
+		if application, err := service.Svc.ApplicationSvc.Get(c, id); err != nil {
+			install.Status, install.Message = spacetemplate.StatusFailed, "application not found"
+		} else if app, err := spacetemplate.ParseApplication(application.ID, application.Context); err != nil {
+			install.Status, install.Message = spacetemplate.StatusFailed, err.Error()
+		} else {
+			install.ApplicationName = app.Name
+			if install.JobName, err = startInstall(c, client, devSpace.Namespace, app); err != nil {
+				install.Status, install.Message = spacetemplate.StatusFailed, err.Error()
+			}
+		}
+
+		if err := service.Svc.DevSpaceTemplateSvc.CreateInstall(c, install); err != nil {
+			log.Warnf("create app install of dev space %d err: %v", devSpace.ID, err)
+		}
+	}
+	return installs, nil
+}
+
+// ensureInstaller create the service account of installer with its kubeconfig
+// for the jobs of nhctl, the installer is allowed to access the namespace of
+// dev space only
+func ensureInstaller(goClient *clientgo.GoClient, cluster *model.ClusterModel, namespace string) error {
+	if err := goClient.CreateNamespacedServiceAccount(
+		spacetemplate.InstallerServiceAccount, namespace, _const.NocalhostDevRoleName,
+	); err != nil {
+		return err
+	}
+	kubeConfig, err := serviceAccountKubeConfig(
+		goClient, cluster, &model.DevSpaceSaModel{Name: spacetemplate.InstallerServiceAccount, Namespace: namespace},
+	)
+	if err != nil {
+		return err
+	}
+	return spacetemplate.EnsureInstallerKubeConfig(goClient.GetClientSet(), namespace, kubeConfig)
+}
+
+// startInstall start the job installing the application with its git credential
+func startInstall(
+	c context.Context, client kubernetes.Interface, namespace string, app *spacetemplate.Application,
+) (string, error) {
+	return spacetemplate.Install(client, namespace, app, appCredential(c, app))
+}
+
+// appCredential the credential of application, nil if the repo is public
+func appCredential(c context.Context, app *spacetemplate.Application) *spacetemplate.Credential {
+	if app.CredentialId > 0 {
+		if gc, err := service.Svc.GitCredentialSvc.Get(c, app.CredentialId); err == nil {
+			return &spacetemplate.Credential{Type: gc.Type, Username: gc.Username, Secret: gc.Secret}
+		}
+	}
+	return nil
+}
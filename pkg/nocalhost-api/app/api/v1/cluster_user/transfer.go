@@ -0,0 +1,124 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/cooperator/ns_scope"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+type TransferRequest struct {
+	// UserId the new owner of dev space
+	UserId *uint64 `json:"user_id" binding:"required"`
+}
+
+// Transfer Transfer the dev space to another user
+// @Summary Transfer the dev space to another user
+// @Description Transfer the ownership of dev space with its applications, the role binding of the owner's service account is moved to the new owner's, the applications installed and the service accounts issued stay in the namespace
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "DevSpace ID"
+// @Param transfer body cluster_user.TransferRequest true "The new owner"
+// @Success 200 {object} model.ClusterUserModel
+// @Router /v1/dev_space/{id}/transfer [post]
+func Transfer(c *gin.Context) {
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind transfer params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	devSpace, errn := HasPrivilegeToSomeDevSpace(c, cast.ToUint64(c.Param("id")))
+	if errn != nil {
+		api.SendResponse(c, errn, nil)
+		return
+	}
+	if devSpace.UserId == *req.UserId {
+		api.SendResponse(c, errno.ErrDevSpaceTransferOwner, nil)
+		return
+	}
+	if _, err := service.Svc.UserSvc.GetCache(*req.UserId); err != nil {
+		api.SendResponse(c, errno.ErrUserNotFound, nil)
+		return
+	}
+
+	if err := transferDevSpace(c, devSpace, *req.UserId); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+
+	result, err := service.Svc.ClusterUserSvc.GetCache(devSpace.ID)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterUserNotFound, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// transferDevSpace grant the new owner first, then switch the owner of record
+// and revoke the previous one, the steps done are rolled back if any failed,
+// so that the dev space is always owned by one of them
+func transferDevSpace(c *gin.Context, devSpace *model.ClusterUserModel, userId uint64) error {
+	previous := devSpace.UserId
+	grant, revoke := service.Svc.AuthorizeNsToUser, service.Svc.UnAuthorizeNsToUser
+	if devSpace.IsClusterAdmin() {
+		grant = func(clusterId, userId uint64, _ string) error {
+			return service.Svc.AuthorizeClusterToUser(clusterId, userId)
+		}
+		revoke = func(clusterId, userId uint64, _ string) error {
+			return service.Svc.UnAuthorizeClusterToUser(clusterId, userId)
+		}
+	}
+
+	if err := grant(devSpace.ClusterId, userId, devSpace.Namespace); err != nil {
+		log.Warnf("grant dev space %d to user %d err: %v", devSpace.ID, userId, err)
+		return errno.ErrDevSpaceTransfer
+	}
+
+	if err := service.Svc.ClusterUserSvc.UpdateColumns(
+		c, devSpace.ID, map[string]interface{}{"user_id": userId},
+	); err != nil {
+		log.Warnf("transfer dev space %d to user %d err: %v", devSpace.ID, userId, err)
+		_ = revoke(devSpace.ClusterId, userId, devSpace.Namespace)
+		return errno.ErrDevSpaceTransfer
+	}
+
+	if err := revoke(devSpace.ClusterId, previous, devSpace.Namespace); err != nil {
+		log.Warnf("revoke dev space %d from user %d err: %v", devSpace.ID, previous, err)
+		if err := service.Svc.ClusterUserSvc.UpdateColumns(
+			c, devSpace.ID, map[string]interface{}{"user_id": previous},
+		); err != nil {
+			log.Errorf("roll back owner of dev space %d err: %v", devSpace.ID, err)
+		}
+		_ = revoke(devSpace.ClusterId, userId, devSpace.Namespace)
+		return errno.ErrDevSpaceTransfer
+	}
+
+	// the new owner is not a cooperator or viewer of the namespace any more
+	if !devSpace.IsClusterAdmin() {
+		if err := ns_scope.RemoveFromCooperator(devSpace.ClusterId, userId, devSpace.Namespace); err != nil {
+			log.Warnf("remove user %d from cooperators of dev space %d err: %v", userId, devSpace.ID, err)
+		}
+		if err := ns_scope.RemoveFromViewer(devSpace.ClusterId, userId, devSpace.Namespace); err != nil {
+			log.Warnf("remove user %d from viewers of dev space %d err: %v", userId, devSpace.ID, err)
+		}
+	}
+
+	if devSpace.ApplicationId > 0 {
+		_ = service.Svc.ApplicationUserSvc.BatchInsert(c, devSpace.ApplicationId, []uint64{userId})
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/leader"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/mail"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/notify"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+	"nocalhost/pkg/nocalhost-api/pkg/ttl"
+)
+
+type TtlRequest struct {
+	// Ttl from now, such as 72h or 7d, empty means never expire
+	Ttl string `json:"ttl"`
+}
+
+// UpdateTtl Update the ttl of dev space
+// @Summary Update the ttl of dev space
+// @Description Update the ttl of dev space from now, the dev space is deleted when expired, empty ttl means never expire
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Param ttl body cluster_user.TtlRequest true "The ttl"
+// @Success 200 {object} model.ClusterUserModel
+// @Router /v1/dev_space/{id}/ttl [put]
+func UpdateTtl(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req TtlRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind ttl params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	devSpace, err := service.Svc.ClusterUserSvc.GetCache(cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterUserNotFound, nil)
+		return
+	}
+	if devSpace.IsClusterAdmin() {
+		api.SendResponse(c, errno.ErrDevSpaceTtl, "cluster scope dev space does not support ttl")
+		return
+	}
+
+	var d time.Duration
+	if req.Ttl != "" {
+		if d, err = ttl.Parse(req.Ttl); err != nil {
+			api.SendResponse(c, errno.ErrDevSpaceTtl, err.Error())
+			return
+		}
+	}
+
+	if err := setDevSpaceTtl(c, &devSpace, d); err != nil {
+		log.Warnf("update ttl of dev space %d err: %v", devSpace.ID, err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, devSpace)
+}
+
+// setDevSpaceTtl the dev space expires after d from now, never if d is 0
+func setDevSpaceTtl(c context.Context, devSpace *model.ClusterUserModel, d time.Duration) error {
+	var expireAt *time.Time
+	if d > 0 {
+		t := time.Now().Add(d)
+		expireAt = &t
+	}
+	if err := service.Svc.ClusterUserSvc.UpdateColumns(
+		c, devSpace.ID, map[string]interface{}{
+			"expire_at":        expireAt,
+			"expiry_warned_at": nil,
+		},
+	); err != nil {
+		return err
+	}
+	devSpace.ExpireAt = expireAt
+	devSpace.ExpiryWarnedAt = nil
+	return nil
+}
+
+// setDefaultDevSpaceTtl the dev space expires after the default ttl of
+// cluster, if the cluster has one
+func setDefaultDevSpaceTtl(c context.Context, devSpace *model.ClusterUserModel) {
+	if devSpace.IsClusterAdmin() {
+		return
+	}
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		return
+	}
+	if d := ttl.ClusterDefault(cluster.Annotations); d > 0 {
+		if err := setDevSpaceTtl(c, devSpace, d); err != nil {
+			log.Warnf("set ttl of dev space %d err: %v", devSpace.ID, err)
+		}
+	}
+}
+
+var reaperOnce = sync.Once{}
+
+// StartReaper warn the owners of dev spaces expiring and delete the ones
+// expired periodically
+func StartReaper() {
+	go reaperOnce.Do(
+		func() {
+			tick := time.NewTicker(ttl.CheckInterval())
+			defer tick.Stop()
+
+			for shutdown.Run(leader.Only(ReapDevSpaces)) {
+				select {
+				case <-tick.C:
+				case <-shutdown.Stopping():
+					return
+				}
+			}
+		},
+	)
+}
+
+// ReapDevSpaces delete the dev spaces expired, or idle for
+// dev_space_ttl.idle since the last activity, protected ones are kept
+func ReapDevSpaces() {
+	defer metrics.ObserveJob("dev_space_reap")()
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("Panic while reaping dev spaces: %v", err)
+		}
+	}()
+
+	// the reaper has no request
+	c := &gin.Context{}
+	devSpaces, _ := service.Svc.ClusterUserSvc.GetList(c, model.ClusterUserModel{})
+	now := time.Now()
+	idle := ttl.Idle()
+	for _, devSpace := range devSpaces {
+		if devSpace.IsClusterAdmin() {
+			continue
+		}
+		expireAt, warnedAt := ttl.Effective(devSpace.ExpireAt, devSpace.ExpiryWarnedAt, devSpace.LastActiveAt(), idle)
+		if expireAt == nil {
+			continue
+		}
+
+		if ttl.ShouldWarn(now, *expireAt, warnedAt) {
+			warnExpiry(c, devSpace, *expireAt)
+			continue
+		}
+
+		if now.Before(*expireAt) {
+			continue
+		}
+		if devSpace.Protected {
+			log.Warnf("Dev space %d(%s) expired but protected, skip deleting", devSpace.ID, devSpace.Namespace)
+			continue
+		}
+
+		log.Infof("Dev space %d(%s) expired at %v, deleting", devSpace.ID, devSpace.Namespace, *expireAt)
+		if err := deleteDevSpace(c, devSpace); err != nil {
+			log.Errorf("Failed to delete dev space %d expired: %v", devSpace.ID, err)
+		}
+	}
+}
+
+// warnExpiry mail the owner if mail server is configured, it is only
+// marked as warned otherwise
+func warnExpiry(c context.Context, devSpace *model.ClusterUserModel, expireAt time.Time) {
+	if mail.Enabled() {
+		owner, err := service.Svc.UserSvc.GetCache(devSpace.UserId)
+		if err != nil {
+			log.Warnf("get owner of dev space %d err: %v", devSpace.ID, err)
+			return
+		}
+		if err := mail.Send(
+			owner.Email, fmt.Sprintf("Your Nocalhost dev space %s is expiring", devSpace.SpaceName),
+			fmt.Sprintf(
+				"Hi %s,\r\n\r\nYour dev space %s (namespace %s) expires at %s, "+
+					"it will be deleted with all the resources in it.\r\n\r\n"+
+					"Please contact the administrator to extend the ttl if you still need it.",
+				owner.Name, devSpace.SpaceName, devSpace.Namespace, expireAt.Format(time.RFC3339),
+			),
+		); err != nil {
+			log.Warnf("mail expiry of dev space %d err: %v", devSpace.ID, err)
+			return
+		}
+	}
+
+	notify.Notify(
+		notify.DevSpaceExpiring, map[string]interface{}{
+			"id":         devSpace.ID,
+			"space_name": devSpace.SpaceName,
+			"namespace":  devSpace.Namespace,
+			"user_id":    devSpace.UserId,
+			"expire_at":  expireAt,
+		}, "Dev space %s (namespace %s) expires at %s",
+		devSpace.SpaceName, devSpace.Namespace, expireAt.Format(time.RFC3339),
+	)
+
+	now := time.Now()
+	if err := service.Svc.ClusterUserSvc.UpdateColumns(
+		c, devSpace.ID, map[string]interface{}{"expiry_warned_at": &now},
+	); err != nil {
+		log.Warnf("mark expiry warned of dev space %d err: %v", devSpace.ID, err)
+	}
+}
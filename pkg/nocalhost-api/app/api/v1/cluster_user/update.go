@@ -14,7 +14,9 @@ import (
 
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/quota"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
 	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
@@ -112,6 +114,22 @@ func UpdateResourceLimit(c *gin.Context) {
 		return
 	}
 
+	// the owner's quota limits the resource of dev space
+	if !ginbase.IsAdmin(c) {
+		q, err := service.Svc.QuotaSvc.Effective(c, devspace.UserId)
+		if err != nil {
+			log.Warnf("get quota err: %v", err)
+			api.SendResponse(c, errno.InternalServerError, nil)
+			return
+		}
+
+		cpu, memory := req.Limits()
+		if quota.CheckSpaceResource(q, cpu, memory) != nil {
+			api.SendResponse(c, errno.ErrSpaceResourceExceed, nil)
+			return
+		}
+	}
+
 	// Build goclient with administrator kubeconfig
 	clusterData, err := service.Svc.ClusterSvc.Get(c, devspace.ClusterId)
 	if err != nil {
@@ -120,7 +138,7 @@ func UpdateResourceLimit(c *gin.Context) {
 		return
 	}
 	var KubeConfig = []byte(clusterData.KubeConfig)
-	goClient, err := clientgo.NewAdminGoClient(KubeConfig)
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, KubeConfig)
 
 	// get client go and check if is admin Kubeconfig
 	if err != nil {
@@ -222,6 +240,11 @@ func UpdateMeshDevSpaceInfo(c *gin.Context) {
 		return
 	}
 
+	if meshHeaderConflict(c, devspace.BaseDevSpaceId, devspace.ID, req.Header) {
+		api.SendResponse(c, errno.ErrMeshHeaderConflict, nil)
+		return
+	}
+
 	info := req
 	info.MeshDevNamespace = devspace.Namespace
 	info.BaseNamespace = basespace.Namespace
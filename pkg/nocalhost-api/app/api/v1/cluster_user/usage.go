@@ -0,0 +1,69 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cluster_user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/usage"
+)
+
+// GetUsage Get the resource usage of dev space
+// @Summary Get the resource usage of dev space
+// @Description Aggregate the cpu/memory requests, limits and real usage (via metrics-server) of the pods in dev space
+// @Tags DevSpace
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path string true "DevSpace ID"
+// @Success 200 {object} usage.Namespace
+// @Router /v1/dev_space/{id}/usage [get]
+func GetUsage(c *gin.Context) {
+	devSpaceId := cast.ToUint64(c.Param("id"))
+	devSpace, err := LoginUserHasModifyPermissionToSomeDevSpace(c, devSpaceId)
+	if err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+
+	if devSpace.Namespace == "" || devSpace.Namespace == "*" {
+		api.SendResponse(c, errno.ErrDevSpaceUsageClusterScope, nil)
+		return
+	}
+
+	cluster, err := service.Svc.ClusterSvc.GetCache(devSpace.ClusterId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterNotFound, nil)
+		return
+	}
+
+	goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(cluster.KubeConfig))
+	if err != nil {
+		api.SendResponse(c, errno.ErrClusterKubeErr, nil)
+		return
+	}
+
+	pods, err := goClient.ListPods(devSpace.Namespace)
+	if err != nil {
+		log.Warnf("get pods of dev space %d err: %v", devSpaceId, err)
+		api.SendResponse(c, errno.ErrClusterUsage, nil)
+		return
+	}
+
+	// real usage is left zero if metrics-server is unavailable
+	podMetrics, err := goClient.ListPodMetrics(devSpace.Namespace)
+	if err != nil {
+		log.Infof("get pod metrics of dev space %d err: %v", devSpaceId, err)
+	}
+
+	api.SendResponse(c, nil, usage.NamespaceUsage(devSpace.Namespace, pods.Items, podMetrics))
+}
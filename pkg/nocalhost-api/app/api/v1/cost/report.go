@@ -0,0 +1,95 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cost
+
+import (
+	"encoding/csv"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/cost"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+type ReportRequest struct {
+	// Month such as 2021-09, the current month by default
+	Month string `form:"month" example:"2021-09"`
+	// GroupBy user, team, cluster or dev_space
+	GroupBy string `form:"group_by" example:"user"`
+	Format  string `form:"format" example:"csv or json"`
+}
+
+// Report Get the cost report
+// @Summary Get the cost report
+// @Description Admin get the monthly cost of the resources requested by the dev spaces, grouped by user, team, cluster or dev space, priced by cost.cpu_core_hour and cost.memory_gib_hour
+// @Tags Cost
+// @Produce  json
+// @Produce  text/csv
+// @param Authorization header string true "Authorization"
+// @Param query query cost.ReportRequest false "The month and group"
+// @Success 200 {object} cost.Report
+// @Router /v1/cost/report [get]
+func Report(c *gin.Context) {
+	// the white list of permission middleware matches the query string too
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req ReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if req.GroupBy == "" {
+		req.GroupBy = cost.GroupByUser
+	}
+	if !cost.ValidGroup(req.GroupBy) {
+		api.SendResponse(c, errno.ErrParam, "group_by must be user, team, cluster or dev_space")
+		return
+	}
+
+	report, err := service.CostReport(c, req.Month, req.GroupBy)
+	if err != nil {
+		log.Warnf("report cost err: %v", err)
+		api.SendResponse(c, errno.ErrParam, err.Error())
+		return
+	}
+	if req.Format != "csv" {
+		api.SendResponse(c, nil, report)
+		return
+	}
+
+	c.Header(
+		"Content-Disposition", fmt.Sprintf("attachment; filename=cost-%s-by-%s.csv", report.Month, report.GroupBy),
+	)
+	c.Header("Content-Type", "text/csv")
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write(
+		[]string{
+			"month", report.GroupBy + "_id", report.GroupBy, "cpu_core_hours", "memory_gib_hours",
+			"cpu_cost", "memory_cost", "cost", "currency",
+		},
+	)
+	row := func(id string, l cost.Line) []string {
+		return []string{
+			report.Month, id, l.Name, cast.ToString(l.CpuCoreHours), cast.ToString(l.MemoryGibHours),
+			cast.ToString(l.CpuCost), cast.ToString(l.MemoryCost), cast.ToString(l.Cost), report.Prices.Currency,
+		}
+	}
+	for _, l := range report.Lines {
+		_ = w.Write(row(cast.ToString(l.ID), l))
+	}
+	_ = w.Write(row("", report.Total))
+	w.Flush()
+}
@@ -0,0 +1,239 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_image
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"nocalhost/internal/nhctl/config_validate"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+type DevImageRequest struct {
+	Name     string `json:"name" binding:"required" example:"golang-1.16"`
+	Language string `json:"language" binding:"required" example:"go"`
+	// Image the dev image, the one without tag allows all tags of the repository
+	Image       string `json:"image" binding:"required" example:"golang:1.16"`
+	Shell       string `json:"shell" example:"zsh"`
+	DebugPort   int    `json:"debug_port" binding:"min=0,max=65535" example:"2345"`
+	WorkDir     string `json:"work_dir" example:"/home/nocalhost-dev"`
+	Description string `json:"description"`
+	// Sort the images of a language are sorted by it, the first is the default
+	Sort int `json:"sort"`
+}
+
+func (r *DevImageRequest) validate() error {
+	if !sets.NewString(config_validate.Languages()...).Has(r.Language) {
+		return errno.ErrDevImageLanguage
+	}
+	return nil
+}
+
+func (r *DevImageRequest) columns() map[string]interface{} {
+	return map[string]interface{}{
+		"name":        r.Name,
+		"language":    r.Language,
+		"image":       r.Image,
+		"shell":       r.Shell,
+		"debug_port":  r.DebugPort,
+		"work_dir":    r.WorkDir,
+		"description": r.Description,
+		"sort":        r.Sort,
+	}
+}
+
+// Create Create dev image
+// @Summary Create dev image
+// @Description Admin add the dev image to the catalog, which is distributed to all dev spaces for nhctl dev start
+// @Tags DevImage
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param image body dev_image.DevImageRequest true "The dev image"
+// @Success 200 {object} model.DevImageModel
+// @Router /v1/dev_image [post]
+func Create(c *gin.Context) {
+	// the white list of permission middleware matches the sub paths too
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req DevImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind dev image params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.validate(); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if exist, err := service.Svc.DevImageSvc.GetByName(c, req.Name); err == nil && exist.ID > 0 {
+		api.SendResponse(c, errno.ErrDevImageExist, nil)
+		return
+	}
+
+	userId, _ := ginbase.LoginUser(c)
+	result := &model.DevImageModel{
+		Name:        req.Name,
+		Language:    req.Language,
+		Image:       req.Image,
+		Shell:       req.Shell,
+		DebugPort:   req.DebugPort,
+		WorkDir:     req.WorkDir,
+		Description: req.Description,
+		Sort:        req.Sort,
+		UserId:      userId,
+	}
+	if err := service.Svc.DevImageSvc.Create(c, result); err != nil {
+		log.Warnf("create dev image err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	go service.SyncDevImages()
+	api.SendResponse(c, nil, result)
+}
+
+// Update Update dev image
+// @Summary Update dev image
+// @Description Admin update the dev image, the catalog in dev spaces is refreshed
+// @Tags DevImage
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Dev image ID"
+// @Param image body dev_image.DevImageRequest true "The dev image"
+// @Success 200 {object} model.DevImageModel
+// @Router /v1/dev_image/{id} [put]
+func Update(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req DevImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind dev image params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.validate(); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.DevImageSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrDevImageNotFound, nil)
+		return
+	}
+	if exist, err := service.Svc.DevImageSvc.GetByName(c, req.Name); err == nil && exist.ID != id {
+		api.SendResponse(c, errno.ErrDevImageExist, nil)
+		return
+	}
+	if err := service.Svc.DevImageSvc.Update(c, id, req.columns()); err != nil {
+		log.Warnf("update dev image err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	go service.SyncDevImages()
+	result, _ := service.Svc.DevImageSvc.Get(c, id)
+	api.SendResponse(c, nil, result)
+}
+
+// Delete Delete dev image
+// @Summary Delete dev image
+// @Description Admin remove the dev image from the catalog, the workloads in DevMode are not changed
+// @Tags DevImage
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Dev image ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_image/{id} [delete]
+func Delete(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.DevImageSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrDevImageNotFound, nil)
+		return
+	}
+	if err := service.Svc.DevImageSvc.Delete(c, id); err != nil {
+		log.Warnf("delete dev image err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	go service.SyncDevImages()
+	api.SendResponse(c, errno.OK, nil)
+}
+
+// List List dev images
+// @Summary List dev images
+// @Description List the dev images of catalog, the images of a language are sorted and the first is the default
+// @Tags DevImage
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param language query string false "Only the images of language, such as go or java"
+// @Success 200 {object} []model.DevImageModel
+// @Router /v1/dev_image [get]
+func List(c *gin.Context) {
+	result, err := service.Svc.DevImageSvc.List(c, c.Query("language"))
+	if err != nil {
+		log.Warnf("list dev image err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// Catalog Get the catalog of dev images
+// @Summary Get the catalog of dev images
+// @Description The catalog distributed to the dev spaces, with whether only the images of it are allowed
+// @Tags DevImage
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} devimage.Catalog
+// @Router /v1/dev_image/catalog [get]
+func Catalog(c *gin.Context) {
+	result, err := service.DevImageCatalog(c)
+	if err != nil {
+		log.Warnf("get dev image catalog err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// Sync Refresh the catalog of dev images in dev spaces
+// @Summary Refresh the catalog of dev images in dev spaces
+// @Description Admin refresh the catalog in all dev spaces, such as after changing dev_image.enforce of the config
+// @Tags DevImage
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_image/sync [post]
+func Sync(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+	go service.SyncDevImages()
+	api.SendResponse(c, errno.OK, nil)
+}
@@ -0,0 +1,108 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_space_template
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/cluster_user"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/spacetemplate"
+)
+
+type TemplateRequest struct {
+	Name               string                           `json:"name" binding:"required"`
+	Description        string                           `json:"description"`
+	SpaceResourceLimit *cluster_user.SpaceResourceLimit `json:"space_resource_limit"`
+	// Applications installed in dev space in order, from git or helm repo
+	Applications []uint64 `json:"applications"`
+	// Env saved to the config map nocalhost-dev-space-env of dev space
+	Env     map[string]string         `json:"env"`
+	Secrets []spacetemplate.SecretRef `json:"secrets" binding:"dive"`
+}
+
+// Validate returns the errno if request is invalid
+func (r *TemplateRequest) Validate(ctx context.Context) error {
+	if r.SpaceResourceLimit != nil {
+		if flag, message := cluster_user.ValidSpaceResourceLimit(*r.SpaceResourceLimit); !flag {
+			log.Warnf("incorrect resource limit [ %v ] of template", message)
+			return errno.ErrFormatResourceLimitParam
+		}
+		if !r.SpaceResourceLimit.Validate() {
+			return errno.ErrValidateResourceQuota
+		}
+	}
+
+	for key := range r.Env {
+		if errs := validation.IsEnvVarName(key); len(errs) > 0 {
+			return &errno.Errno{Code: errno.ErrBind.Code, Message: key + ": " + errs[0]}
+		}
+	}
+
+	for _, id := range r.Applications {
+		application, err := service.Svc.ApplicationSvc.Get(ctx, id)
+		if err != nil {
+			return errno.ErrDevSpaceTemplateApp
+		}
+		app, err := spacetemplate.ParseApplication(application.ID, application.Context)
+		if err != nil {
+			return errno.ErrDevSpaceTemplateApp
+		}
+		if _, err := app.InstallArgs(""); err != nil {
+			return errno.ErrDevSpaceTemplateApp
+		}
+	}
+	return nil
+}
+
+// Columns the columns of template model, the composite fields are saved as json
+func (r *TemplateRequest) Columns() map[string]interface{} {
+	columns := map[string]interface{}{
+		"name":                 r.Name,
+		"description":          r.Description,
+		"space_resource_limit": "",
+		"applications":         "",
+		"env":                  "",
+		"secrets":              "",
+	}
+	if r.SpaceResourceLimit != nil {
+		b, _ := json.Marshal(r.SpaceResourceLimit)
+		columns["space_resource_limit"] = string(b)
+	}
+	if len(r.Applications) > 0 {
+		b, _ := json.Marshal(r.Applications)
+		columns["applications"] = string(b)
+	}
+	if len(r.Env) > 0 {
+		b, _ := json.Marshal(r.Env)
+		columns["env"] = string(b)
+	}
+	if len(r.Secrets) > 0 {
+		b, _ := json.Marshal(r.Secrets)
+		columns["secrets"] = string(b)
+	}
+	return columns
+}
+
+// Model the template model of request
+func (r *TemplateRequest) Model(userId uint64) *model.DevSpaceTemplateModel {
+	columns := r.Columns()
+	return &model.DevSpaceTemplateModel{
+		Name:               r.Name,
+		Description:        r.Description,
+		SpaceResourceLimit: columns["space_resource_limit"].(string),
+		Applications:       columns["applications"].(string),
+		Env:                columns["env"].(string),
+		Secrets:            columns["secrets"].(string),
+		UserId:             userId,
+	}
+}
@@ -0,0 +1,170 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package dev_space_template
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Create Create dev space template
+// @Summary Create dev space template
+// @Description Admin create the template of dev space, with resource limit, env vars, secrets to copy and applications to install
+// @Tags DevSpaceTemplate
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param template body dev_space_template.TemplateRequest true "The template"
+// @Success 200 {object} model.DevSpaceTemplateModel
+// @Router /v1/dev_space_template [post]
+func Create(c *gin.Context) {
+	// the white list of permission middleware matches the sub paths too
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind template params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.Validate(c); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if exist, err := service.Svc.DevSpaceTemplateSvc.GetByName(c, req.Name); err == nil && exist.ID > 0 {
+		api.SendResponse(c, errno.ErrDevSpaceTemplateExist, nil)
+		return
+	}
+
+	userId, _ := ginbase.LoginUser(c)
+	result := req.Model(userId)
+	if err := service.Svc.DevSpaceTemplateSvc.Create(c, result); err != nil {
+		log.Warnf("create template err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// Update Update dev space template
+// @Summary Update dev space template
+// @Description Admin update the template, the dev spaces created from it before are not changed
+// @Tags DevSpaceTemplate
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Template ID"
+// @Param template body dev_space_template.TemplateRequest true "The template"
+// @Success 200 {object} model.DevSpaceTemplateModel
+// @Router /v1/dev_space_template/{id} [put]
+func Update(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind template params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.Validate(c); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.DevSpaceTemplateSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceTemplateNotFound, nil)
+		return
+	}
+	if exist, err := service.Svc.DevSpaceTemplateSvc.GetByName(c, req.Name); err == nil && exist.ID != id {
+		api.SendResponse(c, errno.ErrDevSpaceTemplateExist, nil)
+		return
+	}
+
+	if err := service.Svc.DevSpaceTemplateSvc.Update(c, id, req.Columns()); err != nil {
+		log.Warnf("update template err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	result, _ := service.Svc.DevSpaceTemplateSvc.Get(c, id)
+	api.SendResponse(c, nil, result)
+}
+
+// Delete Delete dev space template
+// @Summary Delete dev space template
+// @Description Admin delete the template, the dev spaces created from it are kept
+// @Tags DevSpaceTemplate
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Template ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/dev_space_template/{id} [delete]
+func Delete(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.DevSpaceTemplateSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceTemplateNotFound, nil)
+		return
+	}
+	if err := service.Svc.DevSpaceTemplateSvc.Delete(c, id); err != nil {
+		log.Warnf("delete template err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, errno.OK, nil)
+}
+
+// List List dev space templates
+// @Summary List dev space templates
+// @Description List the templates which dev spaces can be created from
+// @Tags DevSpaceTemplate
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} []model.DevSpaceTemplateModel
+// @Router /v1/dev_space_template [get]
+func List(c *gin.Context) {
+	result, err := service.Svc.DevSpaceTemplateSvc.List(c)
+	if err != nil {
+		log.Warnf("list template err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// Get Get dev space template
+// @Summary Get dev space template
+// @Description Get the template by id
+// @Tags DevSpaceTemplate
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Template ID"
+// @Success 200 {object} model.DevSpaceTemplateModel
+// @Router /v1/dev_space_template/{id} [get]
+func Get(c *gin.Context) {
+	result, err := service.Svc.DevSpaceTemplateSvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrDevSpaceTemplateNotFound, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
@@ -0,0 +1,37 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package garbage
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+)
+
+// Report Report the orphaned rows
+// @Summary Report the orphaned rows
+// @Description Admin detect the orphaned rows in dry run, e.g. the dev spaces of which the namespace is gone, nothing is cleaned
+// @Tags GarbageCollection
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} service.GarbageReport
+// @Router /v1/gc/orphans [get]
+func Report(c *gin.Context) {
+	api.SendResponse(c, nil, service.CollectGarbage(c, true))
+}
+
+// Collect Clean the orphaned rows
+// @Summary Clean the orphaned rows
+// @Description Admin clean the orphaned rows now, regardless of gc.dry_run, the dev spaces of the users deleted are reported only
+// @Tags GarbageCollection
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} service.GarbageReport
+// @Router /v1/gc/orphans [post]
+func Collect(c *gin.Context) {
+	api.SendResponse(c, nil, service.CollectGarbage(c, false))
+}
@@ -0,0 +1,203 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package git_credential
+
+import (
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/applications"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+type CredentialRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// Type ssh or https
+	Type string `json:"type" binding:"required" example:"https"`
+	// Username of https, the token is used as password
+	Username string `json:"username"`
+	// Secret the private key of ssh or the token of https, kept if empty when updating
+	Secret string `json:"secret"`
+}
+
+func (r *CredentialRequest) validate() error {
+	if r.Type != model.GitCredentialSsh && r.Type != model.GitCredentialHttps {
+		return errno.ErrGitCredentialType
+	}
+	return nil
+}
+
+// Create Create git credential
+// @Summary Create git credential
+// @Description Admin create the credential of private git repos, applications refer to it by credential_id of context
+// @Tags GitCredential
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param credential body git_credential.CredentialRequest true "The credential"
+// @Success 200 {object} model.GitCredentialModel
+// @Router /v1/git_credential [post]
+func Create(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req CredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Secret == "" {
+		log.Warnf("bind git credential params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.validate(); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if exist, err := service.Svc.GitCredentialSvc.GetByName(c, req.Name); err == nil && exist.ID > 0 {
+		api.SendResponse(c, errno.ErrGitCredentialExist, nil)
+		return
+	}
+
+	userId, _ := ginbase.LoginUser(c)
+	result := &model.GitCredentialModel{
+		Name:        req.Name,
+		Description: req.Description,
+		Type:        req.Type,
+		Username:    req.Username,
+		Secret:      req.Secret,
+		UserId:      userId,
+	}
+	if err := service.Svc.GitCredentialSvc.Create(c, result); err != nil {
+		log.Warnf("create git credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// Update Update git credential
+// @Summary Update git credential
+// @Description Admin update the credential, the secret is kept if not specified
+// @Tags GitCredential
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Credential ID"
+// @Param credential body git_credential.CredentialRequest true "The credential"
+// @Success 200 {object} model.GitCredentialModel
+// @Router /v1/git_credential/{id} [put]
+func Update(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req CredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind git credential params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.validate(); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.GitCredentialSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrGitCredentialNotFound, nil)
+		return
+	}
+	if exist, err := service.Svc.GitCredentialSvc.GetByName(c, req.Name); err == nil && exist.ID != id {
+		api.SendResponse(c, errno.ErrGitCredentialExist, nil)
+		return
+	}
+
+	columns := map[string]interface{}{
+		"name":        req.Name,
+		"description": req.Description,
+		"type":        req.Type,
+		"username":    req.Username,
+	}
+	if req.Secret != "" {
+		columns["secret"] = req.Secret
+	}
+	if err := service.Svc.GitCredentialSvc.Update(c, id, columns); err != nil {
+		log.Warnf("update git credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	result, _ := service.Svc.GitCredentialSvc.Get(c, id)
+	api.SendResponse(c, nil, result)
+}
+
+// Delete Delete git credential
+// @Summary Delete git credential
+// @Description Admin delete the credential which is not used by any application
+// @Tags GitCredential
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Credential ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/git_credential/{id} [delete]
+func Delete(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.GitCredentialSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrGitCredentialNotFound, nil)
+		return
+	}
+
+	apps, err := service.Svc.ApplicationSvc.GetList(c, nil)
+	if err != nil {
+		api.SendResponse(c, errno.ErrApplicationGet, nil)
+		return
+	}
+	for _, app := range apps {
+		var context applications.ApplicationJsonContext
+		if json.Unmarshal([]byte(app.Context), &context) == nil && context.CredentialId == id {
+			api.SendResponse(c, errno.ErrGitCredentialInUse, nil)
+			return
+		}
+	}
+
+	if err := service.Svc.GitCredentialSvc.Delete(c, id); err != nil {
+		log.Warnf("delete git credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, errno.OK, nil)
+}
+
+// List List git credentials
+// @Summary List git credentials
+// @Description List the credentials without secrets, for choosing one when creating application
+// @Tags GitCredential
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} []model.GitCredentialModel
+// @Router /v1/git_credential [get]
+func List(c *gin.Context) {
+	result, err := service.Svc.GitCredentialSvc.List(c)
+	if err != nil {
+		log.Warnf("list git credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
@@ -61,6 +61,8 @@ func Configuration(c *gin.Context) {
 		params.AdminFilter,
 		params.EmailAttr,
 		params.UserNameAttr,
+		params.GroupAttr,
+		params.AdminGroups,
 		_const.BoolToUint64Pointer(true),
 	); err != nil {
 		log.Errorf("Fail to saving ldap config, Error %s", err)
@@ -84,6 +86,8 @@ func DeleteConfiguration(c *gin.Context) {
 		"",
 		"",
 		"",
+		"",
+		"",
 		_const.BoolToUint64Pointer(false),
 	); err != nil {
 		log.Errorf("Fail to disable ldap config, Error %s", err)
@@ -216,4 +220,6 @@ type Configurations struct {
 	AdminFilter  string  `json:"admin_filter"`
 	EmailAttr    string  `json:"email_attr" binding:"required"`
 	UserNameAttr string  `json:"user_name_attr"`
+	GroupAttr    string  `json:"group_attr"`
+	AdminGroups  string  `json:"admin_groups"`
 }
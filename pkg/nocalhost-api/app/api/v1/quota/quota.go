@@ -0,0 +1,26 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package quota
+
+import (
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+// QuotaRequest zero value of the fields means unlimited
+type QuotaRequest struct {
+	MaxDevSpaces   uint64  `json:"max_dev_spaces"`
+	MaxClusters    uint64  `json:"max_clusters"`
+	MaxSpaceCpu    float64 `json:"max_space_cpu" example:"4"`
+	MaxSpaceMemory uint64  `json:"max_space_memory" example:"8192"`
+}
+
+// UserQuotaResponse the quota attached to the user is nil if not set,
+// the effective one comes from the teams then
+type UserQuotaResponse struct {
+	Quota     *model.QuotaModel `json:"quota"`
+	Effective model.QuotaModel  `json:"effective"`
+	Usage     model.QuotaUsage  `json:"usage"`
+}
@@ -0,0 +1,77 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package quota
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// GetTeamQuota Get quota of the team
+// @Summary Get quota of the team
+// @Description Admin get the quota applied to the members of team, null if not set
+// @Tags Quotas
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Success 200 {object} model.QuotaModel
+// @Router /v1/teams/{id}/quota [get]
+func GetTeamQuota(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	result, err := service.Svc.QuotaSvc.Get(c, model.QuotaSubjectTeam, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		log.Warnf("get quota err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// SaveTeamQuota Set quota of the team
+// @Summary Set quota of the team
+// @Description Admin set the quota of team, the most permissive one is applied if user belongs to several teams
+// @Tags Quotas
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Param quota body quota.QuotaRequest true "The quota"
+// @Success 200 {object} model.QuotaModel
+// @Router /v1/teams/{id}/quota [put]
+func SaveTeamQuota(c *gin.Context) {
+	teamId := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.TeamSvc.Get(c, teamId); err != nil {
+		api.SendResponse(c, errno.ErrTeamNotFound, nil)
+		return
+	}
+
+	save(c, model.QuotaSubjectTeam, teamId)
+}
+
+// DeleteTeamQuota Delete quota of the team
+// @Summary Delete quota of the team
+// @Description Admin delete the quota of team
+// @Tags Quotas
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":null}"
+// @Router /v1/teams/{id}/quota [delete]
+func DeleteTeamQuota(c *gin.Context) {
+	remove(c, model.QuotaSubjectTeam, cast.ToUint64(c.Param("id")))
+}
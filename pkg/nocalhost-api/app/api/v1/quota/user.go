@@ -0,0 +1,161 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package quota
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// GetMine Get quota of current user
+// @Summary Get quota of current user
+// @Description Get the quota applied to current user and the usage of it
+// @Tags Quotas
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} quota.UserQuotaResponse
+// @Router /v1/me/quota [get]
+func GetMine(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	getUserQuota(c, userId)
+}
+
+// GetUserQuota Get quota of the user
+// @Summary Get quota of the user
+// @Description Admin get the quota attached to the user, the effective one and the usage of it
+// @Tags Quotas
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "User ID"
+// @Success 200 {object} quota.UserQuotaResponse
+// @Router /v1/users/{id}/quota [get]
+func GetUserQuota(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	getUserQuota(c, cast.ToUint64(c.Param("id")))
+}
+
+// SaveUserQuota Set quota of the user
+// @Summary Set quota of the user
+// @Description Admin set the quota of the user, it takes precedence over the quotas of teams
+// @Tags Quotas
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "User ID"
+// @Param quota body quota.QuotaRequest true "The quota"
+// @Success 200 {object} model.QuotaModel
+// @Router /v1/users/{id}/quota [put]
+func SaveUserQuota(c *gin.Context) {
+	userId := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.UserSvc.GetCache(userId); err != nil {
+		api.SendResponse(c, errno.ErrUserNotFound, nil)
+		return
+	}
+
+	save(c, model.QuotaSubjectUser, userId)
+}
+
+// DeleteUserQuota Delete quota of the user
+// @Summary Delete quota of the user
+// @Description Admin delete the quota of the user, the quotas of teams are applied then
+// @Tags Quotas
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "User ID"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":null}"
+// @Router /v1/users/{id}/quota [delete]
+func DeleteUserQuota(c *gin.Context) {
+	remove(c, model.QuotaSubjectUser, cast.ToUint64(c.Param("id")))
+}
+
+func getUserQuota(c *gin.Context, userId uint64) {
+	own, err := service.Svc.QuotaSvc.Get(c, model.QuotaSubjectUser, userId)
+	if err != nil {
+		log.Warnf("get quota err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	effective, err := service.Svc.QuotaSvc.Effective(c, userId)
+	if err != nil {
+		log.Warnf("get effective quota err: %v", err)
+		api.SendResponse(c, errno.ErrUserNotFound, nil)
+		return
+	}
+
+	usage, err := service.Svc.QuotaSvc.Usage(c, userId)
+	if err != nil {
+		log.Warnf("get quota usage err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, UserQuotaResponse{Quota: own, Effective: effective, Usage: usage})
+}
+
+// save the white list of permission middleware matches the sub paths too,
+// so admin is checked here
+func save(c *gin.Context, subjectType string, subjectId uint64) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	var req QuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.MaxSpaceCpu < 0 {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	result, err := service.Svc.QuotaSvc.Save(
+		c, model.QuotaModel{
+			SubjectType:    subjectType,
+			SubjectId:      subjectId,
+			MaxDevSpaces:   req.MaxDevSpaces,
+			MaxClusters:    req.MaxClusters,
+			MaxSpaceCpu:    req.MaxSpaceCpu,
+			MaxSpaceMemory: req.MaxSpaceMemory,
+		},
+	)
+	if err != nil {
+		log.Warnf("save quota err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+func remove(c *gin.Context, subjectType string, subjectId uint64) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	if err := service.Svc.QuotaSvc.Delete(c, subjectType, subjectId); err != nil {
+		log.Warnf("delete quota err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
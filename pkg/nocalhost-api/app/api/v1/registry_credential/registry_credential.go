@@ -0,0 +1,195 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package registry_credential
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/registry"
+)
+
+type CredentialRequest struct {
+	// Name the image pull secret in dev spaces is nocalhost-registry-{name}
+	Name        string `json:"name" binding:"required" example:"harbor"`
+	Description string `json:"description"`
+	// Server the host of registry, e.g. harbor.example.com or https://index.docker.io/v1/
+	Server   string `json:"server" binding:"required" example:"harbor.example.com"`
+	Username string `json:"username" binding:"required"`
+	// Password the password or token, kept if empty when updating
+	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+func (r *CredentialRequest) validate() error {
+	if len(validation.IsDNS1123Label(registry.PullSecretName(r.Name))) > 0 {
+		return errno.ErrRegistryCredentialName
+	}
+	return nil
+}
+
+// Create Create registry credential
+// @Summary Create registry credential
+// @Description Admin create the credential of private image registry, it is distributed to all dev spaces as image pull secret of the default service account
+// @Tags RegistryCredential
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param credential body registry_credential.CredentialRequest true "The credential"
+// @Success 200 {object} model.RegistryCredentialModel
+// @Router /v1/registry_credential [post]
+func Create(c *gin.Context) {
+	var req CredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		log.Warnf("bind registry credential params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.validate(); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+	if exist, err := service.Svc.RegistryCredentialSvc.GetByName(c, req.Name); err == nil && exist.ID > 0 {
+		api.SendResponse(c, errno.ErrRegistryCredentialExist, nil)
+		return
+	}
+
+	userId, _ := ginbase.LoginUser(c)
+	result := &model.RegistryCredentialModel{
+		Name:        req.Name,
+		Description: req.Description,
+		Server:      req.Server,
+		Username:    req.Username,
+		Password:    req.Password,
+		Email:       req.Email,
+		UserId:      userId,
+	}
+	if err := service.Svc.RegistryCredentialSvc.Create(c, result); err != nil {
+		log.Warnf("create registry credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	go service.SyncRegistryCredentials()
+	api.SendResponse(c, nil, result)
+}
+
+// Update Update registry credential
+// @Summary Update registry credential
+// @Description Admin update the credential, the password is kept if not specified, the image pull secrets in dev spaces are refreshed
+// @Tags RegistryCredential
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Credential ID"
+// @Param credential body registry_credential.CredentialRequest true "The credential"
+// @Success 200 {object} model.RegistryCredentialModel
+// @Router /v1/registry_credential/{id} [put]
+func Update(c *gin.Context) {
+	var req CredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind registry credential params err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if err := req.validate(); err != nil {
+		api.SendResponse(c, err, nil)
+		return
+	}
+
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.RegistryCredentialSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrRegistryCredentialNotFound, nil)
+		return
+	}
+	if exist, err := service.Svc.RegistryCredentialSvc.GetByName(c, req.Name); err == nil && exist.ID != id {
+		api.SendResponse(c, errno.ErrRegistryCredentialExist, nil)
+		return
+	}
+
+	columns := map[string]interface{}{
+		"name":        req.Name,
+		"description": req.Description,
+		"server":      req.Server,
+		"username":    req.Username,
+		"email":       req.Email,
+	}
+	if req.Password != "" {
+		columns["password"] = req.Password
+	}
+	if err := service.Svc.RegistryCredentialSvc.Update(c, id, columns); err != nil {
+		log.Warnf("update registry credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	go service.SyncRegistryCredentials()
+	result, _ := service.Svc.RegistryCredentialSvc.Get(c, id)
+	api.SendResponse(c, nil, result)
+}
+
+// Delete Delete registry credential
+// @Summary Delete registry credential
+// @Description Admin delete the credential, its image pull secrets are removed from dev spaces
+// @Tags RegistryCredential
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Credential ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/registry_credential/{id} [delete]
+func Delete(c *gin.Context) {
+	id := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.RegistryCredentialSvc.Get(c, id); err != nil {
+		api.SendResponse(c, errno.ErrRegistryCredentialNotFound, nil)
+		return
+	}
+	if err := service.Svc.RegistryCredentialSvc.Delete(c, id); err != nil {
+		log.Warnf("delete registry credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	go service.SyncRegistryCredentials()
+	api.SendResponse(c, errno.OK, nil)
+}
+
+// List List registry credentials
+// @Summary List registry credentials
+// @Description List the credentials without passwords
+// @Tags RegistryCredential
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} []model.RegistryCredentialModel
+// @Router /v1/registry_credential [get]
+func List(c *gin.Context) {
+	result, err := service.Svc.RegistryCredentialSvc.List(c)
+	if err != nil {
+		log.Warnf("list registry credential err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendResponse(c, nil, result)
+}
+
+// Sync Refresh the image pull secrets in dev spaces
+// @Summary Refresh the image pull secrets in dev spaces
+// @Description Admin refresh the image pull secrets of registry credentials in all dev spaces now instead of waiting for the periodic sync
+// @Tags RegistryCredential
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/registry_credential/sync [post]
+func Sync(c *gin.Context) {
+	go service.SyncRegistryCredentials()
+	api.SendResponse(c, errno.OK, nil)
+}
@@ -0,0 +1,43 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package role
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Create Create custom role
+// @Summary Create custom role
+// @Description Create custom role with a set of permissions
+// @Tags Roles
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param createRole body role.RoleRequest true "The role info"
+// @Success 200 {object} model.RoleModel
+// @Router /v1/roles [post]
+func Create(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == "" {
+		log.Warnf("create role bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	result, err := service.Svc.RoleSvc.Create(c, req.Name, req.Description, req.Permissions)
+	if err != nil {
+		log.Warnf("create role err: %v", err)
+		api.SendResponse(c, errno.ErrRoleCreate, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
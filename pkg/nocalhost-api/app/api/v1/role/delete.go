@@ -0,0 +1,35 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package role
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Delete Delete custom role
+// @Summary Delete custom role
+// @Description Delete custom role and all its bindings, built-in roles can not be deleted
+// @Tags Roles
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Role ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/roles/{id} [delete]
+func Delete(c *gin.Context) {
+	if err := service.Svc.RoleSvc.Delete(c, cast.ToUint64(c.Param("id"))); err != nil {
+		log.Warnf("delete role err: %v", err)
+		api.SendResponse(c, errno.ErrRoleDelete, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
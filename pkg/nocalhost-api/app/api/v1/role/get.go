@@ -0,0 +1,99 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package role
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// List List roles
+// @Summary List roles
+// @Description List built-in and custom roles
+// @Tags Roles
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} model.RoleModel
+// @Router /v1/roles [get]
+func List(c *gin.Context) {
+	result, err := service.Svc.RoleSvc.List(c)
+	if err != nil {
+		log.Warnf("list role err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// Get Get role
+// @Summary Get role
+// @Description Get role
+// @Tags Roles
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Role ID"
+// @Success 200 {object} model.RoleModel
+// @Router /v1/roles/{id} [get]
+func Get(c *gin.Context) {
+	result, err := service.Svc.RoleSvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrRoleNotFound, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// ListBindings List the bindings of role
+// @Summary List the bindings of role
+// @Description List the users and dev spaces the role bound to
+// @Tags Roles
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Role ID"
+// @Success 200 {object} model.RoleBindingModel
+// @Router /v1/roles/{id}/bindings [get]
+func ListBindings(c *gin.Context) {
+	result, err := service.Svc.RoleSvc.ListBindings(
+		c, model.RoleBindingModel{RoleId: cast.ToUint64(c.Param("id"))},
+	)
+	if err != nil {
+		log.Warnf("list role binding err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// ListByUser List the role bindings of user
+// @Summary List the role bindings of user
+// @Description List the role bindings of user
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "User ID"
+// @Success 200 {object} model.RoleBindingModel
+// @Router /v1/users/{id}/roles [get]
+func ListByUser(c *gin.Context) {
+	result, err := service.Svc.RoleSvc.ListBindings(
+		c, model.RoleBindingModel{UserId: cast.ToUint64(c.Param("id"))},
+	)
+	if err != nil {
+		log.Warnf("list role binding err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
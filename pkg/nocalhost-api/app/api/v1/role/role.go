@@ -0,0 +1,20 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package role
+
+// RoleRequest
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required" example:"dev_spaces:read,clusters:*"`
+}
+
+// BindingRequest bind role to user globally, or to the dev space if scope is dev_space
+type BindingRequest struct {
+	UserId  uint64 `json:"user_id" binding:"required"`
+	Scope   string `json:"scope" example:"global or dev_space"`
+	ScopeId uint64 `json:"scope_id" example:"dev space id"`
+}
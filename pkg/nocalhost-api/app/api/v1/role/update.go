@@ -0,0 +1,110 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package role
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Update Update custom role
+// @Summary Update custom role
+// @Description Update the description and permissions of custom role, built-in roles can not be modified
+// @Tags Roles
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Role ID"
+// @Param updateRole body role.RoleRequest true "The role info"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/roles/{id} [put]
+func Update(c *gin.Context) {
+	var req RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("update role bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if err := service.Svc.RoleSvc.Update(
+		c, cast.ToUint64(c.Param("id")), req.Description, req.Permissions,
+	); err != nil {
+		log.Warnf("update role err: %v", err)
+		api.SendResponse(c, errno.ErrRoleUpdate, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+// Bind Bind role to user
+// @Summary Bind role to user
+// @Description Bind role to user globally, or only to the dev space
+// @Tags Roles
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Role ID"
+// @Param binding body role.BindingRequest true "The binding info"
+// @Success 200 {object} model.RoleBindingModel
+// @Router /v1/roles/{id}/bindings [post]
+func Bind(c *gin.Context) {
+	var req BindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("bind role bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if _, err := service.Svc.UserSvc.GetCache(req.UserId); err != nil {
+		api.SendResponse(c, errno.ErrUserNotFound, nil)
+		return
+	}
+
+	result, err := service.Svc.RoleSvc.Bind(c, cast.ToUint64(c.Param("id")), req.UserId, req.Scope, req.ScopeId)
+	if err != nil {
+		log.Warnf("bind role err: %v", err)
+		api.SendResponse(c, errno.ErrRoleBind, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// Unbind Unbind role from user
+// @Summary Unbind role from user
+// @Description Unbind role from user
+// @Tags Roles
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Role ID"
+// @Param binding body role.BindingRequest true "The binding info"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/roles/{id}/bindings [delete]
+func Unbind(c *gin.Context) {
+	var req BindingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("unbind role bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if err := service.Svc.RoleSvc.Unbind(
+		c, cast.ToUint64(c.Param("id")), req.UserId, req.Scope, req.ScopeId,
+	); err != nil {
+		log.Warnf("unbind role err: %v", err)
+		api.SendResponse(c, errno.ErrRoleBind, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
@@ -6,6 +6,7 @@
 package service_account
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"sync"
@@ -110,6 +111,9 @@ func ListAuthorization(c *gin.Context) {
 	)
 
 	wg.Wait()
+
+	// connection of IDE wakes up the dev spaces asleep
+	go service.ActivateDevSpaces(context.TODO(), userId)
 	api.SendResponse(c, nil, result)
 }
 
@@ -0,0 +1,45 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Create Create team
+// @Summary Create team
+// @Description Create team
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param createTeam body team.TeamRequest true "The team info"
+// @Success 200 {object} model.TeamModel
+// @Router /v1/teams [post]
+func Create(c *gin.Context) {
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("create team bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	userId, _ := ginbase.LoginUser(c)
+	result, err := service.Svc.TeamSvc.Create(c, req.Name, req.Description, userId)
+	if err != nil {
+		log.Warnf("create team err: %v", err)
+		api.SendResponse(c, errno.ErrTeamCreate, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
@@ -0,0 +1,58 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Delete Delete team
+// @Summary Delete team
+// @Description Delete team, the resources granted via the team are revoked from members
+// @Tags Teams
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/teams/{id} [delete]
+func Delete(c *gin.Context) {
+	teamId := cast.ToUint64(c.Param("id"))
+
+	members, err := service.Svc.TeamSvc.MemberIds(c, teamId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrTeamNotFound, nil)
+		return
+	}
+
+	grants, err := service.Svc.TeamSvc.ListGrants(c, teamId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrTeamNotFound, nil)
+		return
+	}
+
+	for _, grant := range grants {
+		revokeFromUsers(c, grant, members)
+	}
+
+	if err := service.Svc.TeamSvc.Delete(c, teamId); err != nil {
+		log.Warnf("delete team err: %v", err)
+		api.SendResponse(c, errno.ErrTeamDelete, nil)
+		return
+	}
+
+	if err := service.Svc.QuotaSvc.Delete(c, model.QuotaSubjectTeam, teamId); err != nil {
+		log.Warnf("delete quota of team %d err: %v", teamId, err)
+	}
+
+	api.SendResponse(c, nil, nil)
+}
@@ -0,0 +1,100 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// List List teams
+// @Summary List teams
+// @Description List teams
+// @Tags Teams
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} model.TeamModel
+// @Router /v1/teams [get]
+func List(c *gin.Context) {
+	result, err := service.Svc.TeamSvc.List(c)
+	if err != nil {
+		log.Warnf("list team err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// ListMine List teams of current user
+// @Summary List teams of current user
+// @Description List the teams current user belongs to
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} model.TeamModel
+// @Router /v1/me/teams [get]
+func ListMine(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrLoginRequired, nil)
+		return
+	}
+
+	result, err := service.Svc.TeamSvc.ListByUserId(c, userId)
+	if err != nil {
+		log.Warnf("list team err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// Get Get team
+// @Summary Get team
+// @Description Get team
+// @Tags Teams
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Success 200 {object} model.TeamModel
+// @Router /v1/teams/{id} [get]
+func Get(c *gin.Context) {
+	result, err := service.Svc.TeamSvc.Get(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		api.SendResponse(c, errno.ErrTeamNotFound, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// ListResources List the resources visible to team
+// @Summary List the resources visible to team
+// @Description List the applications and dev spaces granted to team
+// @Tags Teams
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Success 200 {object} model.TeamGrantModel
+// @Router /v1/teams/{id}/resources [get]
+func ListResources(c *gin.Context) {
+	result, err := service.Svc.TeamSvc.ListGrants(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		log.Warnf("list team grant err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, result)
+}
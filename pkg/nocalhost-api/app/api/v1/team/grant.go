@@ -0,0 +1,106 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Grant Grant resource to team
+// @Summary Grant resource to team
+// @Description Grant application or dev space to all members of team
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Param grant body team.GrantRequest true "The resource to grant"
+// @Success 200 {object} model.TeamGrantModel
+// @Router /v1/teams/{id}/resources [post]
+func Grant(c *gin.Context) {
+	var req GrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("grant team bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	teamId := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.TeamSvc.Get(c, teamId); err != nil {
+		api.SendResponse(c, errno.ErrTeamNotFound, nil)
+		return
+	}
+
+	switch req.ResourceType {
+	case model.TeamGrantApplication:
+		if _, err := service.Svc.ApplicationSvc.Get(c, req.ResourceId); err != nil {
+			api.SendResponse(c, errno.ErrApplicationGet, nil)
+			return
+		}
+	case model.TeamGrantDevSpace:
+		if _, err := service.Svc.ClusterUserSvc.GetCache(req.ResourceId); err != nil {
+			api.SendResponse(c, errno.ErrClusterUserNotFound, nil)
+			return
+		}
+	}
+
+	result, err := service.Svc.TeamSvc.Grant(c, teamId, req.ResourceType, req.ResourceId, req.Role)
+	if err != nil {
+		log.Warnf("grant team err: %v", err)
+		api.SendResponse(c, errno.ErrTeamGrant, nil)
+		return
+	}
+
+	members, _ := service.Svc.TeamSvc.MemberIds(c, teamId)
+	grantToUsers(c, &result, members)
+
+	api.SendResponse(c, nil, result)
+}
+
+// Revoke Revoke resource from team
+// @Summary Revoke resource from team
+// @Description Revoke application or dev space from all members of team
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Param grant body team.GrantRequest true "The resource to revoke"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/teams/{id}/resources [delete]
+func Revoke(c *gin.Context) {
+	var req GrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("revoke team bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	teamId := cast.ToUint64(c.Param("id"))
+	grant, err := service.Svc.TeamSvc.GetGrant(c, teamId, req.ResourceType, req.ResourceId)
+	if err != nil {
+		api.SendResponse(c, errno.ErrTeamGrant, nil)
+		return
+	}
+
+	if err := service.Svc.TeamSvc.Revoke(c, teamId, req.ResourceType, req.ResourceId); err != nil {
+		log.Warnf("revoke team err: %v", err)
+		api.SendResponse(c, errno.ErrTeamGrant, nil)
+		return
+	}
+
+	members, _ := service.Svc.TeamSvc.MemberIds(c, teamId)
+	revokeFromUsers(c, grant, members)
+
+	api.SendResponse(c, nil, nil)
+}
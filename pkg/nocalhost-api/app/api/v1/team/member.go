@@ -0,0 +1,118 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// ListMembers List team members
+// @Summary List team members
+// @Description List team members
+// @Tags Teams
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Success 200 {object} model.UserList
+// @Router /v1/teams/{id}/members [get]
+func ListMembers(c *gin.Context) {
+	members, err := service.Svc.TeamSvc.MemberIds(c, cast.ToUint64(c.Param("id")))
+	if err != nil {
+		log.Warnf("list team member err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	userList, _, err := service.Svc.UserSvc.GetUserList(c, model.UserListQuery{})
+	if err != nil {
+		log.Warnf("list team member err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	set := map[uint64]bool{}
+	for _, m := range members {
+		set[m] = true
+	}
+
+	result := make([]*model.UserList, 0, len(members))
+	for _, u := range userList {
+		if set[u.ID] {
+			result = append(result, u)
+		}
+	}
+
+	api.SendResponse(c, nil, result)
+}
+
+// AddMembers Add team members
+// @Summary Add team members
+// @Description Add team members, the resources granted to the team are granted to them too
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Param members body team.MembersRequest true "The user ids"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/teams/{id}/members [post]
+func AddMembers(c *gin.Context) {
+	var req MembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("add team member bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	teamId := cast.ToUint64(c.Param("id"))
+	if _, err := service.Svc.TeamSvc.Get(c, teamId); err != nil {
+		api.SendResponse(c, errno.ErrTeamNotFound, nil)
+		return
+	}
+
+	if err := JoinTeam(c, teamId, req.Users); err != nil {
+		log.Warnf("add team member err: %v", err)
+		api.SendResponse(c, errno.ErrTeamMember, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+// RemoveMembers Remove team members
+// @Summary Remove team members
+// @Description Remove team members, the resources granted via the team are revoked from them
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Param members body team.MembersRequest true "The user ids"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/teams/{id}/members [delete]
+func RemoveMembers(c *gin.Context) {
+	var req MembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("remove team member bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if err := LeaveTeam(c, cast.ToUint64(c.Param("id")), req.Users); err != nil {
+		log.Warnf("remove team member err: %v", err)
+		api.SendResponse(c, errno.ErrTeamMember, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
@@ -0,0 +1,145 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"context"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/cooperator/cluster_scope"
+	"nocalhost/internal/nocalhost-api/service/cooperator/ns_scope"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// TeamRequest
+type TeamRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// MembersRequest
+type MembersRequest struct {
+	Users []uint64 `json:"users" binding:"required"`
+}
+
+// GrantRequest grant application or dev space to team
+type GrantRequest struct {
+	ResourceType string `json:"resource_type" binding:"required" example:"application or dev_space"`
+	ResourceId   uint64 `json:"resource_id" binding:"required"`
+	Role         string `json:"role" example:"cooperator or viewer, only for dev_space"`
+}
+
+// JoinTeam add users to team and grant them the resources of the team
+func JoinTeam(ctx context.Context, teamId uint64, userIds []uint64) error {
+	if err := service.Svc.TeamSvc.AddMembers(ctx, teamId, userIds); err != nil {
+		return err
+	}
+
+	grants, _ := service.Svc.TeamSvc.ListGrants(ctx, teamId)
+	for _, grant := range grants {
+		grantToUsers(ctx, grant, userIds)
+	}
+	return nil
+}
+
+// LeaveTeam remove users from team and revoke the resources granted via the team
+func LeaveTeam(ctx context.Context, teamId uint64, userIds []uint64) error {
+	if err := service.Svc.TeamSvc.RemoveMembers(ctx, teamId, userIds); err != nil {
+		return err
+	}
+
+	grants, _ := service.Svc.TeamSvc.ListGrants(ctx, teamId)
+	for _, grant := range grants {
+		revokeFromUsers(ctx, grant, userIds)
+	}
+	return nil
+}
+
+// grantToUsers apply the team grant to users, the application is authorized
+// via application_user and the dev space is shared as cooperator or viewer
+func grantToUsers(ctx context.Context, grant *model.TeamGrantModel, userIds []uint64) {
+	if len(userIds) == 0 {
+		return
+	}
+
+	switch grant.ResourceType {
+	case model.TeamGrantApplication:
+		if err := service.Svc.ApplicationUserSvc.BatchInsert(ctx, grant.ResourceId, userIds); err != nil {
+			log.Errorf("Error while grant application %d to team %d: %+v", grant.ResourceId, grant.TeamId, err)
+		}
+	case model.TeamGrantDevSpace:
+		cu, err := service.Svc.ClusterUserSvc.GetCache(grant.ResourceId)
+		if err != nil {
+			log.Errorf("Error while share dev space %d to team %d: %+v", grant.ResourceId, grant.TeamId, err)
+			return
+		}
+
+		for _, user := range userIds {
+			if user == cu.UserId {
+				continue
+			}
+
+			switch {
+			case cu.IsClusterAdmin() && grant.Role == model.TeamGrantCooperator:
+				err = cluster_scope.AsCooperator(cu.ClusterId, cu.UserId, user)
+			case cu.IsClusterAdmin():
+				err = cluster_scope.AsViewer(cu.ClusterId, cu.UserId, user)
+			case grant.Role == model.TeamGrantCooperator:
+				err = ns_scope.AsCooperator(cu.ClusterId, user, cu.Namespace)
+			default:
+				err = ns_scope.AsViewer(cu.ClusterId, user, cu.Namespace)
+			}
+			if err != nil {
+				log.Errorf("Error while share dev space %d to user %d: %+v", cu.ID, user, err)
+			}
+		}
+	}
+}
+
+// revokeFromUsers revert grantToUsers, users still granted by other teams are kept
+func revokeFromUsers(ctx context.Context, grant *model.TeamGrantModel, userIds []uint64) {
+	var users []uint64
+	for _, user := range userIds {
+		if !service.Svc.TeamSvc.IsGrantedByOtherTeam(ctx, user, grant.ResourceType, grant.ResourceId, grant.TeamId) {
+			users = append(users, user)
+		}
+	}
+	if len(users) == 0 {
+		return
+	}
+
+	switch grant.ResourceType {
+	case model.TeamGrantApplication:
+		if err := service.Svc.ApplicationUserSvc.BatchDelete(ctx, grant.ResourceId, users); err != nil {
+			log.Errorf("Error while revoke application %d from team %d: %+v", grant.ResourceId, grant.TeamId, err)
+		}
+	case model.TeamGrantDevSpace:
+		cu, err := service.Svc.ClusterUserSvc.GetCache(grant.ResourceId)
+		if err != nil {
+			log.Errorf("Error while unshare dev space %d from team %d: %+v", grant.ResourceId, grant.TeamId, err)
+			return
+		}
+
+		for _, user := range users {
+			if cu.IsClusterAdmin() {
+				if err := cluster_scope.RemoveFromCooperator(cu.ClusterId, cu.UserId, user); err != nil {
+					log.Errorf("Error while remove somebody as cluster cooperator: %+v", err)
+				}
+				if err := cluster_scope.RemoveFromViewer(cu.ClusterId, cu.UserId, user); err != nil {
+					log.Errorf("Error while remove somebody as cluster viewer: %+v", err)
+				}
+			} else {
+				if err := ns_scope.RemoveFromCooperator(cu.ClusterId, user, cu.Namespace); err != nil {
+					log.Errorf("Error while remove somebody as cooperator: %+v", err)
+				}
+				if err := ns_scope.RemoveFromViewer(cu.ClusterId, user, cu.Namespace); err != nil {
+					log.Errorf("Error while remove somebody as viewer: %+v", err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package team
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Update Update team
+// @Summary Update team
+// @Description Update the name and description of team
+// @Tags Teams
+// @Accept  json
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Team ID"
+// @Param updateTeam body team.TeamRequest true "The team info"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/teams/{id} [put]
+func Update(c *gin.Context) {
+	var req TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Warnf("update team bind err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if err := service.Svc.TeamSvc.Update(c, cast.ToUint64(c.Param("id")), req.Name, req.Description); err != nil {
+		log.Warnf("update team err: %v", err)
+		api.SendResponse(c, errno.ErrTeamUpdate, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
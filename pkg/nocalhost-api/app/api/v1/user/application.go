@@ -49,7 +49,7 @@ func listByApplication(c *gin.Context, inApp bool) ([]*model.UserList, error) {
 		return nil, errno.ErrListApplicationUser
 	}
 
-	userList, err := service.Svc.UserSvc.GetUserList(c)
+	userList, _, err := service.Svc.UserSvc.GetUserList(c, model.UserListQuery{})
 	if err != nil {
 		log.Error(err)
 		return nil, errno.ErrListApplicationUser
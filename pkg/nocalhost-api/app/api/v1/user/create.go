@@ -8,6 +8,7 @@ package user
 import (
 	"nocalhost/internal/nocalhost-api/service"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
 
@@ -32,12 +33,23 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	// the roles of users:write can not create administrators
+	if *req.IsAdmin != 0 && !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrUpdateUserDenied, nil)
+		return
+	}
+
 	if req.Password != req.ConfirmPassword {
 		log.Warnf("twice password is not same")
 		api.SendResponse(c, errno.ErrTwicePasswordNotMatch, nil)
 		return
 	}
 
+	if err := service.Svc.UserSvc.CheckPassword(c, 0, req.Password); err != nil {
+		api.SendResponse(c, errno.ErrPasswordPolicy, err.Error())
+		return
+	}
+
 	u, err := service.Svc.UserSvc.Create(c, req.Email, req.Password, req.Name, "", 0, req.Status, req.IsAdmin)
 	if err != nil {
 		log.Warnf("register err: %v", err)
@@ -0,0 +1,140 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	userSvc "nocalhost/internal/nocalhost-api/service/user"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+const maxCsvImportRows = 1000
+
+// ImportCsv Bulk create users from csv
+// @Summary Bulk create users from csv
+// @Description Create users from csv with columns: email, name, role, cluster quota. Role can be
+// @Description 'admin', 'user' or the name of rbac role. All users are created in a single transaction,
+// @Description and the result of each row is returned
+// @Tags Users
+// @Accept  multipart/form-data
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param upload formData file true "The csv file"
+// @Success 200 {object} userSvc.ImportResult
+// @Router /v1/users/import_csv [post]
+func ImportCsv(c *gin.Context) {
+	var reader io.Reader = c.Request.Body
+	if file, err := c.FormFile("upload"); err == nil {
+		fh, err := file.Open()
+		if err != nil {
+			api.SendResponse(c, errno.ErrUserImport, err.Error())
+			return
+		}
+		defer fh.Close()
+		reader = fh
+	}
+
+	records, err := readCsv(reader)
+	if err != nil {
+		api.SendResponse(c, errno.ErrUserImport, err.Error())
+		return
+	}
+
+	rows := make([]userSvc.ImportRow, 0, len(records))
+	roles := make([]*model.RoleModel, 0, len(records))
+	for i, record := range records {
+		row, r := parseCsvRow(c, record)
+		row.Line = i + 1
+		rows = append(rows, row)
+		roles = append(roles, r)
+	}
+
+	results, ok := service.Svc.UserSvc.BulkCreate(c, rows)
+	if !ok {
+		api.SendResponse(c, errno.ErrUserImport, results)
+		return
+	}
+
+	for i, r := range roles {
+		if r == nil {
+			continue
+		}
+		if _, err := service.Svc.RoleSvc.Bind(c, r.ID, results[i].UserId, model.RoleScopeGlobal, 0); err != nil {
+			log.Warnf("Fail to bind role %s to imported user %s: %v", r.Name, results[i].Email, err)
+			results[i].Error = fmt.Sprintf("user created but fail to bind role %s", r.Name)
+		}
+	}
+
+	api.SendResponse(c, nil, results)
+}
+
+// readCsv the header line is skipped if present
+func readCsv(reader io.Reader) ([][]string, error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > 0 && len(records[0]) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "email") {
+		records = records[1:]
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no user found in csv")
+	}
+	if len(records) > maxCsvImportRows {
+		return nil, fmt.Errorf("too many users in csv, at most %d at a time", maxCsvImportRows)
+	}
+	return records, nil
+}
+
+func parseCsvRow(c *gin.Context, record []string) (userSvc.ImportRow, *model.RoleModel) {
+	column := func(i int) string {
+		if i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := userSvc.ImportRow{Email: column(0), Name: column(1)}
+
+	var role *model.RoleModel
+	switch name := column(2); strings.ToLower(name) {
+	case "", "user":
+	case "admin":
+		row.IsAdmin = true
+	default:
+		r, err := service.Svc.RoleSvc.GetByName(c, name)
+		if err != nil {
+			row.Err = fmt.Sprintf("role %s not found", name)
+		}
+		role = r
+	}
+
+	if quota := column(3); quota != "" {
+		q, err := strconv.ParseUint(quota, 10, 64)
+		if err != nil {
+			row.Err = fmt.Sprintf("cluster quota %s is not a number", quota)
+		}
+		row.ClusterQuota = q
+	}
+	return row, role
+}
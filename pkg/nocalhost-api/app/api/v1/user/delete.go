@@ -42,7 +42,7 @@ func Delete(c *gin.Context) {
 	clusterUserList, err := service.Svc.ClusterUserSvc.GetJoinCluster(c, condition)
 	if len(clusterUserList) > 0 {
 		for _, clusterUser := range clusterUserList {
-			goClient, err := clientgo.NewAdminGoClient([]byte(clusterUser.AdminClusterKubeConfig))
+			goClient, err := clientgo.NewAdminGoClientWithContext(c, []byte(clusterUser.AdminClusterKubeConfig))
 			if err != nil {
 				log.Warnf("try to delete userid %d while create go-client fail", clusterUser.UserId)
 				continue
@@ -80,7 +80,7 @@ func Delete(c *gin.Context) {
 		}
 
 		// new client go
-		clientGo, err := clientgo.NewAdminGoClient([]byte(cl.KubeConfig))
+		clientGo, err := clientgo.NewAdminGoClientWithContext(c, []byte(cl.KubeConfig))
 		if err != nil {
 			log.Error(err)
 			continue
@@ -105,6 +105,22 @@ func Delete(c *gin.Context) {
 		return
 	}
 
+	if err := service.Svc.TokenSvc.RevokeAll(c, userId); err != nil {
+		log.Warnf("try to delete access tokens of user %d fail: %v", userId, err)
+	}
+
+	if err := service.Svc.RoleSvc.UnbindAll(c, userId); err != nil {
+		log.Warnf("try to delete role bindings of user %d fail: %v", userId, err)
+	}
+
+	if err := service.Svc.TeamSvc.RemoveMemberFromAll(c, userId); err != nil {
+		log.Warnf("try to remove user %d from teams fail: %v", userId, err)
+	}
+
+	if err := service.Svc.QuotaSvc.Delete(c, model.QuotaSubjectUser, userId); err != nil {
+		log.Warnf("try to delete quota of user %d fail: %v", userId, err)
+	}
+
 	// if delete normal user, needs to delete cluster which added by this user
 	if user.IsAdmin != nil && *user.IsAdmin != 1 {
 		err = service.Svc.ClusterSvc.DeleteByCreator(c, userId)
@@ -9,7 +9,9 @@ import (
 	"context"
 	"github.com/spf13/cast"
 	"strconv"
+	"strings"
 
+	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
 	"nocalhost/pkg/nocalhost-api/app/api"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
@@ -75,14 +77,31 @@ func GetMe(c *gin.Context) {
 
 // Get Get user list
 // @Summary Get user list
-// @Description Get userlist
+// @Description Get userlist, paged with total count if any of size, search, status or sort is given
 // @Tags Users
 // @Accept  json
 // @Produce  json
 // @param Authorization header string true "Authorization"
+// @Param page query int false "Page number, start from 1"
+// @Param size query int false "Page size"
+// @Param search query string false "Search in email and name"
+// @Param status query int false "Filter by status, 1 active 0 inactive"
+// @Param sort query string false "Sort by id, name, email, status, created_at or cluster_count"
+// @Param order query string false "asc or desc"
 // @Success 200 {object} model.UserList "Get user list"
 // @Router /v1/users [get]
 func GetList(c *gin.Context) {
+	var req ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if req.paged() {
+		getPagedList(c, req)
+		return
+	}
+
 	page := c.Query("page")
 	limit := c.Query("limit")
 
@@ -101,3 +120,50 @@ func GetList(c *gin.Context) {
 	)
 	api.SendResponse(c, nil, u)
 }
+
+func getPagedList(c *gin.Context, req ListRequest) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Size <= 0 || req.Size > maxPageSize {
+		req.Size = defaultPageSize
+	}
+
+	if req.Sort != "" {
+		if _, ok := model.UserListSortFields[req.Sort]; !ok {
+			api.SendResponse(c, errno.ErrParam, nil)
+			return
+		}
+	}
+
+	list, total, err := service.Svc.UserSvc.GetUserList(
+		c, model.UserListQuery{
+			Page:   req.Page,
+			Size:   req.Size,
+			Search: strings.TrimSpace(req.Search),
+			Status: req.Status,
+			Sort:   req.Sort,
+			Desc:   strings.EqualFold(req.Order, "desc"),
+		},
+	)
+	if err != nil {
+		log.Warnf("get user list err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	hasMore := 0
+	if uint64(req.Page*req.Size) < total {
+		hasMore = 1
+	}
+
+	api.SendResponse(
+		c, nil, ListResponse{
+			TotalCount: total,
+			HasMore:    hasMore,
+			PageKey:    "page",
+			PageValue:  req.Page,
+			Items:      list,
+		},
+	)
+}
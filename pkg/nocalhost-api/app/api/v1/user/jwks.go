@@ -0,0 +1,28 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/pkg/nocalhost-api/pkg/token"
+)
+
+// JWKS the public keys verifying the access tokens
+// @Summary The public keys verifying the access tokens
+// @Description The keys of jwt_keys in JSON web key set, empty if the tokens are signed by jwt_secret
+// @Tags Users
+// @Produce  json
+// @Success 200 {object} map[string][]token.JWK "{"keys":[]}"
+// @Router /.well-known/jwks.json [get]
+func JWKS(c *gin.Context) {
+	// the keys rotate rarely, the verifiers may cache them for a while
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Writer.Header().Del("Expires")
+	c.JSON(http.StatusOK, gin.H{"keys": token.JWKS()})
+}
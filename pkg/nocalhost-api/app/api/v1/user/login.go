@@ -6,21 +6,43 @@
 package user
 
 import (
-	"nocalhost/internal/nocalhost-api/service/ldap"
+	"errors"
 	"nocalhost/pkg/nocalhost-api/pkg/token"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
 
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/internal/nocalhost-api/service"
+	userSvc "nocalhost/internal/nocalhost-api/service/user"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
 )
 
 func RefreshToken(c *gin.Context) {
-	t, rt, err := token.RefreshFromRequest(c)
+	refreshTokenCtx, err := token.ParseRefreshRequest(c)
+	if err == nil && service.Svc.UserSvc.IsTokenRevoked(refreshTokenCtx) {
+		err = errors.New("refresh token is revoked")
+	}
+
+	// tokens issued before sessions tracked have no session
+	if err == nil && refreshTokenCtx.SessionID != "" {
+		if !service.Svc.SessionSvc.Validate(c, refreshTokenCtx.SessionID, refreshTokenCtx.UserID, c.ClientIP()) {
+			err = errors.New("session is revoked")
+		} else if err = service.Svc.SessionSvc.Extend(c, refreshTokenCtx.SessionID); err != nil {
+			log.Warnf("extend session err: %v", err)
+		}
+	}
+
+	var t, rt string
+	if err == nil {
+		audit.SetUser(c, refreshTokenCtx.UserID)
+		t, rt, err = token.Sign(*refreshTokenCtx)
+	}
 
 	if err != nil {
 		if strings.Contains(err.Error(), "allow") {
@@ -40,6 +62,133 @@ func RefreshToken(c *gin.Context) {
 	)
 }
 
+// Logout Revoke all tokens of current user
+// @Summary Revoke all tokens of current user
+// @Description Revoke all tokens of current user, include the refresh tokens
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":null}"
+// @Router /v1/me/logout [post]
+func Logout(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrLoginRequired, nil)
+		return
+	}
+
+	if err := terminateSessions(c, userId); err != nil {
+		log.Warnf("logout err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+// RevokeTokens Revoke all tokens of the user
+// @Summary Revoke all tokens of the user
+// @Description Admin force-logout the user by revoking all the tokens issued to him, personal access tokens included
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "The user's database id index num"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":null}"
+// @Router /v1/users/{id}/revoke_tokens [post]
+func RevokeTokens(c *gin.Context) {
+	userId := cast.ToUint64(c.Param("id"))
+	if userId == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if err := terminateSessions(c, userId); err != nil {
+		log.Warnf("revoke tokens err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	// unlike logout, the personal access tokens are revoked by admin as well
+	if err := service.Svc.TokenSvc.RevokeAll(c, userId); err != nil {
+		log.Warnf("revoke access tokens err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+// Impersonate Act as the user to reproduce the permission problems
+// @Summary Impersonate the user
+// @Description Admin get a short-lived token to act as the user, the requests made with it are tagged with the admin
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "The user's database id index num"
+// @Success 200 {object} user.ImpersonateResponse "{"code":0,"message":"OK","data":{"token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"}}"
+// @Router /v1/users/{id}/impersonate [post]
+func Impersonate(c *gin.Context) {
+	userId := cast.ToUint64(c.Param("id"))
+	if userId == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	adminId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	sign, expiresAt, err := service.Svc.UserSvc.Impersonate(c, adminId, userId)
+	switch {
+	case err == userSvc.ErrImpersonateNotAdmin:
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	case err == userSvc.ErrImpersonateTarget:
+		api.SendResponse(c, errno.ErrImpersonateUser, nil)
+		return
+	case err != nil:
+		log.Warnf("impersonate user %d err: %v", userId, err)
+		api.SendResponse(c, errno.ErrUserNotFound, nil)
+		return
+	}
+
+	api.SendResponse(
+		c, nil, ImpersonateResponse{
+			Token:     sign,
+			ExpiresAt: expiresAt.Unix(),
+			ActorId:   adminId,
+			UserId:    userId,
+		},
+	)
+}
+
+// UnlockLogin Unlock the user locked by login failures
+// @Summary Unlock the user locked by login failures
+// @Description Admin unlock the user locked by continuous login failures
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "The user's database id index num"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":null}"
+// @Router /v1/users/{id}/unlock [post]
+func UnlockLogin(c *gin.Context) {
+	userId := cast.ToUint64(c.Param("id"))
+	if userId == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if err := service.Svc.UserSvc.UnlockLogin(c, userId); err != nil {
+		log.Warnf("unlock user err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
 // Login Web and plug-in login
 // @Summary Web and plug-in login
 // @Description Web and plug-in login
@@ -49,6 +198,8 @@ func RefreshToken(c *gin.Context) {
 // @Success 200 {string} json "{"code":0,"message":"OK","data":{"token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"}}"
 // @Router /v1/login [post]
 func Login(c *gin.Context) {
+	defer countLogin(c, "password")
+
 	// Binding the data with the u struct.
 	var req LoginCredentials
 	if err := c.Bind(&req); err != nil {
@@ -57,11 +208,12 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	log.Infof("login req %#v", req)
+	// the password and the one time password are never logged
+	log.Infof("login req of %s", req.Email)
 
 	// check param
 	if req.Email == "" || req.Password == "" {
-		log.Warnf("email or password is empty: %v", req)
+		log.Warnf("email or password is empty, email: %s", req.Email)
 		api.SendResponse(c, errno.ErrParam, nil)
 		return
 	}
@@ -73,38 +225,38 @@ func Login(c *gin.Context) {
 		api.SendResponse(c, errno.ErrEmailOrPassword, nil)
 		return
 	}
+	audit.SetUser(c, usr.ID)
+
+	if err := service.Svc.UserSvc.CheckLoginLocked(c, usr.ID, c.ClientIP()); err != nil {
+		api.SendResponse(c, errno.ErrLoginLocked, nil)
+		return
+	}
 
 	ldapBindPass := usr.LdapDN != "" &&
-		ldap.DoBindForLDAP("ldap://localhost", false, false, usr.LdapDN, req.Password) == nil
+		service.Svc.LdapSvc.DoBindForConfiguredLDAP(usr.LdapDN, req.Password) == nil
 
-	if !ldapBindPass {
-		err = service.Svc.UserSvc.EmailLogin(c, req.Email, req.Password)
-		if err != nil {
-			if strings.Contains(err.Error(), "allow") {
-				api.SendResponse(c, errno.ErrUserNotAllow, nil)
-				return
-			}
+	if ldapBindPass {
+		err = service.Svc.UserSvc.LdapLogin(c, usr, req.Otp, c.ClientIP())
+	} else {
+		err = service.Svc.UserSvc.EmailLogin(c, req.Email, req.Password, req.Otp, c.ClientIP())
+	}
 
+	if err != nil {
+		switch {
+		case strings.Contains(err.Error(), "allow"):
+			api.SendResponse(c, errno.ErrUserNotAllow, nil)
+		case err == userSvc.ErrLoginLocked:
+			api.SendResponse(c, errno.ErrLoginLocked, nil)
+		case err == userSvc.ErrSecondFactorRequired:
+			api.SendResponse(c, errno.ErrTwoFactorRequired, nil)
+		case err == userSvc.ErrSecondFactorInvalid:
+			api.SendResponse(c, errno.ErrTwoFactorInvalid, nil)
+		default:
 			log.Warnf("email login err: %v", err)
 			api.SendResponse(c, errno.ErrEmailOrPassword, nil)
-			return
 		}
-	}
-
-	sign, refreshToken, err := token.Sign(
-		token.Context{UserID: usr.ID, Username: usr.Username, Uuid: usr.Uuid, Email: usr.Email, IsAdmin: *usr.IsAdmin},
-	)
-
-	if err != nil {
-		log.Warnf("login err, fail to create token: %v", err)
-		api.SendResponse(c, errno.InternalServerError, nil)
 		return
 	}
 
-	api.SendResponse(
-		c, nil, model.Token{
-			Token:        sign,
-			RefreshToken: refreshToken,
-		},
-	)
+	sendToken(c, usr)
 }
@@ -0,0 +1,102 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+	"github.com/spf13/viper"
+
+	"nocalhost/pkg/nocalhost-api/pkg/token"
+)
+
+// loginStateExpire the time to login with the provider after the url is got
+const loginStateExpire = 10 * time.Minute
+
+// secondFactorProvider signs the challenges apart from the states of providers
+const secondFactorProvider = "2fa"
+
+func loginStateCookie(provider string) string {
+	return "nocalhost_login_state_" + provider
+}
+
+// newLoginState the random state of the authorization url of provider, it is
+// kept in a signed short-lived cookie to be checked by the callback, so one
+// can not be logged in by the authorization code of another (login CSRF)
+func newLoginState(c *gin.Context, provider string) string {
+	state := uuid.NewV4().String()
+	expire := time.Now().Add(loginStateExpire).Unix()
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(
+		loginStateCookie(provider), fmt.Sprintf("%s.%d.%s", state, expire, signLoginState(provider, state, expire)),
+		int(loginStateExpire.Seconds()), "/", "", c.Request.TLS != nil, true,
+	)
+	return state
+}
+
+// verifyLoginState whether the state of the callback is the one of the cookie
+// not expired, the cookie is cleared as the state can be used only once
+func verifyLoginState(c *gin.Context, provider, state string) bool {
+	value, err := c.Cookie(loginStateCookie(provider))
+	c.SetCookie(loginStateCookie(provider), "", -1, "/", "", c.Request.TLS != nil, true)
+	if err != nil || state == "" {
+		return false
+	}
+
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 || parts[0] != state {
+		return false
+	}
+	expire, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expire {
+		return false
+	}
+	return hmac.Equal([]byte(parts[2]), []byte(signLoginState(provider, state, expire)))
+}
+
+// newSecondFactorChallenge the signed short-lived challenge of the user logged
+// in by the identity provider, to verify the second factor by SecondFactorLogin
+// as the authorization code can not be used again
+func newSecondFactorChallenge(userId uint64) string {
+	id := strconv.FormatUint(userId, 10)
+	expire := time.Now().Add(loginStateExpire).Unix()
+	return fmt.Sprintf("%s.%d.%s", id, expire, signLoginState(secondFactorProvider, id, expire))
+}
+
+// verifySecondFactorChallenge the user of the challenge not expired
+func verifySecondFactorChallenge(challenge string) (uint64, bool) {
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	userId, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	expire, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expire {
+		return 0, false
+	}
+	return userId, hmac.Equal([]byte(parts[2]), []byte(signLoginState(secondFactorProvider, parts[0], expire)))
+}
+
+// signLoginState signs by jwt_refresh_secret, which is always a secret of
+// hmac while the access tokens may be signed by jwt_keys
+func signLoginState(provider, state string, expire int64) string {
+	mac := hmac.New(sha256.New, []byte(viper.GetString(token.JWT_REFRESH_SECRET)))
+	mac.Write([]byte(fmt.Sprintf("%s.%s.%d", provider, state, expire)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
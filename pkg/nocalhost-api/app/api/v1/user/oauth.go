@@ -0,0 +1,88 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/oauth"
+)
+
+// OauthAuthUrl Get the url of GitHub or GitLab to login
+// @Summary Get the url of GitHub or GitLab to login
+// @Description Get the url of GitHub or GitLab to login
+// @Tags Users
+// @Produce  json
+// @Param provider path string true "github or gitlab"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":{"url":"https://..."}}"
+// @Router /v1/login/oauth/{provider} [get]
+func OauthAuthUrl(c *gin.Context) {
+	name := c.Param("provider")
+	provider, err := oauth.Get(name)
+	if err != nil {
+		api.SendResponse(c, errno.ErrOauthNotEnabled, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, map[string]string{"url": provider.AuthCodeURL(newLoginState(c, name))})
+}
+
+// OauthLogin Login with GitHub or GitLab
+// @Summary Login with GitHub or GitLab
+// @Description Login with the authorization code of GitHub or GitLab, the user is matched by
+// @Description the verified email and will be created at first login, the state of the authorization url is required
+// @Tags Users
+// @Produce  json
+// @Param provider path string true "github or gitlab"
+// @Param login body user.OauthLoginRequest true "Oauth login info"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":{"token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"}}"
+// @Router /v1/login/oauth/{provider} [post]
+func OauthLogin(c *gin.Context) {
+	defer countLogin(c, "oauth")
+
+	var req OauthLoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		log.Warnf("oauth login bind param err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	provider := c.Param("provider")
+	if !oauth.Enabled(provider) {
+		api.SendResponse(c, errno.ErrOauthNotEnabled, nil)
+		return
+	}
+
+	if !verifyLoginState(c, provider, req.State) {
+		api.SendResponse(c, errno.ErrLoginState, nil)
+		return
+	}
+
+	usr, err := service.Svc.UserSvc.OauthLogin(c, provider, req.Code)
+	if err != nil {
+		switch {
+		case errors.Cause(err) == oauth.ErrNotAllowed:
+			api.SendResponse(c, errno.ErrOauthOrgNotAllowed, nil)
+		case strings.Contains(err.Error(), "allow"):
+			api.SendResponse(c, errno.ErrUserNotAllow, nil)
+		default:
+			log.Warnf("oauth login err: %v", err)
+			api.SendResponse(c, errno.ErrOauthLogin, nil)
+		}
+		return
+	}
+
+	audit.SetUser(c, usr.ID)
+	sendTokenWithSecondFactor(c, usr, req.Otp)
+}
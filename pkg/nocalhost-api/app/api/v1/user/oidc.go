@@ -0,0 +1,103 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/oidc"
+)
+
+// OidcAuthUrl Get the url of oidc provider to login
+// @Summary Get the url of oidc provider to login
+// @Description Get the url of oidc provider to login
+// @Tags Users
+// @Produce  json
+// @Success 200 {string} json "{"code":0,"message":"OK","data":{"url":"https://..."}}"
+// @Router /v1/login/oidc [get]
+func OidcAuthUrl(c *gin.Context) {
+	provider, err := oidc.Default()
+	if err != nil {
+		log.Warnf("oidc provider err: %v", err)
+		api.SendResponse(c, errno.ErrOidcNotEnabled, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, map[string]string{"url": provider.AuthCodeURL(newLoginState(c, "oidc"))})
+}
+
+// OidcLogin Login with oidc id_token or authorization code
+// @Summary Login with oidc id_token or authorization code
+// @Description Login with oidc id_token or authorization code, user will be created at first login.
+// @Description The state of the authorization url is required with code
+// @Tags Users
+// @Produce  json
+// @Param login body user.OidcLoginRequest true "Oidc login info"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":{"token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"}}"
+// @Router /v1/login/oidc [post]
+func OidcLogin(c *gin.Context) {
+	defer countLogin(c, "oidc")
+
+	var req OidcLoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		log.Warnf("oidc login bind param err: %v", err)
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if req.IdToken == "" && req.Code == "" {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if !oidc.Enabled() {
+		api.SendResponse(c, errno.ErrOidcNotEnabled, nil)
+		return
+	}
+
+	idToken := req.IdToken
+	if idToken == "" {
+		if !verifyLoginState(c, "oidc", req.State) {
+			api.SendResponse(c, errno.ErrLoginState, nil)
+			return
+		}
+
+		provider, err := oidc.Default()
+		if err != nil {
+			log.Warnf("oidc provider err: %v", err)
+			api.SendResponse(c, errno.ErrOidcNotEnabled, nil)
+			return
+		}
+
+		if idToken, err = provider.Exchange(req.Code); err != nil {
+			log.Warnf("oidc login err: %v", err)
+			api.SendResponse(c, errno.ErrOidcLogin, nil)
+			return
+		}
+	}
+
+	usr, err := service.Svc.UserSvc.OidcLogin(c, idToken)
+	if err != nil {
+		if strings.Contains(err.Error(), "allow") {
+			api.SendResponse(c, errno.ErrUserNotAllow, nil)
+			return
+		}
+
+		log.Warnf("oidc login err: %v", err)
+		api.SendResponse(c, errno.ErrOidcLogin, nil)
+		return
+	}
+
+	audit.SetUser(c, usr.ID)
+	sendTokenWithSecondFactor(c, usr, req.Otp)
+}
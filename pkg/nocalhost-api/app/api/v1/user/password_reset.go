@@ -0,0 +1,85 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/service"
+	userSvc "nocalhost/internal/nocalhost-api/service/user"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// ForgotPassword Send password reset mail
+// @Summary Send password reset mail
+// @Description Send password reset mail, always success whether the email exists or not
+// @Tags Users
+// @Produce  json
+// @Param forgot body user.ForgotPasswordRequest true "The email of user"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/password/forgot [post]
+func ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if err := service.Svc.UserSvc.RequestPasswordReset(c, req.Email); err != nil {
+		log.Warnf("request password reset err: %v", err)
+		api.SendResponse(c, errno.ErrPasswordResetMail, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+// ResetPassword Reset password with the token from mail
+// @Summary Reset password with the token from mail
+// @Description Reset password with the token from mail, all the login session will be invalid
+// @Tags Users
+// @Produce  json
+// @Param reset body user.ResetPasswordRequest true "The token and new password"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/password/reset [post]
+func ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if req.Password != req.ConfirmPassword {
+		api.SendResponse(c, errno.ErrTwicePasswordNotMatch, nil)
+		return
+	}
+
+	userId, err := service.Svc.UserSvc.ResetPassword(c, req.Token, req.Password)
+	if err != nil {
+		if err == userSvc.ErrPasswordResetTokenInvalid {
+			api.SendResponse(c, errno.ErrPasswordResetToken, nil)
+			return
+		}
+
+		if userSvc.IsPasswordPolicyError(err) {
+			api.SendResponse(c, errno.ErrPasswordPolicy, err.Error())
+			return
+		}
+
+		log.Warnf("reset password err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	// the access tokens are long-lived, revoked only with the password changed
+	if err := service.Svc.TokenSvc.RevokeAll(c, userId); err != nil {
+		log.Warnf("revoke access tokens of user %d err: %v", userId, err)
+	}
+
+	api.SendResponse(c, nil, nil)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"nocalhost/internal/nocalhost-api/service"
+	userSvc "nocalhost/internal/nocalhost-api/service/user"
 	"nocalhost/pkg/nocalhost-api/app/api"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
@@ -47,6 +48,11 @@ func Register(c *gin.Context) {
 
 	err := service.Svc.UserSvc.Register(c, req.Email, req.Password)
 	if err != nil {
+		if userSvc.IsPasswordPolicyError(err) {
+			api.SendResponse(c, errno.ErrPasswordPolicy, err.Error())
+			return
+		}
+
 		log.Warnf("register err: %v", err)
 		api.SendResponse(c, errno.ErrRegisterFailed, nil)
 		return
@@ -0,0 +1,170 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/token"
+)
+
+const loggedInKey = "nocalhost:logged_in"
+
+// countLogin counts the login attempt when the handler returns, it succeeds
+// only if the tokens are sent
+func countLogin(c *gin.Context, method string) {
+	metrics.Login(method, c.GetBool(loggedInKey))
+}
+
+// sendToken starts a session for the logged in user and responds the tokens of it
+func sendToken(c *gin.Context, usr *model.UserBaseModel) {
+	session, err := service.Svc.SessionSvc.Create(c, usr.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		log.Warnf("login err, fail to create session: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	sign, refreshToken, err := token.Sign(
+		token.Context{
+			UserID: usr.ID, Username: usr.Username, Uuid: usr.Uuid, Email: usr.Email, IsAdmin: *usr.IsAdmin,
+			SessionID: session.Sid, Generation: usr.TokenGeneration,
+		},
+	)
+	if err != nil {
+		log.Warnf("login err, fail to create token: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	c.Set(loggedInKey, true)
+	api.SendResponse(
+		c, nil, model.Token{
+			Token:        sign,
+			RefreshToken: refreshToken,
+		},
+	)
+}
+
+// ListSessions List active sessions of current user
+// @Summary List active sessions of current user
+// @Description List the login sessions not revoked or expired, with device, ip and last seen
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} model.SessionModel
+// @Router /v1/me/sessions [get]
+func ListSessions(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	listSessions(c, userId, c.GetString("sid"))
+}
+
+// RevokeSession Revoke a session of current user
+// @Summary Revoke a session of current user
+// @Description Revoke the session, the tokens of it are invalid immediately
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "Session ID"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":null}"
+// @Router /v1/me/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	if err := service.Svc.SessionSvc.Revoke(c, userId, cast.ToUint64(c.Param("id"))); err != nil {
+		log.Warnf("revoke session err: %v", err)
+		api.SendResponse(c, errno.ErrSessionNotFound, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+// ListUserSessions List active sessions of the user
+// @Summary List active sessions of the user
+// @Description Admin list the login sessions of the user
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "The user's database id index num"
+// @Success 200 {object} model.SessionModel
+// @Router /v1/users/{id}/sessions [get]
+func ListUserSessions(c *gin.Context) {
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	listSessions(c, cast.ToUint64(c.Param("id")), "")
+}
+
+// TerminateSessions Terminate all sessions of the user
+// @Summary Terminate all sessions of the user
+// @Description Admin terminate all the sessions of the compromised user, include the issued tokens not belong to any session
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "The user's database id index num"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":null}"
+// @Router /v1/users/{id}/sessions [delete]
+func TerminateSessions(c *gin.Context) {
+	userId := cast.ToUint64(c.Param("id"))
+	if userId == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if !ginbase.IsAdmin(c) {
+		api.SendResponse(c, errno.ErrPermissionDenied, nil)
+		return
+	}
+
+	if err := terminateSessions(c, userId); err != nil {
+		log.Warnf("terminate sessions err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+func terminateSessions(c *gin.Context, userId uint64) error {
+	if err := service.Svc.SessionSvc.RevokeAll(c, userId); err != nil {
+		return err
+	}
+	return service.Svc.UserSvc.RevokeTokens(c, userId)
+}
+
+func listSessions(c *gin.Context, userId uint64, current string) {
+	sessions, err := service.Svc.SessionSvc.List(c, userId)
+	if err != nil {
+		log.Warnf("list sessions err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	for _, s := range sessions {
+		s.Current = current != "" && s.Sid == current
+	}
+	api.SendResponse(c, nil, sessions)
+}
@@ -0,0 +1,205 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package user
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	userSvc "nocalhost/internal/nocalhost-api/service/user"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// EnrollTotp Enroll two-factor authentication
+// @Summary Enroll two-factor authentication
+// @Description Generate totp secret, provisioning uri and recovery codes, take effect after activated
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Success 200 {object} user.TotpEnrollResponse
+// @Router /v1/me/2fa/enroll [post]
+func EnrollTotp(c *gin.Context) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrLoginRequired, nil)
+		return
+	}
+
+	secret, uri, codes, err := service.Svc.UserSvc.EnrollTotp(c, userId)
+	if err != nil {
+		log.Warnf("enroll totp err: %v", err)
+		api.SendResponse(c, errno.ErrTwoFactorEnroll, nil)
+		return
+	}
+
+	api.SendResponse(
+		c, nil, TotpEnrollResponse{
+			Secret:          secret,
+			ProvisioningUri: uri,
+			RecoveryCodes:   codes,
+		},
+	)
+}
+
+// ActivateTotp Activate two-factor authentication
+// @Summary Activate two-factor authentication
+// @Description Activate two-factor authentication with the code from authenticator
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param code body user.TotpCodeRequest true "Totp code"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/me/2fa/activate [post]
+func ActivateTotp(c *gin.Context) {
+	totpCode(c, service.Svc.UserSvc.ActivateTotp)
+}
+
+// DisableTotp Disable two-factor authentication
+// @Summary Disable two-factor authentication
+// @Description Disable two-factor authentication with the code from authenticator or recovery code
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param code body user.TotpCodeRequest true "Totp code or recovery code"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/me/2fa/disable [post]
+func DisableTotp(c *gin.Context) {
+	totpCode(c, service.Svc.UserSvc.DisableTotp)
+}
+
+// ResetTotp Reset two-factor authentication of the user
+// @Summary Reset two-factor authentication of the user
+// @Description Admin reset two-factor authentication for the user lost his authenticator
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param id path uint64 true "The user's database id index num"
+// @Success 200 {object} api.Response "{"code":0,"message":"OK","data":null}"
+// @Router /v1/users/{id}/2fa/reset [post]
+func ResetTotp(c *gin.Context) {
+	userId := cast.ToUint64(c.Param("id"))
+	if userId == 0 {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	if err := service.Svc.UserSvc.ResetTotp(c, userId); err != nil {
+		log.Warnf("reset totp err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
+
+// SecondFactorLogin Login with the second factor after the identity provider
+// @Summary Login with the second factor after the identity provider
+// @Description Verify the code from authenticator or recovery code of the user logged in by oidc or oauth,
+// @Description the challenge is responded with the error of two-factor authentication by the login of them
+// @Tags Users
+// @Produce  json
+// @Param login body user.SecondFactorLoginRequest true "Challenge and totp code"
+// @Success 200 {string} json "{"code":0,"message":"OK","data":{"token":"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9"}}"
+// @Router /v1/login/2fa [post]
+func SecondFactorLogin(c *gin.Context) {
+	defer countLogin(c, "2fa")
+
+	var req SecondFactorLoginRequest
+	if err := c.ShouldBind(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	userId, ok := verifySecondFactorChallenge(req.Challenge)
+	if !ok {
+		api.SendResponse(c, errno.ErrLoginState, nil)
+		return
+	}
+
+	usr, err := service.Svc.UserSvc.GetUserByID(c, userId)
+	if err != nil || usr.ID == 0 {
+		api.SendResponse(c, errno.ErrLoginState, nil)
+		return
+	}
+	audit.SetUser(c, usr.ID)
+
+	// the user may be disabled after the challenge
+	if usr.Status == nil || *usr.Status == 0 {
+		api.SendResponse(c, errno.ErrUserNotAllow, nil)
+		return
+	}
+
+	sendTokenWithSecondFactor(c, usr, req.Otp)
+}
+
+// sendTokenWithSecondFactor sends the token to the user logged in by the
+// identity provider if the second factor is verified, or the challenge to
+// verify it by SecondFactorLogin
+func sendTokenWithSecondFactor(c *gin.Context, usr *model.UserBaseModel, otp string) {
+	if !usr.IsTotpEnabled() {
+		sendToken(c, usr)
+		return
+	}
+
+	if otp == "" {
+		api.SendResponse(
+			c, errno.ErrTwoFactorRequired, SecondFactorChallenge{Challenge: newSecondFactorChallenge(usr.ID)},
+		)
+		return
+	}
+
+	if err := service.Svc.UserSvc.SecondFactorLogin(c, usr, otp, c.ClientIP()); err != nil {
+		switch err {
+		case userSvc.ErrLoginLocked:
+			api.SendResponse(c, errno.ErrLoginLocked, nil)
+		case userSvc.ErrSecondFactorInvalid:
+			api.SendResponse(
+				c, errno.ErrTwoFactorInvalid, SecondFactorChallenge{Challenge: newSecondFactorChallenge(usr.ID)},
+			)
+		default:
+			log.Warnf("two-factor authentication err: %v", err)
+			api.SendResponse(c, errno.InternalServerError, nil)
+		}
+		return
+	}
+
+	sendToken(c, usr)
+}
+
+func totpCode(c *gin.Context, fun func(ctx context.Context, userId uint64, code string) error) {
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		api.SendResponse(c, errno.ErrLoginRequired, nil)
+		return
+	}
+
+	var req TotpCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+
+	if err := fun(c, userId, req.Code); err != nil {
+		if err == userSvc.ErrSecondFactorInvalid {
+			api.SendResponse(c, errno.ErrTwoFactorInvalid, nil)
+			return
+		}
+
+		log.Warnf("two-factor authentication err: %v", err)
+		api.SendResponse(c, errno.ErrTwoFactorEnroll, nil)
+		return
+	}
+
+	api.SendResponse(c, nil, nil)
+}
@@ -23,7 +23,9 @@ import (
 	"github.com/spf13/cast"
 
 	"nocalhost/internal/nocalhost-api/service"
+	userSvc "nocalhost/internal/nocalhost-api/service/user"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
 )
@@ -53,6 +55,10 @@ func Update(c *gin.Context) {
 		return
 	}
 
+	if before, err := service.Svc.UserSvc.GetUserByID(c, userId); err == nil {
+		audit.SetBefore(c, before)
+	}
+
 	userMap := model.UserBaseModel{}
 	if len(req.Email) > 0 {
 		userMap.Email = req.Email
@@ -61,6 +67,15 @@ func Update(c *gin.Context) {
 		userMap.Name = req.Name
 	}
 	if len(req.Password) > 0 {
+		if err := service.Svc.UserSvc.CheckPassword(c, userId, req.Password); err != nil {
+			if userSvc.IsPasswordPolicyError(err) {
+				api.SendResponse(c, errno.ErrPasswordPolicy, err.Error())
+			} else {
+				api.SendResponse(c, errno.InternalServerError, nil)
+			}
+			return
+		}
+
 		pwd, err := auth.Encrypt(req.Password)
 		if err != nil {
 			api.SendResponse(c, errno.InternalServerError, nil)
@@ -78,12 +93,24 @@ func Update(c *gin.Context) {
 		if req.Status != nil {
 			userMap.Status = req.Status
 		}
+		if req.ClusterQuota != nil {
+			if err := service.Svc.UserSvc.UpdateClusterQuota(c, userId, *req.ClusterQuota); err != nil {
+				log.Warnf("[user] update user cluster quota err, %v", err)
+				api.SendResponse(c, errno.InternalServerError, nil)
+				return
+			}
+		}
 	} else {
 		uid, _ := c.Get("userId")
 		if cast.ToUint64(uid) != userId {
 			api.SendResponse(c, errno.ErrPermissionDenied, nil)
 			return
 		}
+		// reject instead of ignoring, so that granting admin never silently fails
+		if req.IsAdmin != nil && *req.IsAdmin != 0 {
+			api.SendResponse(c, errno.ErrUpdateUserDenied, nil)
+			return
+		}
 	}
 
 	result, err := service.Svc.UserSvc.UpdateUser(context.TODO(), userId, &userMap)
@@ -93,6 +120,16 @@ func Update(c *gin.Context) {
 		return
 	}
 
+	// all the tokens should be invalid after password changed
+	if len(req.Password) > 0 {
+		if err := service.Svc.UserSvc.RevokeTokens(context.TODO(), userId); err != nil {
+			log.Warnf("[user] revoke user tokens err, %v", err)
+		}
+		if err := service.Svc.TokenSvc.RevokeAll(context.TODO(), userId); err != nil {
+			log.Warnf("[user] revoke user access tokens err, %v", err)
+		}
+	}
+
 	api.SendResponse(c, nil, result)
 }
 
@@ -44,6 +44,8 @@ type UpdateUserRequest struct {
 	Password string  `json:"password" form:"password"`
 	Status   *uint64 `json:"status" form:"status"`
 	IsAdmin  *uint64 `json:"is_admin" form:"is_admin"`
+
+	ClusterQuota *uint64 `json:"cluster_quota" form:"cluster_quota"`
 }
 
 // LoginCredentials
@@ -51,6 +53,68 @@ type LoginCredentials struct {
 	Email    string `json:"email" form:"email" binding:"required"`
 	Password string `json:"password" form:"password" binding:"required"`
 	From     string `json:"from" form:"from" example:"only use for plugin, web interface do not send this key"`
+	Otp      string `json:"otp" form:"otp" example:"totp code or recovery code, required if two-factor authentication enabled"`
+}
+
+// TotpCodeRequest
+type TotpCodeRequest struct {
+	Code string `json:"code" form:"code" binding:"required"`
+}
+
+// TotpEnrollResponse
+// ImpersonateResponse
+type ImpersonateResponse struct {
+	Token     string `json:"token"`
+	ExpiresAt int64  `json:"expires_at"`
+	ActorId   uint64 `json:"actor_id"`
+	UserId    uint64 `json:"user_id"`
+}
+
+type TotpEnrollResponse struct {
+	Secret          string   `json:"secret"`
+	ProvisioningUri string   `json:"provisioning_uri"`
+	RecoveryCodes   []string `json:"recovery_codes"`
+}
+
+// OidcLoginRequest
+type OidcLoginRequest struct {
+	IdToken string `json:"id_token" form:"id_token"`
+	Code    string `json:"code" form:"code"`
+	// State the state of the callback, required with code
+	State string `json:"state" form:"state"`
+	Otp   string `json:"otp" form:"otp"`
+}
+
+// OauthLoginRequest
+type OauthLoginRequest struct {
+	Code string `json:"code" form:"code" binding:"required"`
+	// State the state of the callback, checked against the one of the authorization url
+	State string `json:"state" form:"state"`
+	Otp   string `json:"otp" form:"otp"`
+}
+
+// SecondFactorLoginRequest
+type SecondFactorLoginRequest struct {
+	// Challenge the one responded with the error of two-factor authentication
+	Challenge string `json:"challenge" form:"challenge" binding:"required"`
+	Otp       string `json:"otp" form:"otp" binding:"required"`
+}
+
+// SecondFactorChallenge
+type SecondFactorChallenge struct {
+	Challenge string `json:"challenge"`
+}
+
+// ForgotPasswordRequest
+type ForgotPasswordRequest struct {
+	Email string `json:"email" form:"email" binding:"required"`
+}
+
+// ResetPasswordRequest
+type ResetPasswordRequest struct {
+	Token           string `json:"token" form:"token" binding:"required"`
+	Password        string `json:"password" form:"password" binding:"required"`
+	ConfirmPassword string `json:"confirm_password" form:"confirm_password" binding:"required"`
 }
 
 // UpdateRequest
@@ -59,6 +123,26 @@ type UpdateRequest struct {
 	Sex    int    `json:"sex"`
 }
 
+const (
+	defaultPageSize = 20
+	maxPageSize     = 200
+)
+
+// ListRequest
+type ListRequest struct {
+	Page   int     `form:"page"`
+	Size   int     `form:"size"`
+	Search string  `form:"search"`
+	Status *uint64 `form:"status"`
+	Sort   string  `form:"sort"`
+	Order  string  `form:"order"`
+}
+
+// paged returns false for the legacy requests with only page and limit
+func (r ListRequest) paged() bool {
+	return r.Size > 0 || r.Search != "" || r.Status != nil || r.Sort != ""
+}
+
 // ListResponse
 type ListResponse struct {
 	TotalCount uint64      `json:"total_count"`
@@ -0,0 +1,65 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/swaggo/swag"
+
+	_ "nocalhost/docs" // the swagger document generated by swag
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/openapi"
+)
+
+var (
+	documentOnce = sync.Once{}
+	document     []byte
+	documentErr  error
+)
+
+// Document the OpenAPI 3 document of /v1 and /v2, converted from the
+// swagger document generated by swag, run `make api-docs` to refresh
+func Document() ([]byte, error) {
+	documentOnce.Do(
+		func() {
+			swagger, err := swag.ReadDoc()
+			if err != nil {
+				documentErr = err
+				return
+			}
+			doc, err := openapi.Convert([]byte(swagger))
+			if err != nil {
+				documentErr = err
+				return
+			}
+
+			routes := make([]openapi.Route, 0, len(Routes))
+			for _, r := range Routes {
+				routes = append(routes, r.Route)
+			}
+			openapi.AddVersion2(doc, routes)
+			document, documentErr = json.MarshalIndent(doc, "", "    ")
+		},
+	)
+	return document, documentErr
+}
+
+// OpenAPI serve the OpenAPI 3 document for generating typed clients
+func OpenAPI(c *gin.Context) {
+	doc, err := Document()
+	if err != nil {
+		log.Errorf("generate openapi document err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", doc)
+}
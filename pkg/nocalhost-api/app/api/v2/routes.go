@@ -0,0 +1,61 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package v2
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/pkg/nocalhost-api/app/api/v1/applications"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/cluster"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/cluster_user"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/user"
+	"nocalhost/pkg/nocalhost-api/pkg/openapi"
+)
+
+// Route the route of /v2, most of them are served by the handlers of /v1
+// with the envelope of /v2, so that the behaviors never diverge
+type Route struct {
+	openapi.Route
+	Handler gin.HandlerFunc
+}
+
+func route(method, path, legacy string, list bool, handler gin.HandlerFunc) Route {
+	return Route{
+		Route:   openapi.Route{Method: method, Path: path, Legacy: legacy, List: list},
+		Handler: handler,
+	}
+}
+
+// Routes of /v2, resources are named in plural, and the path parameter of
+// them is always :id
+var Routes = []Route{
+	route("GET", "/v2/users", "/v2/users", true, ListUsers),
+	route("GET", "/v2/users/:id", "/v1/users/:id", false, user.Get),
+	route("POST", "/v2/users", "/v1/users", false, user.Create),
+	route("PUT", "/v2/users/:id", "/v1/users/:id", false, user.Update),
+	route("DELETE", "/v2/users/:id", "/v1/users/:id", false, user.Delete),
+
+	route("GET", "/v2/clusters", "/v1/cluster", true, cluster.GetList),
+	route("GET", "/v2/clusters/:id", "/v1/cluster/:id/detail", false, cluster.GetDetail),
+	route("POST", "/v2/clusters", "/v1/cluster", false, cluster.Create),
+	route("PUT", "/v2/clusters/:id", "/v1/cluster/:id", false, cluster.Update),
+	route("DELETE", "/v2/clusters/:id", "/v1/cluster/:id", false, cluster.Delete),
+
+	route("GET", "/v2/applications", "/v1/application", true, applications.Get),
+	route("GET", "/v2/applications/:id", "/v1/application/:id", false, applications.GetDetail),
+	route("POST", "/v2/applications", "/v1/application", false, applications.Create),
+	route("PUT", "/v2/applications/:id", "/v1/application/:id", false, applications.Update),
+	route("DELETE", "/v2/applications/:id", "/v1/application/:id", false, applications.Delete),
+
+	route("GET", "/v2/dev_spaces", "/v2/dev_space", true, cluster_user.ListV2),
+	route(
+		"GET", "/v2/dev_spaces/:id", "/v1/dev_space/:id/detail", false,
+		cluster_user.GetJoinClusterAndAppAndUserDetail,
+	),
+	route("POST", "/v2/dev_spaces", "/v1/dev_space", false, cluster_user.Create),
+	route("PUT", "/v2/dev_spaces/:id", "/v1/dev_space/:id", false, cluster_user.Update),
+	route("DELETE", "/v2/dev_spaces/:id", "/v1/dev_space/:id", false, cluster_user.Delete),
+}
@@ -0,0 +1,67 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package v2
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+type UserListQuery struct {
+	Search string  `form:"search"`
+	Status *uint64 `form:"status"`
+	Sort   string  `form:"sort"`
+	Order  string  `form:"order"`
+}
+
+// ListUsers List users
+// @Summary List users
+// @Description List users paged by database, filtered by search and status
+// @Tags Users
+// @Produce  json
+// @param Authorization header string true "Authorization"
+// @Param search query string false "Search in email and name"
+// @Param status query int false "Filter by status, 1 active 0 inactive"
+// @Param sort query string false "Sort by id, name, email, status, created_at or cluster_count"
+// @Param order query string false "asc or desc"
+// @Success 200 {object} []model.UserList
+// @Router /v2/users [get]
+func ListUsers(c *gin.Context) {
+	var req UserListQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		api.SendResponse(c, errno.ErrBind, nil)
+		return
+	}
+	if _, ok := model.UserListSortFields[req.Sort]; req.Sort != "" && !ok {
+		api.SendResponse(c, errno.ErrParam, nil)
+		return
+	}
+
+	page, size := api.PageQuery(c)
+	list, total, err := service.Svc.UserSvc.GetUserList(
+		c, model.UserListQuery{
+			Page:   page,
+			Size:   size,
+			Search: strings.TrimSpace(req.Search),
+			Status: req.Status,
+			Sort:   req.Sort,
+			Desc:   strings.EqualFold(req.Order, "desc"),
+		},
+	)
+	if err != nil {
+		log.Warnf("list users err: %v", err)
+		api.SendResponse(c, errno.InternalServerError, nil)
+		return
+	}
+	api.SendList(c, list, page, size, int(total))
+}
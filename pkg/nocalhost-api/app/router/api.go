@@ -6,13 +6,27 @@
 package routers
 
 import (
+	"strings"
+
+	"nocalhost/pkg/nocalhost-api/app/api/scim"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/access_token"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/application_cluster"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/application_user"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/applications"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/audit_log"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/cluster"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/cluster_user"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/cost"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/dev_image"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/dev_space_template"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/garbage"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/git_credential"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/ldap"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/quota"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/registry_credential"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/role"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/service_account"
+	"nocalhost/pkg/nocalhost-api/app/api/v1/team"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/version"
 	"nocalhost/pkg/nocalhost-api/napp"
 
@@ -25,6 +39,7 @@ import (
 
 	"nocalhost/pkg/nocalhost-api/app/api"
 	"nocalhost/pkg/nocalhost-api/app/api/v1/user"
+	v2 "nocalhost/pkg/nocalhost-api/app/api/v2"
 
 	// import swagger handler
 	_ "nocalhost/docs" // docs is generated by Swag CLI, you have to import it.
@@ -35,10 +50,13 @@ import (
 func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 	// 使用中间件
 	g.Use(middleware.NoCache)
-	g.Use(middleware.Options)
-	g.Use(middleware.Secure)
+	g.Use(middleware.Options())
+	g.Use(middleware.Secure())
 	g.Use(middleware.Logging())
 	g.Use(middleware.RequestID())
+	g.Use(middleware.Trace())
+	g.Use(middleware.Metrics())
+	g.Use(middleware.Audit())
 	g.Use(mw...)
 
 	// 404 Handler.
@@ -53,6 +71,7 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 	if viper.GetString("app.run_mode") == napp.ModeDebug {
 		// swagger api docs
 		g.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+		g.GET("/v2/openapi.json", v2.OpenAPI)
 		// pprof router Performance analysis routing
 		// Closed by default, can be opened in development environment
 		// interview method: HOST/debug/pprof
@@ -64,12 +83,25 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 		// disable swagger docs for release  env=release
 		g.GET("/swagger/*any", ginSwagger.DisablingWrapHandler(swaggerFiles.Handler, "env"))
 	}
+	// the requests of credentials are limited by ip, and the ones creating
+	// the resources of clusters are limited by user
+	authLimit := middleware.RateLimit(middleware.LimitAuth)
+	expensive := middleware.RateLimit(middleware.LimitExpensive)
+
 	// version
 	g.GET("/v1/version", version.Get)
 
-	g.POST("/v1/register", user.Register)
-	g.POST("/v1/login", user.Login)
-	g.POST("/v1/token/refresh", user.RefreshToken)
+	g.POST("/v1/register", authLimit, user.Register)
+	g.POST("/v1/login", authLimit, user.Login)
+	g.GET("/v1/login/oidc", user.OidcAuthUrl)
+	g.POST("/v1/login/oidc", authLimit, user.OidcLogin)
+	g.GET("/v1/login/oauth/:provider", user.OauthAuthUrl)
+	g.POST("/v1/login/oauth/:provider", authLimit, user.OauthLogin)
+	g.POST("/v1/login/2fa", authLimit, user.SecondFactorLogin)
+	g.POST("/v1/token/refresh", authLimit, user.RefreshToken)
+	g.GET("/.well-known/jwks.json", user.JWKS)
+	g.POST("/v1/password/forgot", authLimit, user.ForgotPassword)
+	g.POST("/v1/password/reset", authLimit, user.ResetPassword)
 
 	u := g.Group("/v1/users")
 	u.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
@@ -78,9 +110,20 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 		u.GET("", user.GetList)
 		u.POST("", user.Create)
 		u.PUT("/:id", user.Update)
-		u.POST("/import", user.Import)
+		u.POST("/import", expensive, user.Import)
+		u.POST("/import_csv", expensive, user.ImportCsv)
 		u.GET("/import_status/:id", user.ImportStatus)
 		u.DELETE("/:id", user.Delete)
+		u.POST("/:id/revoke_tokens", user.RevokeTokens)
+		u.POST("/:id/2fa/reset", user.ResetTotp)
+		u.POST("/:id/unlock", user.UnlockLogin)
+		u.POST("/:id/impersonate", user.Impersonate)
+		u.GET("/:id/sessions", user.ListUserSessions)
+		u.DELETE("/:id/sessions", user.TerminateSessions)
+		u.GET("/:id/quota", quota.GetUserQuota)
+		u.PUT("/:id/quota", quota.SaveUserQuota)
+		u.DELETE("/:id/quota", quota.DeleteUserQuota)
+		u.GET("/:id/roles", role.ListByUser)
 		u.GET("/:id/dev_space_list", cluster_user.GetJoinClusterAndAppAndUser)
 		u.GET("/:id/applications", applications.ListPermitted)
 		u.GET("/:id/dev_spaces", cluster_user.ListByUserId)
@@ -91,13 +134,88 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 	m.Use(middleware.AuthMiddleware())
 	{
 		m.GET("", user.GetMe)
+		m.POST("/logout", user.Logout)
+		m.GET("/sessions", user.ListSessions)
+		m.DELETE("/sessions/:id", user.RevokeSession)
+		m.GET("/quota", quota.GetMine)
+		m.GET("/tokens", access_token.List)
+		m.POST("/tokens", access_token.Create)
+		m.DELETE("/tokens/:id", access_token.Revoke)
+		m.POST("/2fa/enroll", user.EnrollTotp)
+		m.POST("/2fa/activate", user.ActivateTotp)
+		m.POST("/2fa/disable", user.DisableTotp)
+		m.GET("/teams", team.ListMine)
+	}
+
+	al := g.Group("/v1/audit_logs")
+	al.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		al.GET("", audit_log.List)
+		al.GET("/export", audit_log.Export)
+	}
+
+	co := g.Group("/v1/cost")
+	co.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		co.GET("/report", expensive, cost.Report)
+	}
+
+	r := g.Group("/v1/roles")
+	r.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		r.GET("", role.List)
+		r.POST("", role.Create)
+		r.GET("/:id", role.Get)
+		r.PUT("/:id", role.Update)
+		r.DELETE("/:id", role.Delete)
+		r.GET("/:id/bindings", role.ListBindings)
+		r.POST("/:id/bindings", role.Bind)
+		r.DELETE("/:id/bindings", role.Unbind)
+	}
+
+	t := g.Group("/v1/teams")
+	t.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		t.GET("", team.List)
+		t.POST("", team.Create)
+		t.GET("/:id", team.Get)
+		t.PUT("/:id", team.Update)
+		t.DELETE("/:id", team.Delete)
+		t.GET("/:id/members", team.ListMembers)
+		t.POST("/:id/members", team.AddMembers)
+		t.DELETE("/:id/members", team.RemoveMembers)
+		t.GET("/:id/resources", team.ListResources)
+		t.POST("/:id/resources", team.Grant)
+		t.DELETE("/:id/resources", team.Revoke)
+		t.GET("/:id/quota", quota.GetTeamQuota)
+		t.PUT("/:id/quota", quota.SaveTeamQuota)
+		t.DELETE("/:id/quota", quota.DeleteTeamQuota)
+	}
+
+	// SCIM provisioning for identity providers
+	sc := g.Group("/scim/v2")
+	sc.Use(scim.AuthMiddleware())
+	{
+		sc.GET("/ServiceProviderConfig", scim.ServiceProviderConfig)
+		sc.GET("/Users", scim.ListUsers)
+		sc.POST("/Users", scim.CreateUser)
+		sc.GET("/Users/:id", scim.GetUser)
+		sc.PUT("/Users/:id", scim.ReplaceUser)
+		sc.PATCH("/Users/:id", scim.PatchUser)
+		sc.DELETE("/Users/:id", scim.DeleteUser)
+		sc.GET("/Groups", scim.ListGroups)
+		sc.POST("/Groups", scim.CreateGroup)
+		sc.GET("/Groups/:id", scim.GetGroup)
+		sc.PUT("/Groups/:id", scim.ReplaceGroup)
+		sc.PATCH("/Groups/:id", scim.PatchGroup)
+		sc.DELETE("/Groups/:id", scim.DeleteGroup)
 	}
 
 	// Clusters
 	c := g.Group("/v1/cluster")
 	c.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
 	{
-		c.POST("", cluster.Create)
+		c.POST("", expensive, cluster.Create)
 		c.GET("", cluster.GetList)
 		c.GET("/:id/dev_space", cluster.GetSpaceList)
 		c.GET("/:id/dev_space/:space_id/detail", cluster.GetSpaceDetail)
@@ -107,9 +225,17 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 		c.POST("/:id/storage_class", cluster.GetStorageClassByKubeConfig)
 		c.PUT("/:id", cluster.Update)
 		c.GET("/:id/gen_namespace", cluster.GenNamespace)
-		c.PUT("/:id/migrate", cluster.Migrate)
+		c.PUT("/:id/migrate", expensive, cluster.Migrate)
+		c.PUT("/:id/kubeconfig", expensive, cluster.RotateKubeConfig)
+		c.GET("/:id/usage", cluster.GetUsage)
+		c.GET("/agents", cluster.ListAgents)
+		c.POST("/agents", cluster.CreateAgent)
+		c.DELETE("/agents/:id", cluster.DeleteAgent)
 	}
 
+	// tunnel of the agent deployed in private cluster, authorized by the registration token
+	g.GET("/v1/agent/connect", cluster.ConnectAgent)
+
 	// Applications
 	a := g.Group("/v1/application")
 	a.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
@@ -120,6 +246,7 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 		a.DELETE("/:id", applications.Delete)
 		a.PUT("/:id", applications.Update)
 		a.PUT("/:id/public", applications.PublicSwitch)
+		a.GET("/:id/git_credential", applications.GetGitCredential)
 		a.POST("/:id/bind_cluster", application_cluster.Create)
 		a.GET("/:id/bound_cluster", application_cluster.GetBound)
 		a.GET("/:id/dev_space", cluster_user.GetFirst)
@@ -137,9 +264,26 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 	n.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
 	{
 		n.GET("/templates", applications.GetNocalhostConfigTemplate)
+		n.GET("/config_schema", applications.GetConfigSchema)
+		n.POST("/config/validate", applications.ValidateConfig)
 		n.GET("/version/upgrade_info", version.UpgradeInfo)
 	}
 
+	// v2 with the envelope, pagination and http status of errors, the
+	// dev space routes of /v2/dev_space are kept in the envelope of v1
+	v := g.Group("/v2")
+	v.Use(api.Envelope, middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		for _, r := range v2.Routes {
+			// limited the same as the routes of /v1 serving them
+			if r.Method == "POST" && (r.Legacy == "/v1/cluster" || r.Legacy == "/v1/dev_space") {
+				v.Handle(r.Method, strings.TrimPrefix(r.Path, "/v2"), expensive, r.Handler)
+				continue
+			}
+			v.Handle(r.Method, strings.TrimPrefix(r.Path, "/v2"), r.Handler)
+		}
+	}
+
 	dv2 := g.Group("/v2/dev_space")
 	dv2.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
 	{
@@ -147,8 +291,9 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 		dv2.GET("/cluster", cluster.GetDevSpaceClusterList)
 		dv2.GET("/detail", cluster_user.GetV2)
 		dv2.GET("/ns_list", cluster_user.GetNsInfo)
-		dv2.POST("/ns_import", cluster_user.NsImport)
-		dv2.POST("/ns_batch_import", cluster_user.NsBatchImport)
+		dv2.GET("/ns_scan/:id", cluster_user.ScanNs)
+		dv2.POST("/ns_import", expensive, cluster_user.NsImport)
+		dv2.POST("/ns_batch_import", expensive, cluster_user.NsBatchImport)
 		dv2.GET("/ns_import_status/:id", cluster_user.ImportStatus)
 		dv2.POST("/share", cluster_user.Share)
 		dv2.POST("/unshare", cluster_user.UnShare)
@@ -158,15 +303,100 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 	dv := g.Group("/v1/dev_space")
 	dv.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
 	{
-		dv.POST("", cluster_user.Create)
+		dv.POST("", expensive, cluster_user.Create)
+		dv.POST("/restore", expensive, cluster_user.Restore)
+		dv.POST("/from_template", expensive, cluster_user.CreateFromTemplate)
 		dv.GET("", cluster_user.ListAll)
+		dv.GET("/idle", cluster_user.ListIdle)
 		dv.DELETE("/:id", cluster_user.Delete)
 		dv.PUT("/:id", cluster_user.Update)
-		dv.POST("/:id/recreate", cluster_user.ReCreate)
+		dv.POST("/:id/recreate", expensive, cluster_user.ReCreate)
+		dv.POST("/:id/clone", expensive, cluster_user.Clone)
+		dv.POST("/:id/transfer", cluster_user.Transfer)
+		dv.GET("/:id/snapshot", cluster_user.GetSnapshot)
+		dv.GET("/:id/app_installs", cluster_user.ListAppInstalls)
+		dv.POST("/:id/app_installs", expensive, cluster_user.InstallApp)
+		dv.GET("/:id/app_installs/:install_id/events", cluster_user.StreamAppInstall)
+		dv.GET("/:id/applications/:name/revisions", cluster_user.ListAppRevisions)
+		dv.POST("/:id/applications/:name/rollback", expensive, cluster_user.RollbackApp)
 		dv.GET("/:id/detail", cluster_user.GetJoinClusterAndAppAndUserDetail)
+		dv.GET("/:id/usage", cluster_user.GetUsage)
+		dv.GET("/:id/activity", cluster_user.GetActivity)
+		dv.POST("/:id/activity", cluster_user.RecordActivity)
+		dv.PUT("/:id/sleep_config", cluster_user.UpdateSleepConfig)
+		dv.POST("/:id/sleep", cluster_user.Sleep)
+		dv.POST("/:id/wakeup", cluster_user.Wakeup)
+		dv.PUT("/:id/ttl", cluster_user.UpdateTtl)
 		dv.PUT("/:id/update_resource_limit", cluster_user.UpdateResourceLimit)
 		dv.PUT("/:id/update_mesh_dev_space_info", cluster_user.UpdateMeshDevSpaceInfo)
 		dv.GET("/:id/mesh_apps_info", cluster_user.GetAppsInfo)
+		dv.GET("/:id/mesh_routes", cluster_user.GetMeshRoutes)
+		dv.GET("/:id/service_accounts", cluster_user.ListServiceAccount)
+		dv.POST("/:id/service_accounts", cluster_user.CreateServiceAccount)
+		dv.POST("/:id/service_accounts/:sa_id/rotate", cluster_user.RotateServiceAccount)
+		dv.DELETE("/:id/service_accounts/:sa_id", cluster_user.RevokeServiceAccount)
+	}
+
+	// Git credentials
+	gc := g.Group("/v1/git_credential")
+	gc.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		gc.GET("", git_credential.List)
+		gc.POST("", git_credential.Create)
+		gc.PUT("/:id", git_credential.Update)
+		gc.DELETE("/:id", git_credential.Delete)
+	}
+
+	// Registry credentials distributed to dev spaces, admin only
+	rc := g.Group("/v1/registry_credential")
+	rc.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		rc.GET("", registry_credential.List)
+		rc.POST("", registry_credential.Create)
+		rc.POST("/sync", expensive, registry_credential.Sync)
+		rc.PUT("/:id", registry_credential.Update)
+		rc.DELETE("/:id", registry_credential.Delete)
+	}
+
+	// DevSpace templates
+	dt := g.Group("/v1/dev_space_template")
+	dt.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		dt.GET("", dev_space_template.List)
+		dt.POST("", dev_space_template.Create)
+		dt.GET("/:id", dev_space_template.Get)
+		dt.PUT("/:id", dev_space_template.Update)
+		dt.DELETE("/:id", dev_space_template.Delete)
+	}
+
+	// Catalog of dev images distributed to dev spaces, admin only except listing
+	di := g.Group("/v1/dev_image")
+	di.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		di.GET("", dev_image.List)
+		di.GET("/catalog", dev_image.Catalog)
+		di.POST("", dev_image.Create)
+		di.POST("/sync", dev_image.Sync)
+		di.PUT("/:id", dev_image.Update)
+		di.DELETE("/:id", dev_image.Delete)
+	}
+
+	// Bulk deployments of application in dev spaces, admin only
+	bd := g.Group("/v1/bulk_deploy")
+	bd.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		bd.GET("", cluster_user.ListBulkDeploy)
+		bd.POST("", expensive, cluster_user.CreateBulkDeploy)
+		bd.GET("/:id", cluster_user.GetBulkDeploy)
+		bd.POST("/:id/retry", expensive, cluster_user.RetryBulkDeploy)
+	}
+
+	// Garbage collection of the orphaned rows, admin only
+	gb := g.Group("/v1/gc")
+	gb.Use(middleware.AuthMiddleware(), middleware.PermissionMiddleware())
+	{
+		gb.GET("/orphans", expensive, garbage.Report)
+		gb.POST("/orphans", expensive, garbage.Collect)
 	}
 
 	l := g.Group("/v1/ldap")
@@ -177,7 +407,7 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 		l.PUT("/config/disable", ldap.DeleteConfiguration)
 		l.PUT("/bind", ldap.TestBind)
 		l.PUT("/search", ldap.TestSearch)
-		l.POST("/trigger", ldap.Trigger)
+		l.POST("/trigger", expensive, ldap.Trigger)
 	}
 
 	// Plug-in
@@ -186,7 +416,7 @@ func Load(g *gin.Engine, mw ...gin.HandlerFunc) *gin.Engine {
 	{
 		pa.GET("/service_accounts", service_account.ListAuthorization)
 		pa.GET("/dev_space", applications.PluginGet)
-		pa.POST("/:id/recreate", cluster_user.PluginReCreate)
+		pa.POST("/:id/recreate", expensive, cluster_user.PluginReCreate)
 		pa.PUT("/application/:id/dev_space/:spaceId/plugin_sync", applications.UpdateApplicationInstall)
 	}
 
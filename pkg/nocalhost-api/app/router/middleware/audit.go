@@ -0,0 +1,126 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/audit"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/utils"
+)
+
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w bodyLogWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Audit records all the mutations made through the api, such as login,
+// token issuance and the changes of users, clusters and dev spaces
+func Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !audited(c.Request) {
+			c.Next()
+			return
+		}
+
+		var bodyBytes []byte
+		if c.Request.Body != nil {
+			bodyBytes, _ = ioutil.ReadAll(c.Request.Body)
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		blw := &bodyLogWriter{
+			body:           bytes.NewBufferString(""),
+			ResponseWriter: c.Writer,
+		}
+		c.Writer = blw
+
+		c.Next()
+
+		// not matched any route
+		if c.FullPath() == "" {
+			return
+		}
+
+		code := errno.InternalServerError.Code
+		var response api.Response
+		if responded, ok := c.Get(api.ResponseCodeKey); ok {
+			code = responded.(int)
+		} else if err := json.Unmarshal(blw.body.Bytes(), &response); err == nil {
+			code = response.Code
+		}
+
+		record := &model.AuditLogModel{
+			UserId:     audit.User(c),
+			Action:     c.Request.Method + " " + c.FullPath(),
+			Path:       c.Request.URL.Path,
+			ResourceId: resourceId(c),
+			Ip:         c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			RequestId:  c.GetString(utils.XRequestID),
+			Code:       code,
+			Payload:    audit.Redact(bodyBytes),
+		}
+
+		if actor, ok := c.Get("actorId"); ok {
+			record.ActorId = actor.(uint64)
+		}
+
+		if before, ok := audit.Before(c); ok && code == 0 {
+			if diff := audit.Diff(before, bodyBytes); len(diff) > 0 {
+				marshal, _ := json.Marshal(diff)
+				record.Diff = string(marshal)
+			}
+		}
+
+		go func() {
+			if err := service.Svc.AuditLogSvc.Record(context.TODO(), record); err != nil {
+				log.Warnf("fail to record audit log of %s: %v", record.Action, err)
+			}
+		}()
+	}
+}
+
+func audited(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	for _, prefix := range []string{"/v1/", "/v2/", "/scim/"} {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceId(c *gin.Context) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	if len(c.Params) > 0 {
+		return c.Params[0].Value
+	}
+	return ""
+}
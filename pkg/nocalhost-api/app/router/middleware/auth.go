@@ -6,15 +6,44 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
+	"nocalhost/internal/nocalhost-api/service/access_token"
 	"nocalhost/pkg/nocalhost-api/app/api"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/tlsserver"
 	"nocalhost/pkg/nocalhost-api/pkg/token"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 )
 
 // AuthMiddleware
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// the span of auth ends before the handlers, or once aborted
+		authCtx, span := trace.StartSpan(c, "auth")
+		defer span.End(nil)
+
+		// personal access token for CI and scripting
+		if t, err := token.BearerFromRequest(c); err == nil && access_token.IsAccessToken(t) {
+			accessTokenAuth(c, authCtx, t, span)
+			return
+		}
+
+		// the service-to-service callers by the client certificates of
+		// app.tls.client_users, the bearer token goes first if any
+		if c.GetHeader("Authorization") == "" {
+			if email, ok := tlsserver.UserOf(c.Request.TLS); ok {
+				clientCertAuth(c, authCtx, email, span)
+				return
+			}
+		}
+
 		// Parse the json web token.
 		ctx, err := token.ParseRequest(c)
 		if err != nil {
@@ -23,11 +52,128 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// token may be revoked by admin or password changing
+		if service.Svc.UserSvc.IsTokenRevoked(ctx) {
+			api.SendResponse(c, errno.ErrTokenInvalid, nil)
+			c.Abort()
+			return
+		}
+
+		// the session may be revoked by user or admin
+		if ctx.SessionID != "" && !service.Svc.SessionSvc.Validate(authCtx, ctx.SessionID, ctx.UserID, c.ClientIP()) {
+			api.SendResponse(c, errno.ErrTokenInvalid, nil)
+			c.Abort()
+			return
+		}
+
+		// admin have to enable two-factor authentication before other requests if enforced
+		if !strings.HasPrefix(c.Request.URL.Path, "/v1/me") && service.Svc.UserSvc.TwoFactorEnrollRequired(ctx.UserID) {
+			api.SendResponse(c, errno.ErrTwoFactorEnrollRequired, nil)
+			c.Abort()
+			return
+		}
+
+		// impersonated requests are tagged with the admin, and can not
+		// change the credential or session of the impersonated user
+		if ctx.IsImpersonated() {
+			if !impersonationAllowed(c) {
+				api.SendResponse(c, errno.ErrImpersonationForbidden, nil)
+				c.Abort()
+				return
+			}
+
+			c.Set("actorId", ctx.ActorID)
+			log.Infof(
+				"[impersonation] admin %d acting as user %d: %s %s",
+				ctx.ActorID, ctx.UserID, c.Request.Method, c.Request.URL.Path,
+			)
+		}
+
 		// set uid to context
 		c.Set("uid", ctx.Uuid)
 		c.Set("userId", ctx.UserID)
 		c.Set("isAdmin", ctx.IsAdmin)
+		c.Set("sid", ctx.SessionID)
 
+		span.Set("user.id", ctx.UserID).End(nil)
 		c.Next()
 	}
 }
+
+func impersonationAllowed(c *gin.Context) bool {
+	if c.Request.Method == http.MethodGet {
+		return true
+	}
+
+	// logout, personal access tokens, two-factor and so on
+	if strings.HasPrefix(c.Request.URL.Path, "/v1/me") {
+		return false
+	}
+
+	// password or profile of the impersonated user
+	return !(c.Request.Method == http.MethodPut && c.FullPath() == "/v1/users/:id")
+}
+
+func accessTokenAuth(c *gin.Context, authCtx context.Context, t string, span *trace.Span) {
+	accessToken, err := service.Svc.TokenSvc.Authenticate(authCtx, t)
+	if err != nil {
+		api.SendResponse(c, errno.ErrTokenInvalid, nil)
+		c.Abort()
+		return
+	}
+
+	// read scope token only allow the read-only requests
+	if c.Request.Method != http.MethodGet && !accessToken.HasScope(model.AccessTokenScopeWrite) {
+		api.SendResponse(c, errno.ErrAccessTokenScope, nil)
+		c.Abort()
+		return
+	}
+
+	u, err := service.Svc.UserSvc.GetCache(accessToken.UserId)
+	if err != nil || u.Status == nil || *u.Status == 0 {
+		api.SendResponse(c, errno.ErrUserNotAllow, nil)
+		c.Abort()
+		return
+	}
+
+	isAdmin := uint64(0)
+	if u.IsAdmin != nil {
+		isAdmin = *u.IsAdmin
+	}
+
+	// set uid to context
+	c.Set("uid", u.Uuid)
+	c.Set("userId", u.ID)
+	c.Set("isAdmin", isAdmin)
+
+	span.Set("user.id", u.ID).End(nil)
+	c.Next()
+}
+
+func clientCertAuth(c *gin.Context, authCtx context.Context, email string, span *trace.Span) {
+	u, err := service.Svc.UserSvc.GetUserByEmail(authCtx, email)
+	if err != nil || u == nil {
+		log.Warnf("user %s of client certificate is not found: %v", email, err)
+		api.SendResponse(c, errno.ErrTokenInvalid, nil)
+		c.Abort()
+		return
+	}
+	if u.Status == nil || *u.Status == 0 {
+		api.SendResponse(c, errno.ErrUserNotAllow, nil)
+		c.Abort()
+		return
+	}
+
+	isAdmin := uint64(0)
+	if u.IsAdmin != nil {
+		isAdmin = *u.IsAdmin
+	}
+
+	// set uid to context
+	c.Set("uid", u.Uuid)
+	c.Set("userId", u.ID)
+	c.Set("isAdmin", isAdmin)
+
+	span.Set("user.id", u.ID).End(nil)
+	c.Next()
+}
@@ -1,15 +1,18 @@
 /*
 * Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
 * This source code is licensed under the Apache License Version 2.0.
-*/
+ */
 
 package middleware
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
 // NoCache is a middleware function that appends headers
@@ -21,33 +24,141 @@ func NoCache(c *gin.Context) {
 	c.Next()
 }
 
+const (
+	CORS_ALLOWED_ORIGINS   = "cors.allowed_origins"
+	CORS_ALLOW_CREDENTIALS = "cors.allow_credentials"
+	CORS_MAX_AGE           = "cors.max_age"
+
+	SECURITY_CONTENT_SECURITY_POLICY = "security_headers.content_security_policy"
+	SECURITY_HSTS_MAX_AGE            = "security_headers.hsts_max_age"
+	SECURITY_HSTS_INCLUDE_SUBDOMAINS = "security_headers.hsts_include_subdomains"
+	SECURITY_FRAME_OPTIONS           = "security_headers.frame_options"
+
+	defaultHSTSMaxAge   = 365 * 24 * time.Hour
+	defaultFrameOptions = "DENY"
+
+	allowMethods  = "GET,POST,PUT,PATCH,DELETE,OPTIONS"
+	allowHeaders  = "authorization, origin, content-type, accept, reraeb, x-request-id"
+	exposeHeaders = "X-Request-ID, Retry-After, Content-Disposition"
+)
+
+// corsPolicy of cors.*, any origin is allowed by default
+type corsPolicy struct {
+	// the origins allowed, e.g. https://dashboard.example.com, or the
+	// subdomains of https://*.example.com, * for any
+	origins     []string
+	credentials bool
+	maxAge      time.Duration
+}
+
+func corsPolicyOf() corsPolicy {
+	p := corsPolicy{
+		origins:     viper.GetStringSlice(CORS_ALLOWED_ORIGINS),
+		credentials: viper.GetBool(CORS_ALLOW_CREDENTIALS),
+		maxAge:      viper.GetDuration(CORS_MAX_AGE),
+	}
+	if len(p.origins) == 0 {
+		p.origins = []string{"*"}
+	}
+	return p
+}
+
+// allowOrigin the value of Access-Control-Allow-Origin for origin, empty if
+// it is not allowed
+func (p corsPolicy) allowOrigin(origin string) string {
+	for _, allowed := range p.origins {
+		if allowed == "*" {
+			// the credentials are never allowed for any origin
+			return "*"
+		}
+		if origin == "" {
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+		if i := strings.Index(allowed, "://*."); i > 0 {
+			scheme, domain := allowed[:i+3], allowed[i+4:]
+			if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, domain) &&
+				len(origin) > len(scheme)+len(domain) {
+				return origin
+			}
+		}
+	}
+	return ""
+}
+
+func (p corsPolicy) header(c *gin.Context) {
+	allowed := p.allowOrigin(c.GetHeader("Origin"))
+	if allowed != "*" {
+		c.Writer.Header().Add("Vary", "Origin")
+	}
+	if allowed == "" {
+		return
+	}
+	c.Header("Access-Control-Allow-Origin", allowed)
+	c.Header("Access-Control-Expose-Headers", exposeHeaders)
+	if p.credentials && allowed != "*" {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+}
+
 // Options is a middleware function that appends headers
 // for options requests and aborts then exits the middleware
-// chain and ends the request.
-func Options(c *gin.Context) {
-	if c.Request.Method != "OPTIONS" {
-		c.Next()
-	} else {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "authorization, origin, content-type, accept")
-		c.Header("Allow", "HEAD,GET,POST,PUT,PATCH,DELETE,OPTIONS")
+// chain and ends the request. The origins allowed are of
+// cors.allowed_origins, so that the web dashboard is hosted
+// on another origin.
+func Options() gin.HandlerFunc {
+	p := corsPolicyOf()
+	return func(c *gin.Context) {
+		p.header(c)
+		if c.Request.Method != "OPTIONS" {
+			c.Next()
+			return
+		}
+		c.Header("Access-Control-Allow-Methods", allowMethods)
+		c.Header("Access-Control-Allow-Headers", allowHeaders)
+		if p.maxAge > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(int(p.maxAge.Seconds())))
+		}
+		c.Header("Allow", "HEAD,"+allowMethods)
 		c.Header("Content-Type", "application/json")
 		c.AbortWithStatus(200)
 	}
 }
 
 // Secure is a middleware function that appends security
-// and resource access headers.
-func Secure(c *gin.Context) {
-	c.Header("Access-Control-Allow-Origin", "*")
-	c.Header("X-Frame-Options", "DENY")
-	c.Header("X-Content-Type-Options", "nosniff")
-	c.Header("X-XSS-Protection", "1; mode=block")
-	if c.Request.TLS != nil {
-		c.Header("Strict-Transport-Security", "max-age=31536000")
-	}
-
-	// Also consider adding Content-Security-Policy headers
-	// c.Header("Content-Security-Policy", "script-src 'self' https://cdnjs.cloudflare.com")
+// and resource access headers, configured by security_headers.*
+func Secure() gin.HandlerFunc {
+	frameOptions := defaultFrameOptions
+	if viper.IsSet(SECURITY_FRAME_OPTIONS) {
+		frameOptions = viper.GetString(SECURITY_FRAME_OPTIONS)
+	}
+	hstsMaxAge := defaultHSTSMaxAge
+	if viper.IsSet(SECURITY_HSTS_MAX_AGE) {
+		hstsMaxAge = viper.GetDuration(SECURITY_HSTS_MAX_AGE)
+	}
+	hsts := ""
+	if hstsMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(hstsMaxAge.Seconds()))
+		if viper.GetBool(SECURITY_HSTS_INCLUDE_SUBDOMAINS) {
+			hsts += "; includeSubDomains"
+		}
+	}
+	csp := viper.GetString(SECURITY_CONTENT_SECURITY_POLICY)
+
+	return func(c *gin.Context) {
+		if frameOptions != "" {
+			c.Header("X-Frame-Options", frameOptions)
+		}
+		c.Header("X-Content-Type-Options", "nosniff")
+		c.Header("X-XSS-Protection", "1; mode=block")
+		// behind the proxy terminating tls too
+		if hsts != "" && (c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https") {
+			c.Header("Strict-Transport-Security", hsts)
+		}
+		if csp != "" {
+			c.Header("Content-Security-Policy", csp)
+		}
+	}
 }
@@ -0,0 +1,81 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+func TestCorsAllowOrigin(t *testing.T) {
+	p := corsPolicy{origins: []string{"https://dashboard.example.com", "https://*.nocalhost.dev"}}
+	cases := map[string]string{
+		"https://dashboard.example.com": "https://dashboard.example.com",
+		"https://a.nocalhost.dev":       "https://a.nocalhost.dev",
+		"https://nocalhost.dev":         "",
+		"http://a.nocalhost.dev":        "",
+		"https://evil.com":              "",
+		"":                              "",
+	}
+	for origin, expected := range cases {
+		if actual := p.allowOrigin(origin); actual != expected {
+			t.Errorf("origin %s should be allowed as '%s', got '%s'", origin, expected, actual)
+		}
+	}
+
+	if actual := (corsPolicy{origins: []string{"*"}}).allowOrigin("https://evil.com"); actual != "*" {
+		t.Errorf("any origin should be allowed by *, got %s", actual)
+	}
+}
+
+func TestOptionsAndSecure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	viper.Set(CORS_ALLOWED_ORIGINS, []string{"https://dashboard.example.com"})
+	viper.Set(CORS_ALLOW_CREDENTIALS, true)
+	viper.Set(CORS_MAX_AGE, time.Hour)
+	viper.Set(SECURITY_CONTENT_SECURITY_POLICY, "default-src 'none'")
+	viper.Set(SECURITY_FRAME_OPTIONS, "")
+	defer func() {
+		for _, key := range []string{
+			CORS_ALLOWED_ORIGINS, CORS_ALLOW_CREDENTIALS, CORS_MAX_AGE,
+			SECURITY_CONTENT_SECURITY_POLICY, SECURITY_FRAME_OPTIONS,
+		} {
+			viper.Set(key, nil)
+		}
+	}()
+
+	g := gin.New()
+	g.Use(Options(), Secure())
+	g.GET("/v1/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodOptions, "/v1/me", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://dashboard.example.com" ||
+		w.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		w.Header().Get("Access-Control-Max-Age") != "3600" {
+		t.Errorf("unexpected headers of preflight %v", w.Header())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	req.Header.Set("X-Forwarded-Proto", "https")
+	w = httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("origin not allowed should not be responded, got %s", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w.Header().Get("X-Frame-Options") != "" || w.Header().Get("Content-Security-Policy") != "default-src 'none'" ||
+		w.Header().Get("Strict-Transport-Security") != "max-age=31536000" {
+		t.Errorf("unexpected security headers %v", w.Header())
+	}
+}
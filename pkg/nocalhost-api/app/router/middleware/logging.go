@@ -6,84 +6,54 @@
 package middleware
 
 import (
-	"bytes"
-	"encoding/json"
-	"io/ioutil"
-	"regexp"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/willf/pad"
 
 	"nocalhost/pkg/nocalhost-api/app/api"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
 )
 
-type bodyLogWriter struct {
-	gin.ResponseWriter
-	body *bytes.Buffer
+// the probes and scrapes are not logged
+var notLogged = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
 }
 
-func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
-}
-
-// Logging is a middleware function that logs the each request.
+// Logging is a middleware function that logs the each request in structured
+// fields, with the request id and trace of it, the errors responded are
+// logged as warnings
 func Logging() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		start := time.Now().UTC()
+		start := time.Now()
 		path := c.Request.URL.Path
 
-		reg := regexp.MustCompile("(/v1/user|/login)")
-		if !reg.MatchString(path) {
+		// Continue.
+		c.Next()
+
+		if notLogged[path] {
 			return
 		}
 
-		// Read the Body content
-		var bodyBytes []byte
-		if c.Request.Body != nil {
-			bodyBytes, _ = ioutil.ReadAll(c.Request.Body)
+		fields := log.Fields{
+			"method":     c.Request.Method,
+			"path":       path,
+			"route":      c.FullPath(),
+			"status":     c.Writer.Status(),
+			"latency_ms": time.Since(start).Milliseconds(),
+			"ip":         c.ClientIP(),
 		}
-
-		// Restore the io.ReadCloser to its original state
-		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
-
-		// The basic informations.
-		method := c.Request.Method
-		ip := c.ClientIP()
-
-		//coloredoutput.Debugf("New request come in, path: %s, Method: %s, body `%s`", path, method, string(bodyBytes))
-		blw := &bodyLogWriter{
-			body:           bytes.NewBufferString(""),
-			ResponseWriter: c.Writer,
+		code, responded := c.Get(api.ResponseCodeKey)
+		if responded {
+			fields["code"] = code
 		}
-		c.Writer = blw
+		logger := log.WithContext(c).WithFields(fields)
 
-		// Continue.
-		c.Next()
-
-		// Calculates the latency.
-		end := time.Now().UTC()
-		latency := end.Sub(start)
-
-		var code int
-		var message string
-
-		// get code and message
-		var response api.Response
-		if err := json.Unmarshal(blw.body.Bytes(), &response); err != nil {
-			log.Errorf("response body can not unmarshal to model.Response struct, body: `%s`, err: %+v",
-				blw.body.Bytes(), err)
-			code = errno.InternalServerError.Code
-			message = err.Error()
-		} else {
-			code = response.Code
-			message = response.Message
+		if responded && code != errno.OK.Code {
+			logger.Warnf("request failed: %s", c.GetString(api.ResponseMessageKey))
+			return
 		}
-
-		log.Infof("%-13s | %-12s | %s %s | {code: %d, message: %s}", latency, ip,
-			pad.Right(method, 5, ""), path, code, message)
+		logger.Info("request")
 	}
 }
@@ -10,8 +10,12 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
 
+	"nocalhost/internal/nocalhost-api/model"
+	"nocalhost/internal/nocalhost-api/service"
 	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/app/router/ginbase"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 )
 
@@ -25,7 +29,7 @@ func PermissionMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		if !admin && !whiteList(c.Request.Method, c.Request.RequestURI) {
+		if !admin && !whiteList(c.Request.Method, c.Request.URL.Path) && !roleAllow(c) {
 			api.SendResponse(c, errno.ErrPermissionDenied, nil)
 			c.Abort()
 			return
@@ -34,6 +38,8 @@ func PermissionMiddleware() gin.HandlerFunc {
 	}
 }
 
+// whiteList the routes open to all the users, the sub paths of them included,
+// the path is matched by whole segments
 func whiteList(method, path string) bool {
 	permissions := map[string]string{
 		"/v1/users":                          "GET",
@@ -42,23 +48,54 @@ func whiteList(method, path string) bool {
 		"/v1/users/[0-9]+/applications":      "GET",
 		"/v1/users/[0-9]+/dev_spaces":        "GET",
 		"/v1/dev_space/[0-9]+/detail":        "GET",
+		"/v1/dev_space/[0-9]+/usage":         "GET",
+		"/v1/dev_space/[0-9]+/sleep_config":  "PUT",
+		"/v1/dev_space/[0-9]+/sleep":         "POST",
+		"/v1/dev_space/[0-9]+/wakeup":        "POST",
+		"/v1/dev_space/[0-9]+/mesh_routes":   "GET",
 		"/v1/dev_space/[0-9]+/recreate":      "POST",
+		"/v1/dev_space/[0-9]+/clone":         "POST",
+		"/v1/dev_space/[0-9]+/snapshot":      "GET",
+		"/v1/dev_space/restore":              "POST",
+		"/v1/dev_space/from_template":        "POST",
+		"/v1/dev_space/[0-9]+/app_installs":  "GET,POST",
+		"/v1/dev_space/[0-9]+/transfer":      "POST",
+		"/v1/dev_space_template":             "GET",
+		"/v1/dev_image":                      "GET",
 		"/v1/application/[0-9]+":             "GET,PUT,DELETE",
 		"/v1/nocalhost/templates":            "GET",
 		"/v1/nocalhost/version/upgrade_info": "GET",
 		"/v1/dev_space":                      "GET,POST",
 
-		"/v1/application": "GET,POST",
+		"/v1/application":    "GET,POST",
+		"/v1/git_credential": "GET",
+
+		"/v1/dev_space/[0-9]+/applications/[^/]+/revisions": "GET",
+		"/v1/dev_space/[0-9]+/applications/[^/]+/rollback":  "POST",
+
+		"/v1/nocalhost/config_schema":   "GET",
+		"/v1/nocalhost/config/validate": "POST",
 
 		"/v1/cluster":                      "POST,GET",
 		"/v1/cluster/[0-9]+":               "PUT,DELETE",
 		"/v1/cluster/[0-9]+/storage_class": "PUT,DELETE",
 		"/v1/cluster/[0-9]+/gen_namespace": "GET",
 		"/v1/cluster/[0-9]+/migrate":       "POST",
+		"/v1/cluster/agents/[0-9]+":        "DELETE",
+		"/v1/cluster/[0-9]+/usage":         "GET",
 
 		"/v1/dev_space/[0-9]+/update_resource_limit": "PUT",
 		"/v1/dev_space/[0-9]+":                       "PUT,DELETE",
 
+		"/v2/users":               "GET",
+		"/v2/users/[0-9]+":        "PUT",
+		"/v2/clusters":            "POST,GET",
+		"/v2/clusters/[0-9]+":     "PUT,DELETE",
+		"/v2/applications":        "GET,POST",
+		"/v2/applications/[0-9]+": "GET,PUT,DELETE",
+		"/v2/dev_spaces":          "GET,POST",
+		"/v2/dev_spaces/[0-9]+":   "PUT,DELETE",
+
 		"/v2/dev_space":         "GET",
 		"/v2/dev_space/cluster": "GET",
 		"/v2/dev_space/share":   "POST",
@@ -66,7 +103,7 @@ func whiteList(method, path string) bool {
 	}
 
 	for reg, med := range permissions {
-		match, _ := regexp.MatchString(reg, path)
+		match, _ := regexp.MatchString("^"+reg+"(/|$)", path)
 		if match && strings.Contains(med, method) {
 			return true
 		}
@@ -75,6 +112,156 @@ func whiteList(method, path string) bool {
 	return false
 }
 
+// routePermission the permission granted by roles to the route, the roles
+// bound to the dev space of the path param are taken into account as well
+type routePermission struct {
+	permission string
+	devSpace   string
+}
+
+// routePermissions by the method and the full path of the routes, the routes
+// not listed are admin only, such as importing users, resetting two-factor
+// authentication or impersonating, as they can be used to take over users
+var routePermissions = map[string]routePermission{
+	"GET /v1/users":                    {model.PermissionUsersRead, ""},
+	"GET /v1/users/:id":                {model.PermissionUsersRead, ""},
+	"GET /v1/users/:id/roles":          {model.PermissionUsersRead, ""},
+	"GET /v1/users/:id/dev_space_list": {model.PermissionUsersRead, ""},
+	"GET /v1/users/:id/applications":   {model.PermissionUsersRead, ""},
+	"GET /v1/users/:id/dev_spaces":     {model.PermissionUsersRead, ""},
+	"GET /v1/users/:id/clusters":       {model.PermissionUsersRead, ""},
+	"POST /v1/users":                   {model.PermissionUsersWrite, ""},
+	"PUT /v1/users/:id":                {model.PermissionUsersWrite, ""},
+	"GET /v2/users":                    {model.PermissionUsersRead, ""},
+	"GET /v2/users/:id":                {model.PermissionUsersRead, ""},
+	"POST /v2/users":                   {model.PermissionUsersWrite, ""},
+	"PUT /v2/users/:id":                {model.PermissionUsersWrite, ""},
+
+	"GET /v1/cluster":                                {model.PermissionClustersRead, ""},
+	"GET /v1/cluster/:id/dev_space":                  {model.PermissionClustersRead, ""},
+	"GET /v1/cluster/:id/dev_space/:space_id/detail": {model.PermissionClustersRead, "space_id"},
+	"GET /v1/cluster/:id/detail":                     {model.PermissionClustersRead, ""},
+	"GET /v1/cluster/:id/storage_class":              {model.PermissionClustersRead, ""},
+	"GET /v1/cluster/:id/gen_namespace":              {model.PermissionClustersRead, ""},
+	"GET /v1/cluster/:id/usage":                      {model.PermissionClustersRead, ""},
+	"GET /v1/cluster/agents":                         {model.PermissionClustersRead, ""},
+	"POST /v1/cluster":                               {model.PermissionClustersWrite, ""},
+	"POST /v1/cluster/:id/storage_class":             {model.PermissionClustersWrite, ""},
+	"PUT /v1/cluster/:id":                            {model.PermissionClustersWrite, ""},
+	"DELETE /v1/cluster/:id":                         {model.PermissionClustersWrite, ""},
+	"PUT /v1/cluster/:id/migrate":                    {model.PermissionClustersWrite, ""},
+	"PUT /v1/cluster/:id/kubeconfig":                 {model.PermissionClustersWrite, ""},
+	"POST /v1/cluster/agents":                        {model.PermissionClustersWrite, ""},
+	"DELETE /v1/cluster/agents/:id":                  {model.PermissionClustersWrite, ""},
+	"GET /v2/clusters":                               {model.PermissionClustersRead, ""},
+	"GET /v2/clusters/:id":                           {model.PermissionClustersRead, ""},
+	"POST /v2/clusters":                              {model.PermissionClustersWrite, ""},
+	"PUT /v2/clusters/:id":                           {model.PermissionClustersWrite, ""},
+	"DELETE /v2/clusters/:id":                        {model.PermissionClustersWrite, ""},
+
+	"GET /v1/dev_space":                                     {model.PermissionDevSpacesRead, ""},
+	"GET /v1/dev_space/idle":                                {model.PermissionDevSpacesRead, ""},
+	"GET /v1/dev_space/:id/snapshot":                        {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/app_installs":                    {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/app_installs/:install_id/events": {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/applications/:name/revisions":    {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/detail":                          {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/usage":                           {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/activity":                        {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/mesh_apps_info":                  {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/mesh_routes":                     {model.PermissionDevSpacesRead, "id"},
+	"GET /v1/dev_space/:id/service_accounts":                {model.PermissionDevSpacesRead, "id"},
+	"POST /v1/dev_space":                                    {model.PermissionDevSpacesWrite, ""},
+	"POST /v1/dev_space/restore":                            {model.PermissionDevSpacesWrite, ""},
+	"POST /v1/dev_space/from_template":                      {model.PermissionDevSpacesWrite, ""},
+	"PUT /v1/dev_space/:id":                                 {model.PermissionDevSpacesWrite, "id"},
+	"DELETE /v1/dev_space/:id":                              {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/recreate":                       {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/clone":                          {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/transfer":                       {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/app_installs":                   {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/applications/:name/rollback":    {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/activity":                       {model.PermissionDevSpacesWrite, "id"},
+	"PUT /v1/dev_space/:id/sleep_config":                    {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/sleep":                          {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/wakeup":                         {model.PermissionDevSpacesWrite, "id"},
+	"PUT /v1/dev_space/:id/ttl":                             {model.PermissionDevSpacesWrite, "id"},
+	"PUT /v1/dev_space/:id/update_resource_limit":           {model.PermissionDevSpacesWrite, "id"},
+	"PUT /v1/dev_space/:id/update_mesh_dev_space_info":      {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/service_accounts":               {model.PermissionDevSpacesWrite, "id"},
+	"POST /v1/dev_space/:id/service_accounts/:sa_id/rotate": {model.PermissionDevSpacesWrite, "id"},
+	"DELETE /v1/dev_space/:id/service_accounts/:sa_id":      {model.PermissionDevSpacesWrite, "id"},
+	"GET /v2/dev_space":                                     {model.PermissionDevSpacesRead, ""},
+	"GET /v2/dev_space/cluster":                             {model.PermissionDevSpacesRead, ""},
+	"GET /v2/dev_space/detail":                              {model.PermissionDevSpacesRead, ""},
+	"GET /v2/dev_space/ns_list":                             {model.PermissionDevSpacesRead, ""},
+	"GET /v2/dev_space/ns_scan/:id":                         {model.PermissionDevSpacesRead, ""},
+	"GET /v2/dev_space/ns_import_status/:id":                {model.PermissionDevSpacesRead, ""},
+	"POST /v2/dev_space/ns_import":                          {model.PermissionDevSpacesWrite, ""},
+	"POST /v2/dev_space/ns_batch_import":                    {model.PermissionDevSpacesWrite, ""},
+	"POST /v2/dev_space/share":                              {model.PermissionDevSpacesWrite, ""},
+	"POST /v2/dev_space/unshare":                            {model.PermissionDevSpacesWrite, ""},
+	"GET /v2/dev_spaces":                                    {model.PermissionDevSpacesRead, ""},
+	"GET /v2/dev_spaces/:id":                                {model.PermissionDevSpacesRead, "id"},
+	"POST /v2/dev_spaces":                                   {model.PermissionDevSpacesWrite, ""},
+	"PUT /v2/dev_spaces/:id":                                {model.PermissionDevSpacesWrite, "id"},
+	"DELETE /v2/dev_spaces/:id":                             {model.PermissionDevSpacesWrite, "id"},
+
+	"GET /v1/application":                                {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id":                            {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id/git_credential":             {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id/bound_cluster":              {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id/dev_space":                  {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id/dev_space/:space_id/detail": {model.PermissionApplicationsRead, "space_id"},
+	"GET /v1/application/:id/dev_space_list":             {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id/cluster/:clusterId":         {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id/users":                      {model.PermissionApplicationsRead, ""},
+	"GET /v1/application/:id/!users":                     {model.PermissionApplicationsRead, ""},
+	"POST /v1/application":                               {model.PermissionApplicationsWrite, ""},
+	"PUT /v1/application/:id":                            {model.PermissionApplicationsWrite, ""},
+	"DELETE /v1/application/:id":                         {model.PermissionApplicationsWrite, ""},
+	"PUT /v1/application/:id/public":                     {model.PermissionApplicationsWrite, ""},
+	"POST /v1/application/:id/bind_cluster":              {model.PermissionApplicationsWrite, ""},
+	"POST /v1/application/:id/users":                     {model.PermissionApplicationsWrite, ""},
+	"DELETE /v1/application/:id/users":                   {model.PermissionApplicationsWrite, ""},
+	"GET /v2/applications":                               {model.PermissionApplicationsRead, ""},
+	"GET /v2/applications/:id":                           {model.PermissionApplicationsRead, ""},
+	"POST /v2/applications":                              {model.PermissionApplicationsWrite, ""},
+	"PUT /v2/applications/:id":                           {model.PermissionApplicationsWrite, ""},
+	"DELETE /v2/applications/:id":                        {model.PermissionApplicationsWrite, ""},
+
+	"GET /v1/cost/report": {model.PermissionBillingRead, ""},
+}
+
+// roleAllow check the permission of the roles bound to the login user
+func roleAllow(c *gin.Context) bool {
+	permission, devSpaceId := requiredPermission(c)
+	if permission == "" {
+		return false
+	}
+
+	userId, err := ginbase.LoginUser(c)
+	if err != nil {
+		return false
+	}
+	return service.Svc.RoleSvc.HasPermission(c, userId, permission, devSpaceId)
+}
+
+// requiredPermission matches the full path of the route as a whole, so that
+// the routes of /v1/dev_space_template are never taken as the ones of dev spaces
+func requiredPermission(c *gin.Context) (string, uint64) {
+	r, ok := routePermissions[c.Request.Method+" "+c.FullPath()]
+	if !ok {
+		return "", 0
+	}
+
+	var devSpaceId uint64
+	if r.devSpace != "" {
+		devSpaceId = cast.ToUint64(c.Param(r.devSpace))
+	}
+	return r.permission, devSpaceId
+}
+
 func IsAdmin(c *gin.Context) (bool, error) {
 	id, ok := c.Get("isAdmin")
 	if !ok {
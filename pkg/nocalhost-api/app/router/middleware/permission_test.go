@@ -0,0 +1,89 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+func TestWhiteList(t *testing.T) {
+	cases := []struct {
+		method, path string
+		allowed      bool
+	}{
+		{"POST", "/v1/dev_space", true},
+		{"GET", "/v1/dev_space/1/detail", true},
+		{"POST", "/v1/dev_space_template", false},
+		{"PUT", "/v1/dev_space_template/1", false},
+		{"GET", "/v1/dev_space_template", true},
+		{"GET", "/v1/users", true},
+		{"POST", "/v1/users", false},
+		{"POST", "/v1/users/1/revoke_tokens", false},
+		{"PUT", "/v1/users/1", true},
+		{"GET", "/v3/v1/users", false},
+	}
+	for _, c := range cases {
+		if actual := whiteList(c.method, c.path); actual != c.allowed {
+			t.Errorf("%s %s should be allowed: %v, got %v", c.method, c.path, c.allowed, actual)
+		}
+	}
+}
+
+func TestRequiredPermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type result struct {
+		permission string
+		devSpaceId uint64
+	}
+	var actual result
+	handler := func(c *gin.Context) {
+		actual.permission, actual.devSpaceId = requiredPermission(c)
+	}
+
+	r := gin.New()
+	r.POST("/v1/users", handler)
+	r.PUT("/v1/users/:id", handler)
+	r.POST("/v1/users/:id/revoke_tokens", handler)
+	r.POST("/v1/users/:id/2fa/reset", handler)
+	r.DELETE("/v1/users/:id/sessions", handler)
+	r.PUT("/v1/users/:id/quota", handler)
+	r.GET("/v1/dev_space/:id/detail", handler)
+	r.POST("/v1/dev_space_template", handler)
+	r.GET("/v1/cluster/:id/dev_space/:space_id/detail", handler)
+
+	cases := []struct {
+		method, path string
+		expected     result
+	}{
+		{"POST", "/v1/users", result{model.PermissionUsersWrite, 0}},
+		{"PUT", "/v1/users/2", result{model.PermissionUsersWrite, 0}},
+		{"POST", "/v1/users/2/revoke_tokens", result{}},
+		{"POST", "/v1/users/2/2fa/reset", result{}},
+		{"DELETE", "/v1/users/2/sessions", result{}},
+		{"PUT", "/v1/users/2/quota", result{}},
+		{"GET", "/v1/dev_space/3/detail", result{model.PermissionDevSpacesRead, 3}},
+		{"POST", "/v1/dev_space_template", result{}},
+		{"GET", "/v1/cluster/1/dev_space/4/detail", result{model.PermissionClustersRead, 4}},
+	}
+	for _, c := range cases {
+		actual = result{}
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(c.method, c.path, nil))
+		if actual != c.expected {
+			t.Errorf("%s %s should require %v, got %v", c.method, c.path, c.expected, actual)
+		}
+	}
+
+	if _, ok := routePermissions[http.MethodDelete+" /v1/users/:id"]; ok {
+		t.Error("deleting users should be admin only")
+	}
+}
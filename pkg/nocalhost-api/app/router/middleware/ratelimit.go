@@ -0,0 +1,104 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/napp"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/metrics"
+	"nocalhost/pkg/nocalhost-api/pkg/ratelimit"
+)
+
+// Policies of rate limiting, configured by rate_limit.<policy>.limit and
+// rate_limit.<policy>.burst
+const (
+	// LimitAuth the logins and the other requests of credentials, by ip
+	LimitAuth = "auth"
+	// LimitExpensive the requests creating the resources of clusters, by
+	// user if authenticated
+	LimitExpensive = "expensive"
+)
+
+var defaultRates = map[string]ratelimit.Rate{
+	LimitAuth:      {Limit: 0.2, Burst: 20},
+	LimitExpensive: {Limit: 0.5, Burst: 30},
+}
+
+var (
+	limiterOnce sync.Once
+	limiter     ratelimit.Limiter
+)
+
+// rateLimiter the buckets in redis if it is configured, so that the replicas
+// share them, nil if rate_limit.enabled is false
+func rateLimiter() ratelimit.Limiter {
+	limiterOnce.Do(
+		func() {
+			if viper.IsSet("rate_limit.enabled") && !viper.GetBool("rate_limit.enabled") {
+				return
+			}
+			if napp.App != nil && napp.App.RedisClient != nil {
+				limiter = ratelimit.NewRedisLimiter(napp.App.RedisClient, viper.GetString("cache.prefix")+"ratelimit:")
+				return
+			}
+			limiter = ratelimit.NewMemoryLimiter()
+		},
+	)
+	return limiter
+}
+
+func rateOf(policy string) ratelimit.Rate {
+	rate := defaultRates[policy]
+	if key := "rate_limit." + policy + ".limit"; viper.IsSet(key) {
+		rate.Limit = viper.GetFloat64(key)
+	}
+	if key := "rate_limit." + policy + ".burst"; viper.IsSet(key) {
+		rate.Burst = viper.GetInt(key)
+	}
+	return rate
+}
+
+// RateLimit limit the requests of policy by the token bucket of user, or of
+// ip before authenticated, the requests limited are responded 429 with the
+// header Retry-After, the requests are allowed if the limiter fails
+func RateLimit(policy string) gin.HandlerFunc {
+	rate := rateOf(policy)
+	return func(c *gin.Context) {
+		l := rateLimiter()
+		if l == nil || !rate.Valid() {
+			c.Next()
+			return
+		}
+
+		key := policy + ":ip:" + c.ClientIP()
+		if userId, ok := c.Value("userId").(uint64); ok {
+			key = policy + ":user:" + strconv.FormatUint(userId, 10)
+		}
+		allowed, retryAfter, err := l.Take(key, rate)
+		if err != nil {
+			log.Warnf("rate limit %s err: %v", key, err)
+			c.Next()
+			return
+		}
+		if !allowed {
+			metrics.RateLimited(policy)
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(1, math.Ceil(retryAfter.Seconds())))))
+			api.SendStatusResponse(c, errno.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+	"nocalhost/pkg/nocalhost-api/pkg/utils"
+)
+
+// Trace continue the W3C trace of header traceparent or start a new one,
+// the span of request is responded in the header and propagated to the
+// kubernetes requests, it must be used after RequestID. The span is exported
+// as the server span of the route if tracing is enabled
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t, parentID := trace.New(), ""
+		if parent, ok := trace.Parse(c.GetHeader(trace.Header)); ok {
+			t, parentID = parent.Child(), parent.SpanID
+		}
+		t.RequestID = c.GetString(utils.XRequestID)
+
+		c.Set(trace.GinKey, t)
+		c.Request = c.Request.WithContext(trace.NewContext(c.Request.Context(), t))
+		c.Writer.Header().Set(trace.Header, t.String())
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		span := trace.Begin(t, parentID, c.Request.Method+" "+route, trace.KindServer).
+			Set("http.method", c.Request.Method).
+			Set("http.route", route).
+			Set("http.client_ip", c.ClientIP())
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.Set("http.status_code", status)
+		if code, ok := c.Get(api.ResponseCodeKey); ok {
+			span.Set("nocalhost.code", code)
+		}
+		if status >= http.StatusInternalServerError {
+			span.End(errors.New(http.StatusText(status)))
+			return
+		}
+		span.End(nil)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "nocalhost/internal/nocalhost-api/rpc/nocalhost/v1"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+	"nocalhost/pkg/nocalhost-api/pkg/tlsserver"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+	"nocalhost/pkg/nocalhost-api/pkg/utils"
+)
+
+// CodeKey the trailer of errno code responded by the routes
+const CodeKey = "nocalhost-code"
+
+// Server serves the gRPC services by the routes of handler in process, the
+// metadata authorization is sent as the header of requests, so that the
+// authorization and permissions are the same as REST
+type Server struct {
+	handler http.Handler
+}
+
+// NewServer the gRPC server of services served by handler, e.g. the gin
+// engine with routes loaded
+func NewServer(handler http.Handler, opts ...grpc.ServerOption) *grpc.Server {
+	s := &Server{handler: handler}
+	server := grpc.NewServer(opts...)
+	pb.RegisterUserServiceServer(server, &userService{s})
+	pb.RegisterClusterServiceServer(server, &clusterService{s})
+	pb.RegisterApplicationServiceServer(server, &applicationService{s})
+	pb.RegisterDevSpaceServiceServer(server, &devSpaceService{s})
+	return server
+}
+
+// Serve listen on addr and serve the gRPC services until failed or stopped,
+// the server is stopped gracefully in the drain of shutdown
+func Serve(addr string, handler http.Handler) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("listen grpc on %s err: %v", addr, err)
+	}
+	// served on tls as the routes if app.tls is configured
+	var opts []grpc.ServerOption
+	tlsConfig, err := tlsserver.Config()
+	if err != nil {
+		log.Fatalf("tls of grpc err: %v", err)
+	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := NewServer(handler, opts...)
+	shutdown.Register("grpc server", func(ctx context.Context) error { return gracefulStop(ctx, server) })
+
+	log.Infof("start grpc server on %s", addr)
+	if err := server.Serve(listener); err != nil {
+		log.Fatalf("serve grpc err: %v", err)
+	}
+}
+
+// gracefulStop wait for the rpcs in flight, they are cancelled if ctx is done
+func gracefulStop(ctx context.Context, server *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		server.Stop()
+		return ctx.Err()
+	}
+}
+
+// forwarded metadata of rpc to the headers of requests
+var forwarded = map[string]string{
+	"authorization":   "Authorization",
+	"x-request-id":    utils.XRequestID,
+	"x-forwarded-for": "X-Forwarded-For",
+	"user-agent":      "User-Agent",
+	trace.Header:      trace.Header,
+}
+
+// newRequest the request of route for rpc, the peer of rpc is the remote
+// address, which is validated with the session of token
+func (s *Server) newRequest(ctx context.Context, method, path string, query url.Values) (*http.Request, error) {
+	target := path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, header := range forwarded {
+			if values := md.Get(key); len(values) > 0 {
+				req.Header.Set(header, values[0])
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			req.RemoteAddr = p.Addr.String()
+		}
+		// the client certificate authenticates the rpc as the request
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			req.TLS = &info.State
+		}
+	}
+	return req, nil
+}
+
+// call the route and decode the data responded to out, the data of lists is
+// decoded to the field list of out with the pagination
+func (s *Server) call(ctx context.Context, path string, query url.Values, list string, out proto.Message) error {
+	req, err := s.newRequest(ctx, http.MethodGet, path, query)
+	if err != nil {
+		return err
+	}
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+	return decode(ctx, w.Code, w.Body.Bytes(), list, out)
+}
+
+// response of the routes, in the envelope of /v1 or /v2
+type response struct {
+	Code       *int             `json:"code"`
+	Message    string           `json:"message"`
+	Data       json.RawMessage  `json:"data"`
+	Pagination *api.Pagination  `json:"pagination"`
+	Error      *api.ErrorDetail `json:"error"`
+}
+
+// unknown fields are dropped, the messages are the subset of the responses
+var unmarshaler = protojson.UnmarshalOptions{DiscardUnknown: true}
+
+func decode(ctx context.Context, httpStatus int, body []byte, list string, out proto.Message) error {
+	var resp response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return status.Errorf(grpcCode(httpStatus), "unexpected response of http status %d", httpStatus)
+	}
+	switch {
+	case resp.Error != nil:
+		return routeError(ctx, httpStatus, resp.Error.Code, resp.Error.Message)
+	case resp.Code != nil && *resp.Code != errno.OK.Code:
+		return routeError(ctx, api.CodeStatus(*resp.Code), *resp.Code, resp.Message)
+	case httpStatus != http.StatusOK:
+		return status.Errorf(grpcCode(httpStatus), "unexpected response of http status %d", httpStatus)
+	}
+
+	data := []byte(resp.Data)
+	if list != "" {
+		data, _ = json.Marshal(map[string]interface{}{list: resp.Data, "pagination": resp.Pagination})
+	} else if len(data) == 0 || data[0] != '{' {
+		// some of the legacy handlers respond empty data if not found
+		return status.Error(codes.NotFound, "not found")
+	}
+	if err := unmarshaler.Unmarshal(data, out); err != nil {
+		return status.Errorf(codes.Internal, "decode response err: %v", err)
+	}
+	return nil
+}
+
+func routeError(ctx context.Context, httpStatus, code int, message string) error {
+	_ = grpc.SetTrailer(ctx, metadata.Pairs(CodeKey, strconv.Itoa(code)))
+	return status.Error(grpcCode(httpStatus), message)
+}
+
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusTooManyRequests:     codes.ResourceExhausted,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusGatewayTimeout:      codes.DeadlineExceeded,
+}
+
+func grpcCode(httpStatus int) codes.Code {
+	if code, ok := grpcCodes[httpStatus]; ok {
+		return code
+	}
+	return codes.Unknown
+}
+
+// pageQuery the query of pagination, zero is the default of /v2
+func pageQuery(page, pageSize int32) url.Values {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", strconv.Itoa(int(page)))
+	}
+	if pageSize > 0 {
+		query.Set("page_size", strconv.Itoa(int(pageSize)))
+	}
+	return query
+}
+
+func setIf(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setUintIf(query url.Values, key string, value uint64) {
+	if value != 0 {
+		query.Set(key, strconv.FormatUint(value, 10))
+	}
+}
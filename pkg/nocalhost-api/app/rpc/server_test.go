@@ -0,0 +1,175 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package rpc
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"nocalhost/internal/nocalhost-api/model"
+	pb "nocalhost/internal/nocalhost-api/rpc/nocalhost/v1"
+	"nocalhost/pkg/nocalhost-api/app/api"
+	"nocalhost/pkg/nocalhost-api/pkg/errno"
+	"nocalhost/pkg/nocalhost-api/pkg/spacetemplate"
+)
+
+func testServer() *Server {
+	gin.SetMode(gin.TestMode)
+	g := gin.New()
+	auth := func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "Bearer token" {
+			api.SendResponse(c, errno.ErrTokenInvalid, nil)
+			c.Abort()
+		}
+	}
+	g.GET("/v1/me", auth, func(c *gin.Context) {
+		api.SendResponse(c, nil, &model.UserBaseModel{ID: 1, Name: "admin", Email: "admin@nocalhost.dev"})
+	})
+
+	v := g.Group("/v2", api.Envelope, auth)
+	v.GET("/clusters", func(c *gin.Context) {
+		api.SendResponse(c, nil, []*model.ClusterListVo{
+			{ClusterList: model.ClusterList{ID: 1, ClusterName: "a", Labels: model.Labels{"env": "dev"}}},
+			{ClusterList: model.ClusterList{ID: 2, ClusterName: "b"}},
+		})
+	})
+	v.GET("/clusters/:id", func(c *gin.Context) {
+		api.SendResponse(c, nil, make([]interface{}, 0))
+	})
+	v.GET("/users/:id", func(c *gin.Context) {
+		api.SendResponse(c, errno.ErrUserNotFound, nil)
+	})
+
+	g.GET("/v1/dev_space/:id/app_installs/:install_id/events", auth, func(c *gin.Context) {
+		if c.Param("id") != "1" {
+			api.SendResponse(c, errno.ErrPermissionDenied, nil)
+			return
+		}
+		sent := false
+		c.Stream(func(io.Writer) bool {
+			if !sent {
+				c.SSEvent("progress", &spacetemplate.ProgressEvent{
+					Type: spacetemplate.EventPod, Name: "productpage-0", Status: spacetemplate.PodImagePulling,
+					Time: time.Now(),
+				})
+				sent = true
+				return true
+			}
+			c.SSEvent("done", &model.DevSpaceAppInstallModel{ID: 2, Status: spacetemplate.StatusInstalled})
+			return false
+		})
+	})
+	return &Server{handler: g}
+}
+
+func authorized() context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+}
+
+func TestUnary(t *testing.T) {
+	s := testServer()
+
+	me, err := (&userService{s}).GetMe(authorized(), &pb.GetMeRequest{})
+	if err != nil || me.Id != 1 || me.Email != "admin@nocalhost.dev" {
+		t.Fatalf("get me: %v %v", me, err)
+	}
+
+	clusters, err := (&clusterService{s}).ListClusters(authorized(), &pb.ListClustersRequest{PageSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters.Clusters) != 1 || clusters.Clusters[0].Labels["env"] != "dev" {
+		t.Fatalf("unexpected clusters %v", clusters.Clusters)
+	}
+	if p := clusters.Pagination; p.Page != 1 || p.PageSize != 1 || p.Total != 2 {
+		t.Fatalf("unexpected pagination %v", p)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := testServer()
+	for name, c := range map[string]struct {
+		call func() error
+		code codes.Code
+	}{
+		"no token": {
+			func() error {
+				_, err := (&userService{s}).GetMe(context.Background(), &pb.GetMeRequest{})
+				return err
+			}, codes.Unauthenticated,
+		},
+		"v2 error": {
+			func() error {
+				_, err := (&userService{s}).GetUser(authorized(), &pb.GetUserRequest{Id: 1})
+				return err
+			}, codes.NotFound,
+		},
+		"empty data": {
+			func() error {
+				_, err := (&clusterService{s}).GetCluster(authorized(), &pb.GetClusterRequest{Id: 1})
+				return err
+			}, codes.NotFound,
+		},
+		"no route": {
+			func() error {
+				_, err := (&applicationService{s}).GetApplication(authorized(), &pb.GetApplicationRequest{Id: 1})
+				return err
+			}, codes.NotFound,
+		},
+	} {
+		if code := status.Code(c.call()); code != c.code {
+			t.Errorf("%s: expect %v, got %v", name, c.code, code)
+		}
+	}
+}
+
+type watchStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*pb.WatchAppInstallResponse
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(resp *pb.WatchAppInstallResponse) error {
+	s.responses = append(s.responses, resp)
+	return nil
+}
+
+func TestWatchAppInstall(t *testing.T) {
+	s := testServer()
+
+	stream := &watchStream{ctx: authorized()}
+	err := (&devSpaceService{s}).WatchAppInstall(&pb.WatchAppInstallRequest{DevSpaceId: 1, InstallId: 2}, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.responses) != 2 {
+		t.Fatalf("expect 2 responses, got %v", stream.responses)
+	}
+	if p := stream.responses[0].Progress; p == nil || p.Name != "productpage-0" || p.Status != "image_pulling" {
+		t.Fatalf("unexpected progress %v", stream.responses[0])
+	}
+	if done := stream.responses[1]; done.Event != "done" || done.Install.GetStatus() != "installed" {
+		t.Fatalf("unexpected done %v", done)
+	}
+
+	stream = &watchStream{ctx: authorized()}
+	err = (&devSpaceService{s}).WatchAppInstall(&pb.WatchAppInstallRequest{DevSpaceId: 3, InstallId: 2}, stream)
+	if status.Code(err) != codes.PermissionDenied || len(stream.responses) != 0 {
+		t.Fatalf("expect permission denied, got %v %v", err, stream.responses)
+	}
+}
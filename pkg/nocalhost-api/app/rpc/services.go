@@ -0,0 +1,138 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pb "nocalhost/internal/nocalhost-api/rpc/nocalhost/v1"
+)
+
+type userService struct {
+	*Server
+}
+
+func (s *userService) GetMe(ctx context.Context, _ *pb.GetMeRequest) (*pb.User, error) {
+	out := &pb.User{}
+	return out, s.call(ctx, "/v1/me", nil, "", out)
+}
+
+func (s *userService) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	out := &pb.User{}
+	return out, s.call(ctx, fmt.Sprintf("/v2/users/%d", req.Id), nil, "", out)
+}
+
+func (s *userService) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	query := pageQuery(req.Page, req.PageSize)
+	setIf(query, "search", req.Search)
+	setIf(query, "sort", req.Sort)
+	setIf(query, "order", req.Order)
+	out := &pb.ListUsersResponse{}
+	return out, s.call(ctx, "/v2/users", query, "users", out)
+}
+
+type clusterService struct {
+	*Server
+}
+
+func (s *clusterService) GetCluster(ctx context.Context, req *pb.GetClusterRequest) (*pb.Cluster, error) {
+	out := &pb.Cluster{}
+	return out, s.call(ctx, fmt.Sprintf("/v2/clusters/%d", req.Id), nil, "", out)
+}
+
+func (s *clusterService) ListClusters(ctx context.Context, req *pb.ListClustersRequest) (
+	*pb.ListClustersResponse, error) {
+	query := pageQuery(req.Page, req.PageSize)
+	setIf(query, "label_selector", req.LabelSelector)
+	out := &pb.ListClustersResponse{}
+	return out, s.call(ctx, "/v2/clusters", query, "clusters", out)
+}
+
+type applicationService struct {
+	*Server
+}
+
+func (s *applicationService) GetApplication(ctx context.Context, req *pb.GetApplicationRequest) (
+	*pb.Application, error) {
+	out := &pb.Application{}
+	return out, s.call(ctx, fmt.Sprintf("/v2/applications/%d", req.Id), nil, "", out)
+}
+
+func (s *applicationService) ListApplications(ctx context.Context, req *pb.ListApplicationsRequest) (
+	*pb.ListApplicationsResponse, error) {
+	query := pageQuery(req.Page, req.PageSize)
+	setUintIf(query, "user_id", req.UserId)
+	out := &pb.ListApplicationsResponse{}
+	return out, s.call(ctx, "/v2/applications", query, "applications", out)
+}
+
+type devSpaceService struct {
+	*Server
+}
+
+func (s *devSpaceService) GetDevSpace(ctx context.Context, req *pb.GetDevSpaceRequest) (*pb.DevSpace, error) {
+	out := &pb.DevSpace{}
+	return out, s.call(ctx, fmt.Sprintf("/v2/dev_spaces/%d", req.Id), nil, "", out)
+}
+
+func (s *devSpaceService) ListDevSpaces(ctx context.Context, req *pb.ListDevSpacesRequest) (
+	*pb.ListDevSpacesResponse, error) {
+	query := pageQuery(req.Page, req.PageSize)
+	setUintIf(query, "owner_user_id", req.OwnerUserId)
+	setUintIf(query, "cluster_id", req.ClusterId)
+	setIf(query, "space_name", req.SpaceName)
+	out := &pb.ListDevSpacesResponse{}
+	return out, s.call(ctx, "/v2/dev_spaces", query, "dev_spaces", out)
+}
+
+// WatchAppInstall served by the route of server-sent events, each event is
+// sent as a response of stream until the installation is finished
+func (s *devSpaceService) WatchAppInstall(req *pb.WatchAppInstallRequest,
+	stream pb.DevSpaceService_WatchAppInstallServer) error {
+	ctx := stream.Context()
+	path := fmt.Sprintf("/v1/dev_space/%d/app_installs/%d/events", req.DevSpaceId, req.InstallId)
+	r, err := s.newRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	w := newEventWriter(
+		func(event string, data []byte) error {
+			resp := &pb.WatchAppInstallResponse{Event: event}
+			var message proto.Message
+			switch event {
+			case "progress":
+				resp.Progress = &pb.ProgressEvent{}
+				message = resp.Progress
+			case "done":
+				resp.Install = &pb.AppInstall{}
+				message = resp.Install
+			default:
+				return nil
+			}
+			if err := unmarshaler.Unmarshal(data, message); err != nil {
+				return status.Errorf(codes.Internal, "decode %s event err: %v", event, err)
+			}
+			return stream.Send(resp)
+		},
+	)
+	s.handler.ServeHTTP(w, r)
+	if w.err != nil {
+		return w.err
+	}
+
+	// the errors are responded in json before streaming
+	if !w.streaming() {
+		return decode(ctx, w.status, w.body.Bytes(), "", &pb.AppInstall{})
+	}
+	return nil
+}
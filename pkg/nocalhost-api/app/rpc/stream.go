@@ -0,0 +1,96 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package rpc
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// eventWriter the response writer of server-sent events, each event written
+// by handler is parsed and sent by send, the responses not in event stream
+// are kept in body
+type eventWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+
+	send func(event string, data []byte) error
+	err  error
+
+	once   sync.Once
+	closed chan bool
+}
+
+func newEventWriter(send func(event string, data []byte) error) *eventWriter {
+	return &eventWriter{header: http.Header{}, status: http.StatusOK, send: send, closed: make(chan bool, 1)}
+}
+
+func (w *eventWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *eventWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *eventWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.body.Write(p)
+	if w.streaming() {
+		w.dispatch()
+	}
+	return len(p), w.err
+}
+
+func (w *eventWriter) streaming() bool {
+	return strings.HasPrefix(w.header.Get("Content-Type"), "text/event-stream")
+}
+
+// dispatch send the events completed in body, events are separated by a
+// blank line
+func (w *eventWriter) dispatch() {
+	for {
+		content := w.body.Bytes()
+		end := bytes.Index(content, []byte("\n\n"))
+		if end < 0 {
+			return
+		}
+		event, data := parseEvent(content[:end])
+		w.body.Next(end + 2)
+		if err := w.send(event, data); err != nil {
+			w.err = err
+			w.once.Do(func() { w.closed <- true })
+			return
+		}
+	}
+}
+
+func parseEvent(frame []byte) (string, []byte) {
+	var event string
+	var data [][]byte
+	for _, line := range bytes.Split(frame, []byte("\n")) {
+		switch {
+		case bytes.HasPrefix(line, []byte("event:")):
+			event = strings.TrimSpace(string(line[len("event:"):]))
+		case bytes.HasPrefix(line, []byte("data:")):
+			data = append(data, bytes.TrimPrefix(line[len("data:"):], []byte(" ")))
+		}
+	}
+	return event, bytes.Join(data, []byte("\n"))
+}
+
+func (w *eventWriter) Flush() {}
+
+// CloseNotify notified if the stream is broken, the handler stops streaming
+// by the context of request if the rpc is canceled
+func (w *eventWriter) CloseNotify() <-chan bool {
+	return w.closed
+}
@@ -15,8 +15,14 @@ import (
 	"syscall"
 	"time"
 
+	"nocalhost/internal/nocalhost-api/cache"
+	"nocalhost/internal/nocalhost-api/migration"
 	"nocalhost/internal/nocalhost-api/model"
 	"nocalhost/pkg/nocalhost-api/conf"
+	"nocalhost/pkg/nocalhost-api/pkg/health"
+	redis2 "nocalhost/pkg/nocalhost-api/pkg/redis"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+	"nocalhost/pkg/nocalhost-api/pkg/tlsserver"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis"
@@ -31,6 +37,8 @@ const (
 	ModeRelease string = "release"
 	// ModeTest test mode
 	ModeTest string = "test"
+
+	defaultShutdownTimeout = 30 * time.Second
 )
 
 // App is singleton
@@ -53,11 +61,14 @@ func New(cfg *conf.Config) *Application {
 	// init db
 	app.DB = model.Init()
 
-	// migrate db
-	model.MigrateDB()
+	// migrate db, refuse to run against the schema of a newer version
+	migrateDB(app.DB)
 
-	// init redis
-	// app.RedisClient = redis2.InitDir()
+	// init redis, shared by the replicas as the cache of hot reads
+	if viper.GetString("cache.driver") == "redis" {
+		app.RedisClient = redis2.Init()
+		cache.EnableRemote(app.RedisClient, viper.GetString("cache.prefix"))
+	}
 
 	// init router
 	// Set gin mode.
@@ -78,31 +89,82 @@ func New(cfg *conf.Config) *Application {
 	//init validate
 	app.Validate = validator.New()
 
+	// the dependencies checked by the probes of readiness
+	health.Register("database", func(ctx context.Context) error { return app.DB.DB().PingContext(ctx) })
+	if app.RedisClient != nil {
+		health.Register("redis", func(ctx context.Context) error { return app.RedisClient.Ping().Err() })
+	}
+
 	return app
 }
 
-// Run start a app
+// migrateDB apply the migrations pending unless database.auto_migrate is false,
+// the schema newer or changed is refused
+func migrateDB(db *gorm.DB) {
+	pending, err := migration.Check(db)
+	if err != nil {
+		log.Fatalf("check database migrations: %v", err)
+	}
+	if len(pending) == 0 {
+		return
+	}
+	if viper.IsSet("database.auto_migrate") && !viper.GetBool("database.auto_migrate") {
+		log.Printf("%d database migrations pending, run nocalhost-api migrate up", len(pending))
+		return
+	}
+	if _, err := migration.Up(db, 0); err != nil {
+		log.Fatalf("migrate database: %v", err)
+	}
+}
+
+// Run start a app, it returns after the server is shut down
 func (a *Application) Run() {
-	log.Printf("Start to listening the incoming requests on http address: %s", viper.GetString("app.addr"))
+	// https if the certificate or ACME of app.tls is configured
+	tlsConfig, err := tlsserver.Config()
+	if err != nil {
+		log.Fatalf("tls: %s", err.Error())
+	}
 	srv := &http.Server{
-		Addr:    viper.GetString("app.addr"),
-		Handler: a.Router,
+		Addr:      viper.GetString("app.addr"),
+		Handler:   a.Router,
+		TLSConfig: tlsConfig,
 	}
 	go func() {
 		// service connections
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if tlsConfig != nil {
+			log.Printf("Start to listening the incoming requests on https address: %s", srv.Addr)
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			log.Printf("Start to listening the incoming requests on http address: %s", srv.Addr)
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatalf("listen: %s", err.Error())
 		}
 	}()
+	shutdown.Register("http server", srv.Shutdown)
+
+	// the challenges of http-01 of ACME, and the redirection to https
+	if handler := tlsserver.ChallengeHandler(); handler != nil {
+		challenge := &http.Server{Addr: viper.GetString(tlsserver.TLS_ACME_HTTP_ADDR), Handler: handler}
+		go func() {
+			if err := challenge.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("listen acme challenges: %s", err.Error())
+			}
+		}()
+		shutdown.Register("acme challenge server", challenge.Shutdown)
+	}
 
-	gracefulStop(srv)
+	a.gracefulStop()
 }
 
 // gracefulStop 优雅退出
-// 等待中断信号以超时 5 秒正常关闭服务器
+// 等待中断信号后，先报告未就绪，等待 app.shutdown_delay 使负载均衡摘除流量，
+// 再在 app.shutdown_timeout 内处理完正在进行的请求和后台任务，最后关闭数据库和 redis 连接池
 // 官方说明：https://github.com/gin-gonic/gin#graceful-shutdown-or-restart
-func gracefulStop(srv *http.Server) {
-	quit := make(chan os.Signal)
+func (a *Application) gracefulStop() {
+	quit := make(chan os.Signal, 1)
 	// kill (no param) default send syscall.SIGTERM
 	// kill -2 is syscall.SIGINT
 	// kill -9 is syscall.SIGKILL
@@ -110,12 +172,30 @@ func gracefulStop(srv *http.Server) {
 	<-quit
 	log.Println("Shutting down server...")
 
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// report not ready, the endpoints are removed by the load balancer in the
+	// delay while the requests are still served
+	shutdown.Begin()
+	if delay := viper.GetDuration("app.shutdown_delay"); delay > 0 {
+		time.Sleep(delay)
+	}
+
+	// The context is used to inform the servers and the background jobs how
+	// long they have to finish the work in flight
+	timeout := viper.GetDuration("app.shutdown_timeout")
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+	if err := shutdown.Drain(ctx); err != nil {
+		log.Println("Server forced to shutdown:", err)
+	}
+
+	if a.RedisClient != nil {
+		_ = a.RedisClient.Close()
+	}
+	if a.DB != nil {
+		_ = a.DB.Close()
 	}
 	log.Println("Server exiting")
 }
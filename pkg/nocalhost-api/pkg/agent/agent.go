@@ -0,0 +1,217 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package agent
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+const (
+	// ConnectPath is the path of nocalhost-api the agent dials to
+	ConnectPath = "/v1/agent/connect"
+
+	defaultCaFile    = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+	defaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	maxBackoff = time.Minute
+)
+
+// Agent is deployed in the cluster which nocalhost-api can not reach,
+// it dials out to nocalhost-api and forwards each stream of the tunnel
+// to the api server of cluster, so the tls is end to end
+type Agent struct {
+	// Server is the url of nocalhost-api
+	Server string
+	// Token is the registration token created by nocalhost-api
+	Token string
+	// Insecure skip verifying the certificate of nocalhost-api
+	Insecure bool
+
+	// ApiServer is the address of api server, default to the in-cluster one
+	ApiServer string
+	CaFile    string
+	TokenFile string
+}
+
+// Run keeps the tunnel connected until the context is done
+func (a *Agent) Run(ctx context.Context) error {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := a.serve(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if time.Since(start) > maxBackoff {
+			backoff = time.Second
+		}
+		log.Warnf("Tunnel to %s disconnected: %v, reconnect in %s", a.Server, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (a *Agent) serve(ctx context.Context) error {
+	conn, err := a.dial(ctx)
+	if err != nil {
+		return err
+	}
+
+	apiServer := a.apiServer()
+	// nocalhost-api opens the streams so agent acts as the server side of spdy
+	tunnel, err := spdy.NewServerConnectionWithPings(
+		conn, func(stream httpstream.Stream, replySent <-chan struct{}) error {
+			go forward(stream, apiServer)
+			return nil
+		}, pingPeriod,
+	)
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "Fail to create tunnel")
+	}
+	defer tunnel.Close()
+
+	log.Infof("Tunnel to %s connected", a.Server)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-tunnel.CloseChan():
+		return errors.New("tunnel closed")
+	}
+}
+
+// dial connects to nocalhost-api and upgrades the connection
+func (a *Agent) dial(ctx context.Context) (net.Conn, error) {
+	u, err := url.Parse(a.Server)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid server of nocalhost-api")
+	}
+
+	ca, err := ioutil.ReadFile(a.caFile())
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to read ca of cluster")
+	}
+	token, err := ioutil.ReadFile(a.tokenFile())
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to read service account token")
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	var conn net.Conn
+	if u.Scheme == "https" {
+		conn, err = tls.DialWithDialer(
+			dialer, "tcp", host, &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: a.Insecure},
+		)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to dial nocalhost-api")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, a.Server+ConnectPath, nil)
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	req.Header.Set(httpstream.HeaderConnection, httpstream.HeaderUpgrade)
+	req.Header.Set(httpstream.HeaderUpgrade, Protocol)
+	req.Header.Set(HeaderAgentCa, base64.StdEncoding.EncodeToString(ca))
+	req.Header.Set(HeaderAgentToken, base64.StdEncoding.EncodeToString(token))
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Fail to request nocalhost-api")
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Fail to read response of nocalhost-api")
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		conn.Close()
+		return nil, errors.New(fmt.Sprintf("Fail to upgrade, status %d: %s", resp.StatusCode, body))
+	}
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+func (a *Agent) apiServer() string {
+	if a.ApiServer != "" {
+		return a.ApiServer
+	}
+	return net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+}
+
+func (a *Agent) caFile() string {
+	if a.CaFile != "" {
+		return a.CaFile
+	}
+	return defaultCaFile
+}
+
+func (a *Agent) tokenFile() string {
+	if a.TokenFile != "" {
+		return a.TokenFile
+	}
+	return defaultTokenFile
+}
+
+// forward copies the bytes between the stream and api server
+func forward(stream httpstream.Stream, apiServer string) {
+	defer stream.Reset()
+
+	conn, err := net.DialTimeout("tcp", apiServer, 10*time.Second)
+	if err != nil {
+		log.Warnf("Fail to dial api server %s: %v", apiServer, err)
+		return
+	}
+	defer conn.Close()
+
+	var once sync.Once
+	done := make(chan struct{})
+	cp := func(dst io.Writer, src io.Reader) {
+		_, _ = io.Copy(dst, src)
+		once.Do(func() { close(done) })
+	}
+	go cp(conn, stream)
+	go cp(stream, conn)
+	<-done
+}
@@ -0,0 +1,129 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package agent
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+func TestServer(t *testing.T) {
+	if !IsTunnel(Server(12)) {
+		t.Fatal("server of agent should be a tunnel")
+	}
+	if IsTunnel("https://10.0.0.1:6443") {
+		t.Fatal("api server should not be a tunnel")
+	}
+
+	c := &rest.Config{Host: Server(12)}
+	Configure(c)
+	if c.Dial == nil || c.TLSClientConfig.ServerName != serverName {
+		t.Fatal("tunnel is not configured")
+	}
+
+	if id, ok := parseHost("cluster-12.nocalhost-agent:443"); !ok || id != 12 {
+		t.Fatalf("unexpected agent id %d", id)
+	}
+}
+
+func TestTunnel(t *testing.T) {
+	log.NewLogger(&log.Config{Writers: "stdout"}, log.InstanceZapLogger)
+
+	// echo server acts as the api server of cluster
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _ = io.Copy(conn, conn)
+			}()
+		}
+	}()
+
+	srv := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != ConnectPath || r.Header.Get("Authorization") != "Bearer secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if _, err := Upgrade(w, 1); err != nil {
+					t.Error(err)
+				}
+			},
+		),
+	)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	tokenFile := filepath.Join(dir, "token")
+	_ = ioutil.WriteFile(caFile, []byte("ca"), 0644)
+	_ = ioutil.WriteFile(tokenFile, []byte("token"), 0644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := &Agent{
+		Server:    srv.URL,
+		Token:     "secret",
+		ApiServer: l.Addr().String(),
+		CaFile:    caFile,
+		TokenFile: tokenFile,
+	}
+	go a.Run(ctx)
+
+	for i := 0; !Connected(1); i++ {
+		if i > 100 {
+			t.Fatal("agent is not connected")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	conn, err := Dial(ctx, "tcp", "cluster-1.nocalhost-agent:443")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 4)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "ping" {
+		t.Fatalf("unexpected %s", b)
+	}
+
+	if _, err := Dial(ctx, "tcp", "cluster-2.nocalhost-agent:443"); err == nil {
+		t.Fatal("agent 2 should not be connected")
+	}
+
+	Disconnect(1)
+	if Connected(1) {
+		t.Fatal("agent should be disconnected")
+	}
+}
@@ -0,0 +1,53 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package agent
+
+import (
+	"bufio"
+	"net"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+// bufferedConn reads the bytes buffered while parsing the http
+// upgrade before reading from the connection
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// streamConn adapts a stream of tunnel to net.Conn, deadlines are
+// not supported and the timeouts are left to the http transport
+type streamConn struct {
+	httpstream.Stream
+	address string
+}
+
+type tunnelAddr string
+
+func (a tunnelAddr) Network() string { return Protocol }
+func (a tunnelAddr) String() string  { return string(a) }
+
+func (c *streamConn) Close() error {
+	return c.Stream.Reset()
+}
+
+func (c *streamConn) LocalAddr() net.Addr {
+	return tunnelAddr(Protocol)
+}
+
+func (c *streamConn) RemoteAddr() net.Addr {
+	return tunnelAddr(c.address)
+}
+
+func (c *streamConn) SetDeadline(t time.Time) error      { return nil }
+func (c *streamConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *streamConn) SetWriteDeadline(t time.Time) error { return nil }
@@ -0,0 +1,109 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package agent
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/spf13/viper"
+
+	"nocalhost/internal/nocalhost-api/global"
+)
+
+const (
+	AGENT_IMAGE  = "cluster_agent.image"
+	AGENT_SERVER = "cluster_agent.server"
+
+	defaultImage = global.NocalhostRegistry + "/nocalhost/public/nocalhost-agent:latest"
+)
+
+var manifest = template.Must(template.New("agent").Parse(`apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ .Namespace }}
+  labels:
+    env: {{ .Namespace }}
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: nocalhost-agent
+  namespace: {{ .Namespace }}
+stringData:
+  token: {{ .Token }}
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: nocalhost-agent
+  namespace: {{ .Namespace }}
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: nocalhost-agent
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: cluster-admin
+subjects:
+  - kind: ServiceAccount
+    name: nocalhost-agent
+    namespace: {{ .Namespace }}
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nocalhost-agent
+  namespace: {{ .Namespace }}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: nocalhost-agent
+  template:
+    metadata:
+      labels:
+        app: nocalhost-agent
+    spec:
+      serviceAccountName: nocalhost-agent
+      containers:
+        - name: nocalhost-agent
+          image: {{ .Image }}
+          args:
+            - --server={{ .Server }}
+          env:
+            - name: NOCALHOST_AGENT_TOKEN
+              valueFrom:
+                secretKeyRef:
+                  name: nocalhost-agent
+                  key: token
+`))
+
+// Manifest renders the resources to deploy the agent with the
+// registration token, server is the url of nocalhost-api the agent
+// dials to, cluster_agent.server is preferred if configured
+func Manifest(server, token string) (string, error) {
+	if s := viper.GetString(AGENT_SERVER); s != "" {
+		server = s
+	}
+	image := viper.GetString(AGENT_IMAGE)
+	if image == "" {
+		image = defaultImage
+	}
+
+	buf := &bytes.Buffer{}
+	err := manifest.Execute(
+		buf, map[string]string{
+			"Namespace": global.NocalhostSystemNamespace,
+			"Token":     token,
+			"Server":    server,
+			"Image":     image,
+		},
+	)
+	return buf.String(), err
+}
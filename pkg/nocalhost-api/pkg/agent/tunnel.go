@@ -0,0 +1,176 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// Protocol is the value of Upgrade header of the tunnel
+	Protocol = "nocalhost-agent"
+
+	// HeaderAgentCa and HeaderAgentToken carry the base64 encoded ca and
+	// service account token of the agent, which are used to access the
+	// api server of the cluster through the tunnel
+	HeaderAgentCa    = "X-Nocalhost-Agent-Ca"
+	HeaderAgentToken = "X-Nocalhost-Agent-Token"
+
+	// hostSuffix of the servers in kubeconfig of the clusters accessed
+	// through the tunnel, the ip of it is never resolved
+	hostSuffix = ".nocalhost-agent"
+
+	// serverName the certificate of api server always contains
+	serverName = "kubernetes.default.svc"
+
+	pingPeriod = 30 * time.Second
+)
+
+var (
+	tunnels     = map[uint64]httpstream.Connection{}
+	tunnelsLock sync.RWMutex
+)
+
+// Server returns the server of kubeconfig of the cluster registered
+// by the agent
+func Server(agentId uint64) string {
+	return fmt.Sprintf("https://cluster-%d%s:443", agentId, hostSuffix)
+}
+
+// IsTunnel returns true if the server is accessed through the agent
+func IsTunnel(server string) bool {
+	_, ok := parseHost(strings.TrimPrefix(server, "https://"))
+	return ok
+}
+
+// Configure dial the api server through the tunnel if the host is
+// the one of Server, nothing changes for the others
+func Configure(c *rest.Config) {
+	if !IsTunnel(c.Host) {
+		return
+	}
+	c.Dial = Dial
+	if c.TLSClientConfig.ServerName == "" {
+		c.TLSClientConfig.ServerName = serverName
+	}
+}
+
+// Connected returns true if the agent has a tunnel to this api server,
+// notice that the tunnels are not shared between replicas of api
+func Connected(agentId uint64) bool {
+	tunnelsLock.RLock()
+	defer tunnelsLock.RUnlock()
+	_, ok := tunnels[agentId]
+	return ok
+}
+
+// Upgrade hijacks the connection of agent as the tunnel, the streams
+// are created by api and each of them is a connection to api server
+func Upgrade(w http.ResponseWriter, agentId uint64) (httpstream.Connection, error) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.New("Connection of agent can not be hijacked")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to hijack connection of agent")
+	}
+
+	if _, err := rw.WriteString(
+		"HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: " + Protocol + "\r\n\r\n",
+	); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Fail to upgrade connection of agent")
+	}
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Fail to upgrade connection of agent")
+	}
+
+	// api opens the streams so acts as the client side of spdy
+	tunnel, err := spdy.NewClientConnectionWithPings(&bufferedConn{Conn: conn, r: rw.Reader}, pingPeriod)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Fail to create tunnel of agent")
+	}
+
+	tunnelsLock.Lock()
+	if old, ok := tunnels[agentId]; ok {
+		old.Close()
+	}
+	tunnels[agentId] = tunnel
+	tunnelsLock.Unlock()
+
+	go func() {
+		<-tunnel.CloseChan()
+		tunnelsLock.Lock()
+		defer tunnelsLock.Unlock()
+		if tunnels[agentId] == tunnel {
+			delete(tunnels, agentId)
+		}
+	}()
+	return tunnel, nil
+}
+
+// Disconnect closes the tunnel of agent
+func Disconnect(agentId uint64) {
+	tunnelsLock.Lock()
+	defer tunnelsLock.Unlock()
+	if tunnel, ok := tunnels[agentId]; ok {
+		tunnel.Close()
+		delete(tunnels, agentId)
+	}
+}
+
+// Dial opens a connection to api server through the tunnel
+func Dial(ctx context.Context, network, address string) (net.Conn, error) {
+	agentId, ok := parseHost(address)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("%s is not accessed through agent", address))
+	}
+
+	tunnelsLock.RLock()
+	tunnel, ok := tunnels[agentId]
+	tunnelsLock.RUnlock()
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Agent of cluster %d is not connected", agentId))
+	}
+
+	stream, err := tunnel.CreateStream(http.Header{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to create stream of tunnel")
+	}
+	return &streamConn{Stream: stream, address: address}, nil
+}
+
+func parseHost(address string) (uint64, bool) {
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+	if !strings.HasPrefix(host, "cluster-") || !strings.HasSuffix(host, hostSuffix) {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(host, "cluster-"), hostSuffix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
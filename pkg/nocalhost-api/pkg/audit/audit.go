@@ -0,0 +1,147 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	beforeKey = "audit.before"
+	userKey   = "audit.user"
+
+	redacted = "******"
+
+	// MaxPayload is the max length of the payload to record
+	MaxPayload = 64 * 1024
+)
+
+// sensitive field names, matched by containing
+var sensitive = []string{"password", "secret", "token", "kubeconfig", "otp", "code", "key", "credential"}
+
+// Change is the change of a field in the request payload
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// SetBefore records the resource before modified, the changes in the
+// payload against it will be recorded in the audit log
+func SetBefore(c *gin.Context, v interface{}) {
+	c.Set(beforeKey, v)
+}
+
+// Before
+func Before(c *gin.Context) (interface{}, bool) {
+	return c.Get(beforeKey)
+}
+
+// SetUser records the user of the unauthenticated requests, such as login
+func SetUser(c *gin.Context, userId uint64) {
+	c.Set(userKey, userId)
+}
+
+// User returns the user of the request, the one set by SetUser first
+func User(c *gin.Context) uint64 {
+	if id, ok := c.Get(userKey); ok {
+		return id.(uint64)
+	}
+	if id, ok := c.Get("userId"); ok {
+		if userId, ok := id.(uint64); ok {
+			return userId
+		}
+	}
+	return 0
+}
+
+// Redact returns the json payload with the sensitive fields masked,
+// payload can not be parsed as json is not recorded
+func Redact(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return ""
+	}
+
+	marshal, err := json.Marshal(redact(v))
+	if err != nil || len(marshal) > MaxPayload {
+		return ""
+	}
+	return string(marshal)
+}
+
+func redact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, item := range t {
+			if isSensitive(k) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redact(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = redact(item)
+		}
+	}
+	return v
+}
+
+func isSensitive(field string) bool {
+	field = strings.ToLower(field)
+	for _, s := range sensitive {
+		if strings.Contains(field, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns the fields of the json payload that differ from before,
+// fields are matched by the json name of before
+func Diff(before interface{}, payload []byte) map[string]Change {
+	if before == nil || len(payload) == 0 {
+		return nil
+	}
+
+	var after map[string]interface{}
+	if err := json.Unmarshal(payload, &after); err != nil {
+		return nil
+	}
+
+	marshal, err := json.Marshal(before)
+	if err != nil {
+		return nil
+	}
+
+	var origin map[string]interface{}
+	if err := json.Unmarshal(marshal, &origin); err != nil {
+		return nil
+	}
+
+	result := map[string]Change{}
+	for k, to := range after {
+		from, ok := origin[k]
+		if !ok || reflect.DeepEqual(from, to) {
+			continue
+		}
+
+		if isSensitive(k) {
+			result[k] = Change{From: redacted, To: redacted}
+			continue
+		}
+		result[k] = Change{From: from, To: to}
+	}
+	return result
+}
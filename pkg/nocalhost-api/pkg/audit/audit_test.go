@@ -0,0 +1,45 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package audit
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	payload := `{"email":"bob@nocalhost.com","password":"123456","clusters":[{"name":"c1","kubeconfig":"apiVersion: v1"}]}`
+
+	result := Redact([]byte(payload))
+	expect := `{"clusters":[{"kubeconfig":"******","name":"c1"}],"email":"bob@nocalhost.com","password":"******"}`
+	if result != expect {
+		t.Errorf("expect %s but got %s", expect, result)
+	}
+
+	if Redact([]byte("not json")) != "" {
+		t.Error("payload not json should not be recorded")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := struct {
+		Name     string `json:"name"`
+		Status   uint64 `json:"status"`
+		Password string `json:"password"`
+	}{Name: "Bob", Status: 1, Password: "hash"}
+
+	diff := Diff(before, []byte(`{"name":"Alice","status":1,"password":"123456","unknown":"x"}`))
+	if len(diff) != 2 {
+		t.Fatalf("expect 2 changes but got %v", diff)
+	}
+
+	if diff["name"].From != "Bob" || diff["name"].To != "Alice" {
+		t.Errorf("unexpected change of name %v", diff["name"])
+	}
+
+	if diff["password"].From != redacted || diff["password"].To != redacted {
+		t.Errorf("password should be redacted, got %v", diff["password"])
+	}
+}
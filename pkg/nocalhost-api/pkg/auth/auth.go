@@ -5,15 +5,143 @@
 
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
 
-// Encrypt encrypts the plain text with bcrypt.
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	ARGON2_MEMORY      = "password_hash.argon2.memory"
+	ARGON2_TIME        = "password_hash.argon2.time"
+	ARGON2_THREADS     = "password_hash.argon2.threads"
+	ARGON2_SALT_LENGTH = "password_hash.argon2.salt_length"
+	ARGON2_KEY_LENGTH  = "password_hash.argon2.key_length"
+
+	argon2idPrefix = "$argon2id$"
+)
+
+var (
+	// ErrMismatchedPassword means the password is not the one hashed
+	ErrMismatchedPassword = errors.New("hashed password is not the hash of the given password")
+
+	// ErrInvalidHash means the hash can not be parsed
+	ErrInvalidHash = errors.New("the hashed password is in invalid format")
+)
+
+// Argon2Params is the parameters of argon2id, they are stored in the hash
+// in PHC string format, so changing them does not break the existing hashes
+type Argon2Params struct {
+	Memory     uint32
+	Time       uint32
+	Threads    uint8
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// CurrentParams returns the parameters from config, the unset ones default to
+// the second recommended option of RFC 9106 with less memory
+func CurrentParams() Argon2Params {
+	p := Argon2Params{Memory: 64 * 1024, Time: 3, Threads: 2, SaltLength: 16, KeyLength: 32}
+	if v := viper.GetUint32(ARGON2_MEMORY); v > 0 {
+		p.Memory = v
+	}
+	if v := viper.GetUint32(ARGON2_TIME); v > 0 {
+		p.Time = v
+	}
+	if v := viper.GetUint32(ARGON2_THREADS); v > 0 && v <= 255 {
+		p.Threads = uint8(v)
+	}
+	if v := viper.GetUint32(ARGON2_SALT_LENGTH); v > 0 {
+		p.SaltLength = v
+	}
+	if v := viper.GetUint32(ARGON2_KEY_LENGTH); v > 0 {
+		p.KeyLength = v
+	}
+	return p
+}
+
+// Encrypt encrypts the plain text with argon2id.
 func Encrypt(source string) (string, error) {
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(source), bcrypt.DefaultCost)
-	return string(hashedBytes), err
+	return encrypt(source, CurrentParams())
+}
+
+func encrypt(source string, p Argon2Params) (string, error) {
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(source), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key),
+	), nil
 }
 
-// Compare compares the encrypted text with the plain text if it's the same.
+// Compare compares the encrypted text with the plain text if it's the same,
+// both argon2id and the legacy bcrypt hashes are supported.
 func Compare(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, argon2idPrefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+
+	p, salt, key, err := decode(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	actual := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+	if subtle.ConstantTimeCompare(key, actual) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+// NeedsRehash returns true if the hash is not argon2id or
+// not hashed with the current parameters
+func NeedsRehash(hashedPassword string) bool {
+	p, _, _, err := decode(hashedPassword)
+	if err != nil {
+		return true
+	}
+
+	current := CurrentParams()
+	return p.Memory != current.Memory || p.Time != current.Time || p.Threads != current.Threads ||
+		p.SaltLength != current.SaltLength || p.KeyLength != current.KeyLength
+}
+
+// decode parses the hash like $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func decode(hashedPassword string) (p Argon2Params, salt, key []byte, err error) {
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
 }
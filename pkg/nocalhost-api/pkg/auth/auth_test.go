@@ -0,0 +1,63 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestArgon2id(t *testing.T) {
+	hash, err := Encrypt("123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Errorf("unexpected hash %s", hash)
+	}
+
+	if err := Compare(hash, "123456"); err != nil {
+		t.Errorf("password should match the hash: %v", err)
+	}
+
+	if err := Compare(hash, "654321"); err != ErrMismatchedPassword {
+		t.Errorf("password should not match the hash: %v", err)
+	}
+
+	if NeedsRehash(hash) {
+		t.Error("hash with the current params should not be rehashed")
+	}
+
+	viper.Set(ARGON2_TIME, 4)
+	defer viper.Set(ARGON2_TIME, 0)
+
+	if !NeedsRehash(hash) {
+		t.Error("hash with the old params should be rehashed")
+	}
+
+	if err := Compare(hash, "123456"); err != nil {
+		t.Errorf("hash with the old params should still be verified: %v", err)
+	}
+}
+
+func TestLegacyBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Compare(string(hash), "123456"); err != nil {
+		t.Errorf("bcrypt hash should be verified: %v", err)
+	}
+
+	if !NeedsRehash(string(hash)) {
+		t.Error("bcrypt hash should be rehashed")
+	}
+}
@@ -42,8 +42,11 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	_const "nocalhost/internal/nhctl/const"
 	"nocalhost/internal/nocalhost-api/global"
+	"nocalhost/pkg/nocalhost-api/pkg/agent"
+	"nocalhost/pkg/nocalhost-api/pkg/cloudauth"
 	"nocalhost/pkg/nocalhost-api/pkg/errno"
 	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
 )
 
 const (
@@ -69,6 +72,9 @@ type GoClient struct {
 	mapper              *restmapper.DeferredDiscoveryRESTMapper
 	restConfig          *rest.Config
 	Config              []byte
+
+	// the trace of kubernetes requests
+	trace *trace.Binding
 }
 
 type InitResult struct {
@@ -81,11 +87,22 @@ func NewAdminGoClient(kubeconfig []byte) (*GoClient, error) {
 	return NewAdminGoClientWithTimeout(kubeconfig, time.Second*5)
 }
 
+// NewAdminGoClientWithContext new client bound to the trace of ctx, the
+// kubernetes requests are sent with the header traceparent and logged as
+// the spans of the trace, including the ones to initial the client
+func NewAdminGoClientWithContext(ctx context.Context, kubeconfig []byte) (*GoClient, error) {
+	return newAdminGoClientWithTimeout(ctx, kubeconfig, time.Second*5)
+}
+
 func NewAdminGoClientWithTimeout(kubeconfig []byte, duration time.Duration) (*GoClient, error) {
+	return newAdminGoClientWithTimeout(context.TODO(), kubeconfig, duration)
+}
+
+func newAdminGoClientWithTimeout(ctx context.Context, kubeconfig []byte, duration time.Duration) (*GoClient, error) {
 	initCh := make(chan *InitResult)
 
 	go func() {
-		client, err := newAdminGoClientTimeUnreliable(kubeconfig)
+		client, err := newAdminGoClientTimeUnreliable(ctx, kubeconfig)
 		initCh <- &InitResult{
 			goClient: client,
 			err:      err,
@@ -103,11 +120,11 @@ func NewAdminGoClientWithTimeout(kubeconfig []byte, duration time.Duration) (*Go
 }
 
 // use this go client generator to avoid out-cluster/in-cluster network issues
-func newAdminGoClientTimeUnreliable(kubeconfig []byte) (*GoClient, error) {
+func newAdminGoClientTimeUnreliable(ctx context.Context, kubeconfig []byte) (*GoClient, error) {
 
 	// first try to access cluster normally
 
-	client, originErr := newGoClient(kubeconfig)
+	client, originErr := newGoClient(ctx, kubeconfig)
 	if originErr == nil && client != nil {
 		originErr = client.requireClusterAdminClient()
 
@@ -120,7 +137,7 @@ func newAdminGoClientTimeUnreliable(kubeconfig []byte) (*GoClient, error) {
 
 	// then try to access current cluster's kube api-server
 
-	client, err, newConfig := newGoClientUseCurrentClusterHost(kubeconfig)
+	client, err, newConfig := newGoClientUseCurrentClusterHost(ctx, kubeconfig)
 	if err == nil && client != nil {
 		err = client.requireClusterAdminClient()
 
@@ -150,13 +167,16 @@ func newAdminGoClientTimeUnreliable(kubeconfig []byte) (*GoClient, error) {
 }
 
 func NewGoClient(kubeconfig []byte) (*GoClient, error) {
-	return newGoClient(kubeconfig)
+	return newGoClient(context.TODO(), kubeconfig)
 }
-func newGoClient(kubeconfig []byte) (*GoClient, error) {
+func newGoClient(ctx context.Context, kubeconfig []byte) (*GoClient, error) {
 	c, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
 	if err != nil {
 		return nil, err
 	}
+	cloudauth.Configure(c)
+	agent.Configure(c)
+	binding := configureTrace(ctx, c)
 	clientSet, err := kubernetes.NewForConfig(c)
 	if err != nil {
 		return nil, err
@@ -171,12 +191,13 @@ func newGoClient(kubeconfig []byte) (*GoClient, error) {
 		client:        clientSet,
 		DynamicClient: dynamicClient,
 		restConfig:    c,
+		trace:         binding,
 	}
 	return client, nil
 }
 
 // try to replace the host to access kube-apiserver
-func newGoClientUseCurrentClusterHost(kubeconfig []byte) (*GoClient, error, []byte) {
+func newGoClientUseCurrentClusterHost(ctx context.Context, kubeconfig []byte) (*GoClient, error, []byte) {
 
 	// Step1. get raw config
 	clientConfig, err := clientcmd.NewClientConfigFromBytes(kubeconfig)
@@ -213,6 +234,8 @@ func newGoClientUseCurrentClusterHost(kubeconfig []byte) (*GoClient, error, []by
 	if err != nil {
 		return nil, err, nil
 	}
+	cloudauth.Configure(c)
+	binding := configureTrace(ctx, c)
 
 	clientSet, err := kubernetes.NewForConfig(c)
 	if err != nil {
@@ -228,6 +251,7 @@ func newGoClientUseCurrentClusterHost(kubeconfig []byte) (*GoClient, error, []by
 		client:        clientSet,
 		DynamicClient: dynamicClient,
 		restConfig:    c,
+		trace:         binding,
 	}
 	return client, nil, newConfig
 }
@@ -0,0 +1,29 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetMutatingWebhookConfiguration get the mutating webhook registered by nocalhost-dep
+func (c *GoClient) GetMutatingWebhookConfiguration(name string) (*admissionv1.MutatingWebhookConfiguration, error) {
+	resource, err := c.client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(
+		context.TODO(), name, metav1.GetOptions{},
+	)
+	return resource, errors.WithStack(err)
+}
+
+// GetEndpoints get the endpoints of service
+func (c *GoClient) GetEndpoints(namespace, name string) (*corev1.Endpoints, error) {
+	resource, err := c.client.CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	return resource, errors.WithStack(err)
+}
@@ -0,0 +1,69 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgo
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const metricsPath = "/apis/metrics.k8s.io/v1beta1"
+
+// NodeMetrics is the usage of node reported by metrics-server
+type NodeMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Usage             corev1.ResourceList `json:"usage"`
+}
+
+// PodMetrics is the usage of containers reported by metrics-server
+type PodMetrics struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Containers        []ContainerMetrics `json:"containers"`
+}
+
+type ContainerMetrics struct {
+	Name  string              `json:"name"`
+	Usage corev1.ResourceList `json:"usage"`
+}
+
+// ListNodeMetrics returns error if metrics-server is not installed
+func (c *GoClient) ListNodeMetrics() ([]NodeMetrics, error) {
+	var list struct {
+		Items []NodeMetrics `json:"items"`
+	}
+	if err := c.getMetrics(metricsPath+"/nodes", &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// ListPodMetrics lists the pods of all namespaces if namespace is empty
+func (c *GoClient) ListPodMetrics(namespace string) ([]PodMetrics, error) {
+	path := metricsPath + "/pods"
+	if namespace != "" {
+		path = metricsPath + "/namespaces/" + namespace + "/pods"
+	}
+
+	var list struct {
+		Items []PodMetrics `json:"items"`
+	}
+	if err := c.getMetrics(path, &list); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *GoClient) getMetrics(path string, v interface{}) error {
+	raw, err := c.client.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(context.TODO())
+	if err != nil {
+		return errors.Wrap(err, "Fail to get metrics, metrics-server may not be installed")
+	}
+	return errors.WithStack(json.Unmarshal(raw, v))
+}
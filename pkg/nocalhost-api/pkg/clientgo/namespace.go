@@ -16,3 +16,25 @@ func (c *GoClient) GetNamespace(namespace string) (*corev1.Namespace, error) {
 func (c *GoClient) GetClientSet() *kubernetes.Clientset {
 	return c.client
 }
+
+// AdoptNamespace merge the labels and annotations into the existing namespace
+func (c *GoClient) AdoptNamespace(namespace string, labels, annotations map[string]string) error {
+	ns, err := c.GetNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		ns.Labels[k] = v
+	}
+	if ns.Annotations == nil {
+		ns.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		ns.Annotations[k] = v
+	}
+	_, err = c.client.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+	return errors.WithStack(err)
+}
@@ -0,0 +1,133 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"nocalhost/internal/nocalhost-api/global"
+)
+
+// CreateNamespacedServiceAccount create a service account with its token secret
+// and bind the cluster role to it, the binding is only effective in the namespace.
+// Unlike CreateServiceAccount, they are not labeled for the watchers of nocalhost-dep
+func (c *GoClient) CreateNamespacedServiceAccount(name, namespace, clusterRole string) error {
+	labels := map[string]string{global.NocalhostCreateByLabel: global.NocalhostName}
+
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+	}
+	if _, err := c.client.CoreV1().ServiceAccounts(namespace).Create(
+		context.TODO(), sa, metav1.CreateOptions{},
+	); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if err := c.createServiceAccountToken(name, namespace); err != nil {
+		return err
+	}
+
+	roleBinding := &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRole,
+		},
+		Subjects: []rbacv1.Subject{
+			{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name},
+		},
+	}
+	_, err := c.client.RbacV1().RoleBindings(namespace).Create(context.TODO(), roleBinding, metav1.CreateOptions{})
+	return errors.WithStack(err)
+}
+
+// RotateServiceAccountToken recreate the token secret of service account,
+// the token controller will sign a new token and the old one is invalid at once
+func (c *GoClient) RotateServiceAccountToken(name, namespace string) error {
+	err := c.DeleteSecret(namespace, name+global.NocalhostSaTokenSuffix)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	// wait for the secret deleted, or the creation will be conflicted
+	if err := wait.Poll(200*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := c.client.CoreV1().Secrets(namespace).Get(
+			context.TODO(), name+global.NocalhostSaTokenSuffix, metav1.GetOptions{},
+		)
+		return k8serrors.IsNotFound(err), nil
+	}); err != nil {
+		return errors.Wrap(err, "wait for token secret deleted")
+	}
+	return c.createServiceAccountToken(name, namespace)
+}
+
+// WaitServiceAccountToken wait the token controller filling the token secret of service account
+func (c *GoClient) WaitServiceAccountToken(name, namespace string) (*corev1.Secret, error) {
+	var secret *corev1.Secret
+	err := wait.Poll(200*time.Millisecond, 10*time.Second, func() (bool, error) {
+		s, err := c.client.CoreV1().Secrets(namespace).Get(
+			context.TODO(), name+global.NocalhostSaTokenSuffix, metav1.GetOptions{},
+		)
+		if err != nil {
+			return false, nil
+		}
+
+		if len(s.Data[global.NocalhostDevServiceAccountTokenKey]) == 0 ||
+			len(s.Data[global.NocalhostDevServiceAccountSecretCaKey]) == 0 {
+			return false, nil
+		}
+		secret = s
+		return true, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "wait for service account token")
+	}
+	return secret, nil
+}
+
+// DeleteNamespacedServiceAccount delete the service account created by
+// CreateNamespacedServiceAccount, with its token secret and role binding
+func (c *GoClient) DeleteNamespacedServiceAccount(name, namespace string) error {
+	err := c.client.RbacV1().RoleBindings(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	err = c.DeleteSecret(namespace, name+global.NocalhostSaTokenSuffix)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+
+	err = c.client.CoreV1().ServiceAccounts(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (c *GoClient) createServiceAccountToken(name, namespace string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name + global.NocalhostSaTokenSuffix,
+			Labels: map[string]string{global.NocalhostCreateByLabel: global.NocalhostName},
+			Annotations: map[string]string{
+				corev1.ServiceAccountNameKey: name,
+			},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+	_, err := c.CreateSecret(namespace, secret)
+	return err
+}
@@ -0,0 +1,91 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clientgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+// configureTrace propagate the trace of requests to api server, the trace of
+// ctx is bound to the client and used if the request is not traced
+func configureTrace(ctx context.Context, c *rest.Config) *trace.Binding {
+	binding := &trace.Binding{}
+	binding.Bind(ctx)
+	c.WrapTransport = transport.Wrappers(
+		c.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+			return &traceTransport{base: rt, binding: binding}
+		},
+	)
+	return binding
+}
+
+// WithContext bind the trace of ctx to the client, for the client created
+// without context
+func (c *GoClient) WithContext(ctx context.Context) *GoClient {
+	if c.trace != nil {
+		c.trace.Bind(ctx)
+	}
+	return c
+}
+
+type traceTransport struct {
+	base    http.RoundTripper
+	binding *trace.Binding
+}
+
+// RoundTrip send the request as a child span of the trace, and log it with
+// the trace, the requests failed are logged as warnings. The span is exported
+// as the client span if tracing is enabled
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	parent := trace.FromContext(req.Context())
+	if parent == nil {
+		parent = t.binding.Get()
+	}
+	if parent == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	span := parent.Child()
+	req = req.Clone(req.Context())
+	req.Header.Set(trace.Header, span.String())
+
+	recorded := trace.Begin(span, parent.SpanID, "kubernetes "+req.Method, trace.KindClient).
+		Set("http.method", req.Method).
+		Set("http.url", req.URL.Path).
+		Set("net.peer.name", req.URL.Host)
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	logger := log.WithContext(trace.NewContext(req.Context(), span)).WithFields(
+		log.Fields{
+			"parent_id":  parent.SpanID,
+			"method":     req.Method,
+			"url":        req.URL.Path,
+			"latency_ms": time.Since(start).Milliseconds(),
+		},
+	)
+	switch {
+	case err != nil:
+		logger.Warnf("kubernetes request err: %v", err)
+		recorded.End(err)
+	case resp.StatusCode >= http.StatusBadRequest:
+		logger.WithFields(log.Fields{"status": resp.StatusCode}).Warn("kubernetes request failed")
+		recorded.Set("http.status_code", resp.StatusCode).End(errors.New(resp.Status))
+	default:
+		logger.WithFields(log.Fields{"status": resp.StatusCode}).Debug("kubernetes request")
+		recorded.Set("http.status_code", resp.StatusCode).End(nil)
+	}
+	return resp, err
+}
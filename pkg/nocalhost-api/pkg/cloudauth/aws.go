@@ -0,0 +1,237 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cloudauth
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	awsTokenPrefix     = "k8s-aws-v1."
+	awsClusterIdHeader = "x-k8s-aws-id"
+	awsDefaultRegion   = "us-east-1"
+
+	// presigned url is valid for 15 minutes despite X-Amz-Expires, refresh it earlier
+	awsTokenExpiry  = 14 * time.Minute
+	awsPresignValid = 60
+)
+
+type awsCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+type awsSource struct {
+	clusterId   string
+	region      string
+	credentials awsCredentials
+	now         func() time.Time
+}
+
+// awsTokenSource supports 'aws eks get-token' and 'aws-iam-authenticator token',
+// assuming role is not supported and left to the plugin
+func awsTokenSource(exec *clientcmdapi.ExecConfig) (oauth2.TokenSource, error) {
+	var clusterId string
+	switch {
+	case command(exec) == "aws" && hasArgs(exec, "eks", "get-token"):
+		clusterId = flag(exec, "--cluster-name")
+	case command(exec) == "aws-iam-authenticator" && hasArgs(exec, "token"):
+		clusterId = flag(exec, "--cluster-id", "-i")
+	default:
+		return nil, nil
+	}
+
+	if clusterId == "" {
+		return nil, errors.New("cluster name is required for EKS token")
+	}
+
+	if flag(exec, "--role-arn", "-r") != "" {
+		return nil, nil
+	}
+
+	credentials, err := loadAwsCredentials(exec)
+	if err != nil {
+		return nil, err
+	}
+
+	region := flag(exec, "--region")
+	if region == "" {
+		region = env(exec, "AWS_REGION", "AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = awsDefaultRegion
+	}
+
+	return &awsSource{clusterId: clusterId, region: region, credentials: *credentials, now: time.Now}, nil
+}
+
+// Token presign the sts GetCallerIdentity request like aws-iam-authenticator,
+// the api server of EKS calls it to identify the caller
+func (s *awsSource) Token() (*oauth2.Token, error) {
+	now := s.now().UTC()
+	presigned := presignGetCallerIdentity(s.clusterId, s.region, s.credentials, now)
+	return &oauth2.Token{
+		AccessToken: awsTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(presigned)),
+		TokenType:   "Bearer",
+		Expiry:      now.Add(awsTokenExpiry),
+	}, nil
+}
+
+func presignGetCallerIdentity(clusterId, region string, credentials awsCredentials, now time.Time) string {
+	host := fmt.Sprintf("sts.%s.amazonaws.com", region)
+	if strings.HasPrefix(region, "cn-") {
+		host += ".cn"
+	}
+
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/sts/aws4_request", date, region)
+	signedHeaders := "host;" + awsClusterIdHeader
+
+	query := url.Values{
+		"Action":              {"GetCallerIdentity"},
+		"Version":             {"2011-06-15"},
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {credentials.AccessKeyId + "/" + scope},
+		"X-Amz-Date":          {amzDate},
+		"X-Amz-Expires":       {fmt.Sprint(awsPresignValid)},
+		"X-Amz-SignedHeaders": {signedHeaders},
+	}
+	if credentials.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+	canonicalQuery := awsCanonicalQuery(query)
+
+	emptyPayload := sha256.Sum256(nil)
+	canonicalRequest := strings.Join(
+		[]string{
+			"GET", "/", canonicalQuery,
+			"host:" + host + "\n" + awsClusterIdHeader + ":" + clusterId + "\n",
+			signedHeaders, hex.EncodeToString(emptyPayload[:]),
+		}, "\n",
+	)
+
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join(
+		[]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(hashedRequest[:])}, "\n",
+	)
+
+	key := hmacSha256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, "sts")
+	key = hmacSha256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+
+	return fmt.Sprintf("https://%s/?%s&X-Amz-Signature=%s", host, canonicalQuery, signature)
+}
+
+// awsCanonicalQuery encode the query sorted by key, spaces are encoded as %20
+func awsCanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(
+			pairs, strings.ReplaceAll(url.QueryEscape(k), "+", "%20")+"="+
+				strings.ReplaceAll(url.QueryEscape(query.Get(k)), "+", "%20"),
+		)
+	}
+	return strings.Join(pairs, "&")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// loadAwsCredentials load the static credentials from the environment variables,
+// or from the profile of the shared credentials file
+func loadAwsCredentials(exec *clientcmdapi.ExecConfig) (*awsCredentials, error) {
+	if accessKey := env(exec, "AWS_ACCESS_KEY_ID"); accessKey != "" {
+		return &awsCredentials{
+			AccessKeyId:     accessKey,
+			SecretAccessKey: env(exec, "AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    env(exec, "AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	file := env(exec, "AWS_SHARED_CREDENTIALS_FILE")
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, errors.New("no AWS credentials found")
+		}
+		file = filepath.Join(home, ".aws", "credentials")
+	}
+
+	profile := flag(exec, "--profile")
+	if profile == "" {
+		profile = env(exec, "AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	values, err := readIniSection(file, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	if values["aws_access_key_id"] == "" {
+		return nil, errors.Errorf("no AWS credentials found in profile %s", profile)
+	}
+	return &awsCredentials{
+		AccessKeyId:     values["aws_access_key_id"],
+		SecretAccessKey: values["aws_secret_access_key"],
+		SessionToken:    values["aws_session_token"],
+	}, nil
+}
+
+func readIniSection(file, section string) (map[string]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "no AWS credentials found")
+	}
+	defer f.Close()
+
+	values := map[string]string{}
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			current = strings.TrimSpace(line[1 : len(line)-1])
+		case current == section:
+			if kv := strings.SplitN(line, "=", 2); len(kv) == 2 {
+				values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	return values, errors.WithStack(scanner.Err())
+}
@@ -0,0 +1,117 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cloudauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	azureAuthorityHost = "https://login.microsoftonline.com"
+	azureImdsEndpoint  = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// azureTokenSource supports 'kubelogin get-token' with service principal
+// and managed identity login, the interactive ones are left to the plugin
+func azureTokenSource(exec *clientcmdapi.ExecConfig) (oauth2.TokenSource, error) {
+	if command(exec) != "kubelogin" || !hasArgs(exec, "get-token") {
+		return nil, nil
+	}
+
+	serverId := flag(exec, "--server-id")
+	if serverId == "" {
+		return nil, errors.New("server id is required for AKS token")
+	}
+
+	clientId := flag(exec, "--client-id")
+	if clientId == "" {
+		clientId = env(exec, "AAD_SERVICE_PRINCIPAL_CLIENT_ID", "AZURE_CLIENT_ID")
+	}
+
+	switch flag(exec, "--login", "-l") {
+	case "spn":
+		tenantId := flag(exec, "--tenant-id", "-t")
+		if tenantId == "" {
+			tenantId = env(exec, "AZURE_TENANT_ID")
+		}
+
+		secret := flag(exec, "--client-secret")
+		if secret == "" {
+			secret = env(exec, "AAD_SERVICE_PRINCIPAL_CLIENT_SECRET", "AZURE_CLIENT_SECRET")
+		}
+
+		if tenantId == "" || clientId == "" || secret == "" {
+			return nil, errors.New("tenant id, client id and client secret are required for service principal login")
+		}
+
+		config := &clientcredentials.Config{
+			ClientID:     clientId,
+			ClientSecret: secret,
+			TokenURL:     fmt.Sprintf("%s/%s/oauth2/v2.0/token", azureAuthorityHost, tenantId),
+			Scopes:       []string{serverId + "/.default"},
+		}
+		return config.TokenSource(context.Background()), nil
+	case "msi":
+		return &azureMsiSource{resource: serverId, clientId: clientId, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// azureMsiSource acquires the token of the managed identity from the instance metadata service
+type azureMsiSource struct {
+	resource string
+	clientId string
+	client   *http.Client
+}
+
+func (s *azureMsiSource) Token() (*oauth2.Token, error) {
+	query := url.Values{"api-version": {"2018-02-01"}, "resource": {s.resource}}
+	if s.clientId != "" {
+		query.Set("client_id", s.clientId)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, azureImdsEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request managed identity token")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("request managed identity token, status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.Wrap(err, "decode managed identity token")
+	}
+
+	token := &oauth2.Token{AccessToken: result.AccessToken, TokenType: "Bearer"}
+	if expiresOn, err := strconv.ParseInt(result.ExpiresOn, 10, 64); err == nil {
+		token.Expiry = time.Unix(expiresOn, 0)
+	}
+	return token, nil
+}
@@ -0,0 +1,149 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cloudauth
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/transport"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+const (
+	CLUSTER_AUTH_NATIVE = "cluster_auth.native"
+)
+
+// provider acquires the token for the exec plugin natively,
+// returns nil if the plugin is not the one of the provider
+type provider func(exec *clientcmdapi.ExecConfig) (oauth2.TokenSource, error)
+
+var (
+	providers = []provider{awsTokenSource, gcpTokenSource, azureTokenSource}
+
+	// token sources are shared by the clients with the same exec config
+	// so the tokens can be reused until they expire
+	sources = sync.Map{}
+)
+
+// Enabled returns true if the tokens of cloud providers are acquired natively, default true
+func Enabled() bool {
+	return !viper.IsSet(CLUSTER_AUTH_NATIVE) || viper.GetBool(CLUSTER_AUTH_NATIVE)
+}
+
+// Configure replace the exec plugin of EKS, GKE and AKS with the native token acquisition,
+// the rest config is not changed if the plugin is unknown or no credential is found,
+// then client-go still try to execute the plugin
+func Configure(c *rest.Config) {
+	if c == nil || c.ExecProvider == nil || !Enabled() {
+		return
+	}
+
+	source, err := TokenSource(c.ExecProvider)
+	if err != nil {
+		log.Warnf("Failed to acquire token for exec plugin %s natively: %v", c.ExecProvider.Command, err)
+		return
+	}
+
+	if source == nil {
+		return
+	}
+
+	c.ExecProvider = nil
+	c.WrapTransport = transport.Wrappers(
+		c.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+			return &oauth2.Transport{Source: source, Base: rt}
+		},
+	)
+}
+
+// TokenSource returns the token source for the exec plugin, nil if the plugin is unknown
+func TokenSource(exec *clientcmdapi.ExecConfig) (oauth2.TokenSource, error) {
+	key := sourceKey(exec)
+	if source, ok := sources.Load(key); ok {
+		return source.(oauth2.TokenSource), nil
+	}
+
+	for _, p := range providers {
+		source, err := p(exec)
+		if err != nil {
+			return nil, err
+		}
+
+		if source != nil {
+			source = oauth2.ReuseTokenSource(nil, source)
+			sources.Store(key, source)
+			return source, nil
+		}
+	}
+	return nil, nil
+}
+
+func sourceKey(exec *clientcmdapi.ExecConfig) string {
+	parts := append([]string{exec.Command}, exec.Args...)
+	for _, env := range exec.Env {
+		parts = append(parts, env.Name+"="+env.Value)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// command returns the name of the plugin without path and extension
+func command(exec *clientcmdapi.ExecConfig) string {
+	return strings.TrimSuffix(filepath.Base(exec.Command), filepath.Ext(exec.Command))
+}
+
+// hasArgs returns true if the args contain all the words in order
+func hasArgs(exec *clientcmdapi.ExecConfig, words ...string) bool {
+	i := 0
+	for _, arg := range exec.Args {
+		if i < len(words) && arg == words[i] {
+			i++
+		}
+	}
+	return i == len(words)
+}
+
+// flag returns the value of the first present flag, both '--flag value' and '--flag=value' are supported
+func flag(exec *clientcmdapi.ExecConfig, names ...string) string {
+	for _, name := range names {
+		for i, arg := range exec.Args {
+			if arg == name && i+1 < len(exec.Args) {
+				return exec.Args[i+1]
+			}
+
+			if strings.HasPrefix(arg, name+"=") {
+				return strings.TrimPrefix(arg, name+"=")
+			}
+		}
+	}
+	return ""
+}
+
+// env returns the first present environment variable, those in exec config take precedence
+func env(exec *clientcmdapi.ExecConfig, names ...string) string {
+	for _, name := range names {
+		for _, e := range exec.Env {
+			if e.Name == name && e.Value != "" {
+				return e.Value
+			}
+		}
+	}
+
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cloudauth
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestFlag(t *testing.T) {
+	exec := &clientcmdapi.ExecConfig{
+		Command: "/usr/local/bin/aws",
+		Args:    []string{"--region", "eu-west-1", "eks", "get-token", "--cluster-name=demo"},
+	}
+
+	if command(exec) != "aws" || !hasArgs(exec, "eks", "get-token") || hasArgs(exec, "token") {
+		t.Errorf("command = %s", command(exec))
+	}
+
+	if flag(exec, "--region") != "eu-west-1" || flag(exec, "--cluster-name") != "demo" || flag(exec, "--profile") != "" {
+		t.Errorf("flags are not parsed")
+	}
+}
+
+func TestAwsToken(t *testing.T) {
+	exec := &clientcmdapi.ExecConfig{
+		Command: "aws",
+		Args:    []string{"eks", "get-token", "--cluster-name", "demo", "--region", "eu-west-1"},
+		Env: []clientcmdapi.ExecEnvVar{
+			{Name: "AWS_ACCESS_KEY_ID", Value: "AKIDEXAMPLE"},
+			{Name: "AWS_SECRET_ACCESS_KEY", Value: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"},
+		},
+	}
+
+	source, err := awsTokenSource(exec)
+	if err != nil || source == nil {
+		t.Fatalf("source = %v, err = %v", source, err)
+	}
+
+	now := time.Date(2021, 8, 1, 12, 0, 0, 0, time.UTC)
+	source.(*awsSource).now = func() time.Time { return now }
+
+	token, err := source.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(token.AccessToken, awsTokenPrefix) || !token.Expiry.Equal(now.Add(awsTokenExpiry)) {
+		t.Fatalf("token = %s, expiry = %v", token.AccessToken, token.Expiry)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token.AccessToken, awsTokenPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	presigned, err := url.Parse(string(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := presigned.Query()
+	if presigned.Host != "sts.eu-west-1.amazonaws.com" ||
+		query.Get("Action") != "GetCallerIdentity" ||
+		query.Get("X-Amz-Credential") != "AKIDEXAMPLE/20210801/eu-west-1/sts/aws4_request" ||
+		query.Get("X-Amz-SignedHeaders") != "host;x-k8s-aws-id" ||
+		len(query.Get("X-Amz-Signature")) != 64 {
+		t.Errorf("presigned url = %s", presigned)
+	}
+
+	again, _ := source.Token()
+	if again.AccessToken != token.AccessToken {
+		t.Errorf("signature is not deterministic")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	unknown := &rest.Config{ExecProvider: &clientcmdapi.ExecConfig{Command: "my-plugin"}}
+	Configure(unknown)
+	if unknown.ExecProvider == nil || unknown.WrapTransport != nil {
+		t.Errorf("unknown plugin should be kept")
+	}
+
+	eks := &rest.Config{
+		ExecProvider: &clientcmdapi.ExecConfig{
+			Command: "aws-iam-authenticator",
+			Args:    []string{"token", "-i", "demo"},
+			Env: []clientcmdapi.ExecEnvVar{
+				{Name: "AWS_ACCESS_KEY_ID", Value: "AKIDEXAMPLE"},
+				{Name: "AWS_SECRET_ACCESS_KEY", Value: "secret"},
+			},
+		},
+	}
+	Configure(eks)
+	if eks.ExecProvider != nil || eks.WrapTransport == nil {
+		t.Errorf("eks plugin should be replaced")
+	}
+
+	// interactive login of kubelogin can not be done natively
+	aks := &rest.Config{
+		ExecProvider: &clientcmdapi.ExecConfig{
+			Command: "kubelogin",
+			Args:    []string{"get-token", "--login", "devicecode", "--server-id", "6dae42f8"},
+		},
+	}
+	Configure(aks)
+	if aks.ExecProvider == nil {
+		t.Errorf("interactive login should be left to the plugin")
+	}
+}
@@ -0,0 +1,52 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cloudauth
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+var gcpScopes = []string{
+	"https://www.googleapis.com/auth/cloud-platform",
+	"https://www.googleapis.com/auth/userinfo.email",
+}
+
+// gcpTokenSource supports 'gke-gcloud-auth-plugin' and 'gcloud config config-helper',
+// the access token is acquired by the application default credentials
+func gcpTokenSource(exec *clientcmdapi.ExecConfig) (oauth2.TokenSource, error) {
+	switch {
+	case command(exec) == "gke-gcloud-auth-plugin":
+	case command(exec) == "gcloud" && hasArgs(exec, "config", "config-helper"):
+	default:
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	if file := env(exec, "GOOGLE_APPLICATION_CREDENTIALS"); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrap(err, "read google application credentials")
+		}
+
+		credentials, err := google.CredentialsFromJSON(ctx, data, gcpScopes...)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse google application credentials")
+		}
+		return credentials.TokenSource, nil
+	}
+
+	credentials, err := google.FindDefaultCredentials(ctx, gcpScopes...)
+	if err != nil {
+		return nil, errors.Wrap(err, "find google application default credentials")
+	}
+	return credentials.TokenSource, nil
+}
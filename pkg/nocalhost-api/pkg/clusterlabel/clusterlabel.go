@@ -0,0 +1,98 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clusterlabel
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	DEV_SPACE_POLICIES = "cluster_label.dev_space_policies"
+)
+
+// Who can create dev spaces on the clusters matched by a policy
+const (
+	// only the administrators
+	CreatorAdmin = "admin"
+	// the administrators and the creator of cluster
+	CreatorOwner = "owner"
+	// nobody, dev spaces can not be created on the clusters
+	CreatorNone = "none"
+)
+
+// Policy restricts who may create dev spaces on the clusters whose
+// labels match the selector, e.g. only admins on clusters with env=prod
+type Policy struct {
+	Selector string `mapstructure:"selector" json:"selector"`
+	Creators string `mapstructure:"creators" json:"creators"`
+}
+
+// Validate checks the keys and values as the labels of kubernetes
+func Validate(l map[string]string) error {
+	var msgs []string
+	for k, v := range l {
+		msgs = append(msgs, validation.IsQualifiedName(k)...)
+		for _, msg := range validation.IsValidLabelValue(v) {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", k, msg))
+		}
+	}
+	if len(msgs) > 0 {
+		return errors.New(strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// Match returns true if the labels match the selector, the syntax of
+// selector is the same as kubectl, such as env=prod,region in (eu,us)
+func Match(selector string, l map[string]string) (bool, error) {
+	s, err := labels.Parse(selector)
+	if err != nil {
+		return false, errors.Wrap(err, "Invalid label selector")
+	}
+	return s.Matches(labels.Set(l)), nil
+}
+
+// DevSpacePolicies returns the dev space policies configured
+func DevSpacePolicies() []Policy {
+	var policies []Policy
+	if err := viper.UnmarshalKey(DEV_SPACE_POLICIES, &policies); err != nil {
+		return nil
+	}
+	return policies
+}
+
+// AllowCreateDevSpace checks all the policies matching the labels of
+// cluster, creating is denied if any of them denies
+func AllowCreateDevSpace(policies []Policy, l map[string]string, isAdmin, isOwner bool) (bool, string) {
+	for _, p := range policies {
+		matched, err := Match(p.Selector, l)
+		if err != nil || !matched {
+			continue
+		}
+
+		switch p.Creators {
+		case CreatorAdmin:
+			if !isAdmin {
+				return false, fmt.Sprintf("only admins can create dev space on clusters with %s", p.Selector)
+			}
+		case CreatorOwner:
+			if !isAdmin && !isOwner {
+				return false, fmt.Sprintf(
+					"only admins and the cluster owner can create dev space on clusters with %s", p.Selector,
+				)
+			}
+		case CreatorNone:
+			return false, fmt.Sprintf("dev space can not be created on clusters with %s", p.Selector)
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,71 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package clusterlabel
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	if err := Validate(map[string]string{"env": "staging", "nocalhost.dev/region": "eu"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(map[string]string{"env prod": "staging"}); err == nil {
+		t.Fatal("invalid key should be rejected")
+	}
+	if err := Validate(map[string]string{"env": "a/b"}); err == nil {
+		t.Fatal("invalid value should be rejected")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	l := map[string]string{"env": "prod", "region": "eu"}
+	for selector, expect := range map[string]bool{
+		"env=prod":                   true,
+		"env=prod,region in (eu,us)": true,
+		"env!=prod":                  false,
+		"!region":                    false,
+		"tier":                       false,
+	} {
+		matched, err := Match(selector, l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if matched != expect {
+			t.Fatalf("selector %s expect %v but got %v", selector, expect, matched)
+		}
+	}
+
+	if _, err := Match("env=(", l); err == nil {
+		t.Fatal("invalid selector should be rejected")
+	}
+}
+
+func TestAllowCreateDevSpace(t *testing.T) {
+	policies := []Policy{
+		{Selector: "env=prod", Creators: CreatorAdmin},
+		{Selector: "env=staging", Creators: CreatorOwner},
+		{Selector: "frozen=true", Creators: CreatorNone},
+	}
+
+	cases := []struct {
+		labels  map[string]string
+		admin   bool
+		owner   bool
+		allowed bool
+	}{
+		{map[string]string{"env": "prod"}, false, true, false},
+		{map[string]string{"env": "prod"}, true, false, true},
+		{map[string]string{"env": "staging"}, false, true, true},
+		{map[string]string{"env": "staging"}, false, false, false},
+		{map[string]string{"env": "dev"}, false, false, true},
+		{map[string]string{"env": "dev", "frozen": "true"}, true, true, false},
+		{nil, false, false, true},
+	}
+	for _, c := range cases {
+		if allowed, _ := AllowCreateDevSpace(policies, c.labels, c.admin, c.owner); allowed != c.allowed {
+			t.Fatalf("labels %v admin %v owner %v expect %v", c.labels, c.admin, c.owner, c.allowed)
+		}
+	}
+}
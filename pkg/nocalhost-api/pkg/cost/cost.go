@@ -0,0 +1,190 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cost
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	COST_SAMPLE_INTERVAL = "cost.sample_interval"
+	COST_CPU_CORE_HOUR   = "cost.cpu_core_hour"
+	COST_MEMORY_GIB_HOUR = "cost.memory_gib_hour"
+	COST_CURRENCY        = "cost.currency"
+	COST_RETENTION       = "cost.retention_months"
+
+	defaultSampleInterval = time.Hour
+	defaultCurrency       = "USD"
+	defaultRetention      = 13
+
+	monthLayout = "2006-01"
+)
+
+// Groups of the reports
+const (
+	GroupByUser     = "user"
+	GroupByTeam     = "team"
+	GroupByCluster  = "cluster"
+	GroupByDevSpace = "dev_space"
+)
+
+// Prices per core hour and GiB hour of the resources requested
+type Prices struct {
+	CpuCoreHour   float64 `json:"cpu_core_hour"`
+	MemoryGibHour float64 `json:"memory_gib_hour"`
+	Currency      string  `json:"currency"`
+}
+
+// Owner the user, team, cluster or dev space the cost is attributed to
+type Owner struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+// Item the resources of a dev space, attributed to the owners evenly, e.g.
+// the teams of the user, so that the sum of the report keeps the same
+type Item struct {
+	Owners         []Owner
+	CpuCoreHours   float64
+	MemoryGibHours float64
+}
+
+// Line of the report of an owner
+type Line struct {
+	Owner
+	CpuCoreHours   float64 `json:"cpu_core_hours"`
+	MemoryGibHours float64 `json:"memory_gib_hours"`
+	CpuCost        float64 `json:"cpu_cost"`
+	MemoryCost     float64 `json:"memory_cost"`
+	Cost           float64 `json:"cost"`
+}
+
+// Report of the cost in a month, the most expensive first
+type Report struct {
+	Month   string `json:"month"`
+	GroupBy string `json:"group_by"`
+	Prices  Prices `json:"prices"`
+	Lines   []Line `json:"lines"`
+	Total   Line   `json:"total"`
+}
+
+// SampleInterval returns the interval of sampling the resources requested
+func SampleInterval() time.Duration {
+	if d := viper.GetDuration(COST_SAMPLE_INTERVAL); d > 0 {
+		return d
+	}
+	return defaultSampleInterval
+}
+
+// Retention returns how many months the samples are kept
+func Retention() int {
+	if n := viper.GetInt(COST_RETENTION); n > 0 {
+		return n
+	}
+	return defaultRetention
+}
+
+// CurrentPrices the prices configured, free by default
+func CurrentPrices() Prices {
+	p := Prices{
+		CpuCoreHour:   viper.GetFloat64(COST_CPU_CORE_HOUR),
+		MemoryGibHour: viper.GetFloat64(COST_MEMORY_GIB_HOUR),
+		Currency:      viper.GetString(COST_CURRENCY),
+	}
+	if p.Currency == "" {
+		p.Currency = defaultCurrency
+	}
+	return p
+}
+
+// ValidGroup whether the reports can be grouped by it
+func ValidGroup(groupBy string) bool {
+	switch groupBy {
+	case GroupByUser, GroupByTeam, GroupByCluster, GroupByDevSpace:
+		return true
+	}
+	return false
+}
+
+// Month the period of month such as 2021-09 in loc, the current month if empty
+func Month(s string, now time.Time, loc *time.Location) (string, time.Time, time.Time, error) {
+	var from time.Time
+	if s == "" {
+		now = now.In(loc)
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	} else {
+		t, err := time.ParseInLocation(monthLayout, s, loc)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, errors.Errorf("invalid month %s, expect such as 2021-09", s)
+		}
+		from = t
+	}
+	return from.Format(monthLayout), from, from.AddDate(0, 1, 0), nil
+}
+
+// Build the report of month by the items, the prices are the current ones
+func Build(month, groupBy string, items []Item, prices Prices) *Report {
+	lines := map[uint64]*Line{}
+	for _, item := range items {
+		if len(item.Owners) == 0 {
+			continue
+		}
+		share := 1 / float64(len(item.Owners))
+		for _, owner := range item.Owners {
+			l, ok := lines[owner.ID]
+			if !ok {
+				l = &Line{Owner: owner}
+				lines[owner.ID] = l
+			}
+			l.CpuCoreHours += item.CpuCoreHours * share
+			l.MemoryGibHours += item.MemoryGibHours * share
+		}
+	}
+
+	report := &Report{Month: month, GroupBy: groupBy, Prices: prices, Lines: make([]Line, 0, len(lines))}
+	for _, l := range lines {
+		l.CpuCost = l.CpuCoreHours * prices.CpuCoreHour
+		l.MemoryCost = l.MemoryGibHours * prices.MemoryGibHour
+		l.Cost = l.CpuCost + l.MemoryCost
+
+		report.Total.CpuCoreHours += l.CpuCoreHours
+		report.Total.MemoryGibHours += l.MemoryGibHours
+		report.Total.CpuCost += l.CpuCost
+		report.Total.MemoryCost += l.MemoryCost
+		report.Total.Cost += l.Cost
+		report.Lines = append(report.Lines, l.round())
+	}
+	report.Total.Name = "total"
+	report.Total = report.Total.round()
+
+	sort.Slice(
+		report.Lines, func(i, j int) bool {
+			if report.Lines[i].Cost != report.Lines[j].Cost {
+				return report.Lines[i].Cost > report.Lines[j].Cost
+			}
+			return report.Lines[i].ID < report.Lines[j].ID
+		},
+	)
+	return report
+}
+
+func (l Line) round() Line {
+	l.CpuCoreHours = round(l.CpuCoreHours)
+	l.MemoryGibHours = round(l.MemoryGibHours)
+	l.CpuCost = round(l.CpuCost)
+	l.MemoryCost = round(l.MemoryCost)
+	l.Cost = round(l.Cost)
+	return l
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,52 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package cost
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonth(t *testing.T) {
+	month, from, to, err := Month("2021-09", time.Now(), time.UTC)
+	if err != nil || month != "2021-09" ||
+		!from.Equal(time.Date(2021, 9, 1, 0, 0, 0, 0, time.UTC)) ||
+		!to.Equal(time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected period %s %v %v %v", month, from, to, err)
+	}
+
+	if month, _, _, _ := Month("", time.Date(2021, 12, 31, 8, 0, 0, 0, time.UTC), time.UTC); month != "2021-12" {
+		t.Errorf("the current month should be 2021-12, but %s", month)
+	}
+	if _, _, _, err := Month("2021-9-1", time.Now(), time.UTC); err == nil {
+		t.Error("2021-9-1 should be invalid")
+	}
+}
+
+func TestBuild(t *testing.T) {
+	a, b := Owner{ID: 1, Name: "a"}, Owner{ID: 2, Name: "b"}
+	report := Build(
+		"2021-09", GroupByTeam, []Item{
+			{Owners: []Owner{a}, CpuCoreHours: 10, MemoryGibHours: 20},
+			// shared by the teams evenly
+			{Owners: []Owner{a, b}, CpuCoreHours: 4, MemoryGibHours: 8},
+			{CpuCoreHours: 100},
+		}, Prices{CpuCoreHour: 0.5, MemoryGibHour: 0.1, Currency: "USD"},
+	)
+
+	if len(report.Lines) != 2 || report.Lines[0].ID != 1 || report.Lines[1].ID != 2 {
+		t.Fatalf("unexpected lines %+v", report.Lines)
+	}
+	if l := report.Lines[0]; l.CpuCoreHours != 12 || l.MemoryGibHours != 24 || l.Cost != 8.4 {
+		t.Errorf("unexpected line of a %+v", l)
+	}
+	if l := report.Lines[1]; l.CpuCoreHours != 2 || l.CpuCost != 1 || l.MemoryCost != 0.4 {
+		t.Errorf("unexpected line of b %+v", l)
+	}
+	if report.Total.CpuCoreHours != 14 || report.Total.Cost != 9.8 {
+		t.Errorf("unexpected total %+v", report.Total)
+	}
+}
@@ -0,0 +1,320 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	ENCRYPTION_PRIMARY = "encryption.primary"
+	ENCRYPTION_KEYS    = "encryption.keys"
+
+	// prefix of the values encrypted, followed by the key id, the data key
+	// wrapped by the key and the value sealed by the data key
+	prefix = "nhenc:v1:"
+	// the data keys unwrapped are cached at most
+	maxCachedKeys = 1024
+)
+
+var (
+	// ErrDisabled the value is encrypted but no key is configured
+	ErrDisabled = errors.New("the value is encrypted but encryption is not configured")
+	// ErrMalformed the value is not encrypted by nocalhost
+	ErrMalformed = errors.New("malformed value encrypted")
+)
+
+// KeyProvider the key encrypting the data keys, e.g. a key file or a KMS,
+// the data keys are wrapped once in a process and cached after unwrapped
+type KeyProvider interface {
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// Keyring encrypts the values by the envelope of the primary key, and
+// decrypts the ones of the other keys, which are reported stale to rotate
+type Keyring struct {
+	primary   string
+	providers map[string]KeyProvider
+
+	lock    sync.Mutex
+	dataKey []byte
+	wrapped string
+	cache   map[string][]byte
+}
+
+// NewKeyring the keyring encrypting by the key of primary
+func NewKeyring(primary string, providers map[string]KeyProvider) (*Keyring, error) {
+	if _, ok := providers[primary]; !ok {
+		return nil, errors.Errorf("primary key %s of encryption is not configured", primary)
+	}
+	for id := range providers {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, errors.Errorf("invalid key id '%s' of encryption", id)
+		}
+	}
+	return &Keyring{primary: primary, providers: providers, cache: map[string][]byte{}}, nil
+}
+
+// Encrypt the value by the primary key, the empty values and the ones
+// encrypted by the primary key already are returned as is
+func (k *Keyring) Encrypt(plain string) (string, error) {
+	if plain == "" {
+		return plain, nil
+	}
+	if id, _, _, err := parse(plain); err == nil {
+		if id == k.primary {
+			return plain, nil
+		}
+		if plain, _, err = k.Decrypt(plain); err != nil {
+			return "", err
+		}
+	}
+
+	dataKey, wrapped, err := k.primaryDataKey()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, []byte(plain))
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.primary + ":" + wrapped + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt the value encrypted, the values not encrypted are returned as is,
+// stale reports whether it should be encrypted again by the primary key
+func (k *Keyring) Decrypt(value string) (plain string, stale bool, err error) {
+	if !IsEncrypted(value) {
+		return value, value != "", nil
+	}
+	id, wrapped, sealed, err := parse(value)
+	if err != nil {
+		return "", false, err
+	}
+	dataKey, err := k.unwrap(id, wrapped)
+	if err != nil {
+		return "", false, err
+	}
+	opened, err := open(dataKey, sealed)
+	if err != nil {
+		return "", false, err
+	}
+	return string(opened), id != k.primary, nil
+}
+
+// primaryDataKey the data key of the process and its envelope by the primary
+func (k *Keyring) primaryDataKey() ([]byte, string, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.dataKey != nil {
+		return k.dataKey, k.wrapped, nil
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, "", errors.Wrap(err, "generate data key")
+	}
+	wrapped, err := k.providers[k.primary].Wrap(dataKey)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "wrap data key by %s", k.primary)
+	}
+	k.dataKey, k.wrapped = dataKey, base64.StdEncoding.EncodeToString(wrapped)
+	return k.dataKey, k.wrapped, nil
+}
+
+func (k *Keyring) unwrap(id, wrapped string) ([]byte, error) {
+	provider, ok := k.providers[id]
+	if !ok {
+		return nil, errors.Errorf("key %s of encryption is not configured", id)
+	}
+	cacheKey := id + ":" + wrapped
+
+	k.lock.Lock()
+	dataKey, ok := k.cache[cacheKey]
+	k.lock.Unlock()
+	if ok {
+		return dataKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if dataKey, err = provider.Unwrap(raw); err != nil {
+		return nil, errors.Wrapf(err, "unwrap data key by %s", id)
+	}
+
+	k.lock.Lock()
+	if len(k.cache) >= maxCachedKeys {
+		k.cache = map[string][]byte{}
+	}
+	k.cache[cacheKey] = dataKey
+	k.lock.Unlock()
+	return dataKey, nil
+}
+
+// IsEncrypted whether the value is encrypted by nocalhost
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+func parse(value string) (id, wrapped string, sealed []byte, err error) {
+	if !IsEncrypted(value) {
+		return "", "", nil, ErrMalformed
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", "", nil, ErrMalformed
+	}
+	if sealed, err = base64.StdEncoding.DecodeString(parts[2]); err != nil {
+		return "", "", nil, ErrMalformed
+	}
+	return parts[0], parts[1], sealed, nil
+}
+
+// seal by aes-256-gcm, the nonce is prepended
+func seal(key, plain []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "generate nonce")
+	}
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	size := aead.NonceSize()
+	plain, err := aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypt")
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "new cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyFile the key of 32 bytes in a file, encoded in base64
+type keyFile struct {
+	key []byte
+}
+
+// NewKeyFile the key provider of the key file, generated by e.g.
+// head -c 32 /dev/urandom | base64
+func NewKeyFile(path string) (KeyProvider, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read key file")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(key) != 32 {
+		return nil, errors.Errorf("key file %s is not 32 bytes encoded in base64", path)
+	}
+	return &keyFile{key: key}, nil
+}
+
+func (f *keyFile) Wrap(dataKey []byte) ([]byte, error) {
+	return seal(f.key, dataKey)
+}
+
+func (f *keyFile) Unwrap(wrapped []byte) ([]byte, error) {
+	return open(f.key, wrapped)
+}
+
+// key configured by encryption.keys
+type key struct {
+	ID   string `mapstructure:"id"`
+	File string `mapstructure:"file"`
+}
+
+var keyring *Keyring
+
+// Init the keyring of encryption.keys, the secrets are stored in plain text
+// if none is configured, encryption.primary is the first key by default
+func Init() error {
+	var keys []key
+	if err := viper.UnmarshalKey(ENCRYPTION_KEYS, &keys); err != nil {
+		return errors.Wrap(err, "parse encryption.keys")
+	}
+	if len(keys) == 0 {
+		keyring = nil
+		return nil
+	}
+
+	providers := map[string]KeyProvider{}
+	for _, k := range keys {
+		provider, err := NewKeyFile(k.File)
+		if err != nil {
+			return errors.Wrapf(err, "key %s of encryption", k.ID)
+		}
+		providers[k.ID] = provider
+	}
+	primary := viper.GetString(ENCRYPTION_PRIMARY)
+	if primary == "" {
+		primary = keys[0].ID
+	}
+	k, err := NewKeyring(primary, providers)
+	if err != nil {
+		return err
+	}
+	keyring = k
+	return nil
+}
+
+// SetKeyring replace the keyring, nil disables the encryption
+func SetKeyring(k *Keyring) {
+	keyring = k
+}
+
+// Enabled whether the secrets are encrypted
+func Enabled() bool {
+	return keyring != nil
+}
+
+// Encrypt the value by the keyring, returned as is if encryption is disabled
+func Encrypt(plain string) (string, error) {
+	if keyring == nil {
+		return plain, nil
+	}
+	return keyring.Encrypt(plain)
+}
+
+// Decrypt the value by the keyring, the values not encrypted are returned as
+// is, stale reports whether it should be encrypted again
+func Decrypt(value string) (plain string, stale bool, err error) {
+	if keyring == nil {
+		if IsEncrypted(value) {
+			return "", false, ErrDisabled
+		}
+		return value, false, nil
+	}
+	return keyring.Decrypt(value)
+}
@@ -0,0 +1,77 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKeyFile(t *testing.T, name string) KeyProvider {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestKeyring(t *testing.T) {
+	old, current := testKeyFile(t, "old"), testKeyFile(t, "current")
+	oldRing, _ := NewKeyring("old", map[string]KeyProvider{"old": old})
+	ring, err := NewKeyring("current", map[string]KeyProvider{"old": old, "current": current})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := ring.Encrypt("apiVersion: v1")
+	if err != nil || !strings.HasPrefix(encrypted, prefix+"current:") {
+		t.Fatalf("unexpected value encrypted %s %v", encrypted, err)
+	}
+	if again, _ := ring.Encrypt(encrypted); again != encrypted {
+		t.Fatal("expect the value encrypted by the primary returned as is")
+	}
+	if plain, stale, err := ring.Decrypt(encrypted); err != nil || stale || plain != "apiVersion: v1" {
+		t.Fatalf("unexpected value decrypted %s %v %v", plain, stale, err)
+	}
+
+	// rotated, the values of the old key are decrypted and stale
+	byOld, _ := oldRing.Encrypt("apiVersion: v1")
+	if plain, stale, err := ring.Decrypt(byOld); err != nil || !stale || plain != "apiVersion: v1" {
+		t.Fatalf("expect the value of old key stale, got %s %v %v", plain, stale, err)
+	}
+	if rotated, _ := ring.Encrypt(byOld); !strings.HasPrefix(rotated, prefix+"current:") {
+		t.Fatalf("expect encrypted by the primary again, got %s", rotated)
+	}
+
+	if plain, stale, _ := ring.Decrypt("plain"); plain != "plain" || !stale {
+		t.Fatal("expect the plain text returned as is and stale")
+	}
+	if _, _, err := oldRing.Decrypt(encrypted); err == nil {
+		t.Fatal("expect the value of unknown key refused")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	SetKeyring(nil)
+	if v, _ := Encrypt("plain"); v != "plain" {
+		t.Fatal("expect plain text stored if disabled")
+	}
+	ring, _ := NewKeyring("k", map[string]KeyProvider{"k": testKeyFile(t, "k")})
+	encrypted, _ := ring.Encrypt("secret")
+	if _, _, err := Decrypt(encrypted); err != ErrDisabled {
+		t.Fatalf("expect ErrDisabled, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package encryption
+
+import (
+	"reflect"
+
+	"github.com/jinzhu/gorm"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// the tag of the fields encrypted at rest, e.g. `encrypted:"true"`
+const tag = "encrypted"
+
+// RegisterGormCallbacks encrypt the fields tagged before they are written,
+// and decrypt the values encrypted after they are read into any struct, so
+// that the columns joined or aliased are decrypted too. The fields tagged
+// which are read in plain text or encrypted by a previous key are encrypted
+// again in place
+func RegisterGormCallbacks(db *gorm.DB) {
+	callback := db.Callback()
+	callback.Create().Before("gorm:create").Register("encryption:before_create", encryptFields)
+	callback.Create().After("gorm:create").Register("encryption:after_create", decryptFields)
+	callback.Update().After("gorm:assign_updating_attributes").Register("encryption:before_update", encryptUpdate)
+	callback.Update().After("gorm:update").Register("encryption:after_update", decryptFields)
+	callback.Query().After("gorm:query").Register("encryption:after_query", decryptQueried)
+}
+
+// encryptFields encrypt the fields tagged of the value in place
+func encryptFields(scope *gorm.Scope) {
+	if !Enabled() || scope.HasError() {
+		return
+	}
+	eachStruct(
+		scope, func(s *gorm.Scope) {
+			for _, field := range s.Fields() {
+				if !isTagged(field) {
+					continue
+				}
+				encrypted, err := Encrypt(field.Field.String())
+				if err != nil {
+					_ = scope.Err(err)
+					return
+				}
+				field.Field.SetString(encrypted)
+			}
+		},
+	)
+}
+
+// encryptUpdate encrypt the columns tagged of the attributes updated, and the
+// fields of the value saved
+func encryptUpdate(scope *gorm.Scope) {
+	if !Enabled() || scope.HasError() {
+		return
+	}
+	if attrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+		updates, _ := attrs.(map[string]interface{})
+		for column, value := range updates {
+			plain, ok := value.(string)
+			if !ok {
+				continue
+			}
+			if field, ok := scope.FieldByName(column); !ok || !isTagged(field) {
+				continue
+			}
+			encrypted, err := Encrypt(plain)
+			if err != nil {
+				_ = scope.Err(err)
+				return
+			}
+			updates[column] = encrypted
+		}
+		return
+	}
+	encryptFields(scope)
+}
+
+// decryptFields decrypt the fields of the value written in place, so that
+// the callers keep the plain text
+func decryptFields(scope *gorm.Scope) {
+	eachStruct(
+		scope, func(s *gorm.Scope) {
+			for _, field := range s.Fields() {
+				if isString(field) && IsEncrypted(field.Field.String()) {
+					if plain, _, err := Decrypt(field.Field.String()); err == nil {
+						field.Field.SetString(plain)
+					}
+				}
+			}
+		},
+	)
+}
+
+// decryptQueried decrypt the values read, and encrypt the stale fields tagged
+func decryptQueried(scope *gorm.Scope) {
+	if scope.HasError() {
+		return
+	}
+
+	// Scan reads into the destination instead of the value of the scope, it is
+	// not the model of a table, so the values are decrypted only
+	if dest, ok := scope.Get("gorm:query_destination"); ok {
+		eachStruct(
+			scope.New(dest), func(s *gorm.Scope) {
+				decryptStrings(s)
+			},
+		)
+		return
+	}
+
+	eachStruct(
+		scope, func(s *gorm.Scope) {
+			if stale := decryptStrings(s); len(stale) > 0 && Enabled() && !s.PrimaryKeyZero() {
+				reencrypt(scope, s, stale)
+			}
+		},
+	)
+}
+
+// decryptStrings decrypt the string fields of the struct in place, returns the
+// plain text of the fields tagged which are stale
+func decryptStrings(s *gorm.Scope) map[string]interface{} {
+	stale := map[string]interface{}{}
+	for _, field := range s.Fields() {
+		if !isString(field) {
+			continue
+		}
+		plain, isStale, err := Decrypt(field.Field.String())
+		if err != nil {
+			log.Errorf("decrypt %s of %s err: %v", field.DBName, s.TableName(), err)
+			continue
+		}
+		field.Field.SetString(plain)
+		if isStale && isTagged(field) {
+			stale[field.DBName] = plain
+		}
+	}
+	return stale
+}
+
+// reencrypt the columns of row by the primary key, the failure is retried
+// by the next read
+func reencrypt(scope, row *gorm.Scope, columns map[string]interface{}) {
+	for column, value := range columns {
+		encrypted, err := Encrypt(value.(string))
+		if err != nil {
+			log.Warnf("encrypt %s of %s err: %v", column, row.TableName(), err)
+			return
+		}
+		columns[column] = encrypted
+	}
+	if err := scope.NewDB().Table(row.TableName()).
+		Where(row.PrimaryKey()+" = ?", row.PrimaryKeyValue()).
+		UpdateColumns(columns).Error; err != nil {
+		log.Warnf("encrypt the rows of %s again err: %v", row.TableName(), err)
+	}
+}
+
+// eachStruct call fn with the scope of each struct of the value, which is a
+// struct or a slice of them
+func eachStruct(scope *gorm.Scope, fn func(s *gorm.Scope)) {
+	value := scope.IndirectValue()
+	switch value.Kind() {
+	case reflect.Struct:
+		fn(scope)
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			elem := value.Index(i)
+			for elem.Kind() == reflect.Ptr {
+				if elem.IsNil() {
+					break
+				}
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct && elem.CanAddr() {
+				fn(scope.New(elem.Addr().Interface()))
+			}
+		}
+	}
+}
+
+func isString(field *gorm.Field) bool {
+	return field.Field.IsValid() && field.Field.Kind() == reflect.String && field.Field.CanSet()
+}
+
+func isTagged(field *gorm.Field) bool {
+	return isString(field) && field.Tag.Get(tag) == "true"
+}
@@ -0,0 +1,124 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package encryption
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+)
+
+// rowDriver a database of one row, the row is the args of the last insert,
+// and every query returns it as the columns of testRowColumns
+type rowDriver struct {
+	row []driver.Value
+}
+
+var testRowColumns = []string{"name", "kubeconfig"}
+
+func (d *rowDriver) Open(string) (driver.Conn, error) { return &rowConn{d}, nil }
+
+type rowConn struct{ d *rowDriver }
+
+func (c *rowConn) Prepare(query string) (driver.Stmt, error) { return &rowStmt{c.d, query}, nil }
+func (c *rowConn) Close() error                              { return nil }
+func (c *rowConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *rowConn) Commit() error                             { return nil }
+func (c *rowConn) Rollback() error                           { return nil }
+
+type rowStmt struct {
+	d     *rowDriver
+	query string
+}
+
+func (s *rowStmt) Close() error  { return nil }
+func (s *rowStmt) NumInput() int { return -1 }
+
+func (s *rowStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "INSERT") {
+		s.d.row = args
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *rowStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &rowRows{row: s.d.row}, nil
+}
+
+type rowRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *rowRows) Columns() []string { return testRowColumns }
+func (r *rowRows) Close() error      { return nil }
+
+func (r *rowRows) Next(dest []driver.Value) error {
+	if r.done || r.row == nil {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+type testCluster struct {
+	Name       string `gorm:"column:name"`
+	KubeConfig string `gorm:"column:kubeconfig" encrypted:"true"`
+}
+
+func (testCluster) TableName() string {
+	return "clusters"
+}
+
+func TestRawScanDecrypted(t *testing.T) {
+	ring, _ := NewKeyring("k", map[string]KeyProvider{"k": testKeyFile(t, "k")})
+	SetKeyring(ring)
+	defer SetKeyring(nil)
+
+	d := &rowDriver{}
+	sql.Register("encryption_row", d)
+	sqlDB, err := sql.Open("encryption_row", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open("encryption_row", sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterGormCallbacks(db)
+
+	if err := db.Create(&testCluster{Name: "c1", KubeConfig: "apiVersion: v1"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := d.row[1].(string); !IsEncrypted(stored) {
+		t.Fatalf("expect the kubeconfig encrypted at rest, got %v", d.row[1])
+	}
+
+	// the columns selected by the joins are read into the structs not the model
+	var list []struct {
+		Name                   string `gorm:"column:name"`
+		AdminClusterKubeConfig string `gorm:"column:kubeconfig"`
+	}
+	if err := db.Raw("SELECT c.name, c.kubeconfig FROM clusters c").Scan(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].AdminClusterKubeConfig != "apiVersion: v1" {
+		t.Fatalf("expect the kubeconfig scanned decrypted, got %+v", list)
+	}
+
+	var one testCluster
+	if err := db.Raw("SELECT name, kubeconfig FROM clusters").Scan(&one).Error; err != nil {
+		t.Fatal(err)
+	}
+	if one.KubeConfig != "apiVersion: v1" {
+		t.Fatalf("expect the kubeconfig scanned decrypted, got %s", one.KubeConfig)
+	}
+}
@@ -22,6 +22,7 @@ var (
 	RouterNotFound             = &Errno{Code: 10005, Message: "router not found"}
 	ErrLoginRequired           = &Errno{Code: 10006, Message: "log in required"}
 	InternalServerTimeoutError = &Errno{Code: 10007, Message: "Internal server timeout"}
+	ErrTooManyRequests         = &Errno{Code: 10008, Message: "Too many requests, please try again later"}
 
 	// user errors for user module request
 	ErrUserNotFound = &Errno{Code: 20102, Message: "The user does not found."}
@@ -40,6 +41,28 @@ var (
 	ErrUserLoginWebNotAllow       = &Errno{Code: 20118, Message: "Normal users are not allowed login web interface"}
 	RefreshTokenInvalidOrNotMatch = &Errno{Code: 20119, Message: "Refresh token is invalid or token not matched"}
 	LDAPBindFail                  = &Errno{Code: 20111, Message: "Fail to login into LDAP"}
+	ErrOidcNotEnabled             = &Errno{Code: 20120, Message: "OIDC login is not enabled"}
+	ErrOidcLogin                  = &Errno{Code: 20121, Message: "Fail to login with OIDC provider"}
+	ErrAccessTokenCreate          = &Errno{Code: 20122, Message: "Failed to create access token"}
+	ErrAccessTokenRevoke          = &Errno{Code: 20123, Message: "Failed to revoke access token"}
+	ErrAccessTokenScope           = &Errno{Code: 20124, Message: "Access token scope is not allowed to do this"}
+	ErrTwoFactorRequired          = &Errno{Code: 20125, Message: "Two-factor authentication code required"}
+	ErrTwoFactorInvalid           = &Errno{Code: 20126, Message: "Two-factor authentication code is incorrect"}
+	ErrTwoFactorEnroll            = &Errno{Code: 20127, Message: "Failed to setup two-factor authentication"}
+	ErrPasswordResetMail          = &Errno{Code: 20129, Message: "Failed to send password reset mail"}
+	ErrPasswordResetToken         = &Errno{Code: 20130, Message: "Password reset link is invalid or expired"}
+	ErrLoginLocked                = &Errno{Code: 20131, Message: "Too many login failures, please try again later"}
+	ErrPasswordPolicy             = &Errno{Code: 20132, Message: "Password does not meet the password policy"}
+	ErrOauthNotEnabled            = &Errno{Code: 20133, Message: "The OAuth login provider is not enabled"}
+	ErrOauthLogin                 = &Errno{Code: 20134, Message: "Fail to login with the OAuth provider"}
+	ErrOauthOrgNotAllowed         = &Errno{Code: 20135, Message: "Only members of the allowed organization can login"}
+	ErrImpersonateUser            = &Errno{Code: 20136, Message: "The user can not be impersonated"}
+	ErrImpersonationForbidden     = &Errno{Code: 20137, Message: "The operation is not allowed while impersonating"}
+	ErrSessionNotFound            = &Errno{Code: 20138, Message: "The session not found"}
+	ErrLoginState                 = &Errno{Code: 20139, Message: "Login state is invalid or expired, please login again"}
+	ErrTwoFactorEnrollRequired    = &Errno{
+		Code: 20128, Message: "Two-factor authentication is required for admin, please enable it first",
+	}
 
 	// cluster errors for cluster module request
 	ErrClusterCreate      = &Errno{Code: 30100, Message: "Failed to add cluster, please try again"}
@@ -71,11 +94,20 @@ var (
 		Code:    30113,
 		Message: "Failed to get the connection from current cluster after short wait, please make sure the cluster exists and check it's network connectivity",
 	}
-	ErrClusterKubeConnect  = &Errno{Code: 30114, Message: "Connect cluster fail, Please check cluster connectivity"}
-	ErrClusterGenNamespace = &Errno{Code: 30115, Message: "Failed to gen namespace"}
-	ErrUserIdRequired      = &Errno{Code: 50116, Message: "User id parameter required"}
-	ErrUserIdFormat        = &Errno{Code: 50117, Message: "User id must be an unsigned integer greater than zero"}
-	ErrUserImport          = &Errno{Code: 50118, Message: "User import failed"}
+	ErrClusterKubeConnect   = &Errno{Code: 30114, Message: "Connect cluster fail, Please check cluster connectivity"}
+	ErrClusterGenNamespace  = &Errno{Code: 30115, Message: "Failed to gen namespace"}
+	ErrClusterQuotaExceed   = &Errno{Code: 30116, Message: "The number of clusters exceeds your cluster quota"}
+	ErrClusterServerDiffer  = &Errno{Code: 30117, Message: "The server of kubeconfig differs from the cluster"}
+	ErrClusterLabelInvalid  = &Errno{Code: 30118, Message: "Invalid labels or annotations of cluster"}
+	ErrClusterSelector      = &Errno{Code: 30119, Message: "Invalid label selector of cluster"}
+	ErrClusterAgentCreate   = &Errno{Code: 30120, Message: "Failed to create cluster agent, please try again"}
+	ErrClusterAgentNotFound = &Errno{Code: 30121, Message: "Cluster agent has not found"}
+	ErrClusterAgentToken    = &Errno{Code: 30122, Message: "Invalid registration token of cluster agent"}
+	ErrClusterAgentConnect  = &Errno{Code: 30123, Message: "Failed to connect the tunnel of cluster agent"}
+	ErrClusterUsage         = &Errno{Code: 30124, Message: "Failed to get resource usage of cluster"}
+	ErrUserIdRequired       = &Errno{Code: 50116, Message: "User id parameter required"}
+	ErrUserIdFormat         = &Errno{Code: 50117, Message: "User id must be an unsigned integer greater than zero"}
+	ErrUserImport           = &Errno{Code: 50118, Message: "User import failed"}
 
 	// application errors for application module request
 	ErrApplicationCreate        = &Errno{Code: 40100, Message: "Failed to add app, please try again"}
@@ -91,6 +123,31 @@ var (
 	ErrApplicationJsonContext   = &Errno{Code: 40108, Message: "Application context Unmarshal JSON fail"}
 	ErrApplicationNameExist     = &Errno{Code: 40109, Message: "Application name already exist"}
 	ErrSensitiveApplicationName = &Errno{Code: 40110, Message: "Application name can't not be 'default.application'"}
+	ErrGitCredentialNotFound    = &Errno{Code: 40112, Message: "Git credential not found"}
+	ErrGitCredentialExist       = &Errno{Code: 40113, Message: "Git credential name already exist"}
+	ErrGitCredentialType        = &Errno{Code: 40114, Message: "Type of git credential must be ssh or https"}
+	ErrGitCredentialSource      = &Errno{Code: 40115, Message: "Git credential can only be used by application from git or OCI registry"}
+	ErrGitCredentialInUse       = &Errno{Code: 40116, Message: "Git credential is used by applications"}
+	ErrHelmChartDigestSource    = &Errno{Code: 40117, Message: "Digest can only be pinned for chart from OCI registry"}
+	ErrHelmChartDigest          = &Errno{Code: 40118, Message: "Digest of chart must be sha256:<hex>"}
+	ErrKustomizeOverlay         = &Errno{
+		Code: 40119, Message: "Overlays can only be declared by kustomize application, with unique names and relative paths",
+	}
+	ErrNocalhostConfigEncoding = &Errno{Code: 40120, Message: "Nocalhost config must be base64 encoded"}
+	ErrNocalhostConfigSchema   = &Errno{Code: 40121, Message: "Nocalhost config does not match the schema"}
+
+	// registry credentials distributed to dev spaces
+	ErrRegistryCredentialNotFound = &Errno{Code: 40122, Message: "Registry credential not found"}
+	ErrRegistryCredentialExist    = &Errno{Code: 40123, Message: "Registry credential name already exist"}
+	ErrRegistryCredentialName     = &Errno{
+		Code:    40124,
+		Message: "Name of registry credential must consist of lower case alphanumeric characters or '-'",
+	}
+
+	// dev images of the catalog
+	ErrDevImageNotFound = &Errno{Code: 40125, Message: "Dev image not found"}
+	ErrDevImageExist    = &Errno{Code: 40126, Message: "Dev image name already exist"}
+	ErrDevImageLanguage = &Errno{Code: 40127, Message: "Language of dev image is unsupported"}
 
 	// application-cluster for application-cluster module request
 	ErrApplicationBoundClusterList = &Errno{
@@ -168,6 +225,58 @@ var (
 	}
 	ErrDeleteServiceAccount = &Errno{Code: 50127, Message: "Delete sa failed."}
 	ErrNsImportFail         = &Errno{Code: 50128, Message: "Namespace import failed"}
+	ErrDevSpaceQuotaExceed  = &Errno{Code: 50129, Message: "The number of dev spaces exceeds your quota"}
+	ErrSpaceResourceExceed  = &Errno{
+		Code:    50130,
+		Message: "The cpu or memory limits of dev space exceeds your quota, they are required if limited by quota",
+	}
+	ErrDevSpaceSaCreate       = &Errno{Code: 50131, Message: "Create service account of dev space failed"}
+	ErrDevSpaceSaNotFound     = &Errno{Code: 50132, Message: "Service account of dev space not found"}
+	ErrDevSpaceSaRotate       = &Errno{Code: 50133, Message: "Rotate token of service account failed"}
+	ErrDevSpaceSaRevoke       = &Errno{Code: 50134, Message: "Revoke service account failed"}
+	ErrDevSpaceSaClusterScope = &Errno{
+		Code:    50135,
+		Message: "Cluster scope dev space does not support namespaced service account",
+	}
+	ErrDevSpacePolicyDenied = &Errno{
+		Code:    50136,
+		Message: "Creating dev space on the cluster is denied by the policy of cluster labels",
+	}
+	ErrDevSpaceUsageClusterScope = &Errno{
+		Code:    50137,
+		Message: "Resource usage of cluster scope dev space is not supported, get the usage of cluster instead",
+	}
+	ErrDevSpaceSleepConfig = &Errno{Code: 50138, Message: "Invalid sleep config of dev space"}
+	ErrDevSpaceSleep       = &Errno{Code: 50139, Message: "Sleep dev space failed"}
+	ErrDevSpaceWakeup      = &Errno{Code: 50140, Message: "Wake up dev space failed"}
+	ErrDevSpaceTtl         = &Errno{Code: 50141, Message: "Invalid ttl of dev space"}
+	ErrDevSpaceSnapshot    = &Errno{Code: 50142, Message: "Snapshot dev space failed"}
+	ErrDevSpaceRestore     = &Errno{
+		Code:    50143,
+		Message: "Dev space is created but some resources failed to restore",
+	}
+	ErrDevSpaceSaRole                = &Errno{Code: 50144, Message: "Role of service account must be editor or viewer"}
+	ErrDevSpaceIsolate               = &Errno{Code: 50145, Message: "Isolate the namespace of dev space failed"}
+	ErrDevSpaceIsolationNotSupported = &Errno{
+		Code:    50146,
+		Message: "Mesh and cluster scope dev space can not be isolated",
+	}
+	ErrDevSpaceTemplateNotFound = &Errno{Code: 50147, Message: "Dev space template not found"}
+	ErrDevSpaceTemplateExist    = &Errno{Code: 50148, Message: "Dev space template name already exists"}
+	ErrDevSpaceTemplateApp      = &Errno{
+		Code:    50149,
+		Message: "Applications of dev space template must exist and come from git or helm repo",
+	}
+	ErrDevSpaceTemplateApply = &Errno{Code: 50150, Message: "Apply dev space template failed"}
+	ErrDevSpaceTransfer      = &Errno{Code: 50151, Message: "Transfer dev space failed"}
+	ErrDevSpaceTransferOwner = &Errno{Code: 50152, Message: "The user already owns the dev space"}
+	ErrDevSpaceAppRevision   = &Errno{Code: 50153, Message: "Revisions of the application in dev space not found"}
+	ErrDevSpaceAppRollback   = &Errno{Code: 50154, Message: "Rollback the application in dev space failed"}
+	ErrDevSpaceAppInstall    = &Errno{Code: 50155, Message: "Install the application in dev space failed"}
+	ErrBulkDeployNoDevSpace  = &Errno{Code: 50156, Message: "No dev space is selected to deploy the application"}
+	ErrBulkDeployNotFound    = &Errno{Code: 50157, Message: "Bulk deployment not found"}
+	ErrAppInstallNotFound    = &Errno{Code: 50158, Message: "Installation of the application in dev space not found"}
+	ErrDevSpaceActivity      = &Errno{Code: 50159, Message: "Activity of dev space must be sync or port_forward"}
 
 	// cluster-user errors for mesh space
 	ErrMeshClusterUserNotFound          = &Errno{Code: 50200, Message: "Base dev space has not found"}
@@ -186,7 +295,12 @@ var (
 		Code:    50211,
 		Message: "Cannot be set as both base space and mesh space",
 	}
-	ErrIstioNotFound = &Errno{Code: 50212, Message: "Please ensure the Istio is installed and running in your cluster"}
+	ErrIstioNotFound      = &Errno{Code: 50212, Message: "Please ensure the Istio is installed and running in your cluster"}
+	ErrNotBaseSpace       = &Errno{Code: 50213, Message: "The dev space is not a base space"}
+	ErrMeshHeaderConflict = &Errno{
+		Code:    50214,
+		Message: "The header is used by another mesh space of the same base space",
+	}
 
 	// application-user for application-user module request
 	ErrListApplicationUser = &Errno{
@@ -237,4 +351,19 @@ var (
 	ErrFailToSearchLDAP = &Errno{
 		Code: 110004, Message: "Failed to search Ldap, please check your Ldap configurations and try again",
 	}
+
+	// role errors for rbac module request
+	ErrRoleNotFound = &Errno{Code: 120001, Message: "Role not found"}
+	ErrRoleCreate   = &Errno{Code: 120002, Message: "Failed to create role, please check the name and permissions"}
+	ErrRoleUpdate   = &Errno{Code: 120003, Message: "Failed to update role, built-in role can not be modified"}
+	ErrRoleDelete   = &Errno{Code: 120004, Message: "Failed to delete role, built-in role can not be deleted"}
+	ErrRoleBind     = &Errno{Code: 120005, Message: "Failed to bind role, please check the scope and try again"}
+
+	// team errors for team module request
+	ErrTeamNotFound = &Errno{Code: 130001, Message: "Team not found"}
+	ErrTeamCreate   = &Errno{Code: 130002, Message: "Failed to create team, the name may already exist"}
+	ErrTeamUpdate   = &Errno{Code: 130003, Message: "Failed to update team, please try again"}
+	ErrTeamDelete   = &Errno{Code: 130004, Message: "Failed to delete team, please try again"}
+	ErrTeamMember   = &Errno{Code: 130005, Message: "Failed to update team members, please try again"}
+	ErrTeamGrant    = &Errno{Code: 130006, Message: "Failed to grant resource to team, please check and try again"}
 )
@@ -0,0 +1,133 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	HEALTH_TIMEOUT         = "health.timeout"
+	HEALTH_CACHE_TTL       = "health.cache_ttl"
+	HEALTH_REQUIRE_CLUSTER = "health.require_cluster"
+
+	defaultTimeout  = 3 * time.Second
+	defaultCacheTTL = 5 * time.Second
+)
+
+// Status of the dependencies and the server
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+)
+
+// Check the dependency, e.g. ping the database, nil if it is healthy
+type Check func(ctx context.Context) error
+
+// Result of a check
+type Result struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report of all the checks, up if all of them are up
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+var (
+	lock   sync.Mutex
+	checks = map[string]Check{}
+
+	// the report is cached for a while, so that the probes of replicas
+	// and load balancers do not flood the dependencies
+	cached   *Report
+	cachedAt time.Time
+)
+
+// Register the check of the dependency by name, e.g. database
+func Register(name string, check Check) {
+	lock.Lock()
+	defer lock.Unlock()
+	checks[name] = check
+	cached = nil
+}
+
+// RequireCluster whether at least one of the clusters is required to be
+// reachable by health.require_cluster
+func RequireCluster() bool {
+	return viper.GetBool(HEALTH_REQUIRE_CLUSTER)
+}
+
+// Run the checks concurrently in health.timeout, the report is cached for
+// health.cache_ttl
+func Run(ctx context.Context) Report {
+	lock.Lock()
+	defer lock.Unlock()
+
+	ttl := viper.GetDuration(HEALTH_CACHE_TTL)
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	if cached != nil && time.Since(cachedAt) < ttl {
+		return *cached
+	}
+
+	timeout := viper.GetDuration(HEALTH_TIMEOUT)
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	results := make(chan struct {
+		name   string
+		result Result
+	}, len(checks))
+	for name, check := range checks {
+		go func(name string, check Check) {
+			start := time.Now()
+			err := run(ctx, check)
+			result := Result{Status: StatusUp, Latency: time.Since(start).Round(time.Microsecond).String()}
+			if err != nil {
+				result.Status, result.Error = StatusDown, err.Error()
+			}
+			results <- struct {
+				name   string
+				result Result
+			}{name, result}
+		}(name, check)
+	}
+	for range checks {
+		r := <-results
+		report.Checks[r.name] = r.result
+		if r.result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	cached, cachedAt = &report, time.Now()
+	return report
+}
+
+// run the check until ctx is done, the checks ignoring ctx are abandoned
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,42 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestRun(t *testing.T) {
+	viper.Set(HEALTH_TIMEOUT, 100*time.Millisecond)
+	defer viper.Set(HEALTH_TIMEOUT, nil)
+
+	calls := 0
+	Register("database", func(ctx context.Context) error { calls++; return nil })
+	report := Run(context.Background())
+	if report.Status != StatusUp || report.Checks["database"].Status != StatusUp {
+		t.Errorf("healthy dependencies should be up, got %+v", report)
+	}
+
+	Register("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+	Register("clusters", func(ctx context.Context) error { time.Sleep(time.Second); return nil })
+	report = Run(context.Background())
+	if report.Status != StatusDown || report.Checks["redis"].Error != "connection refused" {
+		t.Errorf("failed dependency should be down, got %+v", report)
+	}
+	if report.Checks["clusters"].Status != StatusDown || report.Checks["database"].Status != StatusUp {
+		t.Errorf("check out of time should be down, got %+v", report)
+	}
+
+	Run(context.Background())
+	if calls != 2 {
+		t.Errorf("report should be cached, checked %d times", calls)
+	}
+}
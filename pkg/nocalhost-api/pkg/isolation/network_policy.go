@@ -0,0 +1,158 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package isolation
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nocalhost-api/global"
+)
+
+const (
+	ISOLATION_SYSTEM_NAMESPACES = "dev_space_isolation.system_namespaces"
+	ISOLATION_EGRESS_CIDRS      = "dev_space_isolation.egress_cidrs"
+
+	// namespaceNameLabel is labeled to every namespace by kubernetes 1.21+
+	namespaceNameLabel = "kubernetes.io/metadata.name"
+
+	DenyAllPolicy        = "nocalhost-deny-all"
+	AllowNamespacePolicy = "nocalhost-allow-namespace"
+	AllowSystemPolicy    = "nocalhost-allow-system"
+	AllowEgressPolicy    = "nocalhost-allow-egress"
+)
+
+var defaultSystemNamespaces = []string{"kube-system", "nocalhost-reserved"}
+
+// SystemNamespaces the traffic from and to them is allowed, such as ingress
+// controllers and monitors
+func SystemNamespaces() []string {
+	if nss := viper.GetStringSlice(ISOLATION_SYSTEM_NAMESPACES); len(nss) > 0 {
+		return nss
+	}
+	return defaultSystemNamespaces
+}
+
+// EgressCIDRs the traffic to them is allowed, such as the networks outside
+// the cluster, none by default
+func EgressCIDRs() []string {
+	return viper.GetStringSlice(ISOLATION_EGRESS_CIDRS)
+}
+
+// Policies returns the network policies isolating the namespace, all the
+// traffic is denied except the one inside namespace, DNS queries, and the
+// one from or to the system namespaces and egress cidrs
+func Policies(namespace string, systemNamespaces, egressCIDRs []string) []*networkingv1.NetworkPolicy {
+	both := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress}
+	labels := map[string]string{global.NocalhostCreateByLabel: global.NocalhostName}
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}
+	}
+
+	sameNamespace := []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}
+	system := []networkingv1.NetworkPolicyPeer{
+		{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: namespaceNameLabel, Operator: metav1.LabelSelectorOpIn, Values: systemNamespaces},
+				},
+			},
+		},
+	}
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dns := intstr.FromInt(53)
+
+	policies := []*networkingv1.NetworkPolicy{
+		{
+			ObjectMeta: meta(DenyAllPolicy),
+			Spec:       networkingv1.NetworkPolicySpec{PolicyTypes: both},
+		},
+		{
+			ObjectMeta: meta(AllowNamespacePolicy),
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: both,
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: sameNamespace}},
+				Egress:      []networkingv1.NetworkPolicyEgressRule{{To: sameNamespace}},
+			},
+		},
+		{
+			ObjectMeta: meta(AllowSystemPolicy),
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: both,
+				Ingress:     []networkingv1.NetworkPolicyIngressRule{{From: system}},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{To: system},
+					// DNS may be served outside the cluster
+					{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &dns}, {Protocol: &tcp, Port: &dns}}},
+				},
+			},
+		},
+	}
+
+	if len(egressCIDRs) > 0 {
+		to := make([]networkingv1.NetworkPolicyPeer, 0, len(egressCIDRs))
+		for _, cidr := range egressCIDRs {
+			to = append(to, networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}})
+		}
+		policies = append(
+			policies, &networkingv1.NetworkPolicy{
+				ObjectMeta: meta(AllowEgressPolicy),
+				Spec: networkingv1.NetworkPolicySpec{
+					PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+					Egress:      []networkingv1.NetworkPolicyEgressRule{{To: to}},
+				},
+			},
+		)
+	}
+	return policies
+}
+
+// Isolate create or update the network policies isolating the namespace
+func Isolate(client kubernetes.Interface, namespace string) error {
+	api := client.NetworkingV1().NetworkPolicies(namespace)
+	egressCIDRs := EgressCIDRs()
+	for _, policy := range Policies(namespace, SystemNamespaces(), egressCIDRs) {
+		_, err := api.Create(context.TODO(), policy, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			var old *networkingv1.NetworkPolicy
+			if old, err = api.Get(context.TODO(), policy.Name, metav1.GetOptions{}); err == nil {
+				policy.ResourceVersion = old.ResourceVersion
+				_, err = api.Update(context.TODO(), policy, metav1.UpdateOptions{})
+			}
+		}
+		if err != nil {
+			return errors.Wrapf(err, "apply network policy %s/%s", namespace, policy.Name)
+		}
+	}
+
+	// the egress cidrs may be removed from config
+	if len(egressCIDRs) == 0 {
+		err := api.Delete(context.TODO(), AllowEgressPolicy, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete network policy %s/%s", namespace, AllowEgressPolicy)
+		}
+	}
+	return nil
+}
+
+// Release delete the network policies isolating the namespace
+func Release(client kubernetes.Interface, namespace string) error {
+	for _, name := range []string{DenyAllPolicy, AllowNamespacePolicy, AllowSystemPolicy, AllowEgressPolicy} {
+		err := client.NetworkingV1().NetworkPolicies(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete network policy %s/%s", namespace, name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package isolation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/viper"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPolicies(t *testing.T) {
+	policies := Policies("dev", []string{"kube-system"}, nil)
+	if len(policies) != 3 {
+		t.Fatalf("unexpected policies %d", len(policies))
+	}
+	deny := policies[0]
+	if len(deny.Spec.PolicyTypes) != 2 || len(deny.Spec.Ingress) != 0 || len(deny.Spec.Egress) != 0 {
+		t.Fatal("the first policy should deny all")
+	}
+	same := policies[1].Spec
+	if same.Ingress[0].From[0].PodSelector == nil || same.Ingress[0].From[0].NamespaceSelector != nil {
+		t.Fatal("only pods in namespace are allowed")
+	}
+	system := policies[2].Spec
+	if system.Ingress[0].From[0].NamespaceSelector.MatchExpressions[0].Values[0] != "kube-system" ||
+		system.Egress[1].Ports[0].Port.IntValue() != 53 {
+		t.Fatal("system traffic and dns should be allowed")
+	}
+
+	policies = Policies("dev", []string{"kube-system"}, []string{"0.0.0.0/0"})
+	if len(policies) != 4 || policies[3].Spec.Egress[0].To[0].IPBlock.CIDR != "0.0.0.0/0" {
+		t.Fatal("egress cidrs should be allowed")
+	}
+}
+
+func TestIsolateAndRelease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+
+	viper.Set(ISOLATION_EGRESS_CIDRS, []string{"10.0.0.0/8"})
+	if err := Isolate(client, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set(ISOLATION_EGRESS_CIDRS, []string{})
+	defer viper.Set(ISOLATION_EGRESS_CIDRS, nil)
+	// isolate again updates the policies
+	if err := Isolate(client, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := client.NetworkingV1().NetworkPolicies("dev").List(ctx, metav1.ListOptions{})
+	if len(list.Items) != 3 {
+		t.Fatalf("unexpected policies %d", len(list.Items))
+	}
+
+	if err := Release(client, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = client.NetworkingV1().NetworkPolicies("dev").List(ctx, metav1.ListOptions{})
+	if len(list.Items) != 0 {
+		t.Fatalf("policies should be deleted, %d left", len(list.Items))
+	}
+}
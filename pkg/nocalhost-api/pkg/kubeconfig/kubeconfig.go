@@ -0,0 +1,287 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package kubeconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	yaml2 "github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+const (
+	KUBECONFIG_CHECK_INTERVAL  = "cluster_kubeconfig.check_interval"
+	KUBECONFIG_EXPIRY_WARNING  = "cluster_kubeconfig.expiry_warning"
+	KUBECONFIG_AUTO_REFRESH    = "cluster_kubeconfig.auto_refresh"
+	defaultCheckInterval       = time.Hour
+	defaultExpiryWarning       = 7 * 24 * time.Hour
+	execTimeout                = 30 * time.Second
+	execInfoEnv                = "KUBERNETES_EXEC_INFO"
+	defaultExecCredentialGroup = "client.authentication.k8s.io/v1beta1"
+)
+
+// Status of the credential in kubeconfig
+const (
+	StatusValid         = "valid"
+	StatusExpiring      = "expiring"
+	StatusExpired       = "expired"
+	StatusRefreshFailed = "refresh_failed"
+)
+
+// CheckInterval returns the interval of the background expiry check
+func CheckInterval() time.Duration {
+	if d := viper.GetDuration(KUBECONFIG_CHECK_INTERVAL); d > 0 {
+		return d
+	}
+	return defaultCheckInterval
+}
+
+// ExpiryWarning returns how long before the expiry the credential turns into expiring
+func ExpiryWarning() time.Duration {
+	if d := viper.GetDuration(KUBECONFIG_EXPIRY_WARNING); d > 0 {
+		return d
+	}
+	return defaultExpiryWarning
+}
+
+// AutoRefreshEnabled returns true if credential is allowed to be refreshed by exec plugin
+func AutoRefreshEnabled() bool {
+	return viper.GetBool(KUBECONFIG_AUTO_REFRESH)
+}
+
+// Status returns the status of the credential expires at the time,
+// empty if it never expires or the expiry is unknown
+func Status(expireAt *time.Time) string {
+	switch {
+	case expireAt == nil:
+		return ""
+	case !expireAt.After(time.Now()):
+		return StatusExpired
+	case time.Until(*expireAt) < ExpiryWarning():
+		return StatusExpiring
+	default:
+		return StatusValid
+	}
+}
+
+// Expiry returns the earliest expiry of the client certificate and the bearer
+// token of current context, nil if neither of them has an expiry
+func Expiry(kubeConfig []byte) (*time.Time, error) {
+	authInfo, err := currentAuthInfo(kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var expireAt *time.Time
+	earliest := func(t *time.Time) {
+		if t != nil && (expireAt == nil || t.Before(*expireAt)) {
+			expireAt = t
+		}
+	}
+
+	if len(authInfo.ClientCertificateData) > 0 {
+		notAfter, err := certificateExpiry(authInfo.ClientCertificateData)
+		if err != nil {
+			return nil, err
+		}
+		earliest(notAfter)
+	}
+
+	if authInfo.Token != "" {
+		earliest(tokenExpiry(authInfo.Token))
+	}
+	return expireAt, nil
+}
+
+// HasExec returns true if the credential of current context is provided by exec plugin
+func HasExec(kubeConfig []byte) bool {
+	authInfo, err := currentAuthInfo(kubeConfig)
+	return err == nil && authInfo.Exec != nil
+}
+
+// execCredential is the subset of client.authentication.k8s.io ExecCredential
+type execCredential struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		Interactive bool `json:"interactive"`
+	} `json:"spec"`
+	Status *struct {
+		ExpirationTimestamp   *time.Time `json:"expirationTimestamp,omitempty"`
+		Token                 string     `json:"token,omitempty"`
+		ClientCertificateData string     `json:"clientCertificateData,omitempty"`
+		ClientKeyData         string     `json:"clientKeyData,omitempty"`
+	} `json:"status,omitempty"`
+}
+
+// RefreshExec runs the exec plugin of current context and embeds the issued
+// credential into kubeconfig. The exec stanza is kept for the next refresh,
+// client-go skips the plugin while a static credential is present
+func RefreshExec(kubeConfig []byte) ([]byte, *time.Time, error) {
+	config, err := clientcmd.Load(kubeConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "load kubeconfig")
+	}
+
+	authInfo, err := authInfoOf(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if authInfo.Exec == nil {
+		return nil, nil, errors.New("kubeconfig has no exec credential plugin")
+	}
+
+	cred, err := runExec(authInfo.Exec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authInfo.Token = cred.Status.Token
+	if cred.Status.ClientCertificateData != "" {
+		authInfo.ClientCertificateData = []byte(cred.Status.ClientCertificateData)
+		authInfo.ClientKeyData = []byte(cred.Status.ClientKeyData)
+	}
+
+	refreshed, err := write(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expireAt := cred.Status.ExpirationTimestamp
+	if expireAt == nil {
+		if expireAt, err = Expiry(refreshed); err != nil {
+			return nil, nil, err
+		}
+	}
+	return refreshed, expireAt, nil
+}
+
+func runExec(config *clientcmdapi.ExecConfig) (*execCredential, error) {
+	apiVersion := config.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultExecCredentialGroup
+	}
+
+	info := execCredential{APIVersion: apiVersion, Kind: "ExecCredential"}
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, config.Command, config.Args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", execInfoEnv, infoBytes))
+	for _, env := range config.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", env.Name, env.Value))
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "exec plugin %s: %s", config.Command, strings.TrimSpace(stderr.String()))
+	}
+
+	cred := &execCredential{}
+	if err := json.Unmarshal(stdout.Bytes(), cred); err != nil {
+		return nil, errors.Wrapf(err, "decode output of exec plugin %s", config.Command)
+	}
+
+	if cred.Status == nil || (cred.Status.Token == "" && cred.Status.ClientCertificateData == "") {
+		return nil, errors.Errorf("exec plugin %s returns no credential", config.Command)
+	}
+	return cred, nil
+}
+
+// write encodes the kubeconfig to yaml like the dev kubeconfig reader does
+func write(config *clientcmdapi.Config) ([]byte, error) {
+	v1Config := &clientcmdapiv1.Config{}
+	if err := clientcmdapiv1.Convert_api_Config_To_v1_Config(config, v1Config, nil); err != nil {
+		return nil, errors.Wrap(err, "convert kubeconfig")
+	}
+	v1Config.APIVersion, v1Config.Kind = "v1", "Config"
+
+	jsonBytes, err := json.Marshal(v1Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "encode kubeconfig")
+	}
+	return yaml2.JSONToYAML(jsonBytes)
+}
+
+func currentAuthInfo(kubeConfig []byte) (*clientcmdapi.AuthInfo, error) {
+	config, err := clientcmd.Load(kubeConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "load kubeconfig")
+	}
+	return authInfoOf(config)
+}
+
+func authInfoOf(config *clientcmdapi.Config) (*clientcmdapi.AuthInfo, error) {
+	ctx := config.Contexts[config.CurrentContext]
+	if ctx == nil {
+		return nil, errors.Errorf("context %s not found in kubeconfig", config.CurrentContext)
+	}
+
+	authInfo := config.AuthInfos[ctx.AuthInfo]
+	if authInfo == nil {
+		return nil, errors.Errorf("user %s not found in kubeconfig", ctx.AuthInfo)
+	}
+	return authInfo, nil
+}
+
+// certificateExpiry returns the NotAfter of the leaf certificate
+func certificateExpiry(data []byte) (*time.Time, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid client certificate data")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse client certificate")
+	}
+	return &cert.NotAfter, nil
+}
+
+// tokenExpiry returns the exp claim if the token is a jwt, the signature is not verified.
+// Legacy service account tokens and static tokens have no expiry
+func tokenExpiry(token string) *time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil
+	}
+
+	var claims struct {
+		Exp *int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == nil {
+		return nil
+	}
+
+	exp := time.Unix(*claims.Exp, 0)
+	return &exp
+}
@@ -0,0 +1,150 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package kubeconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func newKubeConfig(t *testing.T, authInfo *clientcmdapi.AuthInfo) []byte {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["test"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+	config.AuthInfos["test"] = authInfo
+	config.Contexts["test"] = &clientcmdapi.Context{Cluster: "test", AuthInfo: "test"}
+	config.CurrentContext = "test"
+
+	data, err := write(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newCertificate(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "admin"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func newToken(exp time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"exp":%d}`, exp.Unix())))
+	return header + "." + payload + ".signature"
+}
+
+func TestExpiry(t *testing.T) {
+	certExpiry := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	tokenExpiry := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name     string
+		authInfo *clientcmdapi.AuthInfo
+		want     *time.Time
+	}{
+		{"static token", &clientcmdapi.AuthInfo{Token: "abcdef"}, nil},
+		{"certificate", &clientcmdapi.AuthInfo{ClientCertificateData: newCertificate(t, certExpiry)}, &certExpiry},
+		{"jwt token", &clientcmdapi.AuthInfo{Token: newToken(tokenExpiry)}, &tokenExpiry},
+		{
+			"earliest",
+			&clientcmdapi.AuthInfo{
+				ClientCertificateData: newCertificate(t, certExpiry), Token: newToken(tokenExpiry),
+			},
+			&tokenExpiry,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Expiry(newKubeConfig(t, tt.authInfo))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+			t.Errorf("%s: expiry = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	expiring := time.Now().Add(time.Hour)
+	valid := time.Now().Add(30 * 24 * time.Hour)
+
+	for _, tt := range []struct {
+		expireAt *time.Time
+		want     string
+	}{
+		{nil, ""},
+		{&expired, StatusExpired},
+		{&expiring, StatusExpiring},
+		{&valid, StatusValid},
+	} {
+		if got := Status(tt.expireAt); got != tt.want {
+			t.Errorf("Status(%v) = %s, want %s", tt.expireAt, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshExec(t *testing.T) {
+	output := `{"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential",` +
+		`"status":{"token":"refreshed","expirationTimestamp":"2030-01-01T00:00:00Z"}}`
+	kubeConfig := newKubeConfig(
+		t, &clientcmdapi.AuthInfo{
+			Exec: &clientcmdapi.ExecConfig{
+				Command:    "sh",
+				Args:       []string{"-c", "echo '" + output + "'"},
+				APIVersion: "client.authentication.k8s.io/v1beta1",
+			},
+		},
+	)
+
+	if !HasExec(kubeConfig) {
+		t.Fatal("exec plugin is not detected")
+	}
+
+	refreshed, expireAt, err := RefreshExec(kubeConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authInfo, err := currentAuthInfo(refreshed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if authInfo.Token != "refreshed" || authInfo.Exec == nil {
+		t.Errorf("token = %s, exec = %v", authInfo.Token, authInfo.Exec)
+	}
+
+	if expireAt == nil || !expireAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expiry = %v", expireAt)
+	}
+}
@@ -5,7 +5,12 @@
 
 package log
 
-import "errors"
+import (
+	"context"
+	"errors"
+
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
 
 // A global variable so that coloredoutput functions can be directly accessed
 var log Logger
@@ -134,3 +139,15 @@ func Panicf(format string, args ...interface{}) {
 func WithFields(keyValues Fields) Logger {
 	return log.WithFields(keyValues)
 }
+
+// WithContext logger with the request id and trace of ctx, either the
+// request context or gin context, so that the logs of a request and the
+// kubernetes requests it sent can be correlated, eg:
+// 		log.WithContext(c).Warnf("create namespace err: %v", err)
+func WithContext(ctx context.Context) Logger {
+	t := trace.FromContext(ctx)
+	if t == nil {
+		return log
+	}
+	return log.WithFields(Fields{"request_id": t.RequestID, "trace_id": t.TraceID, "span_id": t.SpanID})
+}
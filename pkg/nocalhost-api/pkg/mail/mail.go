@@ -0,0 +1,94 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package mail
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	MAIL_HOST     = "mail.host"
+	MAIL_PORT     = "mail.port"
+	MAIL_USERNAME = "mail.username"
+	MAIL_PASSWORD = "mail.password"
+	MAIL_FROM     = "mail.from"
+)
+
+// Mailer send the plain text mails
+type Mailer interface {
+	// Enabled returns false if the mails can not be sent
+	Enabled() bool
+	Send(to, subject, body string) error
+}
+
+// SMTP the Mailer through the smtp server configured
+type SMTP struct{}
+
+// NewSMTP
+func NewSMTP() Mailer {
+	return SMTP{}
+}
+
+// Enabled returns true if smtp server is configured
+func Enabled() bool {
+	return SMTP{}.Enabled()
+}
+
+// Send send a plain text mail through the smtp server configured
+func Send(to, subject, body string) error {
+	return SMTP{}.Send(to, subject, body)
+}
+
+// Enabled returns true if smtp server is configured
+func (SMTP) Enabled() bool {
+	return viper.GetString(MAIL_HOST) != ""
+}
+
+// Send send a plain text mail through the smtp server configured
+func (m SMTP) Send(to, subject, body string) error {
+	if !m.Enabled() {
+		return errors.New("Mail server is not configured")
+	}
+
+	host := viper.GetString(MAIL_HOST)
+	port := viper.GetString(MAIL_PORT)
+	if port == "" {
+		port = "25"
+	}
+
+	from := viper.GetString(MAIL_FROM)
+	if from == "" {
+		from = viper.GetString(MAIL_USERNAME)
+	}
+
+	var auth smtp.Auth
+	if username := viper.GetString(MAIL_USERNAME); username != "" {
+		auth = smtp.PlainAuth("", username, viper.GetString(MAIL_PASSWORD), host)
+	}
+
+	msg := strings.Join(
+		[]string{
+			fmt.Sprintf("From: %s", from),
+			fmt.Sprintf("To: %s", to),
+			fmt.Sprintf("Subject: %s", subject),
+			"MIME-Version: 1.0",
+			"Content-Type: text/plain; charset=UTF-8",
+			"",
+			body,
+		}, "\r\n",
+	)
+
+	if err := smtp.SendMail(net.JoinHostPort(host, port), auth, from, []string{to}, []byte(msg)); err != nil {
+		return errors.Wrap(err, "Fail to send mail")
+	}
+	return nil
+}
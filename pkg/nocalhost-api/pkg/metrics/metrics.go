@@ -0,0 +1,238 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package metrics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "nocalhost_api"
+
+// Result of login attempts
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Result of cache lookups
+const (
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of http requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"},
+	)
+
+	dbQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Latency of database operations by table.",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation", "table"},
+	)
+
+	loginTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_total",
+			Help:      "Login attempts by method and result.",
+		}, []string{"method", "result"},
+	)
+
+	cacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Cache lookups by module, layer and result.",
+		}, []string{"module", "layer", "result"},
+	)
+
+	rateLimited = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_total",
+			Help:      "Requests rejected by rate limiting by policy.",
+		}, []string{"policy"},
+	)
+
+	leader = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "leader",
+			Help:      "Whether the replica is the leader running the background jobs.",
+		},
+	)
+
+	jobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "job_duration_seconds",
+			Help:      "Duration of background jobs.",
+			Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300, 600},
+		}, []string{"job"},
+	)
+
+	devSpaces = &devSpaceCollector{
+		desc: prometheus.NewDesc(
+			namespace+"_dev_spaces", "Number of dev spaces by state.", []string{"state"}, nil,
+		),
+	}
+)
+
+func init() {
+	prometheus.MustRegister(
+		requestDuration, dbQueryDuration, loginTotal, cacheLookups, rateLimited, leader, jobDuration, devSpaces,
+	)
+}
+
+// ObserveRequest record the latency of request by the route matched, the
+// requests not matched any route are recorded as route "unknown", so that
+// scans of random paths never blow up the series
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unknown"
+	}
+	requestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// Login count the login attempt of method, e.g. password, oidc or oauth
+func Login(method string, success bool) {
+	result := LoginFailure
+	if success {
+		result = LoginSuccess
+	}
+	loginTotal.WithLabelValues(method, result).Inc()
+}
+
+// CacheLookup count the lookup of module in the layer of cache, the hit
+// ratio is the rate of hits divided by the rate of lookups
+func CacheLookup(module, layer string, hit bool) {
+	result := CacheMiss
+	if hit {
+		result = CacheHit
+	}
+	cacheLookups.WithLabelValues(module, layer, result).Inc()
+}
+
+// RateLimited count the request rejected by the rate limiting policy
+func RateLimited(policy string) {
+	rateLimited.WithLabelValues(policy).Inc()
+}
+
+// SetLeader record whether the replica is the leader of background jobs
+func SetLeader(isLeader bool) {
+	if isLeader {
+		leader.Set(1)
+	} else {
+		leader.Set(0)
+	}
+}
+
+// ObserveJob start timing a run of background job, the returned func
+// records the duration, e.g. defer metrics.ObserveJob("cluster_probe")()
+func ObserveJob(job string) func() {
+	start := time.Now()
+	return func() {
+		jobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+	}
+}
+
+const startedKey = "nocalhost:metrics_started_at"
+
+// RegisterGormCallbacks observe the latency of create, update, delete and
+// query operations of db
+func RegisterGormCallbacks(db *gorm.DB) {
+	callback := db.Callback()
+	for _, processor := range []struct {
+		operation     string
+		p             *gorm.CallbackProcessor
+		before, after string
+	}{
+		{"create", callback.Create(), "gorm:begin_transaction", "gorm:commit_or_rollback_transaction"},
+		{"update", callback.Update(), "gorm:begin_transaction", "gorm:commit_or_rollback_transaction"},
+		{"delete", callback.Delete(), "gorm:begin_transaction", "gorm:commit_or_rollback_transaction"},
+		{"query", callback.Query(), "gorm:query", "gorm:after_query"},
+		{"row_query", callback.RowQuery(), "gorm:row_query", "gorm:row_query"},
+	} {
+		operation := processor.operation
+		processor.p.Before(processor.before).Register("metrics:before_"+operation, startTiming)
+		processor.p.After(processor.after).Register(
+			"metrics:after_"+operation, func(scope *gorm.Scope) { stopTiming(scope, operation) },
+		)
+	}
+}
+
+func startTiming(scope *gorm.Scope) {
+	scope.InstanceSet(startedKey, time.Now())
+}
+
+func stopTiming(scope *gorm.Scope, operation string) {
+	value, ok := scope.InstanceGet(startedKey)
+	if !ok {
+		return
+	}
+	start, ok := value.(time.Time)
+	if !ok {
+		return
+	}
+	table := scope.TableName()
+	if table == "" {
+		table = "unknown"
+	}
+	dbQueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+}
+
+// DevSpaceCounter counts the dev spaces by state when scraped
+type DevSpaceCounter func() (map[string]int, error)
+
+type devSpaceCollector struct {
+	desc    *prometheus.Desc
+	lock    sync.RWMutex
+	counter DevSpaceCounter
+}
+
+// RegisterDevSpaceCounter set the counter of dev spaces, no dev space
+// count is exposed before registered
+func RegisterDevSpaceCounter(counter DevSpaceCounter) {
+	devSpaces.lock.Lock()
+	defer devSpaces.lock.Unlock()
+	devSpaces.counter = counter
+}
+
+func (c *devSpaceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *devSpaceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.lock.RLock()
+	counter := c.counter
+	c.lock.RUnlock()
+	if counter == nil {
+		return
+	}
+
+	counts, err := counter()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for state, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), state)
+	}
+}
@@ -0,0 +1,92 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func gather(t *testing.T, name string) []*dto.Metric {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()
+		}
+	}
+	return nil
+}
+
+func labels(m *dto.Metric) map[string]string {
+	result := map[string]string{}
+	for _, pair := range m.GetLabel() {
+		result[pair.GetName()] = pair.GetValue()
+	}
+	return result
+}
+
+func TestObserveRequest(t *testing.T) {
+	ObserveRequest("GET", "/v1/cluster/:id", 200, 20*time.Millisecond)
+	ObserveRequest("GET", "", 404, time.Millisecond)
+
+	routes := map[string]uint64{}
+	for _, m := range gather(t, "nocalhost_api_http_request_duration_seconds") {
+		routes[labels(m)["route"]] += m.GetHistogram().GetSampleCount()
+	}
+	if routes["/v1/cluster/:id"] != 1 || routes["unknown"] != 1 {
+		t.Fatalf("unexpected requests %v", routes)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	Login("password", true)
+	Login("password", false)
+	Login("password", false)
+
+	results := map[string]float64{}
+	for _, m := range gather(t, "nocalhost_api_login_total") {
+		results[labels(m)["result"]] += m.GetCounter().GetValue()
+	}
+	if results[LoginSuccess] != 1 || results[LoginFailure] != 2 {
+		t.Fatalf("unexpected logins %v", results)
+	}
+}
+
+func TestDevSpaceCounter(t *testing.T) {
+	defer RegisterDevSpaceCounter(nil)
+
+	RegisterDevSpaceCounter(func() (map[string]int, error) { return map[string]int{"asleep": 1, "awake": 3}, nil })
+	counts := map[string]float64{}
+	for _, m := range gather(t, "nocalhost_api_dev_spaces") {
+		counts[labels(m)["state"]] = m.GetGauge().GetValue()
+	}
+	if counts["asleep"] != 1 || counts["awake"] != 3 {
+		t.Fatalf("unexpected dev spaces %v", counts)
+	}
+
+	RegisterDevSpaceCounter(func() (map[string]int, error) { return nil, errors.New("db is down") })
+	if _, err := prometheus.DefaultGatherer.Gather(); err == nil {
+		t.Fatal("error of counter must be reported")
+	}
+}
+
+func TestObserveJob(t *testing.T) {
+	ObserveJob("cluster_probe")()
+
+	for _, m := range gather(t, "nocalhost_api_job_duration_seconds") {
+		if labels(m)["job"] == "cluster_probe" && m.GetHistogram().GetSampleCount() == 1 {
+			return
+		}
+	}
+	t.Fatal("duration of job is not observed")
+}
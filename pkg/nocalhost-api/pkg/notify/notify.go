@@ -0,0 +1,316 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+)
+
+// Events notified, the webhooks subscribe them by notify.webhooks[].events
+const (
+	DevSpaceCreated          = "dev_space.created"
+	DevSpaceExpiring         = "dev_space.expiring"
+	ApplicationInstallFailed = "application.install_failed"
+	ClusterUnreachable       = "cluster.unreachable"
+	UserLocked               = "user.locked"
+)
+
+// Types of webhook, which decide the payload
+const (
+	TypeSlack    = "slack"
+	TypeDingTalk = "dingtalk"
+	TypeFeishu   = "feishu"
+	TypeGeneric  = "generic"
+)
+
+const (
+	NOTIFY_WEBHOOKS = "notify.webhooks"
+
+	defaultTemplate = "[Nocalhost] {{.Message}}"
+	queueSize       = 256
+	workers         = 2
+	maxAttempts     = 3
+	requestTimeout  = 10 * time.Second
+)
+
+// Event notified to the webhooks
+type Event struct {
+	Type    string                 `json:"type"`
+	Time    time.Time              `json:"time"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Webhook configured by notify.webhooks
+type Webhook struct {
+	Name string `mapstructure:"name"`
+	Type string `mapstructure:"type"`
+	URL  string `mapstructure:"url"`
+	// Events subscribed, all if empty
+	Events []string `mapstructure:"events"`
+	// Template of the text, or of the body of generic webhook, executed with
+	// the Event
+	Template string `mapstructure:"template"`
+	// Secret signs the requests, in the way of DingTalk and Feishu robots,
+	// or by the header X-Nocalhost-Signature of generic webhook
+	Secret  string            `mapstructure:"secret"`
+	Headers map[string]string `mapstructure:"headers"`
+
+	tmpl *template.Template
+}
+
+func (w *Webhook) subscribes(eventType string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type notifier struct {
+	webhooks []*Webhook
+	client   *http.Client
+	queue    chan Event
+	backoff  time.Duration
+	now      func() time.Time
+}
+
+func newNotifier(webhooks []*Webhook) (*notifier, error) {
+	for _, w := range webhooks {
+		switch w.Type {
+		case TypeSlack, TypeDingTalk, TypeFeishu, TypeGeneric:
+		case "":
+			w.Type = TypeGeneric
+		default:
+			return nil, errors.Errorf("unknown type %s of webhook %s", w.Type, w.Name)
+		}
+		if w.URL == "" {
+			return nil, errors.Errorf("url of webhook %s is empty", w.Name)
+		}
+
+		text := w.Template
+		if text == "" {
+			text = defaultTemplate
+		}
+		tmpl, err := template.New(w.Name).Parse(text)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse template of webhook %s", w.Name)
+		}
+		w.tmpl = tmpl
+	}
+	return &notifier{
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: requestTimeout},
+		queue:    make(chan Event, queueSize),
+		backoff:  time.Second,
+		now:      time.Now,
+	}, nil
+}
+
+// work deliver the events queued until the shutdown begins
+func (n *notifier) work() {
+	for {
+		select {
+		case event := <-n.queue:
+			for _, w := range n.webhooks {
+				if !w.subscribes(event.Type) {
+					continue
+				}
+				w := w
+				shutdown.Run(
+					func() {
+						if err := n.deliver(w, event); err != nil {
+							log.Warnf("notify %s to webhook %s err: %v", event.Type, w.Name, err)
+						}
+					},
+				)
+			}
+		case <-shutdown.Stopping():
+			return
+		}
+	}
+}
+
+// deliver the event to webhook, retried with exponential backoff if the
+// request fails or is responded 429 or 5xx
+func (n *notifier) deliver(w *Webhook, event Event) error {
+	backoff := n.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		if retry, err = n.send(w, event); err == nil || !retry || attempt == maxAttempts {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-shutdown.Stopping():
+			return err
+		}
+	}
+}
+
+// send the request to webhook, retry reports whether the failure is transient
+func (n *notifier) send(w *Webhook, event Event) (retry bool, err error) {
+	req, err := n.request(w, event)
+	if err != nil {
+		return false, err
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, errors.Wrap(err, "request webhook")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		errors.Errorf("webhook responded %s", resp.Status)
+}
+
+// request build the payload of the type of webhook
+func (n *notifier) request(w *Webhook, event Event) (*http.Request, error) {
+	buf := bytes.Buffer{}
+	if err := w.tmpl.Execute(&buf, event); err != nil {
+		return nil, errors.Wrap(err, "execute template")
+	}
+	text := buf.String()
+
+	target := w.URL
+	var body []byte
+	var err error
+	switch w.Type {
+	case TypeSlack:
+		body, err = json.Marshal(map[string]interface{}{"text": text})
+	case TypeDingTalk:
+		if w.Secret != "" {
+			timestamp := strconv.FormatInt(n.now().UnixNano()/int64(time.Millisecond), 10)
+			target = appendQuery(target, url.Values{"timestamp": {timestamp}, "sign": {dingTalkSign(timestamp, w.Secret)}})
+		}
+		body, err = json.Marshal(
+			map[string]interface{}{"msgtype": "text", "text": map[string]string{"content": text}},
+		)
+	case TypeFeishu:
+		payload := map[string]interface{}{"msg_type": "text", "content": map[string]string{"text": text}}
+		if w.Secret != "" {
+			timestamp := strconv.FormatInt(n.now().Unix(), 10)
+			payload["timestamp"] = timestamp
+			payload["sign"] = feishuSign(timestamp, w.Secret)
+		}
+		body, err = json.Marshal(payload)
+	default:
+		if w.Template != "" {
+			body = buf.Bytes()
+		} else {
+			body, err = json.Marshal(event)
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal payload")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Type == TypeGeneric {
+		req.Header.Set("X-Nocalhost-Event", event.Type)
+		if w.Secret != "" {
+			mac := hmac.New(sha256.New, []byte(w.Secret))
+			mac.Write(body)
+			req.Header.Set("X-Nocalhost-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+	}
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// dingTalkSign the sign of DingTalk robot, the hmac of timestamp and secret
+func dingTalkSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// feishuSign the sign of Feishu robot, keyed by timestamp and secret
+func feishuSign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func appendQuery(target string, values url.Values) string {
+	if strings.Contains(target, "?") {
+		return target + "&" + values.Encode()
+	}
+	return target + "?" + values.Encode()
+}
+
+var defaultNotifier *notifier
+
+// Start the workers delivering the events to the webhooks configured by
+// notify.webhooks, the events are discarded if none is configured
+func Start() error {
+	var webhooks []*Webhook
+	if err := viper.UnmarshalKey(NOTIFY_WEBHOOKS, &webhooks); err != nil {
+		return errors.Wrap(err, "parse notify.webhooks")
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	n, err := newNotifier(webhooks)
+	if err != nil {
+		return err
+	}
+	defaultNotifier = n
+	for i := 0; i < workers; i++ {
+		go n.work()
+	}
+	log.Infof("notify events to %d webhooks", len(webhooks))
+	return nil
+}
+
+// Notify queue the event to deliver in background, it never blocks, the
+// event is dropped if the queue is full
+func Notify(eventType string, fields map[string]interface{}, format string, args ...interface{}) {
+	n := defaultNotifier
+	if n == nil {
+		return
+	}
+	event := Event{Type: eventType, Time: n.now(), Message: fmt.Sprintf(format, args...), Fields: fields}
+	select {
+	case n.queue <- event:
+	default:
+		log.Warnf("notify queue is full, event %s dropped", eventType)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type received struct {
+	header http.Header
+	query  map[string][]string
+	body   []byte
+}
+
+func testServer(t *testing.T, statuses ...int) (*httptest.Server, chan received) {
+	requests := make(chan received, 10)
+	attempt := 0
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				requests <- received{header: r.Header, query: r.URL.Query(), body: body}
+				if attempt < len(statuses) {
+					w.WriteHeader(statuses[attempt])
+				}
+				attempt++
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func testNotifier(t *testing.T, webhooks ...*Webhook) *notifier {
+	n, err := newNotifier(webhooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.backoff = time.Millisecond
+	n.now = func() time.Time { return time.Unix(1600000000, 0) }
+	return n
+}
+
+var event = Event{Type: DevSpaceCreated, Message: "dev space foo created", Fields: map[string]interface{}{"id": 1}}
+
+func TestPayloads(t *testing.T) {
+	server, requests := testServer(t)
+	n := testNotifier(
+		t,
+		&Webhook{Name: "slack", Type: TypeSlack, URL: server.URL},
+		&Webhook{Name: "dingtalk", Type: TypeDingTalk, URL: server.URL + "?access_token=x", Secret: "s"},
+		&Webhook{Name: "feishu", Type: TypeFeishu, URL: server.URL, Template: "{{.Type}}: {{.Message}}"},
+	)
+
+	expects := []string{
+		`{"text":"[Nocalhost] dev space foo created"}`,
+		`{"msgtype":"text","text":{"content":"[Nocalhost] dev space foo created"}}`,
+		`{"content":{"text":"dev_space.created: dev space foo created"},"msg_type":"text"}`,
+	}
+	for i, w := range n.webhooks {
+		if err := n.deliver(w, event); err != nil {
+			t.Fatal(err)
+		}
+		r := <-requests
+		if string(r.body) != expects[i] {
+			t.Fatalf("unexpected payload of %s: %s", w.Name, r.body)
+		}
+		if w.Type == TypeDingTalk {
+			if r.query["access_token"][0] != "x" || r.query["timestamp"][0] != "1600000000000" ||
+				r.query["sign"][0] != dingTalkSign("1600000000000", "s") {
+				t.Fatalf("unexpected sign of dingtalk: %v", r.query)
+			}
+		}
+	}
+}
+
+func TestGenericSignature(t *testing.T) {
+	server, requests := testServer(t)
+	n := testNotifier(t, &Webhook{Name: "generic", URL: server.URL, Secret: "s", Headers: map[string]string{"X-A": "b"}})
+	if err := n.deliver(n.webhooks[0], event); err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-requests
+	decoded := Event{}
+	if err := json.Unmarshal(r.body, &decoded); err != nil || decoded.Type != DevSpaceCreated {
+		t.Fatalf("unexpected body %s: %v", r.body, err)
+	}
+	mac := hmac.New(sha256.New, []byte("s"))
+	mac.Write(r.body)
+	if r.header.Get("X-Nocalhost-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+		t.Fatalf("unexpected signature %s", r.header.Get("X-Nocalhost-Signature"))
+	}
+	if r.header.Get("X-Nocalhost-Event") != DevSpaceCreated || r.header.Get("X-A") != "b" {
+		t.Fatalf("unexpected headers %v", r.header)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	server, requests := testServer(t, http.StatusBadGateway, http.StatusTooManyRequests)
+	n := testNotifier(t, &Webhook{Name: "generic", URL: server.URL})
+	if err := n.deliver(n.webhooks[0], event); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expect delivered at the third attempt, got %d", len(requests))
+	}
+
+	server, requests = testServer(t, http.StatusBadRequest)
+	n = testNotifier(t, &Webhook{Name: "generic", URL: server.URL})
+	if err := n.deliver(n.webhooks[0], event); err == nil || len(requests) != 1 {
+		t.Fatalf("expect not retried if responded 400, got %d %v", len(requests), err)
+	}
+}
+
+func TestSubscribes(t *testing.T) {
+	w := &Webhook{Events: []string{ClusterUnreachable}}
+	if w.subscribes(DevSpaceCreated) || !w.subscribes(ClusterUnreachable) {
+		t.Fatal("unexpected subscription")
+	}
+	if _, err := newNotifier([]*Webhook{{Name: "x", Type: "teams", URL: "http://x"}}); err == nil {
+		t.Fatal("expect unknown type rejected")
+	}
+}
@@ -0,0 +1,91 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package oauth
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type github struct {
+	config
+	apiURL string
+}
+
+// newGithub base url is the address of GitHub Enterprise, e.g. https://github.example.com
+func newGithub(c config) *github {
+	g := &github{config: c, apiURL: "https://api.github.com"}
+	if c.BaseURL == "" {
+		g.BaseURL = "https://github.com"
+	} else {
+		g.apiURL = c.BaseURL + "/api/v3"
+	}
+	return g
+}
+
+func (g *github) AuthCodeURL(state string) string {
+	scope := "read:user user:email"
+	if g.AllowedOrg != "" {
+		scope += " read:org"
+	}
+	return g.authCodeURL(g.BaseURL+"/login/oauth/authorize", scope, state)
+}
+
+func (g *github) Identity(code string) (*Identity, error) {
+	accessToken, err := g.exchange(g.BaseURL+"/login/oauth/access_token", code)
+	if err != nil {
+		return nil, err
+	}
+
+	var user struct {
+		Login string `json:"login"`
+		Name  string `json:"name"`
+	}
+	if err := g.get(g.apiURL+"/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.get(g.apiURL+"/user/emails", accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Login: user.Login, Name: user.Name}
+	for _, e := range emails {
+		if e.Verified && (e.Primary || identity.Email == "") {
+			identity.Email = e.Email
+		}
+	}
+	if identity.Email == "" {
+		return nil, errors.New("No verified email found in GitHub account")
+	}
+
+	if g.AllowedOrg != "" {
+		var orgs []struct {
+			Login string `json:"login"`
+		}
+		if err := g.get(g.apiURL+"/user/orgs", accessToken, &orgs); err != nil {
+			return nil, err
+		}
+
+		allowed := false
+		for _, o := range orgs {
+			if strings.EqualFold(o.Login, g.AllowedOrg) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, ErrNotAllowed
+		}
+	}
+	return identity, nil
+}
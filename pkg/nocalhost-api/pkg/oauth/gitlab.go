@@ -0,0 +1,65 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package oauth
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+type gitlab struct {
+	config
+}
+
+// newGitlab base url is the address of self-hosted GitLab, defaults to gitlab.com
+func newGitlab(c config) *gitlab {
+	if c.BaseURL == "" {
+		c.BaseURL = "https://gitlab.com"
+	}
+	return &gitlab{config: c}
+}
+
+func (g *gitlab) AuthCodeURL(state string) string {
+	return g.authCodeURL(g.BaseURL+"/oauth/authorize", "read_user read_api", state)
+}
+
+func (g *gitlab) Identity(code string) (*Identity, error) {
+	accessToken, err := g.exchange(g.BaseURL+"/oauth/token", code)
+	if err != nil {
+		return nil, err
+	}
+
+	// the primary email of GitLab is always confirmed
+	var user struct {
+		Id          uint64  `json:"id"`
+		Username    string  `json:"username"`
+		Name        string  `json:"name"`
+		Email       string  `json:"email"`
+		ConfirmedAt *string `json:"confirmed_at"`
+	}
+	if err := g.get(g.BaseURL+"/api/v4/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.Email == "" || user.ConfirmedAt == nil {
+		return nil, errors.New("No confirmed email found in GitLab account")
+	}
+
+	if g.AllowedOrg != "" {
+		var member struct {
+			Id uint64 `json:"id"`
+		}
+		// 404 is returned if the user is not a member of the group or its ancestors
+		if err := g.get(
+			fmt.Sprintf("%s/api/v4/groups/%s/members/all/%d", g.BaseURL, url.PathEscape(g.AllowedOrg), user.Id),
+			accessToken, &member,
+		); err != nil || member.Id != user.Id {
+			return nil, ErrNotAllowed
+		}
+	}
+	return &Identity{Login: user.Username, Email: user.Email, Name: user.Name}, nil
+}
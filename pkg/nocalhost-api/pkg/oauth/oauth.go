@@ -0,0 +1,157 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+// Package oauth implements the social login with GitHub and GitLab
+// (including the self-hosted GitHub Enterprise and GitLab)
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	GITHUB = "github"
+	GITLAB = "gitlab"
+
+	// config keys under oauth.<provider>
+	OAUTH_CLIENT_ID     = "client_id"
+	OAUTH_CLIENT_SECRET = "client_secret"
+	OAUTH_REDIRECT_URL  = "redirect_url"
+	OAUTH_BASE_URL      = "base_url"
+	OAUTH_ALLOWED_ORG   = "allowed_org"
+)
+
+var ErrNotAllowed = errors.New("User is not a member of the allowed organization")
+
+// Identity the external identity, email is always verified
+type Identity struct {
+	Login string
+	Email string
+	Name  string
+}
+
+// Provider
+type Provider interface {
+	AuthCodeURL(state string) string
+	Identity(code string) (*Identity, error)
+}
+
+type config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	BaseURL      string
+	AllowedOrg   string
+
+	client *http.Client
+}
+
+func key(provider, name string) string {
+	return "oauth." + provider + "." + name
+}
+
+// Enabled returns true if the client id of provider configured
+func Enabled(provider string) bool {
+	return viper.GetString(key(provider, OAUTH_CLIENT_ID)) != ""
+}
+
+// Get the provider configured by config file
+func Get(provider string) (Provider, error) {
+	if provider != GITHUB && provider != GITLAB {
+		return nil, errors.Errorf("Unsupported oauth provider %s", provider)
+	}
+	if !Enabled(provider) {
+		return nil, errors.Errorf("Oauth provider %s is not configured", provider)
+	}
+
+	c := config{
+		ClientID:     viper.GetString(key(provider, OAUTH_CLIENT_ID)),
+		ClientSecret: viper.GetString(key(provider, OAUTH_CLIENT_SECRET)),
+		RedirectURL:  viper.GetString(key(provider, OAUTH_REDIRECT_URL)),
+		BaseURL:      strings.TrimSuffix(viper.GetString(key(provider, OAUTH_BASE_URL)), "/"),
+		AllowedOrg:   viper.GetString(key(provider, OAUTH_ALLOWED_ORG)),
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if provider == GITHUB {
+		return newGithub(c), nil
+	}
+	return newGitlab(c), nil
+}
+
+func (c *config) authCodeURL(endpoint, scope, state string) string {
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.ClientID},
+		"redirect_uri":  {c.RedirectURL},
+		"scope":         {scope},
+		"state":         {state},
+	}
+	return endpoint + "?" + v.Encode()
+}
+
+// exchange exchanges the authorization code for the access token
+func (c *config) exchange(endpoint, code string) (string, error) {
+	req, err := http.NewRequest(
+		http.MethodPost, endpoint, strings.NewReader(
+			url.Values{
+				"grant_type":    {"authorization_code"},
+				"code":          {code},
+				"redirect_uri":  {c.RedirectURL},
+				"client_id":     {c.ClientID},
+				"client_secret": {c.ClientSecret},
+			}.Encode(),
+		),
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return "", errors.Wrap(err, "Fail to exchange oauth code")
+	}
+	if result.AccessToken == "" {
+		return "", errors.Errorf("Fail to exchange oauth code: %s", result.Error)
+	}
+	return result.AccessToken, nil
+}
+
+// get request the api with the access token and decode the json response
+func (c *config) get(api, accessToken string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, api, nil)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return c.do(req, v)
+}
+
+func (c *config) do(req *http.Request, v interface{}) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("Request %s fail, status %d", req.URL.Path, resp.StatusCode))
+	}
+	return errors.Wrap(json.NewDecoder(resp.Body).Decode(v), "")
+}
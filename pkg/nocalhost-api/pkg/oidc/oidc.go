@@ -0,0 +1,321 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package oidc
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	OIDC_ISSUER        = "oidc.issuer"
+	OIDC_CLIENT_ID     = "oidc.client_id"
+	OIDC_CLIENT_SECRET = "oidc.client_secret"
+	OIDC_REDIRECT_URL  = "oidc.redirect_url"
+
+	jwksRefreshInterval = 10 * time.Minute
+)
+
+// Claims is the subset of the id_token claims nocalhost cares about
+type Claims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     *bool  `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Provider verify id_token issued by Keycloak/Okta/Dex or any
+// other standard OpenID Connect provider
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	client    *http.Client
+	discovery *discovery
+
+	lock      sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	keysFetch time.Time
+}
+
+var (
+	provider     *Provider
+	providerLock sync.Mutex
+)
+
+// Enabled returns true if oidc issuer is configured
+func Enabled() bool {
+	return viper.GetString(OIDC_ISSUER) != ""
+}
+
+// Default returns the provider configured by config file, the discovery
+// document will be loaded lazily at the first call
+func Default() (*Provider, error) {
+	providerLock.Lock()
+	defer providerLock.Unlock()
+
+	if provider != nil {
+		return provider, nil
+	}
+
+	if !Enabled() {
+		return nil, errors.New("OIDC issuer is not configured")
+	}
+
+	p := NewProvider(
+		viper.GetString(OIDC_ISSUER),
+		viper.GetString(OIDC_CLIENT_ID),
+		viper.GetString(OIDC_CLIENT_SECRET),
+		viper.GetString(OIDC_REDIRECT_URL),
+	)
+	if err := p.discover(); err != nil {
+		return nil, err
+	}
+
+	provider = p
+	return provider, nil
+}
+
+func NewProvider(issuer, clientId, clientSecret, redirectUrl string) *Provider {
+	return &Provider{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectUrl,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		keys:         map[string]*rsa.PublicKey{},
+	}
+}
+
+// AuthCodeURL returns the url to redirect the browser to for authentication
+func (p *Provider) AuthCodeURL(state string) string {
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {p.RedirectURL},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+	}
+	return p.discovery.AuthorizationEndpoint + "?" + v.Encode()
+}
+
+// Exchange exchanges the authorization code for the raw id_token
+func (p *Provider) Exchange(code string) (string, error) {
+	resp, err := p.client.PostForm(
+		p.discovery.TokenEndpoint, url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code},
+			"redirect_uri":  {p.RedirectURL},
+			"client_id":     {p.ClientID},
+			"client_secret": {p.ClientSecret},
+		},
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "Fail to exchange oidc code")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.New(fmt.Sprintf("Fail to exchange oidc code, status %d", resp.StatusCode))
+	}
+
+	var result struct {
+		IdToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", errors.Wrap(err, "Fail to decode oidc token response")
+	}
+
+	if result.IdToken == "" {
+		return "", errors.New("No id_token in oidc token response")
+	}
+	return result.IdToken, nil
+}
+
+// Verify validates the signature, issuer, audience and expiry
+// of the id_token and returns its claims
+func (p *Provider) Verify(idToken string) (*Claims, error) {
+	token, err := jwt.Parse(idToken, p.keyFunc)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid id_token")
+	}
+
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("Invalid id_token")
+	}
+
+	if !mapClaims.VerifyIssuer(p.Issuer, true) {
+		return nil, errors.New("Invalid id_token issuer")
+	}
+
+	if !mapClaims.VerifyAudience(p.ClientID, true) {
+		return nil, errors.New("Invalid id_token audience")
+	}
+
+	marshal, err := json.Marshal(mapClaims)
+	if err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	claims := &Claims{}
+	if err := json.Unmarshal(marshal, claims); err != nil {
+		return nil, errors.Wrap(err, "")
+	}
+
+	if claims.Email == "" {
+		return nil, errors.New("Email claim is required in id_token")
+	}
+
+	// the local account is bound by email, so the email must be verified by the provider
+	if claims.EmailVerified == nil || !*claims.EmailVerified {
+		return nil, errors.New("Email of id_token is not verified")
+	}
+	return claims, nil
+}
+
+func (p *Provider) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, jwt.ErrSignatureInvalid
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if key := p.key(kid); key != nil {
+		return key, nil
+	}
+
+	// key may be rotated, refresh and try again
+	if err := p.refreshKeys(); err != nil {
+		return nil, err
+	}
+
+	if key := p.key(kid); key != nil {
+		return key, nil
+	}
+	return nil, errors.New(fmt.Sprintf("Signing key %s not found", kid))
+}
+
+func (p *Provider) key(kid string) *rsa.PublicKey {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	// only one key, no need to match kid
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[kid]
+}
+
+func (p *Provider) discover() error {
+	resp, err := p.client.Get(p.Issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return errors.Wrap(err, "Fail to get oidc discovery document")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("Fail to get oidc discovery document, status %d", resp.StatusCode))
+	}
+
+	d := &discovery{}
+	if err := json.NewDecoder(resp.Body).Decode(d); err != nil {
+		return errors.Wrap(err, "Fail to decode oidc discovery document")
+	}
+
+	if strings.TrimSuffix(d.Issuer, "/") != p.Issuer {
+		return errors.New(fmt.Sprintf("Oidc issuer not match, expect %s but got %s", p.Issuer, d.Issuer))
+	}
+
+	p.discovery = d
+	return p.refreshKeys()
+}
+
+func (p *Provider) refreshKeys() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.keys) > 0 && time.Since(p.keysFetch) < jwksRefreshInterval {
+		return nil
+	}
+
+	resp, err := p.client.Get(p.discovery.JwksURI)
+	if err != nil {
+		return errors.Wrap(err, "Fail to get oidc jwks")
+	}
+	defer resp.Body.Close()
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return errors.Wrap(err, "Fail to decode oidc jwks")
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		key, err := k.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysFetch = time.Now()
+	return nil
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
@@ -0,0 +1,193 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func newTestProvider(t *testing.T, keys map[string]*rsa.PublicKey) (*Provider, *httptest.Server) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+
+	mux.HandleFunc(
+		"/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(
+				discovery{
+					Issuer:                server.URL,
+					AuthorizationEndpoint: server.URL + "/auth",
+					TokenEndpoint:         server.URL + "/token",
+					JwksURI:               server.URL + "/keys",
+				},
+			)
+		},
+	)
+	mux.HandleFunc(
+		"/keys", func(w http.ResponseWriter, r *http.Request) {
+			set := struct {
+				Keys []jwk `json:"keys"`
+			}{}
+			for kid, key := range keys {
+				set.Keys = append(
+					set.Keys, jwk{
+						Kid: kid,
+						Kty: "RSA",
+						N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+						E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+					},
+				)
+			}
+			_ = json.NewEncoder(w).Encode(set)
+		},
+	)
+
+	p := NewProvider(server.URL, "nocalhost", "secret", "http://localhost/callback")
+	if err := p.discover(); err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return p, server
+}
+
+func signIdToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, server := newTestProvider(t, map[string]*rsa.PublicKey{"k1": &key.PublicKey})
+	defer server.Close()
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"iss":            server.URL,
+			"aud":            "nocalhost",
+			"sub":            "user-1",
+			"email":          "anur@nocalhost.com",
+			"email_verified": true,
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	verified, err := p.Verify(signIdToken(t, key, "k1", claims(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verified.Subject != "user-1" || verified.Email != "anur@nocalhost.com" {
+		t.Errorf("unexpected claims %v", verified)
+	}
+
+	cases := map[string]string{
+		"missing email_verified": signIdToken(t, key, "k1", claims(jwt.MapClaims{"email_verified": nil})),
+		"unverified email":       signIdToken(t, key, "k1", claims(jwt.MapClaims{"email_verified": false})),
+		"missing email":          signIdToken(t, key, "k1", claims(jwt.MapClaims{"email": nil})),
+		"wrong issuer":           signIdToken(t, key, "k1", claims(jwt.MapClaims{"iss": "https://evil.com"})),
+		"wrong audience":         signIdToken(t, key, "k1", claims(jwt.MapClaims{"aud": "other"})),
+		"expired":                signIdToken(t, key, "k1", claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"unknown key":            signIdToken(t, other, "k2", claims(nil)),
+		"wrong signature":        signIdToken(t, other, "k1", claims(nil)),
+	}
+	for name, idToken := range cases {
+		if _, err := p.Verify(idToken); err == nil {
+			t.Errorf("%s: id_token should be invalid", name)
+		}
+	}
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(nil))
+	signed, _ := hs.SignedString([]byte("secret"))
+	if _, err := p.Verify(signed); err == nil {
+		t.Error("id_token not signed by rsa should be invalid")
+	}
+}
+
+func TestKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, server := newTestProvider(t, map[string]*rsa.PublicKey{"k1": &key.PublicKey})
+	defer server.Close()
+
+	if k := p.key("k1"); k == nil || k.N.Cmp(key.N) != 0 {
+		t.Error("key k1 should be found")
+	}
+	if p.key("k2") != nil {
+		t.Error("key k2 should not be found")
+	}
+	// the only key is used without kid
+	if k := p.key(""); k == nil || k.N.Cmp(key.N) != 0 {
+		t.Error("the only key should be used without kid")
+	}
+
+	p.keys["k2"] = &other.PublicKey
+	if p.key("") != nil {
+		t.Error("key without kid should not be guessed among multiple keys")
+	}
+}
+
+func TestKeyFuncRefresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string]*rsa.PublicKey{"k1": &key.PublicKey}
+	p, server := newTestProvider(t, keys)
+	defer server.Close()
+
+	// the key is rotated by the provider
+	delete(keys, "k1")
+	keys["k2"] = &key.PublicKey
+	p.keysFetch = time.Now().Add(-2 * jwksRefreshInterval)
+
+	token := &jwt.Token{Method: jwt.SigningMethodRS256, Header: map[string]interface{}{"kid": "k2"}}
+	if _, err := p.keyFunc(token); err != nil {
+		t.Errorf("the rotated key should be found by refreshing: %v", err)
+	}
+	token.Header["kid"] = "k3"
+	if _, err := p.keyFunc(token); err == nil {
+		t.Error("the unknown key should not be found")
+	}
+}
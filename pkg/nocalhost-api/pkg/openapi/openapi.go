@@ -0,0 +1,362 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	Version = "3.0.3"
+
+	definitionsRef = "#/definitions/"
+	schemasRef     = "#/components/schemas/"
+
+	defaultMediaType = "application/json"
+)
+
+// Document the OpenAPI 3 document, kept as plain json objects so that the
+// extensions of swag are passed through
+type Document map[string]interface{}
+
+// Convert the Swagger 2.0 document generated by swag from the annotations of
+// handlers to OpenAPI 3, body and form parameters are moved to request body,
+// the schemas of responses are wrapped by media types and definitions are
+// moved to components
+func Convert(swagger []byte) (Document, error) {
+	var source map[string]interface{}
+	if err := json.Unmarshal(swagger, &source); err != nil {
+		return nil, errors.Wrap(err, "parse swagger document")
+	}
+	if v, _ := source["swagger"].(string); v != "2.0" {
+		return nil, errors.Errorf("unsupported swagger version %q", v)
+	}
+
+	doc := Document{
+		"openapi": Version,
+		"info":    source["info"],
+		"paths":   map[string]interface{}{},
+	}
+	if servers := servers(source); len(servers) > 0 {
+		doc["servers"] = servers
+	}
+
+	components := map[string]interface{}{}
+	if definitions, ok := source["definitions"].(map[string]interface{}); ok {
+		components["schemas"] = rewriteRefs(definitions)
+	}
+	if securities, ok := source["securityDefinitions"].(map[string]interface{}); ok {
+		schemes := map[string]interface{}{}
+		for name, s := range securities {
+			schemes[name] = securityScheme(s.(map[string]interface{}))
+		}
+		components["securitySchemes"] = schemes
+	}
+	doc["components"] = components
+	for _, key := range []string{"security", "tags", "externalDocs"} {
+		if v, ok := source[key]; ok {
+			doc[key] = v
+		}
+	}
+
+	consumes := mediaTypes(source["consumes"])
+	produces := mediaTypes(source["produces"])
+	paths, _ := source["paths"].(map[string]interface{})
+	for path, item := range paths {
+		operations, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		converted := map[string]interface{}{}
+		for method, op := range operations {
+			operation, ok := op.(map[string]interface{})
+			if !ok {
+				converted[method] = op
+				continue
+			}
+			converted[method] = convertOperation(operation, consumes, produces)
+		}
+		doc.Paths()[path] = converted
+	}
+	return doc, nil
+}
+
+// Paths the path items of document
+func (d Document) Paths() map[string]interface{} {
+	paths, ok := d["paths"].(map[string]interface{})
+	if !ok {
+		paths = map[string]interface{}{}
+		d["paths"] = paths
+	}
+	return paths
+}
+
+// Schemas the schemas in components of document
+func (d Document) Schemas() map[string]interface{} {
+	components, ok := d["components"].(map[string]interface{})
+	if !ok {
+		components = map[string]interface{}{}
+		d["components"] = components
+	}
+	schemas, ok := components["schemas"].(map[string]interface{})
+	if !ok {
+		schemas = map[string]interface{}{}
+		components["schemas"] = schemas
+	}
+	return schemas
+}
+
+func servers(source map[string]interface{}) []interface{} {
+	host, _ := source["host"].(string)
+	basePath, _ := source["basePath"].(string)
+	if host == "" && (basePath == "" || basePath == "/") {
+		return nil
+	}
+	url := basePath
+	if host != "" {
+		schemes := mediaTypes(source["schemes"])
+		if len(schemes) == 0 {
+			schemes = []string{"http"}
+		}
+		url = schemes[0] + "://" + host + basePath
+	}
+	return []interface{}{map[string]interface{}{"url": url}}
+}
+
+func convertOperation(source map[string]interface{}, consumes, produces []string) map[string]interface{} {
+	operation := map[string]interface{}{}
+	for key, value := range source {
+		switch key {
+		case "consumes", "produces", "parameters", "responses", "schemes":
+		default:
+			operation[key] = value
+		}
+	}
+	if c := mediaTypes(source["consumes"]); len(c) > 0 {
+		consumes = c
+	}
+	if p := mediaTypes(source["produces"]); len(p) > 0 {
+		produces = p
+	}
+	if len(consumes) == 0 {
+		consumes = []string{defaultMediaType}
+	}
+	if len(produces) == 0 {
+		produces = []string{defaultMediaType}
+	}
+
+	var parameters []interface{}
+	var form []map[string]interface{}
+	parameterList, _ := source["parameters"].([]interface{})
+	for _, p := range parameterList {
+		parameter, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch parameter["in"] {
+		case "body":
+			body := map[string]interface{}{"content": content(consumes, rewriteRefs(parameter["schema"]))}
+			copyKeys(body, parameter, "description", "required")
+			operation["requestBody"] = body
+		case "formData":
+			form = append(form, parameter)
+		default:
+			parameters = append(parameters, convertParameter(parameter))
+		}
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+	if len(form) > 0 {
+		operation["requestBody"] = formBody(form)
+	}
+
+	responses := map[string]interface{}{}
+	sourceResponses, _ := source["responses"].(map[string]interface{})
+	for code, r := range sourceResponses {
+		response, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		converted := map[string]interface{}{"description": response["description"]}
+		if converted["description"] == nil {
+			converted["description"] = ""
+		}
+		if schema, ok := response["schema"]; ok {
+			converted["content"] = content(produces, rewriteRefs(schema))
+		}
+		if headers, ok := response["headers"].(map[string]interface{}); ok {
+			convertedHeaders := map[string]interface{}{}
+			for name, h := range headers {
+				header, _ := h.(map[string]interface{})
+				convertedHeaders[name] = convertParameter(header)
+			}
+			converted["headers"] = convertedHeaders
+		}
+		responses[code] = converted
+	}
+	if len(responses) == 0 {
+		responses["default"] = map[string]interface{}{"description": ""}
+	}
+	operation["responses"] = responses
+	return operation
+}
+
+// convertParameter moves the type of query, path and header parameters into
+// the schema of them
+func convertParameter(source map[string]interface{}) map[string]interface{} {
+	parameter := map[string]interface{}{}
+	copyKeys(parameter, source, "name", "in", "description", "required", "deprecated", "allowEmptyValue")
+	if parameter["in"] == "path" {
+		parameter["required"] = true
+	}
+	parameter["schema"] = parameterSchema(source)
+	return parameter
+}
+
+func parameterSchema(source map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+	for key, value := range source {
+		switch key {
+		case "type", "format", "enum", "default", "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+			"minLength", "maxLength", "pattern", "minItems", "maxItems", "uniqueItems", "multipleOf":
+			schema[key] = value
+		case "items":
+			if items, ok := value.(map[string]interface{}); ok {
+				schema["items"] = parameterSchema(items)
+			}
+		}
+	}
+	if schema["type"] == "file" {
+		schema["type"] = "string"
+		schema["format"] = "binary"
+	}
+	if len(schema) == 0 {
+		schema["type"] = "string"
+	}
+	return schema
+}
+
+// formBody the form parameters as properties of the request body, multipart
+// is required to upload files
+func formBody(parameters []map[string]interface{}) map[string]interface{} {
+	mediaType := "application/x-www-form-urlencoded"
+	properties := map[string]interface{}{}
+	var required []interface{}
+	for _, parameter := range parameters {
+		name, _ := parameter["name"].(string)
+		schema := parameterSchema(parameter)
+		if d, ok := parameter["description"]; ok {
+			schema["description"] = d
+		}
+		if parameter["type"] == "file" {
+			mediaType = "multipart/form-data"
+		}
+		properties[name] = schema
+		if r, _ := parameter["required"].(bool); r {
+			required = append(required, name)
+		}
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return map[string]interface{}{
+		"content": map[string]interface{}{mediaType: map[string]interface{}{"schema": schema}},
+	}
+}
+
+func content(mediaTypes []string, schema interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for _, t := range mediaTypes {
+		result[t] = map[string]interface{}{"schema": schema}
+	}
+	return result
+}
+
+// rewriteRefs point the references of definitions to components, and drop
+// the siblings of $ref which OpenAPI 3 ignores, swag adds type: object to
+// them, file and x-nullable of Swagger 2.0 are converted too
+func rewriteRefs(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := typed["$ref"].(string); ok {
+			return map[string]interface{}{"$ref": strings.Replace(ref, definitionsRef, schemasRef, 1)}
+		}
+		result := make(map[string]interface{}, len(typed))
+		for key, v := range typed {
+			switch key {
+			case "x-nullable":
+				result["nullable"] = v
+			default:
+				result[key] = rewriteRefs(v)
+			}
+		}
+		if result["type"] == "file" {
+			result["type"] = "string"
+			result["format"] = "binary"
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, v := range typed {
+			result[i] = rewriteRefs(v)
+		}
+		return result
+	}
+	return value
+}
+
+func securityScheme(source map[string]interface{}) map[string]interface{} {
+	scheme := map[string]interface{}{}
+	copyKeys(scheme, source, "description")
+	switch source["type"] {
+	case "basic":
+		scheme["type"] = "http"
+		scheme["scheme"] = "basic"
+	case "oauth2":
+		scheme["type"] = "oauth2"
+		flow := map[string]interface{}{}
+		copyKeys(flow, source, "authorizationUrl", "tokenUrl")
+		flow["scopes"] = source["scopes"]
+		if flow["scopes"] == nil {
+			flow["scopes"] = map[string]interface{}{}
+		}
+		flows := map[string]string{
+			"implicit": "implicit", "password": "password", "application": "clientCredentials",
+			"accessCode": "authorizationCode",
+		}
+		name, _ := source["flow"].(string)
+		scheme["flows"] = map[string]interface{}{flows[name]: flow}
+	default:
+		copyKeys(scheme, source, "type", "name", "in")
+	}
+	return scheme
+}
+
+func mediaTypes(value interface{}) []string {
+	list, _ := value.([]interface{})
+	var result []string
+	for _, v := range list {
+		if s, ok := v.(string); ok {
+			result = append(result, s)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func copyKeys(to, from map[string]interface{}, keys ...string) {
+	for _, key := range keys {
+		if v, ok := from[key]; ok {
+			to[key] = v
+		}
+	}
+}
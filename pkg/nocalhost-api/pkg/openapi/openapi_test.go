@@ -0,0 +1,156 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const swagger = `{
+    "swagger": "2.0",
+    "info": {"title": "Nocalhost docs api", "version": "1.0"},
+    "basePath": "/",
+    "paths": {
+        "/v1/cluster": {
+            "get": {
+                "produces": ["application/json"],
+                "summary": "Get the cluster list",
+                "parameters": [
+                    {"type": "string", "name": "Authorization", "in": "header", "required": true},
+                    {"type": "integer", "name": "page", "in": "query"}
+                ],
+                "responses": {
+                    "200": {
+                        "description": "{\"code\":0}",
+                        "schema": {"type": "array", "items": {"$ref": "#/definitions/model.ClusterList"}}
+                    }
+                }
+            },
+            "post": {
+                "consumes": ["application/json"],
+                "parameters": [
+                    {"name": "cluster", "in": "body", "required": true,
+                     "schema": {"type": "object", "$ref": "#/definitions/cluster.CreateClusterRequest"}}
+                ],
+                "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/api.Response"}}}
+            }
+        },
+        "/v1/users/import_csv": {
+            "post": {
+                "parameters": [
+                    {"type": "file", "name": "file", "in": "formData", "required": true},
+                    {"type": "string", "name": "team", "in": "formData"}
+                ],
+                "responses": {"200": {"description": "OK"}}
+            }
+        }
+    },
+    "definitions": {
+        "model.ClusterList": {
+            "type": "object",
+            "properties": {"id": {"type": "integer"}, "user": {"type": "object", "$ref": "#/definitions/model.User"}}
+        }
+    }
+}`
+
+func TestConvert(t *testing.T) {
+	doc, err := Convert([]byte(swagger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := json.Marshal(doc)
+	if strings.Contains(string(content), "#/definitions/") {
+		t.Fatal("references of definitions must be rewritten")
+	}
+	if doc["openapi"] != Version || doc["servers"] != nil {
+		t.Fatalf("unexpected document %s", content)
+	}
+
+	user := doc.Schemas()["model.ClusterList"].(map[string]interface{})["properties"].(map[string]interface{})["user"]
+	if ref := user.(map[string]interface{}); len(ref) != 1 || ref["$ref"] != "#/components/schemas/model.User" {
+		t.Fatalf("siblings of $ref must be dropped: %v", ref)
+	}
+
+	list := doc.Paths()["/v1/cluster"].(map[string]interface{})["get"].(map[string]interface{})
+	parameters := list["parameters"].([]interface{})
+	if schema := parameters[1].(map[string]interface{})["schema"]; schema.(map[string]interface{})["type"] != "integer" {
+		t.Fatalf("type of parameter must be moved into schema: %v", parameters[1])
+	}
+	ok := list["responses"].(map[string]interface{})["200"].(map[string]interface{})
+	if _, wrapped := ok["content"].(map[string]interface{})["application/json"]; !wrapped {
+		t.Fatalf("schema of response must be wrapped by media type: %v", ok)
+	}
+
+	create := doc.Paths()["/v1/cluster"].(map[string]interface{})["post"].(map[string]interface{})
+	if create["requestBody"] == nil || create["parameters"] != nil {
+		t.Fatalf("body parameter must be moved to request body: %v", create)
+	}
+
+	upload := doc.Paths()["/v1/users/import_csv"].(map[string]interface{})["post"].(map[string]interface{})
+	form := upload["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["multipart/form-data"]
+	if form == nil {
+		t.Fatalf("files must be uploaded by multipart form: %v", upload["requestBody"])
+	}
+	file := form.(map[string]interface{})["schema"].(map[string]interface{})["properties"].(map[string]interface{})["file"]
+	if file.(map[string]interface{})["format"] != "binary" {
+		t.Fatalf("unexpected schema of file %v", file)
+	}
+
+	if _, err := Convert([]byte(`{"openapi": "3.0.0"}`)); err == nil {
+		t.Fatal("only swagger 2.0 is supported")
+	}
+}
+
+func TestAddVersion2(t *testing.T) {
+	doc, err := Convert([]byte(swagger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AddVersion2(
+		doc, []Route{
+			{Method: "GET", Path: "/v2/clusters", Legacy: "/v1/cluster", List: true},
+			{Method: "POST", Path: "/v2/clusters", Legacy: "/v1/cluster"},
+			{Method: "DELETE", Path: "/v2/clusters/:id", Legacy: "/v1/cluster/:id"},
+		},
+	)
+
+	list := doc.Paths()["/v2/clusters"].(map[string]interface{})["get"].(map[string]interface{})
+	if list["operationId"] != "listClusters" {
+		t.Fatalf("unexpected operation id %v", list["operationId"])
+	}
+	var names []string
+	for _, p := range list["parameters"].([]interface{}) {
+		names = append(names, p.(map[string]interface{})["name"].(string))
+	}
+	if strings.Join(names, ",") != "Authorization,page,page_size" {
+		t.Fatalf("unexpected parameters %v", names)
+	}
+	content, _ := json.Marshal(list["responses"])
+	for _, expected := range []string{
+		`"items":{"$ref":"#/components/schemas/model.ClusterList"}`,
+		`"pagination":{"$ref":"#/components/schemas/v2.Pagination"}`,
+		`"default":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/v2.ErrorResponse"}}}`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Fatalf("%s is expected in responses %s", expected, content)
+		}
+	}
+
+	legacy := doc.Paths()["/v1/cluster"].(map[string]interface{})["get"].(map[string]interface{})
+	if legacy["operationId"] != nil {
+		t.Fatal("operation of legacy route must be kept")
+	}
+	create := doc.Paths()["/v2/clusters"].(map[string]interface{})["post"].(map[string]interface{})
+	if create["operationId"] != "createCluster" {
+		t.Fatalf("unexpected operation %v", create)
+	}
+	remove := doc.Paths()["/v2/clusters/{id}"].(map[string]interface{})["delete"].(map[string]interface{})
+	if remove["operationId"] != "deleteCluster" || remove["responses"] == nil {
+		t.Fatalf("routes not documented must be described too: %v", remove)
+	}
+}
@@ -0,0 +1,214 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// names of the schemas of /v2 envelopes in components
+const (
+	ErrorSchema      = "v2.ErrorResponse"
+	PaginationSchema = "v2.Pagination"
+)
+
+// Route the route of /v2 served by the handler of Legacy route in /v1, the
+// response of it is wrapped by the envelope of /v2, and paginated if List
+type Route struct {
+	Method string
+	Path   string
+	Legacy string
+	List   bool
+}
+
+var pathParam = regexp.MustCompile(`:([^/]+)`)
+
+// ginPath to the path template of OpenAPI, e.g. /v2/clusters/:id to
+// /v2/clusters/{id}
+func ginPath(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+// AddVersion2 describe the routes of /v2 by the operations of their legacy
+// routes documented, with the envelopes, pagination parameters and error
+// responses of /v2, the routes not documented yet are described with a
+// free-form data
+func AddVersion2(doc Document, routes []Route) {
+	schemas := doc.Schemas()
+	schemas[ErrorSchema] = errorSchema
+	schemas[PaginationSchema] = paginationSchema
+
+	paths := doc.Paths()
+	for _, route := range routes {
+		method := strings.ToLower(route.Method)
+		operation := map[string]interface{}{}
+		if legacy, ok := paths[ginPath(route.Legacy)].(map[string]interface{}); ok {
+			if op, ok := legacy[method].(map[string]interface{}); ok {
+				operation = deepCopy(op)
+			}
+		}
+		operation["operationId"] = operationId(route)
+
+		if route.List {
+			operation["parameters"] = append(withoutPageParameters(operation["parameters"]), pageParameters...)
+		}
+
+		responses, _ := operation["responses"].(map[string]interface{})
+		success, _ := responses["200"].(map[string]interface{})
+		var data interface{}
+		if success != nil {
+			data = dataSchema(success)
+		}
+		success = map[string]interface{}{
+			"description": "OK",
+			"content": map[string]interface{}{
+				defaultMediaType: map[string]interface{}{"schema": envelope(data, route.List)},
+			},
+		}
+		operation["responses"] = map[string]interface{}{
+			"200": success,
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content": map[string]interface{}{
+					defaultMediaType: map[string]interface{}{
+						"schema": map[string]interface{}{"$ref": schemasRef + ErrorSchema},
+					},
+				},
+			},
+		}
+
+		path := ginPath(route.Path)
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		item[method] = operation
+	}
+}
+
+// dataSchema the schema of data in the response of legacy route, the ones
+// documented with the envelope of /v1 have no schema of data
+func dataSchema(response map[string]interface{}) interface{} {
+	media, _ := response["content"].(map[string]interface{})
+	for _, m := range media {
+		schema, _ := m.(map[string]interface{})["schema"].(map[string]interface{})
+		if schema == nil || schema["$ref"] == schemasRef+"api.Response" || schema["type"] == "string" {
+			return nil
+		}
+		return schema
+	}
+	return nil
+}
+
+func envelope(data interface{}, list bool) map[string]interface{} {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	properties := map[string]interface{}{}
+	if list {
+		items := data
+		if schema, ok := data.(map[string]interface{}); ok && schema["type"] == "array" && schema["items"] != nil {
+			items = schema["items"]
+		}
+		properties["data"] = map[string]interface{}{"type": "array", "items": items}
+		properties["pagination"] = map[string]interface{}{"$ref": schemasRef + PaginationSchema}
+	} else {
+		properties["data"] = data
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// operationId e.g. listClusters, getCluster, createCluster, updateCluster
+// and deleteCluster, for the method names of typed clients
+func operationId(route Route) string {
+	segments := strings.Split(strings.Trim(route.Path, "/"), "/")
+	var resource string
+	for i := len(segments) - 1; i > 0; i-- {
+		if !strings.HasPrefix(segments[i], ":") {
+			resource = segments[i]
+			break
+		}
+	}
+	name := ""
+	for _, word := range strings.Split(resource, "_") {
+		if word != "" {
+			name += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+
+	verb := map[string]string{"POST": "create", "PUT": "update", "PATCH": "patch", "DELETE": "delete"}[route.Method]
+	switch {
+	case route.List:
+		return "list" + name
+	case verb == "":
+		verb = "get"
+	}
+	return verb + strings.TrimSuffix(name, "s")
+}
+
+// withoutPageParameters drop the page parameters of legacy route, the
+// pagination of /v2 is the same for all lists
+func withoutPageParameters(value interface{}) []interface{} {
+	parameters, _ := value.([]interface{})
+	var result []interface{}
+	for _, p := range parameters {
+		if parameter, ok := p.(map[string]interface{}); ok && parameter["in"] == "query" {
+			switch parameter["name"] {
+			case "page", "page_size", "size", "limit":
+				continue
+			}
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+func deepCopy(value map[string]interface{}) map[string]interface{} {
+	content, _ := json.Marshal(value)
+	result := map[string]interface{}{}
+	_ = json.Unmarshal(content, &result)
+	return result
+}
+
+var pageParameters = []interface{}{
+	map[string]interface{}{
+		"name": "page", "in": "query", "description": "Page number, start from 1",
+		"schema": map[string]interface{}{"type": "integer", "minimum": 1, "default": 1},
+	},
+	map[string]interface{}{
+		"name": "page_size", "in": "query", "description": "Page size, at most 200",
+		"schema": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 200, "default": 20},
+	},
+}
+
+var errorSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"error"},
+	"properties": map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":     "object",
+			"required": []interface{}{"code", "message"},
+			"properties": map[string]interface{}{
+				"code":       map[string]interface{}{"type": "integer", "description": "The errno of nocalhost"},
+				"message":    map[string]interface{}{"type": "string"},
+				"request_id": map[string]interface{}{"type": "string"},
+			},
+		},
+	},
+}
+
+var paginationSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"page", "page_size", "total"},
+	"properties": map[string]interface{}{
+		"page":      map[string]interface{}{"type": "integer"},
+		"page_size": map[string]interface{}{"type": "integer"},
+		"total":     map[string]interface{}{"type": "integer"},
+	},
+}
@@ -0,0 +1,158 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	POLICY_MIN_LENGTH      = "password_policy.min_length"
+	POLICY_REQUIRE_UPPER   = "password_policy.require_upper"
+	POLICY_REQUIRE_LOWER   = "password_policy.require_lower"
+	POLICY_REQUIRE_DIGIT   = "password_policy.require_digit"
+	POLICY_REQUIRE_SYMBOL  = "password_policy.require_symbol"
+	POLICY_DICTIONARY      = "password_policy.dictionary_check"
+	POLICY_DICTIONARY_FILE = "password_policy.dictionary_file"
+	POLICY_HISTORY         = "password_policy.history"
+
+	defaultMinLength = 6
+)
+
+// the most common passwords, used if no dictionary file is configured
+var commonPasswords = []string{
+	"123456", "1234567", "12345678", "123456789", "1234567890", "111111", "000000", "123123",
+	"654321", "666666", "888888", "password", "password1", "password123", "passw0rd", "qwerty",
+	"qwerty123", "qwertyuiop", "abc123", "abcd1234", "admin", "admin123", "administrator", "root",
+	"letmein", "welcome", "welcome1", "iloveyou", "monkey", "dragon", "master", "sunshine",
+	"princess", "football", "baseball", "superman", "trustno1", "1q2w3e4r", "1qaz2wsx", "zaq12wsx",
+	"changeme", "secret", "nocalhost",
+}
+
+// Policy
+type Policy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	// reject the password if it's in the dictionary, case insensitive
+	Dictionary map[string]struct{}
+
+	// the last n passwords can not be reused, zero means no limit
+	History int
+}
+
+var (
+	dictionary     map[string]struct{}
+	dictionaryFile string
+	dictionaryLock sync.Mutex
+)
+
+// Current returns the policy configured by config file
+func Current() *Policy {
+	p := &Policy{
+		MinLength:     viper.GetInt(POLICY_MIN_LENGTH),
+		RequireUpper:  viper.GetBool(POLICY_REQUIRE_UPPER),
+		RequireLower:  viper.GetBool(POLICY_REQUIRE_LOWER),
+		RequireDigit:  viper.GetBool(POLICY_REQUIRE_DIGIT),
+		RequireSymbol: viper.GetBool(POLICY_REQUIRE_SYMBOL),
+		History:       viper.GetInt(POLICY_HISTORY),
+	}
+	if p.MinLength <= 0 {
+		p.MinLength = defaultMinLength
+	}
+	if viper.GetBool(POLICY_DICTIONARY) {
+		p.Dictionary = loadDictionary(viper.GetString(POLICY_DICTIONARY_FILE))
+	}
+	return p
+}
+
+// Validate returns all the violations of the policy
+func (p *Policy) Validate(password string) error {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		violations = append(violations, "an uppercase letter")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "a lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "a symbol")
+	}
+
+	if len(violations) > 0 {
+		return errors.New("Password must contain " + strings.Join(violations, ", "))
+	}
+
+	if _, ok := p.Dictionary[strings.ToLower(password)]; ok {
+		return errors.New("Password is too common, please choose another one")
+	}
+	return nil
+}
+
+// loadDictionary the dictionary file contains one password per line,
+// it's loaded only once unless the file is changed in config
+func loadDictionary(file string) map[string]struct{} {
+	dictionaryLock.Lock()
+	defer dictionaryLock.Unlock()
+
+	if dictionary != nil && dictionaryFile == file {
+		return dictionary
+	}
+
+	result := map[string]struct{}{}
+	for _, p := range commonPasswords {
+		result[p] = struct{}{}
+	}
+
+	if file != "" {
+		if f, err := os.Open(file); err == nil {
+			scanner := bufio.NewScanner(f)
+			for scanner.Scan() {
+				if line := strings.TrimSpace(scanner.Text()); line != "" {
+					result[strings.ToLower(line)] = struct{}{}
+				}
+			}
+			_ = f.Close()
+		}
+	}
+
+	dictionary = result
+	dictionaryFile = file
+	return dictionary
+}
@@ -0,0 +1,51 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package password
+
+import (
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policy := &Policy{
+		MinLength:     8,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		Dictionary:    map[string]struct{}{"p@ssw0rd!": {}},
+	}
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"Ab1!", true},
+		{"abcdefg1!", true},
+		{"ABCDEFG1!", true},
+		{"Abcdefgh!", true},
+		{"Abcdefgh1", true},
+		{"P@ssw0rd!", true},
+		{"Abcdefg1!", false},
+		{"Correct Horse 1", false},
+	}
+
+	for _, tt := range tests {
+		if err := policy.Validate(tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := Current()
+	if err := policy.Validate("12345"); err == nil {
+		t.Errorf("password shorter than %d should be rejected", defaultMinLength)
+	}
+	if err := policy.Validate("123456"); err != nil {
+		t.Errorf("dictionary check should be disabled by default, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+// Rate of token bucket, the bucket holds Burst tokens at most and is refilled
+// by Limit tokens per second
+type Rate struct {
+	Limit float64
+	Burst int
+}
+
+// Valid whether the rate limits anything
+func (r Rate) Valid() bool {
+	return r.Limit > 0 && r.Burst > 0
+}
+
+// full the duration to refill the empty bucket
+func (r Rate) full() time.Duration {
+	return time.Duration(float64(r.Burst) / r.Limit * float64(time.Second))
+}
+
+// Limiter takes the tokens from the buckets of keys
+type Limiter interface {
+	// Take a token from the bucket of key, retryAfter is the duration until
+	// a token is refilled if not allowed
+	Take(key string, rate Rate) (allowed bool, retryAfter time.Duration, err error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Duration
+}
+
+// take refill the bucket until now and take a token of it
+func (b *bucket) take(now time.Time, rate Rate) (bool, time.Duration) {
+	b.tokens = math.Min(float64(rate.Burst), b.tokens+now.Sub(b.last).Seconds()*rate.Limit)
+	b.last = now
+	b.full = rate.full()
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / rate.Limit * float64(time.Second))
+}
+
+// memoryLimiter the buckets in memory, for the single replica
+type memoryLimiter struct {
+	lock      sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter the limiter of buckets in memory, the replicas limit
+// the requests separately
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+func (m *memoryLimiter) Take(key string, rate Rate) (bool, time.Duration, error) {
+	now := m.now()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rate.Burst), last: now}
+		m.buckets[key] = b
+	}
+	allowed, retryAfter := b.take(now, rate)
+	return allowed, retryAfter, nil
+}
+
+// sweep the buckets refilled to full, the same as the ones never taken
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if now.Sub(b.last) > b.full {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// takeScript refills and takes the bucket in redis atomically, the bucket
+// expires once it would be full
+var takeScript = redis.NewScript(
+	`
+local limit = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'last')
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil or last == nil then
+	tokens = burst
+	last = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - last) / 1000 * limit)
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / limit * 1000)
+end
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'last', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / limit * 1000) + 1000)
+return {allowed, wait}
+`,
+)
+
+// redisLimiter the buckets in redis shared by the replicas
+type redisLimiter struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisLimiter the limiter of buckets in redis, shared by the replicas,
+// the keys are prefixed by prefix
+func NewRedisLimiter(client *redis.Client, prefix string) Limiter {
+	return &redisLimiter{client: client, prefix: prefix, now: time.Now}
+}
+
+func (r *redisLimiter) Take(key string, rate Rate) (bool, time.Duration, error) {
+	now := r.now().UnixNano() / int64(time.Millisecond)
+	result, err := takeScript.Run(r.client, []string{r.prefix + key}, rate.Limit, rate.Burst, now).Result()
+	if err != nil {
+		return false, 0, errors.Wrap(err, "take token from redis")
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, errors.New("unexpected result of taking token from redis")
+	}
+	allowed, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+	return allowed == 1, time.Duration(wait) * time.Millisecond, nil
+}
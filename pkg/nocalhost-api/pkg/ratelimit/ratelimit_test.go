@@ -0,0 +1,71 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/go-redis/redis"
+)
+
+func testLimiter(t *testing.T, limiter Limiter, advance func(time.Duration)) {
+	rate := Rate{Limit: 1, Burst: 2}
+	for i := 0; i < 2; i++ {
+		if allowed, _, err := limiter.Take("user:1", rate); err != nil || !allowed {
+			t.Fatalf("expect burst allowed, got %v %v", allowed, err)
+		}
+	}
+	allowed, retryAfter, err := limiter.Take("user:1", rate)
+	if err != nil || allowed {
+		t.Fatalf("expect limited, got %v %v", allowed, err)
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatalf("unexpected retry after %v", retryAfter)
+	}
+	if allowed, _, _ := limiter.Take("user:2", rate); !allowed {
+		t.Fatal("expect the buckets of keys separated")
+	}
+
+	advance(time.Second)
+	if allowed, _, _ := limiter.Take("user:1", rate); !allowed {
+		t.Fatal("expect a token refilled")
+	}
+	if allowed, _, _ := limiter.Take("user:1", rate); allowed {
+		t.Fatal("expect only a token refilled")
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	limiter := NewMemoryLimiter().(*memoryLimiter)
+	limiter.now = func() time.Time { return now }
+	testLimiter(t, limiter, func(d time.Duration) { now = now.Add(d) })
+
+	now = now.Add(time.Hour)
+	_, _, _ = limiter.Take("user:3", Rate{Limit: 1, Burst: 2})
+	if len(limiter.buckets) != 1 {
+		t.Fatalf("expect the buckets refilled swept, got %d", len(limiter.buckets))
+	}
+}
+
+func TestRedisLimiter(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mr.Close()
+
+	now := time.Unix(1000, 0)
+	limiter := NewRedisLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "nocalhost:ratelimit:").(*redisLimiter)
+	limiter.now = func() time.Time { return now }
+	testLimiter(t, limiter, func(d time.Duration) { now = now.Add(d) })
+
+	if !mr.Exists("nocalhost:ratelimit:user:1") {
+		t.Fatalf("expect bucket in redis, got %v", mr.Keys())
+	}
+}
@@ -0,0 +1,180 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nocalhost-api/global"
+)
+
+const (
+	REGISTRY_CREDENTIAL_SYNC_INTERVAL = "registry_credential.sync_interval"
+	defaultSyncInterval               = 10 * time.Minute
+
+	// PullSecretLabel marks the image pull secrets distributed by nocalhost,
+	// the ones of credentials deleted are removed by it
+	PullSecretLabel       = "nocalhost.dev/registry-credential"
+	pullSecretPrefix      = "nocalhost-registry-"
+	defaultServiceAccount = "default"
+)
+
+// SyncInterval the interval of refreshing the image pull secrets in dev spaces
+func SyncInterval() time.Duration {
+	if d := viper.GetDuration(REGISTRY_CREDENTIAL_SYNC_INTERVAL); d > 0 {
+		return d
+	}
+	return defaultSyncInterval
+}
+
+// Credential the credential of private registry distributed to dev spaces
+type Credential struct {
+	Name     string
+	Server   string
+	Username string
+	Password string
+	Email    string
+}
+
+// PullSecretName the image pull secret of credential in dev space, the name of
+// credential must be a DNS-1123 label
+func PullSecretName(name string) string {
+	return pullSecretPrefix + name
+}
+
+// DockerConfigJson the content of .dockerconfigjson of credential
+func DockerConfigJson(credential *Credential) ([]byte, error) {
+	auth := map[string]string{
+		"username": credential.Username,
+		"password": credential.Password,
+		"auth":     base64.StdEncoding.EncodeToString([]byte(credential.Username + ":" + credential.Password)),
+	}
+	if credential.Email != "" {
+		auth["email"] = credential.Email
+	}
+	return json.Marshal(map[string]interface{}{"auths": map[string]interface{}{credential.Server: auth}})
+}
+
+// ApplyPullSecrets create or refresh the image pull secrets of credentials
+// in namespace, remove the ones of credentials deleted, and patch the
+// default service account to use them, so that the dev images of private
+// registry can be pulled
+func ApplyPullSecrets(client kubernetes.Interface, namespace string, credentials []*Credential) error {
+	api := client.CoreV1().Secrets(namespace)
+	names := map[string]bool{}
+	for _, credential := range credentials {
+		content, err := DockerConfigJson(credential)
+		if err != nil {
+			return errors.Wrapf(err, "encode registry credential %s", credential.Name)
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      PullSecretName(credential.Name),
+				Namespace: namespace,
+				Labels: map[string]string{
+					global.NocalhostCreateByLabel: global.NocalhostName,
+					PullSecretLabel:               "true",
+				},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: content},
+		}
+		names[secret.Name] = true
+
+		_, err = api.Create(context.TODO(), secret, metav1.CreateOptions{})
+		if k8serrors.IsAlreadyExists(err) {
+			_, err = api.Update(context.TODO(), secret, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			return errors.Wrapf(err, "apply image pull secret %s in %s", secret.Name, namespace)
+		}
+	}
+
+	existing, err := api.List(context.TODO(), metav1.ListOptions{LabelSelector: PullSecretLabel + "=true"})
+	if err != nil {
+		return errors.Wrapf(err, "list image pull secrets in %s", namespace)
+	}
+	for _, secret := range existing.Items {
+		if names[secret.Name] {
+			continue
+		}
+		if err := api.Delete(
+			context.TODO(), secret.Name, metav1.DeleteOptions{},
+		); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "delete image pull secret %s in %s", secret.Name, namespace)
+		}
+	}
+
+	return patchServiceAccount(client, namespace, names)
+}
+
+// patchServiceAccount the pull secrets of others are kept, the default
+// service account is created if not created by controller yet
+func patchServiceAccount(client kubernetes.Interface, namespace string, names map[string]bool) error {
+	api := client.CoreV1().ServiceAccounts(namespace)
+	sa, err := api.Get(context.TODO(), defaultServiceAccount, metav1.GetOptions{})
+	create := k8serrors.IsNotFound(err)
+	if create {
+		sa = &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: defaultServiceAccount, Namespace: namespace}}
+		err = nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "get service account %s in %s", defaultServiceAccount, namespace)
+	}
+
+	var pullSecrets []corev1.LocalObjectReference
+	changed := false
+	for _, ref := range sa.ImagePullSecrets {
+		if strings.HasPrefix(ref.Name, pullSecretPrefix) && !names[ref.Name] {
+			changed = true
+			continue
+		}
+		pullSecrets = append(pullSecrets, ref)
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		if !hasPullSecret(sa.ImagePullSecrets, name) {
+			pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: name})
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	sa.ImagePullSecrets = pullSecrets
+
+	if create {
+		_, err = api.Create(context.TODO(), sa, metav1.CreateOptions{})
+	} else {
+		_, err = api.Update(context.TODO(), sa, metav1.UpdateOptions{})
+	}
+	return errors.Wrapf(err, "patch image pull secrets of service account %s in %s", defaultServiceAccount, namespace)
+}
+
+func hasPullSecret(refs []corev1.LocalObjectReference, name string) bool {
+	for _, ref := range refs {
+		if ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApplyPullSecrets(t *testing.T) {
+	ctx := context.TODO()
+	client := fake.NewSimpleClientset(
+		&corev1.ServiceAccount{
+			ObjectMeta:       metav1.ObjectMeta{Name: "default", Namespace: "dev"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "manual"}},
+		},
+	)
+	harbor := &Credential{Name: "harbor", Server: "harbor.example.com", Username: "dev", Password: "secret"}
+	gcr := &Credential{Name: "gcr", Server: "gcr.io", Username: "_json_key", Password: "{}"}
+
+	if err := ApplyPullSecrets(client, "dev", []*Credential{harbor, gcr}); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := client.CoreV1().Secrets("dev").Get(ctx, "nocalhost-registry-harbor", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := struct {
+		Auths map[string]map[string]string `json:"auths"`
+	}{}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+		t.Fatal(err)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson || config.Auths["harbor.example.com"]["auth"] != "ZGV2OnNlY3JldA==" {
+		t.Fatalf("unexpected secret %s %v", secret.Type, config)
+	}
+
+	sa, _ := client.CoreV1().ServiceAccounts("dev").Get(ctx, "default", metav1.GetOptions{})
+	if len(sa.ImagePullSecrets) != 3 || sa.ImagePullSecrets[0].Name != "manual" ||
+		sa.ImagePullSecrets[1].Name != "nocalhost-registry-gcr" {
+		t.Fatalf("unexpected pull secrets %v", sa.ImagePullSecrets)
+	}
+
+	// gcr is deleted, the password of harbor is refreshed
+	harbor.Password = "rotated"
+	if err := ApplyPullSecrets(client, "dev", []*Credential{harbor}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Secrets("dev").Get(ctx, "nocalhost-registry-gcr", metav1.GetOptions{}); err == nil {
+		t.Fatal("the secret of credential deleted must be removed")
+	}
+	sa, _ = client.CoreV1().ServiceAccounts("dev").Get(ctx, "default", metav1.GetOptions{})
+	if len(sa.ImagePullSecrets) != 2 || sa.ImagePullSecrets[1].Name != "nocalhost-registry-harbor" {
+		t.Fatalf("unexpected pull secrets %v", sa.ImagePullSecrets)
+	}
+
+	// the default service account not created by controller yet
+	if err := ApplyPullSecrets(client, "test", []*Credential{harbor}); err != nil {
+		t.Fatal(err)
+	}
+	if sa, err := client.CoreV1().ServiceAccounts("test").Get(ctx, "default", metav1.GetOptions{}); err != nil ||
+		len(sa.ImagePullSecrets) != 1 {
+		t.Fatalf("unexpected service account %v %v", sa, err)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package setupcluster
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"nocalhost/internal/nocalhost-api/global"
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+)
+
+const (
+	HEALTH_PROBE_INTERVAL = "cluster_health.probe_interval"
+	HEALTH_MIN_VERSION    = "cluster_health.min_version"
+	HEALTH_MAX_VERSION    = "cluster_health.max_version"
+
+	defaultProbeInterval = 5 * time.Minute
+	defaultMinVersion    = "1.16"
+
+	nocalhostWebhookName    = "nocalhost-mutating.coding.net"
+	nocalhostWebhookService = "nocalhost-sidecar-injector-controller"
+)
+
+// Health status of cluster
+const (
+	HealthHealthy     = "healthy"
+	HealthDegraded    = "degraded"
+	HealthUnreachable = "unreachable"
+)
+
+// Health is the result of probing a cluster
+type Health struct {
+	Status        string
+	Message       string
+	ServerVersion string
+	Latency       time.Duration
+}
+
+// ProbeInterval returns the interval of the background health probe
+func ProbeInterval() time.Duration {
+	if d := viper.GetDuration(HEALTH_PROBE_INTERVAL); d > 0 {
+		return d
+	}
+	return defaultProbeInterval
+}
+
+// Probe check the api server reachability, the version skew and
+// the health of nocalhost-dep and its admission webhook
+func Probe(kubeConfig []byte) *Health {
+	start := time.Now()
+	goClient, err := clientgo.NewAdminGoClient(kubeConfig)
+	if err != nil {
+		return &Health{Status: HealthUnreachable, Message: err.Error()}
+	}
+
+	info, err := goClient.GetClusterVersion()
+	if err != nil {
+		return &Health{Status: HealthUnreachable, Message: err.Error()}
+	}
+
+	health := &Health{ServerVersion: info.GitVersion, Latency: time.Since(start)}
+	var problems []string
+
+	minVersion := viper.GetString(HEALTH_MIN_VERSION)
+	if minVersion == "" {
+		minVersion = defaultMinVersion
+	}
+	if problem := VersionSkew(info.GitVersion, minVersion, viper.GetString(HEALTH_MAX_VERSION)); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	if err := goClient.GetDepDeploymentStatus(); err != nil {
+		problems = append(problems, err.Error())
+	} else if problem := depVersionSkew(goClient); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	if problem := webhookProblem(goClient); problem != "" {
+		problems = append(problems, problem)
+	}
+
+	health.Status = HealthHealthy
+	if len(problems) > 0 {
+		health.Status = HealthDegraded
+		health.Message = strings.Join(problems, "; ")
+	}
+	return health
+}
+
+// VersionSkew returns the problem if the server version is out of the supported range,
+// the max version is ignored if empty
+func VersionSkew(serverVersion, minVersion, maxVersion string) string {
+	server, err := version.ParseGeneric(serverVersion)
+	if err != nil {
+		return fmt.Sprintf("unknown kubernetes version %s", serverVersion)
+	}
+
+	if min, err := version.ParseGeneric(minVersion); err == nil && server.LessThan(min) {
+		return fmt.Sprintf("kubernetes %s is older than the minimum supported %s", serverVersion, minVersion)
+	}
+
+	if max, err := version.ParseGeneric(maxVersion); err == nil &&
+		(server.Major() > max.Major() || server.Major() == max.Major() && server.Minor() > max.Minor()) {
+		return fmt.Sprintf("kubernetes %s is newer than the maximum supported %s", serverVersion, maxVersion)
+	}
+	return ""
+}
+
+// depVersionSkew compare the image of nocalhost-dep with the one matched with nocalhost-api
+func depVersionSkew(goClient *clientgo.GoClient) string {
+	deployment, err := goClient.GetDeployment(global.NocalhostSystemNamespace, global.NocalhostDepName)
+	if err != nil || len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return ""
+	}
+
+	image := deployment.Spec.Template.Spec.Containers[0].Image
+	if expected := goClient.MatchedArtifactVersion(clientgo.Dep, ""); image != expected {
+		return fmt.Sprintf("nocalhost-dep image %s differs from %s", image, expected)
+	}
+	return ""
+}
+
+func webhookProblem(goClient *clientgo.GoClient) string {
+	if _, err := goClient.GetMutatingWebhookConfiguration(nocalhostWebhookName); err != nil {
+		return "nocalhost admission webhook not found"
+	}
+
+	endpoints, err := goClient.GetEndpoints(global.NocalhostSystemNamespace, nocalhostWebhookService)
+	if err != nil {
+		return "nocalhost admission webhook service not found"
+	}
+
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return ""
+		}
+	}
+	return "nocalhost admission webhook has no ready endpoint"
+}
@@ -0,0 +1,30 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package setupcluster
+
+import "testing"
+
+func TestVersionSkew(t *testing.T) {
+	tests := []struct {
+		server string
+		min    string
+		max    string
+		skewed bool
+	}{
+		{"v1.21.2", "1.16", "", false},
+		{"v1.21.2-eks-0389ca3", "1.16", "1.22", false},
+		{"v1.22.0", "1.16", "1.22", false},
+		{"v1.15.12", "1.16", "", true},
+		{"v1.23.1+k3s1", "1.16", "1.22", true},
+		{"unknown", "1.16", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := VersionSkew(tt.server, tt.min, tt.max); (got != "") != tt.skewed {
+			t.Errorf("VersionSkew(%s, %s, %s) = %q, skewed %v", tt.server, tt.min, tt.max, got, tt.skewed)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package shutdown
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+// Hook stops a server or component in the drain, it returns after the work
+// in flight is done, or ctx is done
+type Hook func(ctx context.Context) error
+
+type hook struct {
+	name string
+	stop Hook
+}
+
+var (
+	lock     sync.Mutex
+	stopping = make(chan struct{})
+	stopped  bool
+	jobs     sync.WaitGroup
+	hooks    []hook
+)
+
+// Register the hook called in the drain, e.g. the graceful stop of servers
+func Register(name string, stop Hook) {
+	lock.Lock()
+	defer lock.Unlock()
+	hooks = append(hooks, hook{name: name, stop: stop})
+}
+
+// Begin the shutdown, the jobs are not started anymore and the server is
+// reported not ready, so that no more requests are routed to it
+func Begin() {
+	lock.Lock()
+	defer lock.Unlock()
+	if !stopped {
+		stopped = true
+		close(stopping)
+	}
+}
+
+// Stopping closed once the shutdown begins, the loops of background jobs
+// select it to exit between runs
+func Stopping() <-chan struct{} {
+	return stopping
+}
+
+// IsStopping whether the shutdown began
+func IsStopping() bool {
+	select {
+	case <-stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run the job unless the shutdown began, the drain waits for the jobs
+// running, false if the job is not run
+func Run(job func()) bool {
+	lock.Lock()
+	if stopped {
+		lock.Unlock()
+		return false
+	}
+	jobs.Add(1)
+	lock.Unlock()
+
+	defer jobs.Done()
+	job()
+	return true
+}
+
+// Drain begin the shutdown, call the hooks concurrently and wait for the
+// jobs running until ctx is done, the hooks see the same deadline
+func Drain(ctx context.Context) error {
+	Begin()
+
+	lock.Lock()
+	registered := append([]hook(nil), hooks...)
+	lock.Unlock()
+
+	wg := sync.WaitGroup{}
+	errs := make(chan error, len(registered))
+	for _, h := range registered {
+		wg.Add(1)
+		go func(h hook) {
+			defer wg.Done()
+			if err := h.stop(ctx); err != nil {
+				errs <- errors.Wrapf(err, "stop %s", h.name)
+				return
+			}
+			log.Infof("%s stopped", h.name)
+		}(h)
+	}
+	wg.Wait()
+	close(errs)
+
+	done := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "wait for background jobs")
+	}
+
+	if err, ok := <-errs; ok {
+		return err
+	}
+	return nil
+}
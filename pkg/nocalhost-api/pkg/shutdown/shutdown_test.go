@@ -0,0 +1,54 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package shutdown
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+func TestDrain(t *testing.T) {
+	log.NewLogger(&log.Config{}, log.InstanceZapLogger)
+
+	var finished, stopped int32
+	started := make(chan struct{})
+	go Run(
+		func() {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			atomic.StoreInt32(&finished, 1)
+		},
+	)
+	<-started
+	Register(
+		"server", func(ctx context.Context) error {
+			atomic.StoreInt32(&stopped, 1)
+			return nil
+		},
+	)
+
+	if IsStopping() {
+		t.Fatal("expect not stopping before drain")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&finished) != 1 || atomic.LoadInt32(&stopped) != 1 {
+		t.Fatal("expect the job finished and the hook called before drained")
+	}
+	if !IsStopping() {
+		t.Fatal("expect stopping")
+	}
+	if Run(func() { t.Fatal("expect no job run after drained") }) {
+		t.Fatal("expect job refused")
+	}
+}
@@ -0,0 +1,151 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package sleep
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	SLEEP_INTERVAL = "dev_space_sleep.interval"
+	SLEEP_TIMEZONE = "dev_space_sleep.timezone"
+
+	defaultInterval = 5 * time.Minute
+)
+
+// Reason of dev space sleeping
+const (
+	ReasonSchedule = "schedule"
+	ReasonInactive = "inactive"
+	ReasonManual   = "manual"
+)
+
+// Schedule the dev space sleeps from Sleep to Wake at the days, the
+// window spans midnight if Wake is not after Sleep, a whole day if equal
+type Schedule struct {
+	// Days of week the window starts, 0 is Sunday
+	Days  []time.Weekday `json:"days"`
+	Sleep string         `json:"sleep" example:"20:00"`
+	Wake  string         `json:"wake" example:"08:00"`
+}
+
+// Config of dev space sleeping, it does not sleep if empty
+type Config struct {
+	Schedules []Schedule `json:"schedules"`
+	// InactiveHours sleep after no activity for so many hours, e.g. IDE
+	// connection, file sync, port-forward and kubectl, disabled if 0
+	InactiveHours int `json:"inactive_hours"`
+}
+
+// Interval returns the interval of checking the dev spaces to sleep or wake
+func Interval() time.Duration {
+	if d := viper.GetDuration(SLEEP_INTERVAL); d > 0 {
+		return d
+	}
+	return defaultInterval
+}
+
+// Location of the schedules, default to the local timezone of nocalhost-api
+func Location() *time.Location {
+	if tz := viper.GetString(SLEEP_TIMEZONE); tz != "" {
+		if loc, err := time.LoadLocation(tz); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+func (c *Config) Validate() error {
+	if c.InactiveHours < 0 {
+		return errors.New("inactive_hours can not be negative")
+	}
+	for _, s := range c.Schedules {
+		if len(s.Days) == 0 {
+			return errors.New("days of schedule is required")
+		}
+		for _, d := range s.Days {
+			if d < time.Sunday || d > time.Saturday {
+				return errors.Errorf("invalid day %d", d)
+			}
+		}
+		if _, err := parseClock(s.Sleep); err != nil {
+			return err
+		}
+		if _, err := parseClock(s.Wake); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) IsEmpty() bool {
+	return c == nil || (len(c.Schedules) == 0 && c.InactiveHours == 0)
+}
+
+// Window returns the start of the schedule window now is in
+func (c *Config) Window(now time.Time) (time.Time, bool) {
+	var start time.Time
+	var in bool
+	for _, s := range c.Schedules {
+		if t, ok := s.window(now); ok && (!in || t.After(start)) {
+			start, in = t, true
+		}
+	}
+	return start, in
+}
+
+// Inactive returns if no activity since so many hours
+func (c *Config) Inactive(now, lastActive time.Time) bool {
+	return c.InactiveHours > 0 && now.Sub(lastActive) >= time.Duration(c.InactiveHours)*time.Hour
+}
+
+func (s *Schedule) window(now time.Time) (time.Time, bool) {
+	sleep, err := parseClock(s.Sleep)
+	if err != nil {
+		return time.Time{}, false
+	}
+	wake, err := parseClock(s.Wake)
+	if err != nil {
+		return time.Time{}, false
+	}
+	duration := wake - sleep
+	if duration <= 0 {
+		duration += 24 * time.Hour
+	}
+
+	// the window may start today or yesterday
+	for _, offset := range []int{0, -1} {
+		day := now.AddDate(0, 0, offset)
+		if !s.hasDay(day.Weekday()) {
+			continue
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, now.Location()).Add(sleep)
+		if !now.Before(start) && now.Before(start.Add(duration)) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (s *Schedule) hasDay(day time.Weekday) bool {
+	for _, d := range s.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, errors.Errorf("invalid time %s, the format is HH:MM", clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,122 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package sleep
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWindow(t *testing.T) {
+	c := &Config{
+		Schedules: []Schedule{
+			// nights of workdays
+			{Days: []time.Weekday{1, 2, 3, 4, 5}, Sleep: "20:00", Wake: "08:00"},
+			// weekends
+			{Days: []time.Weekday{0, 6}, Sleep: "00:00", Wake: "00:00"},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 2021-06-07 is Monday
+	cases := []struct {
+		now   time.Time
+		in    bool
+		start time.Time
+	}{
+		{time.Date(2021, 6, 7, 12, 0, 0, 0, time.UTC), false, time.Time{}},
+		{time.Date(2021, 6, 7, 21, 0, 0, 0, time.UTC), true, time.Date(2021, 6, 7, 20, 0, 0, 0, time.UTC)},
+		{time.Date(2021, 6, 8, 7, 59, 0, 0, time.UTC), true, time.Date(2021, 6, 7, 20, 0, 0, 0, time.UTC)},
+		{time.Date(2021, 6, 8, 8, 0, 0, 0, time.UTC), false, time.Time{}},
+		// Saturday, the window of Friday night is overlapped
+		{time.Date(2021, 6, 12, 7, 0, 0, 0, time.UTC), true, time.Date(2021, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2021, 6, 13, 23, 0, 0, 0, time.UTC), true, time.Date(2021, 6, 13, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, cs := range cases {
+		start, in := c.Window(cs.now)
+		if in != cs.in || !start.Equal(cs.start) {
+			t.Errorf("window of %v is %v %v", cs.now, start, in)
+		}
+	}
+
+	c.InactiveHours = 2
+	now := time.Now()
+	if c.Inactive(now, now.Add(-time.Hour)) || !c.Inactive(now, now.Add(-3*time.Hour)) {
+		t.Error("unexpected inactivity")
+	}
+
+	invalid := &Config{Schedules: []Schedule{{Days: []time.Weekday{1}, Sleep: "25:00", Wake: "08:00"}}}
+	if invalid.Validate() == nil {
+		t.Error("invalid time should not pass")
+	}
+}
+
+func TestSleepAndWake(t *testing.T) {
+	three := int32(3)
+	zero := int32(0)
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &three},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "idle", Namespace: "dev"},
+			Spec:       appsv1.DeploymentSpec{Replicas: &zero},
+		},
+		&appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "dev"}},
+	)
+
+	if err := Sleep(client, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	// sleep again does not overwrite the replicas recorded
+	if err := Sleep(client, "dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+	web, _ := client.AppsV1().Deployments("dev").Get(ctx, "web", metav1.GetOptions{})
+	if *web.Spec.Replicas != 0 || web.Annotations[ReplicasAnnotation] != "3" {
+		t.Fatalf("unexpected deployment %v %v", *web.Spec.Replicas, web.Annotations)
+	}
+	idle, _ := client.AppsV1().Deployments("dev").Get(ctx, "idle", metav1.GetOptions{})
+	if _, ok := idle.Annotations[ReplicasAnnotation]; ok {
+		t.Fatal("deployment scaled to zero should be skipped")
+	}
+	db, _ := client.AppsV1().StatefulSets("dev").Get(ctx, "db", metav1.GetOptions{})
+	if *db.Spec.Replicas != 0 || db.Annotations[ReplicasAnnotation] != "1" {
+		t.Fatalf("unexpected statefulset %v %v", *db.Spec.Replicas, db.Annotations)
+	}
+	ns, _ := client.CoreV1().Namespaces().Get(ctx, "dev", metav1.GetOptions{})
+	if ns.Annotations[StatusAnnotation] != statusAsleep {
+		t.Fatal("namespace should be annotated")
+	}
+
+	if err := Wake(client, "dev"); err != nil {
+		t.Fatal(err)
+	}
+	web, _ = client.AppsV1().Deployments("dev").Get(ctx, "web", metav1.GetOptions{})
+	if *web.Spec.Replicas != 3 || len(web.Annotations) != 0 {
+		t.Fatalf("unexpected deployment %v %v", *web.Spec.Replicas, web.Annotations)
+	}
+	db, _ = client.AppsV1().StatefulSets("dev").Get(ctx, "db", metav1.GetOptions{})
+	if *db.Spec.Replicas != 1 {
+		t.Fatalf("unexpected statefulset %v", *db.Spec.Replicas)
+	}
+	ns, _ = client.CoreV1().Namespaces().Get(ctx, "dev", metav1.GetOptions{})
+	if _, ok := ns.Annotations[StatusAnnotation]; ok {
+		t.Fatal("annotation of namespace should be removed")
+	}
+}
@@ -0,0 +1,150 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package sleep
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ReplicasAnnotation records the replicas of workload before sleeping
+	ReplicasAnnotation = "nocalhost.dev/sleep-replicas"
+	// StatusAnnotation of namespace is asleep when the dev space sleeps
+	StatusAnnotation = "nocalhost.dev/sleep-status"
+
+	statusAsleep = "asleep"
+)
+
+// Sleep scale the deployments and statefulsets of namespace to zero, the
+// replicas are recorded in the annotation of workload
+func Sleep(client kubernetes.Interface, namespace string) error {
+	ctx := context.TODO()
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "list deployments")
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if !asleep(&d.ObjectMeta, d.Spec.Replicas) {
+			continue
+		}
+		d.Spec.Replicas = new(int32)
+		if _, err := client.AppsV1().Deployments(namespace).Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "scale deployment %s", d.Name)
+		}
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "list statefulsets")
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		if !asleep(&s.ObjectMeta, s.Spec.Replicas) {
+			continue
+		}
+		s.Spec.Replicas = new(int32)
+		if _, err := client.AppsV1().StatefulSets(namespace).Update(ctx, s, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "scale statefulset %s", s.Name)
+		}
+	}
+	return annotateNamespace(client, namespace, statusAsleep)
+}
+
+// Wake restore the replicas of workloads recorded when sleeping
+func Wake(client kubernetes.Interface, namespace string) error {
+	ctx := context.TODO()
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "list deployments")
+	}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		replicas, ok := awake(&d.ObjectMeta)
+		if !ok {
+			continue
+		}
+		d.Spec.Replicas = &replicas
+		if _, err := client.AppsV1().Deployments(namespace).Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "scale deployment %s", d.Name)
+		}
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "list statefulsets")
+	}
+	for i := range statefulSets.Items {
+		s := &statefulSets.Items[i]
+		replicas, ok := awake(&s.ObjectMeta)
+		if !ok {
+			continue
+		}
+		s.Spec.Replicas = &replicas
+		if _, err := client.AppsV1().StatefulSets(namespace).Update(ctx, s, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "scale statefulset %s", s.Name)
+		}
+	}
+	return annotateNamespace(client, namespace, "")
+}
+
+// asleep record the replicas before scaling to zero, the workload asleep
+// already or scaled to zero is skipped
+func asleep(meta *metav1.ObjectMeta, replicas *int32) bool {
+	if _, ok := meta.Annotations[ReplicasAnnotation]; ok {
+		return false
+	}
+	n := int32(1)
+	if replicas != nil {
+		n = *replicas
+	}
+	if n == 0 {
+		return false
+	}
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	meta.Annotations[ReplicasAnnotation] = strconv.Itoa(int(n))
+	return true
+}
+
+func awake(meta *metav1.ObjectMeta) (int32, bool) {
+	value, ok := meta.Annotations[ReplicasAnnotation]
+	if !ok {
+		return 0, false
+	}
+	delete(meta.Annotations, ReplicasAnnotation)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		n = 1
+	}
+	return int32(n), true
+}
+
+func annotateNamespace(client kubernetes.Interface, namespace, status string) error {
+	ns, err := client.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "get namespace")
+	}
+	if status == "" {
+		if _, ok := ns.Annotations[StatusAnnotation]; !ok {
+			return nil
+		}
+		delete(ns.Annotations, StatusAnnotation)
+	} else {
+		if ns.Annotations == nil {
+			ns.Annotations = map[string]string{}
+		}
+		ns.Annotations[StatusAnnotation] = status
+	}
+	_, err = client.CoreV1().Namespaces().Update(context.TODO(), ns, metav1.UpdateOptions{})
+	return errors.Wrap(err, "annotate namespace")
+}
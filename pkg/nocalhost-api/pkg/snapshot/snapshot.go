@@ -0,0 +1,221 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"nocalhost/pkg/nocalhost-api/pkg/sleep"
+)
+
+const Version = "v1"
+
+// Snapshot the resources of a namespace which can be applied to another
+// namespace, on the same cluster or not
+type Snapshot struct {
+	Version   string                       `json:"version"`
+	Namespace string                       `json:"namespace"`
+	CreatedAt time.Time                    `json:"created_at"`
+	Resources []*unstructured.Unstructured `json:"resources"`
+}
+
+// resource the kind is served by the first version available in cluster
+type resource struct {
+	kind     string
+	versions []schema.GroupVersionResource
+}
+
+// resources exported in the order to apply, the ones referenced come first,
+// ResourceQuota and LimitRange are set up by the dev space itself
+var resources = []resource{
+	{"ConfigMap", []schema.GroupVersionResource{{Version: "v1", Resource: "configmaps"}}},
+	{"Secret", []schema.GroupVersionResource{{Version: "v1", Resource: "secrets"}}},
+	{"ServiceAccount", []schema.GroupVersionResource{{Version: "v1", Resource: "serviceaccounts"}}},
+	{"PersistentVolumeClaim", []schema.GroupVersionResource{{Version: "v1", Resource: "persistentvolumeclaims"}}},
+	{"Service", []schema.GroupVersionResource{{Version: "v1", Resource: "services"}}},
+	{"Deployment", []schema.GroupVersionResource{{Group: "apps", Version: "v1", Resource: "deployments"}}},
+	{"StatefulSet", []schema.GroupVersionResource{{Group: "apps", Version: "v1", Resource: "statefulsets"}}},
+	{"DaemonSet", []schema.GroupVersionResource{{Group: "apps", Version: "v1", Resource: "daemonsets"}}},
+	{"Job", []schema.GroupVersionResource{{Group: "batch", Version: "v1", Resource: "jobs"}}},
+	{
+		"CronJob", []schema.GroupVersionResource{
+			{Group: "batch", Version: "v1", Resource: "cronjobs"},
+			{Group: "batch", Version: "v1beta1", Resource: "cronjobs"},
+		},
+	},
+	{
+		"Ingress", []schema.GroupVersionResource{
+			{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+			{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"},
+		},
+	},
+}
+
+// Export the resources of namespace, the ones generated by cluster or
+// owned by others are skipped, and the workloads asleep are exported with
+// the replicas before sleeping
+func Export(client dynamic.Interface, namespace string) (*Snapshot, error) {
+	s := &Snapshot{
+		Version:   Version,
+		Namespace: namespace,
+		CreatedAt: time.Now(),
+		Resources: make([]*unstructured.Unstructured, 0),
+	}
+	for _, r := range resources {
+		for _, gvr := range r.versions {
+			list, err := client.Resource(gvr).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				// not served by the cluster, try the next version
+				if k8serrors.IsNotFound(err) {
+					continue
+				}
+				return nil, errors.Wrapf(err, "list %s of namespace %s", gvr.Resource, namespace)
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				if skip(obj) {
+					continue
+				}
+				clean(obj)
+				s.Resources = append(s.Resources, obj)
+			}
+			break
+		}
+	}
+	return s, nil
+}
+
+// Apply create the resources of snapshot in namespace, the ones already
+// existing are kept, all the resources are tried even if some failed
+func Apply(client dynamic.Interface, namespace string, s *Snapshot) error {
+	if s == nil {
+		return nil
+	}
+	if s.Version != Version {
+		return errors.Errorf("unsupported snapshot version %s", s.Version)
+	}
+
+	failed := make([]string, 0)
+	for _, obj := range s.Resources {
+		name := fmt.Sprintf("%s/%s", obj.GetKind(), obj.GetName())
+		gvr, err := resourceOf(obj)
+		if err != nil {
+			failed = append(failed, name)
+			continue
+		}
+
+		obj = obj.DeepCopy()
+		obj.SetNamespace(namespace)
+		if _, err = client.Resource(gvr).Namespace(namespace).Create(
+			context.TODO(), obj, metav1.CreateOptions{},
+		); err != nil && !k8serrors.IsAlreadyExists(err) {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("failed to apply %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func resourceOf(obj *unstructured.Unstructured) (schema.GroupVersionResource, error) {
+	gvk := obj.GroupVersionKind()
+	for _, r := range resources {
+		if r.kind != gvk.Kind {
+			continue
+		}
+		for _, gvr := range r.versions {
+			if gvr.Group == gvk.Group && gvr.Version == gvk.Version {
+				return gvr, nil
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, errors.Errorf("unsupported resource %s", gvk.String())
+}
+
+func skip(obj *unstructured.Unstructured) bool {
+	if len(obj.GetOwnerReferences()) > 0 {
+		return true
+	}
+	switch obj.GetKind() {
+	case "Secret":
+		t, _, _ := unstructured.NestedString(obj.Object, "type")
+		return t == "kubernetes.io/service-account-token"
+	case "ServiceAccount":
+		return obj.GetName() == "default"
+	case "ConfigMap":
+		return obj.GetName() == "kube-root-ca.crt"
+	}
+	return false
+}
+
+// clean remove the fields filled by cluster, which are not allowed or
+// conflict when creating
+func clean(obj *unstructured.Unstructured) {
+	for _, field := range []string{
+		"uid", "resourceVersion", "creationTimestamp", "deletionTimestamp", "generation",
+		"managedFields", "selfLink", "namespace", "ownerReferences",
+	} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	annotations := obj.GetAnnotations()
+	for k := range annotations {
+		if strings.HasPrefix(k, "pv.kubernetes.io/") || strings.HasPrefix(k, "volume.beta.kubernetes.io/") {
+			delete(annotations, k)
+		}
+	}
+	delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+	delete(annotations, "deployment.kubernetes.io/revision")
+
+	switch obj.GetKind() {
+	case "Service":
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+		unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		unstructured.RemoveNestedField(obj.Object, "spec", "healthCheckNodePort")
+		if ports, ok, _ := unstructured.NestedSlice(obj.Object, "spec", "ports"); ok {
+			for _, p := range ports {
+				if port, ok := p.(map[string]interface{}); ok {
+					delete(port, "nodePort")
+				}
+			}
+			_ = unstructured.SetNestedSlice(obj.Object, ports, "spec", "ports")
+		}
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(obj.Object, "spec", "volumeName")
+	case "Job":
+		// generated by the job controller
+		unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+		unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", "controller-uid")
+		unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", "job-name")
+	case "Deployment", "StatefulSet":
+		if replicas, ok := annotations[sleep.ReplicasAnnotation]; ok {
+			var n int64
+			if _, err := fmt.Sscanf(replicas, "%d", &n); err == nil {
+				_ = unstructured.SetNestedField(obj.Object, n, "spec", "replicas")
+			}
+			delete(annotations, sleep.ReplicasAnnotation)
+		}
+	}
+
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	} else {
+		obj.SetAnnotations(annotations)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+
+	"nocalhost/pkg/nocalhost-api/pkg/sleep"
+)
+
+func newObject(apiVersion, kind, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for k, v := range fields {
+		obj.Object[k] = v
+	}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("dev")
+	obj.SetName(name)
+	obj.SetUID("uid")
+	obj.SetResourceVersion("1")
+	return obj
+}
+
+func newClient(objects ...runtime.Object) *fake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{}
+	for _, r := range resources {
+		for _, gvr := range r.versions {
+			listKinds[gvr] = r.kind + "List"
+		}
+	}
+	return fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func TestExportAndApply(t *testing.T) {
+	web := newObject(
+		"apps/v1", "Deployment", "web", map[string]interface{}{
+			"spec":   map[string]interface{}{"replicas": int64(0)},
+			"status": map[string]interface{}{"replicas": int64(0)},
+		},
+	)
+	web.SetAnnotations(map[string]string{sleep.ReplicasAnnotation: "2"})
+	owned := newObject("v1", "ConfigMap", "web-lock", nil)
+	owned.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Deployment", Name: "web"}})
+
+	client := newClient(
+		web, owned,
+		newObject(
+			"v1", "Service", "web", map[string]interface{}{
+				"spec": map[string]interface{}{
+					"clusterIP": "10.0.0.1",
+					"ports":     []interface{}{map[string]interface{}{"port": int64(80), "nodePort": int64(30080)}},
+				},
+			},
+		),
+		newObject("v1", "Secret", "token", map[string]interface{}{"type": "kubernetes.io/service-account-token"}),
+		newObject("v1", "Secret", "app", map[string]interface{}{"type": "Opaque"}),
+		newObject("v1", "ConfigMap", "kube-root-ca.crt", nil),
+		newObject("v1", "ServiceAccount", "default", nil),
+	)
+
+	s, err := Export(client, "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]string{}
+	for _, obj := range s.Resources {
+		kinds[obj.GetKind()] = obj.GetName()
+		if obj.GetUID() != "" || obj.GetResourceVersion() != "" || obj.GetNamespace() != "" {
+			t.Errorf("metadata of %s/%s is not cleaned", obj.GetKind(), obj.GetName())
+		}
+	}
+	if len(s.Resources) != 3 || kinds["Secret"] != "app" || kinds["Service"] != "web" || kinds["Deployment"] != "web" {
+		t.Fatalf("unexpected resources exported %v", kinds)
+	}
+
+	// snapshot survives marshaling
+	b, _ := json.Marshal(s)
+	restored := &Snapshot{}
+	if err := json.Unmarshal(b, restored); err != nil {
+		t.Fatal(err)
+	}
+
+	target := newClient()
+	if err := Apply(target, "clone", restored); err != nil {
+		t.Fatal(err)
+	}
+	// applying again keeps the existing ones
+	if err := Apply(target, "clone", restored); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.TODO()
+	d, err := target.Resource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}).
+		Namespace("clone").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replicas, _, _ := unstructured.NestedInt64(d.Object, "spec", "replicas")
+	if _, ok, _ := unstructured.NestedMap(d.Object, "status"); ok || replicas != 2 || len(d.GetAnnotations()) != 0 {
+		t.Fatalf("unexpected deployment %v", d.Object)
+	}
+	svc, err := target.Resource(schema.GroupVersionResource{Version: "v1", Resource: "services"}).
+		Namespace("clone").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ports, _, _ := unstructured.NestedSlice(svc.Object, "spec", "ports")
+	if _, ok, _ := unstructured.NestedString(svc.Object, "spec", "clusterIP"); ok ||
+		len(ports) != 1 || ports[0].(map[string]interface{})["nodePort"] != nil {
+		t.Fatalf("unexpected service %v", svc.Object)
+	}
+
+	restored.Version = "v0"
+	if Apply(target, "clone", restored) == nil {
+		t.Fatal("unsupported version should fail")
+	}
+}
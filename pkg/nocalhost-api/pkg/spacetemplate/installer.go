@@ -0,0 +1,429 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package spacetemplate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nhctl/appmeta"
+	"nocalhost/internal/nocalhost-api/global"
+	"nocalhost/internal/nocalhost-api/model"
+)
+
+const (
+	TEMPLATE_INSTALLER_IMAGE = "dev_space_template.installer_image"
+	TEMPLATE_INSTALL_TIMEOUT = "dev_space_template.install_timeout"
+
+	defaultInstallTimeout = 30 * time.Minute
+
+	// InstallerServiceAccount the installer runs as, it can access the
+	// namespace of dev space only
+	InstallerServiceAccount = "nocalhost-app-installer"
+	installerKubeConfig     = "nocalhost-app-installer-kubeconfig"
+	installerKubeConfigDir  = "/nocalhost/kubeconfig"
+	installerGitDir         = "/nocalhost/git"
+
+	// the env of https token read by nhctl
+	gitTokenEnv    = "NOCALHOST_GIT_TOKEN"
+	gitUsernameEnv = "NOCALHOST_GIT_USERNAME"
+	// the env of OCI registry password read by nhctl
+	helmPasswordEnv = "NOCALHOST_HELM_PASSWORD"
+	helmUsernameEnv = "NOCALHOST_HELM_USERNAME"
+)
+
+// Status of the application installed
+const (
+	// StatusQueued waiting for the job to start, as the number of dev spaces
+	// deploying in bulk is limited
+	StatusQueued     = "queued"
+	StatusPending    = "pending"
+	StatusInstalling = "installing"
+	StatusInstalled  = "installed"
+	StatusFailed     = "failed"
+)
+
+// InstallerImage the image of nhctl running the installation
+func InstallerImage() string {
+	if image := viper.GetString(TEMPLATE_INSTALLER_IMAGE); image != "" {
+		return image
+	}
+	return fmt.Sprintf("%s/nocalhost/public/nhctl:%s", global.NocalhostRegistry, global.Version)
+}
+
+// InstallTimeout the installation is failed if not finished in time
+func InstallTimeout() time.Duration {
+	if d := viper.GetDuration(TEMPLATE_INSTALL_TIMEOUT); d > 0 {
+		return d
+	}
+	return defaultInstallTimeout
+}
+
+// Application is decoded from the context of nocalhost application
+type Application struct {
+	ID          uint64   `json:"-"`
+	Name        string   `json:"application_name"`
+	URL         string   `json:"application_url"`
+	Source      string   `json:"source"`
+	InstallType string   `json:"install_type"`
+	ResourceDir []string `json:"resource_dir"`
+	// CredentialId the git credential of private repo, or the https
+	// credential of OCI registry
+	CredentialId     uint64 `json:"credential_id"`
+	HelmChartVersion string `json:"helm_chart_version"`
+	HelmChartDigest  string `json:"helm_chart_digest"`
+
+	KustomizeOverlays []KustomizeOverlay `json:"kustomize_overlays"`
+	// Overlay and Images are chosen when installing in dev space, the
+	// namespace of dev space is injected by nhctl
+	Overlay string   `json:"-"`
+	Images  []string `json:"-"`
+}
+
+type KustomizeOverlay struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// IsOci the chart of application is published to OCI registry
+func (a *Application) IsOci() bool {
+	return a.Source == "helm_repo" && strings.HasPrefix(a.URL, "oci://")
+}
+
+// Credential the git credential of application, the secret is the private
+// key of ssh or the token of https
+type Credential struct {
+	Type     string
+	Username string
+	Secret   string
+}
+
+func ParseApplication(id uint64, context string) (*Application, error) {
+	app := &Application{ID: id}
+	if err := json.Unmarshal([]byte(context), app); err != nil {
+		return nil, errors.Wrapf(err, "parse context of application %d", id)
+	}
+	return app, nil
+}
+
+// Customize select the overlay by name and override the images of kustomize
+// application
+func (a *Application) Customize(overlay string, images []string) error {
+	if overlay == "" && len(images) == 0 {
+		return nil
+	}
+	if a.InstallType != "kustomize" {
+		return errors.Errorf("application %s is not installed by kustomize", a.Name)
+	}
+	for _, image := range images {
+		if image == "" || strings.HasPrefix(image, "=") || strings.ContainsAny(image, " \t\n") {
+			return errors.Errorf("invalid image override %q", image)
+		}
+	}
+	a.Images = images
+
+	if overlay == "" {
+		return nil
+	}
+	for _, o := range a.KustomizeOverlays {
+		if o.Name == overlay {
+			a.Overlay = o.Path
+			return nil
+		}
+	}
+	return errors.Errorf("overlay %s of application %s not found", overlay, a.Name)
+}
+
+// InstallArgs the args of nhctl installing the application in namespace,
+// the local applications can not be installed as the sources are not
+// accessible
+func (a *Application) InstallArgs(namespace string) ([]string, error) {
+	args := []string{
+		"install", a.Name, "-n", namespace, "--kubeconfig", installerKubeConfigDir + "/config",
+	}
+
+	switch a.Source {
+	case "git":
+		appType := map[string]string{
+			"rawManifest": "rawManifestGit",
+			"helm_chart":  "helmGit",
+			"kustomize":   "kustomizeGit",
+		}[a.InstallType]
+		if appType == "" {
+			break
+		}
+		args = append(args, "--type", appType, "--git-url", a.URL)
+		for _, dir := range a.ResourceDir {
+			args = append(args, "--resource-path", dir)
+		}
+		if a.Overlay != "" {
+			args = append(args, "--kustomize-overlay", a.Overlay)
+		}
+		for _, image := range a.Images {
+			args = append(args, "--kustomize-image", image)
+		}
+		return args, nil
+	case "helm_repo":
+		args = append(args, "--type", "helmRepo", "--helm-repo-url", a.URL, "--helm-chart-name", a.Name)
+		if a.HelmChartVersion != "" {
+			args = append(args, "--helm-repo-version", a.HelmChartVersion)
+		}
+		if a.HelmChartDigest != "" {
+			args = append(args, "--helm-chart-digest", a.HelmChartDigest)
+		}
+		return args, nil
+	}
+	return nil, errors.Errorf("application %s of %s %s can not be installed automatically", a.Name, a.Source, a.InstallType)
+}
+
+// EnsureInstallerKubeConfig save the kubeconfig of installer service account
+// for nhctl
+func EnsureInstallerKubeConfig(client kubernetes.Interface, namespace, kubeConfig string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      installerKubeConfig,
+			Namespace: namespace,
+			Labels:    map[string]string{global.NocalhostCreateByLabel: global.NocalhostName},
+		},
+		StringData: map[string]string{"config": kubeConfig},
+	}
+	api := client.CoreV1().Secrets(namespace)
+	_, err := api.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = api.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	return errors.Wrapf(err, "save kubeconfig of installer in %s", namespace)
+}
+
+// JobName the job installing the application in namespace
+func JobName(app *Application) string {
+	return fmt.Sprintf("nocalhost-install-%d", app.ID)
+}
+
+// UpgradeJobName the job upgrading the application in namespace
+func UpgradeJobName(app *Application) string {
+	return fmt.Sprintf("nocalhost-upgrade-%d", app.ID)
+}
+
+// UpgradeArgs the args of nhctl upgrading the application installed in
+// namespace, the type of application is kept by nhctl
+func (a *Application) UpgradeArgs(namespace string) ([]string, error) {
+	args, err := a.InstallArgs(namespace)
+	if err != nil {
+		return nil, err
+	}
+	result := []string{"upgrade"}
+	for i := 1; i < len(args); i++ {
+		if args[i] == "--type" {
+			i++
+			continue
+		}
+		result = append(result, args[i])
+	}
+	return result, nil
+}
+
+// Install start the job of nhctl installing the application, returns the
+// name of job, the credential is nil if the repo is public
+func Install(client kubernetes.Interface, namespace string, app *Application, credential *Credential) (string, error) {
+	args, err := app.InstallArgs(namespace)
+	if err != nil {
+		return "", err
+	}
+	return startJob(client, newJob(JobName(app), namespace, args), app, credential)
+}
+
+// Upgrade start the job of nhctl upgrading the application, returns the
+// name of job, the credential is nil if the repo is public
+func Upgrade(client kubernetes.Interface, namespace string, app *Application, credential *Credential) (string, error) {
+	args, err := app.UpgradeArgs(namespace)
+	if err != nil {
+		return "", err
+	}
+	return startJob(client, newJob(UpgradeJobName(app), namespace, args), app, credential)
+}
+
+// startJob the job finished last time is replaced, so that the failed one
+// can be retried
+func startJob(client kubernetes.Interface, job *batchv1.Job, app *Application, credential *Credential) (string, error) {
+	if credential != nil {
+		if err := mountCredential(client, job, app, credential); err != nil {
+			return "", err
+		}
+	}
+
+	api := client.BatchV1().Jobs(job.Namespace)
+	background := metav1.DeletePropagationBackground
+	if err := api.Delete(
+		context.TODO(), job.Name, metav1.DeleteOptions{PropagationPolicy: &background},
+	); err != nil && !k8serrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "delete job %s", job.Name)
+	}
+	if _, err := api.Create(context.TODO(), job, metav1.CreateOptions{}); err != nil {
+		return "", errors.Wrapf(err, "create job %s of %s", job.Name, app.Name)
+	}
+	return job.Name, nil
+}
+
+// newJob the job of nhctl accessing the namespace by the kubeconfig of
+// installer service account
+func newJob(name, namespace string, args []string) *batchv1.Job {
+	backOff := int32(1)
+	deadline := int64(InstallTimeout().Seconds())
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{global.NocalhostCreateByLabel: global.NocalhostName},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backOff,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: InstallerServiceAccount,
+					Containers: []corev1.Container{
+						{
+							Name:    "nhctl",
+							Image:   InstallerImage(),
+							Command: []string{"nhctl"},
+							Args:    args,
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "kubeconfig", MountPath: installerKubeConfigDir, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "kubeconfig",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{SecretName: installerKubeConfig},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// mountCredential save the credential as secret of the job, the ssh key is
+// mounted as file and the https token is passed by env, as the password of
+// registry if the chart is from OCI registry
+func mountCredential(client kubernetes.Interface, job *batchv1.Job, app *Application, credential *Credential) error {
+	name := job.Name + "-git"
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: job.Namespace,
+			Labels:    map[string]string{global.NocalhostCreateByLabel: global.NocalhostName},
+		},
+		StringData: map[string]string{},
+	}
+	container := &job.Spec.Template.Spec.Containers[0]
+
+	switch credential.Type {
+	case model.GitCredentialSsh:
+		secret.StringData[corev1.SSHAuthPrivateKey] = credential.Secret
+		mode := int32(0400)
+		job.Spec.Template.Spec.Volumes = append(
+			job.Spec.Template.Spec.Volumes, corev1.Volume{
+				Name: "git",
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{SecretName: name, DefaultMode: &mode},
+				},
+			},
+		)
+		container.VolumeMounts = append(
+			container.VolumeMounts, corev1.VolumeMount{Name: "git", MountPath: installerGitDir, ReadOnly: true},
+		)
+		container.Args = append(container.Args, "--git-ssh-key", installerGitDir+"/"+corev1.SSHAuthPrivateKey)
+	case model.GitCredentialHttps:
+		secret.StringData["username"] = credential.Username
+		secret.StringData["token"] = credential.Secret
+		usernameEnv, tokenEnv := gitUsernameEnv, gitTokenEnv
+		if app.IsOci() {
+			usernameEnv, tokenEnv = helmUsernameEnv, helmPasswordEnv
+		}
+		for _, env := range [][2]string{{usernameEnv, "username"}, {tokenEnv, "token"}} {
+			container.Env = append(
+				container.Env, corev1.EnvVar{
+					Name: env[0],
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: env[1],
+						},
+					},
+				},
+			)
+		}
+	default:
+		return errors.Errorf("unknown type %s of git credential", credential.Type)
+	}
+
+	api := client.CoreV1().Secrets(job.Namespace)
+	_, err := api.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = api.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	return errors.Wrapf(err, "save git credential of %s", job.Name)
+}
+
+// InstallStatus returns the status of installation by its job, with the
+// reason if failed, or the dependency blocking the application if installing
+func InstallStatus(client kubernetes.Interface, namespace, jobName, application string) (string, string) {
+	job, err := client.BatchV1().Jobs(namespace).Get(context.TODO(), jobName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return StatusFailed, "the job of installation is not found"
+		}
+		// try again later
+		return StatusInstalling, ""
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return StatusInstalled, ""
+		case batchv1.JobFailed:
+			return StatusFailed, condition.Message
+		}
+	}
+	if job.Status.Active > 0 {
+		return StatusInstalling, blockedBy(client, namespace, application)
+	}
+	return StatusPending, ""
+}
+
+// blockedBy the dependency the application is waiting for, it is recorded
+// in the meta of application by nhctl
+func blockedBy(client kubernetes.Interface, namespace, application string) string {
+	secret, err := client.CoreV1().Secrets(namespace).Get(
+		context.TODO(), appmeta.SecretNamePrefix+application, metav1.GetOptions{},
+	)
+	if err != nil {
+		return ""
+	}
+	if blocked := string(secret.Data[appmeta.SecretBlockedByKey]); blocked != "" {
+		return "waiting for dependency " + blocked
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package spacetemplate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nhctl/appmeta"
+)
+
+// Type of progress events
+const (
+	EventInstall     = "install"
+	EventApplication = "application"
+	EventPod         = "pod"
+)
+
+// Status of pods and manifests in progress events
+const (
+	PodPending         = "pending"
+	PodImagePulling    = "image_pulling"
+	PodImagePullFailed = "image_pull_failed"
+	PodCreating        = "creating"
+	PodRunning         = "running"
+	PodReady           = "ready"
+	PodCrashLoop       = "crash_loop"
+	PodSucceeded       = "succeeded"
+	PodFailed          = "failed"
+
+	ManifestApplying = "applying"
+	ManifestApplied  = "applied"
+)
+
+// ProgressEvent the change of the installation, application manifests or
+// pods in dev space, e.g. pod productpage-xxx image_pulling
+type ProgressEvent struct {
+	Type    string    `json:"type"`
+	Name    string    `json:"name"`
+	Status  string    `json:"status"`
+	Message string    `json:"message,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// ProgressTracker the states of installation last seen, so that the
+// changes only are reported by Poll
+type ProgressTracker struct {
+	client      kubernetes.Interface
+	namespace   string
+	jobName     string
+	application string
+	states      map[string]string
+}
+
+func NewProgressTracker(client kubernetes.Interface, namespace, jobName, application string) *ProgressTracker {
+	return &ProgressTracker{
+		client:      client,
+		namespace:   namespace,
+		jobName:     jobName,
+		application: application,
+		states:      map[string]string{},
+	}
+}
+
+// Poll returns the events changed since last poll, and whether the
+// installation is finished, the pods of the installer job are ignored
+func (t *ProgressTracker) Poll() ([]*ProgressEvent, bool) {
+	var events []*ProgressEvent
+	now := time.Now()
+	report := func(eventType, name, status, message string) {
+		key := eventType + "/" + name
+		if state := status + "\n" + message; t.states[key] != state {
+			t.states[key] = state
+			events = append(
+				events, &ProgressEvent{Type: eventType, Name: name, Status: status, Message: message, Time: now},
+			)
+		}
+	}
+
+	if state := t.applicationState(); state != "" {
+		report(EventApplication, t.application, state, "")
+	}
+
+	pods, err := t.client.CoreV1().Pods(t.namespace).List(context.TODO(), metav1.ListOptions{})
+	if err == nil {
+		pulling := t.imagePulling()
+		sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Labels["job-name"] == t.jobName {
+				continue
+			}
+			status, message := PodStatus(pod, pulling[pod.Name])
+			report(EventPod, pod.Name, status, message)
+		}
+	}
+
+	// the status of installation comes last, so that the final state of
+	// pods is reported before the installation is finished
+	status, message := InstallStatus(t.client, t.namespace, t.jobName, t.application)
+	report(EventInstall, t.jobName, status, message)
+	return events, status == StatusInstalled || status == StatusFailed
+}
+
+// applicationState whether the manifests are applied by nhctl, recorded in
+// the meta of application
+func (t *ProgressTracker) applicationState() string {
+	secret, err := t.client.CoreV1().Secrets(t.namespace).Get(
+		context.TODO(), appmeta.SecretNamePrefix+t.application, metav1.GetOptions{},
+	)
+	if err != nil {
+		return ""
+	}
+	switch appmeta.ApplicationState(secret.Data[appmeta.SecretStateKey]) {
+	case appmeta.INSTALLING:
+		return ManifestApplying
+	case appmeta.INSTALLED:
+		return ManifestApplied
+	}
+	return ""
+}
+
+// imagePulling the pods pulling images by their latest image events
+func (t *ProgressTracker) imagePulling() map[string]bool {
+	result := map[string]bool{}
+	events, err := t.client.CoreV1().Events(t.namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return result
+	}
+	latest := map[string]time.Time{}
+	for _, e := range events.Items {
+		if e.InvolvedObject.Kind != "Pod" || (e.Reason != "Pulling" && e.Reason != "Pulled") {
+			continue
+		}
+		at := e.LastTimestamp.Time
+		if at.Before(latest[e.InvolvedObject.Name]) {
+			continue
+		}
+		latest[e.InvolvedObject.Name] = at
+		result[e.InvolvedObject.Name] = e.Reason == "Pulling"
+	}
+	return result
+}
+
+// PodStatus the status of pod in progress events, with the reason of
+// waiting or failure
+func PodStatus(pod *corev1.Pod, pulling bool) (string, string) {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return PodSucceeded, ""
+	case corev1.PodFailed:
+		return PodFailed, pod.Status.Message
+	}
+
+	statuses := append(
+		append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...,
+	)
+	for _, s := range statuses {
+		if s.State.Waiting == nil {
+			continue
+		}
+		switch s.State.Waiting.Reason {
+		case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+			return PodImagePullFailed, fmt.Sprintf("%s: %s", s.Name, s.State.Waiting.Message)
+		case "CrashLoopBackOff":
+			return PodCrashLoop, s.Name
+		case "ContainerCreating", "PodInitializing":
+			if pulling {
+				return PodImagePulling, s.Image
+			}
+			return PodCreating, ""
+		}
+	}
+
+	if pod.Status.Phase == corev1.PodPending {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				return PodPending, strings.TrimSpace(condition.Reason + " " + condition.Message)
+			}
+		}
+		if pulling {
+			return PodImagePulling, ""
+		}
+		return PodPending, ""
+	}
+
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return PodReady, ""
+		}
+	}
+	return PodRunning, ""
+}
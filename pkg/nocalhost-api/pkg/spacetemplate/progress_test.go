@@ -0,0 +1,124 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package spacetemplate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"nocalhost/internal/nhctl/appmeta"
+)
+
+func TestProgressTracker(t *testing.T) {
+	ctx := context.TODO()
+	creating := corev1.ContainerStatus{
+		Name: "productpage", Image: "nocalhost/productpage:1.0",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	}
+	client := fake.NewSimpleClientset(
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "nocalhost-install-1", Namespace: "dev"},
+			Status:     batchv1.JobStatus{Active: 1},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "nocalhost-install-1-abc", Namespace: "dev", Labels: map[string]string{"job-name": "nocalhost-install-1"},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "productpage-0", Namespace: "dev"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending, ContainerStatuses: []corev1.ContainerStatus{creating},
+			},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "productpage-0.pulling", Namespace: "dev"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "productpage-0"},
+			Reason:         "Pulling",
+			LastTimestamp:  metav1.NewTime(time.Now()),
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: appmeta.SecretNamePrefix + "bookinfo", Namespace: "dev"},
+			Data:       map[string][]byte{appmeta.SecretStateKey: []byte(appmeta.INSTALLING)},
+		},
+	)
+
+	tracker := NewProgressTracker(client, "dev", "nocalhost-install-1", "bookinfo")
+	events, finished := tracker.Poll()
+	if finished || len(events) != 3 {
+		t.Fatalf("unexpected events %v %v", events, finished)
+	}
+	for i, expected := range [][2]string{
+		{EventApplication, ManifestApplying}, {EventPod, PodImagePulling}, {EventInstall, StatusInstalling},
+	} {
+		if events[i].Type != expected[0] || events[i].Status != expected[1] {
+			t.Errorf("unexpected event %d: %v", i, events[i])
+		}
+	}
+
+	// nothing changed
+	if events, _ = tracker.Poll(); len(events) != 0 {
+		t.Fatalf("unexpected events %v", events)
+	}
+
+	pod, _ := client.CoreV1().Pods("dev").Get(ctx, "productpage-0", metav1.GetOptions{})
+	pod.Status = corev1.PodStatus{
+		Phase:      corev1.PodRunning,
+		Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+	}
+	_, _ = client.CoreV1().Pods("dev").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+	job, _ := client.BatchV1().Jobs("dev").Get(ctx, "nocalhost-install-1", metav1.GetOptions{})
+	job.Status = batchv1.JobStatus{
+		Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+	}
+	_, _ = client.BatchV1().Jobs("dev").UpdateStatus(ctx, job, metav1.UpdateOptions{})
+
+	events, finished = tracker.Poll()
+	if !finished || len(events) != 2 || events[0].Status != PodReady || events[1].Status != StatusInstalled {
+		t.Fatalf("unexpected events %v %v", events, finished)
+	}
+}
+
+func TestPodStatus(t *testing.T) {
+	pullFailed := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "web",
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "unauthorized"},
+					},
+				},
+			},
+		},
+	}
+	if status, message := PodStatus(pullFailed, false); status != PodImagePullFailed || message != "web: unauthorized" {
+		t.Fatalf("unexpected status %s %s", status, message)
+	}
+
+	unschedulable := &corev1.Pod{
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: "Unschedulable"},
+			},
+		},
+	}
+	if status, message := PodStatus(unschedulable, false); status != PodPending || message != "Unschedulable" {
+		t.Fatalf("unexpected status %s %s", status, message)
+	}
+
+	if status, _ := PodStatus(&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}}, false); status != PodRunning {
+		t.Fatalf("unexpected status %s", status)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package spacetemplate
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nocalhost-api/global"
+)
+
+// EnvConfigMap holds the env vars of template, the workloads of dev space
+// can refer to it by envFrom
+const EnvConfigMap = "nocalhost-dev-space-env"
+
+// SecretRef the secret copied to dev space, such as image pull secrets
+// and certificates
+type SecretRef struct {
+	Namespace string `json:"namespace" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+}
+
+// ApplyEnv create or update the config map of env vars in namespace
+func ApplyEnv(client kubernetes.Interface, namespace string, env map[string]string) error {
+	if len(env) == 0 {
+		return nil
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EnvConfigMap,
+			Namespace: namespace,
+			Labels:    map[string]string{global.NocalhostCreateByLabel: global.NocalhostName},
+		},
+		Data: env,
+	}
+	api := client.CoreV1().ConfigMaps(namespace)
+	_, err := api.Create(context.TODO(), cm, metav1.CreateOptions{})
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = api.Update(context.TODO(), cm, metav1.UpdateOptions{})
+	}
+	return errors.Wrapf(err, "apply env of template in %s", namespace)
+}
+
+// CopySecrets copy the secrets to namespace with the same name, the ones
+// existing are kept
+func CopySecrets(client kubernetes.Interface, namespace string, secrets []SecretRef) error {
+	for _, ref := range secrets {
+		source, err := client.CoreV1().Secrets(ref.Namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "get secret %s/%s", ref.Namespace, ref.Name)
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      source.Name,
+				Namespace: namespace,
+				Labels:    map[string]string{global.NocalhostCreateByLabel: global.NocalhostName},
+			},
+			Type: source.Type,
+			Data: source.Data,
+		}
+		if _, err := client.CoreV1().Secrets(namespace).Create(
+			context.TODO(), secret, metav1.CreateOptions{},
+		); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "copy secret %s/%s to %s", ref.Namespace, ref.Name, namespace)
+		}
+	}
+	return nil
+}
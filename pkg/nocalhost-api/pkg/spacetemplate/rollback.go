@@ -0,0 +1,68 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package spacetemplate
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"nocalhost/internal/nhctl/appmeta"
+	"nocalhost/internal/nhctl/model"
+)
+
+// Revisions the history of application recorded by nhctl in the secret of
+// application meta, the latest first
+func Revisions(client kubernetes.Interface, namespace, application string) ([]*model.RevisionInfo, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(
+		context.TODO(), appmeta.SecretNamePrefix+application, metav1.GetOptions{},
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get meta of application %s in %s", application, namespace)
+	}
+	meta, err := appmeta.Decode(secret)
+	if err != nil {
+		return nil, err
+	}
+	return meta.RevisionInfos(), nil
+}
+
+// RollbackJobName the job rolling back the application to revision
+func RollbackJobName(application string, revision int) string {
+	return fmt.Sprintf("nocalhost-rollback-%s-%d", application, revision)
+}
+
+// Rollback start the job of nhctl rolling back the application to revision,
+// the job of last rolling back to the same revision is replaced, returns the
+// name of job
+func Rollback(client kubernetes.Interface, namespace, application string, revision int, triggeredBy string) (string, error) {
+	job := newJob(
+		RollbackJobName(application, revision), namespace, []string{
+			"rollback", application, "--revision", strconv.Itoa(revision),
+			"-n", namespace, "--kubeconfig", installerKubeConfigDir + "/config",
+		},
+	)
+	container := &job.Spec.Template.Spec.Containers[0]
+	container.Env = append(container.Env, corev1.EnvVar{Name: appmeta.RevisionTriggerEnv, Value: triggeredBy})
+
+	api := client.BatchV1().Jobs(namespace)
+	background := metav1.DeletePropagationBackground
+	if err := api.Delete(
+		context.TODO(), job.Name, metav1.DeleteOptions{PropagationPolicy: &background},
+	); err != nil && !k8serrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "delete job %s", job.Name)
+	}
+	if _, err := api.Create(context.TODO(), job, metav1.CreateOptions{}); err != nil {
+		return "", errors.Wrapf(err, "create job rolling back %s", application)
+	}
+	return job.Name, nil
+}
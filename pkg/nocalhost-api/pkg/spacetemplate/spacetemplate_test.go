@@ -0,0 +1,289 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package spacetemplate
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"nocalhost/internal/nhctl/appmeta"
+)
+
+func TestInstallArgs(t *testing.T) {
+	app, err := ParseApplication(
+		1, `{"application_name":"bookinfo","application_url":"https://github.com/nocalhost/bookinfo.git",`+
+			`"source":"git","install_type":"rawManifest","resource_dir":["manifest/templates"]}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := app.InstallArgs("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(args, " "); got != "install bookinfo -n dev --kubeconfig /nocalhost/kubeconfig/config "+
+		"--type rawManifestGit --git-url https://github.com/nocalhost/bookinfo.git --resource-path manifest/templates" {
+		t.Fatalf("unexpected args %s", got)
+	}
+
+	helm := &Application{Name: "nginx", URL: "https://charts.example.com", Source: "helm_repo", InstallType: "helm_chart"}
+	if args, err := helm.InstallArgs("dev"); err != nil || args[len(args)-1] != "nginx" {
+		t.Fatalf("unexpected args %v %v", args, err)
+	}
+
+	oci := &Application{
+		Name: "nginx", URL: "oci://harbor.example.com/charts", Source: "helm_repo", InstallType: "helm_chart",
+		HelmChartVersion: "1.0.0", HelmChartDigest: "sha256:" + strings.Repeat("a", 64),
+	}
+	if args, err := oci.InstallArgs("dev"); err != nil || !strings.HasSuffix(
+		strings.Join(args, " "), "--helm-repo-version 1.0.0 --helm-chart-digest "+oci.HelmChartDigest,
+	) {
+		t.Fatalf("unexpected args %v %v", args, err)
+	}
+
+	local := &Application{Name: "local", Source: "local", InstallType: "rawManifestLocal"}
+	if _, err := local.InstallArgs("dev"); err == nil {
+		t.Fatal("local application can not be installed")
+	}
+}
+
+func TestCustomize(t *testing.T) {
+	app, err := ParseApplication(
+		1, `{"application_name":"bookinfo","application_url":"https://github.com/nocalhost/bookinfo.git",`+
+			`"source":"git","install_type":"kustomize","resource_dir":["kustomize/base"],`+
+			`"kustomize_overlays":[{"name":"dev","path":"kustomize/overlays/dev"}]}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Customize("prod", nil); err == nil {
+		t.Fatal("overlay prod is not declared")
+	}
+	if err := app.Customize("", []string{"=nginx:1.21"}); err == nil {
+		t.Fatal("image override without name is invalid")
+	}
+	if err := app.Customize("dev", []string{"nginx=registry.io/nginx:1.21"}); err != nil {
+		t.Fatal(err)
+	}
+	args, err := app.InstallArgs("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(args, " "); !strings.HasSuffix(
+		got, "--kustomize-overlay kustomize/overlays/dev --kustomize-image nginx=registry.io/nginx:1.21",
+	) {
+		t.Fatalf("unexpected args %s", got)
+	}
+
+	manifest := &Application{Name: "nginx", Source: "git", InstallType: "rawManifest"}
+	if err := manifest.Customize("dev", nil); err == nil {
+		t.Fatal("overlay can only be selected by kustomize application")
+	}
+}
+
+func TestInstall(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+	app := &Application{ID: 3, Name: "bookinfo", URL: "https://example.com/bookinfo.git", Source: "git", InstallType: "helm_chart"}
+
+	if err := EnsureInstallerKubeConfig(client, "dev", "kubeconfig"); err != nil {
+		t.Fatal(err)
+	}
+	// saved again
+	if err := EnsureInstallerKubeConfig(client, "dev", "kubeconfig"); err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := Install(client, "dev", app, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := InstallStatus(client, "dev", name, app.Name); status != StatusPending {
+		t.Fatalf("unexpected status %s", status)
+	}
+
+	job, _ := client.BatchV1().Jobs("dev").Get(ctx, name, metav1.GetOptions{})
+	job.Status.Active = 1
+	_, _ = client.BatchV1().Jobs("dev").UpdateStatus(ctx, job, metav1.UpdateOptions{})
+	_, _ = client.CoreV1().Secrets("dev").Create(
+		ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: appmeta.SecretNamePrefix + app.Name},
+			Data:       map[string][]byte{appmeta.SecretBlockedByKey: []byte("statefulset/mysql (0/1 ready)")},
+		}, metav1.CreateOptions{},
+	)
+	if status, message := InstallStatus(client, "dev", name, app.Name); status != StatusInstalling ||
+		message != "waiting for dependency statefulset/mysql (0/1 ready)" {
+		t.Fatalf("unexpected status %s %s", status, message)
+	}
+
+	job, _ = client.BatchV1().Jobs("dev").Get(ctx, name, metav1.GetOptions{})
+	job.Status.Active = 0
+	job.Status.Conditions = []batchv1.JobCondition{
+		{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"},
+	}
+	_, _ = client.BatchV1().Jobs("dev").UpdateStatus(ctx, job, metav1.UpdateOptions{})
+	if status, message := InstallStatus(client, "dev", name, app.Name); status != StatusFailed || message != "BackoffLimitExceeded" {
+		t.Fatalf("unexpected status %s %s", status, message)
+	}
+
+	if status, _ := InstallStatus(client, "dev", "none", app.Name); status != StatusFailed {
+		t.Fatalf("job not found should fail, got %s", status)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+	app := &Application{
+		ID: 3, Name: "bookinfo", URL: "https://example.com/bookinfo.git", Source: "git", InstallType: "kustomize",
+		Overlay: "overlays/dev",
+	}
+
+	name, err := Upgrade(client, "dev", app, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := client.BatchV1().Jobs("dev").Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(job.Spec.Template.Spec.Containers[0].Args, " "); got != "upgrade bookinfo -n dev "+
+		"--kubeconfig /nocalhost/kubeconfig/config --git-url https://example.com/bookinfo.git "+
+		"--kustomize-overlay overlays/dev" {
+		t.Fatalf("unexpected args %s", got)
+	}
+
+	// the failed job is replaced when retried
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+	_, _ = client.BatchV1().Jobs("dev").UpdateStatus(ctx, job, metav1.UpdateOptions{})
+	if _, err := Upgrade(client, "dev", app, nil); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := InstallStatus(client, "dev", name, app.Name); status != StatusPending {
+		t.Fatalf("unexpected status %s", status)
+	}
+}
+
+func TestInstallWithCredential(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+
+	ssh := &Application{ID: 1, Name: "ssh", URL: "git@example.com:private.git", Source: "git", InstallType: "rawManifest"}
+	name, err := Install(client, "dev", ssh, &Credential{Type: "ssh", Secret: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, _ := client.BatchV1().Jobs("dev").Get(ctx, name, metav1.GetOptions{})
+	args := strings.Join(job.Spec.Template.Spec.Containers[0].Args, " ")
+	if !strings.Contains(args, "--git-ssh-key /nocalhost/git/ssh-privatekey") {
+		t.Fatalf("ssh key is not passed, args %s", args)
+	}
+	if _, err := client.CoreV1().Secrets("dev").Get(ctx, name+"-git", metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	https := &Application{ID: 2, Name: "https", URL: "https://example.com/private.git", Source: "git", InstallType: "rawManifest"}
+	name, err = Install(client, "dev", https, &Credential{Type: "https", Username: "bot", Secret: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, _ = client.BatchV1().Jobs("dev").Get(ctx, name, metav1.GetOptions{})
+	if env := job.Spec.Template.Spec.Containers[0].Env; len(env) != 2 || env[1].Name != gitTokenEnv {
+		t.Fatalf("token is not passed by env, env %v", env)
+	}
+
+	oci := &Application{ID: 4, Name: "oci", URL: "oci://harbor.example.com/charts", Source: "helm_repo", InstallType: "helm_chart"}
+	name, err = Install(client, "dev", oci, &Credential{Type: "https", Username: "bot", Secret: "token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, _ = client.BatchV1().Jobs("dev").Get(ctx, name, metav1.GetOptions{})
+	if env := job.Spec.Template.Spec.Containers[0].Env; len(env) != 2 || env[1].Name != helmPasswordEnv {
+		t.Fatalf("password of registry is not passed by env, env %v", env)
+	}
+
+	if _, err := Install(client, "dev", &Application{ID: 3, Name: "x", Source: "git", InstallType: "rawManifest"},
+		&Credential{Type: "unknown"}); err == nil {
+		t.Fatal("unknown type of credential should fail")
+	}
+}
+
+func TestEnvAndSecrets(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "shared"},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte("{}")},
+		},
+	)
+	ctx := context.TODO()
+
+	if err := ApplyEnv(client, "dev", map[string]string{"DB_HOST": "db"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyEnv(client, "dev", map[string]string{"DB_HOST": "mysql"}); err != nil {
+		t.Fatal(err)
+	}
+	cm, _ := client.CoreV1().ConfigMaps("dev").Get(ctx, EnvConfigMap, metav1.GetOptions{})
+	if cm.Data["DB_HOST"] != "mysql" {
+		t.Fatalf("unexpected env %v", cm.Data)
+	}
+
+	refs := []SecretRef{{Namespace: "shared", Name: "registry"}}
+	if err := CopySecrets(client, "dev", refs); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopySecrets(client, "dev", refs); err != nil {
+		t.Fatal(err)
+	}
+	secret, err := client.CoreV1().Secrets("dev").Get(ctx, "registry", metav1.GetOptions{})
+	if err != nil || secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Fatalf("unexpected secret %v %v", secret, err)
+	}
+
+	if err := CopySecrets(client, "dev", []SecretRef{{Namespace: "shared", Name: "none"}}); err == nil {
+		t.Fatal("secret not found should fail")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.TODO()
+
+	if _, err := Revisions(client, "dev", "bookinfo"); err == nil {
+		t.Fatal("application not installed should fail")
+	}
+	_, _ = client.CoreV1().Secrets("dev").Create(
+		ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: appmeta.SecretNamePrefix + "bookinfo", Namespace: "dev"},
+			Data:       map[string][]byte{appmeta.SecretStateKey: []byte(appmeta.INSTALLED)},
+		}, metav1.CreateOptions{},
+	)
+	if revisions, err := Revisions(client, "dev", "bookinfo"); err != nil || len(revisions) != 0 {
+		t.Fatalf("unexpected revisions %v %v", revisions, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		name, err := Rollback(client, "dev", "bookinfo", 2, "admin@nocalhost.dev")
+		if err != nil {
+			t.Fatal(err)
+		}
+		job, _ := client.BatchV1().Jobs("dev").Get(ctx, name, metav1.GetOptions{})
+		container := job.Spec.Template.Spec.Containers[0]
+		if args := strings.Join(container.Args, " "); !strings.HasPrefix(args, "rollback bookinfo --revision 2 -n dev") {
+			t.Fatalf("unexpected args %s", args)
+		}
+		if len(container.Env) != 1 || container.Env[0].Value != "admin@nocalhost.dev" {
+			t.Fatalf("trigger is not passed by env, env %v", container.Env)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package tlsserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+const (
+	TLS_CERT_FILE          = "app.tls.cert_file"
+	TLS_KEY_FILE           = "app.tls.key_file"
+	TLS_ACME_DOMAINS       = "app.tls.acme.domains"
+	TLS_ACME_EMAIL         = "app.tls.acme.email"
+	TLS_ACME_CACHE_DIR     = "app.tls.acme.cache_dir"
+	TLS_ACME_DIRECTORY_URL = "app.tls.acme.directory_url"
+	TLS_ACME_HTTP_ADDR     = "app.tls.acme.http_addr"
+	TLS_CLIENT_CA_FILE     = "app.tls.client_ca_file"
+	TLS_CLIENT_AUTH        = "app.tls.client_auth"
+	TLS_CLIENT_USERS       = "app.tls.client_users"
+
+	// the client certificates are verified if given by default, and
+	// required of all the callers by require
+	ClientAuthRequest = "request"
+	ClientAuthRequire = "require"
+
+	defaultACMECacheDir = "data/acme"
+	// the certificate file is checked for rotation at most so often
+	reloadInterval = 10 * time.Second
+)
+
+// ClientUser the user authenticated by the client certificate of the common
+// name, or of the DNS or URI subject alternative name
+type ClientUser struct {
+	CommonName string `mapstructure:"common_name"`
+	Email      string `mapstructure:"email"`
+}
+
+var (
+	once    sync.Once
+	config  *tls.Config
+	manager *autocert.Manager
+	users   []ClientUser
+	initErr error
+)
+
+// Config the tls config of app.tls, by the certificate files or ACME, nil
+// if neither is configured and the server serves plain http
+func Config() (*tls.Config, error) {
+	once.Do(
+		func() {
+			config, manager, users, initErr = load()
+		},
+	)
+	return config, initErr
+}
+
+func load() (*tls.Config, *autocert.Manager, []ClientUser, error) {
+	certFile, keyFile := viper.GetString(TLS_CERT_FILE), viper.GetString(TLS_KEY_FILE)
+	domains := viper.GetStringSlice(TLS_ACME_DOMAINS)
+
+	var cfg *tls.Config
+	var m *autocert.Manager
+	switch {
+	case certFile != "" && len(domains) > 0:
+		return nil, nil, nil, errors.New("app.tls.cert_file and app.tls.acme are exclusive")
+	case certFile != "":
+		r, err := newReloader(certFile, keyFile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		cfg = &tls.Config{GetCertificate: r.getCertificate}
+	case len(domains) > 0:
+		cacheDir := viper.GetString(TLS_ACME_CACHE_DIR)
+		if cacheDir == "" {
+			cacheDir = defaultACMECacheDir
+		}
+		m = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(cacheDir),
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Email:      viper.GetString(TLS_ACME_EMAIL),
+		}
+		if url := viper.GetString(TLS_ACME_DIRECTORY_URL); url != "" {
+			m.Client = &acme.Client{DirectoryURL: url}
+		}
+		// the challenges of tls-alpn-01 are answered on the same port
+		cfg = m.TLSConfig()
+	default:
+		if viper.GetString(TLS_CLIENT_CA_FILE) != "" {
+			return nil, nil, nil, errors.New("app.tls.client_ca_file requires app.tls.cert_file or app.tls.acme")
+		}
+		return nil, nil, nil, nil
+	}
+	cfg.MinVersion = tls.VersionTLS12
+
+	var clientUsers []ClientUser
+	if caFile := viper.GetString(TLS_CLIENT_CA_FILE); caFile != "" {
+		content, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "read client ca of tls")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(content) {
+			return nil, nil, nil, errors.Errorf("no certificate in client ca %s", caFile)
+		}
+		cfg.ClientCAs = pool
+		switch auth := viper.GetString(TLS_CLIENT_AUTH); auth {
+		case "", ClientAuthRequest:
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		case ClientAuthRequire:
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		default:
+			return nil, nil, nil, errors.Errorf("unknown app.tls.client_auth %s, expect request or require", auth)
+		}
+		if err := viper.UnmarshalKey(TLS_CLIENT_USERS, &clientUsers); err != nil {
+			return nil, nil, nil, errors.Wrap(err, "parse app.tls.client_users")
+		}
+	}
+	return cfg, m, clientUsers, nil
+}
+
+// ChallengeHandler the handler of the challenges of http-01 on
+// app.tls.acme.http_addr, the other requests are redirected to https, nil if
+// ACME or the address is not configured
+func ChallengeHandler() http.Handler {
+	if _, err := Config(); err != nil || manager == nil || viper.GetString(TLS_ACME_HTTP_ADDR) == "" {
+		return nil
+	}
+	return manager.HTTPHandler(nil)
+}
+
+// UserOf the email of the user authenticated by the client certificate
+// verified, false if no certificate or it is not of app.tls.client_users
+func UserOf(state *tls.ConnectionState) (string, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	return userOf(state.VerifiedChains[0][0], users)
+}
+
+func userOf(cert *x509.Certificate, users []ClientUser) (string, bool) {
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	for _, u := range users {
+		for _, name := range names {
+			if name != "" && name == u.CommonName {
+				return u.Email, true
+			}
+		}
+	}
+	return "", false
+}
+
+// reloader the certificate of the files, loaded again once they are changed,
+// e.g. renewed by cert-manager
+type reloader struct {
+	certFile, keyFile string
+
+	lock      sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newReloader(certFile, keyFile string) (*reloader, error) {
+	if keyFile == "" {
+		return nil, errors.New("app.tls.key_file is required by app.tls.cert_file")
+	}
+	r := &reloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reloader) reload() error {
+	r.checkedAt = time.Now()
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return errors.Wrap(err, "stat certificate of tls")
+	}
+	if r.cert != nil && info.ModTime().Equal(r.modTime) {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "load certificate of tls")
+	}
+	r.cert, r.modTime = &cert, info.ModTime()
+	return nil
+}
+
+func (r *reloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if time.Since(r.checkedAt) >= reloadInterval {
+		// the previous certificate is served until the new one is complete
+		if err := r.reload(); err != nil {
+			log.Warnf("reload certificate of tls err: %v", err)
+		}
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,109 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package tlsserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+func writeCert(t *testing.T, dir, commonName string, modTime time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, _ := x509.MarshalECPrivateKey(key)
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	_ = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	_ = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	_ = os.Chtimes(certFile, modTime, modTime)
+	return certFile, keyFile
+}
+
+func TestReloader(t *testing.T) {
+	log.NewLogger(&log.Config{}, log.InstanceZapLogger)
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeCert(t, dir, "before", time.Now().Add(-time.Minute))
+	r, err := newReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotated, e.g. by cert-manager
+	writeCert(t, dir, "after", time.Now())
+	cert, _ := r.getCertificate(nil)
+	if leaf, _ := x509.ParseCertificate(cert.Certificate[0]); leaf.Subject.CommonName != "before" {
+		t.Errorf("certificate should be checked for rotation in the interval, got %s", leaf.Subject.CommonName)
+	}
+
+	r.checkedAt = time.Now().Add(-reloadInterval)
+	cert, _ = r.getCertificate(nil)
+	if leaf, _ := x509.ParseCertificate(cert.Certificate[0]); leaf.Subject.CommonName != "after" {
+		t.Errorf("certificate rotated should be reloaded, got %s", leaf.Subject.CommonName)
+	}
+
+	// the previous certificate is served if the files are broken
+	_ = ioutil.WriteFile(keyFile, []byte("broken"), 0600)
+	_ = os.Chtimes(certFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	r.checkedAt = time.Now().Add(-reloadInterval)
+	if cert, _ := r.getCertificate(nil); cert == nil {
+		t.Error("previous certificate should be served")
+	}
+}
+
+func TestUserOf(t *testing.T) {
+	users := []ClientUser{
+		{CommonName: "ci-runner", Email: "ci@nocalhost.dev"},
+		{CommonName: "spiffe://cluster.local/ns/nocalhost/sa/dep", Email: "dep@nocalhost.dev"},
+	}
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/nocalhost/sa/dep")
+	cases := []struct {
+		cert  *x509.Certificate
+		email string
+	}{
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "ci-runner"}}, "ci@nocalhost.dev"},
+		{&x509.Certificate{URIs: []*url.URL{spiffe}}, "dep@nocalhost.dev"},
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}}, ""},
+	}
+	for _, c := range cases {
+		if email, ok := userOf(c.cert, users); email != c.email || ok != (c.email != "") {
+			t.Errorf("user of %v should be %s, got %s", c.cert.Subject, c.email, email)
+		}
+	}
+
+	if _, ok := UserOf(nil); ok {
+		t.Error("plain http should not be authenticated")
+	}
+}
@@ -0,0 +1,280 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package token
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	// the asymmetric keys of the access tokens, the ones other than
+	// jwt_signing_key verify the tokens signed before the rotation only
+	JWT_KEYS        = "jwt_keys"
+	JWT_SIGNING_KEY = "jwt_signing_key"
+)
+
+// keyConfig configured by jwt_keys, the previous keys may be configured by
+// the public key only
+type keyConfig struct {
+	ID         string `mapstructure:"id"`
+	Alg        string `mapstructure:"alg"`
+	PrivateKey string `mapstructure:"private_key"`
+	PublicKey  string `mapstructure:"public_key"`
+}
+
+// Key of RS256 or ES256 signing the access tokens, identified by kid
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// Keyset signs the access tokens by the signing key, and verifies the ones of
+// all the keys, so that the tokens signed before rotation keep valid
+type Keyset struct {
+	signing string
+	keys    map[string]*Key
+	// the ids in the order configured
+	ids []string
+}
+
+var (
+	keysLock sync.RWMutex
+	keyset   *Keyset
+)
+
+// NewKeyset the keyset signing by the key of signing, which must have the
+// private key
+func NewKeyset(signing string, keys []*Key) (*Keyset, error) {
+	ks := &Keyset{signing: signing, keys: map[string]*Key{}}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("id of jwt key is required")
+		}
+		if _, ok := ks.keys[k.ID]; ok {
+			return nil, errors.Errorf("duplicated jwt key %s", k.ID)
+		}
+		ks.keys[k.ID] = k
+		ks.ids = append(ks.ids, k.ID)
+	}
+	k, ok := ks.keys[signing]
+	if !ok {
+		return nil, errors.Errorf("signing key %s of jwt is not configured", signing)
+	}
+	if k.private == nil {
+		return nil, errors.Errorf("private key of signing key %s of jwt is not configured", signing)
+	}
+	return ks, nil
+}
+
+// NewKey the key of alg, RS256 or ES256, by the private key in PEM, or the
+// public key in PEM if private is empty
+func NewKey(id, alg string, private, public []byte) (*Key, error) {
+	k := &Key{ID: id, Method: jwt.GetSigningMethod(alg)}
+	switch k.Method {
+	case jwt.SigningMethodRS256, jwt.SigningMethodES256:
+	default:
+		return nil, errors.Errorf("unsupported alg %s of jwt key %s, use RS256 or ES256", alg, id)
+	}
+
+	if len(private) > 0 {
+		signer, err := parsePrivateKey(private)
+		if err != nil {
+			return nil, errors.Wrapf(err, "private key of jwt key %s", id)
+		}
+		k.private, k.public = signer, signer.Public()
+	} else if len(public) > 0 {
+		block, _ := pem.Decode(public)
+		if block == nil {
+			return nil, errors.Errorf("public key of jwt key %s is not in PEM", id)
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrapf(err, "public key of jwt key %s", id)
+		}
+		k.public = pub
+	} else {
+		return nil, errors.Errorf("private_key or public_key of jwt key %s is required", id)
+	}
+
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		if k.Method != jwt.SigningMethodRS256 {
+			return nil, errors.Errorf("jwt key %s of RSA is not for %s", id, alg)
+		}
+	case *ecdsa.PublicKey:
+		if k.Method != jwt.SigningMethodES256 || pub.Curve != elliptic.P256() {
+			return nil, errors.Errorf("jwt key %s of ECDSA is not of P-256 for %s", id, alg)
+		}
+	default:
+		return nil, errors.Errorf("unsupported key type of jwt key %s", id)
+	}
+	return k, nil
+}
+
+// parsePrivateKey in PKCS#8, PKCS#1 or SEC 1
+func parsePrivateKey(content []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("not in PEM")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, errors.New("unsupported private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key, expect PKCS#8, PKCS#1 or SEC 1")
+}
+
+// InitKeys the keyset of jwt_keys, the access tokens are signed by
+// jwt_secret if none is configured, jwt_signing_key is the first key by default
+func InitKeys() error {
+	var configs []keyConfig
+	if err := viper.UnmarshalKey(JWT_KEYS, &configs); err != nil {
+		return errors.Wrap(err, "parse jwt_keys")
+	}
+	if len(configs) == 0 {
+		SetKeyset(nil)
+		return nil
+	}
+
+	keys := make([]*Key, 0, len(configs))
+	for _, c := range configs {
+		var private, public []byte
+		var err error
+		if c.PrivateKey != "" {
+			if private, err = ioutil.ReadFile(c.PrivateKey); err != nil {
+				return errors.Wrapf(err, "read private key of jwt key %s", c.ID)
+			}
+		} else if c.PublicKey != "" {
+			if public, err = ioutil.ReadFile(c.PublicKey); err != nil {
+				return errors.Wrapf(err, "read public key of jwt key %s", c.ID)
+			}
+		}
+		k, err := NewKey(c.ID, c.Alg, private, public)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, k)
+	}
+	signing := viper.GetString(JWT_SIGNING_KEY)
+	if signing == "" {
+		signing = configs[0].ID
+	}
+	ks, err := NewKeyset(signing, keys)
+	if err != nil {
+		return err
+	}
+	SetKeyset(ks)
+	return nil
+}
+
+// SetKeyset replace the keyset, nil signs the access tokens by jwt_secret
+func SetKeyset(ks *Keyset) {
+	keysLock.Lock()
+	defer keysLock.Unlock()
+	keyset = ks
+}
+
+func currentKeyset() *Keyset {
+	keysLock.RLock()
+	defer keysLock.RUnlock()
+	return keyset
+}
+
+// signingKey the key signing the access tokens, nil if not configured
+func signingKey() *Key {
+	if ks := currentKeyset(); ks != nil {
+		return ks.keys[ks.signing]
+	}
+	return nil
+}
+
+// verifyingKey the public key of the kid in the header of token, the alg
+// must be the one of the key
+func verifyingKey(token *jwt.Token) (interface{}, error) {
+	ks := currentKeyset()
+	if ks == nil {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	kid, _ := token.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok || k.Method.Alg() != token.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return k.public, nil
+}
+
+// JWK the public key in JSON web key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// ECDSA
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS the public keys verifying the access tokens, including the previous
+// ones, so that the other services verify the tokens without the secret
+func JWKS() []JWK {
+	ks := currentKeyset()
+	if ks == nil {
+		return []JWK{}
+	}
+	result := make([]JWK, 0, len(ks.keys))
+	// the signing key goes first
+	result = append(result, ks.keys[ks.signing].jwk())
+	for _, id := range ks.ids {
+		if id != ks.signing {
+			result = append(result, ks.keys[id].jwk())
+		}
+	}
+	return result
+}
+
+func (k *Key) jwk() JWK {
+	encode := base64.RawURLEncoding.EncodeToString
+	j := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		j.Kty = "RSA"
+		j.N = encode(pub.N.Bytes())
+		j.E = encode(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		j.Kty, j.Crv = "EC", "P-256"
+		j.X = encode(pub.X.FillBytes(make([]byte, size)))
+		j.Y = encode(pub.Y.FillBytes(make([]byte, size)))
+	}
+	return j
+}
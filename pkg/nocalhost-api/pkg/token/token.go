@@ -18,6 +18,17 @@ import (
 const (
 	JWT_SECRET         = "jwt_secret"
 	JWT_REFRESH_SECRET = "jwt_refresh_secret"
+
+	// access token should be short-lived, use refresh token to renew it
+	JWT_EXPIRE         = "jwt_expire"
+	JWT_REFRESH_EXPIRE = "jwt_refresh_expire"
+
+	// token for admin to impersonate other user, can not be refreshed
+	JWT_IMPERSONATE_EXPIRE = "jwt_impersonate_expire"
+
+	defaultExpire            = 2 * time.Hour
+	defaultRefreshExpire     = 14 * 24 * time.Hour
+	defaultImpersonateExpire = 15 * time.Minute
 )
 
 var (
@@ -32,6 +43,34 @@ type Context struct {
 	Uuid     string
 	Email    string
 	IsAdmin  uint64
+
+	// ActorID is the admin impersonating the user, zero if not impersonated
+	ActorID uint64
+
+	// SessionID identifies the login session, kept when refreshing
+	SessionID string
+
+	// Generation is the token generation of the user when signed, and the one
+	// of the actor if impersonated, the tokens of a previous one are revoked
+	Generation      uint64
+	ActorGeneration uint64
+}
+
+// IsImpersonated
+func (c *Context) IsImpersonated() bool {
+	return c.ActorID != 0
+}
+
+// IsRevoked returns true if the token is signed with a generation before the
+// current one of the user, which is increased by revoking the tokens
+func (c *Context) IsRevoked(generation uint64) bool {
+	return c.Generation < generation
+}
+
+// IsActorRevoked returns true if the tokens of the actor impersonating are
+// revoked after the token signed
+func (c *Context) IsActorRevoked(generation uint64) bool {
+	return c.ActorGeneration < generation
 }
 
 // secretFunc validates the secret format.
@@ -46,16 +85,39 @@ func secretFunc(secret string) jwt.Keyfunc {
 	}
 }
 
+// accessKeyFunc accepts the access tokens signed by the secret, or by the
+// keys of jwt_keys, including the previous ones
+func accessKeyFunc(secret string) jwt.Keyfunc {
+	hmac := secretFunc(secret)
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return hmac(token)
+		}
+		return verifyingKey(token)
+	}
+}
+
 func RefreshFromRequest(c *gin.Context) (neoSignToken, neoRefreshToken string, err error) {
+	refreshTokenCtx, err := ParseRefreshRequest(c)
+	if err != nil {
+		return "", "", err
+	}
+
+	return Sign(*refreshTokenCtx)
+}
+
+// ParseRefreshRequest gets the sign token and refresh token from the header,
+// returns the context of refresh token if they are matched
+func ParseRefreshRequest(c *gin.Context) (*Context, error) {
 	header := c.Request.Header.Get("Authorization")
 
 	if len(header) == 0 {
-		return "", "", ErrMissingHeader
+		return nil, ErrMissingHeader
 	}
 
 	var t string
 	// Parse the header to get the token part.
-	_, err = fmt.Sscanf(header, "Bearer %s", &t)
+	_, err := fmt.Sscanf(header, "Bearer %s", &t)
 	if err != nil {
 		fmt.Printf("fmt.Sscanf err: %+v", err)
 	}
@@ -67,42 +129,54 @@ func RefreshFromRequest(c *gin.Context) (neoSignToken, neoRefreshToken string, e
 		rt = c.GetHeader("reraeb")
 	}
 
-	return refreshToken(t, rt)
+	return parseRefreshToken(t, rt)
 }
 
-func refreshToken(signToken, refreshToken string) (neoSignToken, neoRefreshToken string, err error) {
+func parseRefreshToken(signToken, refreshToken string) (*Context, error) {
 	// Load the jwt secret from the Gin config if the secret does not specified.
 	secret := ""
 	secret = viper.GetString(JWT_SECRET)
 
 	signTokenCtx, err := Parse(signToken, secret, true)
 	if err != nil {
-		return "", "", err
+		return nil, err
+	}
+
+	if signTokenCtx.IsImpersonated() {
+		return nil, errors.New("Impersonation token can not be refreshed ")
 	}
 
+	// the refresh tokens are signed by the refresh secret only, so that the
+	// access tokens signed by the keys are never accepted as them
 	secret = viper.GetString(JWT_REFRESH_SECRET)
-	refreshTokenCtx, err := Parse(refreshToken, secret, false)
+	refreshTokenCtx, err := parse(refreshToken, secretFunc(secret), false)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 
 	if signTokenCtx.IsAdmin == refreshTokenCtx.IsAdmin &&
 		signTokenCtx.UserID == refreshTokenCtx.UserID &&
 		signTokenCtx.Email == refreshTokenCtx.Email &&
 		signTokenCtx.Uuid == refreshTokenCtx.Uuid &&
-		signTokenCtx.Username == refreshTokenCtx.Username {
+		signTokenCtx.Username == refreshTokenCtx.Username &&
+		signTokenCtx.SessionID == refreshTokenCtx.SessionID {
 
-		return Sign(*refreshTokenCtx)
+		return refreshTokenCtx, nil
 	}
 
-	return "", "", errors.New("Current refreshToken is not the corresponding token of signToken ")
+	return nil, errors.New("Current refreshToken is not the corresponding token of signToken ")
 }
 
 // Parse validates the token with the specified secret,
 // and returns the context if the token was valid.
+// The access tokens signed by the keys of jwt_keys are accepted too.
 func Parse(tokenString string, secret string, skipValidation bool) (*Context, error) {
+	return parse(tokenString, accessKeyFunc(secret), skipValidation)
+}
+
+func parse(tokenString string, keyFunc jwt.Keyfunc, skipValidation bool) (*Context, error) {
 	ctx := &Context{}
-	token, err := jwt.Parse(tokenString, secretFunc(secret))
+	token, err := jwt.Parse(tokenString, keyFunc)
 
 	if !skipValidation && err != nil {
 		return ctx, err
@@ -116,6 +190,18 @@ func Parse(tokenString string, secret string, skipValidation bool) (*Context, er
 		ctx.Uuid = claims["uuid"].(string)
 		ctx.Email = claims["email"].(string)
 		ctx.IsAdmin = uint64(claims["is_admin"].(float64))
+		if actor, ok := claims["actor_id"].(float64); ok {
+			ctx.ActorID = uint64(actor)
+		}
+		if sid, ok := claims["sid"].(string); ok {
+			ctx.SessionID = sid
+		}
+		if gen, ok := claims["gen"].(float64); ok {
+			ctx.Generation = uint64(gen)
+		}
+		if gen, ok := claims["actor_gen"].(float64); ok {
+			ctx.ActorGeneration = uint64(gen)
+		}
 		return ctx, nil
 
 		// Other errors.
@@ -127,13 +213,22 @@ func Parse(tokenString string, secret string, skipValidation bool) (*Context, er
 // ParseRequest gets the token from the header and
 // pass it to the Parse function to parses the token.
 func ParseRequest(c *gin.Context) (*Context, error) {
-	header := c.Request.Header.Get("Authorization")
-
 	// Load the jwt secret from config
 	secret := viper.GetString(JWT_SECRET)
 
+	t, err := BearerFromRequest(c)
+	if err != nil {
+		return &Context{}, err
+	}
+	return Parse(t, secret, false)
+}
+
+// BearerFromRequest gets the bearer token from the `Authorization` header
+func BearerFromRequest(c *gin.Context) (string, error) {
+	header := c.Request.Header.Get("Authorization")
+
 	if len(header) == 0 {
-		return &Context{}, ErrMissingHeader
+		return "", ErrMissingHeader
 	}
 
 	var t string
@@ -142,7 +237,7 @@ func ParseRequest(c *gin.Context) (*Context, error) {
 	if err != nil {
 		fmt.Printf("fmt.Sscanf err: %+v", err)
 	}
-	return Parse(t, secret, false)
+	return t, nil
 }
 
 func Sign(ctx Context) (tokenString, refreshToken string, err error) {
@@ -160,22 +255,65 @@ func Sign(ctx Context) (tokenString, refreshToken string, err error) {
 func SignToken(ctx Context) (tokenString string, err error) {
 	// Load the jwt secret from the Gin config if the secret does not specified.
 	secret := ""
-	secret = viper.GetString("jwt_secret")
+	secret = viper.GetString(JWT_SECRET)
+
+	return signAccess(ctx, secret, expireOrDefault(JWT_EXPIRE, defaultExpire))
+}
+
+// SignImpersonation signs the short-lived token for actor to act as the user,
+// no refresh token is issued
+func SignImpersonation(ctx Context) (tokenString string, expiresAt time.Time, err error) {
+	if ctx.ActorID == 0 {
+		return "", expiresAt, errors.New("actor is required for impersonation")
+	}
 
-	return sign(ctx, secret, 1)
+	expire := expireOrDefault(JWT_IMPERSONATE_EXPIRE, defaultImpersonateExpire)
+	tokenString, err = signAccess(ctx, viper.GetString(JWT_SECRET), expire)
+	return tokenString, time.Now().Add(expire), err
 }
 
 func SignRefreshToken(ctx Context) (tokenString string, err error) {
 	// Load the jwt secret from the Gin config if the secret does not specified.
 	secret := ""
-	secret = viper.GetString("jwt_refresh_secret")
+	secret = viper.GetString(JWT_REFRESH_SECRET)
+
+	return sign(ctx, secret, RefreshExpire())
+}
 
-	return sign(ctx, secret, 14)
+// RefreshExpire returns how long the refresh token lives, that is the
+// max idle time of a login session
+func RefreshExpire() time.Duration {
+	return expireOrDefault(JWT_REFRESH_EXPIRE, defaultRefreshExpire)
+}
+
+func expireOrDefault(key string, defaultExpire time.Duration) time.Duration {
+	if expire := viper.GetDuration(key); expire > 0 {
+		return expire
+	}
+	return defaultExpire
+}
+
+// signAccess signs the access token by the signing key of jwt_keys with its
+// kid, or by the secret if none is configured
+func signAccess(c Context, secret string, expire time.Duration) (tokenString string, err error) {
+	k := signingKey()
+	if k == nil {
+		return sign(c, secret, expire)
+	}
+	token := jwt.NewWithClaims(k.Method, claimsOf(c, expire))
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.private)
 }
 
 // Sign signs the context with the specified secret.
-func sign(c Context, secret string, expDays int) (tokenString string, err error) {
+func sign(c Context, secret string, expire time.Duration) (tokenString string, err error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsOf(c, expire))
+	// Sign the token with the specified secret.
+	tokenString, err = token.SignedString([]byte(secret))
+	return
+}
 
+func claimsOf(c Context, expire time.Duration) jwt.MapClaims {
 	// The token content.
 	// iss: （Issuer）
 	// iat: （Issued At）
@@ -184,19 +322,25 @@ func sign(c Context, secret string, expDays int) (tokenString string, err error)
 	// sub: （Subject）
 	// nbf: （Not Before）
 	// jti: （JWT ID）
-	token := jwt.NewWithClaims(
-		jwt.SigningMethodHS256, jwt.MapClaims{
-			"user_id":  c.UserID,
-			"username": c.Username,
-			"uuid":     c.Uuid,
-			"email":    c.Email,
-			"is_admin": c.IsAdmin,
-			"nbf":      time.Now().Unix(),
-			"iat":      time.Now().Unix(),
-			"exp":      time.Now().AddDate(0, 0, expDays).Unix(),
-		},
-	)
-	// Sign the token with the specified secret.
-	tokenString, err = token.SignedString([]byte(secret))
-	return
+	claims := jwt.MapClaims{
+		"user_id":  c.UserID,
+		"username": c.Username,
+		"uuid":     c.Uuid,
+		"email":    c.Email,
+		"is_admin": c.IsAdmin,
+		"nbf":      time.Now().Unix(),
+		"iat":      time.Now().Unix(),
+		"exp":      time.Now().Add(expire).Unix(),
+	}
+	if c.ActorID != 0 {
+		claims["actor_id"] = c.ActorID
+		claims["actor_gen"] = c.ActorGeneration
+	}
+	if c.Generation != 0 {
+		claims["gen"] = c.Generation
+	}
+	if c.SessionID != "" {
+		claims["sid"] = c.SessionID
+	}
+	return claims
 }
@@ -1,9 +1,16 @@
 package token
 
 import (
-	"github.com/golang-jwt/jwt/v4"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"testing"
 	"time"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
 func TestTokenInvalid(t *testing.T) {
@@ -52,3 +59,157 @@ func TestTokenInvalid(t *testing.T) {
 		println("invalid")
 	}
 }
+
+func TestTokenRevoked(t *testing.T) {
+	secret := "jwt_secret"
+
+	tokenString, err := sign(
+		Context{UserID: 1, Username: "Anur", Uuid: "UUID", Email: "anur@nocalhost.com", Generation: 2}, secret, time.Hour,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Parse(tokenString, secret, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ctx.IsRevoked(0) || ctx.IsRevoked(2) {
+		t.Error("token signed with the current generation should be valid")
+	}
+
+	if !ctx.IsRevoked(3) {
+		t.Error("token signed before revocation should be revoked, even in the same second")
+	}
+
+	// revoke and sign a new one at once, it is not revoked by the revocation before
+	tokenString, err = sign(Context{UserID: 1, Generation: 3}, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx, err = Parse(tokenString, secret, false); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.IsRevoked(3) {
+		t.Error("token signed after the revocation should be valid")
+	}
+}
+
+func TestImpersonationToken(t *testing.T) {
+	secret := "jwt_secret"
+
+	tokenString, err := sign(Context{UserID: 2, Username: "Bob", Uuid: "UUID", Email: "bob@nocalhost.com", ActorID: 1}, secret, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := Parse(tokenString, secret, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ctx.IsImpersonated() || ctx.ActorID != 1 || ctx.UserID != 2 {
+		t.Errorf("actor and subject should be kept in token, got actor %d and subject %d", ctx.ActorID, ctx.UserID)
+	}
+
+	if _, err := parseRefreshToken(tokenString, tokenString); err == nil {
+		t.Error("impersonation token should not be refreshed")
+	}
+}
+
+func newKey(t *testing.T, id, alg string) (*Key, []byte) {
+	var der []byte
+	var err error
+	if alg == "RS256" {
+		key, _ := rsa.GenerateKey(rand.Reader, 2048)
+		der = x509.MarshalPKCS1PrivateKey(key)
+	} else {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	k, err := NewKey(id, alg, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, _ := x509.MarshalPKIXPublicKey(k.public)
+	return k, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public})
+}
+
+func TestAsymmetricKeyRotation(t *testing.T) {
+	secret := "jwt_secret"
+	defer SetKeyset(nil)
+
+	previous, previousPublic := newKey(t, "previous", "RS256")
+	ks, err := NewKeyset("previous", []*Key{previous})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetKeyset(ks)
+
+	ctx := Context{UserID: 1, Username: "Anur", Uuid: "UUID", Email: "anur@nocalhost.com"}
+	old, err := signAccess(ctx, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmac, err := sign(ctx, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotate to the key of ES256, the previous one verifies only
+	current, _ := newKey(t, "current", "ES256")
+	verifying, err := NewKey("previous", "RS256", nil, previousPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewKeyset("previous", []*Key{current, verifying}); err == nil {
+		t.Error("key without the private key should not sign")
+	}
+	if ks, err = NewKeyset("current", []*Key{current, verifying}); err != nil {
+		t.Fatal(err)
+	}
+	SetKeyset(ks)
+
+	signed, err := signAccess(ctx, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tokenString := range map[string]string{"current": signed, "previous": old, "secret": hmac} {
+		if parsed, err := Parse(tokenString, secret, false); err != nil || parsed.UserID != 1 {
+			t.Errorf("token signed by the %s key should be valid, got %v", name, err)
+		}
+	}
+
+	// the access tokens of the keys are not refresh tokens
+	if _, err := parseRefreshToken(signed, signed); err == nil {
+		t.Error("access token signed by the key should not be accepted as refresh token")
+	}
+
+	SetKeyset(nil)
+	if _, err := Parse(signed, secret, false); err == nil {
+		t.Error("token of the key removed should be invalid")
+	}
+	SetKeyset(ks)
+
+	keys := JWKS()
+	if len(keys) != 2 || keys[0].Kid != "current" || keys[0].Kty != "EC" || keys[1].Kty != "RSA" {
+		t.Fatalf("unexpected jwks %+v", keys)
+	}
+	if keys[0].Crv != "P-256" || len(keys[0].X) != 43 || keys[1].E != "AQAB" {
+		t.Errorf("unexpected jwk %+v", keys)
+	}
+}
+
+func TestNewKeyMismatched(t *testing.T) {
+	_, public := newKey(t, "rsa", "RS256")
+	if _, err := NewKey("rsa", "ES256", nil, public); err == nil {
+		t.Error("RSA key should not be used for ES256")
+	}
+	if _, err := NewKey("rsa", "HS256", nil, public); err == nil {
+		t.Error("HS256 should not be configured as key")
+	}
+}
@@ -0,0 +1,62 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	// test vectors from RFC 6238 with SHA1, truncate to 6 digits
+	key := []byte("12345678901234567890")
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for ts, expect := range cases {
+		if got := generate(key, uint64(ts/Period)); got != expect {
+			t.Errorf("time %d: expect %s but got %s", ts, expect, got)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	if !Validate(secret, "081804", now) {
+		t.Error("code of current step should be valid")
+	}
+
+	if !Validate(secret, "081804", now.Add(Period*time.Second)) {
+		t.Error("code of previous step should be valid")
+	}
+
+	if Validate(secret, "081804", now.Add(3*Period*time.Second)) {
+		t.Error("code out of skew should be invalid")
+	}
+
+	if Validate(secret, "000000", now) {
+		t.Error("incorrect code should be invalid")
+	}
+}
+
+func TestValidateStep(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+	now := time.Unix(1111111109, 0)
+
+	if step, ok := ValidateStep(secret, "081804", now); !ok || step != 1111111109/Period {
+		t.Errorf("expect step %d but got %d, %v", 1111111109/Period, step, ok)
+	}
+
+	if step, ok := ValidateStep(secret, "081804", now.Add(Period*time.Second)); !ok || step != 1111111109/Period {
+		t.Errorf("code of previous step should match the step of it, but got %d, %v", step, ok)
+	}
+
+	if _, ok := ValidateStep(secret, "000000", now); ok {
+		t.Error("incorrect code should be invalid")
+	}
+}
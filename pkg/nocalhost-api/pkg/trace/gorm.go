@@ -0,0 +1,85 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package trace
+
+import (
+	"context"
+
+	"github.com/jinzhu/gorm"
+)
+
+const (
+	gormKey     = "nocalhost:trace"
+	gormSpanKey = "nocalhost:trace_span"
+)
+
+// DB the db whose queries are traced as the children of the trace of ctx,
+// gorm v1 does not carry context, so the trace is set to the scope
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if t := FromContext(ctx); t != nil && current() != nil {
+		return db.Set(gormKey, t)
+	}
+	return db
+}
+
+// RegisterGormCallbacks record the create, update, delete and query
+// operations of db as spans, if the db is traced by DB
+func RegisterGormCallbacks(db *gorm.DB) {
+	callback := db.Callback()
+	for _, processor := range []struct {
+		operation     string
+		p             *gorm.CallbackProcessor
+		before, after string
+	}{
+		{"create", callback.Create(), "gorm:begin_transaction", "gorm:commit_or_rollback_transaction"},
+		{"update", callback.Update(), "gorm:begin_transaction", "gorm:commit_or_rollback_transaction"},
+		{"delete", callback.Delete(), "gorm:begin_transaction", "gorm:commit_or_rollback_transaction"},
+		{"query", callback.Query(), "gorm:query", "gorm:after_query"},
+		{"row_query", callback.RowQuery(), "gorm:row_query", "gorm:row_query"},
+	} {
+		operation := processor.operation
+		processor.p.Before(processor.before).Register(
+			"trace:before_"+operation, func(scope *gorm.Scope) { startQuery(scope, operation) },
+		)
+		processor.p.After(processor.after).Register("trace:after_"+operation, endQuery)
+	}
+}
+
+func startQuery(scope *gorm.Scope, operation string) {
+	value, ok := scope.Get(gormKey)
+	if !ok {
+		return
+	}
+	parent, ok := value.(*Context)
+	if !ok || parent == nil {
+		return
+	}
+	table := scope.TableName()
+	span := Begin(parent.Child(), parent.SpanID, "db "+operation+" "+table, KindClient).
+		Set("db.system", scope.Dialect().GetName()).
+		Set("db.operation", operation).
+		Set("db.sql.table", table)
+	if span != nil {
+		scope.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func endQuery(scope *gorm.Scope) {
+	value, ok := scope.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(*Span)
+	if !ok {
+		return
+	}
+	span.Set("db.statement", scope.SQL)
+	if err := scope.DB().Error; err != nil && !gorm.IsRecordNotFoundError(err) {
+		span.End(err)
+		return
+	}
+	span.End(nil)
+}
@@ -0,0 +1,243 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+const (
+	queueSize     = 2048
+	batchSize     = 512
+	flushInterval = 5 * time.Second
+)
+
+// Exporter exports the spans ended to the OTLP/HTTP endpoint of collector in
+// batches, the spans are dropped if the queue is full
+type Exporter struct {
+	endpoint string
+	service  string
+	client   *http.Client
+	queue    chan *trace.Span
+	flushes  chan chan struct{}
+	interval time.Duration
+}
+
+// NewExporter the exporter of endpoint, e.g. http://otel-collector:4318, the
+// spans are posted to /v1/traces of it, service is nocalhost-api if empty
+func NewExporter(endpoint, service string) *Exporter {
+	if service == "" {
+		service = "nocalhost-api"
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	return &Exporter{
+		endpoint: endpoint,
+		service:  service,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		queue:    make(chan *trace.Span, queueSize),
+		flushes:  make(chan chan struct{}),
+		interval: flushInterval,
+	}
+}
+
+// Start recording spans and export them in background
+func Start(e *Exporter) {
+	trace.Register(e)
+	go e.run()
+	log.Infof("export traces to %s", e.endpoint)
+}
+
+// Enqueue the span ended for exporting
+func (e *Exporter) Enqueue(s *trace.Span) {
+	select {
+	case e.queue <- s:
+	default:
+		log.Debugf("trace queue is full, span %s dropped", s.Name)
+	}
+}
+
+func (e *Exporter) run() {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	batch := make([]*trace.Span, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.Export(batch); err != nil {
+			log.Warnf("export %d spans err: %v", len(batch), err)
+		}
+		batch = make([]*trace.Span, 0, batchSize)
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case done := <-e.flushes:
+			for queued := len(e.queue); queued > 0; queued-- {
+				batch = append(batch, <-e.queue)
+				if len(batch) >= batchSize {
+					flush()
+				}
+			}
+			flush()
+			close(done)
+		}
+	}
+}
+
+// Flush export the spans queued, e.g. before the process exits, the exporter
+// must be started
+func (e *Exporter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case e.flushes <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Export the spans in OTLP JSON
+func (e *Exporter) Export(spans []*trace.Span) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.New(fmt.Sprintf("collector responded %s", resp.Status))
+	}
+	return nil
+}
+
+// the messages of OTLP JSON, ids are in hex and timestamps are in strings
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              trace.Kind      `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            otlpStatus      `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string                 `json:"key"`
+		Value map[string]interface{} `json:"value"`
+	}
+)
+
+const (
+	statusOk    = 1
+	statusError = 2
+)
+
+func (e *Exporter) request(spans []*trace.Span) otlpRequest {
+	converted := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentID,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.StartTime.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime.UnixNano(), 10),
+			Status:            otlpStatus{Code: statusOk},
+		}
+		if s.Error != "" {
+			span.Status = otlpStatus{Code: statusError, Message: s.Error}
+		}
+		if s.RequestID != "" {
+			span.Attributes = append(span.Attributes, attribute("request.id", s.RequestID))
+		}
+		for key, value := range s.Attributes {
+			span.Attributes = append(span.Attributes, attribute(key, value))
+		}
+		converted = append(converted, span)
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{
+			{
+				Resource: otlpResource{Attributes: []otlpAttribute{attribute("service.name", e.service)}},
+				ScopeSpans: []otlpScopeSpans{
+					{Scope: otlpScope{Name: "nocalhost/pkg/nocalhost-api/pkg/trace"}, Spans: converted},
+				},
+			},
+		},
+	}
+}
+
+func attribute(key string, value interface{}) otlpAttribute {
+	switch v := value.(type) {
+	case bool:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"boolValue": v}}
+	case int:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"intValue": strconv.Itoa(v)}}
+	case int64:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}}
+	case uint64:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"intValue": strconv.FormatUint(v, 10)}}
+	case float64:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"doubleValue": v}}
+	default:
+		return otlpAttribute{Key: key, Value: map[string]interface{}{"stringValue": fmt.Sprint(v)}}
+	}
+}
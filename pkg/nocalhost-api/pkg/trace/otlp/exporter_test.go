@@ -0,0 +1,103 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package otlp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"nocalhost/pkg/nocalhost-api/pkg/trace"
+)
+
+func TestExport(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_ = json.NewDecoder(r.Body).Decode(&received)
+			},
+		),
+	)
+	defer server.Close()
+
+	parent := trace.New()
+	span := &trace.Span{
+		Context:    parent.Child(),
+		ParentID:   parent.SpanID,
+		Name:       "create namespace",
+		Kind:       trace.KindInternal,
+		StartTime:  time.Unix(1, 0),
+		EndTime:    time.Unix(2, 0),
+		Attributes: map[string]interface{}{"k8s.namespace.name": "nh-dev"},
+		Error:      "forbidden",
+	}
+	if err := NewExporter(server.URL, "").Export([]*trace.Span{span}); err != nil {
+		t.Fatal(err)
+	}
+
+	resource := received["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	service := resource["resource"].(map[string]interface{})["attributes"].([]interface{})[0]
+	if service.(map[string]interface{})["value"].(map[string]interface{})["stringValue"] != "nocalhost-api" {
+		t.Fatalf("unexpected resource %v", resource["resource"])
+	}
+	scope := resource["scopeSpans"].([]interface{})[0].(map[string]interface{})
+	s := scope["spans"].([]interface{})[0].(map[string]interface{})
+	if s["traceId"] != parent.TraceID || s["parentSpanId"] != parent.SpanID || s["name"] != "create namespace" ||
+		s["startTimeUnixNano"] != "1000000000" || s["endTimeUnixNano"] != "2000000000" {
+		t.Fatalf("unexpected span %v", s)
+	}
+	if status := s["status"].(map[string]interface{}); status["code"] != float64(statusError) ||
+		status["message"] != "forbidden" {
+		t.Fatalf("unexpected status %v", status)
+	}
+
+	if err := NewExporter(server.URL+"/unknown", "").Export([]*trace.Span{span}); err == nil {
+		t.Fatal("expect export failed")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	received := make(chan int, 1)
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var request otlpRequest
+				_ = json.NewDecoder(r.Body).Decode(&request)
+				received <- len(request.ResourceSpans[0].ScopeSpans[0].Spans)
+			},
+		),
+	)
+	defer server.Close()
+
+	e := NewExporter(server.URL, "")
+	go e.run()
+	parent := trace.New()
+	for i := 0; i < 2; i++ {
+		e.Enqueue(&trace.Span{Context: parent.Child(), ParentID: parent.SpanID, Name: "query"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := e.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case n := <-received:
+		if n != 2 {
+			t.Fatalf("expect 2 spans exported, got %d", n)
+		}
+	default:
+		t.Fatal("expect spans exported before flush returned")
+	}
+}
@@ -0,0 +1,130 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package trace
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kind the kind of span, the values are the same as OTLP
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+// Span the span recorded and exported by the exporter, the methods of nil
+// span do nothing, so that the spans can be started without checking whether
+// tracing is enabled
+type Span struct {
+	*Context
+	ParentID   string
+	Name       string
+	Kind       Kind
+	StartTime  time.Time
+	EndTime    time.Time
+	Attributes map[string]interface{}
+	Error      string
+
+	lock sync.Mutex
+	done bool
+}
+
+// Begin record the span of c whose parent is parentID, nil if tracing is
+// disabled or c is not sampled
+func Begin(c *Context, parentID, name string, kind Kind) *Span {
+	if c == nil || current() == nil || !c.Sampled() {
+		return nil
+	}
+	return &Span{
+		Context:    c,
+		ParentID:   parentID,
+		Name:       name,
+		Kind:       kind,
+		StartTime:  time.Now(),
+		Attributes: map[string]interface{}{},
+	}
+}
+
+// StartSpan start an internal span as the child of the trace of ctx, the ctx
+// returned carries the span, nothing is recorded if ctx is not traced
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := Begin(parent.Child(), parent.SpanID, name, KindInternal)
+	if span == nil {
+		return ctx, nil
+	}
+	return NewContext(ctx, span.Context), span
+}
+
+// Set the attribute of span
+func (s *Span) Set(key string, value interface{}) *Span {
+	if s == nil {
+		return s
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Attributes[key] = value
+	return s
+}
+
+// End the span and export it, err is recorded as the status of span, only the
+// first call takes effect
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.done {
+		s.lock.Unlock()
+		return
+	}
+	s.done = true
+	s.EndTime = time.Now()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.lock.Unlock()
+
+	if p := current(); p != nil {
+		p.Enqueue(s)
+	}
+}
+
+// Processor processes the spans ended, e.g. exports them to the collector
+type Processor interface {
+	Enqueue(s *Span)
+}
+
+var processor atomic.Value
+
+// Register the processor of spans, the spans are not recorded until a
+// processor is registered
+func Register(p Processor) {
+	processor.Store(&p)
+}
+
+func current() Processor {
+	if p, ok := processor.Load().(*Processor); ok {
+		return *p
+	}
+	return nil
+}
+
+// Sampled whether the trace is sampled by the flags
+func (c *Context) Sampled() bool {
+	flags, err := strconv.ParseUint(c.Flags, 16, 8)
+	return err == nil && flags&1 == 1
+}
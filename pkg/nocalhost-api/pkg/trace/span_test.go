@@ -0,0 +1,54 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package trace
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type recorder []*Span
+
+func (r *recorder) Enqueue(s *Span) {
+	*r = append(*r, s)
+}
+
+func TestSpan(t *testing.T) {
+	parent := New()
+	if Begin(parent, "", "request", KindServer) != nil {
+		t.Fatal("expect nothing recorded until a processor is registered")
+	}
+
+	r := &recorder{}
+	Register(r)
+
+	server := Begin(parent, "", "request", KindServer).Set("http.status_code", 200)
+	ctx, span := StartSpan(NewContext(context.Background(), parent), "create namespace")
+	if FromContext(ctx) != span.Context || span.ParentID != parent.SpanID || span.TraceID != parent.TraceID {
+		t.Fatalf("unexpected span %v of %v", span.Context, parent)
+	}
+	span.End(errors.New("forbidden"))
+	span.End(nil)
+	server.End(nil)
+
+	if len(*r) != 2 || (*r)[0] != span || (*r)[1] != server {
+		t.Fatalf("expect spans ended once, got %v", *r)
+	}
+	if span.Error != "forbidden" || server.Attributes["http.status_code"] != 200 {
+		t.Fatalf("unexpected spans %v %v", span, server)
+	}
+
+	if _, span := StartSpan(context.Background(), "untraced"); span != nil {
+		t.Fatal("expect untraced span not recorded")
+	}
+	parent.Flags = "00"
+	if Begin(parent, "", "request", KindServer) != nil {
+		t.Fatal("expect trace not sampled not recorded")
+	}
+	var none *Span
+	none.Set("key", "value").End(nil)
+}
@@ -0,0 +1,135 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// Header the header of W3C trace context
+	Header = "traceparent"
+
+	// GinKey the key of trace in gin context, gin looks up the values of
+	// string keys in context keys
+	GinKey = "nocalhost:trace"
+
+	version = "00"
+	sampled = "01"
+)
+
+type contextKey struct{}
+
+// Context the W3C trace context of the request of api, with the id of
+// request which is not propagated
+type Context struct {
+	TraceID   string
+	SpanID    string
+	Flags     string
+	RequestID string
+}
+
+// Parse the header traceparent, e.g.
+// 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01
+func Parse(header string) (*Context, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || parts[0] == "ff" || !validHex(parts[0], 2) ||
+		!validID(parts[1], 32) || !validID(parts[2], 16) || !validHex(parts[3], 2) {
+		return nil, false
+	}
+	// future versions may append fields
+	if parts[0] == version && len(parts) != 4 {
+		return nil, false
+	}
+	return &Context{TraceID: parts[1], SpanID: parts[2], Flags: parts[3]}, true
+}
+
+// New the context of a new trace
+func New() *Context {
+	return &Context{TraceID: randomID(16), SpanID: randomID(8), Flags: sampled}
+}
+
+// Child the context of span in the trace, whose parent is the span of c
+func (c *Context) Child() *Context {
+	return &Context{TraceID: c.TraceID, SpanID: randomID(8), Flags: c.Flags, RequestID: c.RequestID}
+}
+
+// String the header traceparent of context
+func (c *Context) String() string {
+	return version + "-" + c.TraceID + "-" + c.SpanID + "-" + c.Flags
+}
+
+// NewContext returns the context carries the trace
+func NewContext(ctx context.Context, c *Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext the trace of context, either the request context or gin
+// context, nil if not traced
+func FromContext(ctx context.Context) *Context {
+	if ctx == nil {
+		return nil
+	}
+	if c, ok := ctx.Value(contextKey{}).(*Context); ok {
+		return c
+	}
+	if c, ok := ctx.Value(GinKey).(*Context); ok {
+		return c
+	}
+	return nil
+}
+
+// Binding the trace bound to a client, for the requests of client-go which
+// are mostly sent with context.TODO
+type Binding struct {
+	value atomic.Value
+}
+
+// Bind the trace of ctx, nothing changes if ctx is not traced
+func (b *Binding) Bind(ctx context.Context) {
+	if c := FromContext(ctx); c != nil {
+		b.value.Store(c)
+	}
+}
+
+// Get the trace bound, nil if not bound
+func (b *Binding) Get() *Context {
+	c, _ := b.value.Load().(*Context)
+	return c
+}
+
+func randomID(size int) string {
+	b := make([]byte, size)
+	for {
+		_, _ = rand.Read(b)
+		// the id of all zeros is invalid
+		for _, v := range b {
+			if v != 0 {
+				return hex.EncodeToString(b)
+			}
+		}
+	}
+}
+
+func validID(id string, length int) bool {
+	return validHex(id, length) && strings.Trim(id, "0") != ""
+}
+
+func validHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
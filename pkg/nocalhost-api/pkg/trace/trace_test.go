@@ -0,0 +1,80 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package trace
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParse(t *testing.T) {
+	c, ok := Parse("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	if !ok || c.TraceID != "0af7651916cd43dd8448eb211c80319c" || c.SpanID != "b7ad6b7169203331" || c.Flags != "01" {
+		t.Fatalf("unexpected trace %v", c)
+	}
+	if _, ok := Parse("01-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-00-future"); !ok {
+		t.Fatal("expect later versions with more fields are accepted")
+	}
+
+	for _, header := range []string{
+		"",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331",
+		"00-00000000000000000000000000000000-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-0000000000000000-01",
+		"00-0AF7651916CD43DD8448EB211C80319C-b7ad6b7169203331-01",
+		"ff-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01",
+		"00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01-extra",
+	} {
+		if _, ok := Parse(header); ok {
+			t.Errorf("expect %q invalid", header)
+		}
+	}
+}
+
+func TestChild(t *testing.T) {
+	parent := New()
+	parent.RequestID = "request"
+	if !regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`).MatchString(parent.String()) {
+		t.Fatalf("unexpected traceparent %s", parent)
+	}
+
+	child := parent.Child()
+	if child.TraceID != parent.TraceID || child.SpanID == parent.SpanID || child.RequestID != "request" {
+		t.Fatalf("unexpected child %v of %v", child, parent)
+	}
+	if parsed, ok := Parse(child.String()); !ok || parsed.SpanID != child.SpanID {
+		t.Fatalf("child %s can not be parsed", child)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	c := New()
+	if FromContext(context.Background()) != nil {
+		t.Fatal("expect no trace")
+	}
+	if FromContext(NewContext(context.Background(), c)) != c {
+		t.Fatal("expect trace of request context")
+	}
+
+	g := &gin.Context{}
+	g.Set(GinKey, c)
+	if FromContext(g) != c {
+		t.Fatal("expect trace of gin context")
+	}
+
+	var binding Binding
+	binding.Bind(context.Background())
+	if binding.Get() != nil {
+		t.Fatal("expect nothing bound")
+	}
+	binding.Bind(g)
+	if binding.Get() != c {
+		t.Fatal("expect trace bound")
+	}
+}
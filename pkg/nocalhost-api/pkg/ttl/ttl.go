@@ -0,0 +1,119 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package ttl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	TTL_CHECK_INTERVAL = "dev_space_ttl.check_interval"
+	TTL_WARN_BEFORE    = "dev_space_ttl.warn_before"
+	TTL_IDLE           = "dev_space_ttl.idle"
+
+	// ClusterDefaultAnnotation of cluster is the ttl of dev spaces created
+	// in the cluster if not specified
+	ClusterDefaultAnnotation = "nocalhost.dev/dev-space-ttl"
+
+	defaultCheckInterval = 10 * time.Minute
+	defaultWarnBefore    = 24 * time.Hour
+)
+
+// CheckInterval returns the interval of reaping the dev spaces expired
+func CheckInterval() time.Duration {
+	if d := viper.GetDuration(TTL_CHECK_INTERVAL); d > 0 {
+		return d
+	}
+	return defaultCheckInterval
+}
+
+// WarnBefore returns how long before the expiry the owner is warned
+func WarnBefore() time.Duration {
+	if d := viper.GetDuration(TTL_WARN_BEFORE); d > 0 {
+		return d
+	}
+	return defaultWarnBefore
+}
+
+// Idle returns how long the dev spaces expire after the last activity, 0
+// disables it, the format is the one of Parse
+func Idle() time.Duration {
+	if s := viper.GetString(TTL_IDLE); s != "" && s != "0" {
+		if d, err := Parse(s); err == nil {
+			return d
+		}
+	}
+	return 0
+}
+
+// Effective returns the expiry of dev space and when the owner was warned,
+// the dev space expires after idle since the last activity if it is
+// earlier, and the owner is warned again once it is active after the warning
+func Effective(expireAt, warnedAt *time.Time, lastActive time.Time, idle time.Duration) (*time.Time, *time.Time) {
+	if idle <= 0 {
+		return expireAt, warnedAt
+	}
+	idleAt := lastActive.Add(idle)
+	if expireAt != nil && !idleAt.Before(*expireAt) {
+		return expireAt, warnedAt
+	}
+	if warnedAt != nil && warnedAt.Before(lastActive) {
+		warnedAt = nil
+	}
+	return &idleAt, warnedAt
+}
+
+// Parse the ttl, days are supported besides the units of time.Duration,
+// such as 7d or 1d12h
+func Parse(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	var days time.Duration
+	if i := strings.Index(s, "d"); i > 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, errors.Errorf("invalid ttl %s", s)
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[i+1:]
+	}
+
+	var d time.Duration
+	if s != "" {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, errors.Errorf("invalid ttl %s", s)
+		}
+	}
+	if d+days <= 0 {
+		return 0, errors.New("ttl must be positive")
+	}
+	return d + days, nil
+}
+
+// ClusterDefault returns the default ttl from the annotations of cluster,
+// 0 if not set or invalid
+func ClusterDefault(annotations map[string]string) time.Duration {
+	value, ok := annotations[ClusterDefaultAnnotation]
+	if !ok {
+		return 0
+	}
+	d, err := Parse(value)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+// ShouldWarn returns true if it expires within WarnBefore and the owner
+// has not been warned
+func ShouldWarn(now, expireAt time.Time, warnedAt *time.Time) bool {
+	return warnedAt == nil && now.Before(expireAt) && !now.Before(expireAt.Add(-WarnBefore()))
+}
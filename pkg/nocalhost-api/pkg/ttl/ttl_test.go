@@ -0,0 +1,76 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package ttl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]time.Duration{
+		"72h":   72 * time.Hour,
+		"7d":    7 * 24 * time.Hour,
+		"1d12h": 36 * time.Hour,
+		"30m":   30 * time.Minute,
+	}
+	for s, expected := range cases {
+		if d, err := Parse(s); err != nil || d != expected {
+			t.Errorf("ttl of %s is %v %v", s, d, err)
+		}
+	}
+
+	for _, s := range []string{"", "0h", "-1h", "xd", "1w"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("%s should be invalid", s)
+		}
+	}
+
+	if ClusterDefault(map[string]string{ClusterDefaultAnnotation: "2d"}) != 48*time.Hour {
+		t.Error("unexpected default ttl of cluster")
+	}
+	if ClusterDefault(map[string]string{ClusterDefaultAnnotation: "invalid"}) != 0 || ClusterDefault(nil) != 0 {
+		t.Error("default ttl of cluster should be 0")
+	}
+}
+
+func TestShouldWarn(t *testing.T) {
+	now := time.Now()
+	if !ShouldWarn(now, now.Add(time.Hour), nil) {
+		t.Error("should warn within a day")
+	}
+	if ShouldWarn(now, now.Add(48*time.Hour), nil) {
+		t.Error("should not warn too early")
+	}
+	if ShouldWarn(now, now.Add(time.Hour), &now) {
+		t.Error("should not warn twice")
+	}
+	if ShouldWarn(now, now.Add(-time.Hour), nil) {
+		t.Error("should not warn after expired")
+	}
+}
+
+func TestEffective(t *testing.T) {
+	now := time.Now()
+	later := now.Add(48 * time.Hour)
+	if expireAt, _ := Effective(&later, nil, now, 0); expireAt != &later {
+		t.Error("idle expiry should be disabled")
+	}
+	if expireAt, _ := Effective(&later, nil, now, 72*time.Hour); expireAt != &later {
+		t.Error("the earlier ttl should be kept")
+	}
+	if expireAt, _ := Effective(nil, nil, now, 24*time.Hour); expireAt == nil || !expireAt.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("should expire a day after the last activity, but %v", expireAt)
+	}
+
+	warned := now.Add(-time.Hour)
+	if _, warnedAt := Effective(nil, &warned, now, 24*time.Hour); warnedAt != nil {
+		t.Error("should warn again after active")
+	}
+	if _, warnedAt := Effective(nil, &warned, now.Add(-2*time.Hour), 24*time.Hour); warnedAt != &warned {
+		t.Error("should not warn twice if inactive")
+	}
+}
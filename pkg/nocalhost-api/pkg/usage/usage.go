@@ -0,0 +1,218 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package usage
+
+import (
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+)
+
+// Quantity of cpu is in cores and memory is in Mi, capacity and
+// allocatable are only available for the cluster and nodes
+type Quantity struct {
+	Capacity    float64 `json:"capacity,omitempty"`
+	Allocatable float64 `json:"allocatable,omitempty"`
+	Requests    float64 `json:"requests"`
+	Limits      float64 `json:"limits"`
+	Usage       float64 `json:"usage"`
+}
+
+type Usage struct {
+	Cpu    Quantity `json:"cpu"`
+	Memory Quantity `json:"memory"`
+	Pods   int      `json:"pods"`
+}
+
+type Node struct {
+	Name string `json:"name"`
+	Usage
+}
+
+type Namespace struct {
+	Namespace string `json:"namespace"`
+	Usage
+}
+
+// Cluster aggregates the requests, limits and real usage of the nodes
+// and namespaces, real usage is zero if metrics-server is unavailable
+type Cluster struct {
+	Usage
+	MetricsAvailable bool        `json:"metrics_available"`
+	Nodes            []Node      `json:"nodes"`
+	Namespaces       []Namespace `json:"namespaces"`
+}
+
+// Aggregate the usage of nodes and namespaces, the pods completed are ignored
+func Aggregate(
+	nodes []corev1.Node, pods []corev1.Pod, nodeMetrics []clientgo.NodeMetrics, podMetrics []clientgo.PodMetrics,
+) *Cluster {
+	nodeUsage := map[string]*Usage{}
+	var nodeNames []string
+	for _, node := range nodes {
+		u := &Usage{}
+		u.Cpu.Capacity = cpu(node.Status.Capacity)
+		u.Cpu.Allocatable = cpu(node.Status.Allocatable)
+		u.Memory.Capacity = memory(node.Status.Capacity)
+		u.Memory.Allocatable = memory(node.Status.Allocatable)
+		nodeUsage[node.Name] = u
+		nodeNames = append(nodeNames, node.Name)
+	}
+
+	nsUsage := map[string]*Usage{}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		requests, limits := podRequestsAndLimits(&pod)
+		ns := namespaceOf(nsUsage, pod.Namespace)
+		ns.add(requests, limits)
+		if u, ok := nodeUsage[pod.Spec.NodeName]; ok {
+			u.add(requests, limits)
+		}
+	}
+
+	for _, m := range nodeMetrics {
+		if u, ok := nodeUsage[m.Name]; ok {
+			u.Cpu.Usage += cpu(m.Usage)
+			u.Memory.Usage += memory(m.Usage)
+		}
+	}
+	for _, m := range podMetrics {
+		ns := namespaceOf(nsUsage, m.Namespace)
+		for _, c := range m.Containers {
+			ns.Cpu.Usage += cpu(c.Usage)
+			ns.Memory.Usage += memory(c.Usage)
+		}
+	}
+
+	result := &Cluster{
+		MetricsAvailable: len(nodeMetrics) > 0,
+		Nodes:            make([]Node, 0, len(nodeUsage)),
+		Namespaces:       make([]Namespace, 0, len(nsUsage)),
+	}
+	sort.Strings(nodeNames)
+	for _, name := range nodeNames {
+		u := nodeUsage[name]
+		u.round()
+		result.Nodes = append(result.Nodes, Node{Name: name, Usage: *u})
+		result.sum(u)
+	}
+
+	var namespaces []string
+	for name := range nsUsage {
+		namespaces = append(namespaces, name)
+	}
+	sort.Strings(namespaces)
+	for _, name := range namespaces {
+		u := nsUsage[name]
+		u.round()
+		result.Namespaces = append(result.Namespaces, Namespace{Namespace: name, Usage: *u})
+	}
+	result.Usage.round()
+	return result
+}
+
+// NamespaceUsage aggregate the usage of pods in a namespace
+func NamespaceUsage(namespace string, pods []corev1.Pod, podMetrics []clientgo.PodMetrics) Namespace {
+	for _, ns := range Aggregate(nil, pods, nil, podMetrics).Namespaces {
+		if ns.Namespace == namespace {
+			return ns
+		}
+	}
+	return Namespace{Namespace: namespace}
+}
+
+func namespaceOf(m map[string]*Usage, namespace string) *Usage {
+	u, ok := m[namespace]
+	if !ok {
+		u = &Usage{}
+		m[namespace] = u
+	}
+	return u
+}
+
+func (c *Cluster) sum(u *Usage) {
+	c.Cpu.Capacity += u.Cpu.Capacity
+	c.Cpu.Allocatable += u.Cpu.Allocatable
+	c.Cpu.Requests += u.Cpu.Requests
+	c.Cpu.Limits += u.Cpu.Limits
+	c.Cpu.Usage += u.Cpu.Usage
+	c.Memory.Capacity += u.Memory.Capacity
+	c.Memory.Allocatable += u.Memory.Allocatable
+	c.Memory.Requests += u.Memory.Requests
+	c.Memory.Limits += u.Memory.Limits
+	c.Memory.Usage += u.Memory.Usage
+	c.Pods += u.Pods
+}
+
+func (u *Usage) add(requests, limits corev1.ResourceList) {
+	u.Cpu.Requests += cpu(requests)
+	u.Cpu.Limits += cpu(limits)
+	u.Memory.Requests += memory(requests)
+	u.Memory.Limits += memory(limits)
+	u.Pods++
+}
+
+func (u *Usage) round() {
+	for _, q := range []*Quantity{&u.Cpu, &u.Memory} {
+		q.Capacity = round(q.Capacity)
+		q.Allocatable = round(q.Allocatable)
+		q.Requests = round(q.Requests)
+		q.Limits = round(q.Limits)
+		q.Usage = round(q.Usage)
+	}
+}
+
+// podRequestsAndLimits is the same as the scheduler, the max of the
+// sum of containers and each init container
+func podRequestsAndLimits(pod *corev1.Pod) (corev1.ResourceList, corev1.ResourceList) {
+	requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		addResourceList(requests, c.Resources.Requests)
+		addResourceList(limits, c.Resources.Limits)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		maxResourceList(requests, c.Resources.Requests)
+		maxResourceList(limits, c.Resources.Limits)
+	}
+	return requests, limits
+}
+
+func addResourceList(list, other corev1.ResourceList) {
+	for name, q := range other {
+		if v, ok := list[name]; ok {
+			v.Add(q)
+			list[name] = v
+		} else {
+			list[name] = q.DeepCopy()
+		}
+	}
+}
+
+func maxResourceList(list, other corev1.ResourceList) {
+	for name, q := range other {
+		if v, ok := list[name]; !ok || q.Cmp(v) > 0 {
+			list[name] = q.DeepCopy()
+		}
+	}
+}
+
+func cpu(list corev1.ResourceList) float64 {
+	return float64(list.Cpu().MilliValue()) / 1000
+}
+
+func memory(list corev1.ResourceList) float64 {
+	return float64(list.Memory().Value()) / 1024 / 1024
+}
+
+func round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,100 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package usage
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"nocalhost/pkg/nocalhost-api/pkg/clientgo"
+)
+
+func resources(cpu, memory string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}
+}
+
+func pod(namespace, node string, phase corev1.PodPhase, containers ...corev1.ResourceList) corev1.Pod {
+	p := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+	for _, c := range containers {
+		p.Spec.Containers = append(
+			p.Spec.Containers, corev1.Container{
+				Resources: corev1.ResourceRequirements{Requests: c, Limits: c},
+			},
+		)
+	}
+	return p
+}
+
+func TestAggregate(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status:     corev1.NodeStatus{Capacity: resources("4", "8Gi"), Allocatable: resources("3800m", "7Gi")},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Status:     corev1.NodeStatus{Capacity: resources("2", "4Gi"), Allocatable: resources("2", "4Gi")},
+		},
+	}
+
+	withInit := pod("dev-1", "node-2", corev1.PodRunning, resources("100m", "128Mi"))
+	withInit.Spec.InitContainers = []corev1.Container{
+		{Resources: corev1.ResourceRequirements{Requests: resources("1", "64Mi")}},
+	}
+
+	pods := []corev1.Pod{
+		pod("dev-1", "node-1", corev1.PodRunning, resources("500m", "256Mi"), resources("250m", "256Mi")),
+		withInit,
+		pod("dev-2", "node-1", corev1.PodSucceeded, resources("2", "1Gi")),
+	}
+
+	nodeMetrics := []clientgo.NodeMetrics{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Usage: resources("1500m", "2Gi")},
+	}
+	podMetrics := []clientgo.PodMetrics{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "dev-1"},
+			Containers: []clientgo.ContainerMetrics{{Usage: resources("300m", "100Mi")}},
+		},
+	}
+
+	result := Aggregate(nodes, pods, nodeMetrics, podMetrics)
+	if !result.MetricsAvailable {
+		t.Fatal("metrics should be available")
+	}
+	if result.Cpu.Capacity != 6 || result.Cpu.Allocatable != 5.8 || result.Memory.Capacity != 12*1024 {
+		t.Fatalf("unexpected capacity %+v %+v", result.Cpu, result.Memory)
+	}
+	// init container requests more cpu than the containers
+	if result.Cpu.Requests != 1.75 || result.Memory.Requests != 640 || result.Pods != 2 {
+		t.Fatalf("unexpected requests %+v %+v", result.Cpu, result.Memory)
+	}
+
+	if len(result.Nodes) != 2 || result.Nodes[0].Name != "node-1" || result.Nodes[0].Cpu.Usage != 1.5 {
+		t.Fatalf("unexpected nodes %+v", result.Nodes)
+	}
+	if len(result.Namespaces) != 1 || result.Namespaces[0].Cpu.Usage != 0.3 || result.Namespaces[0].Pods != 2 {
+		t.Fatalf("unexpected namespaces %+v", result.Namespaces)
+	}
+
+	ns := NamespaceUsage("dev-1", pods, podMetrics)
+	if ns.Memory.Limits != 640 {
+		t.Fatalf("unexpected namespace usage %+v", ns)
+	}
+	if ns := NamespaceUsage("dev-3", pods, podMetrics); ns.Pods != 0 {
+		t.Fatalf("unexpected namespace usage %+v", ns)
+	}
+}
@@ -0,0 +1,288 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package vault
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+	"nocalhost/pkg/nocalhost-api/pkg/shutdown"
+)
+
+const (
+	VAULT_ADDR             = "vault.addr"
+	VAULT_NAMESPACE        = "vault.namespace"
+	VAULT_CA_CERT          = "vault.ca_cert"
+	VAULT_TOKEN            = "vault.token"
+	VAULT_KUBERNETES_ROLE  = "vault.kubernetes.role"
+	VAULT_KUBERNETES_MOUNT = "vault.kubernetes.mount"
+	VAULT_SECRETS          = "vault.secrets"
+	VAULT_DATABASE_CREDS   = "vault.database_creds"
+	VAULT_CACHE_TTL        = "vault.cache_ttl"
+
+	defaultKubernetesMount = "kubernetes"
+	serviceAccountToken    = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultCacheTTL        = 5 * time.Minute
+	// the leases are renewed when so much of the ttl passed
+	renewRatio = 2.0 / 3
+)
+
+// secret the config key replaced by the secret of kv v2
+type secret struct {
+	Key string `mapstructure:"key"`
+	Ref string `mapstructure:"ref"`
+}
+
+var (
+	client *Client
+
+	cacheLock sync.Mutex
+	cache     = map[string]cached{}
+)
+
+type cached struct {
+	value    string
+	expireAt time.Time
+}
+
+// Init login vault if vault.addr is configured, replace the config keys of
+// vault.secrets by the secrets of kv v2, and the username and password of
+// the database driver by the dynamic credentials of vault.database_creds,
+// the leases of the token and the credentials are renewed in background
+func Init(driver string) error {
+	addr := viper.GetString(VAULT_ADDR)
+	if addr == "" {
+		return nil
+	}
+
+	var caCert []byte
+	if path := viper.GetString(VAULT_CA_CERT); path != "" {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "read ca certificate of vault")
+		}
+		caCert = content
+	}
+	c, err := NewClient(addr, viper.GetString(VAULT_NAMESPACE), caCert)
+	if err != nil {
+		return err
+	}
+	if err := login(c); err != nil {
+		return err
+	}
+
+	var secrets []secret
+	if err := viper.UnmarshalKey(VAULT_SECRETS, &secrets); err != nil {
+		return errors.Wrap(err, "parse vault.secrets")
+	}
+	for _, s := range secrets {
+		value, err := c.ReadKV(s.Ref)
+		if err != nil {
+			return errors.Wrapf(err, "read %s from vault", s.Key)
+		}
+		viper.Set(s.Key, value)
+	}
+
+	if path := viper.GetString(VAULT_DATABASE_CREDS); path != "" {
+		creds, err := c.Request(http.MethodGet, path, nil)
+		if err != nil {
+			return errors.Wrap(err, "read database credentials from vault")
+		}
+		username, _ := creds.Data["username"].(string)
+		password, _ := creds.Data["password"].(string)
+		if username == "" {
+			return errors.New("vault issued no database credentials")
+		}
+		viper.Set(driver+".username", username)
+		viper.Set(driver+".password", password)
+		if creds.Renewable {
+			go keepAlive(
+				"database credentials", time.Duration(creds.LeaseDuration)*time.Second,
+				func(increment time.Duration) (time.Duration, error) { return c.Renew(creds.LeaseID, increment) },
+			)
+		}
+		log.Infof("database credentials of %s are issued by vault for %ds", username, creds.LeaseDuration)
+	}
+
+	client = c
+	return nil
+}
+
+// login by the token of vault.token or VAULT_TOKEN, or by the service
+// account if vault.kubernetes.role is configured, the token is renewed if it
+// is renewable, otherwise the kubernetes login is made again before it expires
+func login(c *Client) error {
+	role := viper.GetString(VAULT_KUBERNETES_ROLE)
+	if role == "" {
+		token := viper.GetString(VAULT_TOKEN)
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+		if token == "" {
+			return errors.New("vault.token or vault.kubernetes.role is required to login vault")
+		}
+		c.SetToken(token)
+
+		self, err := c.Request(http.MethodGet, "auth/token/lookup-self", nil)
+		if err != nil {
+			return errors.Wrap(err, "lookup token of vault")
+		}
+		if renewable, _ := self.Data["renewable"].(bool); renewable {
+			ttl, _ := self.Data["ttl"].(float64)
+			go keepAlive("vault token", time.Duration(ttl)*time.Second, c.RenewSelf)
+		}
+		return nil
+	}
+
+	mount := viper.GetString(VAULT_KUBERNETES_MOUNT)
+	if mount == "" {
+		mount = defaultKubernetesMount
+	}
+	kubernetesLogin := func(time.Duration) (time.Duration, error) {
+		jwt, err := ioutil.ReadFile(serviceAccountToken)
+		if err != nil {
+			return 0, errors.Wrap(err, "read service account token")
+		}
+		auth, err := c.LoginKubernetes(mount, role, jwt)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(auth.LeaseDuration) * time.Second, nil
+	}
+	ttl, err := kubernetesLogin(0)
+	if err != nil {
+		return errors.Wrap(err, "login vault by kubernetes")
+	}
+	go keepAlive(
+		"vault token", ttl, func(increment time.Duration) (time.Duration, error) {
+			if ttl, err := c.RenewSelf(increment); err == nil && ttl >= increment/2 {
+				return ttl, nil
+			}
+			// the token reaches its max ttl, login again
+			return kubernetesLogin(increment)
+		},
+	)
+	return nil
+}
+
+// keepAlive renew the lease of ttl until the shutdown, ttl of zero never expires
+func keepAlive(name string, ttl time.Duration, renew func(increment time.Duration) (time.Duration, error)) {
+	if ttl <= 0 {
+		return
+	}
+	increment := ttl
+	for {
+		wait := time.Duration(float64(ttl) * renewRatio)
+		select {
+		case <-time.After(wait):
+		case <-shutdown.Stopping():
+			return
+		}
+
+		renewed, err := renew(increment)
+		if err != nil {
+			log.Errorf("renew %s of vault err: %v", name, err)
+			// retry before it expires
+			ttl -= wait
+			if ttl < 3*time.Second {
+				ttl = 3 * time.Second
+			}
+			continue
+		}
+		if renewed < increment {
+			log.Warnf("%s of vault is renewed for %s only, it reaches the max ttl", name, renewed)
+		}
+		ttl = renewed
+		if ttl <= 0 {
+			return
+		}
+	}
+}
+
+// Enabled whether vault is configured
+func Enabled() bool {
+	return client != nil
+}
+
+// Resolve the secret referred by ref, cached for vault.cache_ttl
+func Resolve(ref string) (string, error) {
+	if client == nil {
+		return "", errors.New("vault is not configured to resolve " + ref)
+	}
+	now := time.Now()
+	cacheLock.Lock()
+	c, ok := cache[ref]
+	cacheLock.Unlock()
+	if ok && now.Before(c.expireAt) {
+		return c.value, nil
+	}
+
+	value, err := client.ReadKV(ref)
+	if err != nil {
+		return "", err
+	}
+	ttl := viper.GetDuration(VAULT_CACHE_TTL)
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	cacheLock.Lock()
+	cache[ref] = cached{value: value, expireAt: now.Add(ttl)}
+	cacheLock.Unlock()
+	return value, nil
+}
+
+// RegisterGormCallbacks resolve the values referring to vault after they are
+// read, e.g. the kubeconfigs of clusters stored in vault, after they are
+// decrypted
+func RegisterGormCallbacks(db *gorm.DB) {
+	db.Callback().Query().After("encryption:after_query").Register("vault:after_query", resolveQueried)
+}
+
+func resolveQueried(scope *gorm.Scope) {
+	if scope.HasError() || client == nil {
+		return
+	}
+	value := scope.IndirectValue()
+	switch value.Kind() {
+	case reflect.Struct:
+		resolveFields(scope)
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			elem := value.Index(i)
+			for elem.Kind() == reflect.Ptr && !elem.IsNil() {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct && elem.CanAddr() {
+				resolveFields(scope.New(elem.Addr().Interface()))
+			}
+		}
+	}
+}
+
+func resolveFields(scope *gorm.Scope) {
+	for _, field := range scope.Fields() {
+		if !field.Field.IsValid() || field.Field.Kind() != reflect.String || !field.Field.CanSet() {
+			continue
+		}
+		if ref := field.Field.String(); IsReference(ref) {
+			resolved, err := Resolve(ref)
+			if err != nil {
+				log.Errorf("resolve %s of %s err: %v", field.DBName, scope.TableName(), err)
+				continue
+			}
+			field.Field.SetString(resolved)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package vault
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ReferencePrefix the prefix of the values referring to the secrets of kv v2,
+// e.g. vault:secret/nocalhost/clusters/prod#kubeconfig
+const ReferencePrefix = "vault:"
+
+// Secret the response of vault
+type Secret struct {
+	LeaseID       string                 `json:"lease_id"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Renewable     bool                   `json:"renewable"`
+	Data          map[string]interface{} `json:"data"`
+	Auth          *Auth                  `json:"auth"`
+}
+
+// Auth the token issued by the login
+type Auth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+	Renewable     bool   `json:"renewable"`
+}
+
+// Client of the http api of vault
+type Client struct {
+	addr      string
+	namespace string
+	http      *http.Client
+
+	lock  sync.RWMutex
+	token string
+}
+
+// NewClient the client of vault at addr, the server certificate is verified
+// by caCert if it is not empty
+func NewClient(addr, namespace string, caCert []byte) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(caCert) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("invalid ca certificate of vault")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return &Client{
+		addr:      strings.TrimSuffix(addr, "/"),
+		namespace: namespace,
+		http:      &http.Client{Timeout: 10 * time.Second, Transport: transport},
+	}, nil
+}
+
+// SetToken the token of the requests
+func (c *Client) SetToken(token string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.token = token
+}
+
+// Request the api of path, e.g. secret/data/nocalhost, body is sent as json
+// if not nil
+func (c *Client) Request(method, path string, body interface{}) (*Secret, error) {
+	var reader *bytes.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "marshal request of vault")
+		}
+		reader = bytes.NewReader(content)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, c.addr+"/v1/"+strings.TrimPrefix(path, "/"), reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request of vault")
+	}
+	c.lock.RLock()
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	c.lock.RUnlock()
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "request vault")
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response of vault")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var failure struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(content, &failure)
+		return nil, errors.Errorf("vault responded %s to %s: %s", resp.Status, path, strings.Join(failure.Errors, "; "))
+	}
+
+	secret := &Secret{}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, secret); err != nil {
+			return nil, errors.Wrap(err, "unmarshal response of vault")
+		}
+	}
+	return secret, nil
+}
+
+// LoginKubernetes login by the token of service account, the token issued
+// is used by the requests after
+func (c *Client) LoginKubernetes(mount, role string, jwt []byte) (*Auth, error) {
+	secret, err := c.Request(
+		http.MethodPost, "auth/"+mount+"/login", map[string]string{"role": role, "jwt": string(jwt)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return nil, errors.New("vault issued no token by kubernetes login")
+	}
+	c.SetToken(secret.Auth.ClientToken)
+	return secret.Auth, nil
+}
+
+// RenewSelf renew the token, the ttl may be shorter than increment if it
+// reaches the max ttl
+func (c *Client) RenewSelf(increment time.Duration) (time.Duration, error) {
+	secret, err := c.Request(
+		http.MethodPost, "auth/token/renew-self", map[string]interface{}{"increment": int(increment.Seconds())},
+	)
+	if err != nil {
+		return 0, err
+	}
+	if secret.Auth == nil {
+		return 0, errors.New("vault renewed no token")
+	}
+	return time.Duration(secret.Auth.LeaseDuration) * time.Second, nil
+}
+
+// Renew the lease, e.g. of the database credentials
+func (c *Client) Renew(leaseID string, increment time.Duration) (time.Duration, error) {
+	secret, err := c.Request(
+		http.MethodPut, "sys/leases/renew",
+		map[string]interface{}{"lease_id": leaseID, "increment": int(increment.Seconds())},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(secret.LeaseDuration) * time.Second, nil
+}
+
+// ReadKV the field of the secret of kv v2 referred by ref, which is
+// <mount>/<path>#<field> with or without the prefix vault:
+func (c *Client) ReadKV(ref string) (string, error) {
+	mount, path, field, err := ParseReference(ref)
+	if err != nil {
+		return "", err
+	}
+	secret, err := c.Request(http.MethodGet, mount+"/data/"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	data, _ := secret.Data["data"].(map[string]interface{})
+	value, ok := data[field]
+	if !ok {
+		return "", errors.Errorf("field %s of %s/%s is not found in vault", field, mount, path)
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+// IsReference whether the value refers to a secret of vault
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, ReferencePrefix)
+}
+
+// ParseReference the mount, path and field of ref
+func ParseReference(ref string) (mount, path, field string, err error) {
+	ref = strings.TrimPrefix(ref, ReferencePrefix)
+	i := strings.LastIndex(ref, "#")
+	if i < 0 {
+		return "", "", "", errors.Errorf("field of vault reference %s is missing", ref)
+	}
+	ref, field = ref[:i], ref[i+1:]
+	parts := strings.SplitN(strings.Trim(ref, "/"), "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" || field == "" {
+		return "", "", "", errors.Errorf("invalid vault reference %s, expect <mount>/<path>#<field>", ref)
+	}
+	return parts[0], parts[1], field, nil
+}
@@ -0,0 +1,99 @@
+/*
+* Copyright (C) 2021 THL A29 Limited, a Tencent company.  All rights reserved.
+* This source code is licensed under the Apache License Version 2.0.
+ */
+
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"nocalhost/pkg/nocalhost-api/pkg/log"
+)
+
+func testVault(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("X-Vault-Token") != "root" {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+					return
+				}
+				var response interface{}
+				switch r.URL.Path {
+				case "/v1/auth/token/lookup-self":
+					response = map[string]interface{}{"data": map[string]interface{}{"renewable": false, "ttl": 0}}
+				case "/v1/secret/data/nocalhost":
+					response = map[string]interface{}{
+						"data": map[string]interface{}{"data": map[string]interface{}{"jwt_secret": "from-vault"}},
+					}
+				case "/v1/database/creds/nocalhost-api":
+					response = map[string]interface{}{
+						"lease_id": "database/creds/nocalhost-api/1", "lease_duration": 3600, "renewable": false,
+						"data": map[string]interface{}{"username": "v-nocalhost", "password": "p"},
+					}
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(response)
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseReference(t *testing.T) {
+	mount, path, field, err := ParseReference("vault:secret/nocalhost/clusters/prod#kubeconfig")
+	if err != nil || mount != "secret" || path != "nocalhost/clusters/prod" || field != "kubeconfig" {
+		t.Fatalf("unexpected reference %s %s %s %v", mount, path, field, err)
+	}
+	for _, ref := range []string{"secret/nocalhost", "secret#field", "vault:/x#"} {
+		if _, _, _, err := ParseReference(ref); err == nil {
+			t.Fatalf("expect %s invalid", ref)
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	log.NewLogger(&log.Config{}, log.InstanceZapLogger)
+	defer viper.Reset()
+	defer func() { client = nil }()
+
+	server := testVault(t)
+	viper.Set(VAULT_ADDR, server.URL)
+	viper.Set(VAULT_TOKEN, "root")
+	viper.Set(VAULT_SECRETS, []map[string]interface{}{{"key": "jwt_secret", "ref": "secret/nocalhost#jwt_secret"}})
+	viper.Set(VAULT_DATABASE_CREDS, "database/creds/nocalhost-api")
+	if err := Init("mysql"); err != nil {
+		t.Fatal(err)
+	}
+	if viper.GetString("jwt_secret") != "from-vault" {
+		t.Fatalf("expect jwt_secret from vault, got %s", viper.GetString("jwt_secret"))
+	}
+	if viper.GetString("mysql.username") != "v-nocalhost" || viper.GetString("mysql.password") != "p" {
+		t.Fatal("expect the database credentials issued by vault")
+	}
+
+	if value, err := Resolve("vault:secret/nocalhost#jwt_secret"); err != nil || value != "from-vault" {
+		t.Fatalf("unexpected value resolved %s %v", value, err)
+	}
+	if _, err := Resolve("vault:secret/nocalhost#missing"); err == nil {
+		t.Fatal("expect the missing field refused")
+	}
+
+	client.SetToken("wrong")
+	if _, err := client.ReadKV("secret/other#x"); err == nil {
+		t.Fatal("expect permission denied")
+	}
+	if value, err := Resolve("vault:secret/nocalhost#jwt_secret"); err != nil || value != "from-vault" {
+		t.Fatalf("expect the value cached, got %s %v", value, err)
+	}
+}